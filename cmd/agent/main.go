@@ -4,11 +4,16 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"runtime"
 
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent"
 	agent_client "github.com/deviceplane/deviceplane/pkg/agent/client"
+	"github.com/deviceplane/deviceplane/pkg/engine"
 	"github.com/deviceplane/deviceplane/pkg/engine/docker"
+	"github.com/deviceplane/deviceplane/pkg/engine/kubernetes"
+	"github.com/deviceplane/deviceplane/pkg/engine/podman"
+	"github.com/deviceplane/deviceplane/pkg/engine/systemd"
 	"github.com/segmentio/conf"
 )
 
@@ -22,29 +27,62 @@ var config struct {
 	ConfDir           string `conf:"conf-dir"`
 	StateDir          string `conf:"state-dir"`
 	ServerPort        int    `conf:"server-port"`
+	StatsDPort        int    `conf:"statsd-port"`
 	LogLevel          string `conf:"log-level"`
+	Engine            string `conf:"engine"`
+	PodmanSocket      string `conf:"podman-socket"`
+	Kubeconfig        string `conf:"kubeconfig"`
+	KubeNamespace     string `conf:"kube-namespace"`
 }
 
 func init() {
 	config.Controller = "https://cloud.deviceplane.com:443/api"
-	config.ConfDir = "/etc/deviceplane"
-	config.StateDir = "/var/lib/deviceplane"
+	config.ConfDir = defaultConfDir
+	config.StateDir = defaultStateDir
 	config.ServerPort = 4444
+	config.StatsDPort = 8125
 	config.LogLevel = "info"
+	config.Engine = "docker"
+	config.Kubeconfig = kubernetes.DefaultKubeconfig
+	config.KubeNamespace = kubernetes.DefaultNamespace
 }
 
 func main() {
 	conf.Load(&config)
 
+	// Before anything else can fail, so that an agent update that can't
+	// start is rolled back
+	project := agent.ResolveProject(config.StateDir, config.Project)
+	agent.RecordStart(config.StateDir, project, version, os.Args[0])
+
 	lvl, err := log.ParseLevel(config.LogLevel)
 	if err != nil {
 		log.WithError(err).Fatal("--log-level")
 	}
 	log.SetLevel(lvl)
 
-	engine, err := docker.NewEngine()
-	if err != nil {
-		log.WithError(err).Fatal("create docker client")
+	var engine engine.Engine
+	switch config.Engine {
+	case "docker":
+		engine, err = docker.NewEngine()
+		if err != nil {
+			log.WithError(err).Fatal("create docker client")
+		}
+	case "podman":
+		engine, err = podman.NewEngine(config.PodmanSocket)
+		if err != nil {
+			log.WithError(err).Fatal("create podman client")
+		}
+	case "kubernetes":
+		engine, err = kubernetes.NewEngine(config.Kubeconfig, config.KubeNamespace)
+		if err != nil {
+			log.WithError(err).Fatal("create kubernetes client")
+		}
+	default:
+		log.WithField("engine", config.Engine).Fatal("--engine")
+	}
+	if runtime.GOOS == "linux" {
+		engine = systemd.NewEngine(engine, systemd.DefaultUnitDir)
 	}
 
 	controllerURL, err := url.Parse(config.Controller)
@@ -52,16 +90,24 @@ func main() {
 		log.WithError(err).Fatal("parse controller URL")
 	}
 
-	client := agent_client.NewClient(controllerURL, config.Project, http.DefaultClient)
-	agent, err := agent.NewAgent(client, engine, config.Project, config.RegistrationToken,
-		config.ConfDir, config.StateDir, version, os.Args[0], config.ServerPort)
+	if project != config.Project {
+		log.WithField("project", project).Info("device was transferred")
+	}
+
+	client := agent_client.NewClient(controllerURL, project, http.DefaultClient)
+	a, err := agent.NewAgent(client, engine, project, config.RegistrationToken,
+		config.ConfDir, config.StateDir, version, os.Args[0], config.ServerPort, config.StatsDPort)
 	if err != nil {
 		log.WithError(err).Fatal("failure creating agent")
 	}
 
-	if err := agent.Initialize(); err != nil {
+	if err := a.Initialize(); err == agent.ErrDecommissioned {
+		// Exiting would just get the agent restarted
+		log.Info("device was decommissioned, not starting")
+		select {}
+	} else if err != nil {
 		log.WithError(err).Fatal("failure while initializing agent")
 	}
 
-	agent.Run()
+	a.Run()
 }
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+const (
+	defaultConfDir  = "/etc/deviceplane"
+	defaultStateDir = "/var/lib/deviceplane"
+)
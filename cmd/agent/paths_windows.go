@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+var (
+	defaultConfDir  = filepath.Join(os.Getenv("ProgramData"), "deviceplane", "conf")
+	defaultStateDir = filepath.Join(os.Getenv("ProgramData"), "deviceplane", "state")
+)
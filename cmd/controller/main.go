@@ -1,24 +1,53 @@
 package main
 
 import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
 	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/controller/clientaddr"
 	"github.com/deviceplane/deviceplane/pkg/controller/connman"
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/metrics"
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/controller/oidc"
+	"github.com/deviceplane/deviceplane/pkg/controller/ratelimit"
 	"github.com/deviceplane/deviceplane/pkg/controller/runner"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/agentrollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/alerting"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/auditlog"
 	"github.com/deviceplane/deviceplane/pkg/controller/runner/datadog"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/devicestatus"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/gitsync"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/releaserollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/runner/staledevices"
+	"github.com/deviceplane/deviceplane/pkg/controller/saml"
 	"github.com/deviceplane/deviceplane/pkg/controller/service"
 	mysql_store "github.com/deviceplane/deviceplane/pkg/controller/store/mysql"
+	postgres_store "github.com/deviceplane/deviceplane/pkg/controller/store/postgres"
+	sqlite_store "github.com/deviceplane/deviceplane/pkg/controller/store/sqlite"
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlstore"
 	"github.com/deviceplane/deviceplane/pkg/email"
 	"github.com/deviceplane/deviceplane/pkg/email/smtp"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	_ "github.com/deviceplane/deviceplane/pkg/statik"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/handlers"
+	_ "github.com/lib/pq"
 	"github.com/rakyll/statik/fs"
+	"google.golang.org/grpc"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -34,6 +63,12 @@ var (
 		Flag("mysql", "").
 		Default("deviceplane:deviceplane@tcp(localhost:3306)/deviceplane?parseTime=true").
 		String()
+	postgres = kingpin.
+			Flag("postgres", "").
+			String()
+	sqlite = kingpin.
+		Flag("sqlite", "").
+		String()
 	statsdAddress = kingpin.
 			Flag("statsd", "").
 			Default("127.0.0.1:8125").
@@ -67,6 +102,92 @@ var (
 	smtpPassword = kingpin.
 			Flag("smtp-password", "").
 			String()
+	oidcIssuer = kingpin.
+			Flag("oidc-issuer", "").
+			String()
+	oidcClientID = kingpin.
+			Flag("oidc-client-id", "").
+			String()
+	oidcClientSecret = kingpin.
+				Flag("oidc-client-secret", "").
+				String()
+	oidcRedirectURL = kingpin.
+			Flag("oidc-redirect-url", "").
+			String()
+	oidcGroupsClaim = kingpin.
+			Flag("oidc-groups-claim", "").
+			Default(oidc.DefaultGroupsClaim).
+			String()
+	samlIDPMetadataURL = kingpin.
+				Flag("saml-idp-metadata-url", "").
+				String()
+	samlRootURL = kingpin.
+			Flag("saml-root-url", "").
+			String()
+	samlCertificate = kingpin.
+			Flag("saml-certificate", "").
+			String()
+	samlKey = kingpin.
+		Flag("saml-key", "").
+		String()
+	samlEmailAttribute = kingpin.
+				Flag("saml-email-attribute", "").
+				Default(saml.DefaultEmailAttribute).
+				String()
+	samlGivenNameAttribute = kingpin.
+				Flag("saml-given-name-attribute", "").
+				Default(saml.DefaultGivenNameAttribute).
+				String()
+	samlFamilyNameAttribute = kingpin.
+				Flag("saml-family-name-attribute", "").
+				Default(saml.DefaultFamilyNameAttribute).
+				String()
+	samlGroupsAttribute = kingpin.
+				Flag("saml-groups-attribute", "").
+				Default(saml.DefaultGroupsAttribute).
+				String()
+	maxDevices = kingpin.
+			Flag("max-devices", "").
+			Int()
+	maxApplications = kingpin.
+			Flag("max-applications", "").
+			Int()
+	maxReleaseConfigSize = kingpin.
+				Flag("max-release-config-size", "").
+				Int()
+	maxRemoteSessions = kingpin.
+				Flag("max-remote-sessions", "").
+				Int()
+	advertiseURL = kingpin.
+			Flag("advertise-url", "").
+			String()
+	controllerSecret = kingpin.
+				Flag("controller-secret", "").
+				String()
+	rateLimit = kingpin.
+			Flag("rate-limit", "").
+			Float64()
+	rateLimitBurst = kingpin.
+			Flag("rate-limit-burst", "").
+			Default("100").
+			Int()
+	trustedProxies = kingpin.
+			Flag("trusted-proxy", "").
+			Strings()
+	metricsAddr = kingpin.
+			Flag("metrics-addr", "").
+			String()
+	grpcAddr = kingpin.
+			Flag("grpc-addr", "").
+			String()
+	shutdownDelay = kingpin.
+			Flag("shutdown-delay", "").
+			Default("5s").
+			Duration()
+	shutdownTimeout = kingpin.
+			Flag("shutdown-timeout", "").
+			Default("30s").
+			Duration()
 )
 
 func main() {
@@ -86,12 +207,7 @@ func main() {
 		log.WithError(err).Fatal("statik")
 	}
 
-	db, err := tryConnect(*mysql)
-	if err != nil {
-		log.WithError(err).Fatal("connect to MySQL")
-	}
-
-	sqlStore := mysql_store.NewStore(db)
+	sqlStore := openStore()
 
 	st, err := statsd.New(*statsdAddress,
 		statsd.WithNamespace("deviceplane."),
@@ -102,38 +218,228 @@ func main() {
 
 	emailProvider := getEmailProvider(*emailProvider)
 
-	connman := connman.New()
+	connectionManager := connman.New()
+	if *advertiseURL != "" {
+		if *controllerSecret == "" {
+			log.Fatal("--controller-secret is required with --advertise-url")
+		}
+		connectionManager = connman.NewShared(sqlStore, *advertiseURL, *controllerSecret)
+	}
+
+	var oidcProvider *oidc.Provider
+	if *oidcIssuer != "" {
+		oidcProvider = oidc.NewProvider(oidc.Config{
+			Issuer:       *oidcIssuer,
+			ClientID:     *oidcClientID,
+			ClientSecret: *oidcClientSecret,
+			RedirectURL:  *oidcRedirectURL,
+			GroupsClaim:  *oidcGroupsClaim,
+		})
+	}
+
+	var samlProvider *saml.Provider
+	if *samlIDPMetadataURL != "" {
+		samlConfig := saml.Config{
+			IDPMetadataURL:      *samlIDPMetadataURL,
+			RootURL:             *samlRootURL,
+			EmailAttribute:      *samlEmailAttribute,
+			GivenNameAttribute:  *samlGivenNameAttribute,
+			FamilyNameAttribute: *samlFamilyNameAttribute,
+			GroupsAttribute:     *samlGroupsAttribute,
+		}
+		if *samlCertificate != "" || *samlKey != "" {
+			samlConfig.Certificate, samlConfig.Key, err = loadSAMLKeyPair(*samlCertificate, *samlKey)
+			if err != nil {
+				log.WithError(err).Fatal("loading saml key pair")
+			}
+		}
+		samlProvider, err = saml.NewProvider(samlConfig)
+		if err != nil {
+			log.WithError(err).Fatal("creating saml provider")
+		}
+	}
+
+	var rateLimiter *ratelimit.Limiter
+	if *rateLimit > 0 {
+		rateLimiter = ratelimit.New(*rateLimit, *rateLimitBurst)
+	}
+
+	trustedProxyNetworks, err := clientaddr.ParseTrustedProxies(*trustedProxies)
+	if err != nil {
+		log.WithError(err).Fatal("parsing trusted proxies")
+	}
+
+	notificationSender := notifications.NewSender(emailProvider, *emailFromName, *emailFromAddress)
+	eventPublisher := events.NewPublisher(sqlStore, sqlStore, notificationSender, st)
 
 	runnerManager := runner.NewManager([]runner.Runner{
-		datadog.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, st, connman),
-	})
+		datadog.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, st, connectionManager),
+		agentrollout.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, st),
+		releaserollout.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, st, eventPublisher),
+		gitsync.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, st, eventPublisher),
+		devicestatus.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, eventPublisher),
+		auditlog.NewRunner(sqlStore, sqlStore, sqlStore),
+		alerting.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, notificationSender, st),
+		staledevices.NewRunner(sqlStore, sqlStore, sqlStore, sqlStore, st),
+	}, sqlStore)
 	runnerManager.Start()
 
-	svc := service.NewService(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore,
-		sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore,
-		emailProvider, *emailFromName, *emailFromAddress, *allowedEmailDomains, statikFS, st, connman, allowedOriginURLs)
+	svc := service.NewService(sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore,
+		sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore,
+		sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, sqlStore, emailProvider, *emailFromName, *emailFromAddress, *allowedEmailDomains, statikFS, st, connectionManager, eventPublisher, oidcProvider, samlProvider,
+		models.LimitsConfig{
+			MaxDevices:           *maxDevices,
+			MaxApplications:      *maxApplications,
+			MaxReleaseConfigSize: *maxReleaseConfigSize,
+			MaxRemoteSessions:    *maxRemoteSessions,
+		}, rateLimiter, trustedProxyNetworks, allowedOriginURLs)
+
+	// Metrics are served on their own address, which is usually kept
+	// private, rather than alongside the API
+	if *metricsAddr != "" {
+		metrics.RegisterConnectedDevices(connectionManager.Count)
+
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, metricsMux); err != nil {
+				log.WithError(err).Fatal("listen and serve metrics")
+			}
+		}()
+	}
+
+	// The gRPC API is also served on its own address, for backend services
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.WithError(err).Fatal("listen for grpc")
+		}
+		grpcServer = svc.GRPCServer()
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.WithError(err).Fatal("serve grpc")
+			}
+		}()
+	}
 
 	server := &http.Server{
 		Addr: *addr,
 		Handler: handlers.CORS(
 			handlers.AllowCredentials(),
 			handlers.AllowedHeaders([]string{"Content-Type"}),
+			handlers.ExposedHeaders([]string{
+				ratelimit.LimitHeader, ratelimit.RemainingHeader, ratelimit.ResetHeader, ratelimit.RetryAfterHeader,
+				models.TotalPagesHeader, models.TotalItemCountHeader, models.NextPageAfterHeader,
+			}),
 			handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE"}),
 			handlers.AllowedOrigins(*allowedOrigins),
 		)(svc),
 	}
 
-	if err := server.ListenAndServe(); err != nil {
+	shutdownDone := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		<-signals
+
+		shutdown(server, grpcServer, svc, connectionManager, runnerManager, eventPublisher)
+		close(shutdownDone)
+	}()
+
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.WithError(err).Fatal("listen and serve")
 	}
+	<-shutdownDone
 }
 
-func tryConnect(uri string) (*sql.DB, error) {
+// shutdown drains the controller. It stops reporting ready and waits for
+// load balancers to notice, finishes the API requests in flight, then
+// disconnects devices so they reconnect to another controller and lets the
+// runners and event deliveries in progress finish.
+func shutdown(server *http.Server, grpcServer *grpc.Server, svc *service.Service, connectionManager *connman.ConnectionManager,
+	runnerManager *runner.Manager, eventPublisher *events.Publisher,
+) {
+	log.Info("shutting down")
+	svc.StartShutdown()
+	time.Sleep(*shutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.WithError(err).Error("shut down server")
+	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+	if err := connectionManager.Close(ctx); err != nil {
+		log.WithError(err).Error("close device connections")
+	}
+	if err := runnerManager.Stop(ctx); err != nil {
+		log.WithError(err).Error("stop runners")
+	}
+	if err := eventPublisher.Drain(ctx); err != nil {
+		log.WithError(err).Error("drain event deliveries")
+	}
+}
+
+// stopGRPC finishes the gRPC calls in flight, and cancels any that are
+// still going once ctx is done. Event and metrics streams end as soon as
+// shutdown starts, but followed logs go on until their clients cancel them.
+func stopGRPC(ctx context.Context, grpcServer *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+}
+
+// openStore connects to PostgreSQL or opens a SQLite database and brings
+// its schema up to date if --postgres or --sqlite is set, and connects to
+// MySQL otherwise.
+func openStore() *sqlstore.Store {
+	if *postgres != "" {
+		db, err := tryConnect("postgres", *postgres)
+		if err != nil {
+			log.WithError(err).Fatal("connect to PostgreSQL")
+		}
+		if err := postgres_store.Migrate(context.Background(), db); err != nil {
+			log.WithError(err).Fatal("migrate PostgreSQL")
+		}
+		return postgres_store.NewStore(db)
+	}
+
+	if *sqlite != "" {
+		db, err := sqlite_store.Open(*sqlite)
+		if err != nil {
+			log.WithError(err).Fatal("open SQLite")
+		}
+		if err := sqlite_store.Migrate(context.Background(), db); err != nil {
+			log.WithError(err).Fatal("migrate SQLite")
+		}
+		return sqlite_store.NewStore(db)
+	}
+
+	db, err := tryConnect("mysql", *mysql)
+	if err != nil {
+		log.WithError(err).Fatal("connect to MySQL")
+	}
+	return mysql_store.NewStore(db)
+}
+
+func tryConnect(driver, uri string) (*sql.DB, error) {
 	var db *sql.DB
 	var err error
 
 	for i := 0; i < 30; i++ {
-		if db, err = sql.Open("mysql", uri); err != nil {
+		if db, err = sql.Open(driver, uri); err != nil {
 			time.Sleep(time.Second)
 			continue
 		}
@@ -163,3 +469,22 @@ func getEmailProvider(emailProvider string) email.Interface {
 		return nil
 	}
 }
+
+func loadSAMLKeyPair(certificateFile, keyFile string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	keyPair, err := tls.LoadX509KeyPair(certificateFile, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certificate, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("saml key has to be an RSA key")
+	}
+
+	return certificate, key, nil
+}
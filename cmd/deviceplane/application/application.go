@@ -2,6 +2,7 @@ package application
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,7 +10,9 @@ import (
 	"time"
 
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/client"
 	"github.com/deviceplane/deviceplane/pkg/interpolation"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -35,7 +38,15 @@ func addApplicationArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
 }
 
 func applicationListAction(c *kingpin.ParseContext) error {
-	applications, err := config.APIClient.ListApplications(context.TODO(), *config.Flags.Project)
+	var applications []models.Application
+	var err error
+	if options, ok := cliutils.ListOptions(*applicationPageSizeFlag, *applicationAfterFlag); ok {
+		var page *client.Page
+		applications, page, err = config.APIClient.ListApplicationsPage(context.TODO(), *config.Flags.Project, options)
+		defer cliutils.PrintNextPage(page)
+	} else {
+		applications, err = config.APIClient.ListApplications(context.TODO(), *config.Flags.Project)
+	}
 	if err != nil {
 		return err
 	}
@@ -75,7 +86,8 @@ func applicationDeployAction(c *kingpin.ParseContext) error {
 		return err
 	}
 
-	release, err := config.APIClient.CreateRelease(context.TODO(), *config.Flags.Project, *applicationArg, finalYamlConfig)
+	release, err := config.APIClient.CreateRelease(context.TODO(), *config.Flags.Project, *applicationArg, finalYamlConfig,
+		*applicationDeployNotesFlag, *applicationDeploySourceFlag)
 	if err != nil {
 		return err
 	}
@@ -85,6 +97,71 @@ func applicationDeployAction(c *kingpin.ParseContext) error {
 	return nil
 }
 
+func applicationValidateAction(c *kingpin.ParseContext) error {
+	yamlConfigBytes, err := ioutil.ReadFile(*applicationValidateFileArg)
+	if err != nil {
+		return err
+	}
+
+	finalYamlConfig, err := interpolation.Interpolate(string(yamlConfigBytes), os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	validateReleaseResponse, err := config.APIClient.ValidateRelease(context.TODO(), *config.Flags.Project, *applicationArg, finalYamlConfig)
+	if err != nil {
+		return err
+	}
+
+	for _, diagnostic := range validateReleaseResponse.Diagnostics {
+		fmt.Printf("%s: %s\n", diagnostic.Severity, diagnostic.String())
+	}
+
+	if !validateReleaseResponse.Valid {
+		return errors.New("config is not valid")
+	}
+
+	fmt.Printf("Config for application %s is valid!\n", *applicationArg)
+
+	return nil
+}
+
+func applicationRollbackAction(c *kingpin.ParseContext) error {
+	release, err := config.APIClient.RollbackApplication(context.TODO(), *config.Flags.Project, *applicationArg, *applicationRollbackReleaseFlag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Application %s rolled back to release %s as release %d!\n", *applicationArg, release.SourceReleaseID, release.Number)
+
+	return nil
+}
+
+func applicationGitSyncSetAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.SetApplicationGitSync(context.TODO(), *config.Flags.Project, *applicationArg,
+		*applicationGitSyncRepositoryFlag, *applicationGitSyncBranchFlag, *applicationGitSyncPathFlag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Application %s will be synced from %s!\n", *applicationArg, *applicationGitSyncPathFlag)
+
+	return nil
+}
+
+func applicationGitSyncInspectAction(c *kingpin.ParseContext) error {
+	gitSync, err := config.APIClient.GetApplicationGitSync(context.TODO(), *config.Flags.Project, *applicationArg)
+	if err != nil {
+		return err
+	}
+
+	return cliutils.PrintWithFormat(gitSync, *applicationOutputFlag)
+}
+
+func applicationGitSyncRemoveAction(c *kingpin.ParseContext) error {
+	return config.APIClient.DeleteApplicationGitSync(context.TODO(), *config.Flags.Project, *applicationArg)
+}
+
 func applicationInspectAction(c *kingpin.ParseContext) error {
 	if applicationConfigOnlyFlag == nil || !*applicationConfigOnlyFlag {
 		application, err := config.APIClient.GetApplication(context.TODO(), *config.Flags.Project, *applicationArg)
@@ -158,7 +235,7 @@ func applicationEditAction(c *kingpin.ParseContext) error {
 		return err
 	}
 
-	release, err = config.APIClient.CreateRelease(context.TODO(), *config.Flags.Project, *applicationArg, string(yamlConfigBytes))
+	release, err = config.APIClient.CreateRelease(context.TODO(), *config.Flags.Project, *applicationArg, string(yamlConfigBytes), "", "")
 	if err != nil {
 		return err
 	}
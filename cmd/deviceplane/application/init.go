@@ -9,9 +9,22 @@ var (
 	applicationArg        *string = &[]string{""}[0]
 	applicationOutputFlag *string = &[]string{""}[0]
 
+	applicationPageSizeFlag *int    = &[]int{0}[0]
+	applicationAfterFlag    *string = &[]string{""}[0]
+
 	applicationConfigOnlyFlag *bool = &[]bool{false}[0]
 
-	applicationDeployFileArg *string = &[]string{""}[0]
+	applicationDeployFileArg   *string = &[]string{""}[0]
+	applicationValidateFileArg *string = &[]string{""}[0]
+
+	applicationDeployNotesFlag  *string = &[]string{""}[0]
+	applicationDeploySourceFlag *string = &[]string{""}[0]
+
+	applicationRollbackReleaseFlag *string = &[]string{""}[0]
+
+	applicationGitSyncRepositoryFlag *string = &[]string{""}[0]
+	applicationGitSyncBranchFlag     *string = &[]string{""}[0]
+	applicationGitSyncPathFlag       *string = &[]string{""}[0]
 
 	config *global.Config
 )
@@ -30,6 +43,7 @@ func Initialize(c *global.Config) {
 		cliutils.FormatJSON,
 		cliutils.FormatJSONStream,
 	)
+	cliutils.AddPageFlags(applicationPageSizeFlag, applicationAfterFlag, applicationListCmd)
 	applicationListCmd.Action(applicationListAction)
 
 	applicationInspectCmd := applicationCmd.Command("inspect", "Inspect an application.")
@@ -53,7 +67,40 @@ func Initialize(c *global.Config) {
 		applicationDeployCmd := attachmentPoint.Command("deploy", "Deploy an application's config from a file.")
 		addApplicationArg(applicationDeployCmd)
 		applicationDeployCmd.Arg("file", "File path of the yaml file to deploy.").Required().ExistingFileVar(applicationDeployFileArg)
+		applicationDeployCmd.Flag("notes", "Notes on what's in the release.").StringVar(applicationDeployNotesFlag)
+		applicationDeployCmd.Flag("source", "What the release was built from, such as a commit SHA or CI build URL.").StringVar(applicationDeploySourceFlag)
 		applicationDeployCmd.Action(applicationDeployAction)
+
+		applicationValidateCmd := attachmentPoint.Command("validate", "Validate an application's config from a file without deploying it.")
+		addApplicationArg(applicationValidateCmd)
+		applicationValidateCmd.Arg("file", "File path of the yaml file to validate.").Required().ExistingFileVar(applicationValidateFileArg)
+		applicationValidateCmd.Action(applicationValidateAction)
+
+		applicationRollbackCmd := attachmentPoint.Command("rollback", "Roll an application back to its previous release.")
+		addApplicationArg(applicationRollbackCmd)
+		applicationRollbackCmd.Flag("release", "Release ID or number to roll back to, instead of the previous release.").StringVar(applicationRollbackReleaseFlag)
+		applicationRollbackCmd.Action(applicationRollbackAction)
 	})
 
+	applicationGitSyncCmd := applicationCmd.Command("git-sync", "Sync an application's releases from a spec file in a git repository.")
+
+	applicationGitSyncSetCmd := applicationGitSyncCmd.Command("set", "Start syncing an application from git, or change where it's synced from.")
+	addApplicationArg(applicationGitSyncSetCmd)
+	applicationGitSyncSetCmd.Flag("repository", "URL of the git repository. Credentials for private repositories can be included.").Required().StringVar(applicationGitSyncRepositoryFlag)
+	applicationGitSyncSetCmd.Flag("branch", "Branch to sync from, instead of the repository's default branch.").StringVar(applicationGitSyncBranchFlag)
+	applicationGitSyncSetCmd.Flag("path", "Path of the spec file in the repository.").Required().StringVar(applicationGitSyncPathFlag)
+	applicationGitSyncSetCmd.Action(applicationGitSyncSetAction)
+
+	applicationGitSyncInspectCmd := applicationGitSyncCmd.Command("inspect", "Inspect where an application is synced from and how the last sync went.")
+	addApplicationArg(applicationGitSyncInspectCmd)
+	cliutils.AddFormatFlag(applicationOutputFlag, applicationGitSyncInspectCmd,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	applicationGitSyncInspectCmd.Action(applicationGitSyncInspectAction)
+
+	applicationGitSyncRemoveCmd := applicationGitSyncCmd.Command("remove", "Stop syncing an application from git.")
+	addApplicationArg(applicationGitSyncRemoveCmd)
+	applicationGitSyncRemoveCmd.Action(applicationGitSyncRemoveAction)
+
 }
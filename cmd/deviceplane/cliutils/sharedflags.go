@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"strings"
 
+	"github.com/deviceplane/deviceplane/pkg/client"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 	"gopkg.in/yaml.v2"
 )
@@ -35,6 +38,36 @@ func AddFormatFlag(formatVar *string, categoryCmd *kingpin.CmdClause, allowedFor
 	fFlag.EnumVar(formatVar, allowedFormats...)
 }
 
+// AddPageFlags adds flags to list a page of a listing instead of all of it.
+func AddPageFlags(pageSizeVar *int, afterVar *string, categoryCmd *kingpin.CmdClause) {
+	categoryCmd.Flag("page-size", fmt.Sprintf("List a page of this many items, up to %d, instead of all of them.", models.MaxPageSize)).IntVar(pageSizeVar)
+	categoryCmd.Flag("after", "List the page after the item with this ID.").StringVar(afterVar)
+}
+
+// ListOptions returns the page that the page flags select, and whether they
+// select one at all.
+func ListOptions(pageSize int, after string) (client.ListOptions, bool) {
+	if pageSize == 0 && after == "" {
+		return client.ListOptions{}, false
+	}
+	if pageSize == 0 {
+		pageSize = models.MaxPageSize
+	}
+	return client.ListOptions{
+		PageSize: pageSize,
+		After:    after,
+	}, true
+}
+
+// PrintNextPage tells the user how to list the page after page, if there is
+// one. It's printed to stderr so formatted output can still be parsed.
+func PrintNextPage(page *client.Page) {
+	if page == nil || page.NextAfter == "" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d in total. List the next page with --after %s\n", page.TotalCount, page.NextAfter)
+}
+
 func PrintWithFormat(obj interface{}, format string) error {
 	switch format {
 	case FormatJSONStream:
@@ -2,79 +2,316 @@ package device
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/client"
+	"github.com/deviceplane/deviceplane/pkg/controller/connectivity"
 	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/hako/durafmt"
 	"golang.org/x/sync/errgroup"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 func deviceListAction(c *kingpin.ParseContext) error {
-	var filters []models.Filter
-	for _, textFilter := range *deviceFilterListFlag {
-		filter, err := parseTextFilter(textFilter)
+	filters, err := parseFilters(*deviceFilterListFlag)
+	if err != nil {
+		return err
+	}
+	filters, columns, err := applyDeviceView(filters)
+	if err != nil {
+		return err
+	}
+
+	var devices []models.Device
+	if options, ok := cliutils.ListOptions(*devicePageSizeFlag, *deviceAfterFlag); ok {
+		var page *client.Page
+		devices, page, err = config.APIClient.ListDevicesPage(context.TODO(), filters, *config.Flags.Project, options)
+		defer cliutils.PrintNextPage(page)
+	} else {
+		devices, err = config.APIClient.ListDevices(context.TODO(), filters, *config.Flags.Project)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		printDeviceTable(devices, columns)
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(devices, *deviceOutputFlag)
+}
+
+func deviceRebootAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.RebootDevice(context.TODO(), *config.Flags.Project, *deviceArg, *powerActionReasonFlag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully initiated reboot")
+	return nil
+}
+
+func deviceShutdownAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.ShutdownDevice(context.TODO(), *config.Flags.Project, *deviceArg, *powerActionReasonFlag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully initiated shutdown")
+	return nil
+}
+
+func deviceApproveAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.ApproveDevice(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully approved device")
+	return nil
+}
+
+func deviceDecommissionAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.DecommissionDevice(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully initiated decommission")
+	return nil
+}
+
+func deviceTransferAction(c *kingpin.ParseContext) error {
+	d, err := config.APIClient.TransferDevice(context.TODO(), *config.Flags.Project, *deviceArg, *transferProjectArg)
+	if err != nil {
+		return err
+	}
+
+	if d.TransferProjectID == "" {
+		fmt.Println("Successfully cancelled transfer")
+	} else {
+		fmt.Println("Successfully initiated transfer")
+	}
+	return nil
+}
+
+func devicePinAgentAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.SetDeviceAgentVersion(context.TODO(), *config.Flags.Project, *deviceArg, *agentVersionArg, models.AgentSpec{
+		Digests:    *agentDigestsFlag,
+		Signatures: *agentSignaturesFlag,
+		Rollback:   *agentRollbackFlag,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pinned device to agent version %s\n", *agentVersionArg)
+	return nil
+}
+
+func deviceUnpinAgentAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.SetDeviceAgentVersion(context.TODO(), *config.Flags.Project, *deviceArg, "", models.AgentSpec{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Unpinned device's agent version")
+	return nil
+}
+
+func devicePinReleaseAction(c *kingpin.ParseContext) error {
+	_, err := config.APIClient.SetDeviceReleasePin(context.TODO(), *config.Flags.Project, *deviceArg, *releasePinApplicationArg, *releasePinReleaseArg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pinned device to release %s of %s\n", *releasePinReleaseArg, *releasePinApplicationArg)
+	return nil
+}
+
+func deviceUnpinReleaseAction(c *kingpin.ParseContext) error {
+	err := config.APIClient.DeleteDeviceReleasePin(context.TODO(), *config.Flags.Project, *deviceArg, *releasePinApplicationArg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Unpinned device's release of %s\n", *releasePinApplicationArg)
+	return nil
+}
+
+func deviceEnvListAction(c *kingpin.ParseContext) error {
+	environment, err := config.APIClient.GetDeviceEnvironment(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		var keys []string
+		for key := range environment {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Key", "Value"})
+		for _, key := range keys {
+			table.Append([]string{key, environment[key]})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(environment, *deviceOutputFlag)
+}
+
+func deviceEnvSetAction(c *kingpin.ParseContext) error {
+	parts := strings.SplitN(*environmentVariableArg, "=", 2)
+	if len(parts) != 2 {
+		return errors.New("expected KEY=VALUE")
+	}
+
+	return config.APIClient.SetDeviceEnvironmentVariable(context.TODO(), *config.Flags.Project, *deviceArg, parts[0], parts[1])
+}
+
+func deviceEnvUnsetAction(c *kingpin.ParseContext) error {
+	return config.APIClient.DeleteDeviceEnvironmentVariable(context.TODO(), *config.Flags.Project, *deviceArg, *environmentVariableArg)
+}
+
+func deviceFilesListAction(c *kingpin.ParseContext) error {
+	if *fileBrowserPathArg == "" {
+		roots, err := config.APIClient.ListFileBrowserRoots(context.TODO(), *config.Flags.Project, *deviceArg)
 		if err != nil {
 			return err
 		}
 
-		filters = append(filters, filter)
+		if *deviceOutputFlag == cliutils.FormatTable {
+			table := cliutils.DefaultTable()
+			table.SetHeader([]string{"Path", "Writable"})
+			for _, root := range roots {
+				table.Append([]string{root.Path, strconv.FormatBool(root.Writable)})
+			}
+			table.Render()
+			return nil
+		}
+
+		return cliutils.PrintWithFormat(roots, *deviceOutputFlag)
 	}
 
-	devices, err := config.APIClient.ListDevices(context.TODO(), filters, *config.Flags.Project)
+	files, err := config.APIClient.ListBrowsedFiles(context.TODO(), *config.Flags.Project, *deviceArg, *fileBrowserPathArg)
 	if err != nil {
 		return err
 	}
 
 	if *deviceOutputFlag == cliutils.FormatTable {
 		table := cliutils.DefaultTable()
-		table.SetHeader([]string{"Name", "Status", "IP", "OS", "Labels", "Last Seen", "Created"})
-		for _, d := range devices {
-			createdStr := cliutils.DurafmtSince(d.CreatedAt).String() + " ago"
-			lastSeenStr := cliutils.DurafmtSince(d.LastSeenAt).String() + " ago"
-
-			labelsArr := make([]string, len(d.Labels))
-			i := 0
-			for k, v := range d.Labels {
-				labelsArr[i] = fmt.Sprintf("%s:%s", k, v)
-				i += 1
-			}
-			labelsStr := strings.Join(labelsArr, "\n")
-
+		table.SetHeader([]string{"Mode", "Size", "Modified", "Name"})
+		for _, file := range files {
 			table.Append([]string{
-				d.Name,
-				string(d.Status),
-				d.Info.IPAddress,
-				d.Info.OSRelease.Name,
-				labelsStr,
-				lastSeenStr,
-				createdStr,
+				file.Mode,
+				strconv.FormatInt(file.Size, 10),
+				cliutils.DurafmtSince(file.ModTime).String() + " ago",
+				file.Name,
 			})
 		}
 		table.Render()
 		return nil
 	}
 
-	return cliutils.PrintWithFormat(devices, *deviceOutputFlag)
+	return cliutils.PrintWithFormat(files, *deviceOutputFlag)
 }
 
-func deviceRebootAction(c *kingpin.ParseContext) error {
-	err := config.APIClient.RebootDevice(context.TODO(), *config.Flags.Project, *deviceArg)
+func deviceFilesStatAction(c *kingpin.ParseContext) error {
+	file, err := config.APIClient.StatBrowsedFile(context.TODO(), *config.Flags.Project, *deviceArg, *fileBrowserPathArg)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Successfully initiated reboot")
+	return cliutils.PrintWithFormat(file, *deviceOutputFlag)
+}
+
+func deviceFilesCatAction(c *kingpin.ParseContext) error {
+	r, err := config.APIClient.ReadBrowsedFile(context.TODO(), *config.Flags.Project, *deviceArg, *fileBrowserPathArg)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(os.Stdout, r)
+	return err
+}
+
+func deviceHostCommandListAction(c *kingpin.ParseContext) error {
+	hostCommands, err := config.APIClient.ListHostCommands(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Name"})
+		for _, hostCommand := range hostCommands {
+			table.Append([]string{hostCommand.Name})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(hostCommands, *deviceOutputFlag)
+}
+
+func deviceHostCommandRunAction(c *kingpin.ParseContext) error {
+	result, err := config.APIClient.RunHostCommand(context.TODO(), *config.Flags.Project, *deviceArg, *hostCommandArg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(result.Output)
+	if result.Truncated {
+		fmt.Fprintln(os.Stderr, "Output was truncated")
+	}
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("exit code %d", result.ExitCode)
+	}
 	return nil
 }
 
+func deviceExportAction(c *kingpin.ParseContext) error {
+	filters, err := parseFilters(*deviceFilterListFlag)
+	if err != nil {
+		return err
+	}
+	filters, _, err = applyDeviceView(filters)
+	if err != nil {
+		return err
+	}
+
+	r, err := config.APIClient.ExportDevices(context.TODO(), filters, *config.Flags.Project, models.ExportFormat(*deviceOutputFlag))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(os.Stdout, r)
+	return err
+}
+
 func deviceInspectAction(c *kingpin.ParseContext) error {
 	device, err := config.APIClient.GetDevice(context.TODO(), *config.Flags.Project, *deviceArg)
 	if err != nil {
@@ -84,12 +321,249 @@ func deviceInspectAction(c *kingpin.ParseContext) error {
 	return cliutils.PrintWithFormat(device, *deviceOutputFlag)
 }
 
+func deviceConnectivityAction(c *kingpin.ParseContext) error {
+	history, err := config.APIClient.GetDeviceConnectivityHistory(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		statusStr := string(history.Status)
+		if history.StatusSince != nil {
+			statusStr += " for " + cliutils.DurafmtSince(*history.StatusSince).String()
+		}
+		fmt.Printf("Status: %s\n", statusStr)
+		if history.Flapping {
+			fmt.Printf("Flapping: went offline %d times in the last %s\n", history.FlapCount, durafmt.Parse(connectivity.FlapWindow))
+		}
+		fmt.Println()
+
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Status", "At"})
+		for _, event := range history.Events {
+			table.Append([]string{
+				string(event.Status),
+				event.At.Local().Format(time.RFC1123),
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(history, *deviceOutputFlag)
+}
+
+func deviceStaleAction(c *kingpin.ParseContext) error {
+	report, err := config.APIClient.GetStaleDeviceCleanupReport(context.TODO(), *config.Flags.Project)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		enabledStr := "disabled"
+		if report.Config.Enabled {
+			enabledStr = "enabled"
+		}
+		if report.Config.AfterDays > 0 {
+			fmt.Printf("Policy: %s devices unseen for %d days (%s)\n", report.Config.Action, report.Config.AfterDays, enabledStr)
+		} else {
+			fmt.Println("Policy: not configured")
+		}
+		if report.ExcludedCount > 0 {
+			fmt.Printf("Excluded by label: %d\n", report.ExcludedCount)
+		}
+		fmt.Println()
+
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Name", "ID", "Last Seen"})
+		for _, d := range report.Devices {
+			table.Append([]string{
+				d.Name,
+				d.ID,
+				cliutils.DurafmtSince(d.LastSeenAt).String() + " ago",
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(report, *deviceOutputFlag)
+}
+
 func deviceSSHAction(c *kingpin.ParseContext) error {
 	conn, err := config.APIClient.InitiateSSH(context.TODO(), *config.Flags.Project, *deviceArg)
 	if err != nil {
 		return err
 	}
 
+	return runSSH(conn, *sshCommandsArg)
+}
+
+func deviceExecAction(c *kingpin.ParseContext) error {
+	conn, err := config.APIClient.InitiateExec(context.TODO(), *config.Flags.Project, *deviceArg, *execApplicationArg, *execServiceArg)
+	if err != nil {
+		return err
+	}
+
+	return runSSH(conn, *execCommandArg)
+}
+
+func devicePortForwardAction(c *kingpin.ParseContext) error {
+	localPort, remotePort, err := parsePortForwardPorts(*portForwardPortsArg)
+	if err != nil {
+		return err
+	}
+
+	if (*portForwardApplicationFlag == "") != (*portForwardServiceFlag == "") {
+		return errors.New("--application and --service must be used together")
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	fmt.Printf("Forwarding from %s -> %d\n", listener.Addr(), remotePort)
+
+	return forwardConnections(listener, func() (net.Conn, error) {
+		return config.APIClient.InitiatePortForward(context.TODO(),
+			*config.Flags.Project, *deviceArg,
+			*portForwardApplicationFlag, *portForwardServiceFlag, remotePort,
+		)
+	})
+}
+
+func deviceRemoteAccessAction(profile string) func(c *kingpin.ParseContext) error {
+	return func(c *kingpin.ParseContext) error {
+		listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(*remoteAccessLocalPortFlag)))
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+
+		switch profile {
+		case models.RemoteAccessProfileVNC:
+			fmt.Printf("Connect a VNC viewer to vnc://%s\n", listener.Addr())
+		case models.RemoteAccessProfileRDP:
+			fmt.Printf("Connect a remote desktop client to %s\n", listener.Addr())
+		}
+
+		return forwardConnections(listener, func() (net.Conn, error) {
+			return config.APIClient.InitiateRemoteAccess(context.TODO(),
+				*config.Flags.Project, *deviceArg, profile, *remoteAccessPortFlag,
+			)
+		})
+	}
+}
+
+// forwardConnections proxies every connection accepted by listener to a
+// new connection from dial.
+func forwardConnections(listener net.Listener, dial func() (net.Conn, error)) error {
+	for {
+		localConn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer localConn.Close()
+
+			conn, err := dial()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to forward connection: %v\n", err)
+				return
+			}
+			defer conn.Close()
+
+			go io.Copy(conn, localConn)
+			io.Copy(localConn, conn)
+		}()
+	}
+}
+
+func deviceCopyAction(c *kingpin.ParseContext) error {
+	sourceDevice, sourcePath := parseCopyPath(*copySourceArg)
+	destinationDevice, destinationPath := parseCopyPath(*copyDestinationArg)
+
+	switch {
+	case sourceDevice != "" && destinationDevice != "":
+		return errors.New("copying between devices is not supported")
+	case sourceDevice != "":
+		return downloadFile(sourceDevice, sourcePath, destinationPath)
+	case destinationDevice != "":
+		return uploadFile(sourcePath, destinationDevice, destinationPath)
+	default:
+		return errors.New(`either the source or the destination must be on a device, e.g. "my-device:/path"`)
+	}
+}
+
+func downloadFile(device, devicePath, localPath string) error {
+	r, mode, err := config.APIClient.DownloadFile(context.TODO(), *config.Flags.Project, device, devicePath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if localPath == "-" {
+		_, err := io.Copy(os.Stdout, r)
+		return err
+	}
+
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(devicePath))
+	}
+
+	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func uploadFile(localPath, device, devicePath string) error {
+	var r io.Reader = os.Stdin
+	size := int64(-1)
+	mode := os.FileMode(0644)
+
+	if localPath != "-" {
+		file, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", localPath)
+		}
+		if info.Size() > models.MaxFileTransferSize {
+			return fmt.Errorf("%s is larger than %d bytes", localPath, models.MaxFileTransferSize)
+		}
+
+		r = file
+		size = info.Size()
+		mode = info.Mode()
+
+		if strings.HasSuffix(devicePath, "/") {
+			devicePath += filepath.Base(localPath)
+		}
+	}
+
+	return config.APIClient.UploadFile(context.TODO(), *config.Flags.Project, device, devicePath, mode, r, size)
+}
+
+// runSSH runs the local ssh client against conn, which is expected to be
+// served by the agent's SSH server.
+func runSSH(conn net.Conn, commands []string) error {
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
 		return err
@@ -122,7 +596,7 @@ func deviceSSHAction(c *kingpin.ParseContext) error {
 			"127.0.0.1",
 			"-o",
 			fmt.Sprintf("ConnectTimeout=%d", *sshTimeoutFlag),
-		}, *sshCommandsArg...)
+		}, commands...)
 
 		cmd := exec.CommandContext(
 			ctx,
@@ -0,0 +1,128 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func deviceDriftAction(c *kingpin.ParseContext) error {
+	if *deviceArg != "" {
+		return deviceDriftForDevice()
+	}
+
+	filters, err := parseFilters(*deviceFilterListFlag)
+	if err != nil {
+		return err
+	}
+	filters, _, err = applyDeviceView(filters)
+	if err != nil {
+		return err
+	}
+
+	report, err := config.APIClient.GetDriftReport(context.TODO(), filters, *config.Flags.Project)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		summaryHeader := []string{"In Sync", "Pending", "Failing", "Unknown"}
+
+		fmt.Println("Devices:")
+		table := cliutils.DefaultTable()
+		table.SetHeader(summaryHeader)
+		table.Append(driftSummaryRow(report.Summary))
+		table.Render()
+
+		if len(report.Applications) != 0 {
+			fmt.Println()
+			fmt.Println("Applications:")
+			table = cliutils.DefaultTable()
+			table.SetHeader(append([]string{"Application"}, summaryHeader...))
+			for _, application := range report.Applications {
+				table.Append(append([]string{application.ApplicationName}, driftSummaryRow(application.Summary)...))
+			}
+			table.Render()
+		}
+
+		var drifting []models.DeviceDrift
+		for _, device := range report.Devices {
+			if device.Status != models.DriftStatusInSync {
+				drifting = append(drifting, device)
+			}
+		}
+		if len(drifting) != 0 {
+			fmt.Println()
+			fmt.Println("Drifting devices:")
+			table = cliutils.DefaultTable()
+			table.SetHeader([]string{"Device", "Status", "Applications"})
+			for _, device := range drifting {
+				table.Append([]string{device.DeviceName, string(device.Status), driftingApplications(device)})
+			}
+			table.Render()
+		}
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(report, *deviceOutputFlag)
+}
+
+func deviceDriftForDevice() error {
+	drift, err := config.APIClient.GetDeviceDrift(context.TODO(), *config.Flags.Project, *deviceArg)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		fmt.Printf("Status: %s\n\n", drift.Status)
+
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Application", "Service", "Status", "Desired Release", "Current Release"})
+		for _, application := range drift.Applications {
+			for _, service := range application.Services {
+				currentReleaseID := service.CurrentReleaseID
+				if currentReleaseID == "" {
+					currentReleaseID = "-"
+				}
+				table.Append([]string{
+					application.ApplicationName,
+					service.Service,
+					string(service.Status),
+					application.DesiredReleaseID,
+					currentReleaseID,
+				})
+			}
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(drift, *deviceOutputFlag)
+}
+
+func driftSummaryRow(summary models.DriftSummary) []string {
+	return []string{
+		strconv.Itoa(summary.InSync),
+		strconv.Itoa(summary.Pending),
+		strconv.Itoa(summary.Failing),
+		strconv.Itoa(summary.Unknown),
+	}
+}
+
+func driftingApplications(device models.DeviceDrift) string {
+	var s string
+	for _, application := range device.Applications {
+		if application.Status == models.DriftStatusInSync {
+			continue
+		}
+		if s != "" {
+			s += "\n"
+		}
+		s += fmt.Sprintf("%s (%s)", application.ApplicationName, application.Status)
+	}
+	return s
+}
@@ -2,10 +2,12 @@ package device
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/global"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -15,11 +17,50 @@ var (
 	deviceArg *string = &[]string{""}[0]
 
 	deviceFilterListFlag *[]string = &[][]string{[]string{}}[0]
+	devicePageSizeFlag   *int      = &[]int{0}[0]
+	deviceAfterFlag      *string   = &[]string{""}[0]
+	deviceViewFlag       *string   = &[]string{""}[0]
+
+	deviceViewArg             *string   = &[]string{""}[0]
+	deviceViewDescriptionFlag *string   = &[]string{""}[0]
+	deviceViewColumnsFlag     *[]string = &[][]string{[]string{}}[0]
 
 	deviceOutputFlag *string = &[]string{""}[0]
 
 	sshCommandsArg *[]string = &[][]string{[]string{}}[0]
 
+	execApplicationArg *string   = &[]string{""}[0]
+	execServiceArg     *string   = &[]string{""}[0]
+	execCommandArg     *[]string = &[][]string{[]string{}}[0]
+
+	portForwardPortsArg        *string = &[]string{""}[0]
+	portForwardApplicationFlag *string = &[]string{""}[0]
+	portForwardServiceFlag     *string = &[]string{""}[0]
+
+	remoteAccessPortFlag      *int = &[]int{0}[0]
+	remoteAccessLocalPortFlag *int = &[]int{0}[0]
+
+	hostCommandArg *string = &[]string{""}[0]
+
+	powerActionReasonFlag *string = &[]string{""}[0]
+
+	agentVersionArg     *string            = &[]string{""}[0]
+	agentDigestsFlag    *map[string]string = &[]map[string]string{map[string]string{}}[0]
+	agentSignaturesFlag *map[string]string = &[]map[string]string{map[string]string{}}[0]
+	agentRollbackFlag   *bool              = &[]bool{false}[0]
+
+	environmentVariableArg *string = &[]string{""}[0]
+
+	fileBrowserPathArg *string = &[]string{""}[0]
+
+	copySourceArg      *string = &[]string{""}[0]
+	copyDestinationArg *string = &[]string{""}[0]
+
+	transferProjectArg *string = &[]string{""}[0]
+
+	releasePinApplicationArg *string = &[]string{""}[0]
+	releasePinReleaseArg     *string = &[]string{""}[0]
+
 	config *global.Config
 )
 
@@ -29,15 +70,56 @@ func Initialize(c *global.Config) {
 	deviceCmd := c.App.Command("device", "Manage devices.")
 
 	deviceListCmd := deviceCmd.Command("list", "List devices.")
-	deviceListCmd.Flag("filter", `Label key/values used to filter devices. e.g. "--filter status=online --filter labels.location=hq2"`).StringsVar(deviceFilterListFlag)
+	deviceListCmd.Flag("filter", `Filters devices must all match. e.g. "--filter status=online --filter labels.location=hq2", or selectors like "--filter 'device.lastSeenAt < now-24h, location in (hq1, hq2)'"`).StringsVar(deviceFilterListFlag)
+	deviceListCmd.Flag("view", "Saved view to list devices with. Its filters are combined with any others, and its columns are shown.").StringVar(deviceViewFlag)
 	cliutils.AddFormatFlag(deviceOutputFlag, deviceListCmd,
 		cliutils.FormatTable,
 		cliutils.FormatYAML,
 		cliutils.FormatJSON,
 		cliutils.FormatJSONStream,
 	)
+	cliutils.AddPageFlags(devicePageSizeFlag, deviceAfterFlag, deviceListCmd)
 	deviceListCmd.Action(deviceListAction)
 
+	deviceExportCmd := deviceCmd.Command("export", "Export the device inventory, with labels, IPs, versions and running releases.")
+	deviceExportCmd.Flag("filter", "Filters devices must all match, as with device list.").StringsVar(deviceFilterListFlag)
+	deviceExportCmd.Flag("view", "Saved view whose filters devices must also match.").StringVar(deviceViewFlag)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceExportCmd,
+		string(models.ExportFormatCSV),
+		string(models.ExportFormatNDJSON),
+	)
+	deviceExportCmd.Action(deviceExportAction)
+
+	deviceViewCmd := deviceCmd.Command("view", "Manage saved views, which are named filters and columns shared with everyone in the project.")
+
+	deviceViewListCmd := deviceViewCmd.Command("list", "List the project's saved views.")
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceViewListCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceViewListCmd.Action(deviceViewListAction)
+
+	deviceViewCreateCmd := deviceViewCmd.Command("create", "Save a view.")
+	addDeviceViewFlags(deviceViewCreateCmd)
+	deviceViewCreateCmd.Action(deviceViewCreateAction)
+
+	deviceViewUpdateCmd := deviceViewCmd.Command("update", "Replace a saved view's filters, columns and description.")
+	addDeviceViewFlags(deviceViewUpdateCmd)
+	deviceViewUpdateCmd.Action(deviceViewUpdateAction)
+
+	deviceViewInspectCmd := deviceViewCmd.Command("inspect", "Inspect a saved view.")
+	deviceViewInspectCmd.Arg("view", "View name.").Required().StringVar(deviceViewArg)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceViewInspectCmd,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceViewInspectCmd.Action(deviceViewInspectAction)
+
+	deviceViewDeleteCmd := deviceViewCmd.Command("delete", "Delete a saved view.")
+	deviceViewDeleteCmd.Arg("view", "View name.").Required().StringVar(deviceViewArg)
+	deviceViewDeleteCmd.Action(deviceViewDeleteAction)
+
 	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
 		deviceSSHCmd := attachmentPoint.Command("ssh", "SSH into a device.")
 		addDeviceArg(deviceSSHCmd)
@@ -46,6 +128,84 @@ func Initialize(c *global.Config) {
 		deviceSSHCmd.Arg("ssh-commands", "If provided, runs commands, prints output, and exits after SSH completes.").StringsVar(sshCommandsArg)
 	})
 
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		deviceExecCmd := attachmentPoint.Command("exec", "Run a command in a service's container on a device.")
+		addDeviceArg(deviceExecCmd)
+		deviceExecCmd.Arg("application", "Application name.").Required().StringVar(execApplicationArg)
+		deviceExecCmd.Arg("service", "Service name.").Required().StringVar(execServiceArg)
+		deviceExecCmd.Flag("timeout", "Maximum length to attempt establishing a connection.").Default("60").IntVar(sshTimeoutFlag)
+		deviceExecCmd.Arg("command", "If provided, runs the command instead of a shell.").StringsVar(execCommandArg)
+		deviceExecCmd.Action(deviceExecAction)
+	})
+
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		devicePortForwardCmd := attachmentPoint.Command("port-forward", "Forward a local port to a port on a device.")
+		addDeviceArg(devicePortForwardCmd)
+		devicePortForwardCmd.Arg("ports", `Local port and device port. e.g. "8080:80", or "8080" to use the same port on both ends.`).Required().StringVar(portForwardPortsArg)
+		devicePortForwardCmd.Flag("application", "Forward to a port inside one of this application's services instead of the device itself.").StringVar(portForwardApplicationFlag)
+		devicePortForwardCmd.Flag("service", "Service to forward to. Required with --application.").StringVar(portForwardServiceFlag)
+		devicePortForwardCmd.Action(devicePortForwardAction)
+	})
+
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		deviceVNCCmd := attachmentPoint.Command("vnc", "Connect to a device's VNC server through a local port.")
+		addRemoteAccessFlags(deviceVNCCmd, models.RemoteAccessProfileVNC)
+	})
+
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		deviceRDPCmd := attachmentPoint.Command("rdp", "Connect to a device's remote desktop server through a local port.")
+		addRemoteAccessFlags(deviceRDPCmd, models.RemoteAccessProfileRDP)
+	})
+
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		deviceCopyCmd := attachmentPoint.Command("cp", "Copy a file to or from a device.")
+		deviceCopyCmd.Arg("source", `Source file. e.g. "my-device:/var/log/syslog", or a local path. Use "-" for stdin.`).Required().StringVar(copySourceArg)
+		deviceCopyCmd.Arg("destination", `Destination file or directory. e.g. "my-device:/etc/app.conf", or a local path. Use "-" for stdout.`).Required().StringVar(copyDestinationArg)
+		deviceCopyCmd.Action(deviceCopyAction)
+	})
+
+	deviceFilesCmd := deviceCmd.Command("files", "Browse the directories a device allows remote access to.")
+
+	deviceFilesListCmd := deviceFilesCmd.Command("ls", "List a directory, or the browsable directories if no path is given.")
+	addDeviceArg(deviceFilesListCmd)
+	deviceFilesListCmd.Arg("path", "Directory path.").StringVar(fileBrowserPathArg)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceFilesListCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceFilesListCmd.Action(deviceFilesListAction)
+
+	deviceFilesStatCmd := deviceFilesCmd.Command("stat", "Show a file's properties.")
+	addDeviceArg(deviceFilesStatCmd)
+	deviceFilesStatCmd.Arg("path", "File path.").Required().StringVar(fileBrowserPathArg)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceFilesStatCmd,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceFilesStatCmd.Action(deviceFilesStatAction)
+
+	deviceFilesCatCmd := deviceFilesCmd.Command("cat", "Print a file's contents.")
+	addDeviceArg(deviceFilesCatCmd)
+	deviceFilesCatCmd.Arg("path", "File path.").Required().StringVar(fileBrowserPathArg)
+	deviceFilesCatCmd.Action(deviceFilesCatAction)
+
+	deviceHostCommandCmd := deviceCmd.Command("host-command", "Run commands a device allows on its host.")
+
+	deviceHostCommandListCmd := deviceHostCommandCmd.Command("list", "List the host commands a device allows.")
+	addDeviceArg(deviceHostCommandListCmd)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceHostCommandListCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceHostCommandListCmd.Action(deviceHostCommandListAction)
+
+	deviceHostCommandRunCmd := deviceHostCommandCmd.Command("run", "Run a host command on a device.")
+	addDeviceArg(deviceHostCommandRunCmd)
+	deviceHostCommandRunCmd.Arg("host-command", "Host command name.").Required().StringVar(hostCommandArg)
+	deviceHostCommandRunCmd.Action(deviceHostCommandRunAction)
+
 	deviceInspectCmd := deviceCmd.Command("inspect", "Inspect a device's properties and labels.")
 	addDeviceArg(deviceInspectCmd)
 	cliutils.AddFormatFlag(deviceOutputFlag, deviceInspectCmd,
@@ -54,11 +214,121 @@ func Initialize(c *global.Config) {
 	)
 	deviceInspectCmd.Action(deviceInspectAction)
 
+	deviceConnectivityCmd := deviceCmd.Command("connectivity", "Show when a device has come online and gone offline over the last week.")
+	addDeviceArg(deviceConnectivityCmd)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceConnectivityCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceConnectivityCmd.Action(deviceConnectivityAction)
+
+	deviceDriftCmd := deviceCmd.Command("drift", "Compare the releases devices should run with the ones they report running. Summarizes the whole fleet unless a device is given.")
+	deviceDriftCmd.Arg("device", "Device name.").StringVar(deviceArg)
+	deviceDriftCmd.Flag("filter", "Filters devices must all match, as with device list.").StringsVar(deviceFilterListFlag)
+	deviceDriftCmd.Flag("view", "Saved view whose filters devices must also match.").StringVar(deviceViewFlag)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceDriftCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceDriftCmd.Action(deviceDriftAction)
+
+	deviceVersionsCmd := deviceCmd.Command("versions", `Count devices by the agent version and releases they report running. Devices on a version can be listed with filters like "--filter 'releases.web < 42'" or "--filter 'info.agentVersion < 1.10'".`)
+	deviceVersionsCmd.Flag("filter", "Filters devices must all match, as with device list.").StringsVar(deviceFilterListFlag)
+	deviceVersionsCmd.Flag("view", "Saved view whose filters devices must also match.").StringVar(deviceViewFlag)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceVersionsCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceVersionsCmd.Action(deviceVersionsAction)
+
+	deviceStaleCmd := deviceCmd.Command("stale", "List the devices the project's stale device cleanup policy would archive or delete if it ran now.")
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceStaleCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceStaleCmd.Action(deviceStaleAction)
+
+	deviceApproveCmd := deviceCmd.Command("approve", "Approve a device that's pending approval, letting it get releases and remote access.")
+	addDeviceArg(deviceApproveCmd)
+	deviceApproveCmd.Action(deviceApproveAction)
+
+	deviceDecommissionCmd := deviceCmd.Command("decommission", "Remove a device's services, config files and agent state the next time it checks in, then archive it.")
+	addDeviceArg(deviceDecommissionCmd)
+	deviceDecommissionCmd.Action(deviceDecommissionAction)
+
+	deviceTransferCmd := deviceCmd.Command("transfer", "Move a device to another project the next time it checks in. Transfer it to its current project to cancel.")
+	addDeviceArg(deviceTransferCmd)
+	deviceTransferCmd.Arg("target-project", "Project to move the device to.").Required().StringVar(transferProjectArg)
+	deviceTransferCmd.Action(deviceTransferAction)
+
+	devicePinAgentCmd := deviceCmd.Command("pin-agent", "Pin a device to an agent version, leaving it out of agent rollouts.")
+	addDeviceArg(devicePinAgentCmd)
+	devicePinAgentCmd.Arg("version", "Agent version.").Required().StringVar(agentVersionArg)
+	devicePinAgentCmd.Flag("digest", `SHA-256 digest of the agent binary for a platform, checked before updating. e.g. "--digest linux/arm=<digest>"`).StringMapVar(agentDigestsFlag)
+	devicePinAgentCmd.Flag("signature", `Ed25519 signature of a platform's version, platform and digest, required by agents with an update public key. e.g. "--signature linux/arm=<signature>"`).StringMapVar(agentSignaturesFlag)
+	devicePinAgentCmd.Flag("rollback", "Allow the device to go back to an older agent version. Signatures have to allow it too.").BoolVar(agentRollbackFlag)
+	devicePinAgentCmd.Action(devicePinAgentAction)
+
+	deviceUnpinAgentCmd := deviceCmd.Command("unpin-agent", "Unpin a device's agent version.")
+	addDeviceArg(deviceUnpinAgentCmd)
+	deviceUnpinAgentCmd.Action(deviceUnpinAgentAction)
+
+	devicePinReleaseCmd := deviceCmd.Command("pin-release", "Pin a device to a release of an application, leaving it out of new releases and rollouts.")
+	addDeviceArg(devicePinReleaseCmd)
+	devicePinReleaseCmd.Arg("application", "Application name.").Required().StringVar(releasePinApplicationArg)
+	devicePinReleaseCmd.Arg("release", "Release ID or number.").Required().StringVar(releasePinReleaseArg)
+	devicePinReleaseCmd.Action(devicePinReleaseAction)
+
+	deviceUnpinReleaseCmd := deviceCmd.Command("unpin-release", "Unpin a device's release of an application.")
+	addDeviceArg(deviceUnpinReleaseCmd)
+	deviceUnpinReleaseCmd.Arg("application", "Application name.").Required().StringVar(releasePinApplicationArg)
+	deviceUnpinReleaseCmd.Action(deviceUnpinReleaseAction)
+
+	deviceEnvCmd := deviceCmd.Command("env", "Manage a device's environment variables, which are interpolated into its releases.")
+
+	deviceEnvListCmd := deviceEnvCmd.Command("list", "List a device's own environment variables.")
+	addDeviceArg(deviceEnvListCmd)
+	cliutils.AddFormatFlag(deviceOutputFlag, deviceEnvListCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	deviceEnvListCmd.Action(deviceEnvListAction)
+
+	deviceEnvSetCmd := deviceEnvCmd.Command("set", "Set an environment variable on a device.")
+	addDeviceArg(deviceEnvSetCmd)
+	deviceEnvSetCmd.Arg("variable", "Variable to set, as KEY=VALUE.").Required().StringVar(environmentVariableArg)
+	deviceEnvSetCmd.Action(deviceEnvSetAction)
+
+	deviceEnvUnsetCmd := deviceEnvCmd.Command("unset", "Remove an environment variable from a device.")
+	addDeviceArg(deviceEnvUnsetCmd)
+	deviceEnvUnsetCmd.Arg("key", "Variable name.").Required().StringVar(environmentVariableArg)
+	deviceEnvUnsetCmd.Action(deviceEnvUnsetAction)
+
 	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
 		deviceRebootCmd := attachmentPoint.Command("reboot", "Reboot a device.")
 		addDeviceArg(deviceRebootCmd)
+		deviceRebootCmd.Flag("reason", "Reason for the reboot, reported by the device.").StringVar(powerActionReasonFlag)
 		deviceRebootCmd.Action(deviceRebootAction)
 	})
+
+	cliutils.GlobalAndCategorizedCmd(config.App, deviceCmd, func(attachmentPoint cliutils.HasCommand) {
+		deviceShutdownCmd := attachmentPoint.Command("shutdown", "Shut down a device.")
+		addDeviceArg(deviceShutdownCmd)
+		deviceShutdownCmd.Flag("reason", "Reason for the shutdown, reported by the device.").StringVar(powerActionReasonFlag)
+		deviceShutdownCmd.Action(deviceShutdownAction)
+	})
+}
+
+func addRemoteAccessFlags(cmd *kingpin.CmdClause, profile string) {
+	addDeviceArg(cmd)
+	cmd.Flag("port", fmt.Sprintf("Port the server listens on, if not %d.", models.RemoteAccessProfilePorts[profile])).IntVar(remoteAccessPortFlag)
+	cmd.Flag("local-port", "Local port to listen on. A free port is picked by default.").IntVar(remoteAccessLocalPortFlag)
+	cmd.Action(deviceRemoteAccessAction(profile))
 }
 
 func addDeviceArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
@@ -81,3 +351,16 @@ func addDeviceArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
 	})
 	return arg
 }
+
+func addDeviceViewFlags(cmd *kingpin.CmdClause) {
+	cmd.Arg("view", "View name.").Required().StringVar(deviceViewArg)
+	cmd.Flag("filter", "Filters devices in the view must all match, as with device list.").StringsVar(deviceFilterListFlag)
+
+	columns := make([]string, len(models.DeviceViewColumns))
+	for i, column := range models.DeviceViewColumns {
+		columns[i] = string(column)
+	}
+	cmd.Flag("column", "Column to show devices with, in order. Defaults to those of device list.").EnumsVar(deviceViewColumnsFlag, columns...)
+
+	cmd.Flag("description", "Description of the view.").StringVar(deviceViewDescriptionFlag)
+}
@@ -2,12 +2,43 @@ package device
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/utils"
 )
 
+// textFilterRegex matches the filters parseTextFilter has always taken,
+// like status=online or labels.location!=hq2.
+var textFilterRegex = regexp.MustCompile(`^[^\s!=<>(),'"]+!?=[^=]`)
+
+// parseFilters parses filters given on the command line, which are either
+// simple key=value filters or selectors, like
+// "device.lastSeenAt < now-24h, location in (hq1, hq2)".
+func parseFilters(texts []string) (models.Query, error) {
+	var filters models.Query
+	for _, text := range texts {
+		if textFilterRegex.MatchString(text) {
+			filter, err := parseTextFilter(text)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
+		selectorFilters, err := query.ParseSelector(text)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid filter "%s": %v`, text, err)
+		}
+		filters = append(filters, selectorFilters...)
+	}
+	return filters, nil
+}
+
 func parseTextFilter(text string) (models.Filter, error) {
 	if strings.HasPrefix(text, "labels.") {
 		text = text[len("labels."):]
@@ -88,3 +119,33 @@ func parseTextFilter(text string) (models.Filter, error) {
 
 	return nil, fmt.Errorf(`invalid or missing operator in filter "%s"`, text)
 }
+
+// parsePortForwardPorts parses either "local:remote" or a single port used
+// for both.
+func parsePortForwardPorts(text string) (int, int, error) {
+	parts := strings.SplitN(text, ":", 2)
+
+	ports := make([]int, len(parts))
+	for i, part := range parts {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 {
+			return 0, 0, fmt.Errorf("invalid port %q", part)
+		}
+		ports[i] = port
+	}
+
+	if len(ports) == 1 {
+		return ports[0], ports[0], nil
+	}
+	return ports[0], ports[1], nil
+}
+
+// parseCopyPath splits a "device:/path" argument. Anything else, including
+// Windows paths like "C:\file", is a local path and returns an empty device.
+func parseCopyPath(text string) (string, string) {
+	i := strings.Index(text, ":")
+	if i <= 0 || strings.ContainsAny(text[:i], `/\`) || !strings.HasPrefix(text[i+1:], "/") {
+		return "", text
+	}
+	return text[:i], text[i+1:]
+}
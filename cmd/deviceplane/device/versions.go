@@ -0,0 +1,63 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func deviceVersionsAction(c *kingpin.ParseContext) error {
+	filters, err := parseFilters(*deviceFilterListFlag)
+	if err != nil {
+		return err
+	}
+	filters, _, err = applyDeviceView(filters)
+	if err != nil {
+		return err
+	}
+
+	report, err := config.APIClient.GetDeviceVersionsReport(context.TODO(), filters, *config.Flags.Project)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		fmt.Println("Agent versions:")
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Agent Version", "Devices"})
+		for _, agentVersion := range report.AgentVersions {
+			version := agentVersion.Version
+			if version == "" {
+				version = "-"
+			}
+			table.Append([]string{version, strconv.Itoa(agentVersion.Count)})
+		}
+		table.Render()
+
+		if len(report.Applications) != 0 {
+			fmt.Println()
+			fmt.Println("Releases:")
+			table = cliutils.DefaultTable()
+			table.SetHeader([]string{"Application", "Release", "Devices"})
+			for _, application := range report.Applications {
+				for _, release := range application.Releases {
+					table.Append([]string{
+						application.Application,
+						strconv.FormatUint(uint64(release.Release), 10),
+						strconv.Itoa(release.Count),
+					})
+				}
+				if application.NotReported != 0 {
+					table.Append([]string{application.Application, "-", strconv.Itoa(application.NotReported)})
+				}
+			}
+			table.Render()
+		}
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(report, *deviceOutputFlag)
+}
@@ -0,0 +1,198 @@
+package device
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var deviceViewColumnHeaders = map[models.DeviceViewColumn]string{
+	models.DeviceViewColumnName:          "Name",
+	models.DeviceViewColumnID:            "ID",
+	models.DeviceViewColumnStatus:        "Status",
+	models.DeviceViewColumnIPAddress:     "IP",
+	models.DeviceViewColumnOS:            "OS",
+	models.DeviceViewColumnKernelVersion: "Kernel",
+	models.DeviceViewColumnAgentVersion:  "Agent",
+	models.DeviceViewColumnUptime:        "Uptime",
+	models.DeviceViewColumnLabels:        "Labels",
+	models.DeviceViewColumnLastSeen:      "Last Seen",
+	models.DeviceViewColumnCreated:       "Created",
+}
+
+// deviceColumnValue returns how a device is shown in a column of a table.
+func deviceColumnValue(d models.Device, column models.DeviceViewColumn) string {
+	switch column {
+	case models.DeviceViewColumnName:
+		return d.Name
+	case models.DeviceViewColumnID:
+		return d.ID
+	case models.DeviceViewColumnStatus:
+		if d.Decommissioning {
+			return "decommissioning"
+		} else if d.TransferProjectID != "" {
+			return "transferring"
+		} else if d.PendingApproval {
+			return "pending approval"
+		}
+		return string(d.Status)
+	case models.DeviceViewColumnIPAddress:
+		return d.Info.IPAddress
+	case models.DeviceViewColumnOS:
+		return d.Info.OSRelease.Name
+	case models.DeviceViewColumnKernelVersion:
+		return d.Info.KernelVersion
+	case models.DeviceViewColumnAgentVersion:
+		return d.Info.AgentVersion
+	case models.DeviceViewColumnUptime:
+		if d.Status == models.DeviceStatusOnline && !d.Info.Boot.Time.IsZero() {
+			return cliutils.DurafmtSince(d.Info.Boot.Time).String()
+		}
+		return "-"
+	case models.DeviceViewColumnLabels:
+		labels := make([]string, 0, len(d.Labels))
+		for k, v := range d.Labels {
+			labels = append(labels, fmt.Sprintf("%s:%s", k, v))
+		}
+		sort.Strings(labels)
+		return strings.Join(labels, "\n")
+	case models.DeviceViewColumnLastSeen:
+		return cliutils.DurafmtSince(d.LastSeenAt).String() + " ago"
+	case models.DeviceViewColumnCreated:
+		return cliutils.DurafmtSince(d.CreatedAt).String() + " ago"
+	}
+	return ""
+}
+
+func printDeviceTable(devices []models.Device, columns []models.DeviceViewColumn) {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = deviceViewColumnHeaders[column]
+	}
+
+	table := cliutils.DefaultTable()
+	table.SetHeader(header)
+	for _, d := range devices {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = deviceColumnValue(d, column)
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
+// applyDeviceView adds the filters of the view given by --view, if any, and
+// returns the columns to show devices with.
+func applyDeviceView(filters models.Query) (models.Query, []models.DeviceViewColumn, error) {
+	if *deviceViewFlag == "" {
+		return filters, models.DefaultDeviceViewColumns, nil
+	}
+
+	view, err := config.APIClient.GetDeviceView(context.TODO(), *config.Flags.Project, *deviceViewFlag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return append(view.Query, filters...), view.Columns, nil
+}
+
+func deviceViewRequest() (*models.SetDeviceViewRequest, error) {
+	filters, err := parseFilters(*deviceFilterListFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]models.DeviceViewColumn, len(*deviceViewColumnsFlag))
+	for i, column := range *deviceViewColumnsFlag {
+		columns[i] = models.DeviceViewColumn(column)
+	}
+
+	return &models.SetDeviceViewRequest{
+		Name:        *deviceViewArg,
+		Description: *deviceViewDescriptionFlag,
+		Query:       filters,
+		Columns:     columns,
+	}, nil
+}
+
+func deviceViewListAction(c *kingpin.ParseContext) error {
+	views, err := config.APIClient.ListDeviceViews(context.TODO(), *config.Flags.Project)
+	if err != nil {
+		return err
+	}
+
+	if *deviceOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Name", "Description", "Filters", "Columns", "Created"})
+		for _, v := range views {
+			columns := make([]string, len(v.Columns))
+			for i, column := range v.Columns {
+				columns[i] = string(column)
+			}
+
+			table.Append([]string{
+				v.Name,
+				v.Description,
+				fmt.Sprintf("%d", len(v.Query)),
+				strings.Join(columns, ", "),
+				cliutils.DurafmtSince(v.CreatedAt).String() + " ago",
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(views, *deviceOutputFlag)
+}
+
+func deviceViewCreateAction(c *kingpin.ParseContext) error {
+	req, err := deviceViewRequest()
+	if err != nil {
+		return err
+	}
+
+	if _, err := config.APIClient.CreateDeviceView(context.TODO(), *config.Flags.Project, *req); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved view %s. List its devices with \"deviceplane device list --view %s\"\n", *deviceViewArg, *deviceViewArg)
+	return nil
+}
+
+func deviceViewUpdateAction(c *kingpin.ParseContext) error {
+	req, err := deviceViewRequest()
+	if err != nil {
+		return err
+	}
+
+	if _, err := config.APIClient.UpdateDeviceView(context.TODO(), *config.Flags.Project, *deviceViewArg, *req); err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully updated view")
+	return nil
+}
+
+func deviceViewInspectAction(c *kingpin.ParseContext) error {
+	view, err := config.APIClient.GetDeviceView(context.TODO(), *config.Flags.Project, *deviceViewArg)
+	if err != nil {
+		return err
+	}
+
+	return cliutils.PrintWithFormat(view, *deviceOutputFlag)
+}
+
+func deviceViewDeleteAction(c *kingpin.ParseContext) error {
+	if err := config.APIClient.DeleteDeviceView(context.TODO(), *config.Flags.Project, *deviceViewArg); err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully deleted view")
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/global"
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/metrics"
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/project"
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/release"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -35,6 +36,7 @@ func main() {
 	configure.Initialize(&config)
 	project.Initialize(&config)
 	application.Initialize(&config)
+	release.Initialize(&config)
 	device.Initialize(&config)
 	metrics.Initialize(&config)
 
@@ -27,10 +27,27 @@ func Initialize(c *global.Config) {
 	addDeviceArg(metricsDeviceCmd)
 	metricsDeviceCmd.Action(deviceMetricsAction)
 
+	metricsIngestedCmd := metricsCmd.Command("ingested", "Get metrics sent to the device's agent over StatsD or OTLP.")
+	addDeviceArg(metricsIngestedCmd)
+	metricsIngestedCmd.Action(ingestedMetricsAction)
+
 	metricsServiceCmd := metricsCmd.Command("service", "Get metrics on a service running on a device.")
-	metricsApplicationArg := metricsServiceCmd.Arg("application", "Application name.").Required()
-	metricsApplicationArg.StringVar(metricsApplicationArgVar)
-	metricsApplicationArg.HintAction(func() []string {
+	addApplicationArg(metricsServiceCmd)
+	addServiceArg(metricsServiceCmd, "The name of the service exposing the metrics endpoint.")
+	addDeviceArg(metricsServiceCmd)
+	metricsServiceCmd.Action(serviceMetricsAction)
+
+	metricsStatsCmd := metricsCmd.Command("stats", "Stream resource usage of a service running on a device.")
+	addApplicationArg(metricsStatsCmd)
+	addServiceArg(metricsStatsCmd, "Service name.")
+	addDeviceArg(metricsStatsCmd)
+	metricsStatsCmd.Action(serviceStatsAction)
+}
+
+func addApplicationArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
+	arg := cmd.Arg("application", "Application name.").Required()
+	arg.StringVar(metricsApplicationArgVar)
+	arg.HintAction(func() []string {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 		defer cancel()
 		applications, err := config.APIClient.ListApplications(ctx, *config.Flags.Project)
@@ -45,9 +62,13 @@ func Initialize(c *global.Config) {
 		fmt.Println("-") // TODO: find out kingpin won't autocomplete without this
 		return appnames
 	})
-	metricsServiceArg := metricsServiceCmd.Arg("service", "The name of the service exposing the metrics endpoint.").Required()
-	metricsServiceArg.StringVar(metricsServiceArgVar)
-	metricsServiceArg.HintAction(func() []string {
+	return arg
+}
+
+func addServiceArg(cmd *kingpin.CmdClause, help string) *kingpin.ArgClause {
+	arg := cmd.Arg("service", help).Required()
+	arg.StringVar(metricsServiceArgVar)
+	arg.HintAction(func() []string {
 		if metricsApplicationArgVar == nil || *metricsApplicationArgVar == "" {
 			return nil
 		}
@@ -66,8 +87,7 @@ func Initialize(c *global.Config) {
 		fmt.Println("-") // TODO: find out kingpin won't autocomplete without this
 		return services
 	})
-	addDeviceArg(metricsServiceCmd)
-	metricsServiceCmd.Action(serviceMetricsAction)
+	return arg
 }
 
 func addDeviceArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
@@ -3,7 +3,10 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	units "github.com/docker/go-units"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -17,6 +20,16 @@ func deviceMetricsAction(c *kingpin.ParseContext) error {
 	return nil
 }
 
+func ingestedMetricsAction(c *kingpin.ParseContext) error {
+	metrics, err := config.APIClient.GetIngestedMetrics(context.TODO(), *config.Flags.Project, *deviceArgVar)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(*metrics)
+	return nil
+}
+
 func serviceMetricsAction(c *kingpin.ParseContext) error {
 	metrics, err := config.APIClient.GetServiceMetrics(
 		context.TODO(),
@@ -30,3 +43,23 @@ func serviceMetricsAction(c *kingpin.ParseContext) error {
 	fmt.Println(*metrics)
 	return nil
 }
+
+func serviceStatsAction(c *kingpin.ParseContext) error {
+	return config.APIClient.StreamServiceStats(
+		context.TODO(),
+		*config.Flags.Project,
+		*deviceArgVar, *metricsApplicationArgVar, *metricsServiceArgVar,
+		func(stats engine.Stats) error {
+			fmt.Printf(
+				"%s  CPU %.2f%%  MEM %s / %s  NET %s / %s  BLOCK %s / %s  PIDS %d\n",
+				stats.Time.Format(time.RFC3339),
+				stats.CPUPercent,
+				units.BytesSize(float64(stats.MemoryUsage)), units.BytesSize(float64(stats.MemoryLimit)),
+				units.BytesSize(float64(stats.NetworkRx)), units.BytesSize(float64(stats.NetworkTx)),
+				units.BytesSize(float64(stats.BlockRead)), units.BytesSize(float64(stats.BlockWrite)),
+				stats.PIDs,
+			)
+			return nil
+		},
+	)
+}
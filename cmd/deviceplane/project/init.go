@@ -1,6 +1,8 @@
 package project
 
 import (
+	"time"
+
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/global"
 )
@@ -8,6 +10,22 @@ import (
 var (
 	projectOutputFlag *string = &[]string{""}[0]
 
+	projectEventsTypeFlag *[]string = &[]string{}
+
+	auditLogUserFlag           *string        = &[]string{""}[0]
+	auditLogServiceAccountFlag *string        = &[]string{""}[0]
+	auditLogActionFlag         *string        = &[]string{""}[0]
+	auditLogBeforeFlag         *string        = &[]string{""}[0]
+	auditLogSinceFlag          *time.Duration = &[]time.Duration{0}[0]
+	auditLogLimitFlag          *int           = &[]int{0}[0]
+
+	alertsSinceFlag     *time.Duration = &[]time.Duration{0}[0]
+	silenceRuleFlag     *string        = &[]string{""}[0]
+	silenceDeviceFlag   *string        = &[]string{""}[0]
+	silenceDurationFlag *time.Duration = &[]time.Duration{0}[0]
+	silenceReasonFlag   *string        = &[]string{""}[0]
+	alertSilenceArg     *string        = &[]string{""}[0]
+
 	config *global.Config
 )
 
@@ -27,4 +45,62 @@ func Initialize(c *global.Config) {
 
 	projectCreateCmd := projectCmd.Command("create", "Create a new project.")
 	projectCreateCmd.Action(projectCreateAction)
+
+	projectEventsCmd := projectCmd.Command("events", "Watch events in a project as they happen.")
+	projectEventsCmd.Flag("type", "Only show events of this type. Can be given more than once.").StringsVar(projectEventsTypeFlag)
+	cliutils.AddFormatFlag(projectOutputFlag, projectEventsCmd,
+		cliutils.FormatTable,
+		cliutils.FormatJSONStream,
+	)
+	projectEventsCmd.Action(projectEventsAction)
+
+	projectAuditLogCmd := projectCmd.Command("audit-log", "List the mutating API calls and remote access sessions in a project, newest first.")
+	projectAuditLogCmd.Flag("user", "Only list calls made by this user ID.").StringVar(auditLogUserFlag)
+	projectAuditLogCmd.Flag("service-account", "Only list calls made by this service account ID.").StringVar(auditLogServiceAccountFlag)
+	projectAuditLogCmd.Flag("action", "Only list calls of this action, such as SSH or CreateRelease.").StringVar(auditLogActionFlag)
+	projectAuditLogCmd.Flag("before", "List entries before the one with this ID, to page through the log.").StringVar(auditLogBeforeFlag)
+	projectAuditLogCmd.Flag("since", "Only list entries from this long ago, such as 24h.").DurationVar(auditLogSinceFlag)
+	projectAuditLogCmd.Flag("limit", "Maximum number of entries to list.").IntVar(auditLogLimitFlag)
+	cliutils.AddFormatFlag(projectOutputFlag, projectAuditLogCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+		cliutils.FormatJSONStream,
+	)
+	projectAuditLogCmd.Action(projectAuditLogAction)
+
+	projectAlertsCmd := projectCmd.Command("alerts", "List the firing and recently resolved alerts in a project.")
+	projectAlertsCmd.Flag("since", "List alerts resolved this long ago, such as 72h.").DurationVar(alertsSinceFlag)
+	cliutils.AddFormatFlag(projectOutputFlag, projectAlertsCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+		cliutils.FormatJSONStream,
+	)
+	projectAlertsCmd.Action(projectAlertsAction)
+
+	projectSilenceCmd := projectCmd.Command("silence", "Stop notifications for alerts for a while. Alerts are still recorded.")
+	projectSilenceCmd.Flag("rule", "Only silence alerts from this rule.").StringVar(silenceRuleFlag)
+	projectSilenceCmd.Flag("device", "Only silence alerts for this device.").StringVar(silenceDeviceFlag)
+	projectSilenceCmd.Flag("for", "How long to silence alerts for, such as 2h.").Required().DurationVar(silenceDurationFlag)
+	projectSilenceCmd.Flag("reason", "Why the alerts are being silenced.").StringVar(silenceReasonFlag)
+	cliutils.AddFormatFlag(projectOutputFlag, projectSilenceCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	projectSilenceCmd.Action(projectSilenceAction)
+
+	projectSilencesCmd := projectCmd.Command("silences", "List the alert silences in a project.")
+	cliutils.AddFormatFlag(projectOutputFlag, projectSilencesCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+		cliutils.FormatJSONStream,
+	)
+	projectSilencesCmd.Action(projectSilencesAction)
+
+	projectUnsilenceCmd := projectCmd.Command("unsilence", "Remove an alert silence.")
+	projectUnsilenceCmd.Arg("silence", "Alert silence ID.").Required().StringVar(alertSilenceArg)
+	projectUnsilenceCmd.Action(projectUnsilenceAction)
 }
@@ -3,8 +3,10 @@ package project
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -42,3 +44,166 @@ func projectCreateAction(c *kingpin.ParseContext) error {
 
 	return nil
 }
+
+func projectEventsAction(c *kingpin.ParseContext) error {
+	var types []models.EventType
+	for _, t := range *projectEventsTypeFlag {
+		types = append(types, models.EventType(t))
+	}
+
+	return config.APIClient.StreamEvents(context.TODO(), *config.Flags.Project, types, func(event models.Event) error {
+		if *projectOutputFlag == cliutils.FormatJSONStream {
+			return cliutils.PrintWithFormat([]models.Event{event}, cliutils.FormatJSONStream)
+		}
+
+		fmt.Printf("%s  %-20s %s\n", event.CreatedAt.Format(time.RFC3339), event.Type, eventSubject(event))
+		return nil
+	})
+}
+
+// eventSubject describes what an event is about in a few words.
+func eventSubject(event models.Event) string {
+	switch {
+	case event.Device != nil:
+		return fmt.Sprintf("device %s (%s)", event.Device.Name, event.Device.Status)
+	case event.Release != nil:
+		return fmt.Sprintf("release %s of application %s", event.Release.ID, event.Release.ApplicationID)
+	case event.Rollout != nil:
+		return fmt.Sprintf("rollout %s at step %d of %d", event.Rollout.ID, event.Rollout.Step+1, len(event.Rollout.Steps))
+	case event.ApplicationStatus != nil:
+		s := event.ApplicationStatus
+		return fmt.Sprintf("device %s application %s at release %s", s.DeviceID, s.ApplicationID, s.CurrentReleaseID)
+	case event.ServiceStatus != nil:
+		s := event.ServiceStatus
+		return fmt.Sprintf("device %s service %s/%s at release %s", s.DeviceID, s.ApplicationID, s.Service, s.CurrentReleaseID)
+	}
+	return ""
+}
+
+func projectAuditLogAction(c *kingpin.ParseContext) error {
+	var since time.Time
+	if *auditLogSinceFlag != 0 {
+		since = time.Now().Add(-*auditLogSinceFlag)
+	}
+
+	entries, err := config.APIClient.ListAuditLogEntries(context.TODO(), *config.Flags.Project,
+		*auditLogUserFlag, *auditLogServiceAccountFlag, *auditLogActionFlag, *auditLogBeforeFlag, since, *auditLogLimitFlag)
+	if err != nil {
+		return err
+	}
+
+	if *projectOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"ID", "Time", "Who", "Action", "Request", "Status", "From"})
+		for _, e := range entries {
+			who := e.UserID
+			if who == "" {
+				who = e.ServiceAccountID
+			}
+			table.Append([]string{
+				e.ID,
+				e.CreatedAt.Format(time.RFC3339),
+				who,
+				e.Action,
+				e.Method + " " + e.Path,
+				fmt.Sprintf("%d", e.StatusCode),
+				e.RemoteAddr,
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(entries, *projectOutputFlag)
+}
+
+func projectAlertsAction(c *kingpin.ParseContext) error {
+	var since time.Time
+	if *alertsSinceFlag != 0 {
+		since = time.Now().Add(-*alertsSinceFlag)
+	}
+
+	alerts, err := config.APIClient.ListAlerts(context.TODO(), *config.Flags.Project, since)
+	if err != nil {
+		return err
+	}
+
+	if *projectOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"ID", "Rule", "Device", "Status", "Started", "Summary"})
+		for _, a := range alerts {
+			status := string(models.AlertStatusFiring)
+			if a.ResolvedAt != nil {
+				status = string(models.AlertStatusResolved)
+			}
+			table.Append([]string{
+				a.ID,
+				a.Rule,
+				a.DeviceID,
+				status,
+				a.StartedAt.Format(time.RFC3339),
+				a.Summary,
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(alerts, *projectOutputFlag)
+}
+
+func projectSilenceAction(c *kingpin.ParseContext) error {
+	silence, err := config.APIClient.CreateAlertSilence(context.TODO(), *config.Flags.Project,
+		*silenceRuleFlag, *silenceDeviceFlag, time.Now().Add(*silenceDurationFlag), *silenceReasonFlag)
+	if err != nil {
+		return err
+	}
+
+	if *projectOutputFlag == cliutils.FormatTable {
+		printAlertSilences([]models.AlertSilence{*silence})
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(silence, *projectOutputFlag)
+}
+
+func projectSilencesAction(c *kingpin.ParseContext) error {
+	silences, err := config.APIClient.ListAlertSilences(context.TODO(), *config.Flags.Project)
+	if err != nil {
+		return err
+	}
+
+	if *projectOutputFlag == cliutils.FormatTable {
+		printAlertSilences(silences)
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(silences, *projectOutputFlag)
+}
+
+func printAlertSilences(silences []models.AlertSilence) {
+	table := cliutils.DefaultTable()
+	table.SetHeader([]string{"ID", "Rule", "Device", "Until", "Reason"})
+	for _, s := range silences {
+		rule := s.Rule
+		if rule == "" {
+			rule = "*"
+		}
+		device := s.DeviceID
+		if device == "" {
+			device = "*"
+		}
+		table.Append([]string{
+			s.ID,
+			rule,
+			device,
+			s.Until.Format(time.RFC3339),
+			s.Reason,
+		})
+	}
+	table.Render()
+}
+
+func projectUnsilenceAction(c *kingpin.ParseContext) error {
+	return config.APIClient.DeleteAlertSilence(context.TODO(), *config.Flags.Project, *alertSilenceArg)
+}
@@ -0,0 +1,93 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/global"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	releaseApplicationArg *string = &[]string{""}[0]
+	releaseFileArg        *string = &[]string{""}[0]
+
+	releaseFromComposeFlag *bool = &[]bool{false}[0]
+
+	releaseNotesFlag  *string = &[]string{""}[0]
+	releaseSourceFlag *string = &[]string{""}[0]
+
+	releaseIdempotencyKeyFlag *string = &[]string{""}[0]
+
+	releaseOutputFlag *string = &[]string{""}[0]
+
+	releasePageSizeFlag *int    = &[]int{0}[0]
+	releaseAfterFlag    *string = &[]string{""}[0]
+
+	releaseArg          *string = &[]string{""}[0]
+	releaseDiffFromFlag *string = &[]string{""}[0]
+
+	config *global.Config
+)
+
+func Initialize(c *global.Config) {
+	config = c
+
+	releaseCmd := config.App.Command("release", "Manage releases.")
+	cliutils.RequireAccessKey(config, releaseCmd)
+	cliutils.RequireProject(config, releaseCmd)
+
+	releaseCreateCmd := releaseCmd.Command("create", "Create a new release from a file.")
+	addApplicationArg(releaseCreateCmd)
+	releaseCreateCmd.Arg("file", "File path of the yaml file to release.").Required().ExistingFileVar(releaseFileArg)
+	releaseCreateCmd.Flag("from-compose", "Convert the file from a docker-compose file.").Default("false").BoolVar(releaseFromComposeFlag)
+	releaseCreateCmd.Flag("notes", "Notes on what's in the release.").StringVar(releaseNotesFlag)
+	releaseCreateCmd.Flag("source", "What the release was built from, such as a commit SHA or CI build URL.").StringVar(releaseSourceFlag)
+	releaseCreateCmd.Flag("idempotency-key", "Key unique to the build, such as a CI build ID. Retries with the same key don't create more releases.").StringVar(releaseIdempotencyKeyFlag)
+	releaseCreateCmd.Action(releaseCreateAction)
+
+	releaseListCmd := releaseCmd.Command("list", "List an application's releases.")
+	addApplicationArg(releaseListCmd)
+	cliutils.AddFormatFlag(releaseOutputFlag, releaseListCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+		cliutils.FormatJSONStream,
+	)
+	cliutils.AddPageFlags(releasePageSizeFlag, releaseAfterFlag, releaseListCmd)
+	releaseListCmd.Action(releaseListAction)
+
+	releaseDiffCmd := releaseCmd.Command("diff", "Show what changed in a release.")
+	addApplicationArg(releaseDiffCmd)
+	releaseDiffCmd.Arg("release", "Release ID or number.").Required().StringVar(releaseArg)
+	releaseDiffCmd.Flag("from", "Release ID or number to compare with, instead of the previous release.").StringVar(releaseDiffFromFlag)
+	cliutils.AddFormatFlag(releaseOutputFlag, releaseDiffCmd,
+		cliutils.FormatTable,
+		cliutils.FormatYAML,
+		cliutils.FormatJSON,
+	)
+	releaseDiffCmd.Action(releaseDiffAction)
+}
+
+func addApplicationArg(cmd *kingpin.CmdClause) *kingpin.ArgClause {
+	arg := cmd.Arg("application", "Application name.").Required()
+	arg.StringVar(releaseApplicationArg)
+	arg.HintAction(func() []string {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		applications, err := config.APIClient.ListApplications(ctx, *config.Flags.Project)
+		if err != nil {
+			return nil
+		}
+
+		var appnames []string
+		for _, a := range applications {
+			appnames = append(appnames, a.Name)
+		}
+		fmt.Println("-") // TODO: find out kingpin won't autocomplete without this
+		return appnames
+	})
+	return arg
+}
@@ -0,0 +1,155 @@
+package release
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/deviceplane/deviceplane/cmd/deviceplane/cliutils"
+	"github.com/deviceplane/deviceplane/pkg/client"
+	"github.com/deviceplane/deviceplane/pkg/interpolation"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func releaseCreateAction(c *kingpin.ParseContext) error {
+	yamlConfigBytes, err := ioutil.ReadFile(*releaseFileArg)
+	if err != nil {
+		return err
+	}
+
+	finalYamlConfig, err := interpolation.Interpolate(string(yamlConfigBytes), os.Getenv)
+	if err != nil {
+		return err
+	}
+
+	if *releaseFromComposeFlag {
+		convertComposeResponse, err := config.APIClient.ConvertCompose(context.TODO(), *config.Flags.Project, *releaseApplicationArg, finalYamlConfig)
+		if err != nil {
+			return err
+		}
+
+		for _, dropped := range convertComposeResponse.Dropped {
+			fmt.Printf("%s: %s\n", dropped.Severity, dropped.String())
+		}
+
+		finalYamlConfig = convertComposeResponse.RawConfig
+	}
+
+	if *releaseIdempotencyKeyFlag != "" {
+		createCIReleaseResponse, err := config.APIClient.CreateCIRelease(context.TODO(), *config.Flags.Project, *releaseApplicationArg,
+			*releaseIdempotencyKeyFlag, finalYamlConfig, *releaseNotesFlag, *releaseSourceFlag)
+		if err != nil {
+			return err
+		}
+
+		release := createCIReleaseResponse.Release
+		if !createCIReleaseResponse.Created {
+			fmt.Printf("Release %s for application %s was already created at %s!\n", release.ID, *releaseApplicationArg, release.CreatedAt.Format("Mon Jan _2 15:04:05 2006"))
+			return nil
+		}
+		fmt.Printf("Release %s for application %s successfully created at %s!\n", release.ID, *releaseApplicationArg, release.CreatedAt.Format("Mon Jan _2 15:04:05 2006"))
+		return nil
+	}
+
+	release, err := config.APIClient.CreateRelease(context.TODO(), *config.Flags.Project, *releaseApplicationArg, finalYamlConfig,
+		*releaseNotesFlag, *releaseSourceFlag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Release %s for application %s successfully created at %s!\n", release.ID, *releaseApplicationArg, release.CreatedAt.Format("Mon Jan _2 15:04:05 2006"))
+
+	return nil
+}
+
+func releaseListAction(c *kingpin.ParseContext) error {
+	var releases []models.ReleaseFull
+	var err error
+	if options, ok := cliutils.ListOptions(*releasePageSizeFlag, *releaseAfterFlag); ok {
+		var page *client.Page
+		releases, page, err = config.APIClient.ListReleasesPage(context.TODO(), *config.Flags.Project, *releaseApplicationArg, options)
+		defer cliutils.PrintNextPage(page)
+	} else {
+		releases, err = config.APIClient.ListReleases(context.TODO(), *config.Flags.Project, *releaseApplicationArg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *releaseOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Number", "ID", "Created", "Created By", "Source", "Notes"})
+		for _, release := range releases {
+			table.Append([]string{
+				fmt.Sprint(release.Number),
+				release.ID,
+				cliutils.DurafmtSince(release.CreatedAt).String() + " ago",
+				createdBy(release),
+				release.Source,
+				release.Notes,
+			})
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(releases, *releaseOutputFlag)
+}
+
+func createdBy(release models.ReleaseFull) string {
+	switch {
+	case release.CreatedByUser != nil:
+		return release.CreatedByUser.Email
+	case release.CreatedByServiceAccount != nil:
+		return release.CreatedByServiceAccount.Name
+	}
+	return ""
+}
+
+func releaseDiffAction(c *kingpin.ParseContext) error {
+	diff, err := config.APIClient.DiffReleases(context.TODO(), *config.Flags.Project, *releaseApplicationArg, *releaseDiffFromFlag, *releaseArg)
+	if err != nil {
+		return err
+	}
+
+	if *releaseOutputFlag == cliutils.FormatTable {
+		table := cliutils.DefaultTable()
+		table.SetHeader([]string{"Service", "Change", "From", "To"})
+		for _, service := range diff.AddedServices {
+			table.Append([]string{service, "added", "", ""})
+		}
+		for _, service := range diff.RemovedServices {
+			table.Append([]string{service, "removed", "", ""})
+		}
+		for _, serviceDiff := range diff.ChangedServices {
+			if serviceDiff.Image != nil {
+				table.Append([]string{serviceDiff.Service, "image", serviceDiff.Image.From, serviceDiff.Image.To})
+			}
+			for _, keyChange := range serviceDiff.Environment {
+				var from, to string
+				if keyChange.From != nil {
+					from = *keyChange.From
+				}
+				if keyChange.To != nil {
+					to = *keyChange.To
+				}
+				table.Append([]string{serviceDiff.Service, "environment " + keyChange.Key, from, to})
+			}
+			for _, port := range serviceDiff.RemovedPorts {
+				table.Append([]string{serviceDiff.Service, "port", port, ""})
+			}
+			for _, port := range serviceDiff.AddedPorts {
+				table.Append([]string{serviceDiff.Service, "port", "", port})
+			}
+			for _, key := range serviceDiff.OtherChanges {
+				table.Append([]string{serviceDiff.Service, key, "", ""})
+			}
+		}
+		table.Render()
+		return nil
+	}
+
+	return cliutils.PrintWithFormat(diff, *releaseOutputFlag)
+}
@@ -1,14 +1,13 @@
 module github.com/deviceplane/deviceplane
 
-go 1.12
+go 1.20
 
 require (
 	github.com/DataDog/datadog-go v3.2.0+incompatible
-	github.com/Knetic/govaluate v3.0.0+incompatible // indirect
-	github.com/Microsoft/go-winio v0.4.13 // indirect
 	github.com/apex/log v1.1.0
 	github.com/cobaugh/osrelease v0.0.0-20181218015638-a93a0a55a249
-	github.com/docker/distribution v2.7.1+incompatible // indirect
+	github.com/coreos/go-systemd v0.0.0-20190321100706-95778dfbb74e
+	github.com/crewjam/saml v0.4.14
 	github.com/docker/docker v1.13.1
 	github.com/docker/go-connections v0.4.0
 	github.com/docker/go-units v0.3.3
@@ -16,19 +15,16 @@ require (
 	github.com/fsnotify/fsnotify v1.4.7
 	github.com/function61/holepunch-server v0.0.0-20190515131118-6a4402002d39
 	github.com/gliderlabs/ssh v0.2.3-0.20190927203027-f6d256ee2510
-	github.com/go-playground/locales v0.12.1 // indirect
-	github.com/go-playground/universal-translator v0.16.0 // indirect
 	github.com/go-sql-driver/mysql v1.4.1
-	github.com/gomodule/redigo v2.0.0+incompatible // indirect
 	github.com/gorilla/handlers v1.4.0
 	github.com/gorilla/mux v1.7.1
 	github.com/gorilla/websocket v1.4.0
 	github.com/hako/durafmt v0.0.0-20191009132224-3f39dc1ed9f4
+	github.com/hashicorp/yamux v0.0.0-20190923154419-df201c70410d
 	github.com/kr/pty v1.1.3
-	github.com/leodido/go-urn v1.1.0 // indirect
+	github.com/lib/pq v1.10.9
 	github.com/olekukonko/tablewriter v0.0.3
-	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.2.1
 	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4
 	github.com/prometheus/common v0.7.0
@@ -36,17 +32,76 @@ require (
 	github.com/rakyll/statik v0.1.6
 	github.com/segmentio/conf v1.1.0
 	github.com/segmentio/ksuid v1.0.2
-	github.com/sendgrid/rest v2.4.1+incompatible // indirect
-	github.com/sendgrid/sendgrid-go v3.4.1+incompatible
-	github.com/stretchr/testify v1.3.0
-	github.com/urfave/cli v1.20.0 // indirect
+	github.com/stretchr/testify v1.8.1
 	github.com/vishvananda/netns v0.0.0-20190625233234-7109fa855b0f
-	github.com/zorkian/go-datadog-api v2.24.0+incompatible // indirect
-	golang.org/x/build v0.0.0-20190604154314-6c34d49dff48 // indirect
-	golang.org/x/crypto v0.0.0-20191002192127-34f69633bfdc
-	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	golang.org/x/crypto v0.15.0
+	golang.org/x/sync v0.5.0
+	google.golang.org/grpc v1.61.1
+	google.golang.org/protobuf v1.31.0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
-	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/go-playground/validator.v9 v9.29.1
 	gopkg.in/yaml.v2 v2.2.2
+	modernc.org/sqlite v1.29.5
+)
+
+require (
+	github.com/Microsoft/go-winio v0.4.13 // indirect
+	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
+	github.com/alecthomas/units v0.0.0-20190717042225-c3de453c63f4 // indirect
+	github.com/anmitsu/go-shlex v0.0.0-20161002113705-648efa622239 // indirect
+	github.com/beevik/etree v1.1.0 // indirect
+	github.com/beevik/ntp v0.2.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/docker/distribution v2.7.1+incompatible // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/ema/qdisc v0.0.0-20190904071900-b82c76788043 // indirect
+	github.com/go-playground/locales v0.12.1 // indirect
+	github.com/go-playground/universal-translator v0.16.0 // indirect
+	github.com/godbus/dbus v0.0.0-20190402143921-271e53dc4968 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/google/uuid v1.4.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/hodgesds/perf-utils v0.0.7 // indirect
+	github.com/jonboulle/clockwork v0.2.2 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/leodido/go-urn v1.1.0 // indirect
+	github.com/lufia/iostat v0.0.0-20170605150913-9f7362b77ad3 // indirect
+	github.com/mattermost/xml-roundtrip-validator v0.1.0 // indirect
+	github.com/mattn/go-isatty v0.0.16 // indirect
+	github.com/mattn/go-runewidth v0.0.6 // indirect
+	github.com/mattn/go-xmlrpc v0.0.3 // indirect
+	github.com/matttproud/golang_protobuf_extensions v1.0.1 // indirect
+	github.com/mdlayher/genetlink v0.0.0-20190828143517-e35f2bf499b9 // indirect
+	github.com/mdlayher/netlink v0.0.0-20190828143259-340058475d09 // indirect
+	github.com/mdlayher/wifi v0.0.0-20190303161829-b1436901ddee // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/prometheus/procfs v0.0.7 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/russellhaering/goxmldsig v1.4.0 // indirect
+	github.com/segmentio/go-snakecase v1.1.0 // indirect
+	github.com/segmentio/objconv v1.0.1 // indirect
+	github.com/siebenmann/go-kstat v0.0.0-20160321171754-d34789b79745 // indirect
+	github.com/sirupsen/logrus v1.4.2 // indirect
+	github.com/soundcloud/go-runit v0.0.0-20150630195641-06ad41a06c4a // indirect
+	go.uber.org/atomic v1.3.2 // indirect
+	go.uber.org/multierr v1.1.0 // indirect
+	golang.org/x/net v0.18.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/appengine v1.6.8 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20231106174013-bbf56f31fb17 // indirect
+	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+	gopkg.in/go-playground/mold.v2 v2.2.0 // indirect
+	gopkg.in/validator.v2 v2.0.0-20180514200540-135c24b11c19 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	modernc.org/gc/v3 v3.0.0-20240107210532-573471604cb6 // indirect
+	modernc.org/libc v1.41.0 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.7.2 // indirect
+	modernc.org/strutil v1.2.0 // indirect
+	modernc.org/token v1.1.0 // indirect
 )
@@ -2,17 +2,24 @@ package agent
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
-	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/client"
+	"github.com/deviceplane/deviceplane/pkg/agent/configfiles"
+	"github.com/deviceplane/deviceplane/pkg/agent/geolocation"
 	"github.com/deviceplane/deviceplane/pkg/agent/info"
+	"github.com/deviceplane/deviceplane/pkg/agent/ingest"
+	"github.com/deviceplane/deviceplane/pkg/agent/metrics"
 	"github.com/deviceplane/deviceplane/pkg/agent/server/local"
 	"github.com/deviceplane/deviceplane/pkg/agent/server/remote"
 	"github.com/deviceplane/deviceplane/pkg/agent/service"
@@ -31,9 +38,12 @@ import (
 )
 
 const (
-	accessKeyFilename = "access-key"
-	deviceIDFilename  = "device-id"
-	bundleFilename    = "bundle"
+	accessKeyFilename   = "access-key"
+	deviceIDFilename    = "device-id"
+	bundleFilename      = "bundle"
+	powerActionFilename = "power-action"
+
+	metricsBufferDirname = "metrics-buffer"
 )
 
 var (
@@ -48,17 +58,33 @@ type Agent struct {
 	confDir                string
 	stateDir               string
 	serverPort             int
+	statsDPort             int
 	supervisor             *supervisor.Supervisor
+	configFiles            *configfiles.Syncer
+	service                *service.Service
 	statusGarbageCollector *status.GarbageCollector
 	infoReporter           *info.Reporter
+	locator                *geolocation.Locator
+	metricsConfig          *metrics.Config
+	hostMetrics            *metrics.HostMetrics
+	ingestedMetrics        *ingest.Store
 	localServer            *local.Server
 	remoteServer           *remote.Server
 	updater                *updater.Updater
+
+	lastPowerAction     models.PowerAction
+	lastPowerActionLock sync.RWMutex
+
+	downloadedBundle int32
+
+	// heartbeatIntervalSeconds is how often bundles are downloaded, as set
+	// by the latest bundle
+	heartbeatIntervalSeconds int32
 }
 
 func NewAgent(
 	client *client.Client, engine engine.Engine,
-	projectID, registrationToken, confDir, stateDir, version, binaryPath string, serverPort int,
+	projectID, registrationToken, confDir, stateDir, version, binaryPath string, serverPort, statsDPort int,
 ) (*Agent, error) {
 	if version == "" {
 		return nil, errVersionNotSet
@@ -73,6 +99,8 @@ func NewAgent(
 		return nil, errors.Wrap(err, "start fsnotify variables")
 	}
 
+	client.SetConnectorTLSConfig(connectorTLSConfig(variables))
+
 	supervisor := supervisor.NewSupervisor(
 		engine,
 		variables,
@@ -92,9 +120,28 @@ func NewAgent(
 		},
 	)
 
-	service := service.NewService(variables, supervisor, engine, confDir)
+	agent := &Agent{}
+
+	metricsConfig := metrics.NewConfig()
+	metricsBuffer := metrics.NewBuffer(filepath.Join(stateDir, projectID, metricsBufferDirname), metrics.DefaultBufferSize)
+	hostMetrics := metrics.NewHostMetrics(variables, metricsConfig, metricsBuffer, func() bool {
+		return agent.remoteServer.Connected()
+	})
+	ingestedMetrics := ingest.NewStore()
 
-	return &Agent{
+	service := service.NewService(variables, supervisor, engine, confDir, hostMetrics, metricsBuffer, ingestedMetrics, metricsConfig,
+		func(ctx context.Context, sessionRecordingID, recording string) error {
+			return client.FinishSessionRecording(ctx, sessionRecordingID, models.FinishSessionRecordingRequest{
+				Recording: recording,
+			})
+		},
+		agent.performPowerAction,
+	)
+	remoteServer := remote.NewServer(client, service)
+	agentUpdater := updater.NewUpdater(projectID, version, binaryPath, filepath.Join(stateDir, projectID), variables)
+	locator := geolocation.NewLocator(variables)
+
+	*agent = Agent{
 		client:                 client,
 		variables:              variables,
 		projectID:              projectID,
@@ -102,17 +149,41 @@ func NewAgent(
 		confDir:                confDir,
 		stateDir:               stateDir,
 		serverPort:             serverPort,
+		statsDPort:             statsDPort,
 		supervisor:             supervisor,
+		configFiles:            configfiles.NewSyncer(filepath.Join(stateDir, projectID), supervisor.RestartService),
+		service:                service,
 		statusGarbageCollector: status.NewGarbageCollector(client.DeleteDeviceApplicationStatus, client.DeleteDeviceServiceStatus),
-		infoReporter:           info.NewReporter(client, version),
+		infoReporter:           info.NewReporter(client, engine, version, remoteServer.LastDisconnect, agent.getLastPowerAction, agentUpdater.LastFailedUpdate, locator.Location, variables.GetErrors, variables.GetLabels),
+		locator:                locator,
+		metricsConfig:          metricsConfig,
+		hostMetrics:            hostMetrics,
+		ingestedMetrics:        ingestedMetrics,
 		localServer:            local.NewServer(service),
-		remoteServer:           remote.NewServer(client, service),
-		updater:                updater.NewUpdater(projectID, version, binaryPath),
-	}, nil
+		remoteServer:           remoteServer,
+		updater:                agentUpdater,
+	}
+
+	return agent, nil
+}
+
+// RecordStart records that the agent was started, so that an agent update
+// that fails before the agent is running is still rolled back.
+func RecordStart(stateDir, projectID, version, binaryPath string) {
+	updater.NewUpdater(projectID, version, binaryPath, filepath.Join(stateDir, projectID), nil).RecordStart()
+}
+
+func connectorTLSConfig(variables variables.Interface) func() *tls.Config {
+	return func() *tls.Config {
+		return client.ConnectorTLSConfig(
+			variables.GetConnectorClientCertificate(),
+			variables.GetConnectorControllerCertificates(),
+		)
+	}
 }
 
 func (a *Agent) fileLocation(elem ...string) string {
-	return path.Join(
+	return filepath.Join(
 		append(
 			[]string{a.stateDir, a.projectID},
 			elem...,
@@ -131,6 +202,10 @@ func (a *Agent) writeFile(contents []byte, elem ...string) error {
 }
 
 func (a *Agent) Initialize() error {
+	if _, err := os.Stat(a.fileLocation(decommissionedFilename)); err == nil {
+		return ErrDecommissioned
+	}
+
 	if _, err := os.Stat(a.fileLocation(accessKeyFilename)); err == nil {
 		log.Info("device already registered")
 	} else if os.IsNotExist(err) {
@@ -155,6 +230,10 @@ func (a *Agent) Initialize() error {
 	a.client.SetAccessKey(string(accessKeyBytes))
 	a.client.SetDeviceID(string(deviceIDBytes))
 
+	if err := a.loadLastPowerAction(); err != nil {
+		log.WithError(err).Error("load last power action")
+	}
+
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
@@ -183,36 +262,156 @@ func (a *Agent) register() error {
 	return nil
 }
 
+// loadLastPowerAction marks the last power action as completed if the agent
+// is starting for the first time since it was performed.
+func (a *Agent) loadLastPowerAction() error {
+	powerActionBytes, err := ioutil.ReadFile(a.fileLocation(powerActionFilename))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var powerAction models.PowerAction
+	if err := json.Unmarshal(powerActionBytes, &powerAction); err != nil {
+		return err
+	}
+
+	if powerAction.CompletedAt.IsZero() && powerAction.Error == "" {
+		powerAction.CompletedAt = time.Now()
+		if err := a.saveLastPowerAction(powerAction); err != nil {
+			return err
+		}
+		log.WithField("action", powerAction.Action).
+			WithField("reason", powerAction.Reason).
+			Info("completed power action")
+	}
+
+	a.lastPowerActionLock.Lock()
+	a.lastPowerAction = powerAction
+	a.lastPowerActionLock.Unlock()
+
+	return nil
+}
+
+func (a *Agent) saveLastPowerAction(powerAction models.PowerAction) error {
+	powerActionBytes, err := json.Marshal(powerAction)
+	if err != nil {
+		return err
+	}
+	if err := a.writeFile(powerActionBytes, powerActionFilename); err != nil {
+		return err
+	}
+
+	a.lastPowerActionLock.Lock()
+	a.lastPowerAction = powerAction
+	a.lastPowerActionLock.Unlock()
+
+	return nil
+}
+
+func (a *Agent) getLastPowerAction() models.PowerAction {
+	a.lastPowerActionLock.RLock()
+	defer a.lastPowerActionLock.RUnlock()
+	return a.lastPowerAction
+}
+
+// performPowerAction records the power action so that its completion can be
+// reported once the device is back, reports it, and gracefully stops all
+// services before performing it. Services are supervised again if it fails.
+func (a *Agent) performPowerAction(ctx context.Context, powerAction models.PowerAction, perform func() error) error {
+	if err := a.saveLastPowerAction(powerAction); err != nil {
+		return errors.Wrap(err, "save power action")
+	}
+
+	if err := a.infoReporter.Report(); err != nil {
+		log.WithError(err).Error("report device info")
+	}
+
+	if err := a.supervisor.Shutdown(ctx); err != nil {
+		log.WithError(err).Error("stop services")
+	}
+
+	if err := perform(); err != nil {
+		powerAction.Error = err.Error()
+		if saveErr := a.saveLastPowerAction(powerAction); saveErr != nil {
+			log.WithError(saveErr).Error("save power action")
+		}
+		if reportErr := a.infoReporter.Report(); reportErr != nil {
+			log.WithError(reportErr).Error("report device info")
+		}
+		a.supervisor.Resume()
+		return err
+	}
+
+	return nil
+}
+
 func (a *Agent) Run() {
+	go a.updater.Watch(a.healthy)
 	go a.runBundleApplier()
 	go a.runInfoReporter()
+	go a.locator.Run()
+	go a.hostMetrics.Run()
 	go a.runRemoteServer()
 	go a.runLocalServer()
+	if a.statsDPort != 0 {
+		go a.runStatsDServer()
+	}
 	select {}
 }
 
+// healthy returns whether the agent can be managed by the controller, which
+// is used to decide whether an update to the agent succeeded.
+func (a *Agent) healthy() bool {
+	return atomic.LoadInt32(&a.downloadedBundle) == 1 && a.remoteServer.Connected()
+}
+
 func (a *Agent) runBundleApplier() {
-	if bundle := a.loadSavedBundle(); bundle != nil {
+	if bundle := a.loadSavedBundle(); bundle != nil && bundle.Decommission {
+		a.decommission()
+	} else if bundle != nil && bundle.TransferProjectID != "" {
+		a.transfer(bundle.TransferProjectID)
+	} else if bundle != nil {
+		a.configFiles.SetConfigFiles(bundle.ConfigFiles)
 		a.supervisor.SetApplications(bundle.Applications)
+		a.service.SetControllerSSHKeys(bundle.SSHKeys)
+		a.metricsConfig.Set(bundle.MetricsConfig)
+		a.setHeartbeatInterval(*bundle)
 	}
 
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
 	for {
-		if bundle := a.downloadLatestBundle(); bundle != nil {
+		if bundle := a.downloadLatestBundle(); bundle != nil && bundle.Decommission {
+			a.decommission()
+		} else if bundle != nil && bundle.TransferProjectID != "" {
+			a.transfer(bundle.TransferProjectID)
+		} else if bundle != nil {
+			atomic.StoreInt32(&a.downloadedBundle, 1)
+			// Config files are written first so that new containers see them
+			a.configFiles.SetConfigFiles(bundle.ConfigFiles)
 			a.supervisor.SetApplications(bundle.Applications)
+			a.service.SetControllerSSHKeys(bundle.SSHKeys)
+			a.metricsConfig.Set(bundle.MetricsConfig)
 			a.statusGarbageCollector.SetBundle(*bundle)
-			a.updater.SetDesiredVersion(bundle.DesiredAgentVersion)
+			a.updater.SetDesiredVersion(bundle.DesiredAgentVersion, bundle.DesiredAgentSpec)
+			a.setHeartbeatInterval(*bundle)
 		}
 
-		select {
-		case <-ticker.C:
-			continue
-		}
+		time.Sleep(a.getHeartbeatInterval())
 	}
 }
 
+func (a *Agent) setHeartbeatInterval(bundle models.Bundle) {
+	atomic.StoreInt32(&a.heartbeatIntervalSeconds, int32(bundle.HeartbeatIntervalSeconds))
+}
+
+func (a *Agent) getHeartbeatInterval() time.Duration {
+	if seconds := atomic.LoadInt32(&a.heartbeatIntervalSeconds); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return models.DefaultHeartbeatInterval
+}
+
 func (a *Agent) loadSavedBundle() *models.Bundle {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -268,21 +467,19 @@ func (a *Agent) downloadLatestBundle() *models.Bundle {
 	return bundle
 }
 
+// runInfoReporter reports the device's info every minute, or every
+// heartbeat if those are further apart.
 func (a *Agent) runInfoReporter() {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-
 	for {
 		if err := a.infoReporter.Report(); err != nil {
 			log.WithError(err).Error("report device info")
-			goto cont
 		}
 
-	cont:
-		select {
-		case <-ticker.C:
-			continue
+		interval := a.getHeartbeatInterval()
+		if interval < time.Minute {
+			interval = time.Minute
 		}
+		time.Sleep(interval)
 	}
 }
 
@@ -304,16 +501,24 @@ func (a *Agent) runLocalServer() {
 	}
 }
 
-func (a *Agent) runRemoteServer() {
+// runStatsDServer accepts StatsD metrics from applications on the device.
+// It only listens on localhost, like the local server.
+func (a *Agent) runStatsDServer() {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
 	for {
-		if err := a.remoteServer.Serve(); err != nil {
-			log.WithError(err).Error("serve remote device API")
+		conn, err := net.ListenPacket("udp", fmt.Sprintf("127.0.0.1:%d", a.statsDPort))
+		if err != nil {
+			log.WithError(err).Error("listen for statsd metrics")
 			goto cont
 		}
 
+		if err := a.ingestedMetrics.ServeStatsD(conn); err != nil {
+			log.WithError(err).Error("serve statsd metrics")
+		}
+		conn.Close()
+
 	cont:
 		select {
 		case <-ticker.C:
@@ -321,3 +526,7 @@ func (a *Agent) runRemoteServer() {
 		}
 	}
 }
+
+func (a *Agent) runRemoteServer() {
+	a.remoteServer.Run()
+}
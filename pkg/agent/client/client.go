@@ -3,12 +3,16 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/models"
@@ -27,6 +31,12 @@ type Client struct {
 
 	deviceID  string
 	accessKey string
+
+	connectorTLSConfig func() *tls.Config
+
+	clockSkew    time.Duration
+	clockSkewSet bool
+	clockLock    sync.RWMutex
 }
 
 func NewClient(url *url.URL, projectID string, httpClient *http.Client) *Client {
@@ -48,6 +58,27 @@ func (c *Client) SetAccessKey(accessKey string) {
 	c.accessKey = accessKey
 }
 
+// SetConnectorTLSConfig sets the function used to get the TLS config for
+// the connector WebSocket. It's called on every dial so that certificates
+// can be rotated without restarting the agent.
+func (c *Client) SetConnectorTLSConfig(connectorTLSConfig func() *tls.Config) {
+	c.connectorTLSConfig = connectorTLSConfig
+}
+
+// ControllerAddress returns the host and port of the controller.
+func (c *Client) ControllerAddress() string {
+	port := c.url.Port()
+	if port == "" {
+		switch c.url.Scheme {
+		case "http":
+			port = "80"
+		default:
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(c.url.Hostname(), port)
+}
+
 func (c *Client) RegisterDevice(ctx context.Context, registrationToken string) (*models.RegisterDeviceResponse, error) {
 	reqBytes, err := json.Marshal(models.RegisterDeviceRequest{
 		DeviceRegistrationTokenID: registrationToken,
@@ -99,6 +130,41 @@ func (c *Client) SetDeviceInfo(ctx context.Context, req models.SetDeviceInfoRequ
 	return c.post(ctx, req, nil, "projects", c.projectID, "devices", c.deviceID, "info")
 }
 
+// FinishDecommission reports that the device removed its services and
+// config files, after which its access key stops working. Unlike other
+// requests it fails unless the controller succeeded, since the agent wipes
+// its state afterwards.
+func (c *Client) FinishDecommission(ctx context.Context) error {
+	return c.postChecked(ctx, "projects", c.projectID, "devices", c.deviceID, "decommissioned")
+}
+
+// FinishTransfer reports that the device is ready to move to the project
+// it's being transferred to. Like FinishDecommission it fails unless the
+// controller succeeded, since the agent moves its state afterwards.
+func (c *Client) FinishTransfer(ctx context.Context) error {
+	return c.postChecked(ctx, "projects", c.projectID, "devices", c.deviceID, "transferred")
+}
+
+func (c *Client) postChecked(ctx context.Context, path ...string) error {
+	req, err := http.NewRequest("POST", getURL(c.url, path...), nil)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("controller responded with %s", resp.Status)
+	}
+	return nil
+}
+
 func (c *Client) SetDeviceApplicationStatus(ctx context.Context, applicationID string, req models.SetDeviceApplicationStatusRequest) error {
 	return c.post(ctx, req, nil, "projects", c.projectID, "devices", c.deviceID, "applications", applicationID, "deviceapplicationstatuses")
 }
@@ -115,24 +181,74 @@ func (c *Client) DeleteDeviceServiceStatus(ctx context.Context, applicationID, s
 	return c.delete(ctx, nil, "projects", c.projectID, "devices", c.deviceID, "applications", applicationID, "services", service, "deviceservicestatuses")
 }
 
-func (c *Client) InitiateDeviceConnection(ctx context.Context) (net.Conn, error) {
+func (c *Client) FinishSessionRecording(ctx context.Context, sessionRecordingID string, req models.FinishSessionRecordingRequest) error {
+	return c.post(ctx, req, nil, "projects", c.projectID, "devices", c.deviceID, "sessionrecordings", sessionRecordingID)
+}
+
+// InitiateDeviceConnection opens the connection the controller uses to reach
+// this device. It also returns whether the controller agreed to multiplex
+// sessions over it.
+func (c *Client) InitiateDeviceConnection(ctx context.Context) (net.Conn, bool, error) {
 	req, err := http.NewRequest("", "", nil)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	req.SetBasicAuth(c.accessKey, "")
+	req.Header.Set(models.DeviceConnectionProtocolHeader, models.DeviceConnectionProtocolYamux)
 
-	wsConn, _, err := websocket.DefaultDialer.Dial(getWebsocketURL(c.url, "projects", c.projectID, "devices", c.deviceID, "connection"), req.Header)
+	wsConn, resp, err := c.websocketDialer().Dial(getWebsocketURL(c.url, "projects", c.projectID, "devices", c.deviceID, "connection"), req.Header)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	return wsconnadapter.New(wsConn), nil
+	multiplexed := resp.Header.Get(models.DeviceConnectionProtocolHeader) == models.DeviceConnectionProtocolYamux
+
+	return wsconnadapter.New(wsConn), multiplexed, nil
 }
 
 func (c *Client) Revdial(ctx context.Context, path string) (*websocket.Conn, *http.Response, error) {
-	return websocket.DefaultDialer.Dial(getWebsocketURL(c.url, strings.TrimPrefix(path, "/")), nil)
+	return c.websocketDialer().Dial(getWebsocketURL(c.url, strings.TrimPrefix(path, "/")), nil)
+}
+
+func (c *Client) websocketDialer() *websocket.Dialer {
+	if c.connectorTLSConfig == nil {
+		return websocket.DefaultDialer
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = c.connectorTLSConfig()
+	return &dialer
+}
+
+// ClockSkew returns how far ahead of the controller's clock the device's
+// clock was during the last request to the controller.
+func (c *Client) ClockSkew() (time.Duration, bool) {
+	c.clockLock.RLock()
+	defer c.clockLock.RUnlock()
+	return c.clockSkew, c.clockSkewSet
+}
+
+// do performs a request, keeping track of the clock skew using the
+// response's Date header. The header only has a resolution of a second, so
+// the controller's time is taken to be halfway through that second.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		localTime := start.Add(time.Since(start) / 2)
+		controllerTime := date.Add(500 * time.Millisecond)
+
+		c.clockLock.Lock()
+		c.clockSkew = localTime.Sub(controllerTime)
+		c.clockSkewSet = true
+		c.clockLock.Unlock()
+	}
+
+	return resp, nil
 }
 
 func (c *Client) get(ctx context.Context, out interface{}, s ...string) error {
@@ -143,7 +259,7 @@ func (c *Client) get(ctx context.Context, out interface{}, s ...string) error {
 
 	req.SetBasicAuth(c.accessKey, "")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -181,7 +297,7 @@ func (c *Client) post(ctx context.Context, in, out interface{}, s ...string) err
 
 	req.SetBasicAuth(c.accessKey, "")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -213,7 +329,7 @@ func (c *Client) delete(ctx context.Context, out interface{}, s ...string) error
 
 	req.SetBasicAuth(c.accessKey, "")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
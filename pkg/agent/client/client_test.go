@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	client := NewClient(u, "project", nil)
+
+	_, ok := client.ClockSkew()
+	require.False(t, ok)
+
+	require.NoError(t, client.get(context.Background(), nil, "bundle"))
+
+	skew, ok := client.ClockSkew()
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Hour), float64(skew), float64(2*time.Second))
+}
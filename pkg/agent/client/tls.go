@@ -0,0 +1,47 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+var (
+	errControllerCertificateNotPinned = errors.New("controller certificate doesn't match any pinned certificate")
+)
+
+// ConnectorTLSConfig returns a TLS config that presents clientCertificate,
+// if set, and only trusts controllers whose certificate has the same public
+// key as one of pinnedCertificates, if any are set. Pinned certificates
+// replace the usual CA verification so that controllers with self-signed
+// certificates can be used.
+func ConnectorTLSConfig(clientCertificate *tls.Certificate, pinnedCertificates []*x509.Certificate) *tls.Config {
+	config := &tls.Config{}
+	if clientCertificate != nil {
+		config.Certificates = []tls.Certificate{*clientCertificate}
+	}
+	if len(pinnedCertificates) > 0 {
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyPinnedCertificate(pinnedCertificates)
+	}
+	return config
+}
+
+func verifyPinnedCertificate(pinnedCertificates []*x509.Certificate) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errControllerCertificateNotPinned
+		}
+		leaf, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return err
+		}
+		for _, pinnedCertificate := range pinnedCertificates {
+			if bytes.Equal(leaf.RawSubjectPublicKeyInfo, pinnedCertificate.RawSubjectPublicKeyInfo) {
+				return nil
+			}
+		}
+		return errControllerCertificateNotPinned
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "controller"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return certificate
+}
+
+func TestConnectorTLSConfig(t *testing.T) {
+	pinned := testCertificate(t)
+	other := testCertificate(t)
+
+	config := ConnectorTLSConfig(nil, nil)
+	require.False(t, config.InsecureSkipVerify)
+	require.Nil(t, config.VerifyPeerCertificate)
+
+	config = ConnectorTLSConfig(nil, []*x509.Certificate{pinned})
+	require.True(t, config.InsecureSkipVerify)
+	require.NoError(t, config.VerifyPeerCertificate([][]byte{pinned.Raw}, nil))
+	require.Equal(t, errControllerCertificateNotPinned, config.VerifyPeerCertificate([][]byte{other.Raw}, nil))
+	require.Equal(t, errControllerCertificateNotPinned, config.VerifyPeerCertificate(nil, nil))
+}
@@ -0,0 +1,182 @@
+package configfiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/file"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/pkg/errors"
+)
+
+// stateFilename records the path of every config file that was written, so
+// that files removed from the bundle can be removed from the host
+const stateFilename = "config-files"
+
+// Syncer keeps the config files in the bundle written to the host.
+type Syncer struct {
+	stateDir       string
+	restartService func(applicationID, service string)
+
+	lock sync.Mutex
+}
+
+func NewSyncer(stateDir string, restartService func(applicationID, service string)) *Syncer {
+	return &Syncer{
+		stateDir:       stateDir,
+		restartService: restartService,
+	}
+}
+
+// SetConfigFiles writes every config file whose content, mode or owner
+// doesn't match the host and restarts the services that depend on it. Each
+// file is replaced atomically, so services never see a partial file. Files
+// that were written before but aren't in configFiles anymore are removed.
+func (s *Syncer) SetConfigFiles(configFiles []models.BundledConfigFile) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	previousPaths, err := s.readState()
+	if err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Error("read config files state")
+	}
+
+	paths := make(map[string]string)
+	restartServices := make(map[models.BundledServiceReference]struct{})
+	for _, configFile := range configFiles {
+		paths[configFile.ID] = configFile.Path
+
+		changed, err := write(configFile)
+		if err != nil {
+			log.WithField("path", configFile.Path).WithError(err).Error("sync config file")
+			continue
+		}
+		if !changed {
+			continue
+		}
+
+		log.WithField("path", configFile.Path).Info("updated config file")
+		for _, service := range configFile.RestartServices {
+			restartServices[service] = struct{}{}
+		}
+	}
+
+	for id, path := range previousPaths {
+		if paths[id] == path {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.WithField("path", path).WithError(err).Error("remove config file")
+		}
+	}
+
+	if err := s.writeState(paths); err != nil {
+		log.WithError(err).Error("write config files state")
+	}
+
+	for service := range restartServices {
+		s.restartService(service.ApplicationID, service.Service)
+	}
+}
+
+// write writes a config file if it doesn't match the host and returns
+// whether it did.
+func write(configFile models.BundledConfigFile) (bool, error) {
+	uid, gid, err := parseOwner(configFile.Owner)
+	if err != nil {
+		return false, err
+	}
+	mode := os.FileMode(configFile.Mode).Perm()
+
+	if current, err := ioutil.ReadFile(configFile.Path); err == nil && bytes.Equal(current, []byte(configFile.Content)) {
+		info, err := os.Stat(configFile.Path)
+		if err != nil {
+			return false, err
+		}
+		if info.Mode().Perm() == mode && ownedBy(info, uid, gid) {
+			return false, nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(configFile.Path), 0755); err != nil {
+		return false, err
+	}
+
+	dir, name := filepath.Split(configFile.Path)
+	tempFile, err := ioutil.TempFile(dir, fmt.Sprintf(".%s", name))
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.WriteString(configFile.Content); err != nil {
+		tempFile.Close()
+		return false, err
+	}
+	if err := tempFile.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tempFile.Name(), mode); err != nil {
+		return false, err
+	}
+	if uid >= 0 {
+		if err := os.Chown(tempFile.Name(), uid, gid); err != nil {
+			return false, err
+		}
+	}
+
+	return true, os.Rename(tempFile.Name(), configFile.Path)
+}
+
+// parseOwner parses a uid:gid owner, returning -1 for both if it's empty.
+func parseOwner(owner string) (int, int, error) {
+	if owner == "" {
+		return -1, -1, nil
+	}
+
+	parts := strings.Split(owner, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid owner %s", owner)
+	}
+	uid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse uid")
+	}
+	gid, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse gid")
+	}
+	return uid, gid, nil
+}
+
+func (s *Syncer) readState() (map[string]string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(s.stateDir, stateFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths map[string]string
+	if err := json.Unmarshal(contents, &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func (s *Syncer) writeState(paths map[string]string) error {
+	contents, err := json.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.stateDir, 0700); err != nil {
+		return err
+	}
+	return file.WriteFileAtomic(filepath.Join(s.stateDir, stateFilename), contents, 0644)
+}
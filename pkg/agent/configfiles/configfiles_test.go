@@ -0,0 +1,62 @@
+package configfiles
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetConfigFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configfiles")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var restarted []models.BundledServiceReference
+	s := NewSyncer(filepath.Join(dir, "state"), func(applicationID, service string) {
+		restarted = append(restarted, models.BundledServiceReference{
+			ApplicationID: applicationID,
+			Service:       service,
+		})
+	})
+
+	path := filepath.Join(dir, "etc", "app", "app.conf")
+	configFile := models.BundledConfigFile{
+		ID:      "cfg_1",
+		Path:    path,
+		Content: "level = info\n",
+		Mode:    0600,
+		RestartServices: []models.BundledServiceReference{
+			{ApplicationID: "app_1", Service: "api"},
+		},
+	}
+
+	s.SetConfigFiles([]models.BundledConfigFile{configFile})
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "level = info\n", string(contents))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	require.Len(t, restarted, 1)
+
+	// Files that didn't change aren't written and don't restart services
+	s.SetConfigFiles([]models.BundledConfigFile{configFile})
+	require.Len(t, restarted, 1)
+
+	configFile.Content = "level = debug\n"
+	s.SetConfigFiles([]models.BundledConfigFile{configFile})
+	contents, err = ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "level = debug\n", string(contents))
+	require.Len(t, restarted, 2)
+
+	// Files removed from the bundle are removed from the host
+	s.SetConfigFiles(nil)
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
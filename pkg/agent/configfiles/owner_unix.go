@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package configfiles
+
+import (
+	"os"
+	"syscall"
+)
+
+func ownedBy(info os.FileInfo, uid, gid int) bool {
+	if uid < 0 {
+		return true
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+	return int(stat.Uid) == uid && int(stat.Gid) == gid
+}
@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"context"
+	"os"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+)
+
+const decommissionedFilename = "decommissioned"
+
+// ErrDecommissioned is returned when initializing an agent on a device that
+// was decommissioned. The agent doesn't register the device again.
+var ErrDecommissioned = errors.New("device was decommissioned")
+
+// decommission removes the device's services and config files, tells the
+// controller, and then wipes the agent's state, including its access key.
+// A marker is left behind so that the agent doesn't register again when
+// it's restarted. Failures are retried with the next bundle.
+func (a *Agent) decommission() {
+	log.Info("decommissioning device")
+
+	if err := a.supervisor.RemoveAll(context.TODO()); err != nil {
+		log.WithError(err).Error("remove services")
+		return
+	}
+	a.configFiles.SetConfigFiles(nil)
+
+	if err := a.client.FinishDecommission(context.TODO()); err != nil {
+		log.WithError(err).Error("finish decommission")
+		return
+	}
+
+	if err := os.RemoveAll(a.fileLocation()); err != nil {
+		log.WithError(err).Error("remove state")
+	}
+	if err := a.writeFile(nil, decommissionedFilename); err != nil {
+		log.WithError(err).Error("save decommissioned marker")
+	}
+
+	log.Info("device decommissioned")
+	os.Exit(0)
+}
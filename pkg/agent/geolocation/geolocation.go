@@ -0,0 +1,119 @@
+package geolocation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/agent/modem"
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	// How often the device is located. IP geolocation services rate limit
+	// requests, so this shouldn't be much more often.
+	interval = 5 * time.Minute
+
+	sourceTimeout = 30 * time.Second
+)
+
+var errNoFix = errors.New("no fix")
+
+// Locator periodically locates the device using the sources configured in
+// the geolocation variable, in order, until one of them succeeds.
+type Locator struct {
+	variables variables.Interface
+
+	location *models.Location
+	lock     sync.RWMutex
+}
+
+func NewLocator(variables variables.Interface) *Locator {
+	return &Locator{
+		variables: variables,
+	}
+}
+
+// Location returns the device's location, or nil if geolocation isn't
+// configured or hasn't succeeded yet.
+func (l *Locator) Location() *models.Location {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	return l.location
+}
+
+func (l *Locator) Run() {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		l.locate()
+
+		select {
+		case <-ticker.C:
+			continue
+		}
+	}
+}
+
+// locate updates the location. The last known location is kept if none of
+// the sources currently succeed.
+func (l *Locator) locate() {
+	sources := l.variables.GetGeolocationSources()
+	if len(sources) == 0 {
+		l.lock.Lock()
+		l.location = nil
+		l.lock.Unlock()
+		return
+	}
+
+	for _, source := range sources {
+		location, err := locate(source)
+		if err != nil {
+			log.WithField("source", source.Type).WithError(err).Debug("locate device")
+			continue
+		}
+
+		l.lock.Lock()
+		l.location = location
+		l.lock.Unlock()
+		return
+	}
+}
+
+func locate(source variables.GeolocationSource) (*models.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sourceTimeout)
+	defer cancel()
+
+	var latitude, longitude float64
+	var err error
+	switch source.Type {
+	case variables.GeolocationSourceGPSD:
+		latitude, longitude, err = locateGPSD(ctx, source.Address)
+	case variables.GeolocationSourceNMEA:
+		latitude, longitude, err = locateNMEA(ctx, source.Address)
+	case variables.GeolocationSourceModem:
+		var ok bool
+		latitude, longitude, ok, err = modem.GPSLocation(ctx)
+		if err == nil && !ok {
+			err = errNoFix
+		}
+	case variables.GeolocationSourceIP:
+		latitude, longitude, err = locateIP(ctx, source.Address)
+	default:
+		return nil, errors.New("unknown geolocation source")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Location{
+		Latitude:  latitude,
+		Longitude: longitude,
+		Source:    source.Type,
+		Time:      time.Now(),
+	}, nil
+}
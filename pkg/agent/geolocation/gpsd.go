@@ -0,0 +1,57 @@
+package geolocation
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+)
+
+const defaultGPSDAddress = "localhost:2947"
+
+type gpsdReport struct {
+	Class string  `json:"class"`
+	Mode  int     `json:"mode"`
+	Lat   float64 `json:"lat"`
+	Lon   float64 `json:"lon"`
+}
+
+// locateGPSD waits for a position report with a 2D or 3D fix from gpsd.
+func locateGPSD(ctx context.Context, address string) (float64, float64, error) {
+	if address == "" {
+		address = defaultGPSDAddress
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(sourceTimeout))
+	}
+
+	if _, err := conn.Write([]byte(`?WATCH={"enable":true,"json":true};`)); err != nil {
+		return 0, 0, err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var report gpsdReport
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			continue
+		}
+		if report.Class == "TPV" && report.Mode >= 2 {
+			return report.Lat, report.Lon, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, errNoFix
+}
@@ -0,0 +1,56 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const defaultIPGeolocationURL = "https://ipapi.co/json/"
+
+// ipGeolocationResponse covers the field names used by common IP
+// geolocation services.
+type ipGeolocationResponse struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Lat       *float64 `json:"lat"`
+	Lon       *float64 `json:"lon"`
+}
+
+// locateIP looks up the location of the device's public IP address. It's
+// only accurate to around the city the device is in.
+func locateIP(ctx context.Context, url string) (float64, float64, error) {
+	if url == "" {
+		url = defaultIPGeolocationURL
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("IP geolocation returned %s", resp.Status)
+	}
+
+	var location ipGeolocationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&location); err != nil {
+		return 0, 0, err
+	}
+
+	switch {
+	case location.Latitude != nil && location.Longitude != nil:
+		return *location.Latitude, *location.Longitude, nil
+	case location.Lat != nil && location.Lon != nil:
+		return *location.Lat, *location.Lon, nil
+	}
+	return 0, 0, errNoFix
+}
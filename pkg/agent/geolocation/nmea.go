@@ -0,0 +1,114 @@
+package geolocation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var errInvalidCoordinate = errors.New("invalid NMEA coordinate")
+
+// locateNMEA reads NMEA sentences from a GPS receiver's serial device until
+// one of them has a fix. The device is expected to already be configured
+// with the receiver's baud rate.
+func locateNMEA(ctx context.Context, device string) (float64, float64, error) {
+	file, err := os.Open(device)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	latitude, longitude, err := readNMEA(file)
+	if ctx.Err() != nil {
+		return 0, 0, ctx.Err()
+	}
+	return latitude, longitude, err
+}
+
+func readNMEA(r io.Reader) (float64, float64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		latitude, longitude, ok := parseNMEASentence(strings.TrimSpace(scanner.Text()))
+		if ok {
+			return latitude, longitude, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	return 0, 0, errNoFix
+}
+
+// parseNMEASentence gets the position from GGA and RMC sentences that have
+// a fix. Talker IDs other than GP, such as GN for multi-constellation
+// receivers, are accepted too.
+func parseNMEASentence(sentence string) (float64, float64, bool) {
+	if i := strings.Index(sentence, "*"); i != -1 {
+		sentence = sentence[:i]
+	}
+	fields := strings.Split(sentence, ",")
+	if len(fields[0]) != 6 || fields[0][0] != '$' {
+		return 0, 0, false
+	}
+
+	var latitudeFields, longitudeFields []string
+	switch fields[0][3:] {
+	case "GGA":
+		// Fix quality 0 means there's no fix
+		if len(fields) < 7 || fields[6] == "" || fields[6] == "0" {
+			return 0, 0, false
+		}
+		latitudeFields, longitudeFields = fields[2:4], fields[4:6]
+	case "RMC":
+		if len(fields) < 7 || fields[2] != "A" {
+			return 0, 0, false
+		}
+		latitudeFields, longitudeFields = fields[3:5], fields[5:7]
+	default:
+		return 0, 0, false
+	}
+
+	latitude, err := parseNMEACoordinate(latitudeFields[0], latitudeFields[1], 2)
+	if err != nil {
+		return 0, 0, false
+	}
+	longitude, err := parseNMEACoordinate(longitudeFields[0], longitudeFields[1], 3)
+	if err != nil {
+		return 0, 0, false
+	}
+	return latitude, longitude, true
+}
+
+// parseNMEACoordinate converts a coordinate in degrees and decimal minutes,
+// such as 4807.038 for 48 degrees and 7.038 minutes, to decimal degrees.
+func parseNMEACoordinate(value, hemisphere string, degreeDigits int) (float64, error) {
+	if len(value) < degreeDigits {
+		return 0, errInvalidCoordinate
+	}
+
+	degrees, err := strconv.ParseFloat(value[:degreeDigits], 64)
+	if err != nil {
+		return 0, errInvalidCoordinate
+	}
+	minutes, err := strconv.ParseFloat(value[degreeDigits:], 64)
+	if err != nil {
+		return 0, errInvalidCoordinate
+	}
+
+	coordinate := degrees + minutes/60
+	switch hemisphere {
+	case "N", "E":
+		return coordinate, nil
+	case "S", "W":
+		return -coordinate, nil
+	}
+	return 0, errInvalidCoordinate
+}
@@ -0,0 +1,29 @@
+package geolocation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadNMEA(t *testing.T) {
+	t.Run("fix", func(t *testing.T) {
+		latitude, longitude, err := readNMEA(strings.NewReader(strings.Join([]string{
+			"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74",
+			"$GPGGA,123519,,,,,0,00,,,M,,M,,*66",
+			"$GNRMC,123519,A,4807.038,N,01131.000,W,022.4,084.4,230394,003.1,W*6A",
+		}, "\n")))
+		require.NoError(t, err)
+		require.InDelta(t, 48.1173, latitude, 0.0001)
+		require.InDelta(t, -11.5167, longitude, 0.0001)
+	})
+
+	t.Run("no fix", func(t *testing.T) {
+		_, _, err := readNMEA(strings.NewReader(strings.Join([]string{
+			"$GPGGA,123519,,,,,0,00,,,M,,M,,*66",
+			"$GPRMC,123519,V,,,,,,,230394,,*6A",
+		}, "\n")))
+		require.Equal(t, errNoFix, err)
+	})
+}
@@ -0,0 +1,100 @@
+package info
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	deviceTreeDir = "/proc/device-tree"
+	dmiDir        = "/sys/class/dmi/id"
+)
+
+// Firmware often leaves DMI fields set to placeholders like these rather
+// than leaving them empty
+var dmiPlaceholders = []string{
+	"to be filled by o.e.m.",
+	"default string",
+	"system product name",
+	"system serial number",
+	"not applicable",
+	"not specified",
+	"none",
+	"0",
+}
+
+// setBoard fills in the board's model, serial number, SoC and revision.
+// ARM boards describe themselves in the device tree and /proc/cpuinfo, while
+// x86 machines report the same things through DMI.
+func setBoard(hardware *models.Hardware, deviceTreeDir, dmiDir string, cpuInfo map[string]string) {
+	hardware.Model = firstNonEmpty(
+		readDeviceTreeString(filepath.Join(deviceTreeDir, "model")),
+		readDMIString(filepath.Join(dmiDir, "product_name")),
+		cpuInfo["Model"],
+	)
+	hardware.SerialNumber = firstNonEmpty(
+		readDeviceTreeString(filepath.Join(deviceTreeDir, "serial-number")),
+		cpuInfo["Serial"],
+		readDMIString(filepath.Join(dmiDir, "product_serial")),
+	)
+	hardware.SoC = firstNonEmpty(
+		readDeviceTreeSoC(filepath.Join(deviceTreeDir, "compatible")),
+		cpuInfo["Hardware"],
+	)
+	hardware.BoardRevision = firstNonEmpty(
+		cpuInfo["Revision"],
+		readDMIString(filepath.Join(dmiDir, "board_version")),
+	)
+}
+
+// readDeviceTreeString reads a device tree string property, which is NUL
+// terminated.
+func readDeviceTreeString(path string) string {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.TrimRight(contents, "\x00")))
+}
+
+// readDeviceTreeSoC reads the SoC from the device tree's compatible list,
+// which goes from the most specific entry, the board, to the least specific
+// one, the SoC, such as "nvidia,tegra210".
+func readDeviceTreeSoC(path string) string {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	entries := strings.Split(string(bytes.TrimRight(contents, "\x00")), "\x00")
+	if len(entries) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(entries[len(entries)-1])
+}
+
+func readDMIString(path string) string {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	value := strings.TrimSpace(string(contents))
+	for _, placeholder := range dmiPlaceholders {
+		if strings.EqualFold(value, placeholder) {
+			return ""
+		}
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package info
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetBoard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "board")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	deviceTreeDir := filepath.Join(dir, "device-tree")
+	dmiDir := filepath.Join(dir, "dmi")
+	require.NoError(t, os.MkdirAll(deviceTreeDir, 0755))
+	require.NoError(t, os.MkdirAll(dmiDir, 0755))
+
+	var hardware models.Hardware
+	setBoard(&hardware, deviceTreeDir, dmiDir, map[string]string{})
+	require.Equal(t, models.Hardware{}, hardware)
+
+	// A Jetson Nano
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deviceTreeDir, "model"), []byte("NVIDIA Jetson Nano Developer Kit\x00"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deviceTreeDir, "serial-number"), []byte("1422019012345\x00"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(deviceTreeDir, "compatible"), []byte("nvidia,p3450-0000\x00nvidia,jetson-nano\x00nvidia,tegra210\x00"), 0644))
+
+	hardware = models.Hardware{}
+	setBoard(&hardware, deviceTreeDir, dmiDir, map[string]string{})
+	require.Equal(t, models.Hardware{
+		Model:        "NVIDIA Jetson Nano Developer Kit",
+		SerialNumber: "1422019012345",
+		SoC:          "nvidia,tegra210",
+	}, hardware)
+
+	// An x86 machine with placeholder DMI fields
+	require.NoError(t, os.RemoveAll(deviceTreeDir))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dmiDir, "product_name"), []byte("NUC8i5BEH\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dmiDir, "product_serial"), []byte("To Be Filled By O.E.M.\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dmiDir, "board_version"), []byte("J72693-306\n"), 0644))
+
+	hardware = models.Hardware{}
+	setBoard(&hardware, deviceTreeDir, dmiDir, map[string]string{})
+	require.Equal(t, models.Hardware{
+		Model:         "NUC8i5BEH",
+		BoardRevision: "J72693-306",
+	}, hardware)
+
+	// A Raspberry Pi on an older kernel without a device tree model
+	hardware = models.Hardware{}
+	setBoard(&hardware, deviceTreeDir, filepath.Join(dir, "missing"), map[string]string{
+		"Hardware": "BCM2835",
+		"Revision": "a02082",
+		"Serial":   "00000000deadbeef",
+	})
+	require.Equal(t, models.Hardware{
+		SerialNumber:  "00000000deadbeef",
+		SoC:           "BCM2835",
+		BoardRevision: "a02082",
+	}, hardware)
+}
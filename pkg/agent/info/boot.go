@@ -0,0 +1,31 @@
+package info
+
+import (
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// getBoot gets when the device booted and why. A boot is requested if it
+// follows a power action that was requested before the boot and completed
+// once the agent started after it.
+func getBoot(bootTime time.Time, lastPowerAction models.PowerAction) models.Boot {
+	if bootTime.IsZero() {
+		return models.Boot{}
+	}
+
+	boot := models.Boot{
+		Time:   bootTime,
+		Reason: models.BootReasonUnknown,
+	}
+
+	if lastPowerAction.Error == "" &&
+		lastPowerAction.RequestedAt.Before(bootTime) &&
+		lastPowerAction.CompletedAt.After(bootTime) {
+		boot.Reason = models.BootReasonRequested
+	} else if reason := getHardwareBootReason(); reason != "" {
+		boot.Reason = reason
+	}
+
+	return boot
+}
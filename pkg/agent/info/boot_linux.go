@@ -0,0 +1,81 @@
+package info
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Watchdog boot status flags from linux/watchdog.h
+const (
+	wdiofOverheat   = 0x0001
+	wdiofExternal1  = 0x0004
+	wdiofExternal2  = 0x0008
+	wdiofPowerUnder = 0x0010
+	wdiofCardReset  = 0x0020
+	wdiofPowerOver  = 0x0040
+)
+
+func getBootTime() (time.Time, error) {
+	fields, err := readProcStat()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	btime, err := strconv.ParseInt(fields["btime"], 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(btime, 0), nil
+}
+
+func readProcStat() (map[string]string, error) {
+	contents, err := ioutil.ReadFile("/proc/stat")
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(string(contents), "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) == 2 {
+			fields[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+	return fields, nil
+}
+
+// getHardwareBootReason reads why the device last reset from the watchdog
+// drivers that report it. It returns an empty string if none of them know.
+func getHardwareBootReason() string {
+	paths, err := filepath.Glob("/sys/class/watchdog/watchdog*/bootstatus")
+	if err != nil {
+		return ""
+	}
+
+	for _, path := range paths {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		bootStatus, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 0, 32)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case bootStatus&wdiofOverheat != 0:
+			return models.BootReasonOverheat
+		case bootStatus&(wdiofPowerUnder|wdiofPowerOver) != 0:
+			return models.BootReasonPower
+		case bootStatus&(wdiofCardReset|wdiofExternal1|wdiofExternal2) != 0:
+			return models.BootReasonWatchdog
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package info
+
+import (
+	"time"
+)
+
+func getBootTime() (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func getHardwareBootReason() string {
+	return ""
+}
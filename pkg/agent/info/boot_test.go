@@ -0,0 +1,34 @@
+package info
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBoot(t *testing.T) {
+	bootTime := time.Now().Add(-time.Hour)
+
+	require.Equal(t, models.Boot{}, getBoot(time.Time{}, models.PowerAction{}))
+
+	require.Equal(t, models.BootReasonRequested, getBoot(bootTime, models.PowerAction{
+		Action:      models.PowerActionReboot,
+		RequestedAt: bootTime.Add(-time.Minute),
+		CompletedAt: bootTime.Add(time.Minute),
+	}).Reason)
+
+	// A power action that completed before the last boot doesn't explain it
+	require.NotEqual(t, models.BootReasonRequested, getBoot(bootTime, models.PowerAction{
+		Action:      models.PowerActionReboot,
+		RequestedAt: bootTime.Add(-2 * time.Hour),
+		CompletedAt: bootTime.Add(-time.Hour),
+	}).Reason)
+
+	require.NotEqual(t, models.BootReasonRequested, getBoot(bootTime, models.PowerAction{
+		Action:      models.PowerActionReboot,
+		RequestedAt: bootTime.Add(-time.Minute),
+		Error:       "reboot failed",
+	}).Reason)
+}
@@ -0,0 +1,26 @@
+package info
+
+import (
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Skew can only be measured to within about a second since the controller's
+// time comes from the Date header. Anything smaller is reported as no skew
+// so that noise doesn't cause the info to be reported again.
+const clockSkewResolution = 2 * time.Second
+
+func getClock(clockSkew time.Duration, clockSkewSet bool) models.Clock {
+	var clock models.Clock
+
+	if clockSkewSet && (clockSkew >= clockSkewResolution || clockSkew <= -clockSkewResolution) {
+		clock.Skew = int64(clockSkew.Round(time.Second) / time.Second)
+	}
+
+	if ntpSynchronized, ok := getNTPSynchronized(); ok {
+		clock.NTPSynchronized = &ntpSynchronized
+	}
+
+	return clock
+}
@@ -0,0 +1,22 @@
+package info
+
+import (
+	"syscall"
+)
+
+// From linux/timex.h
+const (
+	timeError = 5
+	staUnsync = 0x0040
+)
+
+// getNTPSynchronized asks the kernel whether the clock is being kept in sync
+// by an NTP daemon such as ntpd, chrony or systemd-timesyncd.
+func getNTPSynchronized() (bool, bool) {
+	var timex syscall.Timex
+	state, err := syscall.Adjtimex(&timex)
+	if err != nil {
+		return false, false
+	}
+	return state != timeError && timex.Status&staUnsync == 0, true
+}
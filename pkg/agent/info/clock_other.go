@@ -0,0 +1,8 @@
+//go:build !linux
+// +build !linux
+
+package info
+
+func getNTPSynchronized() (bool, bool) {
+	return false, false
+}
@@ -0,0 +1,189 @@
+package info
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const smartctlTimeout = 30 * time.Second
+
+var mountEscapes = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+// getFilesystems reports usage for mounted filesystems backed by a block
+// device. Each device is only reported once, at the first place it's
+// mounted, so bind mounts don't show up as separate filesystems.
+func getFilesystems() ([]models.Filesystem, error) {
+	file, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var filesystems []models.Filesystem
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		device, mountpoint, fsType := fields[0], mountEscapes.Replace(fields[1]), fields[2]
+		if !strings.HasPrefix(device, "/dev/") || seen[device] {
+			continue
+		}
+
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(mountpoint, &stat); err != nil {
+			continue
+		}
+		seen[device] = true
+
+		blockSize := uint64(stat.Bsize)
+		filesystems = append(filesystems, models.Filesystem{
+			Device:      device,
+			Mountpoint:  mountpoint,
+			Type:        fsType,
+			TotalBytes:  uint64(stat.Blocks) * blockSize,
+			FreeBytes:   uint64(stat.Bavail) * blockSize,
+			TotalInodes: uint64(stat.Files),
+			FreeInodes:  uint64(stat.Ffree),
+		})
+	}
+
+	return filesystems, scanner.Err()
+}
+
+// getDisks reports the health of physical block devices. Virtual devices
+// such as loop and device mapper devices don't have a device directory in
+// sysfs and are skipped.
+func getDisks() ([]models.Disk, error) {
+	infos, err := ioutil.ReadDir("/sys/block")
+	if err != nil {
+		return nil, err
+	}
+
+	_, smartctlErr := exec.LookPath("smartctl")
+
+	var disks []models.Disk
+	for _, info := range infos {
+		name := info.Name()
+		if strings.HasPrefix(name, "mmcblk") && (strings.Contains(name, "boot") || strings.Contains(name, "rpmb")) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join("/sys/block", name, "device")); err != nil {
+			continue
+		}
+
+		disk := models.Disk{
+			Name: name,
+		}
+		if strings.HasPrefix(name, "mmcblk") {
+			disk.Healthy, disk.WearLevel = getEMMCHealth(name)
+		} else if smartctlErr == nil {
+			disk.Healthy, disk.WearLevel = getSMARTHealth(name)
+		}
+
+		disks = append(disks, disk)
+	}
+
+	return disks, nil
+}
+
+// getEMMCHealth reads the health reports that eMMC 5.0 and later devices
+// expose. Lifetime estimates are in steps of 10% of the device's rated
+// endurance, and a pre-EOL value other than normal means the device is
+// running out of reserved blocks.
+func getEMMCHealth(name string) (*bool, *int) {
+	var healthy *bool
+	var wearLevel *int
+
+	preEOLInfo, err := readHexValues(filepath.Join("/sys/block", name, "device", "pre_eol_info"))
+	if err == nil && len(preEOLInfo) == 1 && preEOLInfo[0] != 0 {
+		h := preEOLInfo[0] == 1
+		healthy = &h
+	}
+
+	lifeTime, err := readHexValues(filepath.Join("/sys/block", name, "device", "life_time"))
+	if err == nil {
+		estimate := 0
+		for _, value := range lifeTime {
+			if value > estimate {
+				estimate = value
+			}
+		}
+		if estimate != 0 {
+			w := estimate * 10
+			if w > 100 {
+				w = 100
+			}
+			wearLevel = &w
+		}
+	}
+
+	return healthy, wearLevel
+}
+
+func readHexValues(path string) ([]int, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []int
+	for _, field := range strings.Fields(string(contents)) {
+		value, err := strconv.ParseInt(strings.TrimPrefix(field, "0x"), 16, 32)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, int(value))
+	}
+	return values, nil
+}
+
+type smartctlOutput struct {
+	SMARTStatus *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	NVMeSMARTHealthInformationLog *struct {
+		PercentageUsed int `json:"percentage_used"`
+	} `json:"nvme_smart_health_information_log"`
+}
+
+// getSMARTHealth gets a disk's health from smartctl. Disks that are spun
+// down are skipped rather than woken up.
+func getSMARTHealth(name string) (*bool, *int) {
+	ctx, cancel := context.WithTimeout(context.Background(), smartctlTimeout)
+	defer cancel()
+
+	// smartctl's exit status is a bit mask that's set for failing disks too,
+	// so the output is parsed whenever there is some
+	output, _ := exec.CommandContext(ctx, "smartctl", "--json", "-n", "standby", "-H", "-A", filepath.Join("/dev", name)).Output()
+
+	var smartctl smartctlOutput
+	if err := json.Unmarshal(output, &smartctl); err != nil {
+		return nil, nil
+	}
+
+	var healthy *bool
+	if smartctl.SMARTStatus != nil {
+		healthy = &smartctl.SMARTStatus.Passed
+	}
+
+	var wearLevel *int
+	if smartctl.NVMeSMARTHealthInformationLog != nil {
+		wearLevel = &smartctl.NVMeSMARTHealthInformationLog.PercentageUsed
+	}
+
+	return healthy, wearLevel
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package info
+
+import (
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getFilesystems() ([]models.Filesystem, error) {
+	return nil, nil
+}
+
+func getDisks() ([]models.Disk, error) {
+	return nil, nil
+}
@@ -0,0 +1,28 @@
+package info
+
+import (
+	"context"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/modem"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getModems() ([]models.Modem, error) {
+	modems, err := modem.Modems(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []models.Modem
+	for _, m := range modems {
+		ret = append(ret, models.Modem{
+			Manufacturer:     m.Manufacturer,
+			Model:            m.Model,
+			State:            m.State,
+			AccessTechnology: m.AccessTechnology,
+			Operator:         m.Operator,
+			APN:              m.APN,
+		})
+	}
+	return ret, nil
+}
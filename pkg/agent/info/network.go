@@ -0,0 +1,79 @@
+package info
+
+import (
+	"net"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getNetworkInterfaces() ([]models.NetworkInterface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var networkInterfaces []models.NetworkInterface
+	for _, iface := range interfaces {
+		// Skip the host side of container veth pairs since they come and go
+		// with containers
+		if iface.Flags&net.FlagLoopback != 0 || strings.HasPrefix(iface.Name, "veth") {
+			continue
+		}
+
+		networkInterface := models.NetworkInterface{
+			Name:         iface.Name,
+			HardwareAddr: iface.HardwareAddr.String(),
+			MTU:          iface.MTU,
+			Up:           iface.Flags&net.FlagUp != 0,
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if ipnet.IP.To4() != nil {
+				networkInterface.IPv4Addresses = append(networkInterface.IPv4Addresses, ipnet.String())
+			} else {
+				networkInterface.IPv6Addresses = append(networkInterface.IPv6Addresses, ipnet.String())
+			}
+		}
+
+		networkInterfaces = append(networkInterfaces, networkInterface)
+	}
+
+	return networkInterfaces, nil
+}
+
+// getControllerInterface finds the interface that traffic to the controller
+// is routed through. Connecting a UDP socket picks a route without sending
+// anything.
+func getControllerInterface(controllerAddress string, networkInterfaces []models.NetworkInterface) (string, error) {
+	conn, err := net.Dial("udp", controllerAddress)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	localAddr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return "", nil
+	}
+
+	for _, networkInterface := range networkInterfaces {
+		addresses := append(networkInterface.IPv4Addresses, networkInterface.IPv6Addresses...)
+		for _, address := range addresses {
+			ip, _, err := net.ParseCIDR(address)
+			if err == nil && ip.Equal(localAddr.IP) {
+				return networkInterface.Name, nil
+			}
+		}
+	}
+
+	return "", nil
+}
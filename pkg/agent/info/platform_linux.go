@@ -0,0 +1,120 @@
+package info
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getKernelVersion() (string, error) {
+	var utsname syscall.Utsname
+	if err := syscall.Uname(&utsname); err != nil {
+		return "", err
+	}
+	return utsnameString(&utsname.Release), nil
+}
+
+func getHardware() (*models.Hardware, error) {
+	var utsname syscall.Utsname
+	if err := syscall.Uname(&utsname); err != nil {
+		return nil, err
+	}
+
+	hardware := models.Hardware{
+		Architecture: utsnameString(&utsname.Machine),
+		CPUCount:     runtime.NumCPU(),
+	}
+
+	cpuInfo, err := readProcFields("/proc/cpuinfo")
+	if err != nil {
+		return nil, err
+	}
+	hardware.CPUModel = getCPUModel(cpuInfo)
+	setBoard(&hardware, deviceTreeDir, dmiDir, cpuInfo)
+
+	totalMemory, err := getTotalMemory()
+	if err != nil {
+		return nil, err
+	}
+	hardware.TotalMemory = totalMemory
+
+	return &hardware, nil
+}
+
+// utsnameString converts a utsname field to a string. The fields are int8
+// arrays on some architectures and uint8 arrays on others.
+func utsnameString(p interface{}) string {
+	var field *[65]byte
+	switch f := p.(type) {
+	case *[65]int8:
+		field = (*[65]byte)(unsafe.Pointer(f))
+	case *[65]uint8:
+		field = f
+	}
+	if i := bytes.IndexByte(field[:], 0); i != -1 {
+		return string(field[:i])
+	}
+	return string(field[:])
+}
+
+// getCPUModel gets the CPU model from /proc/cpuinfo. x86 kernels report it
+// per processor as "model name", while many ARM boards only report the
+// board under "Hardware" or "Model".
+func getCPUModel(cpuInfo map[string]string) string {
+	for _, key := range []string{"model name", "Model", "Hardware", "cpu model", "cpu"} {
+		if value, ok := cpuInfo[key]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// getTotalMemory returns the total memory in bytes.
+func getTotalMemory() (uint64, error) {
+	fields, err := readProcFields("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+
+	memTotal := strings.TrimSuffix(fields["MemTotal"], " kB")
+	if memTotal == "" {
+		return 0, nil
+	}
+
+	kilobytes, err := strconv.ParseUint(memTotal, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return kilobytes * 1024, nil
+}
+
+// readProcFields reads "key: value" lines, keeping the first value seen for
+// each key.
+func readProcFields(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		if _, ok := fields[key]; !ok {
+			fields[key] = strings.TrimSpace(parts[1])
+		}
+	}
+	return fields, scanner.Err()
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package info
+
+import (
+	"runtime"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getKernelVersion() (string, error) {
+	return "", nil
+}
+
+func getHardware() (*models.Hardware, error) {
+	return &models.Hardware{
+		Architecture: runtime.GOARCH,
+		CPUCount:     runtime.NumCPU(),
+	}, nil
+}
@@ -2,29 +2,67 @@ package info
 
 import (
 	"context"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/client"
+	"github.com/deviceplane/deviceplane/pkg/engine"
 	"github.com/deviceplane/deviceplane/pkg/models"
 )
 
+const (
+	engineVersionTimeout = 10 * time.Second
+
+	// Disk health rarely changes and checking it can be slow, so it's
+	// checked less often than the rest of the info
+	diskHealthInterval = time.Hour
+)
+
 type Reporter struct {
-	client       *client.Client // TODO: interface
-	agentVersion string
+	client           *client.Client // TODO: interface
+	engine           engine.Engine
+	agentVersion     string
+	lastDisconnect   func() models.ConnectorDisconnect
+	lastPowerAction  func() models.PowerAction
+	lastFailedUpdate func() models.AgentUpdateFailure
+	location         func() *models.Location
+	variableErrors   func() map[string]string
+	localLabels      func() map[string]string
 
-	info models.DeviceInfo
+	info           models.DeviceInfo
+	bootTime       time.Time
+	disks          []models.Disk
+	disksCheckedAt time.Time
+	lock           sync.Mutex
 }
 
-func NewReporter(client *client.Client, agentVersion string) *Reporter {
+func NewReporter(
+	client *client.Client, engine engine.Engine, agentVersion string,
+	lastDisconnect func() models.ConnectorDisconnect, lastPowerAction func() models.PowerAction,
+	lastFailedUpdate func() models.AgentUpdateFailure, location func() *models.Location,
+	variableErrors func() map[string]string, localLabels func() map[string]string,
+) *Reporter {
 	return &Reporter{
-		client:       client,
-		agentVersion: agentVersion,
+		client:           client,
+		engine:           engine,
+		agentVersion:     agentVersion,
+		lastDisconnect:   lastDisconnect,
+		lastPowerAction:  lastPowerAction,
+		lastFailedUpdate: lastFailedUpdate,
+		location:         location,
+		variableErrors:   variableErrors,
+		localLabels:      localLabels,
 	}
 }
 
 func (r *Reporter) Report() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	newInfo := r.readInfo()
-	if newInfo != r.info {
+	if !reflect.DeepEqual(newInfo, r.info) {
 		if err := r.client.SetDeviceInfo(context.TODO(), models.SetDeviceInfoRequest{
 			DeviceInfo: newInfo,
 		}); err != nil {
@@ -37,7 +75,13 @@ func (r *Reporter) Report() error {
 
 func (r *Reporter) readInfo() models.DeviceInfo {
 	info := models.DeviceInfo{
-		AgentVersion: r.agentVersion,
+		AgentVersion:            r.agentVersion,
+		LastConnectorDisconnect: r.lastDisconnect(),
+		LastPowerAction:         r.lastPowerAction(),
+		LastFailedAgentUpdate:   r.lastFailedUpdate(),
+		Location:                r.location(),
+		VariableErrors:          r.variableErrors(),
+		LocalLabels:             r.localLabels(),
 	}
 
 	ipAddress, err := getIPAddress()
@@ -47,6 +91,27 @@ func (r *Reporter) readInfo() models.DeviceInfo {
 		log.WithError(err).Error("failed to get IP address")
 	}
 
+	networkInterfaces, err := getNetworkInterfaces()
+	if err == nil {
+		info.NetworkInterfaces = networkInterfaces
+	} else {
+		log.WithError(err).Error("failed to get network interfaces")
+	}
+
+	defaultRoute, err := getDefaultRoute()
+	if err == nil {
+		info.DefaultRoute = *defaultRoute
+	} else {
+		log.WithError(err).Error("failed to get default route")
+	}
+
+	controllerInterface, err := getControllerInterface(r.client.ControllerAddress(), networkInterfaces)
+	if err == nil {
+		info.ControllerInterface = controllerInterface
+	} else {
+		log.WithError(err).Error("failed to get controller interface")
+	}
+
 	osRelease, err := getOSRelease()
 	if err == nil {
 		info.OSRelease = *osRelease
@@ -54,5 +119,76 @@ func (r *Reporter) readInfo() models.DeviceInfo {
 		log.WithError(err).Error("failed to get OS release")
 	}
 
+	kernelVersion, err := getKernelVersion()
+	if err == nil {
+		info.KernelVersion = kernelVersion
+	} else {
+		log.WithError(err).Error("failed to get kernel version")
+	}
+
+	// The boot time can't change while the agent is running, and reading it
+	// again can give a slightly different time if the clock was adjusted
+	if r.bootTime.IsZero() {
+		bootTime, err := getBootTime()
+		if err == nil {
+			r.bootTime = bootTime
+		} else {
+			log.WithError(err).Error("failed to get boot time")
+		}
+	}
+	info.Boot = getBoot(r.bootTime, info.LastPowerAction)
+	info.Clock = getClock(r.client.ClockSkew())
+
+	hardware, err := getHardware()
+	if err == nil {
+		info.Hardware = *hardware
+	} else {
+		log.WithError(err).Error("failed to get hardware")
+	}
+
+	filesystems, err := getFilesystems()
+	if err == nil {
+		info.Filesystems = filesystems
+	} else {
+		log.WithError(err).Error("failed to get filesystems")
+	}
+
+	thermal, err := getThermal()
+	if err == nil {
+		info.Thermal = *thermal
+	} else {
+		log.WithError(err).Error("failed to get thermal state")
+	}
+
+	modems, err := getModems()
+	if err == nil {
+		info.Modems = modems
+	} else {
+		log.WithError(err).Error("failed to get modems")
+	}
+
+	if time.Since(r.disksCheckedAt) >= diskHealthInterval {
+		disks, err := getDisks()
+		if err == nil {
+			r.disks = disks
+		} else {
+			log.WithError(err).Error("failed to get disks")
+		}
+		r.disksCheckedAt = time.Now()
+	}
+	info.Disks = r.disks
+
+	ctx, cancel := context.WithTimeout(context.Background(), engineVersionTimeout)
+	defer cancel()
+	engineVersion, err := r.engine.Version(ctx)
+	if err == nil {
+		info.Engine = models.EngineInfo{
+			Name:    engineVersion.Name,
+			Version: engineVersion.Version,
+		}
+	} else {
+		log.WithError(err).Error("failed to get engine version")
+	}
+
 	return info
 }
@@ -0,0 +1,81 @@
+package info
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const rtfUp = 0x1
+
+var nativeEndian binary.ByteOrder = binary.LittleEndian
+
+func init() {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 0 {
+		nativeEndian = binary.BigEndian
+	}
+}
+
+func getDefaultRoute() (*models.Route, error) {
+	file, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseDefaultRoute(file)
+}
+
+// parseDefaultRoute finds the default route with the lowest metric in the
+// kernel's IPv4 routing table. Addresses in the table are hex encoded in host
+// byte order.
+func parseDefaultRoute(r io.Reader) (*models.Route, error) {
+	var route models.Route
+	lowestMetric := -1
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[0] == "Iface" {
+			continue
+		}
+		if fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&rtfUp == 0 {
+			continue
+		}
+		metric, err := strconv.Atoi(fields[6])
+		if err != nil {
+			continue
+		}
+		if lowestMetric != -1 && metric >= lowestMetric {
+			continue
+		}
+
+		gateway, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		nativeEndian.PutUint32(ip, uint32(gateway))
+
+		route = models.Route{
+			Interface: fields[0],
+			Gateway:   ip.String(),
+		}
+		lowestMetric = metric
+	}
+
+	return &route, scanner.Err()
+}
@@ -0,0 +1,36 @@
+package info
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaultRoute(t *testing.T) {
+	// The kernel prints addresses in host byte order
+	hex := func(ip string) string {
+		return fmt.Sprintf("%08X", nativeEndian.Uint32(net.ParseIP(ip).To4()))
+	}
+
+	table := strings.Join([]string{
+		"Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT",
+		fmt.Sprintf("wlan0\t00000000\t%s\t0003\t0\t0\t600\t00000000\t0\t0\t0", hex("10.0.0.1")),
+		fmt.Sprintf("eth0\t00000000\t%s\t0003\t0\t0\t100\t00000000\t0\t0\t0", hex("192.168.1.1")),
+		fmt.Sprintf("eth0\t%s\t00000000\t0001\t0\t0\t100\t%s\t0\t0\t0", hex("192.168.1.0"), hex("255.255.255.0")),
+	}, "\n")
+
+	route, err := parseDefaultRoute(strings.NewReader(table))
+	require.NoError(t, err)
+	require.Equal(t, models.Route{
+		Interface: "eth0",
+		Gateway:   "192.168.1.1",
+	}, *route)
+
+	route, err = parseDefaultRoute(strings.NewReader(""))
+	require.NoError(t, err)
+	require.Equal(t, models.Route{}, *route)
+}
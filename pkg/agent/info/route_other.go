@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package info
+
+import (
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func getDefaultRoute() (*models.Route, error) {
+	return &models.Route{}, nil
+}
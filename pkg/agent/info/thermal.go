@@ -0,0 +1,46 @@
+package info
+
+import (
+	"math"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/thermal"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// getThermal gets the device's temperatures and throttling state.
+// Temperatures are rounded to whole degrees so that small fluctuations
+// don't cause the info to be reported again.
+func getThermal() (*models.Thermal, error) {
+	zones, err := thermal.Zones()
+	if err != nil {
+		return nil, err
+	}
+
+	var info models.Thermal
+	for _, zone := range zones {
+		info.Zones = append(info.Zones, models.ThermalZone{
+			Name:        zone.Name,
+			Type:        zone.Type,
+			Temperature: int(math.Round(zone.Temperature)),
+		})
+	}
+
+	coolingThrottled, err := thermal.CoolingThrottled()
+	if err != nil {
+		return nil, err
+	}
+	info.Throttled = coolingThrottled
+
+	throttling, err := thermal.ReadThrottling()
+	if err != nil {
+		return nil, err
+	}
+	if throttling != nil {
+		info.Throttled = info.Throttled || throttling.Throttled || throttling.FrequencyCapped
+		info.ThrottledSinceBoot = throttling.ThrottledOccurred || throttling.FrequencyCappedOccurred
+		info.UnderVoltage = throttling.UnderVoltage
+		info.UnderVoltageSinceBoot = throttling.UnderVoltageOccurred
+	}
+
+	return &info, nil
+}
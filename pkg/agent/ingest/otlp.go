@@ -0,0 +1,219 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const maxOTLPRequestSize = 4 << 20
+
+// OTLP aggregation temporalities
+const (
+	temporalityDelta      = 1
+	temporalityCumulative = 2
+)
+
+// The subset of OTLP's JSON encoding that's needed to ingest gauges, sums
+// and histograms.
+type otlpRequest struct {
+	ResourceMetrics []struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeMetrics []struct {
+			Metrics []otlpMetric `json:"metrics"`
+		} `json:"scopeMetrics"`
+		// Used instead of scopeMetrics by older exporters
+		InstrumentationLibraryMetrics []struct {
+			Metrics []otlpMetric `json:"metrics"`
+		} `json:"instrumentationLibraryMetrics"`
+	} `json:"resourceMetrics"`
+}
+
+type otlpMetric struct {
+	Name  string `json:"name"`
+	Gauge *struct {
+		DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+	Sum *struct {
+		DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+		AggregationTemporality int                   `json:"aggregationTemporality"`
+		IsMonotonic            bool                  `json:"isMonotonic"`
+	} `json:"sum"`
+	Histogram *struct {
+		DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+		AggregationTemporality int                      `json:"aggregationTemporality"`
+	} `json:"histogram"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+	AsDouble   *float64       `json:"asDouble"`
+	AsInt      *otlpInt       `json:"asInt"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+	Count      otlpInt        `json:"count"`
+	Sum        *float64       `json:"sum"`
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string  `json:"stringValue"`
+		IntValue    *otlpInt `json:"intValue"`
+		DoubleValue *float64 `json:"doubleValue"`
+		BoolValue   *bool    `json:"boolValue"`
+	} `json:"value"`
+}
+
+// otlpInt is a 64 bit integer, which OTLP encodes as a JSON string but
+// which some exporters send as a number.
+type otlpInt int64
+
+func (i *otlpInt) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(b, `"`)
+	v, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = otlpInt(v)
+	return nil
+}
+
+func (p otlpNumberDataPoint) value() (float64, bool) {
+	switch {
+	case p.AsDouble != nil:
+		return *p.AsDouble, true
+	case p.AsInt != nil:
+		return float64(*p.AsInt), true
+	default:
+		return 0, false
+	}
+}
+
+// HandleOTLP ingests metrics sent with the OTLP/HTTP protocol. Only the
+// JSON encoding is supported, so exporters need to be configured with the
+// http/json protocol. The resource's service.name attribute is added to
+// every metric as the service_name label.
+func (s *Store) HandleOTLP(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		http.Error(w, "only the http/json OTLP protocol is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	var req otlpRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxOTLPRequestSize)).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, resourceMetrics := range req.ResourceMetrics {
+		var serviceName string
+		for _, attribute := range resourceMetrics.Resource.Attributes {
+			if attribute.Key == "service.name" {
+				serviceName = attribute.stringValue()
+			}
+		}
+
+		var metrics []otlpMetric
+		for _, scopeMetrics := range resourceMetrics.ScopeMetrics {
+			metrics = append(metrics, scopeMetrics.Metrics...)
+		}
+		for _, libraryMetrics := range resourceMetrics.InstrumentationLibraryMetrics {
+			metrics = append(metrics, libraryMetrics.Metrics...)
+		}
+
+		for _, metric := range metrics {
+			s.ingestOTLPMetric(metric, serviceName)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+func (s *Store) ingestOTLPMetric(metric otlpMetric, serviceName string) {
+	switch {
+	case metric.Gauge != nil:
+		for _, dataPoint := range metric.Gauge.DataPoints {
+			if value, ok := dataPoint.value(); ok {
+				s.set(metric.Name, otlpLabels(dataPoint.Attributes, serviceName), dto.MetricType_GAUGE, value)
+			}
+		}
+
+	case metric.Sum != nil:
+		metricType := dto.MetricType_GAUGE
+		if metric.Sum.IsMonotonic {
+			metricType = dto.MetricType_COUNTER
+		}
+
+		for _, dataPoint := range metric.Sum.DataPoints {
+			value, ok := dataPoint.value()
+			if !ok {
+				continue
+			}
+
+			labels := otlpLabels(dataPoint.Attributes, serviceName)
+			switch {
+			case metric.Sum.AggregationTemporality != temporalityDelta:
+				s.set(metric.Name, labels, metricType, value)
+			case metricType == dto.MetricType_COUNTER:
+				s.add(metric.Name, labels, value)
+			default:
+				s.adjust(metric.Name, labels, value)
+			}
+		}
+
+	case metric.Histogram != nil:
+		for _, dataPoint := range metric.Histogram.DataPoints {
+			labels := otlpLabels(dataPoint.Attributes, serviceName)
+			var sum float64
+			if dataPoint.Sum != nil {
+				sum = *dataPoint.Sum
+			}
+
+			if metric.Histogram.AggregationTemporality == temporalityCumulative {
+				s.set(metric.Name+"_count", labels, dto.MetricType_COUNTER, float64(dataPoint.Count))
+				s.set(metric.Name+"_sum", labels, dto.MetricType_COUNTER, sum)
+			} else {
+				s.add(metric.Name+"_count", labels, float64(dataPoint.Count))
+				s.add(metric.Name+"_sum", labels, sum)
+			}
+		}
+	}
+}
+
+func otlpLabels(attributes []otlpKeyValue, serviceName string) map[string]string {
+	labels := make(map[string]string, len(attributes)+1)
+	for _, attribute := range attributes {
+		labels[attribute.Key] = attribute.stringValue()
+	}
+	if serviceName != "" {
+		labels["service_name"] = serviceName
+	}
+	return labels
+}
+
+func (kv otlpKeyValue) stringValue() string {
+	switch {
+	case kv.Value.StringValue != nil:
+		return *kv.Value.StringValue
+	case kv.Value.IntValue != nil:
+		return strconv.FormatInt(int64(*kv.Value.IntValue), 10)
+	case kv.Value.DoubleValue != nil:
+		return strconv.FormatFloat(*kv.Value.DoubleValue, 'g', -1, 64)
+	case kv.Value.BoolValue != nil:
+		return strconv.FormatBool(*kv.Value.BoolValue)
+	default:
+		return ""
+	}
+}
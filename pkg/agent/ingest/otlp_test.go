@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleOTLP(t *testing.T) {
+	s := NewStore()
+
+	post := func(contentType, body string) int {
+		r := httptest.NewRequest("POST", "/v1/metrics", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		s.HandleOTLP(w, r)
+		return w.Code
+	}
+
+	require.Equal(t, 200, post("application/json", `{
+  "resourceMetrics": [{
+    "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "sensor"}}]},
+    "scopeMetrics": [{
+      "metrics": [
+        {"name": "sensor.temperature", "gauge": {"dataPoints": [
+          {"asDouble": 21.5, "attributes": [{"key": "probe", "value": {"intValue": "2"}}]}
+        ]}},
+        {"name": "sensor.readings", "sum": {"aggregationTemporality": 2, "isMonotonic": true, "dataPoints": [
+          {"asInt": "120"}
+        ]}},
+        {"name": "sensor.errors", "sum": {"aggregationTemporality": 1, "isMonotonic": true, "dataPoints": [
+          {"asInt": 2}, {"asInt": "3"}
+        ]}},
+        {"name": "sensor.read.duration", "histogram": {"aggregationTemporality": 2, "dataPoints": [
+          {"count": "4", "sum": 0.8}
+        ]}}
+      ]
+    }]
+  }]
+}`))
+
+	require.Equal(t, `# TYPE sensor_errors counter
+sensor_errors{service_name="sensor"} 5
+# TYPE sensor_read_duration_count counter
+sensor_read_duration_count{service_name="sensor"} 4
+# TYPE sensor_read_duration_sum counter
+sensor_read_duration_sum{service_name="sensor"} 0.8
+# TYPE sensor_readings counter
+sensor_readings{service_name="sensor"} 120
+# TYPE sensor_temperature gauge
+sensor_temperature{probe="2",service_name="sensor"} 21.5
+`, exposition(t, s))
+
+	require.Equal(t, 415, post("application/x-protobuf", ""))
+	require.Equal(t, 400, post("application/json", "{"))
+}
@@ -0,0 +1,95 @@
+package ingest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/apex/log"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const maxStatsDPacketSize = 65535
+
+// ServeStatsD reads StatsD packets from conn until it's closed. Counters
+// become counters, gauges become gauges and timers, histograms and
+// distributions become _count and _sum counters. Sets aren't supported.
+// DogStatsD style tags are turned into labels.
+func (s *Store) ServeStatsD(conn net.PacketConn) error {
+	buf := make([]byte, maxStatsDPacketSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if err := s.handleStatsDLine(line); err != nil {
+				log.WithField("line", line).WithError(err).Debug("invalid statsd line")
+			}
+		}
+	}
+}
+
+var errUnsupportedStatsDType = errors.New("unsupported metric type")
+
+func (s *Store) handleStatsDLine(line string) error {
+	i := strings.Index(line, ":")
+	if i <= 0 {
+		return errors.New("missing value")
+	}
+	name, rest := line[:i], line[i+1:]
+
+	fields := strings.Split(rest, "|")
+	if len(fields) < 2 {
+		return errors.New("missing type")
+	}
+	rawValue, metricType := fields[0], fields[1]
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value '%s'", rawValue)
+	}
+
+	sampleRate := 1.0
+	labels := make(map[string]string)
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "@"):
+			sampleRate, err = strconv.ParseFloat(field[1:], 64)
+			if err != nil || sampleRate <= 0 || sampleRate > 1 {
+				return fmt.Errorf("invalid sample rate '%s'", field[1:])
+			}
+		case strings.HasPrefix(field, "#"):
+			for _, tag := range strings.Split(field[1:], ",") {
+				if k := strings.Index(tag, ":"); k > 0 {
+					labels[tag[:k]] = tag[k+1:]
+				}
+			}
+		}
+	}
+
+	switch metricType {
+	case "c":
+		s.add(name, labels, value/sampleRate)
+	case "g":
+		if strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-") {
+			s.adjust(name, labels, value)
+		} else {
+			s.set(name, labels, dto.MetricType_GAUGE, value)
+		}
+	case "ms", "h", "d":
+		s.add(name+"_count", labels, 1/sampleRate)
+		s.add(name+"_sum", labels, value/sampleRate)
+	default:
+		return errUnsupportedStatsDType
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func exposition(t *testing.T, s *Store) string {
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/metrics/ingested", nil))
+	require.Equal(t, 200, w.Code)
+	return w.Body.String()
+}
+
+func TestStatsD(t *testing.T) {
+	s := NewStore()
+
+	for _, line := range []string{
+		"app.requests:1|c|#route:/login,method:POST",
+		"app.requests:1|c|@0.5|#route:/login,method:POST",
+		"app.queue:10|g",
+		"app.queue:-3|g",
+		"app.latency:20|ms",
+		"app.latency:40|ms",
+	} {
+		require.NoError(t, s.handleStatsDLine(line))
+	}
+	require.Error(t, s.handleStatsDLine("app.requests"))
+	require.Error(t, s.handleStatsDLine("app.requests:1"))
+	require.Error(t, s.handleStatsDLine("app.requests:x|c"))
+	require.Error(t, s.handleStatsDLine("app.requests:1|c|@2"))
+	require.Error(t, s.handleStatsDLine("app.users:42|s"))
+
+	require.Equal(t, `# TYPE app_latency_count counter
+app_latency_count 2
+# TYPE app_latency_sum counter
+app_latency_sum 60
+# TYPE app_queue gauge
+app_queue 7
+# TYPE app_requests counter
+app_requests{method="POST",route="/login"} 3
+`, exposition(t, s))
+
+	// Conflicting types are dropped
+	require.NoError(t, s.handleStatsDLine("app.requests:5|g"))
+	require.Contains(t, exposition(t, s), `app_requests{method="POST",route="/login"} 3`)
+
+	// Series that stop being written to expire
+	s.now = func() time.Time {
+		return time.Now().Add(staleAfter + time.Minute)
+	}
+	require.Equal(t, "", exposition(t, s))
+	require.NoError(t, s.handleStatsDLine("app.requests:5|g"))
+	require.Equal(t, "# TYPE app_requests gauge\napp_requests 5\n", exposition(t, s))
+}
@@ -0,0 +1,237 @@
+package ingest
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// Series that haven't been written to in this long are dropped, so
+	// that containers that stop reporting don't leave stale values behind
+	staleAfter = 10 * time.Minute
+
+	// maxSeries bounds the memory used by applications that put unbounded
+	// values such as IDs into labels
+	maxSeries = 10000
+)
+
+type series struct {
+	name    string
+	labels  map[string]string
+	value   float64
+	updated time.Time
+}
+
+// Store holds the metrics ingested from applications running on the device
+// and serves them in the Prometheus text format, so the controller can pull
+// them over the device connection like any other metrics.
+type Store struct {
+	series map[string]*series
+	types  map[string]dto.MetricType
+	lock   sync.Mutex
+
+	now func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		series: make(map[string]*series),
+		types:  make(map[string]dto.MetricType),
+		now:    time.Now,
+	}
+}
+
+// add increments a counter.
+func (s *Store) add(name string, labels map[string]string, delta float64) {
+	s.update(name, labels, dto.MetricType_COUNTER, func(value float64) float64 {
+		return value + delta
+	})
+}
+
+// set sets a gauge, or a counter whose running total is tracked by the
+// application.
+func (s *Store) set(name string, labels map[string]string, metricType dto.MetricType, value float64) {
+	s.update(name, labels, metricType, func(float64) float64 {
+		return value
+	})
+}
+
+// adjust changes a gauge relative to its current value.
+func (s *Store) adjust(name string, labels map[string]string, delta float64) {
+	s.update(name, labels, dto.MetricType_GAUGE, func(value float64) float64 {
+		return value + delta
+	})
+}
+
+func (s *Store) update(name string, labels map[string]string, metricType dto.MetricType, f func(float64) float64) {
+	name = sanitizeName(name)
+	if name == "" {
+		return
+	}
+	sanitizedLabels := make(map[string]string, len(labels))
+	for labelName, value := range labels {
+		if labelName = sanitizeLabelName(labelName); labelName != "" && value != "" {
+			sanitizedLabels[labelName] = value
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// A name can only have one type in an exposition
+	if existingType, ok := s.types[name]; ok && existingType != metricType {
+		log.WithField("metric", name).Debug("dropping ingested metric with conflicting type")
+		return
+	}
+
+	key := seriesKey(name, sanitizedLabels)
+	ser, ok := s.series[key]
+	if !ok {
+		if len(s.series) >= maxSeries {
+			log.WithField("metric", name).Debug("dropping ingested metric over series limit")
+			return
+		}
+		ser = &series{
+			name:   name,
+			labels: sanitizedLabels,
+		}
+		s.series[key] = ser
+		s.types[name] = metricType
+	}
+
+	ser.value = f(ser.value)
+	ser.updated = s.now()
+}
+
+func (s *Store) gather() []*dto.MetricFamily {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	families := make(map[string]*dto.MetricFamily)
+	for key, ser := range s.series {
+		if now.Sub(ser.updated) > staleAfter {
+			delete(s.series, key)
+			continue
+		}
+
+		family, ok := families[ser.name]
+		if !ok {
+			name, metricType := ser.name, s.types[ser.name]
+			family = &dto.MetricFamily{
+				Name: &name,
+				Type: &metricType,
+			}
+			families[ser.name] = family
+		}
+
+		value := ser.value
+		metric := &dto.Metric{}
+		for _, labelName := range sortedKeys(ser.labels) {
+			labelName, labelValue := labelName, ser.labels[labelName]
+			metric.Label = append(metric.Label, &dto.LabelPair{
+				Name:  &labelName,
+				Value: &labelValue,
+			})
+		}
+		if family.GetType() == dto.MetricType_COUNTER {
+			metric.Counter = &dto.Counter{Value: &value}
+		} else {
+			metric.Gauge = &dto.Gauge{Value: &value}
+		}
+		family.Metric = append(family.Metric, metric)
+	}
+
+	// Forget the types of names that no longer have any series
+	for name := range s.types {
+		if _, ok := families[name]; !ok {
+			delete(s.types, name)
+		}
+	}
+
+	var names []string
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret []*dto.MetricFamily
+	for _, name := range names {
+		family := families[name]
+		sort.Slice(family.Metric, func(i, j int) bool {
+			return labelsString(family.Metric[i]) < labelsString(family.Metric[j])
+		})
+		ret = append(ret, family)
+	}
+	return ret
+}
+
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, family := range s.gather() {
+		if err := encoder.Encode(family); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	w.Write(buf.Bytes())
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	parts := []string{name}
+	for _, labelName := range sortedKeys(labels) {
+		parts = append(parts, labelName, labels[labelName])
+	}
+	return strings.Join(parts, "\xff")
+}
+
+func labelsString(metric *dto.Metric) string {
+	var parts []string
+	for _, label := range metric.Label {
+		parts = append(parts, label.GetName(), label.GetValue())
+	}
+	return strings.Join(parts, "\xff")
+}
+
+// sanitizeName replaces the characters that aren't allowed in Prometheus
+// names, such as the dots commonly used in StatsD and OpenTelemetry names.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// sanitizeLabelName is like sanitizeName, but label names can't contain
+// colons.
+func sanitizeLabelName(name string) string {
+	return strings.Replace(sanitizeName(name), ":", "_", -1)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
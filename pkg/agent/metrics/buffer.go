@@ -0,0 +1,239 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/file"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DefaultBufferSize bounds the disk space used by metrics collected while
+// the device is offline. At the default host metrics interval this holds
+// several hours of collections.
+const DefaultBufferSize = 10 << 20
+
+const bufferEntrySuffix = ".prom"
+
+// Buffer stores metrics collected while the device can't be reached by the
+// controller, so that they can be backfilled with their original timestamps
+// once it reconnects. Each collection is stored in its own file named after
+// the time it was collected, and the oldest collections are dropped when
+// the buffer is over its size limit.
+type Buffer struct {
+	dir     string
+	maxSize int64
+	lock    sync.Mutex
+}
+
+func NewBuffer(dir string, maxSize int64) *Buffer {
+	return &Buffer{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+type bufferEntry struct {
+	timestamp int64
+	size      int64
+}
+
+func (e bufferEntry) name() string {
+	return strconv.FormatInt(e.timestamp, 10) + bufferEntrySuffix
+}
+
+// Add stores metrics in the Prometheus text format that were collected at
+// time t.
+func (b *Buffer) Add(t time.Time, metrics []byte) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0700); err != nil {
+		return err
+	}
+
+	entry := bufferEntry{timestamp: timestampMs(t)}
+	if err := file.WriteFileAtomic(filepath.Join(b.dir, entry.name()), metrics, 0644); err != nil {
+		return err
+	}
+
+	entries, err := b.entries()
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	for _, entry := range entries {
+		size += entry.size
+	}
+	for len(entries) > 0 && size > b.maxSize {
+		if err := os.Remove(filepath.Join(b.dir, entries[0].name())); err != nil {
+			return err
+		}
+		size -= entries[0].size
+		entries = entries[1:]
+	}
+
+	return nil
+}
+
+// entries returns the buffered collections, oldest first.
+func (b *Buffer) entries() ([]bufferEntry, error) {
+	fileInfos, err := ioutil.ReadDir(b.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []bufferEntry
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		// Skips the temporary files of interrupted writes
+		if !strings.HasSuffix(name, bufferEntrySuffix) {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(strings.TrimSuffix(name, bufferEntrySuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, bufferEntry{
+			timestamp: timestamp,
+			size:      fileInfo.Size(),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].timestamp < entries[j].timestamp
+	})
+
+	return entries, nil
+}
+
+// ServeHTTP serves every buffered collection as one exposition in the
+// Prometheus text format, with each sample timestamped with the time it
+// was collected. The BufferedMetricsThroughHeader of the response is the
+// timestamp of the newest collection served, which is passed back with a
+// DELETE request once the metrics have been stored so that they aren't
+// served again.
+func (b *Buffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		b.serve(w)
+	case http.MethodDelete:
+		through, err := strconv.ParseInt(r.URL.Query().Get("through"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid through timestamp", http.StatusBadRequest)
+			return
+		}
+		if err := b.remove(through); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (b *Buffer) serve(w http.ResponseWriter) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries, err := b.entries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var names []string
+	metricFamilies := make(map[string]*dto.MetricFamily)
+	var through int64
+	for _, entry := range entries {
+		contents, err := ioutil.ReadFile(filepath.Join(b.dir, entry.name()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		through = entry.timestamp
+
+		var parser expfmt.TextParser
+		entryMetricFamilies, err := parser.TextToMetricFamilies(bytes.NewReader(contents))
+		if err != nil {
+			// Corrupt entries can't be fixed by retrying, so they're
+			// skipped and removed with the rest
+			continue
+		}
+
+		for name, entryMetricFamily := range entryMetricFamilies {
+			for _, metric := range entryMetricFamily.Metric {
+				if metric.TimestampMs == nil {
+					timestamp := entry.timestamp
+					metric.TimestampMs = &timestamp
+				}
+			}
+
+			metricFamily, ok := metricFamilies[name]
+			if !ok {
+				metricFamilies[name] = entryMetricFamily
+				names = append(names, name)
+				continue
+			}
+			if metricFamily.GetType() != entryMetricFamily.GetType() {
+				continue
+			}
+			metricFamily.Metric = append(metricFamily.Metric, entryMetricFamily.Metric...)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, name := range names {
+		if err := encoder.Encode(metricFamilies[name]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	if len(entries) > 0 {
+		w.Header().Set(models.BufferedMetricsThroughHeader, strconv.FormatInt(through, 10))
+	}
+	w.Write(buf.Bytes())
+}
+
+// remove removes the collections made at or before the through timestamp.
+func (b *Buffer) remove(through int64) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entries, err := b.entries()
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.timestamp > through {
+			break
+		}
+		if err := os.Remove(filepath.Join(b.dir, entry.name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func timestampMs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b := NewBuffer(dir, DefaultBufferSize)
+
+	resp := httptest.NewRecorder()
+	b.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host/buffered", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "", resp.Body.String())
+	require.Equal(t, "", resp.Header().Get(models.BufferedMetricsThroughHeader))
+
+	require.NoError(t, b.Add(time.Unix(1, 0), []byte("# TYPE load1 gauge\nload1 0.5\n")))
+	require.NoError(t, b.Add(time.Unix(2, 0), []byte("# TYPE load1 gauge\nload1 0.75\n")))
+
+	resp = httptest.NewRecorder()
+	b.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host/buffered", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, "# TYPE load1 gauge\nload1 0.5 1000\nload1 0.75 2000\n", resp.Body.String())
+	require.Equal(t, "2000", resp.Header().Get(models.BufferedMetricsThroughHeader))
+
+	// Collections made after the response was served are kept
+	require.NoError(t, b.Add(time.Unix(3, 0), []byte("# TYPE load1 gauge\nload1 1\n")))
+
+	resp = httptest.NewRecorder()
+	b.ServeHTTP(resp, httptest.NewRequest("DELETE", "/metrics/host/buffered?through=2000", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	resp = httptest.NewRecorder()
+	b.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host/buffered", nil))
+	require.Equal(t, "# TYPE load1 gauge\nload1 1 3000\n", resp.Body.String())
+	require.Equal(t, "3000", resp.Header().Get(models.BufferedMetricsThroughHeader))
+}
+
+func TestBufferSizeLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "metrics-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	metrics := []byte("# TYPE load1 gauge\nload1 0.5\n")
+	b := NewBuffer(dir, int64(2*len(metrics)))
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, b.Add(time.Unix(int64(i), 0), metrics))
+	}
+
+	// The oldest collection is dropped
+	resp := httptest.NewRecorder()
+	b.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host/buffered", nil))
+	require.Equal(t, "# TYPE load1 gauge\nload1 0.5 2000\nload1 0.5 3000\n", resp.Body.String())
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/node_exporter/collector"
+)
+
+var defaultCollectors = []string{
+	"cpu",
+	"diskstats",
+	"filesystem",
+	"loadavg",
+	"meminfo",
+	"textfile",
+	"time",
+	"netdev",
+	"thermal_zone",
+	"throttling",
+	"modem",
+}
+
+var collectorCreators = map[string]func() (collector.Collector, error){
+	"cpu":          collector.NewCPUCollector,
+	"diskstats":    collector.NewDiskstatsCollector,
+	"filesystem":   collector.NewFilesystemCollector,
+	"loadavg":      collector.NewLoadavgCollector,
+	"meminfo":      collector.NewMeminfoCollector,
+	"textfile":     collector.NewTextFileCollector,
+	"time":         collector.NewTimeCollector,
+	"runit":        collector.NewRunitCollector,
+	"supervisord":  collector.NewSupervisordCollector,
+	"netdev":       collector.NewNetDevCollector,
+	"ntp":          collector.NewNtpCollector,
+	"thermal_zone": collector.NewThermalZoneCollector,
+	"throttling":   newThrottlingCollector,
+	"modem":        newModemCollector,
+}
@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package metrics
+
+import (
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// Most node_exporter collectors only support Linux, so other platforms only
+// get the portable ones.
+var defaultCollectors = []string{
+	"textfile",
+	"time",
+}
+
+var collectorCreators = map[string]func() (collector.Collector, error){
+	"textfile": collector.NewTextFileCollector,
+	"time":     collector.NewTimeCollector,
+	"ntp":      collector.NewNtpCollector,
+}
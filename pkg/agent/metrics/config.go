@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Config holds the metrics config sent by the controller in the bundle.
+type Config struct {
+	config models.BundledMetricsConfig
+	lock   sync.RWMutex
+}
+
+func NewConfig() *Config {
+	return &Config{}
+}
+
+func (c *Config) Set(config models.BundledMetricsConfig) {
+	c.lock.Lock()
+	c.config = config
+	c.lock.Unlock()
+}
+
+func (c *Config) Get() models.BundledMetricsConfig {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.config
+}
+
+// Filter wraps a handler that serves metrics in the Prometheus text format
+// so that only the allowed metrics are served, with the configured labels
+// added. Responses that can't be parsed are served as is.
+func (c *Config) Filter(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		config := c.Get()
+		if len(config.AllowedMetrics) == 0 && len(config.Labels) == 0 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		bw := &bufferedResponseWriter{
+			header: make(http.Header),
+			status: http.StatusOK,
+		}
+		handler.ServeHTTP(bw, r)
+
+		body := bw.body.Bytes()
+		if bw.status == http.StatusOK {
+			if filtered, err := filter(body, config); err == nil {
+				body = filtered
+				bw.header.Set("Content-Type", string(expfmt.FmtText))
+			}
+		}
+
+		for key, values := range bw.header {
+			if key == "Content-Length" {
+				continue
+			}
+			w.Header()[key] = values
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(bw.status)
+		w.Write(body)
+	})
+}
+
+func filter(body []byte, config models.BundledMetricsConfig) ([]byte, error) {
+	var parser expfmt.TextParser
+	metricFamilies, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	var labelNames []string
+	for name := range config.Labels {
+		labelNames = append(labelNames, name)
+	}
+	sort.Strings(labelNames)
+
+	var names []string
+	for name := range metricFamilies {
+		if allowed(name, config.AllowedMetrics) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, name := range names {
+		metricFamily := metricFamilies[name]
+		for _, metric := range metricFamily.Metric {
+			addLabels(metric, labelNames, config.Labels)
+		}
+		if err := encoder.Encode(metricFamily); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func allowed(name string, allowedMetrics []string) bool {
+	if len(allowedMetrics) == 0 {
+		return true
+	}
+	for _, allowedMetric := range allowedMetrics {
+		if strings.HasSuffix(allowedMetric, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(allowedMetric, "*")) {
+				return true
+			}
+		} else if name == allowedMetric {
+			return true
+		}
+	}
+	return false
+}
+
+// addLabels adds labels that the metric doesn't already have. Label names
+// come from device labels, so characters that Prometheus doesn't allow are
+// replaced.
+func addLabels(metric *dto.Metric, names []string, labels map[string]string) {
+	existing := make(map[string]struct{})
+	for _, label := range metric.Label {
+		existing[label.GetName()] = struct{}{}
+	}
+	for _, name := range names {
+		labelName, value := labelName(name), labels[name]
+		if _, ok := existing[labelName]; ok || labelName == "" || value == "" {
+			continue
+		}
+		existing[labelName] = struct{}{}
+		metric.Label = append(metric.Label, &dto.LabelPair{
+			Name:  &labelName,
+			Value: &value,
+		})
+	}
+	sort.Slice(metric.Label, func(i, j int) bool {
+		return metric.Label[i].GetName() < metric.Label[j].GetName()
+	})
+}
+
+func labelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
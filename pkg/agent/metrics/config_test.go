@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFilter(t *testing.T) {
+	status := http.StatusOK
+	body := `# TYPE node_load1 gauge
+node_load1 0.5
+# TYPE node_memory_MemFree_bytes gauge
+node_memory_MemFree_bytes{zone="normal"} 1024
+# TYPE node_cpu_seconds_total counter
+node_cpu_seconds_total{cpu="0",mode="idle"} 100
+`
+	config := NewConfig()
+	handler := config.Filter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics/host", nil))
+		return w
+	}
+
+	// Nothing is changed without a config
+	require.Equal(t, body, get().Body.String())
+
+	config.Set(models.BundledMetricsConfig{
+		AllowedMetrics: []string{"node_load1", "node_memory_*"},
+		Labels: map[string]string{
+			"zone":     "ignored",
+			"site.id":  "berlin",
+			"project":  "fleet",
+			"optional": "",
+		},
+	})
+	require.Equal(t, `# TYPE node_load1 gauge
+node_load1{project="fleet",site_id="berlin",zone="ignored"} 0.5
+# TYPE node_memory_MemFree_bytes gauge
+node_memory_MemFree_bytes{project="fleet",site_id="berlin",zone="normal"} 1024
+`, get().Body.String())
+
+	// Errors aren't filtered
+	status = http.StatusInternalServerError
+	body = "host metrics are not working"
+	w := get()
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.Equal(t, body, w.Body.String())
+}
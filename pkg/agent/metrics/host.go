@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/metrics/datadog/filtering"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+const DefaultHostMetricsInterval = 30 * time.Second
+
+// HostMetrics collects host metrics on the interval set by the
+// host-metrics-interval variable and serves the latest collection. This
+// keeps the cost of collection on the device independent of how often the
+// controller or users scrape it. The collectors used can be changed with the
+// host-metrics-collectors variable. The project's metrics config is used
+// when the variables aren't set. Collections made while the device isn't
+// connected to the controller are also added to the buffer.
+type HostMetrics struct {
+	variables variables.Interface
+	config    *Config
+	buffer    *Buffer
+	connected func() bool
+
+	collectors []string
+	registry   *prometheus.Registry
+
+	metrics     []byte
+	collectErr  error
+	collectedAt time.Time
+	lock        sync.RWMutex
+}
+
+func NewHostMetrics(variables variables.Interface, config *Config, buffer *Buffer, connected func() bool) *HostMetrics {
+	return &HostMetrics{
+		variables: variables,
+		config:    config,
+		buffer:    buffer,
+		connected: connected,
+	}
+}
+
+func (h *HostMetrics) Run() {
+	for {
+		h.collect()
+
+		interval := h.variables.GetHostMetricsInterval()
+		if interval <= 0 {
+			interval = time.Duration(h.config.Get().HostIntervalSeconds) * time.Second
+		}
+		if interval <= 0 {
+			interval = DefaultHostMetricsInterval
+		}
+
+		select {
+		case <-time.After(interval):
+			continue
+		}
+	}
+}
+
+func (h *HostMetrics) collect() {
+	metrics, err := h.gather()
+	collectedAt := time.Now()
+
+	if metrics != nil && !h.connected() {
+		if err := h.buffer.Add(collectedAt, metrics); err != nil {
+			log.WithError(err).Error("buffer host metrics")
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err != nil {
+		log.WithError(err).Error("collect host metrics")
+	}
+	// Collectors that fail are skipped, so keep whatever was collected
+	if metrics != nil {
+		h.metrics = metrics
+		h.collectedAt = collectedAt
+	}
+	h.collectErr = err
+}
+
+func (h *HostMetrics) gather() ([]byte, error) {
+	collectors := h.variables.GetHostMetricsCollectors()
+	if len(collectors) == 0 {
+		collectors = h.config.Get().HostCollectors
+	}
+	if len(collectors) == 0 {
+		collectors = defaultCollectors
+	}
+
+	if h.registry == nil || !reflect.DeepEqual(collectors, h.collectors) {
+		config := DefaultNodeCollectorConfig
+		config.Collectors = collectors
+
+		nodeCollector, err := NewNodeCollector(&config)
+		if err != nil {
+			return nil, err
+		}
+
+		registry := prometheus.NewRegistry()
+		if err := registry.Register(nodeCollector); err != nil {
+			return nil, err
+		}
+
+		h.registry = registry
+		h.collectors = collectors
+	}
+
+	metricFamilies, gatherErr := h.registry.Gather()
+	if len(metricFamilies) == 0 {
+		return nil, gatherErr
+	}
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, metricFamily := range metricFamilies {
+		if err := encoder.Encode(metricFamily); err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(filtering.FilterNodePrefix(buf.String())), gatherErr
+}
+
+func (h *HostMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	if h.metrics == nil {
+		if h.collectErr != nil {
+			http.Error(w, "host metrics are not working, check agent logs for details", http.StatusInternalServerError)
+			return
+		}
+		http.Error(w, "host metrics haven't been collected yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	w.Header().Set("Last-Modified", h.collectedAt.UTC().Format(http.TimeFormat))
+	w.Write(h.metrics)
+}
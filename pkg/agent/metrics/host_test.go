@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/stretchr/testify/require"
+)
+
+type testVariables struct {
+	variables.Interface
+	hostMetricsCollectors []string
+}
+
+func (v *testVariables) GetHostMetricsInterval() time.Duration {
+	return 0
+}
+
+func (v *testVariables) GetHostMetricsCollectors() []string {
+	return v.hostMetricsCollectors
+}
+
+func TestHostMetrics(t *testing.T) {
+	v := &testVariables{
+		hostMetricsCollectors: []string{"time"},
+	}
+	h := NewHostMetrics(v, NewConfig(), nil, func() bool {
+		return true
+	})
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host", nil))
+	require.Equal(t, http.StatusServiceUnavailable, resp.Code)
+
+	h.collect()
+
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.True(t, strings.Contains(resp.Body.String(), "time_seconds"))
+	require.False(t, strings.Contains(resp.Body.String(), "node_time_seconds"))
+
+	v.hostMetricsCollectors = []string{"nonexistent"}
+	h.collect()
+
+	// The last successful collection is still served
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics/host", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.Equal(t, ErrInvalidCollector, h.collectErr)
+}
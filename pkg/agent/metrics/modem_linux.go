@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/modem"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// modemCollector exposes the signal and data usage of cellular modems
+// managed by ModemManager.
+type modemCollector struct {
+	info          *prometheus.Desc
+	signalQuality *prometheus.Desc
+	rssi          *prometheus.Desc
+	rsrp          *prometheus.Desc
+	rsrq          *prometheus.Desc
+	snr           *prometheus.Desc
+	receivedBytes *prometheus.Desc
+	sentBytes     *prometheus.Desc
+}
+
+func newModemCollector() (collector.Collector, error) {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName("node", "modem", name),
+			help,
+			append([]string{"modem"}, labels...), nil,
+		)
+	}
+	return &modemCollector{
+		info:          desc("info", "Modem information", "manufacturer", "model", "state", "access_technology", "operator", "apn"),
+		signalQuality: desc("signal_quality_percent", "Signal quality as a percentage"),
+		rssi:          desc("rssi_dbm", "Received signal strength indicator in dBm"),
+		rsrp:          desc("rsrp_dbm", "Reference signal received power in dBm"),
+		rsrq:          desc("rsrq_db", "Reference signal received quality in dB"),
+		snr:           desc("snr_db", "Signal to noise ratio in dB"),
+		receivedBytes: desc("received_bytes_total", "Bytes received by the modem's connected bearer"),
+		sentBytes:     desc("sent_bytes_total", "Bytes sent by the modem's connected bearer"),
+	}, nil
+}
+
+func (c *modemCollector) Update(ch chan<- prometheus.Metric) error {
+	modems, err := modem.Modems(context.TODO())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range modems {
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1,
+			m.Index, m.Manufacturer, m.Model, m.State, m.AccessTechnology, m.Operator, m.APN)
+		ch <- prometheus.MustNewConstMetric(c.signalQuality, prometheus.GaugeValue, float64(m.SignalQuality), m.Index)
+		ch <- prometheus.MustNewConstMetric(c.receivedBytes, prometheus.CounterValue, float64(m.BytesReceived), m.Index)
+		ch <- prometheus.MustNewConstMetric(c.sentBytes, prometheus.CounterValue, float64(m.BytesSent), m.Index)
+
+		for desc, value := range map[*prometheus.Desc]*float64{
+			c.rssi: m.RSSI,
+			c.rsrp: m.RSRP,
+			c.rsrq: m.RSRQ,
+			c.snr:  m.SNR,
+		} {
+			if value != nil {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *value, m.Index)
+			}
+		}
+	}
+
+	return nil
+}
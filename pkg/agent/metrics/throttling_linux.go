@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"github.com/deviceplane/deviceplane/pkg/agent/thermal"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// throttlingCollector exposes the Raspberry Pi firmware's under-voltage and
+// throttling state. It doesn't expose anything on other devices.
+type throttlingCollector struct {
+	active   *prometheus.Desc
+	occurred *prometheus.Desc
+}
+
+func newThrottlingCollector() (collector.Collector, error) {
+	return &throttlingCollector{
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName("node", "throttling", "active"),
+			"Whether the throttling condition is currently active",
+			[]string{"condition"}, nil,
+		),
+		occurred: prometheus.NewDesc(
+			prometheus.BuildFQName("node", "throttling", "occurred"),
+			"Whether the throttling condition has occurred since boot",
+			[]string{"condition"}, nil,
+		),
+	}, nil
+}
+
+func (c *throttlingCollector) Update(ch chan<- prometheus.Metric) error {
+	throttling, err := thermal.ReadThrottling()
+	if err != nil {
+		return err
+	}
+	if throttling == nil {
+		return nil
+	}
+
+	for _, condition := range []struct {
+		name     string
+		active   bool
+		occurred bool
+	}{
+		{"under_voltage", throttling.UnderVoltage, throttling.UnderVoltageOccurred},
+		{"frequency_capped", throttling.FrequencyCapped, throttling.FrequencyCappedOccurred},
+		{"throttled", throttling.Throttled, throttling.ThrottledOccurred},
+		{"soft_temperature_limit", throttling.SoftTemperatureLimit, throttling.SoftTemperatureLimitOccurred},
+	} {
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, boolToFloat(condition.active), condition.name)
+		ch <- prometheus.MustNewConstMetric(c.occurred, prometheus.GaugeValue, boolToFloat(condition.occurred), condition.name)
+	}
+
+	return nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
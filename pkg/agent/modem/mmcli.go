@@ -0,0 +1,72 @@
+package modem
+
+// mmcli's JSON output reports every value as a string.
+
+type modemList struct {
+	ModemList []string `json:"modem-list"`
+}
+
+type modemInfo struct {
+	Modem struct {
+		Generic struct {
+			Manufacturer       string   `json:"manufacturer"`
+			Model              string   `json:"model"`
+			State              string   `json:"state"`
+			AccessTechnologies []string `json:"access-technologies"`
+			SignalQuality      struct {
+				Value string `json:"value"`
+			} `json:"signal-quality"`
+			Bearers []string `json:"bearers"`
+		} `json:"generic"`
+		ThreeGPP struct {
+			OperatorName string `json:"operator-name"`
+		} `json:"3gpp"`
+	} `json:"modem"`
+}
+
+type modemSignal struct {
+	Modem struct {
+		Signal struct {
+			Refresh struct {
+				Rate string `json:"rate"`
+			} `json:"refresh"`
+			LTE   signalValues `json:"lte"`
+			FiveG signalValues `json:"5g"`
+			UMTS  signalValues `json:"umts"`
+			GSM   signalValues `json:"gsm"`
+		} `json:"signal"`
+	} `json:"modem"`
+}
+
+type signalValues struct {
+	RSSI string `json:"rssi"`
+	RSRP string `json:"rsrp"`
+	RSRQ string `json:"rsrq"`
+	SNR  string `json:"snr"`
+}
+
+type bearerInfo struct {
+	Bearer struct {
+		Properties struct {
+			APN string `json:"apn"`
+		} `json:"properties"`
+		Stats struct {
+			BytesRx string `json:"bytes-rx"`
+			BytesTx string `json:"bytes-tx"`
+		} `json:"stats"`
+		Status struct {
+			Connected string `json:"connected"`
+		} `json:"status"`
+	} `json:"bearer"`
+}
+
+type modemLocation struct {
+	Modem struct {
+		Location struct {
+			GPS struct {
+				Latitude  string `json:"latitude"`
+				Longitude string `json:"longitude"`
+			} `json:"gps"`
+		} `json:"location"`
+	} `json:"modem"`
+}
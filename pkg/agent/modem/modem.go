@@ -0,0 +1,179 @@
+package modem
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	mmcliTimeout = 10 * time.Second
+
+	// How often ModemManager is asked to refresh extended signal
+	// information for modems that haven't been set up to report it
+	signalRefreshRate = 60
+)
+
+// Modem is the state of a cellular modem managed by ModemManager. Signal
+// values are nil if the modem doesn't report them for its current access
+// technology.
+type Modem struct {
+	Index            string
+	Manufacturer     string
+	Model            string
+	State            string
+	AccessTechnology string
+	Operator         string
+	SignalQuality    int
+
+	RSSI *float64
+	RSRP *float64
+	RSRQ *float64
+	SNR  *float64
+
+	APN           string
+	BytesReceived uint64
+	BytesSent     uint64
+}
+
+// Modems gets the state of every modem known to ModemManager. It returns
+// nothing if ModemManager isn't installed.
+func Modems(ctx context.Context) ([]Modem, error) {
+	if _, err := exec.LookPath("mmcli"); err != nil {
+		return nil, nil
+	}
+
+	var list modemList
+	if err := mmcli(ctx, &list, "-L"); err != nil {
+		return nil, err
+	}
+
+	var modems []Modem
+	for _, modemPath := range list.ModemList {
+		modem, err := getModem(ctx, path.Base(modemPath))
+		if err != nil {
+			return nil, err
+		}
+		modems = append(modems, *modem)
+	}
+	return modems, nil
+}
+
+func getModem(ctx context.Context, index string) (*Modem, error) {
+	var info modemInfo
+	if err := mmcli(ctx, &info, "-m", index); err != nil {
+		return nil, err
+	}
+
+	modem := Modem{
+		Index:            index,
+		Manufacturer:     info.Modem.Generic.Manufacturer,
+		Model:            info.Modem.Generic.Model,
+		State:            info.Modem.Generic.State,
+		AccessTechnology: strings.Join(info.Modem.Generic.AccessTechnologies, ","),
+		Operator:         info.Modem.ThreeGPP.OperatorName,
+	}
+	modem.SignalQuality, _ = strconv.Atoi(info.Modem.Generic.SignalQuality.Value)
+
+	var signal modemSignal
+	if err := mmcli(ctx, &signal, "-m", index, "--signal-get"); err != nil {
+		return nil, err
+	}
+	if signal.Modem.Signal.Refresh.Rate == "0" {
+		// Extended signal information isn't gathered until it's set up, so
+		// it'll be there the next time the modem is checked
+		_ = mmcli(ctx, nil, "-m", index, "--signal-setup="+strconv.Itoa(signalRefreshRate))
+	}
+	for _, values := range []signalValues{
+		signal.Modem.Signal.LTE,
+		signal.Modem.Signal.FiveG,
+		signal.Modem.Signal.UMTS,
+		signal.Modem.Signal.GSM,
+	} {
+		if rssi := parseFloat(values.RSSI); rssi != nil {
+			modem.RSSI = rssi
+			modem.RSRP = parseFloat(values.RSRP)
+			modem.RSRQ = parseFloat(values.RSRQ)
+			modem.SNR = parseFloat(values.SNR)
+			break
+		}
+	}
+
+	for _, bearerPath := range info.Modem.Generic.Bearers {
+		var bearer bearerInfo
+		if err := mmcli(ctx, &bearer, "-b", path.Base(bearerPath)); err != nil {
+			return nil, err
+		}
+		if bearer.Bearer.Status.Connected != "yes" {
+			continue
+		}
+		modem.APN = bearer.Bearer.Properties.APN
+		modem.BytesReceived, _ = strconv.ParseUint(bearer.Bearer.Stats.BytesRx, 10, 64)
+		modem.BytesSent, _ = strconv.ParseUint(bearer.Bearer.Stats.BytesTx, 10, 64)
+		break
+	}
+
+	return &modem, nil
+}
+
+func mmcli(ctx context.Context, v interface{}, args ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, mmcliTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "mmcli", append([]string{"-J"}, args...)...).Output()
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(output, v)
+}
+
+// parseFloat parses a value. mmcli reports "--" for values it doesn't
+// have.
+func parseFloat(value string) *float64 {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// GPSLocation gets the latitude and longitude from the GPS receiver of the
+// first modem that has a fix. Modems only report their location once it's
+// been enabled, so it's enabled for modems that don't have one yet.
+func GPSLocation(ctx context.Context) (float64, float64, bool, error) {
+	if _, err := exec.LookPath("mmcli"); err != nil {
+		return 0, 0, false, nil
+	}
+
+	var list modemList
+	if err := mmcli(ctx, &list, "-L"); err != nil {
+		return 0, 0, false, err
+	}
+
+	for _, modemPath := range list.ModemList {
+		index := path.Base(modemPath)
+
+		var location modemLocation
+		if err := mmcli(ctx, &location, "-m", index, "--location-get"); err != nil {
+			return 0, 0, false, err
+		}
+
+		latitude := parseFloat(location.Modem.Location.GPS.Latitude)
+		longitude := parseFloat(location.Modem.Location.GPS.Longitude)
+		if latitude == nil || longitude == nil {
+			_ = mmcli(ctx, nil, "-m", index, "--location-enable-gps-raw")
+			continue
+		}
+
+		return *latitude, *longitude, true, nil
+	}
+
+	return 0, 0, false, nil
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+// +build !windows
+
+package modem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const fakeMmcli = `#!/bin/sh
+case "$*" in
+"-J -L")
+	echo '{"modem-list":["/org/freedesktop/ModemManager1/Modem/0"]}' ;;
+"-J -m 0")
+	echo '{"modem":{"generic":{"manufacturer":"Quectel","model":"EC25","state":"connected","access-technologies":["lte"],"signal-quality":{"value":"67","recent":"yes"},"bearers":["/org/freedesktop/ModemManager1/Bearer/1"]},"3gpp":{"operator-name":"Carrier"}}}' ;;
+"-J -m 0 --signal-get")
+	echo '{"modem":{"signal":{"refresh":{"rate":"60"},"lte":{"rssi":"-65.00","rsrp":"-95.00","rsrq":"-10.00","snr":"12.40"},"umts":{"rssi":"--"}}}}' ;;
+"-J -b 1")
+	echo '{"bearer":{"properties":{"apn":"internet"},"stats":{"bytes-rx":"1024","bytes-tx":"512"},"status":{"connected":"yes"}}}' ;;
+*)
+	exit 1 ;;
+esac
+`
+
+func TestModems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modem")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "mmcli"), []byte(fakeMmcli), 0755))
+
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", dir)
+
+	modems, err := Modems(context.Background())
+	require.NoError(t, err)
+
+	float := func(f float64) *float64 {
+		return &f
+	}
+	require.Equal(t, []Modem{
+		{
+			Index:            "0",
+			Manufacturer:     "Quectel",
+			Model:            "EC25",
+			State:            "connected",
+			AccessTechnology: "lte",
+			Operator:         "Carrier",
+			SignalQuality:    67,
+			RSSI:             float(-65),
+			RSRP:             float(-95),
+			RSRQ:             float(-10),
+			SNR:              float(12.4),
+			APN:              "internet",
+			BytesReceived:    1024,
+			BytesSent:        512,
+		},
+	}, modems)
+}
@@ -3,29 +3,34 @@ package netns
 import (
 	"bufio"
 	"context"
-	"fmt"
+	"errors"
 	"net"
 	"net/http"
 	"runtime"
+	"strconv"
 	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/engine"
-	"github.com/vishvananda/netns"
 )
 
 const (
 	timeout = time.Second
 )
 
+var (
+	errNoContainerAddress = errors.New("container has no IP address")
+)
+
 type request struct {
 	ctx         context.Context
 	containerID string
 	port        int
-	path        string
+	do          func(ctx context.Context, address string) response
 }
 
 type response struct {
 	response *http.Response
+	conn     net.Conn
 	err      error
 }
 
@@ -64,38 +69,50 @@ func (m *Manager) ProcessRequest(
 		ctx:         ctx,
 		containerID: containerID,
 		port:        port,
-		path:        path,
+		do: func(ctx context.Context, address string) response {
+			return get(ctx, address, path)
+		},
 	}
 	resp := <-m.out
 	return resp.response, resp.err
 }
 
-func (m *Manager) processRequest(ctx context.Context, req request) response {
-	inspectResponse, err := m.engine.InspectContainer(ctx, req.containerID)
-	if err != nil {
-		return response{
-			err: err,
-		}
+// Dial connects to a port in a container's network. The connection stays
+// usable after the manager moves on to other containers.
+func (m *Manager) Dial(ctx context.Context, containerID string, port int) (net.Conn, error) {
+	m.in <- request{
+		ctx:         ctx,
+		containerID: containerID,
+		port:        port,
+		do:          dial,
 	}
+	resp := <-m.out
+	return resp.conn, resp.err
+}
 
-	containerNamespace, err := netns.GetFromPid(inspectResponse.PID)
-	if err != nil {
+// doAtAddress makes a request to a container on the address it was
+// assigned, for when its network namespace can't be entered.
+func doAtAddress(ctx context.Context, req request, inspectResponse *engine.InspectResponse) response {
+	if inspectResponse.IPAddress == "" {
 		return response{
-			err: err,
+			err: errNoContainerAddress,
 		}
 	}
-	defer containerNamespace.Close()
+	return req.do(ctx, net.JoinHostPort(inspectResponse.IPAddress, strconv.Itoa(req.port)))
+}
 
-	if err := netns.Set(containerNamespace); err != nil {
-		return response{
-			err: err,
-		}
+func dial(ctx context.Context, address string) response {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	return response{
+		conn: conn,
+		err:  err,
 	}
+}
 
+func get(ctx context.Context, address, path string) response {
 	var dialer net.Dialer
-	conn, err := dialer.DialContext(
-		ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", req.port),
-	)
+	conn, err := dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
 		return response{
 			err: err,
@@ -103,7 +120,7 @@ func (m *Manager) processRequest(ctx context.Context, req request) response {
 	}
 
 	httpRequest, err := http.NewRequestWithContext(
-		ctx, "GET", string(req.path), nil,
+		ctx, "GET", path, nil,
 	)
 	if err != nil {
 		return response{
@@ -0,0 +1,39 @@
+package netns
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vishvananda/netns"
+)
+
+func (m *Manager) processRequest(ctx context.Context, req request) response {
+	inspectResponse, err := m.engine.InspectContainer(ctx, req.containerID)
+	if err != nil {
+		return response{
+			err: err,
+		}
+	}
+
+	// Without a PID there's no namespace to enter, so the container is
+	// reached on its address instead
+	if inspectResponse.PID == 0 {
+		return doAtAddress(ctx, req, inspectResponse)
+	}
+
+	containerNamespace, err := netns.GetFromPid(inspectResponse.PID)
+	if err != nil {
+		return response{
+			err: err,
+		}
+	}
+	defer containerNamespace.Close()
+
+	if err := netns.Set(containerNamespace); err != nil {
+		return response{
+			err: err,
+		}
+	}
+
+	return req.do(ctx, fmt.Sprintf("127.0.0.1:%d", req.port))
+}
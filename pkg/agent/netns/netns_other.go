@@ -0,0 +1,21 @@
+//go:build !linux
+// +build !linux
+
+package netns
+
+import (
+	"context"
+)
+
+// Network namespaces are Linux specific. Elsewhere, containers are reached
+// through the address they were assigned on the container network instead.
+func (m *Manager) processRequest(ctx context.Context, req request) response {
+	inspectResponse, err := m.engine.InspectContainer(ctx, req.containerID)
+	if err != nil {
+		return response{
+			err: err,
+		}
+	}
+
+	return doAtAddress(ctx, req, inspectResponse)
+}
@@ -0,0 +1,42 @@
+package remote
+
+import (
+	"math/rand"
+	"time"
+)
+
+// backoff doubles the delay between reconnects up to max. Delays are
+// jittered so that devices disconnected at the same time don't all
+// reconnect at the same time.
+type backoff struct {
+	initial time.Duration
+	max     time.Duration
+	current time.Duration
+	random  *rand.Rand
+}
+
+func newBackoff(initial, max time.Duration) *backoff {
+	return &backoff{
+		initial: initial,
+		max:     max,
+		current: initial,
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// next returns a delay between half and all of the current delay, then
+// doubles the current delay.
+func (b *backoff) next() time.Duration {
+	delay := b.current/2 + time.Duration(b.random.Int63n(int64(b.current/2)+1))
+
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+
+	return delay
+}
+
+func (b *backoff) reset() {
+	b.current = b.initial
+}
@@ -0,0 +1,28 @@
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoff(t *testing.T) {
+	b := newBackoff(time.Second, 10*time.Second)
+
+	for _, current := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	} {
+		delay := b.next()
+		require.True(t, delay >= current/2, "%s < %s", delay, current/2)
+		require.True(t, delay <= current, "%s > %s", delay, current)
+	}
+
+	b.reset()
+	require.True(t, b.next() <= time.Second)
+}
@@ -2,18 +2,43 @@ package remote
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/client"
 	"github.com/deviceplane/deviceplane/pkg/agent/server/conncontext"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/revdial"
 	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
 	"github.com/pkg/errors"
 )
 
+const (
+	initialReconnectDelay = time.Second
+	maxReconnectDelay     = 5 * time.Minute
+
+	// Connections that stay up this long are considered stable, so the
+	// reconnect after they drop starts again from the initial delay
+	stableConnectionDuration = time.Minute
+)
+
+var (
+	errConnectionClosed = errors.New("connection closed")
+)
+
 type Server struct {
 	client     *client.Client
 	httpServer *http.Server
+
+	connected int32
+
+	lastDisconnect     models.ConnectorDisconnect
+	lastDisconnectLock sync.Mutex
 }
 
 func NewServer(client *client.Client, service http.Handler) *Server {
@@ -26,15 +51,68 @@ func NewServer(client *client.Client, service http.Handler) *Server {
 	}
 }
 
+// Run keeps the connection to the controller open, reconnecting with
+// exponential backoff whenever it drops.
+func (s *Server) Run() {
+	backoff := newBackoff(initialReconnectDelay, maxReconnectDelay)
+
+	for {
+		connectedAt := time.Now()
+		err := s.Serve()
+		if err == nil {
+			err = errConnectionClosed
+		}
+		log.WithError(err).Error("serve remote device API")
+
+		s.lastDisconnectLock.Lock()
+		s.lastDisconnect = models.ConnectorDisconnect{
+			Reason: err.Error(),
+			Time:   time.Now().UTC().Truncate(time.Second),
+		}
+		s.lastDisconnectLock.Unlock()
+
+		if time.Since(connectedAt) >= stableConnectionDuration {
+			backoff.reset()
+		}
+		time.Sleep(backoff.next())
+	}
+}
+
+// LastDisconnect returns the reason and time of the last time the
+// connection to the controller dropped.
+func (s *Server) LastDisconnect() models.ConnectorDisconnect {
+	s.lastDisconnectLock.Lock()
+	defer s.lastDisconnectLock.Unlock()
+	return s.lastDisconnect
+}
+
+// Connected returns whether the connection to the controller is currently
+// open.
+func (s *Server) Connected() bool {
+	return atomic.LoadInt32(&s.connected) == 1
+}
+
 func (s *Server) Serve() error {
-	conn, err := s.client.InitiateDeviceConnection(context.TODO())
+	conn, multiplexed, err := s.client.InitiateDeviceConnection(context.TODO())
 	if err != nil {
 		return errors.Wrap(err, "initiate connection")
 	}
 
-	listener := revdial.NewListener(conn, func(ctx context.Context, path string) (*websocket.Conn, *http.Response, error) {
-		return s.client.Revdial(ctx, path)
-	})
+	atomic.StoreInt32(&s.connected, 1)
+	defer atomic.StoreInt32(&s.connected, 0)
+
+	var listener net.Listener
+	if multiplexed {
+		listener, err = yamux.Server(conn, nil)
+		if err != nil {
+			conn.Close()
+			return errors.Wrap(err, "create session")
+		}
+	} else {
+		listener = revdial.NewListener(conn, func(ctx context.Context, path string) (*websocket.Conn, *http.Response, error) {
+			return s.client.Revdial(ctx, path)
+		})
+	}
 	defer listener.Close()
 
 	return s.httpServer.Serve(listener)
@@ -2,13 +2,21 @@ package client
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
 )
 
 func GetAgentMetrics(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
@@ -47,6 +55,60 @@ func GetDeviceMetrics(ctx context.Context, deviceConn net.Conn) (*http.Response,
 	return http.ReadResponse(bufio.NewReader(deviceConn), req)
 }
 
+func GetBufferedDeviceMetrics(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		"/metrics/host/buffered",
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func DeleteBufferedDeviceMetrics(ctx context.Context, deviceConn net.Conn, through string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"DELETE",
+		"/metrics/host/buffered?"+url.Values{"through": []string{through}}.Encode(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func GetIngestedMetrics(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		"/metrics/ingested",
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
 func GetServiceMetrics(ctx context.Context, deviceConn net.Conn, applicationID, service string, metricPath string, metricPort uint) (*http.Response, error) {
 	serviceURL := url.URL{
 		Path: fmt.Sprintf(
@@ -98,19 +160,209 @@ func GetImagePullProgress(ctx context.Context, deviceConn net.Conn, applicationI
 	return http.ReadResponse(bufio.NewReader(deviceConn), req)
 }
 
-func InitiateSSH(ctx context.Context, deviceConn net.Conn) error {
-	req, err := http.NewRequestWithContext(ctx, "POST", "/ssh", nil)
+func GetServiceStats(ctx context.Context, deviceConn net.Conn, applicationID, service string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf(
+			"/applications/%s/services/%s/stats",
+			applicationID, service,
+		),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+// GetServiceLogs gets a service's output as newline delimited JSON chunks,
+// starting from its last tail lines, or all of them if tail is zero.
+func GetServiceLogs(ctx context.Context, deviceConn net.Conn, applicationID, service string, follow bool, tail int) (*http.Response, error) {
+	query := url.Values{}
+	if follow {
+		query.Set("follow", "true")
+	}
+	if tail > 0 {
+		query.Set("tail", strconv.Itoa(tail))
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fmt.Sprintf(
+			"/applications/%s/services/%s/logs?%s",
+			applicationID, service, query.Encode(),
+		),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+// InitiateSSH starts an SSH session. If sessionRecordingID is set the device
+// records the session and uploads it under that ID.
+func InitiateSSH(ctx context.Context, deviceConn net.Conn, sessionRecordingID string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", withSessionRecording("/ssh", sessionRecordingID), nil)
+	if err != nil {
+		return err
+	}
+	return req.Write(deviceConn)
+}
+
+func InitiateExec(ctx context.Context, deviceConn net.Conn, applicationID, service, sessionRecordingID string) error {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		withSessionRecording(fmt.Sprintf(
+			"/applications/%s/services/%s/exec",
+			applicationID, service,
+		), sessionRecordingID),
+		nil,
+	)
 	if err != nil {
 		return err
 	}
 	return req.Write(deviceConn)
 }
 
-func InitiateReboot(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
+// InitiatePortForward asks the device to connect to port, inside the
+// service's container if applicationID is set, and returns a connection
+// proxied to it once it has.
+func InitiatePortForward(ctx context.Context, deviceConn net.Conn, applicationID, service string, port int) (net.Conn, error) {
+	portForwardURL := url.URL{
+		Path: "/portforward",
+	}
+	if applicationID != "" {
+		portForwardURL.Path = fmt.Sprintf(
+			"/applications/%s/services/%s/portforward",
+			applicationID, service,
+		)
+	}
+
+	query := portForwardURL.Query()
+	query.Set("port", strconv.Itoa(port))
+	portForwardURL.RawQuery = query.Encode()
+
 	req, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		"/reboot",
+		portForwardURL.RequestURI(),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(deviceConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.New(strings.TrimSpace(string(message)))
+	}
+
+	return &bufferedConn{
+		Conn:   deviceConn,
+		reader: reader,
+	}, nil
+}
+
+// bufferedConn reads through the reader the response was read with so that
+// nothing the device sent right after it is lost.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func DownloadFile(ctx context.Context, deviceConn net.Conn, path string) (*http.Response, error) {
+	return getFile(ctx, deviceConn, withFilePath("/files", path))
+}
+
+// UploadFile writes size bytes from body to path on the device. An empty
+// mode leaves it up to the device.
+func UploadFile(ctx context.Context, deviceConn net.Conn, path, mode string, body io.Reader, size int64) (*http.Response, error) {
+	return putFile(ctx, deviceConn, withFilePath("/files", path), mode, body, size)
+}
+
+// BrowseFiles performs one of the read-only file browser operations on path.
+func BrowseFiles(ctx context.Context, deviceConn net.Conn, operation, path string) (*http.Response, error) {
+	return getFile(ctx, deviceConn, withFilePath("/filebrowser/"+operation, path))
+}
+
+func WriteBrowsedFile(ctx context.Context, deviceConn net.Conn, path, mode string, body io.Reader, size int64) (*http.Response, error) {
+	return putFile(ctx, deviceConn, withFilePath("/filebrowser/"+models.FileBrowserOperationWrite, path), mode, body, size)
+}
+
+func getFile(ctx context.Context, deviceConn net.Conn, fileURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		fileURL,
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func putFile(ctx context.Context, deviceConn net.Conn, fileURL, mode string, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"PUT",
+		fileURL,
+		body,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if mode != "" {
+		req.Header.Set(models.FileModeHeader, mode)
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func ListHostCommands(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"GET",
+		"/hostcommands",
 		nil,
 	)
 	if err != nil {
@@ -123,3 +375,69 @@ func InitiateReboot(ctx context.Context, deviceConn net.Conn) (*http.Response, e
 
 	return http.ReadResponse(bufio.NewReader(deviceConn), req)
 }
+
+func RunHostCommand(ctx context.Context, deviceConn net.Conn, hostCommand string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		fmt.Sprintf("/hostcommands/%s", url.PathEscape(hostCommand)),
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func InitiateReboot(ctx context.Context, deviceConn net.Conn, reason string) (*http.Response, error) {
+	return initiatePowerAction(ctx, deviceConn, "/reboot", reason)
+}
+
+func InitiateShutdown(ctx context.Context, deviceConn net.Conn, reason string) (*http.Response, error) {
+	return initiatePowerAction(ctx, deviceConn, "/shutdown", reason)
+}
+
+func initiatePowerAction(ctx context.Context, deviceConn net.Conn, path, reason string) (*http.Response, error) {
+	reqBytes, err := json.Marshal(models.PowerActionRequest{
+		Reason: reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		path,
+		bytes.NewReader(reqBytes),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.Write(deviceConn); err != nil {
+		return nil, err
+	}
+
+	return http.ReadResponse(bufio.NewReader(deviceConn), req)
+}
+
+func withSessionRecording(path, sessionRecordingID string) string {
+	if sessionRecordingID == "" {
+		return path
+	}
+	return path + "?" + url.Values{
+		models.SessionRecordingQueryParam: []string{sessionRecordingID},
+	}.Encode()
+}
+
+func withFilePath(fileURL, path string) string {
+	return fileURL + "?" + url.Values{
+		models.FilePathQueryParam: []string{path},
+	}.Encode()
+}
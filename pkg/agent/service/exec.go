@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gliderlabs/ssh"
+	"github.com/gorilla/mux"
+)
+
+var (
+	// Same as the host shell, bash is preferred when the image has it
+	execShell = []string{"/bin/sh", "-c", `if [ -x /bin/bash ]; then exec /bin/bash; else exec /bin/sh; fi`}
+)
+
+// exec serves SSH sessions that run inside a service's container rather
+// than on the host.
+func (s *Service) exec(w http.ResponseWriter, r *http.Request) {
+	if s.variables.GetDisableExec() {
+		http.Error(w, "exec is disabled", http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	applicationID := vars["application"]
+	service := vars["service"]
+
+	containerID, ok := s.supervisorLookup.GetContainerID(applicationID, service)
+	if !ok {
+		http.Error(w, "service is not running", http.StatusNotFound)
+		return
+	}
+
+	handler := s.execServerHandler(containerID)
+	if sessionRecordingID := r.URL.Query().Get(models.SessionRecordingQueryParam); sessionRecordingID != "" {
+		handler = s.recordSessions(sessionRecordingID, handler)
+	}
+
+	s.serveSSH(w, r, s.variables.GetDisableExec, handler)
+}
+
+func (s *Service) execServerHandler(containerID string) func(context.Context) func(ssh.Session) {
+	return func(ctx context.Context) func(ssh.Session) {
+		return func(session ssh.Session) {
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			config := engine.ExecConfig{
+				Cmd:    session.Command(),
+				Stdin:  session,
+				Stdout: session,
+				Stderr: session.Stderr(),
+			}
+			if len(config.Cmd) == 0 {
+				config.Cmd = execShell
+			}
+
+			ptyReq, winCh, isPty := session.Pty()
+			if isPty {
+				resize := make(chan engine.WindowSize)
+				go func() {
+					defer close(resize)
+					for win := range winCh {
+						select {
+						case resize <- engine.WindowSize{Height: uint(win.Height), Width: uint(win.Width)}:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}()
+
+				config.Tty = true
+				config.Env = []string{fmt.Sprintf("TERM=%s", ptyReq.Term)}
+				config.Resize = resize
+			}
+
+			exitCode, err := s.engine.ExecContainer(ctx, containerID, config)
+			if err != nil {
+				log.WithError(err).Error("exec in container")
+				fmt.Fprintln(session.Stderr(), err.Error())
+				session.Exit(1)
+				return
+			}
+			session.Exit(exitCode)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+func (s *Service) fileBrowserRoots(w http.ResponseWriter, r *http.Request) {
+	roots := []models.FileBrowserRoot{}
+	for _, fileBrowserPath := range s.variables.GetFileBrowserPaths() {
+		roots = append(roots, models.FileBrowserRoot{
+			Path:     fileBrowserPath.Path,
+			Writable: fileBrowserPath.Writable,
+		})
+	}
+
+	utils.Respond(w, roots)
+}
+
+func (s *Service) statBrowsedFile(w http.ResponseWriter, r *http.Request) {
+	path, resolvedPath, ok := s.getBrowsedPath(w, r, false)
+	if !ok {
+		return
+	}
+
+	info, err := os.Stat(resolvedPath)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	utils.Respond(w, convertFileInfo(path, info))
+}
+
+func (s *Service) listBrowsedFiles(w http.ResponseWriter, r *http.Request) {
+	path, resolvedPath, ok := s.getBrowsedPath(w, r, false)
+	if !ok {
+		return
+	}
+
+	infos, err := ioutil.ReadDir(resolvedPath)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+
+	files := []models.FileInfo{}
+	for _, info := range infos {
+		files = append(files, convertFileInfo(filepath.Join(path, info.Name()), info))
+	}
+
+	utils.Respond(w, files)
+}
+
+func (s *Service) readBrowsedFile(w http.ResponseWriter, r *http.Request) {
+	_, resolvedPath, ok := s.getBrowsedPath(w, r, false)
+	if !ok {
+		return
+	}
+
+	serveFile(w, resolvedPath)
+}
+
+func (s *Service) writeBrowsedFile(w http.ResponseWriter, r *http.Request) {
+	_, resolvedPath, ok := s.getBrowsedPath(w, r, true)
+	if !ok {
+		return
+	}
+
+	receiveFile(w, r, resolvedPath)
+}
+
+// getBrowsedPath returns the requested path along with the path it resolves
+// to once symlinks are followed. Both have to be under one of the configured
+// file browser paths, so that symlinks can't be used to escape them.
+func (s *Service) getBrowsedPath(w http.ResponseWriter, r *http.Request, write bool) (string, string, bool) {
+	path, ok := getFilePath(w, r)
+	if !ok {
+		return "", "", false
+	}
+
+	fileBrowserPaths := s.variables.GetFileBrowserPaths()
+	if !isBrowsable(fileBrowserPaths, path, write) {
+		http.Error(w, "path is not browsable", http.StatusForbidden)
+		return "", "", false
+	}
+
+	resolvedPath, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) && write {
+		// Files can be created in existing directories
+		var resolvedDir string
+		resolvedDir, err = filepath.EvalSymlinks(filepath.Dir(path))
+		resolvedPath = filepath.Join(resolvedDir, filepath.Base(path))
+	}
+	if err != nil {
+		writeFileError(w, err)
+		return "", "", false
+	}
+
+	if !isBrowsable(fileBrowserPaths, resolvedPath, write) {
+		http.Error(w, "path is not browsable", http.StatusForbidden)
+		return "", "", false
+	}
+
+	return path, resolvedPath, true
+}
+
+func isBrowsable(fileBrowserPaths []variables.FileBrowserPath, path string, write bool) bool {
+	for _, fileBrowserPath := range fileBrowserPaths {
+		if write && !fileBrowserPath.Writable {
+			continue
+		}
+
+		if isWithin(path, fileBrowserPath.Path) {
+			return true
+		}
+		if resolvedRoot, err := filepath.EvalSymlinks(fileBrowserPath.Path); err == nil && isWithin(path, resolvedRoot) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+func convertFileInfo(path string, info os.FileInfo) models.FileInfo {
+	return models.FileInfo{
+		Name:    info.Name(),
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    info.Mode().String(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+}
@@ -0,0 +1,144 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBrowser(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebrowser")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	configDir := filepath.Join(dir, "config")
+	dataDir := filepath.Join(dir, "data")
+	secretsDir := filepath.Join(dir, "secrets")
+	for _, d := range []string{configDir, dataDir, secretsDir} {
+		require.NoError(t, os.Mkdir(d, 0755))
+	}
+	require.NoError(t, ioutil.WriteFile(filepath.Join(configDir, "app.conf"), []byte("key=value\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(secretsDir, "key"), []byte("secret"), 0600))
+	require.NoError(t, os.Symlink(secretsDir, filepath.Join(configDir, "secrets")))
+
+	s := &Service{
+		variables: &testVariables{
+			fileBrowserPaths: []variables.FileBrowserPath{
+				{Path: configDir},
+				{Path: dataDir, Writable: true},
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/filebrowser/roots", s.fileBrowserRoots).Methods("GET")
+	router.HandleFunc("/filebrowser/stat", s.statBrowsedFile).Methods("GET")
+	router.HandleFunc("/filebrowser/list", s.listBrowsedFiles).Methods("GET")
+	router.HandleFunc("/filebrowser/read", s.readBrowsedFile).Methods("GET")
+	router.HandleFunc("/filebrowser/write", s.writeBrowsedFile).Methods("PUT")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	browse := func(operation, path string) *http.Response {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		resp, err := client.BrowseFiles(context.Background(), deviceConn, operation, path)
+		require.NoError(t, err)
+		return resp
+	}
+
+	write := func(path, contents string) *http.Response {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		resp, err := client.WriteBrowsedFile(context.Background(), deviceConn, path, "", strings.NewReader(contents), int64(len(contents)))
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("roots", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationRoots, "")
+		defer resp.Body.Close()
+
+		var roots []models.FileBrowserRoot
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&roots))
+		require.Equal(t, []models.FileBrowserRoot{
+			{Path: configDir},
+			{Path: dataDir, Writable: true},
+		}, roots)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationList, configDir)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var files []models.FileInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&files))
+		require.Len(t, files, 2)
+		require.Equal(t, "app.conf", files[0].Name)
+		require.Equal(t, filepath.Join(configDir, "app.conf"), files[0].Path)
+		require.Equal(t, int64(10), files[0].Size)
+	})
+
+	t.Run("stat", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationStat, configDir)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var file models.FileInfo
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&file))
+		require.True(t, file.IsDir)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationRead, filepath.Join(configDir, "app.conf"))
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		contents, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "key=value\n", string(contents))
+	})
+
+	t.Run("outside paths", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationRead, filepath.Join(secretsDir, "key"))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = browse(models.FileBrowserOperationRead, filepath.Join(configDir, "..", "secrets", "key"))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("symlink escape", func(t *testing.T) {
+		resp := browse(models.FileBrowserOperationRead, filepath.Join(configDir, "secrets", "key"))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		resp := write(filepath.Join(dataDir, "calibration"), "42")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		contents, err := ioutil.ReadFile(filepath.Join(dataDir, "calibration"))
+		require.NoError(t, err)
+		require.Equal(t, "42", string(contents))
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		resp := write(filepath.Join(configDir, "app.conf"), "key=other\n")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
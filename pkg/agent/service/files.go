@@ -0,0 +1,139 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func (s *Service) downloadFile(w http.ResponseWriter, r *http.Request) {
+	if s.variables.GetDisableFileTransfer() {
+		http.Error(w, "file transfer is disabled", http.StatusForbidden)
+		return
+	}
+
+	path, ok := getFilePath(w, r)
+	if !ok {
+		return
+	}
+
+	serveFile(w, path)
+}
+
+func serveFile(w http.ResponseWriter, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+	if !info.Mode().IsRegular() {
+		http.Error(w, fmt.Sprintf("%s is not a regular file", path), http.StatusBadRequest)
+		return
+	}
+	if info.Size() > models.MaxFileTransferSize {
+		http.Error(w, fmt.Sprintf("%s is larger than %d bytes", path, models.MaxFileTransferSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	w.Header().Set(models.FileModeHeader, strconv.FormatUint(uint64(info.Mode().Perm()), 8))
+	io.CopyN(w, file, info.Size())
+}
+
+func (s *Service) uploadFile(w http.ResponseWriter, r *http.Request) {
+	if s.variables.GetDisableFileTransfer() {
+		http.Error(w, "file transfer is disabled", http.StatusForbidden)
+		return
+	}
+
+	path, ok := getFilePath(w, r)
+	if !ok {
+		return
+	}
+
+	receiveFile(w, r, path)
+}
+
+// receiveFile writes the request body to a temporary file next to path and
+// renames it into place, so that a failed transfer never leaves a partially
+// written file behind.
+func receiveFile(w http.ResponseWriter, r *http.Request, path string) {
+	mode := os.FileMode(0644)
+	if modeString := r.Header.Get(models.FileModeHeader); modeString != "" {
+		parsedMode, err := strconv.ParseUint(modeString, 8, 32)
+		if err != nil || os.FileMode(parsedMode)&^os.ModePerm != 0 {
+			http.Error(w, "invalid file mode", http.StatusBadRequest)
+			return
+		}
+		mode = os.FileMode(parsedMode)
+	}
+
+	if r.ContentLength > models.MaxFileTransferSize {
+		http.Error(w, fmt.Sprintf("file is larger than %d bytes", models.MaxFileTransferSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		http.Error(w, fmt.Sprintf("%s is a directory", path), http.StatusBadRequest)
+		return
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		writeFileError(w, err)
+		return
+	}
+	defer os.Remove(tmpFile.Name())
+
+	_, err = io.Copy(tmpFile, http.MaxBytesReader(w, r.Body, models.MaxFileTransferSize))
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := os.Chmod(tmpFile.Name(), mode); err != nil {
+		writeFileError(w, err)
+		return
+	}
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		writeFileError(w, err)
+		return
+	}
+}
+
+func getFilePath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	path := r.URL.Query().Get(models.FilePathQueryParam)
+	if !filepath.IsAbs(path) {
+		http.Error(w, "path must be absolute", http.StatusBadRequest)
+		return "", false
+	}
+	return filepath.Clean(path), true
+}
+
+func writeFileError(w http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case os.IsPermission(err):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type testVariables struct {
+	variables.Interface
+	disableFileTransfer bool
+	hostCommands        map[string]string
+	fileBrowserPaths    []variables.FileBrowserPath
+}
+
+func (v *testVariables) GetDisableFileTransfer() bool {
+	return v.disableFileTransfer
+}
+
+func (v *testVariables) GetHostCommands() map[string]string {
+	return v.hostCommands
+}
+
+func (v *testVariables) GetFileBrowserPaths() []variables.FileBrowserPath {
+	return v.fileBrowserPaths
+}
+
+func TestFileTransfer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "files")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	v := &testVariables{}
+	s := &Service{
+		variables: v,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/files", s.downloadFile).Methods("GET")
+	router.HandleFunc("/files", s.uploadFile).Methods("PUT")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func() net.Conn {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		return deviceConn
+	}
+
+	path := filepath.Join(dir, "app.conf")
+
+	t.Run("upload", func(t *testing.T) {
+		resp, err := client.UploadFile(context.Background(), dial(), path, "600", strings.NewReader("key=value\n"), 10)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		contents, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "key=value\n", string(contents))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("download", func(t *testing.T) {
+		resp, err := client.DownloadFile(context.Background(), dial(), path)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "600", resp.Header.Get(models.FileModeHeader))
+
+		contents, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, "key=value\n", string(contents))
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		resp, err := client.DownloadFile(context.Background(), dial(), "app.conf")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		resp, err := client.DownloadFile(context.Background(), dial(), filepath.Join(dir, "missing"))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		resp, err := client.DownloadFile(context.Background(), dial(), dir)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		v.disableFileTransfer = true
+		defer func() {
+			v.disableFileTransfer = false
+		}()
+
+		resp, err := client.DownloadFile(context.Background(), dial(), path)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
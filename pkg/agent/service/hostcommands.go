@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+	"github.com/gorilla/mux"
+)
+
+const (
+	hostCommandTimeout   = 5 * time.Minute
+	maxHostCommandOutput = 64 << 10
+)
+
+func (s *Service) listHostCommands(w http.ResponseWriter, r *http.Request) {
+	hostCommands := []models.HostCommand{}
+	for name := range s.variables.GetHostCommands() {
+		hostCommands = append(hostCommands, models.HostCommand{
+			Name: name,
+		})
+	}
+	sort.Slice(hostCommands, func(i, j int) bool {
+		return hostCommands[i].Name < hostCommands[j].Name
+	})
+
+	utils.Respond(w, hostCommands)
+}
+
+// runHostCommand runs one of the commands allowed by the host-commands
+// variable and responds once it has exited. Only the command's name is
+// taken from the request, so the controller can't run anything else.
+func (s *Service) runHostCommand(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["hostcommand"]
+
+	command, ok := s.variables.GetHostCommands()[name]
+	if !ok {
+		http.Error(w, "host command is not allowed", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), hostCommandTimeout)
+	defer cancel()
+
+	utils.Respond(w, runHostCommand(ctx, command))
+}
+
+func runHostCommand(ctx context.Context, command string) models.HostCommandResult {
+	shellCommand := shellCommand(command)
+	cmd := exec.CommandContext(ctx, shellCommand[0], shellCommand[1:]...)
+
+	output := &limitedBuffer{
+		limit: maxHostCommandOutput,
+	}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	var result models.HostCommandResult
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+		}
+		if ctx.Err() != nil {
+			result.Error = ctx.Err().Error()
+		} else if result.ExitCode == -1 {
+			result.Error = err.Error()
+		}
+	}
+	result.Output = string(output.bytes)
+	result.Truncated = output.truncated
+
+	return result
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the
+// rest.
+type limitedBuffer struct {
+	bytes     []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if remaining := b.limit - len(b.bytes); n > remaining {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.bytes = append(b.bytes, p...)
+	return n, nil
+}
@@ -0,0 +1,93 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostCommands(t *testing.T) {
+	s := &Service{
+		variables: &testVariables{
+			hostCommands: map[string]string{
+				"rescan-sensors": "echo rescanned",
+				"fail":           "echo failed >&2; exit 3",
+				"noisy":          "yes | head -c 100000",
+			},
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/hostcommands", s.listHostCommands).Methods("GET")
+	router.HandleFunc("/hostcommands/{hostcommand}", s.runHostCommand).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func() net.Conn {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		return deviceConn
+	}
+
+	run := func(name string) models.HostCommandResult {
+		resp, err := client.RunHostCommand(context.Background(), dial(), name)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.HostCommandResult
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	t.Run("list", func(t *testing.T) {
+		resp, err := client.ListHostCommands(context.Background(), dial())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var hostCommands []models.HostCommand
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&hostCommands))
+		require.Equal(t, []models.HostCommand{
+			{Name: "fail"},
+			{Name: "noisy"},
+			{Name: "rescan-sensors"},
+		}, hostCommands)
+	})
+
+	t.Run("run", func(t *testing.T) {
+		result := run("rescan-sensors")
+		require.Equal(t, 0, result.ExitCode)
+		require.Equal(t, "rescanned\n", result.Output)
+	})
+
+	t.Run("exit code", func(t *testing.T) {
+		result := run("fail")
+		require.Equal(t, 3, result.ExitCode)
+		require.Equal(t, "failed\n", result.Output)
+		require.Empty(t, result.Error)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		result := run("noisy")
+		require.True(t, result.Truncated)
+		require.Equal(t, maxHostCommandOutput, len(result.Output))
+		require.True(t, strings.HasPrefix(result.Output, "y\ny\n"))
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		resp, err := client.RunHostCommand(context.Background(), dial(), "rm -rf /")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// logs streams a service's output as newline delimited JSON chunks. With
+// follow set it keeps streaming until the client goes away or the container
+// stops. Tail limits how many of the most recent lines it starts from.
+func (s *Service) logs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["application"]
+	service := vars["service"]
+
+	var tail int
+	if tailParam := r.URL.Query().Get("tail"); tailParam != "" {
+		var err error
+		tail, err = strconv.Atoi(tailParam)
+		if err != nil || tail < 0 {
+			http.Error(w, "invalid tail", http.StatusBadRequest)
+			return
+		}
+	}
+
+	containerID, ok := s.supervisorLookup.GetContainerID(applicationID, service)
+	if !ok {
+		w.WriteHeader(codes.StatusLogsNotAvailable)
+		return
+	}
+
+	output := &logOutput{w: w}
+	err := s.engine.StreamContainerLogs(r.Context(), containerID, engine.LogsConfig{
+		Follow: r.URL.Query().Get("follow") == "true",
+		Tail:   tail,
+		Stdout: output.stream(models.LogStreamStdout),
+		Stderr: output.stream(models.LogStreamStderr),
+	})
+	if err != nil && !output.started {
+		http.Error(w, err.Error(), codes.StatusLogsNotAvailable)
+		return
+	}
+	output.start()
+}
+
+// logOutput encodes what's written to each of a service's streams as
+// chunks, once the first one has been written.
+type logOutput struct {
+	sync.Mutex
+	w       http.ResponseWriter
+	encoder *json.Encoder
+	started bool
+}
+
+func (o *logOutput) start() {
+	if o.started {
+		return
+	}
+	o.w.Header().Set("Content-Type", "application/x-ndjson")
+	o.w.WriteHeader(http.StatusOK)
+	o.encoder = json.NewEncoder(o.w)
+	o.started = true
+}
+
+func (o *logOutput) stream(stream string) *logStream {
+	return &logStream{output: o, stream: stream}
+}
+
+type logStream struct {
+	output *logOutput
+	stream string
+}
+
+func (s *logStream) Write(p []byte) (int, error) {
+	s.output.Lock()
+	defer s.output.Unlock()
+
+	s.output.start()
+	if err := s.output.encoder.Encode(models.LogChunk{
+		Stream: s.stream,
+		Data:   string(p),
+	}); err != nil {
+		return 0, err
+	}
+	if flusher, ok := s.output.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return len(p), nil
+}
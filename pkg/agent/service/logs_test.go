@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/agent/supervisor"
+	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLookup struct {
+	supervisor.Lookup
+	containerIDs map[string]string
+}
+
+func (f *fakeLookup) GetContainerID(applicationID, service string) (string, bool) {
+	containerID, ok := f.containerIDs[applicationID+"/"+service]
+	return containerID, ok
+}
+
+type fakeLogsEngine struct {
+	engine.Engine
+	configs chan engine.LogsConfig
+}
+
+func (f *fakeLogsEngine) StreamContainerLogs(ctx context.Context, id string, config engine.LogsConfig) error {
+	if id != "web-container" {
+		return engine.ErrInstanceNotFound
+	}
+	f.configs <- config
+	config.Stdout.Write([]byte("listening on :80\n"))
+	config.Stderr.Write([]byte("no config found\n"))
+	return nil
+}
+
+func TestLogs(t *testing.T) {
+	e := &fakeLogsEngine{configs: make(chan engine.LogsConfig, 1)}
+	s := &Service{
+		supervisorLookup: &fakeLookup{containerIDs: map[string]string{
+			"app/web": "web-container",
+			"app/db":  "db-container",
+		}},
+		engine: e,
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/applications/{application}/services/{service}/logs", s.logs).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	getLogs := func(service string, follow bool, tail int) *http.Response {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+
+		resp, err := client.GetServiceLogs(context.Background(), deviceConn, "app", service, follow, tail)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := getLogs("web", true, 10)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	config := <-e.configs
+	require.True(t, config.Follow)
+	require.Equal(t, 10, config.Tail)
+
+	var chunks []models.LogChunk
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var chunk models.LogChunk
+		require.NoError(t, decoder.Decode(&chunk))
+		chunks = append(chunks, chunk)
+	}
+	require.Equal(t, []models.LogChunk{
+		{Stream: models.LogStreamStdout, Data: "listening on :80\n"},
+		{Stream: models.LogStreamStderr, Data: "no config found\n"},
+	}, chunks)
+
+	for _, service := range []string{"db", "worker"} {
+		resp := getLogs(service, false, 0)
+		resp.Body.Close()
+		require.Equal(t, codes.StatusLogsNotAvailable, resp.StatusCode, service)
+	}
+}
@@ -1,13 +1,21 @@
 package service
 
 import (
+	"bytes"
 	"encoding/base64"
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
 	"strconv"
 
+	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/utils"
 	"github.com/gorilla/mux"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 )
 
 func (s *Service) metrics(w http.ResponseWriter, r *http.Request) {
@@ -15,6 +23,13 @@ func (s *Service) metrics(w http.ResponseWriter, r *http.Request) {
 	applicationID := vars["application"]
 	service := vars["service"]
 
+	// Endpoints declared in the service's spec take precedence over the
+	// endpoint requested by the controller
+	if endpoints := s.supervisorLookup.GetMetricsEndpoints(applicationID, service); len(endpoints) > 0 {
+		s.scrapeMetrics(w, r, applicationID, service, endpoints)
+		return
+	}
+
 	query := r.URL.Query()
 
 	portRaw := query.Get("port")
@@ -48,3 +63,139 @@ func (s *Service) metrics(w http.ResponseWriter, r *http.Request) {
 
 	utils.ProxyResponse(w, resp)
 }
+
+// scrapeMetrics scrapes every endpoint declared by a service and responds
+// with their samples merged together. Endpoints that can't be scraped are
+// skipped so that one broken endpoint doesn't hide the others.
+func (s *Service) scrapeMetrics(w http.ResponseWriter, r *http.Request,
+	applicationID, service string, endpoints []models.MetricsEndpoint,
+) {
+	containerID, ok := s.supervisorLookup.GetContainerID(applicationID, service)
+	if !ok {
+		w.WriteHeader(codes.StatusMetricsNotAvailable)
+		return
+	}
+
+	var scraped [][]*dto.MetricFamily
+	var scrapeErr error
+	for _, endpoint := range endpoints {
+		path := endpoint.Path
+		if path == "" {
+			path = models.DefaultMetricPath
+		}
+
+		metricFamilies, err := s.scrapeEndpoint(r, containerID, int(endpoint.Port), path)
+		if err != nil {
+			log.WithField("application", applicationID).
+				WithField("service", service).
+				WithField("port", endpoint.Port).
+				WithError(err).Error("scrape service metrics")
+			scrapeErr = err
+			continue
+		}
+
+		scraped = append(scraped, withLabels(metricFamilies, endpoint.Labels))
+	}
+	if len(scraped) == 0 {
+		http.Error(w, scrapeErr.Error(), codes.StatusMetricsNotAvailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, metricFamily := range mergeMetricFamilies(scraped) {
+		if err := encoder.Encode(metricFamily); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", string(expfmt.FmtText))
+	w.Write(buf.Bytes())
+}
+
+func (s *Service) scrapeEndpoint(r *http.Request, containerID string, port int, path string) (map[string]*dto.MetricFamily, error) {
+	resp, err := s.netnsManager.ProcessRequest(r.Context(), containerID, port, path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+
+	return parseMetrics(resp.Body)
+}
+
+func parseMetrics(r io.Reader) (map[string]*dto.MetricFamily, error) {
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(r)
+}
+
+// withLabels adds labels to every metric that doesn't already have them.
+func withLabels(metricFamilies map[string]*dto.MetricFamily, labels map[string]string) []*dto.MetricFamily {
+	var names []string
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret []*dto.MetricFamily
+	for _, metricFamily := range metricFamilies {
+		for _, metric := range metricFamily.Metric {
+			existing := make(map[string]struct{})
+			for _, label := range metric.Label {
+				existing[label.GetName()] = struct{}{}
+			}
+			for _, name := range names {
+				if _, ok := existing[name]; ok {
+					continue
+				}
+				name, value := name, labels[name]
+				metric.Label = append(metric.Label, &dto.LabelPair{
+					Name:  &name,
+					Value: &value,
+				})
+			}
+			sort.Slice(metric.Label, func(i, j int) bool {
+				return metric.Label[i].GetName() < metric.Label[j].GetName()
+			})
+		}
+		ret = append(ret, metricFamily)
+	}
+	return ret
+}
+
+// mergeMetricFamilies combines the metric families scraped from several
+// endpoints, since a family can only appear once in an exposition. Families
+// with the same name but a different type than the first one seen are
+// dropped.
+func mergeMetricFamilies(scraped [][]*dto.MetricFamily) []*dto.MetricFamily {
+	byName := make(map[string]*dto.MetricFamily)
+	for _, metricFamilies := range scraped {
+		for _, metricFamily := range metricFamilies {
+			existing, ok := byName[metricFamily.GetName()]
+			if !ok {
+				byName[metricFamily.GetName()] = metricFamily
+				continue
+			}
+			if existing.GetType() != metricFamily.GetType() {
+				continue
+			}
+			existing.Metric = append(existing.Metric, metricFamily.Metric...)
+		}
+	}
+
+	var names []string
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret []*dto.MetricFamily
+	for _, name := range names {
+		ret = append(ret, byName[name])
+	}
+	return ret
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeScrapedMetrics(t *testing.T) {
+	first, err := parseMetrics(strings.NewReader(`# TYPE requests_total counter
+requests_total{code="200"} 10
+requests_total{code="500",role="api"} 1
+`))
+	require.NoError(t, err)
+
+	second, err := parseMetrics(strings.NewReader(`# TYPE requests_total counter
+requests_total{code="200"} 4
+# TYPE queue_length gauge
+queue_length 3
+`))
+	require.NoError(t, err)
+
+	third, err := parseMetrics(strings.NewReader(`# TYPE requests_total gauge
+requests_total 7
+`))
+	require.NoError(t, err)
+
+	merged := mergeMetricFamilies([][]*dto.MetricFamily{
+		withLabels(first, map[string]string{"role": "edge"}),
+		withLabels(second, map[string]string{"role": "worker", "zone": "a"}),
+		withLabels(third, nil),
+	})
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, metricFamily := range merged {
+		require.NoError(t, encoder.Encode(metricFamily))
+	}
+
+	require.Equal(t, `# TYPE queue_length gauge
+queue_length{role="worker",zone="a"} 3
+# TYPE requests_total counter
+requests_total{code="200",role="edge"} 10
+requests_total{code="500",role="api"} 1
+requests_total{code="200",role="worker",zone="a"} 4
+`, buf.String())
+}
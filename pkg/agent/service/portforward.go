@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// portForward connects to a TCP port on the device, or in a service's
+// container when the route names one, and then proxies the raw connection
+// to it. Errors are returned as regular responses, while success is
+// signalled with an empty 200 response after which the stream starts.
+func (s *Service) portForward(w http.ResponseWriter, r *http.Request) {
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil || port <= 0 || port > 65535 {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	var conn net.Conn
+	vars := mux.Vars(r)
+	if applicationID, ok := vars["application"]; ok {
+		containerID, ok := s.supervisorLookup.GetContainerID(applicationID, vars["service"])
+		if !ok {
+			http.Error(w, "service is not running", http.StatusNotFound)
+			return
+		}
+		conn, err = s.netnsManager.Dial(r.Context(), containerID, port)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(r.Context(), "tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer conn.Close()
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can't be hijacked", http.StatusInternalServerError)
+		return
+	}
+	clientConn, buf, err := hijacker.Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer clientConn.Close()
+
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	if err := resp.Write(clientConn); err != nil {
+		return
+	}
+
+	go io.Copy(conn, buf)
+	io.Copy(clientConn, conn)
+}
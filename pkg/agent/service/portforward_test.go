@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPortForward(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+
+	go func() {
+		conn, err := target.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(conn, conn)
+	}()
+
+	s := &Service{}
+	router := mux.NewRouter()
+	router.HandleFunc("/portforward", s.portForward).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func() net.Conn {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		return deviceConn
+	}
+
+	t.Run("invalid port", func(t *testing.T) {
+		_, err := client.InitiatePortForward(context.Background(), dial(), "", "", 0)
+		require.EqualError(t, err, "invalid port")
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		port := target.Addr().(*net.TCPAddr).Port
+		conn, err := client.InitiatePortForward(context.Background(), dial(), "", "", port)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(buf))
+	})
+}
@@ -1,19 +1,54 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"os/exec"
 	"time"
 
 	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+const (
+	powerActionTimeout = 5 * time.Minute
 )
 
 func (s *Service) reboot(w http.ResponseWriter, r *http.Request) {
+	s.powerAction(w, r, models.PowerActionReboot, rebootCommand)
+}
+
+func (s *Service) shutdown(w http.ResponseWriter, r *http.Request) {
+	s.powerAction(w, r, models.PowerActionShutdown, shutdownCommand)
+}
+
+// powerAction responds right away and then performs the action in the
+// background, since the device may go down before a response could be
+// sent.
+func (s *Service) powerAction(w http.ResponseWriter, r *http.Request, action string, command []string) {
+	// Older controllers don't send a request body
+	var req models.PowerActionRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	powerAction := models.PowerAction{
+		Action:      action,
+		Reason:      req.Reason,
+		RequestedAt: time.Now(),
+	}
+
 	go func() {
-		time.Sleep(1000)
-		err := exec.Command("/sbin/reboot").Run()
+		ctx, cancel := context.WithTimeout(context.Background(), powerActionTimeout)
+		defer cancel()
+
+		err := s.performPowerAction(ctx, powerAction, func() error {
+			return exec.Command(command[0], command[1:]...).Run()
+		})
 		if err != nil {
-			log.WithError(err).Error("failed to reboot")
+			log.WithField("action", action).WithError(err).Error("failed to perform power action")
 		}
 	}()
+
+	utils.Respond(w, powerAction)
 }
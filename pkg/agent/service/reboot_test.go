@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPowerActions(t *testing.T) {
+	performed := make(chan models.PowerAction, 1)
+	s := &Service{
+		performPowerAction: func(ctx context.Context, powerAction models.PowerAction, perform func() error) error {
+			performed <- powerAction
+			return nil
+		},
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/reboot", s.reboot).Methods("POST")
+	router.HandleFunc("/shutdown", s.shutdown).Methods("POST")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	dial := func() net.Conn {
+		deviceConn, err := net.Dial("tcp", server.Listener.Addr().String())
+		require.NoError(t, err)
+		return deviceConn
+	}
+
+	for _, tc := range []struct {
+		action   string
+		initiate func(ctx context.Context, deviceConn net.Conn, reason string) (*http.Response, error)
+	}{
+		{models.PowerActionReboot, client.InitiateReboot},
+		{models.PowerActionShutdown, client.InitiateShutdown},
+	} {
+		t.Run(tc.action, func(t *testing.T) {
+			resp, err := tc.initiate(context.Background(), dial(), "kernel update")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var powerAction models.PowerAction
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&powerAction))
+			require.Equal(t, tc.action, powerAction.Action)
+			require.Equal(t, "kernel update", powerAction.Reason)
+
+			select {
+			case performedPowerAction := <-performed:
+				require.Equal(t, tc.action, performedPowerAction.Action)
+				require.Equal(t, "kernel update", performedPowerAction.Reason)
+			case <-time.After(5 * time.Second):
+				t.Fatal("power action wasn't performed")
+			}
+		})
+	}
+}
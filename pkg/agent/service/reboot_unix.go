@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+var (
+	rebootCommand   = []string{"/sbin/reboot"}
+	shutdownCommand = []string{"/sbin/poweroff"}
+)
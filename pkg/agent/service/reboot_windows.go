@@ -0,0 +1,6 @@
+package service
+
+var (
+	rebootCommand   = []string{"shutdown.exe", "/r", "/t", "0"}
+	shutdownCommand = []string{"shutdown.exe", "/s", "/t", "0"}
+)
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/asciicast"
+	"github.com/gliderlabs/ssh"
+)
+
+const (
+	maxSessionRecordingSize = 10 << 20
+
+	defaultRecordingWidth  = 80
+	defaultRecordingHeight = 24
+)
+
+// recordSessions wraps handler so that the output of each session is
+// recorded and uploaded to the controller once the session ends.
+func (s *Service) recordSessions(sessionRecordingID string, handler func(context.Context) func(ssh.Session)) func(context.Context) func(ssh.Session) {
+	return func(ctx context.Context) func(ssh.Session) {
+		sessionHandler := handler(ctx)
+
+		return func(session ssh.Session) {
+			ptyReq, _, _ := session.Pty()
+			width, height := ptyReq.Window.Width, ptyReq.Window.Height
+			if width == 0 || height == 0 {
+				width, height = defaultRecordingWidth, defaultRecordingHeight
+			}
+
+			recorder := asciicast.NewRecorder(width, height, session.RawCommand(), ptyReq.Term, maxSessionRecordingSize)
+
+			sessionHandler(&recordedSession{
+				Session:  session,
+				recorder: recorder,
+			})
+
+			go s.uploadSessionRecording(sessionRecordingID, recorder)
+		}
+	}
+}
+
+func (s *Service) uploadSessionRecording(sessionRecordingID string, recorder *asciicast.Recorder) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if recorder.Truncated() {
+		log.WithField("session_recording_id", sessionRecordingID).Warn("session recording truncated")
+	}
+
+	if err := s.finishSessionRecording(ctx, sessionRecordingID, string(recorder.Bytes())); err != nil {
+		log.WithError(err).Error("upload session recording")
+	}
+}
+
+type recordedSession struct {
+	ssh.Session
+	recorder *asciicast.Recorder
+
+	ptyOnce sync.Once
+	pty     ssh.Pty
+	winCh   <-chan ssh.Window
+	isPty   bool
+}
+
+func (s *recordedSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	s.recorder.Output(p[:n])
+	return n, err
+}
+
+func (s *recordedSession) Stderr() io.ReadWriter {
+	return &recordedStderr{
+		ReadWriter: s.Session.Stderr(),
+		recorder:   s.recorder,
+	}
+}
+
+// Pty records window changes on their way to the handler.
+func (s *recordedSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	s.ptyOnce.Do(func() {
+		var winCh <-chan ssh.Window
+		s.pty, winCh, s.isPty = s.Session.Pty()
+		if winCh == nil {
+			return
+		}
+
+		recordedWinCh := make(chan ssh.Window)
+		go func() {
+			defer close(recordedWinCh)
+			for win := range winCh {
+				s.recorder.Resize(win.Width, win.Height)
+				recordedWinCh <- win
+			}
+		}()
+		s.winCh = recordedWinCh
+	})
+	return s.pty, s.winCh, s.isPty
+}
+
+type recordedStderr struct {
+	io.ReadWriter
+	recorder *asciicast.Recorder
+}
+
+func (s *recordedStderr) Write(p []byte) (int, error) {
+	n, err := s.ReadWriter.Write(p)
+	s.recorder.Output(p[:n])
+	return n, err
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSession struct {
+	ssh.Session
+	out   bytes.Buffer
+	winCh chan ssh.Window
+}
+
+func (s *fakeSession) Write(p []byte) (int, error) {
+	return s.out.Write(p)
+}
+
+func (s *fakeSession) Stderr() io.ReadWriter {
+	return &s.out
+}
+
+func (s *fakeSession) RawCommand() string {
+	return ""
+}
+
+func (s *fakeSession) Pty() (ssh.Pty, <-chan ssh.Window, bool) {
+	return ssh.Pty{
+		Term:   "xterm",
+		Window: ssh.Window{Width: 100, Height: 30},
+	}, s.winCh, true
+}
+
+func TestRecordSessions(t *testing.T) {
+	type upload struct {
+		id, recording string
+	}
+	uploads := make(chan upload, 1)
+
+	s := &Service{
+		finishSessionRecording: func(ctx context.Context, sessionRecordingID, recording string) error {
+			uploads <- upload{sessionRecordingID, recording}
+			return nil
+		},
+	}
+
+	session := &fakeSession{
+		winCh: make(chan ssh.Window, 1),
+	}
+	session.winCh <- ssh.Window{Width: 120, Height: 40}
+	close(session.winCh)
+
+	handler := s.recordSessions("rec_1", func(ctx context.Context) func(ssh.Session) {
+		return func(session ssh.Session) {
+			_, winCh, _ := session.Pty()
+			<-winCh
+
+			io.WriteString(session, "out")
+			io.WriteString(session.Stderr(), "err")
+		}
+	})
+	handler(context.Background())(session)
+
+	require.Equal(t, "outerr", session.out.String())
+
+	u := <-uploads
+	require.Equal(t, "rec_1", u.id)
+
+	lines := strings.Split(strings.TrimSpace(u.recording), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[0], `{"version":2,"width":100,"height":30,`))
+	require.True(t, strings.HasSuffix(lines[1], `,"r","120x40"]`))
+	require.True(t, strings.HasSuffix(lines[2], `,"o","out"]`))
+	require.True(t, strings.HasSuffix(lines[3], `,"o","err"]`))
+}
@@ -1,7 +1,7 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -9,14 +9,13 @@ import (
 	"net/http"
 	"sync"
 
-	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/agent/ingest"
 	"github.com/deviceplane/deviceplane/pkg/agent/metrics"
 	"github.com/deviceplane/deviceplane/pkg/agent/netns"
 	"github.com/deviceplane/deviceplane/pkg/agent/supervisor"
 	"github.com/deviceplane/deviceplane/pkg/agent/variables"
 	"github.com/deviceplane/deviceplane/pkg/engine"
-	"github.com/deviceplane/deviceplane/pkg/metrics/datadog/filtering"
-	"github.com/deviceplane/deviceplane/pkg/utils"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/gliderlabs/ssh"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -28,17 +27,28 @@ import (
 type Service struct {
 	variables        variables.Interface
 	supervisorLookup supervisor.Lookup
+	engine           engine.Engine
 	confDir          string
 	netnsManager     *netns.Manager
 	router           *mux.Router
 
+	finishSessionRecording func(ctx context.Context, sessionRecordingID, recording string) error
+	performPowerAction     func(ctx context.Context, powerAction models.PowerAction, perform func() error) error
+
 	signer     ssh.Signer
 	signerLock sync.Mutex
+
+	controllerSSHKeys        []gossh.PublicKey
+	enforceControllerSSHKeys bool
+	controllerSSHKeysLock    sync.RWMutex
 }
 
 func NewService(
 	variables variables.Interface, supervisorLookup supervisor.Lookup,
 	engine engine.Engine, confDir string,
+	hostMetrics, metricsBuffer http.Handler, ingestedMetrics *ingest.Store, metricsConfig *metrics.Config,
+	finishSessionRecording func(ctx context.Context, sessionRecordingID, recording string) error,
+	performPowerAction func(ctx context.Context, powerAction models.PowerAction, perform func() error) error,
 ) *Service {
 	netnsManager := netns.NewManager(engine)
 	netnsManager.Start()
@@ -46,17 +56,40 @@ func NewService(
 	s := &Service{
 		variables:        variables,
 		supervisorLookup: supervisorLookup,
+		engine:           engine,
 		confDir:          confDir,
 		netnsManager:     netnsManager,
 		router:           mux.NewRouter(),
+
+		finishSessionRecording: finishSessionRecording,
+		performPowerAction:     performPowerAction,
 	}
 	go s.getSigner()
 
 	s.router.HandleFunc("/ssh", s.ssh).Methods("POST")
 	s.router.HandleFunc("/reboot", s.reboot).Methods("POST")
+	s.router.HandleFunc("/shutdown", s.shutdown).Methods("POST")
 	s.router.HandleFunc("/applications/{application}/services/{service}/imagepullprogress", s.imagePullProgress).Methods("GET")
-	s.router.HandleFunc("/applications/{application}/services/{service}/metrics", s.metrics).Methods("GET")
-	s.router.Handle("/metrics/host", newHostMetricsHandler())
+	s.router.Handle("/applications/{application}/services/{service}/metrics", metricsConfig.Filter(http.HandlerFunc(s.metrics))).Methods("GET")
+	s.router.HandleFunc("/applications/{application}/services/{service}/stats", s.stats).Methods("GET")
+	s.router.HandleFunc("/applications/{application}/services/{service}/logs", s.logs).Methods("GET")
+	s.router.HandleFunc("/applications/{application}/services/{service}/exec", s.exec).Methods("POST")
+	s.router.HandleFunc("/applications/{application}/services/{service}/portforward", s.portForward).Methods("POST")
+	s.router.HandleFunc("/portforward", s.portForward).Methods("POST")
+	s.router.HandleFunc("/files", s.downloadFile).Methods("GET")
+	s.router.HandleFunc("/files", s.uploadFile).Methods("PUT")
+	s.router.HandleFunc("/filebrowser/roots", s.fileBrowserRoots).Methods("GET")
+	s.router.HandleFunc("/filebrowser/stat", s.statBrowsedFile).Methods("GET")
+	s.router.HandleFunc("/filebrowser/list", s.listBrowsedFiles).Methods("GET")
+	s.router.HandleFunc("/filebrowser/read", s.readBrowsedFile).Methods("GET")
+	s.router.HandleFunc("/filebrowser/write", s.writeBrowsedFile).Methods("PUT")
+	s.router.HandleFunc("/hostcommands", s.listHostCommands).Methods("GET")
+	s.router.HandleFunc("/hostcommands/{hostcommand}", s.runHostCommand).Methods("POST")
+	s.router.Handle("/metrics/host", metricsConfig.Filter(hostMetrics))
+	s.router.Handle("/metrics/host/buffered", metricsConfig.Filter(metricsBuffer)).Methods("GET")
+	s.router.Handle("/metrics/host/buffered", metricsBuffer).Methods("DELETE")
+	s.router.Handle("/metrics/ingested", metricsConfig.Filter(ingestedMetrics))
+	s.router.HandleFunc("/v1/metrics", ingestedMetrics.HandleOTLP).Methods("POST")
 	s.router.Handle("/metrics/agent", promhttp.Handler())
 
 	s.router.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
@@ -109,34 +142,3 @@ func (s *Service) getSigner() (ssh.Signer, error) {
 
 	return s.signer, nil
 }
-
-func newHostMetricsHandler() http.Handler {
-	var hostMetricsHandler http.Handler
-	unfilteredHostMetricsHandler, err := metrics.HostMetricsHandler(nil)
-	if err == nil { // Proxy handler response and filter node prefix
-		hostMetricsHandler = http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			var buf bytes.Buffer
-			rwHttp := utils.ResponseWriter{
-				Headers: w.Header(),
-				Writer:  &buf,
-			}
-
-			(*unfilteredHostMetricsHandler).ServeHTTP(&rwHttp, r)
-
-			w.WriteHeader(rwHttp.Status)
-			if rwHttp.Status == http.StatusOK {
-				rawHostMetricsString := buf.String()
-				filteredHostMetrics := filtering.FilterNodePrefix(rawHostMetricsString)
-				w.Write([]byte(filteredHostMetrics))
-			} else {
-				w.Write(buf.Bytes())
-			}
-		}))
-	} else {
-		log.WithError(err).Error("create host metrics handler")
-		hostMetricsHandler = http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "host metrics are not working, check agent logs for details", http.StatusInternalServerError)
-		}))
-	}
-	return hostMetricsHandler
-}
@@ -3,25 +3,16 @@ package service
 import (
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"os/exec"
-	"syscall"
 	"time"
-	"unsafe"
 
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/server/conncontext"
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/gliderlabs/ssh"
-	"github.com/kr/pty"
 	"github.com/pkg/errors"
-)
-
-const (
-	// Simple script to start a preferred shell
-	// On Debian and Ubuntu /bin/sh links to dash, whereas bash is what's actually preferred
-	// This is fairly hacky and there's likely a better approach to determining the preferred shell
-	entrypoint = `if [ "$(readlink /bin/sh)" = "dash" ] && [ -f "/bin/bash" ]; then exec /bin/bash; else exec /bin/sh; fi`
+	gossh "golang.org/x/crypto/ssh"
 )
 
 func (s *Service) ssh(w http.ResponseWriter, r *http.Request) {
@@ -30,6 +21,17 @@ func (s *Service) ssh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	handler := sshServerHandler
+	if sessionRecordingID := r.URL.Query().Get(models.SessionRecordingQueryParam); sessionRecordingID != "" {
+		handler = s.recordSessions(sessionRecordingID, handler)
+	}
+
+	s.serveSSH(w, r, s.variables.GetDisableSSH, handler)
+}
+
+// serveSSH runs an SSH server over the connection r came in on. Sessions
+// are cancelled as soon as disabled returns true.
+func (s *Service) serveSSH(w http.ResponseWriter, r *http.Request, disabled func() bool, handler func(context.Context) func(ssh.Session)) {
 	conn := conncontext.GetConn(r)
 
 	ctx, cancel := context.WithCancel(r.Context())
@@ -42,7 +44,7 @@ func (s *Service) ssh(w http.ResponseWriter, r *http.Request) {
 		for {
 			select {
 			case <-ticker.C:
-				if s.variables.GetDisableSSH() {
+				if disabled() {
 					cancel()
 					return
 				}
@@ -59,17 +61,17 @@ func (s *Service) ssh(w http.ResponseWriter, r *http.Request) {
 	}
 
 	sshServer := &ssh.Server{
-		Handler:         sshServerHandler(ctx),
+		Handler:         handler(ctx),
 		RequestHandlers: ssh.DefaultRequestHandlers,
 		ChannelHandlers: ssh.DefaultChannelHandlers,
 		HostSigners:     []ssh.Signer{signer},
 	}
 
 	var options []ssh.Option
-	if len(s.variables.GetAuthorizedSSHKeys()) > 0 {
+	if controllerKeys, enforce := s.getControllerSSHKeys(); enforce || len(s.variables.GetAuthorizedSSHKeys()) > 0 {
 		options = []ssh.Option{
 			ssh.PublicKeyAuth(func(ctx ssh.Context, key ssh.PublicKey) bool {
-				for _, authorizedKey := range s.variables.GetAuthorizedSSHKeys() {
+				for _, authorizedKey := range append(s.variables.GetAuthorizedSSHKeys(), controllerKeys...) {
 					if ssh.KeysEqual(key, authorizedKey) {
 						return true
 					}
@@ -88,17 +90,38 @@ func (s *Service) ssh(w http.ResponseWriter, r *http.Request) {
 	sshServer.HandleConn(conn)
 }
 
+// SetControllerSSHKeys updates the keys managed by the controller. Keys
+// that fail to parse are skipped so that one bad key doesn't lock everyone
+// out.
+func (s *Service) SetControllerSSHKeys(sshKeys models.BundledSSHKeys) {
+	var publicKeys []gossh.PublicKey
+	for _, authorizedKey := range sshKeys.AuthorizedKeys {
+		publicKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(authorizedKey))
+		if err != nil {
+			log.WithError(err).Error("parse controller SSH key")
+			continue
+		}
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	s.controllerSSHKeysLock.Lock()
+	s.controllerSSHKeys = publicKeys
+	s.enforceControllerSSHKeys = sshKeys.Enforce
+	s.controllerSSHKeysLock.Unlock()
+}
+
+func (s *Service) getControllerSSHKeys() ([]gossh.PublicKey, bool) {
+	s.controllerSSHKeysLock.RLock()
+	defer s.controllerSSHKeysLock.RUnlock()
+	return s.controllerSSHKeys, s.enforceControllerSSHKeys
+}
+
 func sshServerHandler(ctx context.Context) func(s ssh.Session) {
 	return func(s ssh.Session) {
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		innerCommand := s.RawCommand()
-		if innerCommand == "" {
-			innerCommand = entrypoint
-		}
-
-		command := []string{"/bin/sh", "-c", innerCommand}
+		command := shellCommand(s.RawCommand())
 
 		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 
@@ -106,25 +129,10 @@ func sshServerHandler(ctx context.Context) func(s ssh.Session) {
 		if isPty {
 			cmd.Env = append(cmd.Env, fmt.Sprintf("TERM=%s", ptyReq.Term))
 
-			f, err := pty.Start(cmd)
-			if err != nil {
+			if err := runWithPTY(cmd, s, winCh); err != nil {
 				log.WithError(err).Error("start PTY")
 				return
 			}
-
-			go func() {
-				for win := range winCh {
-					syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCSWINSZ),
-						uintptr(unsafe.Pointer(&struct {
-							h, w, x, y uint16
-						}{
-							uint16(win.Height), uint16(win.Width), 0, 0,
-						})))
-				}
-			}()
-
-			go io.Copy(f, s)
-			io.Copy(s, f)
 		} else {
 			cmd.Stdout = s
 			cmd.Stderr = s
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestSetControllerSSHKeys(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	sshPublicKey, err := gossh.NewPublicKey(publicKey)
+	require.NoError(t, err)
+
+	s := &Service{}
+
+	keys, enforce := s.getControllerSSHKeys()
+	require.Empty(t, keys)
+	require.False(t, enforce)
+
+	s.SetControllerSSHKeys(models.BundledSSHKeys{
+		Enforce: true,
+		AuthorizedKeys: []string{
+			string(gossh.MarshalAuthorizedKey(sshPublicKey)),
+			"ssh-rsa not-a-key",
+		},
+	})
+
+	keys, enforce = s.getControllerSSHKeys()
+	require.True(t, enforce)
+	require.Len(t, keys, 1)
+	require.Equal(t, sshPublicKey.Marshal(), keys[0].Marshal())
+
+	s.SetControllerSSHKeys(models.BundledSSHKeys{})
+
+	keys, enforce = s.getControllerSSHKeys()
+	require.Empty(t, keys)
+	require.False(t, enforce)
+}
@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import (
+	"io"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/kr/pty"
+)
+
+const (
+	// Simple script to start a preferred shell
+	// On Debian and Ubuntu /bin/sh links to dash, whereas bash is what's actually preferred
+	// This is fairly hacky and there's likely a better approach to determining the preferred shell
+	entrypoint = `if [ "$(readlink /bin/sh)" = "dash" ] && [ -f "/bin/bash" ]; then exec /bin/bash; else exec /bin/sh; fi`
+)
+
+func shellCommand(innerCommand string) []string {
+	if innerCommand == "" {
+		innerCommand = entrypoint
+	}
+	return []string{"/bin/sh", "-c", innerCommand}
+}
+
+func runWithPTY(cmd *exec.Cmd, s ssh.Session, winCh <-chan ssh.Window) error {
+	f, err := pty.Start(cmd)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for win := range winCh {
+			syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCSWINSZ),
+				uintptr(unsafe.Pointer(&struct {
+					h, w, x, y uint16
+				}{
+					uint16(win.Height), uint16(win.Width), 0, 0,
+				})))
+		}
+	}()
+
+	go io.Copy(f, s)
+	io.Copy(s, f)
+	return nil
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/gliderlabs/ssh"
+)
+
+func shellCommand(innerCommand string) []string {
+	if innerCommand == "" {
+		return []string{"powershell.exe", "-NoLogo"}
+	}
+	return []string{"cmd.exe", "/c", innerCommand}
+}
+
+// Windows has no PTYs, so interactive sessions are served over plain pipes.
+// Window size changes are ignored.
+func runWithPTY(cmd *exec.Cmd, s ssh.Session, winCh <-chan ssh.Window) error {
+	go func() {
+		for range winCh {
+		}
+	}()
+
+	// Child processes on Windows need the system environment to start
+	cmd.Env = append(os.Environ(), cmd.Env...)
+
+	cmd.Stdin = s
+	cmd.Stdout = s
+	cmd.Stderr = s
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	cmd.Wait()
+	return nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/gorilla/mux"
+)
+
+// stats streams newline delimited JSON samples until the client goes away
+// or the container stops.
+func (s *Service) stats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	applicationID := vars["application"]
+	service := vars["service"]
+
+	containerID, ok := s.supervisorLookup.GetContainerID(applicationID, service)
+	if !ok {
+		w.WriteHeader(codes.StatusStatsNotAvailable)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	started := false
+
+	err := s.engine.StreamContainerStats(r.Context(), containerID, func(stats engine.Stats) error {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if err := encoder.Encode(stats); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		http.Error(w, err.Error(), codes.StatusStatsNotAvailable)
+	}
+}
@@ -18,11 +18,14 @@ type ApplicationSupervisor struct {
 	variables     variables.Interface
 	reporter      *Reporter
 	validators    []validator.Validator
+	networks      *applicationNetworks
 
 	serviceNames            map[string]struct{}
+	networkNames            map[string]struct{}
 	serviceSupervisors      map[string]*ServiceSupervisor
 	serviceSupervisorGCDone chan struct{}
 	containerGCDone         chan struct{}
+	networkGCDone           chan struct{}
 
 	once     sync.Once
 	lock     sync.RWMutex
@@ -45,11 +48,14 @@ func NewApplicationSupervisor(
 		variables:     variables,
 		reporter:      reporter,
 		validators:    validators,
+		networks:      newApplicationNetworks(applicationID, engine),
 
 		serviceNames:            make(map[string]struct{}),
+		networkNames:            make(map[string]struct{}),
 		serviceSupervisors:      make(map[string]*ServiceSupervisor),
 		serviceSupervisorGCDone: make(chan struct{}),
 		containerGCDone:         make(chan struct{}),
+		networkGCDone:           make(chan struct{}),
 
 		ctx:    ctx,
 		cancel: cancel,
@@ -69,6 +75,12 @@ func (s *ApplicationSupervisor) SetApplication(application models.FullBundledApp
 
 	s.reporter.SetDesiredApplication(application.LatestRelease.ID, application.LatestRelease.Config)
 
+	// Update the networks in use before any service gets the chance to
+	// create new ones so that they aren't garbage collected
+	s.lock.Lock()
+	s.networkNames = networkNames(application.LatestRelease.Config)
+	s.lock.Unlock()
+
 	serviceNames := make(map[string]struct{})
 	for serviceName, service := range application.LatestRelease.Config {
 		s.lock.Lock()
@@ -81,6 +93,7 @@ func (s *ApplicationSupervisor) SetApplication(application models.FullBundledApp
 				s.variables,
 				s.reporter,
 				s.validators,
+				s.networks,
 			)
 			s.serviceSupervisors[serviceName] = serviceSupervisor
 		}
@@ -98,6 +111,7 @@ func (s *ApplicationSupervisor) SetApplication(application models.FullBundledApp
 	s.once.Do(func() {
 		go s.serviceSupervisorGC()
 		go s.containerGC()
+		go s.networkGC()
 	})
 }
 
@@ -108,7 +122,7 @@ func (s *ApplicationSupervisor) Stop() {
 	s.cancel()
 
 	wg := &sync.WaitGroup{}
-	wg.Add(len(s.serviceSupervisors) + 3)
+	wg.Add(len(s.serviceSupervisors) + 4)
 
 	go func() {
 		s.reporter.Stop()
@@ -122,6 +136,10 @@ func (s *ApplicationSupervisor) Stop() {
 		<-s.containerGCDone
 		wg.Done()
 	}()
+	go func() {
+		<-s.networkGCDone
+		wg.Done()
+	}()
 	for _, serviceSupervisor := range s.serviceSupervisors {
 		go func(serviceSupervisor *ServiceSupervisor) {
 			serviceSupervisor.Stop()
@@ -204,3 +222,40 @@ func (s *ApplicationSupervisor) containerGC() {
 		}
 	}
 }
+
+func (s *ApplicationSupervisor) networkGC() {
+	ticker := time.NewTicker(defaultTickerFrequency)
+	defer ticker.Stop()
+
+	for {
+		networks, err := utils.NetworkList(s.ctx, s.engine, map[string]struct{}{
+			models.NetworkLabel: struct{}{},
+		}, map[string]string{
+			models.ApplicationLabel: s.applicationID,
+		})
+		if err != nil {
+			goto cont
+		}
+
+		s.lock.RLock()
+		for _, network := range networks {
+			networkName := network.Labels[models.NetworkLabel]
+			if _, ok := s.networkNames[networkName]; !ok {
+				// Networks can't be removed while containers are still
+				// attached to them, in which case this is retried on the
+				// next tick
+				go s.engine.RemoveNetwork(s.ctx, network.ID)
+			}
+		}
+		s.lock.RUnlock()
+
+	cont:
+		select {
+		case <-s.ctx.Done():
+			s.networkGCDone <- struct{}{}
+			return
+		case <-ticker.C:
+			continue
+		}
+	}
+}
@@ -54,6 +54,11 @@ func newImagePuller(
 }
 
 func (p *imagePuller) Pull(ctx context.Context, image string) error {
+	// Services that aren't containers, such as systemd units, have no image
+	if image == "" {
+		return nil
+	}
+
 	p.currentlyPulling.Store(true)
 	defer p.currentlyPulling.Store(false)
 
@@ -79,7 +84,9 @@ func (p *imagePuller) Pull(ctx context.Context, image string) error {
 		}
 	}()
 
-	return agent_utils.ImagePull(ctx, p.engine, image, p.variables.GetRegistryAuth, w)
+	return agent_utils.ImagePull(ctx, p.engine, image, func() string {
+		return variables.RegistryAuthForImage(p.variables, image)
+	}, w)
 }
 
 func (p *imagePuller) Progress() (map[string]PullEvent, bool) {
@@ -1,8 +1,11 @@
 package supervisor
 
+import "github.com/deviceplane/deviceplane/pkg/models"
+
 type Lookup interface {
 	GetContainerID(applicationID string, service string) (string, bool)
 	GetImagePullProgress(applicationID string, service string) (map[string]PullEvent, bool)
+	GetMetricsEndpoints(applicationID string, service string) []models.MetricsEndpoint
 }
 
 var _ Lookup = &Supervisor{}
@@ -33,6 +36,18 @@ func (s *Supervisor) GetImagePullProgress(applicationID, service string) (map[st
 	return progress, ok
 }
 
+// GetMetricsEndpoints returns the metrics endpoints declared by the service's
+// current spec.
+func (s *Supervisor) GetMetricsEndpoints(applicationID, service string) []models.MetricsEndpoint {
+	var endpoints []models.MetricsEndpoint
+	s.withServiceSupervisor(applicationID, service, func(s *ServiceSupervisor) {
+		s.lock.RLock()
+		endpoints = s.service.Metrics
+		s.lock.RUnlock()
+	})
+	return endpoints
+}
+
 func (s *Supervisor) withServiceSupervisor(
 	applicationID, service string,
 	f func(*ServiceSupervisor),
@@ -0,0 +1,161 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/utils"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/hash"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/spec"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
+)
+
+var (
+	errNetworkContainerNotRunning = errors.New("network container is not running")
+)
+
+// applicationNetworks creates the networks of an application as services
+// that are attached to them get created.
+type applicationNetworks struct {
+	applicationID string
+	engine        engine.Engine
+
+	lock sync.Mutex
+}
+
+func newApplicationNetworks(applicationID string, engine engine.Engine) *applicationNetworks {
+	return &applicationNetworks{
+		applicationID: applicationID,
+		engine:        engine,
+	}
+}
+
+// ensure returns the engine name of an application network, creating the
+// network first if it doesn't exist yet.
+func (n *applicationNetworks) ensure(ctx context.Context, name string) (string, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	networks, err := utils.NetworkList(ctx, n.engine, nil, map[string]string{
+		models.ApplicationLabel: n.applicationID,
+		models.NetworkLabel:     name,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(networks) > 0 {
+		return networks[0].Name, nil
+	}
+
+	networkName := strings.Join([]string{name, hash.ShortHash(n.applicationID)}, "-")
+	if _, err := utils.NetworkCreate(ctx, n.engine, networkName, map[string]string{
+		models.ApplicationLabel: n.applicationID,
+		models.NetworkLabel:     name,
+	}); err != nil {
+		return "", err
+	}
+
+	return networkName, nil
+}
+
+func networkNames(config map[string]models.Service) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, service := range config {
+		if service.Networks == nil {
+			continue
+		}
+		for _, network := range service.Networks.Networks {
+			names[network.Name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// resolveNetworks points a service at the engine resources backing the
+// networks it uses, since their names are generated.
+func (s *ServiceSupervisor) resolveNetworks(ctx context.Context, service models.Service) (models.Service, error) {
+	service, err := s.resolveNetworkMode(ctx, service)
+	if err != nil {
+		return service, err
+	}
+
+	if service.Networks == nil {
+		return service, nil
+	}
+
+	networks := &yamltypes.Networks{}
+	for _, network := range service.Networks.Networks {
+		networkName, err := s.networks.ensure(ctx, network.Name)
+		if err != nil {
+			return service, err
+		}
+		networks.Networks = append(networks.Networks, &yamltypes.Network{
+			Name:    networkName,
+			Aliases: network.Aliases,
+		})
+	}
+	service.Networks = networks
+
+	return service, nil
+}
+
+// resolveNetworkMode points services that share the network stack of another
+// service at that service's container, since container names are generated.
+// The container is recorded in a label so that the service can be recreated
+// when the container it depends on is replaced.
+func (s *ServiceSupervisor) resolveNetworkMode(ctx context.Context, service models.Service) (models.Service, error) {
+	target, ok := spec.NetworkContainer(service)
+	if !ok {
+		return service, nil
+	}
+
+	instance, err := s.networkContainer(ctx, target)
+	if err != nil {
+		return service, err
+	}
+
+	labels := make(map[string]string)
+	for k, v := range service.Labels {
+		labels[k] = v
+	}
+	labels[models.NetworkContainerLabel] = instance.ID
+
+	service.NetworkMode = models.NetworkModeContainerPrefix + instance.ID
+	service.Labels = labels
+
+	return service, nil
+}
+
+// networkContainerChanged reports whether the container an instance shares
+// its network stack with has since been replaced.
+func (s *ServiceSupervisor) networkContainerChanged(ctx context.Context, service models.Service, instance engine.Instance) bool {
+	target, ok := spec.NetworkContainer(service)
+	if !ok {
+		return false
+	}
+
+	networkInstance, err := s.networkContainer(ctx, target)
+	if err != nil {
+		return false
+	}
+
+	return instance.Labels[models.NetworkContainerLabel] != networkInstance.ID
+}
+
+func (s *ServiceSupervisor) networkContainer(ctx context.Context, target string) (*engine.Instance, error) {
+	instances, err := utils.ContainerList(ctx, s.engine, nil, map[string]string{
+		models.ApplicationLabel: s.applicationID,
+		models.ServiceLabel:     target,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, errNetworkContainerNotRunning
+	}
+	return &instances[0], nil
+}
@@ -25,6 +25,7 @@ type ServiceSupervisor struct {
 	engine        engine.Engine
 	reporter      *Reporter
 	validators    []validator.Validator
+	networks      *applicationNetworks
 
 	imagePuller *imagePuller
 
@@ -51,6 +52,7 @@ func NewServiceSupervisor(
 	variables variables.Interface,
 	reporter *Reporter,
 	validators []validator.Validator,
+	networks *applicationNetworks,
 ) *ServiceSupervisor {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &ServiceSupervisor{
@@ -59,6 +61,7 @@ func NewServiceSupervisor(
 		engine:        engine,
 		reporter:      reporter,
 		validators:    validators,
+		networks:      networks,
 
 		imagePuller: newImagePuller(applicationID, serviceName, engine, variables),
 
@@ -104,6 +107,8 @@ func (s *ServiceSupervisor) reconcileLoop() {
 
 		ctx, cancel := context.WithCancel(s.ctx)
 
+		var resolvedService models.Service
+
 		startCanceler := func() {
 			go func() {
 				ticker := time.NewTicker(defaultTickerFrequency)
@@ -136,7 +141,8 @@ func (s *ServiceSupervisor) reconcileLoop() {
 			// TODO: filter down to just one instance if we find more
 			instance := instances[0]
 
-			if hashLabel, ok := instance.Labels[models.HashLabel]; ok && hashLabel == spec.Hash(service, s.serviceName) {
+			if hashLabel, ok := instance.Labels[models.HashLabel]; ok && hashLabel == spec.Hash(service, s.serviceName) &&
+				!s.networkContainerChanged(ctx, service, instance) {
 				s.sendKeepAliveService(service)
 				s.sendKeepAliveRelease(release)
 				goto cont
@@ -173,11 +179,19 @@ func (s *ServiceSupervisor) reconcileLoop() {
 			}
 		}
 
+		resolvedService, err = s.resolveNetworks(ctx, spec.WithStandardLabels(service, s.applicationID, s.serviceName))
+		if err != nil {
+			log.WithField("service", s.serviceName).
+				WithError(err).
+				Error("resolve networks")
+			goto cont
+		}
+
 		if _, err = utils.ContainerCreate(
 			ctx,
 			s.engine,
 			strings.Join([]string{s.serviceName, hash.ShortHash(s.applicationID), spec.ShortHash(service, s.serviceName)}, "-"),
-			spec.WithStandardLabels(service, s.applicationID, s.serviceName),
+			resolvedService,
 		); err != nil {
 			goto cont
 		}
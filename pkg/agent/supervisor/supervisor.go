@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/utils"
 	"github.com/deviceplane/deviceplane/pkg/agent/validator"
 	"github.com/deviceplane/deviceplane/pkg/agent/variables"
@@ -21,6 +22,7 @@ type Supervisor struct {
 
 	applicationIDs         map[string]struct{}
 	applicationSupervisors map[string]*ApplicationSupervisor
+	shutdown               bool
 	once                   sync.Once
 
 	lock   sync.RWMutex
@@ -52,6 +54,13 @@ func NewSupervisor(
 }
 
 func (s *Supervisor) SetApplications(applications []models.FullBundledApplication) {
+	s.lock.RLock()
+	shutdown := s.shutdown
+	s.lock.RUnlock()
+	if shutdown {
+		return
+	}
+
 	applicationIDs := make(map[string]struct{})
 	for _, application := range applications {
 		s.lock.Lock()
@@ -80,9 +89,126 @@ func (s *Supervisor) SetApplications(applications []models.FullBundledApplicatio
 	s.once.Do(func() {
 		go s.applicationSupervisorGC()
 		go s.containerGC()
+		go s.networkGC()
 	})
 }
 
+// Shutdown stops supervising applications and then gracefully stops their
+// containers, so that they aren't killed abruptly when the device powers
+// off. Applications are ignored until Resume is called.
+func (s *Supervisor) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	s.shutdown = true
+	// Dangling application supervisors are left to applicationSupervisorGC
+	var applicationSupervisors []*ApplicationSupervisor
+	for applicationID := range s.applicationIDs {
+		if applicationSupervisor, ok := s.applicationSupervisors[applicationID]; ok {
+			applicationSupervisors = append(applicationSupervisors, applicationSupervisor)
+		}
+	}
+	s.lock.Unlock()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(applicationSupervisors))
+	for _, applicationSupervisor := range applicationSupervisors {
+		go func(applicationSupervisor *ApplicationSupervisor) {
+			applicationSupervisor.Stop()
+			wg.Done()
+		}(applicationSupervisor)
+	}
+	wg.Wait()
+
+	instances, err := utils.ContainerList(ctx, s.engine, map[string]struct{}{
+		models.ApplicationLabel: struct{}{},
+	}, nil, false)
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, len(instances))
+	for _, instance := range instances {
+		go func(instanceID string) {
+			errs <- utils.ContainerStop(ctx, s.engine, instanceID)
+		}(instance.ID)
+	}
+	for range instances {
+		if stopErr := <-errs; stopErr != nil {
+			err = stopErr
+		}
+	}
+	return err
+}
+
+// RemoveAll stops supervising applications and removes their containers and
+// networks, such as when the device is decommissioned.
+func (s *Supervisor) RemoveAll(ctx context.Context) error {
+	if err := s.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	instances, err := utils.ContainerList(ctx, s.engine, map[string]struct{}{
+		models.ApplicationLabel: struct{}{},
+	}, nil, true)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if err := utils.ContainerRemove(ctx, s.engine, instance.ID); err != nil {
+			return err
+		}
+	}
+
+	networks, err := utils.NetworkList(ctx, s.engine, map[string]struct{}{
+		models.ApplicationLabel: struct{}{},
+		models.NetworkLabel:     struct{}{},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if err := s.engine.RemoveNetwork(ctx, network.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resume undoes Shutdown. Applications are supervised again from the next
+// call to SetApplications.
+func (s *Supervisor) Resume() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.shutdown {
+		return
+	}
+	s.shutdown = false
+	s.applicationIDs = make(map[string]struct{})
+	s.applicationSupervisors = make(map[string]*ApplicationSupervisor)
+}
+
+// RestartService restarts a service's container in the background, such as
+// after a config file it depends on changed. Services that aren't running
+// are left alone.
+func (s *Supervisor) RestartService(applicationID, service string) {
+	containerID, ok := s.GetContainerID(applicationID, service)
+	if !ok {
+		return
+	}
+
+	go func() {
+		logger := log.WithField("application", applicationID).WithField("service", service)
+		if err := utils.ContainerStop(s.ctx, s.engine, containerID); err != nil {
+			logger.WithError(err).Error("stop service to restart it")
+			return
+		}
+		if err := utils.ContainerStart(s.ctx, s.engine, containerID); err != nil {
+			logger.WithError(err).Error("start restarted service")
+		}
+	}()
+}
+
 func (s *Supervisor) applicationSupervisorGC() {
 	ticker := time.NewTicker(defaultTickerFrequency)
 	defer ticker.Stop()
@@ -147,3 +273,35 @@ func (s *Supervisor) containerGC() {
 		}
 	}
 }
+
+func (s *Supervisor) networkGC() {
+	ticker := time.NewTicker(defaultTickerFrequency)
+	defer ticker.Stop()
+
+	for {
+		networks, err := utils.NetworkList(s.ctx, s.engine, map[string]struct{}{
+			models.ApplicationLabel: struct{}{},
+			models.NetworkLabel:     struct{}{},
+		}, nil)
+		if err != nil {
+			goto cont
+		}
+
+		s.lock.RLock()
+		for _, network := range networks {
+			applicationID := network.Labels[models.ApplicationLabel]
+			if _, ok := s.applicationSupervisors[applicationID]; !ok {
+				// Removal fails until the containerGC has removed every
+				// container attached to the network
+				go s.engine.RemoveNetwork(s.ctx, network.ID)
+			}
+		}
+		s.lock.RUnlock()
+
+	cont:
+		select {
+		case <-ticker.C:
+			continue
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package supervisor
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEngine struct {
+	engine.Engine
+
+	lock      sync.Mutex
+	instances map[string]engine.Instance
+	calls     []string
+}
+
+func (f *fakeEngine) CreateContainer(ctx context.Context, name string, s models.Service) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, "create "+name)
+	f.instances[name] = engine.Instance{ID: name, Labels: s.Labels}
+	return name, nil
+}
+
+func (f *fakeEngine) StartContainer(ctx context.Context, id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, "start "+id)
+	instance := f.instances[id]
+	instance.Running = true
+	f.instances[id] = instance
+	return nil
+}
+
+func (f *fakeEngine) ListContainers(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string, all bool) ([]engine.Instance, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var instances []engine.Instance
+	for _, instance := range f.instances {
+		if (all || instance.Running) && engine.MatchesFilters(instance.Labels, keyFilters, keyAndValueFilters) {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, nil
+}
+
+func (f *fakeEngine) StopContainer(ctx context.Context, id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, "stop "+id)
+	return nil
+}
+
+func (f *fakeEngine) RemoveContainer(ctx context.Context, id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, "remove "+id)
+	delete(f.instances, id)
+	return nil
+}
+
+func (f *fakeEngine) PullImage(ctx context.Context, image, registryAuth string, w io.Writer) error {
+	return nil
+}
+
+func (f *fakeEngine) ListNetworks(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string) ([]engine.Network, error) {
+	return nil, nil
+}
+
+type fakeVariables struct {
+	variables.Interface
+}
+
+func (f *fakeVariables) GetRegistryAuth() string {
+	return ""
+}
+
+func (f *fakeVariables) GetRegistryCredentials() map[string]string {
+	return nil
+}
+
+func TestSupervisorAdoptsContainersAcrossUpdate(t *testing.T) {
+	// A container started by the previous version of the agent. Its hash
+	// label is pinned, since services are only recreated when it changes.
+	e := &fakeEngine{
+		instances: map[string]engine.Instance{
+			"web-container": {
+				ID: "web-container",
+				Labels: map[string]string{
+					models.ApplicationLabel: "app_1",
+					models.ServiceLabel:     "web",
+					models.HashLabel:        "254aaee73f9f833276fedbe73846ec0578200a25d518cb451c9ebd23ea153a02",
+				},
+				Running: true,
+			},
+		},
+	}
+
+	reported := make(chan string, 10)
+	s := NewSupervisor(e, &fakeVariables{},
+		func(ctx context.Context, applicationID, currentReleaseID string) error {
+			return nil
+		},
+		func(ctx context.Context, applicationID, service, currentReleaseID string) error {
+			reported <- service + " " + currentReleaseID
+			return nil
+		},
+		nil,
+	)
+
+	// The updated agent starts with the saved bundle
+	s.SetApplications([]models.FullBundledApplication{{
+		Application: models.BundledApplication{ID: "app_1"},
+		LatestRelease: models.Release{
+			ID: "rel_1",
+			Config: map[string]models.Service{
+				"web": {Image: "nginx"},
+			},
+		},
+	}})
+
+	select {
+	case status := <-reported:
+		require.Equal(t, "web rel_1", status)
+	case <-time.After(3 * defaultTickerFrequency):
+		t.Fatal("service status wasn't reported")
+	}
+
+	containerID, ok := s.GetContainerID("app_1", "web")
+	require.True(t, ok)
+	require.Equal(t, "web-container", containerID)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	require.Empty(t, e.calls)
+}
@@ -0,0 +1,46 @@
+package thermal
+
+// Zone is a thermal zone and its temperature in degrees Celsius.
+type Zone struct {
+	Name        string
+	Type        string
+	Temperature float64
+}
+
+// Throttling is the throttling state reported by the Raspberry Pi firmware.
+// Each condition is reported both for right now and for whether it has
+// happened at any point since boot.
+type Throttling struct {
+	UnderVoltage         bool
+	FrequencyCapped      bool
+	Throttled            bool
+	SoftTemperatureLimit bool
+
+	UnderVoltageOccurred         bool
+	FrequencyCappedOccurred      bool
+	ThrottledOccurred            bool
+	SoftTemperatureLimitOccurred bool
+}
+
+// Bits of the firmware's get_throttled value
+const (
+	underVoltageBit = 1 << iota
+	frequencyCappedBit
+	throttledBit
+	softTemperatureLimitBit
+
+	occurredShift = 16
+)
+
+func parseThrottling(value uint64) Throttling {
+	return Throttling{
+		UnderVoltage:                 value&underVoltageBit != 0,
+		FrequencyCapped:              value&frequencyCappedBit != 0,
+		Throttled:                    value&throttledBit != 0,
+		SoftTemperatureLimit:         value&softTemperatureLimitBit != 0,
+		UnderVoltageOccurred:         value&(underVoltageBit<<occurredShift) != 0,
+		FrequencyCappedOccurred:      value&(frequencyCappedBit<<occurredShift) != 0,
+		ThrottledOccurred:            value&(throttledBit<<occurredShift) != 0,
+		SoftTemperatureLimitOccurred: value&(softTemperatureLimitBit<<occurredShift) != 0,
+	}
+}
@@ -0,0 +1,124 @@
+package thermal
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	thermalPath = "/sys/class/thermal"
+
+	// Newer Raspberry Pi kernels expose the firmware's throttling state in
+	// sysfs. Older ones only have vcgencmd.
+	getThrottledPath = "/sys/devices/platform/soc/soc:firmware/get_throttled"
+
+	vcgencmdTimeout = 5 * time.Second
+)
+
+// Zones reads the temperature of every thermal zone.
+func Zones() ([]Zone, error) {
+	paths, err := filepath.Glob(filepath.Join(thermalPath, "thermal_zone*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var zones []Zone
+	for _, path := range paths {
+		zoneType, err := readString(filepath.Join(path, "type"))
+		if err != nil {
+			continue
+		}
+		// Disabled zones fail to read
+		temp, err := readInt(filepath.Join(path, "temp"))
+		if err != nil {
+			continue
+		}
+
+		zones = append(zones, Zone{
+			Name:        strings.TrimPrefix(filepath.Base(path), "thermal_zone"),
+			Type:        zoneType,
+			Temperature: float64(temp) / 1000,
+		})
+	}
+
+	return zones, nil
+}
+
+// CoolingThrottled returns true if a CPU cooling device is currently
+// lowering the CPU's frequency. This works on boards such as the Jetson that
+// throttle through the kernel's thermal framework.
+func CoolingThrottled() (bool, error) {
+	paths, err := filepath.Glob(filepath.Join(thermalPath, "cooling_device*"))
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range paths {
+		deviceType, err := readString(filepath.Join(path, "type"))
+		if err != nil || !strings.Contains(deviceType, "cpufreq") {
+			continue
+		}
+		state, err := readInt(filepath.Join(path, "cur_state"))
+		if err == nil && state > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// ReadThrottling reads the Raspberry Pi firmware's throttling state. It
+// returns nil if the device doesn't report one.
+func ReadThrottling() (*Throttling, error) {
+	value, err := readString(getThrottledPath)
+	if os.IsNotExist(err) {
+		if _, err := exec.LookPath("vcgencmd"); err != nil {
+			return nil, nil
+		}
+		value, err = vcgencmdGetThrottled()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	throttled, err := strconv.ParseUint(strings.TrimPrefix(value, "0x"), 16, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	throttling := parseThrottling(throttled)
+	return &throttling, nil
+}
+
+func vcgencmdGetThrottled() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), vcgencmdTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "vcgencmd", "get_throttled").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(output)), "throttled="), nil
+}
+
+func readString(path string) (string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+func readInt(path string) (int64, error) {
+	value, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package thermal
+
+// Zones isn't supported on this platform.
+func Zones() ([]Zone, error) {
+	return nil, nil
+}
+
+// CoolingThrottled isn't supported on this platform.
+func CoolingThrottled() (bool, error) {
+	return false, nil
+}
+
+// ReadThrottling isn't supported on this platform.
+func ReadThrottling() (*Throttling, error) {
+	return nil, nil
+}
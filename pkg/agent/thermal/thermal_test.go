@@ -0,0 +1,16 @@
+package thermal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThrottling(t *testing.T) {
+	require.Equal(t, Throttling{}, parseThrottling(0))
+	require.Equal(t, Throttling{
+		Throttled:            true,
+		UnderVoltageOccurred: true,
+		ThrottledOccurred:    true,
+	}, parseThrottling(0x50004))
+}
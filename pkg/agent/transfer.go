@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apex/log"
+)
+
+const (
+	transferredFilename = "transferred"
+
+	// Guards against transfer markers that point at each other
+	maxTransfers = 100
+)
+
+// ResolveProject returns the project that the device's agent is in. When a
+// device is transferred its agent's state is moved to the new project's
+// directory and a marker pointing at it is left behind, so that agents
+// configured with the original project keep working.
+func ResolveProject(stateDir, projectID string) string {
+	for i := 0; i < maxTransfers; i++ {
+		contents, err := ioutil.ReadFile(filepath.Join(stateDir, projectID, transferredFilename))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.WithError(err).Error("read transferred marker")
+			}
+			return projectID
+		}
+
+		next := strings.TrimSpace(string(contents))
+		if next == "" {
+			return projectID
+		}
+		projectID = next
+	}
+	return projectID
+}
+
+// transfer tells the controller that the device is ready to move to
+// another project and then moves the agent's state there. The agent exits
+// afterwards so that it's restarted in the new project. Failures are
+// retried with the next bundle.
+func (a *Agent) transfer(projectID string) {
+	// A saved bundle from before the agent restarted
+	if projectID == a.projectID {
+		return
+	}
+
+	logger := log.WithField("target_project_id", projectID)
+	logger.Info("transferring device")
+
+	if err := a.client.FinishTransfer(context.TODO()); err != nil {
+		logger.WithError(err).Error("finish transfer")
+		return
+	}
+
+	// Anything already there is left over from an earlier transfer out of
+	// that project
+	target := filepath.Join(a.stateDir, projectID)
+	if err := os.RemoveAll(target); err != nil {
+		logger.WithError(err).Error("remove previous state")
+	}
+	if err := os.Rename(a.fileLocation(), target); err != nil {
+		logger.WithError(err).Error("move state")
+		return
+	}
+	if err := a.writeFile([]byte(projectID), transferredFilename); err != nil {
+		logger.WithError(err).Error("save transferred marker")
+	}
+
+	logger.Info("device transferred")
+	os.Exit(0)
+}
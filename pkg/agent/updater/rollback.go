@@ -0,0 +1,188 @@
+package updater
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/file"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	pendingUpdateFilename = "pending-update"
+	failedUpdateFilename  = "failed-update"
+
+	previousSuffix = ".previous"
+	failedSuffix   = ".failed"
+
+	// An updated agent that isn't healthy within this long is rolled back
+	healthTimeout = 10 * time.Minute
+
+	// An updated agent that's started this many times without becoming
+	// healthy is rolled back, in case it crashes before the health timeout
+	maxStartAttempts = 3
+
+	// A version that was rolled back isn't updated to again for this long.
+	// Updates can fail because of problems with the device's network rather
+	// than with the version, so it's eventually retried.
+	retryFailedUpdateAfter = 6 * time.Hour
+)
+
+// pendingUpdate is saved before the agent replaces its binary and removed
+// once the new version is healthy.
+type pendingUpdate struct {
+	PreviousVersion string `json:"previousVersion"`
+	Version         string `json:"version"`
+	StartAttempts   int    `json:"startAttempts"`
+}
+
+// RecordStart counts a start of an agent that was just updated, and rolls
+// the update back if it's been started too many times without becoming
+// healthy. It's called before the agent does anything that can fail, so
+// that an update that crashes while starting up is rolled back too.
+func (u *Updater) RecordStart() {
+	update, ok := u.currentUpdate()
+	if !ok {
+		return
+	}
+
+	update.StartAttempts++
+	if update.StartAttempts > maxStartAttempts {
+		u.rollback(update, "agent restarted too many times")
+		return
+	}
+	if err := u.savePendingUpdate(update); err != nil {
+		log.WithError(err).Error("save pending update")
+	}
+}
+
+// Watch checks that an agent that was just updated becomes healthy. If it
+// doesn't within the health timeout, the previous binary is restored and
+// the agent exits so that it's restarted with the previous version. It
+// returns once the update is known to have succeeded or there is no update
+// in progress.
+func (u *Updater) Watch(healthy func() bool) {
+	update, ok := u.currentUpdate()
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	timeout := time.After(healthTimeout)
+
+	for {
+		if healthy() {
+			log.WithField("version", u.version).Info("agent update succeeded")
+			u.removePendingUpdate()
+			return
+		}
+
+		select {
+		case <-ticker.C:
+			continue
+		case <-timeout:
+			u.rollback(update, "agent didn't become healthy")
+			return
+		}
+	}
+}
+
+// currentUpdate returns the update to this agent's version that's in
+// progress, if there is one.
+func (u *Updater) currentUpdate() (pendingUpdate, bool) {
+	var update pendingUpdate
+	if err := u.readState(pendingUpdateFilename, &update); os.IsNotExist(err) {
+		return update, false
+	} else if err != nil {
+		log.WithError(err).Error("read pending update")
+		u.removePendingUpdate()
+		return update, false
+	}
+
+	// The binary was never replaced or was already rolled back
+	if update.Version != u.version {
+		u.removePendingUpdate()
+		return update, false
+	}
+
+	return update, true
+}
+
+func (u *Updater) rollback(update pendingUpdate, reason string) {
+	logger := log.WithField("version", update.Version).WithField("previous_version", update.PreviousVersion)
+	logger.WithField("reason", reason).Error("rolling back agent update")
+
+	previousPath := u.binaryPath + previousSuffix
+	if _, err := os.Stat(previousPath); err != nil {
+		logger.WithError(err).Error("find previous agent binary")
+		u.removePendingUpdate()
+		return
+	}
+
+	if err := u.writeState(failedUpdateFilename, models.AgentUpdateFailure{
+		Version: update.Version,
+		Reason:  reason,
+		Time:    time.Now().UTC().Truncate(time.Second),
+	}); err != nil {
+		logger.WithError(err).Error("save failed update")
+	}
+
+	if err := replaceBinary(previousPath, u.binaryPath, u.binaryPath+failedSuffix); err != nil {
+		logger.WithError(err).Error("restore previous agent binary")
+		return
+	}
+	u.removePendingUpdate()
+
+	os.Exit(0)
+}
+
+// recentlyFailed returns whether an update to version was rolled back
+// recently.
+func (u *Updater) recentlyFailed(version string) bool {
+	update := u.LastFailedUpdate()
+	return update.Version == version && time.Since(update.Time) < retryFailedUpdateAfter
+}
+
+// LastFailedUpdate returns the last update that was rolled back, so that
+// it can be reported to the controller.
+func (u *Updater) LastFailedUpdate() models.AgentUpdateFailure {
+	var update models.AgentUpdateFailure
+	if err := u.readState(failedUpdateFilename, &update); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Error("read failed update")
+	}
+	return update
+}
+
+func (u *Updater) savePendingUpdate(update pendingUpdate) error {
+	return u.writeState(pendingUpdateFilename, update)
+}
+
+func (u *Updater) removePendingUpdate() {
+	if err := os.Remove(filepath.Join(u.stateDir, pendingUpdateFilename)); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Error("remove pending update")
+	}
+}
+
+func (u *Updater) readState(filename string, v interface{}) error {
+	contents, err := ioutil.ReadFile(filepath.Join(u.stateDir, filename))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(contents, v)
+}
+
+func (u *Updater) writeState(filename string, v interface{}) error {
+	contents, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(u.stateDir, 0700); err != nil {
+		return err
+	}
+	return file.WriteFileAtomic(filepath.Join(u.stateDir, filename), contents, 0644)
+}
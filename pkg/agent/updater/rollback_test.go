@@ -0,0 +1,138 @@
+package updater
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	u := NewUpdater("project", "1.1.0", filepath.Join(dir, binaryName), dir, nil)
+
+	require.NoError(t, u.savePendingUpdate(pendingUpdate{
+		PreviousVersion: "1.0.0",
+		Version:         "1.1.0",
+	}))
+	u.Watch(func() bool {
+		return true
+	})
+
+	// The update is done once the new version is healthy
+	_, err = os.Stat(filepath.Join(dir, pendingUpdateFilename))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, u.savePendingUpdate(pendingUpdate{
+		PreviousVersion: "1.1.0",
+		Version:         "1.2.0",
+	}))
+	u.Watch(func() bool {
+		return false
+	})
+
+	// The binary was never replaced, so there's nothing to watch
+	_, err = os.Stat(filepath.Join(dir, pendingUpdateFilename))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRecordStart(t *testing.T) {
+	if dir := os.Getenv("UPDATER_TEST_AGENT_DIR"); dir != "" {
+		// An agent that exits before it's running, e.g. from a log.Fatal
+		NewUpdater("project", "1.1.0", filepath.Join(dir, binaryName), dir, nil).RecordStart()
+		os.Exit(1)
+	}
+
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	binaryPath := filepath.Join(dir, binaryName)
+	require.NoError(t, ioutil.WriteFile(binaryPath, []byte("new"), 0755))
+	require.NoError(t, ioutil.WriteFile(binaryPath+previousSuffix, []byte("old"), 0755))
+
+	u := NewUpdater("project", "1.1.0", binaryPath, dir, nil)
+	require.NoError(t, u.savePendingUpdate(pendingUpdate{
+		PreviousVersion: "1.0.0",
+		Version:         "1.1.0",
+	}))
+
+	start := func() error {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestRecordStart$")
+		cmd.Env = append(os.Environ(), "UPDATER_TEST_AGENT_DIR="+dir)
+		return cmd.Run()
+	}
+
+	for i := 0; i < maxStartAttempts; i++ {
+		require.Error(t, start())
+
+		contents, err := ioutil.ReadFile(binaryPath)
+		require.NoError(t, err)
+		require.Equal(t, "new", string(contents))
+	}
+
+	// Rolling back exits the agent too, so only its effects are checked
+	start()
+
+	contents, err := ioutil.ReadFile(binaryPath)
+	require.NoError(t, err)
+	require.Equal(t, "old", string(contents))
+
+	_, err = os.Stat(filepath.Join(dir, pendingUpdateFilename))
+	require.True(t, os.IsNotExist(err))
+
+	failure := u.LastFailedUpdate()
+	require.Equal(t, "1.1.0", failure.Version)
+	require.Equal(t, "agent restarted too many times", failure.Reason)
+}
+
+func TestRecentlyFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	u := NewUpdater("project", "1.0.0", filepath.Join(dir, binaryName), dir, nil)
+	require.False(t, u.recentlyFailed("1.1.0"))
+
+	require.NoError(t, u.writeState(failedUpdateFilename, models.AgentUpdateFailure{
+		Version: "1.1.0",
+		Time:    time.Now(),
+	}))
+	require.True(t, u.recentlyFailed("1.1.0"))
+	require.False(t, u.recentlyFailed("1.2.0"))
+
+	require.NoError(t, u.writeState(failedUpdateFilename, models.AgentUpdateFailure{
+		Version: "1.1.0",
+		Time:    time.Now().Add(-retryFailedUpdateAfter),
+	}))
+	require.False(t, u.recentlyFailed("1.1.0"))
+}
+
+func TestReplaceBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "updater")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src, dst, previous := filepath.Join(dir, "src"), filepath.Join(dir, "dst"), filepath.Join(dir, "previous")
+	require.NoError(t, ioutil.WriteFile(src, []byte("new"), 0755))
+	require.NoError(t, ioutil.WriteFile(dst, []byte("current"), 0755))
+	require.NoError(t, ioutil.WriteFile(previous, []byte("old"), 0755))
+
+	require.NoError(t, replaceBinary(src, dst, previous))
+
+	contents, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	require.Equal(t, "new", string(contents))
+
+	contents, err = ioutil.ReadFile(previous)
+	require.NoError(t, err)
+	require.Equal(t, "current", string(contents))
+}
@@ -1,44 +1,60 @@
 package updater
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/pkg/errors"
 )
 
 const (
-	location = "https://downloads.deviceplane.com/agent/%s/linux/%s/deviceplane-agent"
+	location = "https://downloads.deviceplane.com/agent/%s/%s/%s/%s"
 )
 
 type Updater struct {
 	projectID  string
 	version    string
 	binaryPath string
+	stateDir   string
+	variables  variables.Interface
 
 	desiredVersion string
+	desiredSpec    string
 	once           sync.Once
 	lock           sync.RWMutex
 }
 
-func NewUpdater(projectID, version, binaryPath string) *Updater {
+// NewUpdater returns an updater for the agent binary at binaryPath. The
+// state of in progress updates is kept in stateDir so that failed updates
+// can be rolled back.
+func NewUpdater(projectID, version, binaryPath, stateDir string, variables variables.Interface) *Updater {
 	return &Updater{
 		projectID:  projectID,
 		version:    version,
 		binaryPath: binaryPath,
+		stateDir:   stateDir,
+		variables:  variables,
 	}
 }
 
-func (u *Updater) SetDesiredVersion(desiredVersion string) {
+// SetDesiredVersion sets the agent version to update to. The desired spec
+// is the JSON encoded models.AgentSpec the new binary is verified against.
+func (u *Updater) SetDesiredVersion(desiredVersion, desiredSpec string) {
 	u.lock.Lock()
 	u.desiredVersion = desiredVersion
+	u.desiredSpec = desiredSpec
 	u.lock.Unlock()
 
 	u.once.Do(func() {
@@ -53,10 +69,11 @@ func (u *Updater) updater() {
 	for {
 		u.lock.RLock()
 		desiredVersion := u.desiredVersion
+		desiredSpec := u.desiredSpec
 		u.lock.RUnlock()
 
-		if desiredVersion != "" && desiredVersion != u.version {
-			if err := u.update(desiredVersion); err != nil {
+		if desiredVersion != "" && desiredVersion != u.version && !u.recentlyFailed(desiredVersion) {
+			if err := u.update(desiredVersion, desiredSpec); err != nil {
 				log.WithError(err).Error("update agent")
 				goto cont
 			}
@@ -67,42 +84,89 @@ func (u *Updater) updater() {
 	}
 }
 
-func (u *Updater) update(desiredVersion string) error {
-	resp, err := http.Get(fmt.Sprintf(location, desiredVersion, runtime.GOARCH))
+func (u *Updater) update(desiredVersion, desiredSpec string) error {
+	var spec models.AgentSpec
+	if desiredSpec != "" {
+		if err := json.Unmarshal([]byte(desiredSpec), &spec); err != nil {
+			return errors.Wrap(err, "parse agent spec")
+		}
+	}
+
+	if err := checkVersion(u.version, desiredVersion, spec); err != nil {
+		return err
+	}
+
+	resp, err := http.Get(fmt.Sprintf(location, desiredVersion, runtime.GOOS, runtime.GOARCH, binaryName))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download agent: %s", resp.Status)
+	}
 
-	f, err := ioutil.TempFile("", "")
+	// Created next to the binary so that it can be renamed into place
+	f, err := ioutil.TempFile(filepath.Dir(u.binaryPath), "."+binaryName)
 	if err != nil {
 		return err
 	}
 	defer os.Remove(f.Name())
 
+	digest := sha256.New()
+
 	for _, action := range []func() error{
 		func() error {
-			_, err := io.Copy(f, resp.Body)
+			_, err := io.Copy(io.MultiWriter(f, digest), resp.Body)
 			return err
 		},
 		func() error {
 			return f.Close()
 		},
+		func() error {
+			return verify(desiredVersion, digest.Sum(nil), spec, u.variables.GetAgentUpdatePublicKey())
+		},
 		func() error {
 			return os.Chmod(f.Name(), 0755)
 		},
 		func() error {
-			return syscall.Unlink(u.binaryPath)
+			return u.savePendingUpdate(pendingUpdate{
+				PreviousVersion: u.version,
+				Version:         desiredVersion,
+			})
 		},
 		func() error {
-			return os.Rename(f.Name(), u.binaryPath)
+			return replaceBinary(f.Name(), u.binaryPath, u.binaryPath+previousSuffix)
 		},
 	} {
 		if err = action(); err != nil {
+			u.removePendingUpdate()
 			return err
 		}
 	}
 
+	// There's no handoff between the old and new agent processes. Services
+	// keep running through the restart, since containers aren't stopped
+	// when the agent exits and the new agent adopts those whose spec hash
+	// matches the saved bundle. Remote sessions through the controller are
+	// dropped and have to reconnect.
+	log.WithField("version", desiredVersion).Info("restarting to update agent")
 	os.Exit(0)
 	return nil
 }
+
+// replaceBinary moves the binary at dst to previous and then moves src to
+// dst. A running executable can't be deleted on Windows, but it can be
+// renamed, so this works on every platform.
+func replaceBinary(src, dst, previous string) error {
+	if err := os.Remove(previous); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(dst, previous); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		os.Rename(previous, dst)
+		return err
+	}
+	return nil
+}
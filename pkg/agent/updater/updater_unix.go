@@ -0,0 +1,8 @@
+//go:build !windows
+// +build !windows
+
+package updater
+
+const (
+	binaryName = "deviceplane-agent"
+)
@@ -0,0 +1,5 @@
+package updater
+
+const (
+	binaryName = "deviceplane-agent.exe"
+)
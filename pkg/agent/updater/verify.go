@@ -0,0 +1,73 @@
+package updater
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ed25519"
+)
+
+var (
+	errDigestMismatch   = errors.New("agent binary doesn't match its digest")
+	errInvalidSignature = errors.New("agent binary's signature is invalid")
+	errDowngrade        = errors.New("agent version is older than the running one and the spec doesn't allow rolling back")
+)
+
+// checkVersion refuses to update to a version older than the running one,
+// unless the spec allows rolling back to it. Otherwise an old binary with
+// known problems could be installed using its still valid signature.
+func checkVersion(version, desiredVersion string, spec models.AgentSpec) error {
+	if models.CompareVersions(desiredVersion, version) < 0 && !spec.Rollback {
+		return errDowngrade
+	}
+	return nil
+}
+
+// verify checks the digest of a downloaded agent binary against the spec
+// from the bundle. Agents configured with an update public key only accept
+// binaries whose version, platform and digest are signed with the matching
+// private key. Without one, binaries that the spec has no digest for are
+// accepted so that projects that don't publish digests can still update.
+func verify(version string, digest []byte, spec models.AgentSpec, publicKey string) error {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	expectedDigest, ok := spec.Digests[platform]
+	if !ok {
+		if publicKey != "" {
+			return fmt.Errorf("agent spec has no digest for %s", platform)
+		}
+		return nil
+	}
+	if !strings.EqualFold(expectedDigest, hex.EncodeToString(digest)) {
+		return errDigestMismatch
+	}
+
+	if publicKey == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("agent update public key isn't a base64 encoded Ed25519 public key")
+	}
+
+	encodedSignature, ok := spec.Signatures[platform]
+	if !ok {
+		return fmt.Errorf("agent spec has no signature for %s", platform)
+	}
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return errors.Wrap(err, "decode signature")
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), spec.SignedMessage(version, platform), signature) {
+		return errInvalidSignature
+	}
+
+	return nil
+}
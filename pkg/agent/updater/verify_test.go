@@ -0,0 +1,77 @@
+package updater
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"runtime"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestVerify(t *testing.T) {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	digest := sha256.Sum256([]byte("agent"))
+	otherDigest := sha256.Sum256([]byte("tampered agent"))
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	encodedPublicKey := base64.StdEncoding.EncodeToString(publicKey)
+
+	spec := models.AgentSpec{
+		Digests: map[string]string{
+			platform: hex.EncodeToString(digest[:]),
+		},
+	}
+	spec.Signatures = map[string]string{
+		platform: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, spec.SignedMessage("1.2.0", platform))),
+	}
+
+	// Without a public key only the digest is checked
+	require.NoError(t, verify("1.2.0", digest[:], models.AgentSpec{}, ""))
+	require.NoError(t, verify("1.2.0", digest[:], spec, ""))
+	require.Equal(t, errDigestMismatch, verify("1.2.0", otherDigest[:], spec, ""))
+
+	require.NoError(t, verify("1.2.0", digest[:], spec, encodedPublicKey))
+	require.Error(t, verify("1.2.0", digest[:], models.AgentSpec{}, encodedPublicKey))
+	require.Error(t, verify("1.2.0", digest[:], models.AgentSpec{Digests: spec.Digests}, encodedPublicKey))
+
+	otherPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.Equal(t, errInvalidSignature, verify("1.2.0", digest[:], spec, base64.StdEncoding.EncodeToString(otherPublicKey)))
+
+	// The signature only covers the version it was made for
+	require.Equal(t, errInvalidSignature, verify("1.1.0", digest[:], spec, encodedPublicKey))
+
+	// Or the version being rolled back to, if it's allowed
+	rollbackSpec := spec
+	rollbackSpec.Rollback = true
+	require.Equal(t, errInvalidSignature, verify("1.2.0", digest[:], rollbackSpec, encodedPublicKey))
+	rollbackSpec.Signatures = map[string]string{
+		platform: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, rollbackSpec.SignedMessage("1.2.0", platform))),
+	}
+	require.NoError(t, verify("1.2.0", digest[:], rollbackSpec, encodedPublicKey))
+}
+
+func TestSignedMessage(t *testing.T) {
+	spec := models.AgentSpec{
+		Digests: map[string]string{
+			"linux/arm": "ABCDEF",
+		},
+	}
+	require.Equal(t, "deviceplane-agent\n1.2.0\nlinux/arm\nabcdef\n", string(spec.SignedMessage("1.2.0", "linux/arm")))
+
+	spec.Rollback = true
+	require.Equal(t, "deviceplane-agent\n1.2.0\nlinux/arm\nabcdef\nrollback\n", string(spec.SignedMessage("1.2.0", "linux/arm")))
+}
+
+func TestCheckVersion(t *testing.T) {
+	require.NoError(t, checkVersion("1.2.0", "1.3.0", models.AgentSpec{}))
+	require.NoError(t, checkVersion("1.2.0", "1.10.0", models.AgentSpec{}))
+	require.Equal(t, errDowngrade, checkVersion("1.2.0", "1.1.0", models.AgentSpec{}))
+	require.NoError(t, checkVersion("1.2.0", "1.1.0", models.AgentSpec{Rollback: true}))
+}
@@ -82,3 +82,35 @@ func ImagePull(ctx context.Context, eng engine.Engine, image string, getRegistry
 		return nil
 	}, time.Hour)
 }
+
+func NetworkCreate(ctx context.Context, eng engine.Engine, name string, labels map[string]string) (string, error) {
+	var id string
+
+	err := Retry(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = eng.CreateNetwork(ctx, name, labels)
+		if err != nil {
+			log.WithError(err).Error("create network")
+			return err
+		}
+		return nil
+	}, 2*time.Minute)
+
+	return id, err
+}
+
+func NetworkList(ctx context.Context, eng engine.Engine, keyFilters map[string]struct{}, keyAndValueFilters map[string]string) ([]engine.Network, error) {
+	var networks []engine.Network
+
+	err := Retry(ctx, func(ctx context.Context) error {
+		var err error
+		networks, err = eng.ListNetworks(ctx, keyFilters, keyAndValueFilters)
+		if err != nil {
+			log.WithError(err).Error("list networks")
+			return err
+		}
+		return nil
+	}, 2*time.Minute)
+
+	return networks, err
+}
@@ -0,0 +1,39 @@
+package fsnotify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var labelKeyRegex = regexp.MustCompile("^[a-zA-Z0-9-]+$")
+
+// parseLabelsFile reads one "key=value" label per line. Blank lines and
+// lines starting with # are skipped. Keys and values follow the same rules
+// as labels set through the controller.
+func parseLabelsFile(in []byte) (map[string]string, error) {
+	labels := make(map[string]string)
+	for i, line := range strings.Split(string(in), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d isn't a key=value pair", i+1)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if len(key) > 100 || !labelKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d has an invalid label key", i+1)
+		}
+		if len(value) == 0 || len(value) > 100 {
+			return nil, fmt.Errorf("line %d has an invalid label value", i+1)
+		}
+
+		labels[key] = value
+	}
+	return labels, nil
+}
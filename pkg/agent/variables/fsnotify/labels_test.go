@@ -0,0 +1,32 @@
+package fsnotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelsFile(t *testing.T) {
+	labels, err := parseLabelsFile([]byte(`
+# Stamped during provisioning
+site=warehouse-3
+rack = b12
+
+customer=acme=west
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"site":     "warehouse-3",
+		"rack":     "b12",
+		"customer": "acme=west",
+	}, labels)
+
+	_, err = parseLabelsFile([]byte("site"))
+	require.Error(t, err)
+
+	_, err = parseLabelsFile([]byte("site name=warehouse-3"))
+	require.Error(t, err)
+
+	_, err = parseLabelsFile([]byte("site="))
+	require.Error(t, err)
+}
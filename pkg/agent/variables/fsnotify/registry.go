@@ -0,0 +1,53 @@
+package fsnotify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/variables"
+)
+
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth     string `json:"auth"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// parseRegistryCredentialsFile parses a Docker config file into the
+// registry auth for each registry, in the same format as the registry auth
+// variable.
+func parseRegistryCredentialsFile(in []byte) (map[string]string, error) {
+	var config dockerConfig
+	if err := json.Unmarshal(in, &config); err != nil {
+		return nil, err
+	}
+
+	credentials := make(map[string]string)
+	for server, auth := range config.Auths {
+		registry := variables.NormalizeRegistry(server)
+		if registry == "" {
+			return nil, fmt.Errorf("invalid registry %q", server)
+		}
+
+		switch {
+		case auth.Username != "":
+			credentials[registry] = base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		case auth.Auth != "":
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil || !strings.Contains(string(decoded), ":") {
+				return nil, fmt.Errorf("invalid auth for registry %s", server)
+			}
+			credentials[registry] = auth.Auth
+		default:
+			return nil, fmt.Errorf("no credentials for registry %s", server)
+		}
+	}
+
+	return credentials, nil
+}
@@ -0,0 +1,35 @@
+package fsnotify
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistryCredentialsFile(t *testing.T) {
+	credentials, err := parseRegistryCredentialsFile([]byte(`{
+		"auths": {
+			"https://index.docker.io/v1/": {
+				"auth": "dXNlcjpwYXNzd29yZA=="
+			},
+			"registry.example.com": {
+				"username": "user",
+				"password": "password"
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"docker.io":            "dXNlcjpwYXNzd29yZA==",
+		"registry.example.com": "dXNlcjpwYXNzd29yZA==",
+	}, credentials)
+
+	_, err = parseRegistryCredentialsFile([]byte(`{"auths": {"quay.io": {"auth": "not base64"}}}`))
+	require.Error(t, err)
+
+	_, err = parseRegistryCredentialsFile([]byte(`{"auths": {"quay.io": {}}}`))
+	require.Error(t, err)
+
+	_, err = parseRegistryCredentialsFile([]byte(`{"auths": `))
+	require.Error(t, err)
+}
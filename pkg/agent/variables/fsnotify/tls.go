@@ -0,0 +1,40 @@
+package fsnotify
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+var (
+	errConnectorClientCertificateIncomplete = errors.New("connector client certificate and key must both be set")
+	errNoCertificates                       = errors.New("no certificates found")
+)
+
+func parseCertificatesFile(in []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	rest := in
+
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, errNoCertificates
+	}
+
+	return certificates, nil
+}
@@ -1,9 +1,11 @@
 package fsnotify
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -11,6 +13,7 @@ import (
 	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/variables"
 	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -26,10 +29,37 @@ type Variables struct {
 	hostSignerKeySet         bool
 	registryAuth             string
 	registryAuthSet          bool
+	registryCredentials      map[string]string
+	registryCredentialsSet   bool
 	whitelistedImages        []string
 	whitelistedImagesSet     bool
 	disableCustomCommands    bool
 	disableCustomCommandsSet bool
+	disableExec              bool
+	disableExecSet           bool
+	disableFileTransfer      bool
+	disableFileTransferSet   bool
+	hostCommands             map[string]string
+	hostCommandsSet          bool
+	fileBrowserPaths         []variables.FileBrowserPath
+	fileBrowserPathsSet      bool
+	geolocationSources       []variables.GeolocationSource
+	geolocationSourcesSet    bool
+	hostMetricsInterval      time.Duration
+	hostMetricsIntervalSet   bool
+	hostMetricsCollectors    []string
+	hostMetricsCollectorsSet bool
+	agentUpdatePublicKey     string
+	agentUpdatePublicKeySet  bool
+	labels                   map[string]string
+	labelsSet                bool
+
+	connectorClientCertificate        *tls.Certificate
+	connectorClientCertificateSet     bool
+	connectorControllerCertificates   []*x509.Certificate
+	connectorControllerCertificateSet bool
+
+	errors map[string]string
 }
 
 func NewVariables(dir string) *Variables {
@@ -67,22 +97,40 @@ func (v *Variables) Start() error {
 }
 
 func (v *Variables) refresh() {
-	for _, refresher := range []func() error{
-		v.refreshDisableSSH,
-		v.refreshAuthorizedSSHKeys,
-		v.refreshHostSignerKey,
-		v.refreshRegistryAuth,
-		v.refreshWhitelistedImages,
-		v.refreshDisableCustomCommands,
+	refreshErrors := make(map[string]string)
+	for variable, refresher := range map[string]func() error{
+		variables.DisableSSH:              v.refreshDisableSSH,
+		variables.AuthorizedSSHKeys:       v.refreshAuthorizedSSHKeys,
+		variables.HostSignerKey:           v.refreshHostSignerKey,
+		variables.RegistryAuth:            v.refreshRegistryAuth,
+		variables.RegistryCredentials:     v.refreshRegistryCredentials,
+		variables.WhitelistedImages:       v.refreshWhitelistedImages,
+		variables.DisableCustomCommands:   v.refreshDisableCustomCommands,
+		variables.DisableExec:             v.refreshDisableExec,
+		variables.DisableFileTransfer:     v.refreshDisableFileTransfer,
+		variables.HostCommands:            v.refreshHostCommands,
+		variables.FileBrowserPaths:        v.refreshFileBrowserPaths,
+		variables.Geolocation:             v.refreshGeolocationSources,
+		variables.HostMetricsInterval:     v.refreshHostMetricsInterval,
+		variables.HostMetricsCollectors:   v.refreshHostMetricsCollectors,
+		variables.AgentUpdatePublicKey:    v.refreshAgentUpdatePublicKey,
+		variables.Labels:                  v.refreshLabels,
+		variables.ConnectorClientCert:     v.refreshConnectorClientCertificate,
+		variables.ConnectorControllerCert: v.refreshConnectorControllerCertificates,
 	} {
 		if err := refresher(); err != nil {
-			log.WithError(err).Error("variables refresh")
+			log.WithField("variable", variable).WithError(err).Error("variables refresh")
+			refreshErrors[variable] = err.Error()
 		}
 	}
+
+	v.lock.Lock()
+	v.errors = refreshErrors
+	v.lock.Unlock()
 }
 
 func (v *Variables) refreshDisableSSH() error {
-	_, err := os.Stat(path.Join(v.dir, variables.DisableSSH))
+	_, err := os.Stat(filepath.Join(v.dir, variables.DisableSSH))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -101,7 +149,7 @@ func (v *Variables) refreshDisableSSH() error {
 }
 
 func (v *Variables) refreshAuthorizedSSHKeys() error {
-	bytes, err := ioutil.ReadFile(path.Join(v.dir, variables.AuthorizedSSHKeys))
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.AuthorizedSSHKeys))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -124,7 +172,7 @@ func (v *Variables) refreshAuthorizedSSHKeys() error {
 }
 
 func (v *Variables) refreshHostSignerKey() error {
-	bytes, err := ioutil.ReadFile(path.Join(v.dir, variables.HostSignerKey))
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.HostSignerKey))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -143,7 +191,7 @@ func (v *Variables) refreshHostSignerKey() error {
 }
 
 func (v *Variables) refreshRegistryAuth() error {
-	bytes, err := ioutil.ReadFile(path.Join(v.dir, variables.RegistryAuth))
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.RegistryAuth))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -161,8 +209,37 @@ func (v *Variables) refreshRegistryAuth() error {
 	return nil
 }
 
+func (v *Variables) refreshRegistryCredentials() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.RegistryCredentials))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		registryCredentials, err := parseRegistryCredentialsFile(bytes)
+		if err != nil {
+			// Keep the last valid credentials so that a file that's being
+			// edited doesn't break pulls
+			if !v.registryCredentialsSet {
+				v.registryCredentials = make(map[string]string)
+				v.registryCredentialsSet = true
+			}
+			return errors.Wrap(err, "parse registry credentials")
+		}
+		v.registryCredentials = registryCredentials
+		v.registryCredentialsSet = true
+	} else if os.IsNotExist(err) {
+		v.registryCredentials = make(map[string]string)
+		v.registryCredentialsSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
 func (v *Variables) refreshWhitelistedImages() error {
-	bytes, err := ioutil.ReadFile(path.Join(v.dir, variables.WhitelistedImages))
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.WhitelistedImages))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -189,7 +266,7 @@ func (v *Variables) refreshWhitelistedImages() error {
 }
 
 func (v *Variables) refreshDisableCustomCommands() error {
-	_, err := os.Stat(path.Join(v.dir, variables.DisableCustomCommands))
+	_, err := os.Stat(filepath.Join(v.dir, variables.DisableCustomCommands))
 
 	v.lock.Lock()
 	defer v.lock.Unlock()
@@ -207,6 +284,321 @@ func (v *Variables) refreshDisableCustomCommands() error {
 	return nil
 }
 
+func (v *Variables) refreshDisableExec() error {
+	_, err := os.Stat(filepath.Join(v.dir, variables.DisableExec))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.disableExec = true
+		v.disableExecSet = true
+	} else if os.IsNotExist(err) {
+		v.disableExec = false
+		v.disableExecSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshDisableFileTransfer() error {
+	_, err := os.Stat(filepath.Join(v.dir, variables.DisableFileTransfer))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.disableFileTransfer = true
+		v.disableFileTransferSet = true
+	} else if os.IsNotExist(err) {
+		v.disableFileTransfer = false
+		v.disableFileTransferSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+// refreshHostCommands reads one "name: command" pair per line. Blank lines
+// and lines starting with # are skipped.
+func (v *Variables) refreshHostCommands() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.HostCommands))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.hostCommands = map[string]string{}
+		for _, line := range strings.Split(string(bytes), "\n") {
+			line = strings.TrimSpace(line)
+			if len(line) == 0 || strings.HasPrefix(line, "#") {
+				continue
+			}
+
+			i := strings.Index(line, ":")
+			if i == -1 {
+				continue
+			}
+			name := strings.TrimSpace(line[:i])
+			command := strings.TrimSpace(line[i+1:])
+			if len(name) == 0 || len(command) == 0 {
+				continue
+			}
+
+			v.hostCommands[name] = command
+		}
+
+		v.hostCommandsSet = true
+	} else if os.IsNotExist(err) {
+		v.hostCommands = map[string]string{}
+		v.hostCommandsSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+// refreshFileBrowserPaths reads one absolute path per line, optionally
+// followed by "rw" to allow writes under it.
+func (v *Variables) refreshFileBrowserPaths() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.FileBrowserPaths))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.fileBrowserPaths = []variables.FileBrowserPath{}
+		for _, line := range strings.Split(string(bytes), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			if !filepath.IsAbs(fields[0]) {
+				log.WithField("path", fields[0]).Error("ignoring relative file browser path")
+				continue
+			}
+
+			v.fileBrowserPaths = append(v.fileBrowserPaths, variables.FileBrowserPath{
+				Path:     filepath.Clean(fields[0]),
+				Writable: len(fields) > 1 && fields[1] == "rw",
+			})
+		}
+
+		v.fileBrowserPathsSet = true
+	} else if os.IsNotExist(err) {
+		v.fileBrowserPaths = []variables.FileBrowserPath{}
+		v.fileBrowserPathsSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+// refreshGeolocationSources reads one source per line, in the order they
+// should be tried, each optionally followed by an address.
+func (v *Variables) refreshGeolocationSources() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.Geolocation))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.geolocationSources = []variables.GeolocationSource{}
+		for _, line := range strings.Split(string(bytes), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+
+			switch fields[0] {
+			case variables.GeolocationSourceGPSD, variables.GeolocationSourceNMEA,
+				variables.GeolocationSourceModem, variables.GeolocationSourceIP:
+			default:
+				log.WithField("source", fields[0]).Error("ignoring unknown geolocation source")
+				continue
+			}
+			if fields[0] == variables.GeolocationSourceNMEA && len(fields) < 2 {
+				log.Error("ignoring nmea geolocation source without a device")
+				continue
+			}
+
+			source := variables.GeolocationSource{
+				Type: fields[0],
+			}
+			if len(fields) > 1 {
+				source.Address = fields[1]
+			}
+			v.geolocationSources = append(v.geolocationSources, source)
+		}
+
+		v.geolocationSourcesSet = true
+	} else if os.IsNotExist(err) {
+		v.geolocationSources = []variables.GeolocationSource{}
+		v.geolocationSourcesSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+// refreshHostMetricsInterval reads a duration such as "30s". It's zero if
+// the variable isn't set or isn't a valid duration.
+func (v *Variables) refreshHostMetricsInterval() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.HostMetricsInterval))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		interval, err := time.ParseDuration(strings.TrimSpace(string(bytes)))
+		if err != nil {
+			log.WithError(err).Error("invalid host metrics interval")
+			interval = 0
+		}
+		v.hostMetricsInterval = interval
+		v.hostMetricsIntervalSet = true
+	} else if os.IsNotExist(err) {
+		v.hostMetricsInterval = 0
+		v.hostMetricsIntervalSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshHostMetricsCollectors() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.HostMetricsCollectors))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.hostMetricsCollectors = []string{}
+		for _, collector := range strings.Split(string(bytes), "\n") {
+			collector = strings.TrimSpace(collector)
+			if len(collector) != 0 {
+				v.hostMetricsCollectors = append(v.hostMetricsCollectors, collector)
+			}
+		}
+
+		v.hostMetricsCollectorsSet = true
+	} else if os.IsNotExist(err) {
+		v.hostMetricsCollectors = []string{}
+		v.hostMetricsCollectorsSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshAgentUpdatePublicKey() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.AgentUpdatePublicKey))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		v.agentUpdatePublicKey = strings.TrimSpace(string(bytes))
+		v.agentUpdatePublicKeySet = true
+	} else if os.IsNotExist(err) {
+		v.agentUpdatePublicKey = ""
+		v.agentUpdatePublicKeySet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshLabels() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.Labels))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		labels, err := parseLabelsFile(bytes)
+		if err != nil {
+			// Keep the last valid labels so that a mistake in the file
+			// doesn't remove labels from the device
+			if !v.labelsSet {
+				v.labels = make(map[string]string)
+				v.labelsSet = true
+			}
+			return errors.Wrap(err, "parse labels")
+		}
+		v.labels = labels
+		v.labelsSet = true
+	} else if os.IsNotExist(err) {
+		v.labels = make(map[string]string)
+		v.labelsSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshConnectorClientCertificate() error {
+	certBytes, certErr := ioutil.ReadFile(filepath.Join(v.dir, variables.ConnectorClientCert))
+	keyBytes, keyErr := ioutil.ReadFile(filepath.Join(v.dir, variables.ConnectorClientKey))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	switch {
+	case certErr == nil && keyErr == nil:
+		certificate, err := tls.X509KeyPair(certBytes, keyBytes)
+		if err != nil {
+			return err
+		}
+		v.connectorClientCertificate = &certificate
+		v.connectorClientCertificateSet = true
+	case os.IsNotExist(certErr) && os.IsNotExist(keyErr):
+		v.connectorClientCertificate = nil
+		v.connectorClientCertificateSet = true
+	case certErr != nil && !os.IsNotExist(certErr):
+		return certErr
+	case keyErr != nil && !os.IsNotExist(keyErr):
+		return keyErr
+	default:
+		return errConnectorClientCertificateIncomplete
+	}
+
+	return nil
+}
+
+func (v *Variables) refreshConnectorControllerCertificates() error {
+	bytes, err := ioutil.ReadFile(filepath.Join(v.dir, variables.ConnectorControllerCert))
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if err == nil {
+		certificates, err := parseCertificatesFile(bytes)
+		if err != nil {
+			return err
+		}
+		v.connectorControllerCertificates = certificates
+		v.connectorControllerCertificateSet = true
+	} else if os.IsNotExist(err) {
+		v.connectorControllerCertificates = nil
+		v.connectorControllerCertificateSet = true
+	} else {
+		return err
+	}
+
+	return nil
+}
+
 func (v *Variables) GetDisableSSH() bool {
 	v.waitFor(func() bool {
 		return v.disableSSHSet
@@ -235,6 +627,13 @@ func (v *Variables) GetRegistryAuth() string {
 	return v.registryAuth
 }
 
+func (v *Variables) GetRegistryCredentials() map[string]string {
+	v.waitFor(func() bool {
+		return v.registryCredentialsSet
+	})
+	return v.registryCredentials
+}
+
 func (v *Variables) GetWhitelistedImages() []string {
 	v.waitFor(func() bool {
 		return v.whitelistedImagesSet
@@ -249,6 +648,89 @@ func (v *Variables) GetDisableCustomCommands() bool {
 	return v.disableCustomCommands
 }
 
+func (v *Variables) GetDisableExec() bool {
+	v.waitFor(func() bool {
+		return v.disableExecSet
+	})
+	return v.disableExec
+}
+
+func (v *Variables) GetDisableFileTransfer() bool {
+	v.waitFor(func() bool {
+		return v.disableFileTransferSet
+	})
+	return v.disableFileTransfer
+}
+
+func (v *Variables) GetHostCommands() map[string]string {
+	v.waitFor(func() bool {
+		return v.hostCommandsSet
+	})
+	return v.hostCommands
+}
+
+func (v *Variables) GetFileBrowserPaths() []variables.FileBrowserPath {
+	v.waitFor(func() bool {
+		return v.fileBrowserPathsSet
+	})
+	return v.fileBrowserPaths
+}
+
+func (v *Variables) GetGeolocationSources() []variables.GeolocationSource {
+	v.waitFor(func() bool {
+		return v.geolocationSourcesSet
+	})
+	return v.geolocationSources
+}
+
+func (v *Variables) GetHostMetricsInterval() time.Duration {
+	v.waitFor(func() bool {
+		return v.hostMetricsIntervalSet
+	})
+	return v.hostMetricsInterval
+}
+
+func (v *Variables) GetHostMetricsCollectors() []string {
+	v.waitFor(func() bool {
+		return v.hostMetricsCollectorsSet
+	})
+	return v.hostMetricsCollectors
+}
+
+func (v *Variables) GetAgentUpdatePublicKey() string {
+	v.waitFor(func() bool {
+		return v.agentUpdatePublicKeySet
+	})
+	return v.agentUpdatePublicKey
+}
+
+func (v *Variables) GetLabels() map[string]string {
+	v.waitFor(func() bool {
+		return v.labelsSet
+	})
+	return v.labels
+}
+
+func (v *Variables) GetConnectorClientCertificate() *tls.Certificate {
+	v.waitFor(func() bool {
+		return v.connectorClientCertificateSet
+	})
+	return v.connectorClientCertificate
+}
+
+func (v *Variables) GetConnectorControllerCertificates() []*x509.Certificate {
+	v.waitFor(func() bool {
+		return v.connectorControllerCertificateSet
+	})
+	return v.connectorControllerCertificates
+}
+
+func (v *Variables) GetErrors() map[string]string {
+	v.lock.RLock()
+	defer v.lock.RUnlock()
+	return v.errors
+}
+
 func (v *Variables) waitFor(getField func() bool) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
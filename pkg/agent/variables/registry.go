@@ -0,0 +1,35 @@
+package variables
+
+import (
+	"strings"
+
+	canonical_image "github.com/deviceplane/deviceplane/pkg/image"
+)
+
+const dockerHubRegistry = "docker.io"
+
+// RegistryAuthForImage returns the registry auth to pull image with. The
+// credentials for the image's registry are used if there are any, and the
+// registry auth variable otherwise. Pulls should call this on each attempt
+// so that credentials changed while a pull is retrying are picked up.
+func RegistryAuthForImage(v Interface, image string) string {
+	registry := strings.SplitN(canonical_image.ToCanonical(image), "/", 2)[0]
+	if auth, ok := v.GetRegistryCredentials()[registry]; ok {
+		return auth
+	}
+	return v.GetRegistryAuth()
+}
+
+// NormalizeRegistry converts a registry server from a Docker config file,
+// which may be a URL, to the host that appears in image names.
+func NormalizeRegistry(server string) string {
+	server = strings.TrimPrefix(server, "https://")
+	server = strings.TrimPrefix(server, "http://")
+	server = strings.SplitN(server, "/", 2)[0]
+
+	switch server {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return dockerHubRegistry
+	}
+	return server
+}
@@ -0,0 +1,43 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type registryVariables struct {
+	Interface
+	registryAuth        string
+	registryCredentials map[string]string
+}
+
+func (v registryVariables) GetRegistryAuth() string {
+	return v.registryAuth
+}
+
+func (v registryVariables) GetRegistryCredentials() map[string]string {
+	return v.registryCredentials
+}
+
+func TestRegistryAuthForImage(t *testing.T) {
+	v := registryVariables{
+		registryAuth: "default",
+		registryCredentials: map[string]string{
+			"docker.io":            "hub",
+			"registry.example.com": "example",
+		},
+	}
+
+	require.Equal(t, "hub", RegistryAuthForImage(v, "nginx"))
+	require.Equal(t, "hub", RegistryAuthForImage(v, "deviceplane/agent:1.0.0"))
+	require.Equal(t, "example", RegistryAuthForImage(v, "registry.example.com/app/api:latest"))
+	require.Equal(t, "default", RegistryAuthForImage(v, "quay.io/app/api"))
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	require.Equal(t, "docker.io", NormalizeRegistry("https://index.docker.io/v1/"))
+	require.Equal(t, "docker.io", NormalizeRegistry("docker.io"))
+	require.Equal(t, "registry.example.com:5000", NormalizeRegistry("http://registry.example.com:5000/v2/"))
+	require.Equal(t, "quay.io", NormalizeRegistry("quay.io"))
+}
@@ -1,6 +1,10 @@
 package variables
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
 	"golang.org/x/crypto/ssh"
 )
 
@@ -9,8 +13,22 @@ const (
 	AuthorizedSSHKeys     = "authorized-ssh-keys"
 	HostSignerKey         = "host-signer-key"
 	RegistryAuth          = "registry-auth"
+	RegistryCredentials   = "registry-credentials"
 	WhitelistedImages     = "whitelisted-images"
 	DisableCustomCommands = "disable-custom-commands"
+	DisableExec           = "disable-exec"
+	DisableFileTransfer   = "disable-file-transfer"
+	HostCommands          = "host-commands"
+	FileBrowserPaths      = "file-browser-paths"
+	Geolocation           = "geolocation"
+	HostMetricsInterval   = "host-metrics-interval"
+	HostMetricsCollectors = "host-metrics-collectors"
+	AgentUpdatePublicKey  = "agent-update-public-key"
+	Labels                = "labels"
+
+	ConnectorClientCert     = "connector-client-cert"
+	ConnectorClientKey      = "connector-client-key"
+	ConnectorControllerCert = "connector-controller-cert"
 )
 
 type Interface interface {
@@ -18,6 +36,44 @@ type Interface interface {
 	GetAuthorizedSSHKeys() []ssh.PublicKey
 	GetHostSignerKey() string
 	GetRegistryAuth() string
+	GetRegistryCredentials() map[string]string
 	GetWhitelistedImages() []string
 	GetDisableCustomCommands() bool
+	GetDisableExec() bool
+	GetDisableFileTransfer() bool
+	GetHostCommands() map[string]string
+	GetFileBrowserPaths() []FileBrowserPath
+	GetGeolocationSources() []GeolocationSource
+	GetHostMetricsInterval() time.Duration
+	GetHostMetricsCollectors() []string
+	GetAgentUpdatePublicKey() string
+	GetLabels() map[string]string
+	GetConnectorClientCertificate() *tls.Certificate
+	GetConnectorControllerCertificates() []*x509.Certificate
+
+	// GetErrors returns the error from the last time each variable with a
+	// problem was read, keyed by variable
+	GetErrors() map[string]string
+}
+
+// FileBrowserPath is a host directory whose contents can be browsed
+// remotely.
+type FileBrowserPath struct {
+	Path     string
+	Writable bool
+}
+
+const (
+	GeolocationSourceGPSD  = "gpsd"
+	GeolocationSourceNMEA  = "nmea"
+	GeolocationSourceModem = "modem"
+	GeolocationSourceIP    = "ip"
+)
+
+// GeolocationSource is a way of locating the device. Address is the gpsd
+// address, the NMEA serial device, or the IP geolocation URL, and is empty
+// for modems or to use the source's default.
+type GeolocationSource struct {
+	Type    string
+	Address string
 }
@@ -0,0 +1,129 @@
+// Package asciicast records terminal sessions in the asciicast v2 format
+// used by asciinema, so recordings can be replayed with existing players.
+package asciicast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	eventOutput = "o"
+	eventResize = "r"
+)
+
+type header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Command   string            `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder collects output and resize events. Recording stops once limit
+// bytes have been recorded, but the recording stays valid.
+type Recorder struct {
+	start     time.Time
+	limit     int
+	buf       bytes.Buffer
+	pending   []byte
+	truncated bool
+	lock      sync.Mutex
+	now       func() time.Time
+}
+
+func NewRecorder(width, height int, command, term string, limit int) *Recorder {
+	r := &Recorder{
+		limit: limit,
+		now:   time.Now,
+	}
+	r.start = r.now()
+
+	h := header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Command:   command,
+	}
+	if term != "" {
+		h.Env = map[string]string{
+			"TERM": term,
+		}
+	}
+	r.writeLine(h)
+
+	return r
+}
+
+// Output records p as terminal output. Multi-byte characters split across
+// calls are held back until they're complete, since events must be valid
+// UTF-8.
+func (r *Recorder) Output(p []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	data := append(r.pending, p...)
+	n := len(data)
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				n = len(data) - i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[n:]...)
+
+	if n > 0 {
+		r.writeEvent(eventOutput, string(data[:n]))
+	}
+}
+
+func (r *Recorder) Resize(width, height int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.writeEvent(eventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+// Truncated reports whether events were dropped because of the limit.
+func (r *Recorder) Truncated() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.truncated
+}
+
+func (r *Recorder) Bytes() []byte {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]byte(nil), r.buf.Bytes()...)
+}
+
+func (r *Recorder) writeEvent(kind, data string) {
+	if r.truncated {
+		return
+	}
+	elapsed := float64(r.now().Sub(r.start)) / float64(time.Second)
+	r.writeLine([]interface{}{elapsed, kind, data})
+}
+
+func (r *Recorder) writeLine(v interface{}) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if r.limit > 0 && r.buf.Len()+len(line)+1 > r.limit {
+		r.truncated = true
+		return
+	}
+	r.buf.Write(line)
+	r.buf.WriteByte('\n')
+}
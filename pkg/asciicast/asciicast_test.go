@@ -0,0 +1,52 @@
+package asciicast
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	now := start
+
+	r := NewRecorder(80, 24, "/bin/sh", "xterm", 0)
+	r.start = start
+	r.now = func() time.Time {
+		return now
+	}
+
+	now = start.Add(500 * time.Millisecond)
+	r.Output([]byte("hello\r\n"))
+
+	// "é" split across two writes
+	now = start.Add(time.Second)
+	r.Output([]byte{0xc3})
+	r.Output([]byte{0xa9})
+
+	now = start.Add(2 * time.Second)
+	r.Resize(100, 40)
+
+	lines := strings.Split(strings.TrimSpace(string(r.Bytes())), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[0], `{"version":2,"width":80,"height":24,`))
+	require.True(t, strings.HasSuffix(lines[0], `"command":"/bin/sh","env":{"TERM":"xterm"}}`))
+	require.Equal(t, `[0.5,"o","hello\r\n"]`, lines[1])
+	require.Equal(t, `[1,"o","é"]`, lines[2])
+	require.Equal(t, `[2,"r","100x40"]`, lines[3])
+	require.False(t, r.Truncated())
+}
+
+func TestRecorderLimit(t *testing.T) {
+	r := NewRecorder(80, 24, "", "", 100)
+	size := len(r.Bytes())
+
+	r.Output([]byte(strings.Repeat("a", 100)))
+	require.True(t, r.Truncated())
+	require.Len(t, r.Bytes(), size)
+
+	r.Output([]byte("b"))
+	require.Len(t, r.Bytes(), size)
+}
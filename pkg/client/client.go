@@ -1,18 +1,24 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/deviceplane/deviceplane/pkg/engine"
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/function61/holepunch-server/pkg/wsconnadapter"
 	"github.com/gorilla/websocket"
@@ -22,14 +28,42 @@ const (
 	projectsURL     = "projects"
 	applicationsURL = "applications"
 	releasesURL     = "releases"
+	rollbackURL     = "rollback"
 	devicesURL      = "devices"
+	deviceViewsURL  = "deviceviews"
 	sshURL          = "ssh"
 	executeURL      = "execute"
+	execURL         = "exec"
+	portForwardURL  = "portforward"
+	remoteAccessURL = "remoteaccess"
 	rebootURL       = "reboot"
+	shutdownURL     = "shutdown"
+	agentVersionURL = "agentversion"
+	approveURL      = "approve"
+	decommissionURL = "decommission"
+	transferURL     = "transfer"
+	bulkURL         = "bulk"
+	exportURL       = "export"
+	connectivityURL = "connectivity"
+	staleURL        = "stale"
+	driftURL        = "drift"
+	versionsURL     = "versions"
+	environmentURL  = "environment"
+	filesURL        = "files"
+	fileBrowserURL  = "filebrowser"
+	hostCommandsURL = "hostcommands"
 	bundleURL       = "bundle"
 	metricsURL      = "metrics"
+	statsURL        = "stats"
 	servicesURL     = "services"
 	membershipsURL  = "memberships"
+	releasePinURL   = "releasepin"
+	gitSyncURL      = "gitsync"
+	ciURL           = "ci"
+	eventsURL       = "events"
+	auditLogURL     = "auditlog"
+	alertsURL       = "alerts"
+	alertSilenceURL = "alertsilences"
 )
 
 type Client struct {
@@ -78,17 +112,111 @@ func (c *Client) ListProjects(ctx context.Context, project string) ([]models.Pro
 	return projects, nil
 }
 
+// ListOptions selects a page of a listing. Pages of PageSize items are
+// listed starting after the item whose ID is After, or from the beginning if
+// it's empty.
+type ListOptions struct {
+	PageSize int
+	After    string
+}
+
+// Page describes a page of a listing.
+type Page struct {
+	TotalCount int
+	// NextAfter is the After of the next page, and is empty on the last one.
+	NextAfter string
+}
+
+// ListApplications lists all of a project's applications, a page at a time.
 func (c *Client) ListApplications(ctx context.Context, project string) ([]models.Application, error) {
 	var applications []models.Application
-	if err := c.get(ctx, &applications, projectsURL, project, applicationsURL); err != nil {
-		return nil, err
+	options := ListOptions{PageSize: models.MaxPageSize}
+	for {
+		pageApplications, page, err := c.ListApplicationsPage(ctx, project, options)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, pageApplications...)
+		if page.NextAfter == "" {
+			return applications, nil
+		}
+		options.After = page.NextAfter
+	}
+}
+
+func (c *Client) ListApplicationsPage(ctx context.Context, project string, options ListOptions) ([]models.Application, *Page, error) {
+	var applications []models.Application
+	page, err := c.getPage(ctx, &applications, options, nil, projectsURL, project, applicationsURL)
+	if err != nil {
+		return nil, nil, err
 	}
-	return applications, nil
+	return applications, page, nil
 }
 
+// ListDevices lists all of a project's devices that match filters, a page at
+// a time.
 func (c *Client) ListDevices(ctx context.Context, filters []models.Filter, project string) ([]models.Device, error) {
 	var devices []models.Device
+	options := ListOptions{PageSize: models.MaxPageSize}
+	for {
+		pageDevices, page, err := c.ListDevicesPage(ctx, filters, project, options)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, pageDevices...)
+		if page.NextAfter == "" {
+			return devices, nil
+		}
+		options.After = page.NextAfter
+	}
+}
+
+func (c *Client) ListDevicesPage(ctx context.Context, filters []models.Filter, project string, options ListOptions) ([]models.Device, *Page, error) {
+	var devices []models.Device
+
+	urlValues, err := filterValues(filters)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, err := c.getPage(ctx, &devices, options, urlValues, projectsURL, project, devicesURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return devices, page, nil
+}
+
+// ExportDevices returns the devices matching filters as CSV or newline
+// delimited JSON. The caller must close the returned reader.
+func (c *Client) ExportDevices(ctx context.Context, filters []models.Filter, project string, format models.ExportFormat) (io.ReadCloser, error) {
+	urlValues, err := filterValues(filters)
+	if err != nil {
+		return nil, err
+	}
+	urlValues.Set(models.ExportFormatQueryParam, string(format))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", getURL(c.url, projectsURL, project, devicesURL, exportURL)+"?"+urlValues.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileTransferError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
 
+	return resp.Body, nil
+}
+
+// filterValues encodes filters as the query parameters device listings
+// take them in.
+func filterValues(filters []models.Filter) (url.Values, error) {
 	urlValues := url.Values{}
 	for _, filter := range filters {
 		bytes, err := json.Marshal(filter)
@@ -99,16 +227,7 @@ func (c *Client) ListDevices(ctx context.Context, filters []models.Filter, proje
 		b64Filter := base64.StdEncoding.EncodeToString(bytes)
 		urlValues.Add("filter", b64Filter)
 	}
-
-	var queryString string
-	if encoded := urlValues.Encode(); encoded != "" {
-		queryString = "?" + encoded
-	}
-
-	if err := c.get(ctx, &devices, projectsURL, project, devicesURL+queryString); err != nil {
-		return nil, err
-	}
-	return devices, nil
+	return urlValues, nil
 }
 
 func (c *Client) GetApplication(ctx context.Context, project, application string) (*models.Application, error) {
@@ -135,6 +254,14 @@ func (c *Client) GetDeviceMetrics(ctx context.Context, project, device string) (
 	return &rawOpenMetrics, nil
 }
 
+func (c *Client) GetIngestedMetrics(ctx context.Context, project, device string) (*string, error) {
+	var rawOpenMetrics string
+	if err := c.get(ctx, &rawOpenMetrics, projectsURL, project, devicesURL, device, metricsURL, "ingested"); err != nil {
+		return nil, err
+	}
+	return &rawOpenMetrics, nil
+}
+
 func (c *Client) GetServiceMetrics(ctx context.Context, project, device, application, service string) (*string, error) {
 	var rawOpenMetrics string
 	if err := c.get(ctx, &rawOpenMetrics, projectsURL, project, devicesURL, device, applicationsURL, application, servicesURL, service, metricsURL); err != nil {
@@ -143,6 +270,164 @@ func (c *Client) GetServiceMetrics(ctx context.Context, project, device, applica
 	return &rawOpenMetrics, nil
 }
 
+// StreamServiceStats calls f with every stats sample sent by the device until
+// the context is cancelled, the service stops, or f returns an error.
+func (c *Client) StreamServiceStats(ctx context.Context, project, device, application, service string, f func(engine.Stats) error) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", getURL(c.url, projectsURL, project, devicesURL, device, applicationsURL, application, servicesURL, service, statsURL), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleResponse(resp, nil)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var stats engine.Stats
+		if err := decoder.Decode(&stats); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := f(stats); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamEvents calls f with every event published in the project until the
+// context is cancelled or f returns an error. Passing types only streams
+// events of those types.
+func (c *Client) StreamEvents(ctx context.Context, project string, types []models.EventType, f func(models.Event) error) error {
+	query := url.Values{}
+	for _, t := range types {
+		query.Add("type", string(t))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", getURL(c.url, projectsURL, project, eventsURL)+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleResponse(resp, nil)
+	}
+
+	// Only data lines matter, since the event's type and ID are also in its
+	// JSON. Comments and blank lines are keepalives and separators.
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event models.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			return err
+		}
+		if err := f(event); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// ListAuditLogEntries lists a project's audit log newest first, optionally
+// filtered by who made the calls and what they were. Entries before the one
+// with the ID before are listed, to page through the log.
+func (c *Client) ListAuditLogEntries(ctx context.Context, project, user, serviceAccount, action, before string, since time.Time, limit int) ([]models.AuditLogEntry, error) {
+	urlValues := url.Values{}
+	if user != "" {
+		urlValues.Set("user", user)
+	}
+	if serviceAccount != "" {
+		urlValues.Set("serviceaccount", serviceAccount)
+	}
+	if action != "" {
+		urlValues.Set("action", action)
+	}
+	if before != "" {
+		urlValues.Set("before", before)
+	}
+	if !since.IsZero() {
+		urlValues.Set("since", since.Format(time.RFC3339))
+	}
+	if limit != 0 {
+		urlValues.Set("limit", strconv.Itoa(limit))
+	}
+
+	var queryString string
+	if encoded := urlValues.Encode(); encoded != "" {
+		queryString = "?" + encoded
+	}
+
+	var auditLogEntries []models.AuditLogEntry
+	if err := c.get(ctx, &auditLogEntries, projectsURL, project, auditLogURL+queryString); err != nil {
+		return nil, err
+	}
+	return auditLogEntries, nil
+}
+
+// ListAlerts lists a project's firing alerts, and those resolved since a
+// time. The controller picks how far back to go if since is zero.
+func (c *Client) ListAlerts(ctx context.Context, project string, since time.Time) ([]models.Alert, error) {
+	var queryString string
+	if !since.IsZero() {
+		queryString = "?" + url.Values{"since": []string{since.Format(time.RFC3339)}}.Encode()
+	}
+
+	var alerts []models.Alert
+	if err := c.get(ctx, &alerts, projectsURL, project, alertsURL+queryString); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (c *Client) ListAlertSilences(ctx context.Context, project string) ([]models.AlertSilence, error) {
+	var alertSilences []models.AlertSilence
+	if err := c.get(ctx, &alertSilences, projectsURL, project, alertSilenceURL); err != nil {
+		return nil, err
+	}
+	return alertSilences, nil
+}
+
+func (c *Client) CreateAlertSilence(ctx context.Context, project, rule, device string, until time.Time, reason string) (*models.AlertSilence, error) {
+	var alertSilence models.AlertSilence
+	if err := c.post(ctx, models.CreateAlertSilenceRequest{
+		Rule:   rule,
+		Device: device,
+		Until:  until,
+		Reason: reason,
+	}, &alertSilence, projectsURL, project, alertSilenceURL); err != nil {
+		return nil, err
+	}
+	return &alertSilence, nil
+}
+
+func (c *Client) DeleteAlertSilence(ctx context.Context, project, alertSilence string) error {
+	return c.delete(ctx, projectsURL, project, alertSilenceURL, alertSilence)
+}
+
 func (c *Client) GetLatestRelease(ctx context.Context, project, application string) (*models.Release, error) {
 	var release models.Release
 	if err := c.get(ctx, &release, projectsURL, project, applicationsURL, application, releasesURL, "latest"); err != nil {
@@ -151,21 +436,469 @@ func (c *Client) GetLatestRelease(ctx context.Context, project, application stri
 	return &release, nil
 }
 
-func (c *Client) CreateRelease(ctx context.Context, project, application, yamlConfig string) (*models.Release, error) {
+func (c *Client) CreateRelease(ctx context.Context, project, application, yamlConfig, notes, source string) (*models.Release, error) {
 	var release models.Release
 	if err := c.post(ctx, models.CreateReleaseRequest{
 		RawConfig: yamlConfig,
+		Notes:     notes,
+		Source:    source,
 	}, &release, projectsURL, project, applicationsURL, application, releasesURL); err != nil {
 		return nil, err
 	}
 	return &release, nil
 }
 
-func (c *Client) RebootDevice(ctx context.Context, project, device string) error {
-	if err := c.post(ctx, []byte{}, nil, projectsURL, project, devicesURL, device, rebootURL); err != nil {
+// CreateCIRelease creates a release at most once for an idempotency key, such
+// as a CI build ID, so it's safe to retry.
+func (c *Client) CreateCIRelease(ctx context.Context, project, application, idempotencyKey, yamlConfig, notes, source string) (*models.CreateCIReleaseResponse, error) {
+	var createCIReleaseResponse models.CreateCIReleaseResponse
+	if err := c.post(ctx, models.CreateCIReleaseRequest{
+		IdempotencyKey: idempotencyKey,
+		RawConfig:      yamlConfig,
+		Notes:          notes,
+		Source:         source,
+	}, &createCIReleaseResponse, projectsURL, project, applicationsURL, application, releasesURL, ciURL); err != nil {
+		return nil, err
+	}
+	return &createCIReleaseResponse, nil
+}
+
+// ListReleases lists an application's releases along with who created them.
+// ListReleases lists all of an application's releases, a page at a time.
+func (c *Client) ListReleases(ctx context.Context, project, application string) ([]models.ReleaseFull, error) {
+	var releases []models.ReleaseFull
+	options := ListOptions{PageSize: models.MaxPageSize}
+	for {
+		pageReleases, page, err := c.ListReleasesPage(ctx, project, application, options)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, pageReleases...)
+		if page.NextAfter == "" {
+			return releases, nil
+		}
+		options.After = page.NextAfter
+	}
+}
+
+func (c *Client) ListReleasesPage(ctx context.Context, project, application string, options ListOptions) ([]models.ReleaseFull, *Page, error) {
+	var releases []models.ReleaseFull
+	page, err := c.getPage(ctx, &releases, options, url.Values{"full": []string{""}}, projectsURL, project, applicationsURL, application, releasesURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return releases, page, nil
+}
+
+// DiffReleases compares release with from, or with the release before it if
+// from is empty.
+func (c *Client) DiffReleases(ctx context.Context, project, application, from, release string) (*models.ReleaseDiff, error) {
+	diffURL := "diff"
+	if from != "" {
+		diffURL += "?from=" + url.QueryEscape(from)
+	}
+
+	var diff models.ReleaseDiff
+	if err := c.get(ctx, &diff, projectsURL, project, applicationsURL, application, releasesURL, release, diffURL); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// RollbackApplication creates a new release with the config of the given
+// release, or of the one before the running release if it's empty.
+func (c *Client) RollbackApplication(ctx context.Context, project, application, release string) (*models.Release, error) {
+	var r models.Release
+	if err := c.post(ctx, models.RollbackApplicationRequest{
+		Release: release,
+	}, &r, projectsURL, project, applicationsURL, application, rollbackURL); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (c *Client) GetApplicationGitSync(ctx context.Context, project, application string) (*models.ApplicationGitSync, error) {
+	var gitSync models.ApplicationGitSync
+	if err := c.get(ctx, &gitSync, projectsURL, project, applicationsURL, application, gitSyncURL); err != nil {
+		return nil, err
+	}
+	return &gitSync, nil
+}
+
+func (c *Client) SetApplicationGitSync(ctx context.Context, project, application, repository, branch, path string) (*models.ApplicationGitSync, error) {
+	var gitSync models.ApplicationGitSync
+	if err := c.put(ctx, models.SetApplicationGitSyncRequest{
+		Repository: repository,
+		Branch:     branch,
+		Path:       path,
+	}, &gitSync, projectsURL, project, applicationsURL, application, gitSyncURL); err != nil {
+		return nil, err
+	}
+	return &gitSync, nil
+}
+
+func (c *Client) DeleteApplicationGitSync(ctx context.Context, project, application string) error {
+	return c.delete(ctx, projectsURL, project, applicationsURL, application, gitSyncURL)
+}
+
+func (c *Client) ValidateRelease(ctx context.Context, project, application, yamlConfig string) (*models.ValidateReleaseResponse, error) {
+	var validateReleaseResponse models.ValidateReleaseResponse
+	if err := c.post(ctx, models.ValidateReleaseRequest{
+		RawConfig: yamlConfig,
+	}, &validateReleaseResponse, projectsURL, project, applicationsURL, application, releasesURL, "validate"); err != nil {
+		return nil, err
+	}
+	return &validateReleaseResponse, nil
+}
+
+func (c *Client) ConvertCompose(ctx context.Context, project, application, compose string) (*models.ConvertComposeResponse, error) {
+	var convertComposeResponse models.ConvertComposeResponse
+	if err := c.post(ctx, models.ConvertComposeRequest{
+		Compose: compose,
+	}, &convertComposeResponse, projectsURL, project, applicationsURL, application, releasesURL, "compose"); err != nil {
+		return nil, err
+	}
+	return &convertComposeResponse, nil
+}
+
+func (c *Client) RebootDevice(ctx context.Context, project, device, reason string) (*models.PowerAction, error) {
+	var powerAction models.PowerAction
+	if err := c.post(ctx, models.PowerActionRequest{
+		Reason: reason,
+	}, &powerAction, projectsURL, project, devicesURL, device, rebootURL); err != nil {
+		return nil, err
+	}
+	return &powerAction, nil
+}
+
+func (c *Client) ApproveDevice(ctx context.Context, project, device string) (*models.Device, error) {
+	var d models.Device
+	if err := c.post(ctx, nil, &d, projectsURL, project, devicesURL, device, approveURL); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (c *Client) DecommissionDevice(ctx context.Context, project, device string) (*models.Device, error) {
+	var d models.Device
+	if err := c.post(ctx, nil, &d, projectsURL, project, devicesURL, device, decommissionURL); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// BulkDeviceOperation applies an operation to the devices given by ID or
+// name, or to the devices matching a selector, reporting how it went on each.
+func (c *Client) BulkDeviceOperation(ctx context.Context, project string, req models.BulkDeviceOperationRequest) (*models.BulkDeviceOperationResponse, error) {
+	var response models.BulkDeviceOperationResponse
+	if err := c.post(ctx, req, &response, projectsURL, project, devicesURL, bulkURL); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetDeviceConnectivityHistory returns when a device has come online and gone
+// offline over the last week, and whether it's flapping.
+func (c *Client) GetDeviceConnectivityHistory(ctx context.Context, project, device string) (*models.DeviceConnectivityHistory, error) {
+	var history models.DeviceConnectivityHistory
+	if err := c.get(ctx, &history, projectsURL, project, devicesURL, device, connectivityURL); err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+func (c *Client) ListDeviceViews(ctx context.Context, project string) ([]models.DeviceView, error) {
+	var deviceViews []models.DeviceView
+	if err := c.get(ctx, &deviceViews, projectsURL, project, deviceViewsURL); err != nil {
+		return nil, err
+	}
+	return deviceViews, nil
+}
+
+func (c *Client) GetDeviceView(ctx context.Context, project, deviceView string) (*models.DeviceView, error) {
+	var v models.DeviceView
+	if err := c.get(ctx, &v, projectsURL, project, deviceViewsURL, deviceView); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (c *Client) CreateDeviceView(ctx context.Context, project string, req models.SetDeviceViewRequest) (*models.DeviceView, error) {
+	var v models.DeviceView
+	if err := c.post(ctx, req, &v, projectsURL, project, deviceViewsURL); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (c *Client) UpdateDeviceView(ctx context.Context, project, deviceView string, req models.SetDeviceViewRequest) (*models.DeviceView, error) {
+	var v models.DeviceView
+	if err := c.put(ctx, req, &v, projectsURL, project, deviceViewsURL, deviceView); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func (c *Client) DeleteDeviceView(ctx context.Context, project, deviceView string) error {
+	return c.delete(ctx, projectsURL, project, deviceViewsURL, deviceView)
+}
+
+// GetDeviceDrift compares the releases a device should run with the ones it
+// reports running.
+func (c *Client) GetDeviceDrift(ctx context.Context, project, device string) (*models.DeviceDrift, error) {
+	var drift models.DeviceDrift
+	if err := c.get(ctx, &drift, projectsURL, project, devicesURL, device, driftURL); err != nil {
+		return nil, err
+	}
+	return &drift, nil
+}
+
+// GetDriftReport summarizes how the releases the devices matching filters
+// should run compare with the ones they report running.
+func (c *Client) GetDriftReport(ctx context.Context, filters []models.Filter, project string) (*models.DriftReport, error) {
+	urlValues, err := filterValues(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	path := driftURL
+	if encoded := urlValues.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+
+	var report models.DriftReport
+	if err := c.get(ctx, &report, projectsURL, project, devicesURL, path); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetDeviceVersionsReport counts the devices matching filters by the agent
+// version and releases they report running.
+func (c *Client) GetDeviceVersionsReport(ctx context.Context, filters []models.Filter, project string) (*models.DeviceVersionsReport, error) {
+	urlValues, err := filterValues(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	path := versionsURL
+	if encoded := urlValues.Encode(); encoded != "" {
+		path += "?" + encoded
+	}
+
+	var report models.DeviceVersionsReport
+	if err := c.get(ctx, &report, projectsURL, project, devicesURL, path); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetStaleDeviceCleanupReport returns the devices the project's stale device
+// cleanup policy would remove if it ran now.
+func (c *Client) GetStaleDeviceCleanupReport(ctx context.Context, project string) (*models.StaleDeviceCleanupReport, error) {
+	var report models.StaleDeviceCleanupReport
+	if err := c.get(ctx, &report, projectsURL, project, devicesURL, staleURL); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (c *Client) TransferDevice(ctx context.Context, project, device, targetProject string) (*models.Device, error) {
+	var d models.Device
+	if err := c.post(ctx, models.TransferDeviceRequest{
+		Project: targetProject,
+	}, &d, projectsURL, project, devicesURL, device, transferURL); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SetDeviceAgentVersion pins a device to an agent version, or unpins it if
+// version is empty.
+func (c *Client) SetDeviceAgentVersion(ctx context.Context, project, device, version string, spec models.AgentSpec) (*models.Device, error) {
+	var d models.Device
+	if err := c.post(ctx, models.SetDeviceAgentVersionRequest{
+		Version: version,
+		Spec:    spec,
+	}, &d, projectsURL, project, devicesURL, device, agentVersionURL); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SetDeviceReleasePin pins a device to a release of an application, given by
+// its ID or number.
+func (c *Client) SetDeviceReleasePin(ctx context.Context, project, device, application, release string) (*models.DeviceReleasePin, error) {
+	var releasePin models.DeviceReleasePin
+	if err := c.put(ctx, models.SetDeviceReleasePinRequest{
+		Release: release,
+	}, &releasePin, projectsURL, project, devicesURL, device, applicationsURL, application, releasePinURL); err != nil {
+		return nil, err
+	}
+	return &releasePin, nil
+}
+
+func (c *Client) DeleteDeviceReleasePin(ctx context.Context, project, device, application string) error {
+	return c.delete(ctx, projectsURL, project, devicesURL, device, applicationsURL, application, releasePinURL)
+}
+
+func (c *Client) GetDeviceEnvironment(ctx context.Context, project, device string) (map[string]string, error) {
+	var environment map[string]string
+	if err := c.get(ctx, &environment, projectsURL, project, devicesURL, device, environmentURL); err != nil {
+		return nil, err
+	}
+	return environment, nil
+}
+
+func (c *Client) SetDeviceEnvironmentVariable(ctx context.Context, project, device, key, value string) error {
+	var v string
+	return c.put(ctx, map[string]string{
+		"key":   key,
+		"value": value,
+	}, &v, projectsURL, project, devicesURL, device, environmentURL)
+}
+
+func (c *Client) DeleteDeviceEnvironmentVariable(ctx context.Context, project, device, key string) error {
+	return c.delete(ctx, projectsURL, project, devicesURL, device, environmentURL, key)
+}
+
+func (c *Client) ShutdownDevice(ctx context.Context, project, device, reason string) (*models.PowerAction, error) {
+	var powerAction models.PowerAction
+	if err := c.post(ctx, models.PowerActionRequest{
+		Reason: reason,
+	}, &powerAction, projectsURL, project, devicesURL, device, shutdownURL); err != nil {
+		return nil, err
+	}
+	return &powerAction, nil
+}
+
+// DownloadFile returns the contents of the file at path on the device along
+// with its permission bits. The caller must close the returned reader.
+func (c *Client) DownloadFile(ctx context.Context, project, deviceID, path string) (io.ReadCloser, os.FileMode, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", getFileURL(c.url, path, projectsURL, project, devicesURL, deviceID, filesURL), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := fileTransferError(resp); err != nil {
+		resp.Body.Close()
+		return nil, 0, err
+	}
+
+	mode, err := strconv.ParseUint(resp.Header.Get(models.FileModeHeader), 8, 32)
+	if err != nil {
+		mode = 0644
+	}
+
+	return resp.Body, os.FileMode(mode).Perm(), nil
+}
+
+// UploadFile writes size bytes from r to path on the device.
+func (c *Client) UploadFile(ctx context.Context, project, deviceID, path string, mode os.FileMode, r io.Reader, size int64) error {
+	return c.putFile(ctx, getFileURL(c.url, path, projectsURL, project, devicesURL, deviceID, filesURL), mode, r, size)
+}
+
+func (c *Client) putFile(ctx context.Context, u string, mode os.FileMode, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", u, r)
+	if err != nil {
 		return err
 	}
-	return nil
+
+	req.SetBasicAuth(c.accessKey, "")
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(models.FileModeHeader, strconv.FormatUint(uint64(mode.Perm()), 8))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return fileTransferError(resp)
+}
+
+func (c *Client) ListFileBrowserRoots(ctx context.Context, project, deviceID string) ([]models.FileBrowserRoot, error) {
+	var roots []models.FileBrowserRoot
+	if err := c.get(ctx, &roots, projectsURL, project, devicesURL, deviceID, fileBrowserURL, models.FileBrowserOperationRoots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+func (c *Client) StatBrowsedFile(ctx context.Context, project, deviceID, path string) (*models.FileInfo, error) {
+	var file models.FileInfo
+	if err := c.browseFiles(ctx, &file, project, deviceID, models.FileBrowserOperationStat, path); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (c *Client) ListBrowsedFiles(ctx context.Context, project, deviceID, path string) ([]models.FileInfo, error) {
+	var files []models.FileInfo
+	if err := c.browseFiles(ctx, &files, project, deviceID, models.FileBrowserOperationList, path); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ReadBrowsedFile returns the contents of a file under one of the device's
+// file browser paths. The caller must close the returned reader.
+func (c *Client) ReadBrowsedFile(ctx context.Context, project, deviceID, path string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		getFileURL(c.url, path, projectsURL, project, devicesURL, deviceID, fileBrowserURL, models.FileBrowserOperationRead), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := fileTransferError(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+func (c *Client) WriteBrowsedFile(ctx context.Context, project, deviceID, path string, mode os.FileMode, r io.Reader, size int64) error {
+	return c.putFile(ctx, getFileURL(c.url, path, projectsURL, project, devicesURL, deviceID, fileBrowserURL, models.FileBrowserOperationWrite), mode, r, size)
+}
+
+func (c *Client) browseFiles(ctx context.Context, out interface{}, project, deviceID, operation, path string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		getFileURL(c.url, path, projectsURL, project, devicesURL, deviceID, fileBrowserURL, operation), nil)
+	if err != nil {
+		return err
+	}
+
+	return c.performRequest(req, out)
+}
+
+func (c *Client) ListHostCommands(ctx context.Context, project, deviceID string) ([]models.HostCommand, error) {
+	var hostCommands []models.HostCommand
+	if err := c.get(ctx, &hostCommands, projectsURL, project, devicesURL, deviceID, hostCommandsURL); err != nil {
+		return nil, err
+	}
+	return hostCommands, nil
+}
+
+func (c *Client) RunHostCommand(ctx context.Context, project, deviceID, hostCommand string) (*models.HostCommandResult, error) {
+	var result models.HostCommandResult
+	if err := c.post(ctx, []byte{}, &result, projectsURL, project, devicesURL, deviceID, hostCommandsURL, hostCommand); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 func (c *Client) Execute(ctx context.Context, project, deviceID, command string) (*models.ExecuteResponse, error) {
@@ -192,6 +925,79 @@ func (c *Client) InitiateSSH(ctx context.Context, project, deviceID string) (net
 	return wsconnadapter.New(wsConn), nil
 }
 
+func (c *Client) InitiateExec(ctx context.Context, project, deviceID, application, service string) (net.Conn, error) {
+	req, err := http.NewRequestWithContext(ctx, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	wsConn, _, err := websocket.DefaultDialer.Dial(getWebsocketURL(c.url, projectsURL, project, devicesURL, deviceID, applicationsURL, application, servicesURL, service, execURL), req.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return wsconnadapter.New(wsConn), nil
+}
+
+// InitiatePortForward returns a connection to port on the device, or in the
+// service's container if application is set.
+func (c *Client) InitiatePortForward(ctx context.Context, project, deviceID, application, service string, port int) (net.Conn, error) {
+	req, err := http.NewRequestWithContext(ctx, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	path := []string{projectsURL, project, devicesURL, deviceID}
+	if application != "" {
+		path = append(path, applicationsURL, application, servicesURL, service)
+	}
+	path = append(path, portForwardURL)
+
+	query := url.Values{}
+	query.Set("port", strconv.Itoa(port))
+
+	return dialForwardedPort(getWebsocketURL(c.url, path...)+"?"+query.Encode(), req.Header)
+}
+
+// InitiateRemoteAccess returns a connection to the server for a remote
+// access profile on the device, e.g. its VNC server. The profile's default
+// port is used if port is zero.
+func (c *Client) InitiateRemoteAccess(ctx context.Context, project, deviceID, profile string, port int) (net.Conn, error) {
+	req, err := http.NewRequestWithContext(ctx, "", "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SetBasicAuth(c.accessKey, "")
+
+	u := getWebsocketURL(c.url, projectsURL, project, devicesURL, deviceID, remoteAccessURL, profile)
+	if port != 0 {
+		query := url.Values{}
+		query.Set("port", strconv.Itoa(port))
+		u += "?" + query.Encode()
+	}
+
+	return dialForwardedPort(u, req.Header)
+}
+
+func dialForwardedPort(u string, header http.Header) (net.Conn, error) {
+	wsConn, resp, err := websocket.DefaultDialer.Dial(u, header)
+	if err != nil {
+		if resp != nil {
+			if message, _ := ioutil.ReadAll(resp.Body); len(message) > 0 {
+				return nil, errors.New(strings.TrimSpace(string(message)))
+			}
+		}
+		return nil, err
+	}
+
+	return wsconnadapter.New(wsConn), nil
+}
+
 func (c *Client) get(ctx context.Context, out interface{}, s ...string) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", getURL(c.url, s...), nil)
 	if err != nil {
@@ -201,7 +1007,66 @@ func (c *Client) get(ctx context.Context, out interface{}, s ...string) error {
 	return c.performRequest(req, out)
 }
 
+// getPage gets a page of a listing, with query added to the URL.
+func (c *Client) getPage(ctx context.Context, out interface{}, options ListOptions, query url.Values, s ...string) (*Page, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+	if options.PageSize != 0 {
+		query.Set(models.PageSizeQueryParam, strconv.Itoa(options.PageSize))
+	}
+	if options.After != "" {
+		query.Set(models.AfterQueryParam, options.After)
+	}
+
+	reqURL := getURL(c.url, s...)
+	if encoded := query.Encode(); encoded != "" {
+		reqURL += "?" + encoded
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.accessKey, "")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := c.handleResponse(resp, out); err != nil {
+		return nil, err
+	}
+
+	totalCount, _ := strconv.Atoi(resp.Header.Get(models.TotalItemCountHeader))
+	return &Page{
+		TotalCount: totalCount,
+		NextAfter:  resp.Header.Get(models.NextPageAfterHeader),
+	}, nil
+}
+
 func (c *Client) post(ctx context.Context, in, out interface{}, s ...string) error {
+	return c.send(ctx, "POST", in, out, s...)
+}
+
+func (c *Client) put(ctx context.Context, in, out interface{}, s ...string) error {
+	return c.send(ctx, "PUT", in, out, s...)
+}
+
+func (c *Client) delete(ctx context.Context, s ...string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", getURL(c.url, s...), nil)
+	if err != nil {
+		return err
+	}
+
+	// The response has no body
+	var out string
+	return c.performRequest(req, &out)
+}
+
+func (c *Client) send(ctx context.Context, method string, in, out interface{}, s ...string) error {
 	var reqBytes []byte
 
 	switch v := in.(type) {
@@ -217,7 +1082,7 @@ func (c *Client) post(ctx context.Context, in, out interface{}, s ...string) err
 
 	reader := bytes.NewReader(reqBytes)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", getURL(c.url, s...), reader)
+	req, err := http.NewRequestWithContext(ctx, method, getURL(c.url, s...), reader)
 	if err != nil {
 		return err
 	}
@@ -258,6 +1123,24 @@ func (c *Client) handleResponse(resp *http.Response, out interface{}) error {
 	}
 }
 
+func getFileURL(u *url.URL, path string, s ...string) string {
+	return getURL(u, s...) + "?" + url.Values{
+		models.FilePathQueryParam: []string{path},
+	}.Encode()
+}
+
+// fileTransferError surfaces the device's error message, since it's more
+// useful than the status alone when a path is missing or not permitted.
+func fileTransferError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	if message, _ := ioutil.ReadAll(resp.Body); len(message) > 0 {
+		return errors.New(strings.TrimSpace(string(message)))
+	}
+	return fmt.Errorf("%d %s", resp.StatusCode, resp.Status)
+}
+
 func getURL(u *url.URL, s ...string) string {
 	return strings.Join(append([]string{u.String()}, s...), "/")
 }
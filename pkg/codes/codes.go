@@ -5,4 +5,6 @@ const (
 	StatusDeviceConnectionFailure       = 601
 	StatusMetricsNotAvailable           = 602
 	StatusImagePullProgressNotAvailable = 603
+	StatusStatsNotAvailable             = 604
+	StatusLogsNotAvailable              = 605
 )
@@ -0,0 +1,248 @@
+package alerting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/scheduling"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	AlertStatusHeader = "X-Deviceplane-Alert-Status"
+
+	// Retention is how long resolved alerts are kept
+	Retention = 30 * 24 * time.Hour
+
+	maxRuleNameSize = 100
+)
+
+var (
+	ErrMissingRuleName      = errors.New("alert rules need a name")
+	ErrRuleNameTooLong      = errors.New("alert rule names must be at most 100 characters")
+	ErrInvalidThreshold     = errors.New("alert rule thresholds must be percentages between 0 and 100")
+	ErrInvalidDuration      = errors.New("alert rule durations can't be negative")
+	ErrMissingService       = errors.New("service not running alert rules need an application and a service")
+	ErrApplicationNotExists = errors.New("application does not exist")
+)
+
+// Firing is a rule firing for a device or, if DeviceID is empty, for the
+// project.
+type Firing struct {
+	DeviceID string
+	Summary  string
+}
+
+// ServiceRunning reports whether an application's service is running on a
+// device.
+type ServiceRunning func(deviceID, applicationID, service string) (bool, error)
+
+// Evaluate returns where a rule is firing. The application is only needed
+// for service not running rules, and is where the rule's application was
+// looked up.
+func Evaluate(rule models.AlertRule, devices []models.Device, application *models.Application,
+	serviceRunning ServiceRunning, now time.Time,
+) ([]Firing, error) {
+	if rule.Query != nil {
+		var err error
+		devices, _, err = query.QueryDevices(devices, *rule.Query)
+		if err != nil {
+			return nil, errors.Wrap(err, "query devices")
+		}
+	}
+
+	var firings []Firing
+	switch rule.Type {
+	case models.AlertRuleTypeDeviceOffline:
+		duration := time.Duration(rule.DurationSeconds) * time.Second
+		for _, device := range devices {
+			if device.Status != models.DeviceStatusOffline {
+				continue
+			}
+			offlineFor := now.Sub(device.LastSeenAt)
+			if offlineFor < duration {
+				continue
+			}
+			firings = append(firings, Firing{
+				DeviceID: device.ID,
+				Summary: fmt.Sprintf("Device %s has been offline since %s",
+					device.Name, device.LastSeenAt.UTC().Format(time.RFC3339)),
+			})
+		}
+
+	case models.AlertRuleTypeDiskUsage:
+		for _, device := range devices {
+			var full []string
+			for _, filesystem := range device.Info.Filesystems {
+				if usage := filesystem.Usage() * 100; usage > rule.Threshold {
+					full = append(full, fmt.Sprintf("%s (%.0f%%)", filesystem.Mountpoint, usage))
+				}
+			}
+			if len(full) == 0 {
+				continue
+			}
+			firings = append(firings, Firing{
+				DeviceID: device.ID,
+				Summary: fmt.Sprintf("Device %s is using more than %g%% of %s",
+					device.Name, rule.Threshold, strings.Join(full, ", ")),
+			})
+		}
+
+	case models.AlertRuleTypeServiceNotRunning:
+		if application == nil {
+			return nil, ErrApplicationNotExists
+		}
+
+		var onlineDevices []models.Device
+		for _, device := range devices {
+			if device.Status == models.DeviceStatusOnline {
+				onlineDevices = append(onlineDevices, device)
+			}
+		}
+
+		scheduledDevices, err := scheduling.GetScheduledDevices(onlineDevices, application.SchedulingRule)
+		if err != nil {
+			return nil, errors.Wrap(err, "get scheduled devices")
+		}
+		if len(scheduledDevices) == 0 {
+			return nil, nil
+		}
+
+		notRunning := 0
+		for _, scheduledDevice := range scheduledDevices {
+			running, err := serviceRunning(scheduledDevice.ID, application.ID, rule.Service)
+			if err != nil {
+				return nil, errors.Wrap(err, "get service running")
+			}
+			if !running {
+				notRunning++
+			}
+		}
+
+		percentage := float64(notRunning) * 100 / float64(len(scheduledDevices))
+		if percentage > rule.Threshold {
+			firings = append(firings, Firing{
+				Summary: fmt.Sprintf("Service %s of %s isn't running on %d of %d devices (%.1f%%)",
+					rule.Service, application.Name, notRunning, len(scheduledDevices), percentage),
+			})
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown alert rule type %q", rule.Type)
+	}
+
+	return firings, nil
+}
+
+// Silenced returns whether an alert matches any of the silences at a time.
+func Silenced(silences []models.AlertSilence, alert models.Alert, now time.Time) bool {
+	for _, silence := range silences {
+		if !now.Before(silence.Until) {
+			continue
+		}
+		if silence.Rule != "" && silence.Rule != alert.Rule {
+			continue
+		}
+		if silence.DeviceID != "" && silence.DeviceID != alert.DeviceID {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// Message returns the message an alert notification is sent to channels
+// as.
+func Message(notification models.AlertNotification) notifications.Message {
+	alert := notification.Alert
+
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(string(notification.Status)), alert.Rule)
+	text := fmt.Sprintf("%s\n\nProject: %s\nRule: %s\nStarted at: %s\n",
+		alert.Summary,
+		alert.ProjectID,
+		alert.Rule,
+		alert.StartedAt.UTC().Format(time.RFC3339),
+	)
+	if alert.ResolvedAt != nil {
+		text += fmt.Sprintf("Resolved at: %s\n", alert.ResolvedAt.UTC().Format(time.RFC3339))
+	}
+
+	return notifications.Message{
+		Subject:  subject,
+		Text:     text,
+		Key:      alert.ID,
+		Resolved: notification.Status == models.AlertStatusResolved,
+		Severity: notifications.SeverityCritical,
+		Payload:  notification,
+		Header: map[string]string{
+			AlertStatusHeader: string(notification.Status),
+		},
+	}
+}
+
+// Validate checks the rules and channels of an alerts config.
+func Validate(config models.AlertsConfig) error {
+	channels := make(map[string]bool, len(config.Channels))
+	for _, channel := range config.Channels {
+		if err := notifications.Validate(channel); err != nil {
+			return err
+		}
+		if channels[channel.Name] {
+			return fmt.Errorf("duplicate alert channel %q", channel.Name)
+		}
+		channels[channel.Name] = true
+	}
+
+	rules := make(map[string]bool, len(config.Rules))
+	for _, rule := range config.Rules {
+		if rule.Name == "" {
+			return ErrMissingRuleName
+		}
+		if len(rule.Name) > maxRuleNameSize {
+			return ErrRuleNameTooLong
+		}
+		if rules[rule.Name] {
+			return fmt.Errorf("duplicate alert rule %q", rule.Name)
+		}
+		rules[rule.Name] = true
+
+		switch rule.Type {
+		case models.AlertRuleTypeDeviceOffline:
+			if rule.DurationSeconds < 0 {
+				return ErrInvalidDuration
+			}
+		case models.AlertRuleTypeDiskUsage:
+			if rule.Threshold < 0 || rule.Threshold > 100 {
+				return ErrInvalidThreshold
+			}
+		case models.AlertRuleTypeServiceNotRunning:
+			if rule.Threshold < 0 || rule.Threshold > 100 {
+				return ErrInvalidThreshold
+			}
+			if rule.Application == "" || rule.Service == "" {
+				return ErrMissingService
+			}
+		default:
+			return fmt.Errorf("unknown alert rule type %q", rule.Type)
+		}
+
+		if rule.Query != nil {
+			if err := query.ValidateQuery(*rule.Query); err != nil {
+				return errors.Wrapf(err, "alert rule %q", rule.Name)
+			}
+		}
+
+		for _, channel := range rule.Channels {
+			if !channels[channel] {
+				return fmt.Errorf("alert rule %q has unknown channel %q", rule.Name, channel)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestEvaluateDeviceOffline(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	devices := []models.Device{
+		{ID: "dev_1", Name: "a", Status: models.DeviceStatusOffline, LastSeenAt: now.Add(-time.Hour)},
+		{ID: "dev_2", Name: "b", Status: models.DeviceStatusOffline, LastSeenAt: now.Add(-5 * time.Minute)},
+		{ID: "dev_3", Name: "c", Status: models.DeviceStatusOnline, LastSeenAt: now},
+	}
+
+	firings, err := Evaluate(models.AlertRule{
+		Name:            "offline",
+		Type:            models.AlertRuleTypeDeviceOffline,
+		DurationSeconds: 15 * 60,
+	}, devices, nil, nil, now)
+	require.NoError(t, err)
+	require.Len(t, firings, 1)
+	require.Equal(t, "dev_1", firings[0].DeviceID)
+}
+
+func TestEvaluateDiskUsage(t *testing.T) {
+	devices := []models.Device{
+		{ID: "dev_1", Name: "a", Info: models.DeviceInfo{Filesystems: []models.Filesystem{
+			{Mountpoint: "/", TotalBytes: 100, FreeBytes: 5},
+		}}},
+		{ID: "dev_2", Name: "b", Info: models.DeviceInfo{Filesystems: []models.Filesystem{
+			{Mountpoint: "/", TotalBytes: 100, FreeBytes: 50, TotalInodes: 100, FreeInodes: 2},
+		}}},
+		{ID: "dev_3", Name: "c", Info: models.DeviceInfo{Filesystems: []models.Filesystem{
+			{Mountpoint: "/", TotalBytes: 100, FreeBytes: 50},
+		}}},
+	}
+
+	firings, err := Evaluate(models.AlertRule{
+		Name:      "disk",
+		Type:      models.AlertRuleTypeDiskUsage,
+		Threshold: 90,
+	}, devices, nil, nil, time.Now())
+	require.NoError(t, err)
+	require.Len(t, firings, 2)
+	require.Equal(t, "dev_1", firings[0].DeviceID)
+	require.Equal(t, "dev_2", firings[1].DeviceID)
+	require.Contains(t, firings[0].Summary, "/ (95%)")
+}
+
+func TestEvaluateServiceNotRunning(t *testing.T) {
+	var devices []models.Device
+	for _, id := range []string{"dev_1", "dev_2", "dev_3", "dev_4"} {
+		devices = append(devices, models.Device{ID: id, Status: models.DeviceStatusOnline})
+	}
+	devices = append(devices, models.Device{ID: "dev_5", Status: models.DeviceStatusOffline})
+
+	application := &models.Application{
+		ID:   "app_1",
+		Name: "app",
+		SchedulingRule: models.SchedulingRule{
+			ScheduleType: models.ScheduleTypeAllDevices,
+		},
+	}
+	rule := models.AlertRule{
+		Name:        "service",
+		Type:        models.AlertRuleTypeServiceNotRunning,
+		Application: "app",
+		Service:     "web",
+		Threshold:   20,
+	}
+
+	running := map[string]bool{"dev_1": true, "dev_2": true, "dev_3": true}
+	serviceRunning := func(deviceID, applicationID, service string) (bool, error) {
+		require.Equal(t, "app_1", applicationID)
+		require.Equal(t, "web", service)
+		return running[deviceID], nil
+	}
+
+	firings, err := Evaluate(rule, devices, application, serviceRunning, time.Now())
+	require.NoError(t, err)
+	require.Len(t, firings, 1)
+	require.Equal(t, "", firings[0].DeviceID)
+	require.Contains(t, firings[0].Summary, "1 of 4 devices")
+
+	running["dev_4"] = true
+	firings, err = Evaluate(rule, devices, application, serviceRunning, time.Now())
+	require.NoError(t, err)
+	require.Empty(t, firings)
+
+	_, err = Evaluate(rule, devices, nil, serviceRunning, time.Now())
+	require.Equal(t, ErrApplicationNotExists, err)
+}
+
+func TestSilenced(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	alert := models.Alert{Rule: "offline", DeviceID: "dev_1"}
+
+	require.False(t, Silenced(nil, alert, now))
+	require.True(t, Silenced([]models.AlertSilence{
+		{Until: now.Add(time.Hour)},
+	}, alert, now))
+	require.True(t, Silenced([]models.AlertSilence{
+		{Rule: "offline", DeviceID: "dev_1", Until: now.Add(time.Hour)},
+	}, alert, now))
+	require.False(t, Silenced([]models.AlertSilence{
+		{Rule: "offline", DeviceID: "dev_1", Until: now},
+	}, alert, now))
+	require.False(t, Silenced([]models.AlertSilence{
+		{Rule: "disk", Until: now.Add(time.Hour)},
+		{DeviceID: "dev_2", Until: now.Add(time.Hour)},
+	}, alert, now))
+}
+
+func TestMessage(t *testing.T) {
+	startedAt := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	resolvedAt := startedAt.Add(time.Hour)
+	alert := models.Alert{
+		ID:        "alr_1",
+		ProjectID: "prj_1",
+		Rule:      "offline",
+		DeviceID:  "dev_1",
+		Summary:   "Device a has been offline",
+		StartedAt: startedAt,
+	}
+
+	message := Message(models.AlertNotification{
+		Status: models.AlertStatusFiring,
+		Alert:  alert,
+	})
+	require.Equal(t, "[FIRING] offline", message.Subject)
+	require.Contains(t, message.Text, "Device a has been offline")
+	require.NotContains(t, message.Text, "Resolved at")
+	require.Equal(t, "alr_1", message.Key)
+	require.False(t, message.Resolved)
+	require.Equal(t, "firing", message.Header[AlertStatusHeader])
+
+	alert.ResolvedAt = &resolvedAt
+	message = Message(models.AlertNotification{
+		Status: models.AlertStatusResolved,
+		Alert:  alert,
+	})
+	require.Equal(t, "[RESOLVED] offline", message.Subject)
+	require.Contains(t, message.Text, "Resolved at: 2020-03-10T13:00:00Z")
+	require.True(t, message.Resolved)
+	require.Equal(t, "alr_1", message.Key)
+}
+
+func TestValidate(t *testing.T) {
+	channels := []models.AlertChannel{
+		{Name: "ops", Type: models.AlertChannelTypeWebhook, URL: "https://example.com/alerts"},
+		{Name: "oncall", Type: models.AlertChannelTypeEmail, Emails: []string{"oncall@example.com"}},
+	}
+	valid := func(rule models.AlertRule) error {
+		return Validate(models.AlertsConfig{
+			Rules:    []models.AlertRule{rule},
+			Channels: channels,
+		})
+	}
+
+	require.NoError(t, valid(models.AlertRule{
+		Name:            "offline",
+		Type:            models.AlertRuleTypeDeviceOffline,
+		DurationSeconds: 900,
+		Channels:        []string{"ops", "oncall"},
+	}))
+	require.NoError(t, valid(models.AlertRule{
+		Name:        "service",
+		Type:        models.AlertRuleTypeServiceNotRunning,
+		Application: "app",
+		Service:     "web",
+		Threshold:   5,
+	}))
+
+	require.Equal(t, ErrMissingRuleName, valid(models.AlertRule{
+		Type: models.AlertRuleTypeDeviceOffline,
+	}))
+	require.Equal(t, ErrInvalidThreshold, valid(models.AlertRule{
+		Name:      "disk",
+		Type:      models.AlertRuleTypeDiskUsage,
+		Threshold: 120,
+	}))
+	require.Equal(t, ErrMissingService, valid(models.AlertRule{
+		Name: "service",
+		Type: models.AlertRuleTypeServiceNotRunning,
+	}))
+	require.Error(t, valid(models.AlertRule{
+		Name: "unknown",
+		Type: "cpu",
+	}))
+	require.Error(t, valid(models.AlertRule{
+		Name:     "offline",
+		Type:     models.AlertRuleTypeDeviceOffline,
+		Channels: []string{"pager"},
+	}))
+
+	require.Error(t, Validate(models.AlertsConfig{
+		Rules: []models.AlertRule{
+			{Name: "offline", Type: models.AlertRuleTypeDeviceOffline},
+			{Name: "offline", Type: models.AlertRuleTypeDeviceOffline},
+		},
+	}))
+	require.Equal(t, notifications.ErrInvalidURL, Validate(models.AlertsConfig{
+		Channels: []models.AlertChannel{
+			{Name: "ops", Type: models.AlertChannelTypeWebhook, URL: "ftp://example.com"},
+		},
+	}))
+	require.Equal(t, notifications.ErrMissingEmails, Validate(models.AlertsConfig{
+		Channels: []models.AlertChannel{
+			{Name: "oncall", Type: models.AlertChannelTypeEmail},
+		},
+	}))
+}
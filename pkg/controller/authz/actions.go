@@ -21,32 +21,93 @@ const (
 	ActionGetLatestRelease             = Action("GetLatestRelease")
 	ActionGetRelease                   = Action("GetRelease")
 	ActionListReleases                 = Action("ListReleases")
+	ActionValidateRelease              = Action("ValidateRelease")
+	ActionConvertCompose               = Action("ConvertCompose")
 	ActionPreviewApplicationScheduling = Action("PreviewApplicationScheduling")
 	ActionGetDevice                    = Action("GetDevice")
 	ActionListDevices                  = Action("ListDevices")
 	ActionGetImagePullProgress         = Action("GetImagePullProgress")
 	ActionGetMetrics                   = Action("GetMetrics")
 	ActionGetServiceMetrics            = Action("GetServiceMetrics")
+	ActionGetServiceStats              = Action("GetServiceStats")
+	ActionGetServiceLogs               = Action("GetServiceLogs")
+	ActionListHostCommands             = Action("ListHostCommands")
+	ActionBrowseFiles                  = Action("BrowseFiles")
 	ActionGetDeviceRegistrationToken   = Action("GetDeviceRegistrationToken")
 	ActionListDeviceRegistrationTokens = Action("ListDeviceRegistrationTokens")
 	ActionGetProjectConfig             = Action("GetProjectConfig")
+	ActionGetEnvironmentFile           = Action("GetEnvironmentFile")
+	ActionListEnvironmentFiles         = Action("ListEnvironmentFiles")
+	ActionGetDeviceEnvironment         = Action("GetDeviceEnvironment")
+	ActionGetConfigFile                = Action("GetConfigFile")
+	ActionListConfigFiles              = Action("ListConfigFiles")
+	ActionGetDeviceGroup               = Action("GetDeviceGroup")
+	ActionListDeviceGroups             = Action("ListDeviceGroups")
+	ActionGetDeviceView                = Action("GetDeviceView")
+	ActionListDeviceViews              = Action("ListDeviceViews")
+	ActionGetRollout                   = Action("GetRollout")
+	ActionListRollouts                 = Action("ListRollouts")
+	ActionListDeviceReleasePins        = Action("ListDeviceReleasePins")
+	ActionGetApplicationGitSync        = Action("GetApplicationGitSync")
+	ActionStreamEvents                 = Action("StreamEvents")
+	ActionListAlerts                   = Action("ListAlerts")
+	ActionListAlertSilences            = Action("ListAlertSilences")
 
 	ActionCreateApplication                  = Action("CreateApplication")
 	ActionUpdateApplication                  = Action("UpdateApplication")
 	ActionDeleteApplication                  = Action("DeleteApplication")
+	ActionRollbackApplication                = Action("RollbackApplication")
 	ActionCreateRelease                      = Action("CreateRelease")
 	ActionUpdateDevice                       = Action("UpdateDevice")
 	ActionDeleteDevice                       = Action("DeleteDevice")
+	ActionApproveDevice                      = Action("ApproveDevice")
+	ActionDecommissionDevice                 = Action("DecommissionDevice")
+	ActionTransferDevice                     = Action("TransferDevice")
 	ActionSSH                                = Action("SSH")
+	ActionExec                               = Action("Exec")
+	ActionPortForward                        = Action("PortForward")
+	ActionAccessDeviceEndpoint               = Action("AccessDeviceEndpoint")
+	ActionDownloadFile                       = Action("DownloadFile")
+	ActionUploadFile                         = Action("UploadFile")
+	ActionWriteBrowsedFile                   = Action("WriteBrowsedFile")
+	ActionRunHostCommand                     = Action("RunHostCommand")
 	ActionReboot                             = Action("Reboot")
+	ActionShutdown                           = Action("Shutdown")
 	ActionListAllDeviceLabels                = Action("ListAllDeviceLabels")
 	ActionSetDeviceLabel                     = Action("SetDeviceLabel")
 	ActionDeleteDeviceLabel                  = Action("DeleteDeviceLabel")
+	ActionSetDeviceEnvironmentVariable       = Action("SetDeviceEnvironmentVariable")
+	ActionDeleteDeviceEnvironmentVariable    = Action("DeleteDeviceEnvironmentVariable")
 	ActionCreateDeviceRegistrationToken      = Action("CreateDeviceRegistrationToken")
 	ActionUpdateDeviceRegistrationToken      = Action("UpdateDeviceRegistrationToken")
 	ActionDeleteDeviceRegistrationToken      = Action("DeleteDeviceRegistrationToken")
 	ActionSetDeviceRegistrationTokenLabel    = Action("SetDeviceRegistrationTokenLabel")
 	ActionDeleteDeviceRegistrationTokenLabel = Action("DeleteDeviceRegistrationTokenLabel")
+	ActionCreateEnvironmentFile              = Action("CreateEnvironmentFile")
+	ActionUpdateEnvironmentFile              = Action("UpdateEnvironmentFile")
+	ActionDeleteEnvironmentFile              = Action("DeleteEnvironmentFile")
+	ActionCreateConfigFile                   = Action("CreateConfigFile")
+	ActionUpdateConfigFile                   = Action("UpdateConfigFile")
+	ActionDeleteConfigFile                   = Action("DeleteConfigFile")
+	ActionCreateDeviceGroup                  = Action("CreateDeviceGroup")
+	ActionUpdateDeviceGroup                  = Action("UpdateDeviceGroup")
+	ActionDeleteDeviceGroup                  = Action("DeleteDeviceGroup")
+	ActionAddDeviceGroupMember               = Action("AddDeviceGroupMember")
+	ActionRemoveDeviceGroupMember            = Action("RemoveDeviceGroupMember")
+	ActionCreateDeviceView                   = Action("CreateDeviceView")
+	ActionUpdateDeviceView                   = Action("UpdateDeviceView")
+	ActionDeleteDeviceView                   = Action("DeleteDeviceView")
+	ActionCreateRollout                      = Action("CreateRollout")
+	ActionHaltRollout                        = Action("HaltRollout")
+	ActionSetDeviceReleasePin                = Action("SetDeviceReleasePin")
+	ActionDeleteDeviceReleasePin             = Action("DeleteDeviceReleasePin")
+	ActionSetApplicationGitSync              = Action("SetApplicationGitSync")
+	ActionDeleteApplicationGitSync           = Action("DeleteApplicationGitSync")
+	ActionCreateAlertSilence                 = Action("CreateAlertSilence")
+	ActionDeleteAlertSilence                 = Action("DeleteAlertSilence")
+
+	ActionSetDeviceRegistrationTokenEnvironmentVariable    = Action("SetDeviceRegistrationTokenEnvironmentVariable")
+	ActionDeleteDeviceRegistrationTokenEnvironmentVariable = Action("DeleteDeviceRegistrationTokenEnvironmentVariable")
 
 	ActionUpdateProject                   = Action("UpdateProject")
 	ActionDeleteProject                   = Action("DeleteProject")
@@ -69,6 +130,9 @@ const (
 	ActionListServiceAccountRoleBinding   = Action("ListServiceAccountRoleBinding")
 	ActionDeleteServiceAccountRoleBinding = Action("DeleteServiceAccountRoleBinding")
 	ActionSetProjectConfig                = Action("SetProjectConfig")
+	ActionGetSessionRecording             = Action("GetSessionRecording")
+	ActionListSessionRecordings           = Action("ListSessionRecordings")
+	ActionListAuditLogEntries             = Action("ListAuditLogEntries")
 )
 
 var (
@@ -82,37 +146,100 @@ var (
 		ActionListMembershipRoleBindings,
 		ActionGetServiceAccount,
 		ActionListServiceAccounts,
+		ActionGetServiceAccountRoleBinding,
+		ActionListServiceAccountRoleBinding,
 		ActionGetApplication,
 		ActionListApplications,
 		ActionGetLatestRelease,
 		ActionGetRelease,
 		ActionListReleases,
+		ActionValidateRelease,
+		ActionConvertCompose,
 		ActionPreviewApplicationScheduling,
 		ActionGetDevice,
 		ActionListDevices,
+		ActionListAllDeviceLabels,
 		ActionGetImagePullProgress,
 		ActionGetMetrics,
 		ActionGetServiceMetrics,
+		ActionGetServiceStats,
+		ActionGetServiceLogs,
+		ActionListHostCommands,
+		ActionBrowseFiles,
 		ActionGetDeviceRegistrationToken,
 		ActionListDeviceRegistrationTokens,
 		ActionGetProjectConfig,
+		ActionGetEnvironmentFile,
+		ActionListEnvironmentFiles,
+		ActionGetDeviceEnvironment,
+		ActionGetConfigFile,
+		ActionListConfigFiles,
+		ActionGetDeviceGroup,
+		ActionListDeviceGroups,
+		ActionGetDeviceView,
+		ActionListDeviceViews,
+		ActionGetRollout,
+		ActionListRollouts,
+		ActionListDeviceReleasePins,
+		ActionGetApplicationGitSync,
+		ActionStreamEvents,
+		ActionListAlerts,
+		ActionListAlertSilences,
 	}
 	writeActions = append(readActions, []Action{
 		ActionCreateApplication,
 		ActionUpdateApplication,
 		ActionDeleteApplication,
+		ActionRollbackApplication,
 		ActionCreateRelease,
 		ActionUpdateDevice,
 		ActionDeleteDevice,
+		ActionApproveDevice,
+		ActionDecommissionDevice,
+		ActionTransferDevice,
 		ActionSSH,
+		ActionExec,
+		ActionPortForward,
+		ActionAccessDeviceEndpoint,
+		ActionDownloadFile,
+		ActionUploadFile,
+		ActionWriteBrowsedFile,
+		ActionRunHostCommand,
 		ActionReboot,
+		ActionShutdown,
 		ActionSetDeviceLabel,
 		ActionDeleteDeviceLabel,
+		ActionSetDeviceEnvironmentVariable,
+		ActionDeleteDeviceEnvironmentVariable,
 		ActionCreateDeviceRegistrationToken,
 		ActionUpdateDeviceRegistrationToken,
 		ActionDeleteDeviceRegistrationToken,
 		ActionSetDeviceRegistrationTokenLabel,
 		ActionDeleteDeviceRegistrationTokenLabel,
+		ActionSetDeviceRegistrationTokenEnvironmentVariable,
+		ActionDeleteDeviceRegistrationTokenEnvironmentVariable,
+		ActionCreateEnvironmentFile,
+		ActionUpdateEnvironmentFile,
+		ActionDeleteEnvironmentFile,
+		ActionCreateConfigFile,
+		ActionUpdateConfigFile,
+		ActionDeleteConfigFile,
+		ActionCreateDeviceGroup,
+		ActionUpdateDeviceGroup,
+		ActionDeleteDeviceGroup,
+		ActionAddDeviceGroupMember,
+		ActionRemoveDeviceGroupMember,
+		ActionCreateDeviceView,
+		ActionUpdateDeviceView,
+		ActionDeleteDeviceView,
+		ActionCreateRollout,
+		ActionHaltRollout,
+		ActionSetDeviceReleasePin,
+		ActionDeleteDeviceReleasePin,
+		ActionSetApplicationGitSync,
+		ActionDeleteApplicationGitSync,
+		ActionCreateAlertSilence,
+		ActionDeleteAlertSilence,
 	}...)
 	adminActions = append(writeActions, []Action{
 		ActionUpdateProject,
@@ -134,5 +261,19 @@ var (
 		ActionCreateServiceAccountRoleBinding,
 		ActionDeleteServiceAccountRoleBinding,
 		ActionSetProjectConfig,
+		ActionGetSessionRecording,
+		ActionListSessionRecordings,
+		ActionListAuditLogEntries,
 	}...)
 )
+
+// IsReadAction returns whether an action only reads. Every other action is
+// recorded in the audit log.
+func IsReadAction(action Action) bool {
+	for _, readAction := range readActions {
+		if action == readAction {
+			return true
+		}
+	}
+	return false
+}
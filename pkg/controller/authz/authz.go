@@ -1,5 +1,7 @@
 package authz
 
+import "fmt"
+
 type Config struct {
 	Rules []Rule `yaml:"rules,omitempty"`
 }
@@ -8,6 +10,22 @@ type Rule struct {
 	Resources []Resource `yaml:"resources,omitempty"`
 	Actions   []Action   `yaml:"actions,omitempty"`
 	Effect    Effect     `yaml:"effect,omitempty"`
+	// DeviceGroups limits the rule to requests about a single device that's
+	// in one of these groups, given by name or ID.
+	DeviceGroups []string `yaml:"deviceGroups,omitempty"`
+	// Applications limits the rule to requests about one of these
+	// applications, given by name or ID.
+	Applications []string `yaml:"applications,omitempty"`
+}
+
+// Scope is what a request is about, for rules limited to device groups or
+// applications. InDeviceGroups returns whether the request's device is in
+// any of the given groups, and IsApplication whether the request's
+// application is any of the given ones. Rules limited to either are skipped
+// if its function is nil.
+type Scope struct {
+	InDeviceGroups func([]string) bool
+	IsApplication  func([]string) bool
 }
 
 var (
@@ -38,10 +56,31 @@ var (
 )
 
 func Evaluate(requestedResource Resource, requestedAction Action, configs []Config) bool {
+	return EvaluateScoped(requestedResource, requestedAction, configs, Scope{})
+}
+
+// EvaluateDevice is Evaluate for a request about a single device. inGroups
+// returns whether the device is in any of the given groups. Rules limited
+// to device groups are skipped if it's nil.
+func EvaluateDevice(requestedResource Resource, requestedAction Action, configs []Config, inGroups func([]string) bool) bool {
+	return EvaluateScoped(requestedResource, requestedAction, configs, Scope{
+		InDeviceGroups: inGroups,
+	})
+}
+
+// EvaluateScoped is Evaluate for a request about a single device or
+// application.
+func EvaluateScoped(requestedResource Resource, requestedAction Action, configs []Config, scope Scope) bool {
 	oneAllow := false
 	oneDeny := false
 	for _, config := range configs {
 		for _, rule := range config.Rules {
+			if len(rule.DeviceGroups) != 0 && (scope.InDeviceGroups == nil || !scope.InDeviceGroups(rule.DeviceGroups)) {
+				continue
+			}
+			if len(rule.Applications) != 0 && (scope.IsApplication == nil || !scope.IsApplication(rule.Applications)) {
+				continue
+			}
 			rule = resolveRule(rule)
 			for _, ruleResource := range rule.Resources {
 				if ruleResource == requestedResource || ruleResource == ResourceAny {
@@ -61,6 +100,80 @@ func Evaluate(requestedResource Resource, requestedAction Action, configs []Conf
 	return oneAllow && !oneDeny
 }
 
+// HasDeviceGroupRules returns whether any rule in configs is limited to
+// device groups.
+func HasDeviceGroupRules(configs []Config) bool {
+	for _, config := range configs {
+		for _, rule := range config.Rules {
+			if len(rule.DeviceGroups) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasApplicationRules returns whether any rule in configs is limited to
+// applications.
+func HasApplicationRules(configs []Config) bool {
+	for _, config := range configs {
+		for _, rule := range config.Rules {
+			if len(rule.Applications) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks that a role config only uses known resources, actions and
+// effects, so a typo doesn't silently grant or deny nothing.
+func Validate(config Config) error {
+	for _, rule := range config.Rules {
+		for _, resource := range rule.Resources {
+			if !validResource(resource) {
+				return fmt.Errorf("unknown resource %q", resource)
+			}
+		}
+		for _, action := range rule.Actions {
+			if !validAction(action) {
+				return fmt.Errorf("unknown action %q", action)
+			}
+		}
+		switch rule.Effect {
+		case "", EffectAllow, EffectDeny:
+		default:
+			return fmt.Errorf("unknown effect %q", rule.Effect)
+		}
+	}
+	return nil
+}
+
+func validResource(resource Resource) bool {
+	if resource == ResourceAny {
+		return true
+	}
+	for _, r := range resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func validAction(action Action) bool {
+	switch action {
+	case ActionReadAll, ActionWriteAll, ActionAdminAll:
+		return true
+	}
+	for _, a := range adminActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
 func resolveRule(rule Rule) Rule {
 	var finalActions []Action
 	for _, action := range rule.Actions {
@@ -247,4 +247,126 @@ func TestEvaluate(t *testing.T) {
 			require.False(t, Evaluate(scenario.resource, scenario.action, scenario.configs))
 		}
 	})
+
+	t.Run("device groups", func(t *testing.T) {
+		configs := []Config{
+			ReadAllRole,
+			{
+				Rules: []Rule{
+					{
+						Resources:    []Resource{ResourceDevices},
+						Actions:      []Action{ActionSSH},
+						DeviceGroups: []string{"lab"},
+					},
+				},
+			},
+		}
+		inLab := func(groups []string) bool {
+			for _, group := range groups {
+				if group == "lab" {
+					return true
+				}
+			}
+			return false
+		}
+		notInLab := func(groups []string) bool {
+			return false
+		}
+
+		require.True(t, HasDeviceGroupRules(configs))
+		require.False(t, HasDeviceGroupRules([]Config{ReadAllRole}))
+
+		require.True(t, EvaluateDevice(ResourceDevices, ActionSSH, configs, inLab))
+		require.False(t, EvaluateDevice(ResourceDevices, ActionSSH, configs, notInLab))
+		require.False(t, Evaluate(ResourceDevices, ActionSSH, configs))
+
+		// Rules that aren't limited to groups still apply
+		require.True(t, EvaluateDevice(ResourceDevices, ActionGetDevice, configs, notInLab))
+	})
+
+	t.Run("applications", func(t *testing.T) {
+		configs := []Config{
+			ReadAllRole,
+			{
+				Rules: []Rule{
+					{
+						Resources:    []Resource{ResourceReleases},
+						Actions:      []Action{ActionCreateRelease},
+						Applications: []string{"web"},
+					},
+				},
+			},
+		}
+		isWeb := func(applications []string) bool {
+			for _, application := range applications {
+				if application == "web" {
+					return true
+				}
+			}
+			return false
+		}
+		isNotWeb := func(applications []string) bool {
+			return false
+		}
+
+		require.True(t, HasApplicationRules(configs))
+		require.False(t, HasApplicationRules([]Config{ReadAllRole}))
+
+		require.True(t, EvaluateScoped(ResourceReleases, ActionCreateRelease, configs, Scope{IsApplication: isWeb}))
+		require.False(t, EvaluateScoped(ResourceReleases, ActionCreateRelease, configs, Scope{IsApplication: isNotWeb}))
+		require.False(t, Evaluate(ResourceReleases, ActionCreateRelease, configs))
+
+		require.True(t, EvaluateScoped(ResourceReleases, ActionGetRelease, configs, Scope{IsApplication: isNotWeb}))
+	})
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(AdminAllRole))
+	require.NoError(t, Validate(Config{
+		Rules: []Rule{
+			{
+				Resources:    []Resource{ResourceDevices},
+				Actions:      []Action{ActionSSH},
+				Effect:       EffectDeny,
+				DeviceGroups: []string{"production"},
+			},
+		},
+	}))
+	require.Error(t, Validate(Config{
+		Rules: []Rule{
+			{
+				Resources: []Resource{"device"},
+				Actions:   []Action{ActionSSH},
+			},
+		},
+	}))
+	require.Error(t, Validate(Config{
+		Rules: []Rule{
+			{
+				Resources: []Resource{ResourceDevices},
+				Actions:   []Action{"ssh"},
+			},
+		},
+	}))
+	require.Error(t, Validate(Config{
+		Rules: []Rule{
+			{
+				Resources: []Resource{ResourceDevices},
+				Actions:   []Action{ActionSSH},
+				Effect:    "maybe",
+			},
+		},
+	}))
+}
+
+func TestIsReadAction(t *testing.T) {
+	require.True(t, IsReadAction(ActionListDevices))
+	require.True(t, IsReadAction(ActionStreamEvents))
+	require.False(t, IsReadAction(ActionCreateRelease))
+	require.False(t, IsReadAction(ActionSSH))
+	require.False(t, IsReadAction(ActionListAuditLogEntries))
+	require.True(t, IsReadAction(ActionListAlerts))
+	require.False(t, IsReadAction(ActionCreateAlertSilence))
+	require.True(t, IsReadAction(ActionListDeviceViews))
+	require.False(t, IsReadAction(ActionCreateDeviceView))
 }
@@ -16,7 +16,42 @@ const (
 	ResourceReleases                      = Resource("releases")
 	ResourceDevices                       = Resource("devices")
 	ResourceDeviceLabels                  = Resource("devicelabels")
+	ResourceDeviceEnvironments            = Resource("deviceenvironments")
 	ResourceDeviceRegistrationTokens      = Resource("deviceregistrationtokens")
 	ResourceDeviceRegistrationTokenLabels = Resource("deviceregistrationtokenlabels")
 	ResourceProjectConfigs                = Resource("projectconfigs")
+	ResourceEnvironmentFiles              = Resource("environmentfiles")
+	ResourceConfigFiles                   = Resource("configfiles")
+	ResourceSessionRecordings             = Resource("sessionrecordings")
+	ResourceDeviceGroups                  = Resource("devicegroups")
+	ResourceDeviceViews                   = Resource("deviceviews")
+	ResourceRollouts                      = Resource("rollouts")
+	ResourceAuditLog                      = Resource("auditlog")
+	ResourceAlerts                        = Resource("alerts")
 )
+
+var resources = []Resource{
+	ResourceProjects,
+	ResourceRoles,
+	ResourceMemberships,
+	ResourceMembershipRoleBindings,
+	ResourceServiceAccounts,
+	ResourceServiceAccountAccessKeys,
+	ResourceServiceAccountRoleBindings,
+	ResourceApplications,
+	ResourceReleases,
+	ResourceDevices,
+	ResourceDeviceLabels,
+	ResourceDeviceEnvironments,
+	ResourceDeviceRegistrationTokens,
+	ResourceDeviceRegistrationTokenLabels,
+	ResourceProjectConfigs,
+	ResourceEnvironmentFiles,
+	ResourceConfigFiles,
+	ResourceSessionRecordings,
+	ResourceDeviceGroups,
+	ResourceDeviceViews,
+	ResourceRollouts,
+	ResourceAuditLog,
+	ResourceAlerts,
+}
@@ -0,0 +1,88 @@
+package clientaddr
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const forwardedForHeader = "X-Forwarded-For"
+
+// TrustedProxies are the networks of the load balancers and proxies in front
+// of the controller, whose X-Forwarded-For headers can be believed.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses a list of CIDRs or single IP addresses.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	var trustedProxies TrustedProxies
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			trustedProxies = append(trustedProxies, &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid trusted proxy %q", proxy)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return trustedProxies, nil
+}
+
+// RemoteAddr returns the address a request came from. That's the address of
+// its connection, unless the connection is from a trusted proxy, in which
+// case X-Forwarded-For is followed from the right, past any other trusted
+// proxies, to the first address that isn't one. Entries to the left of that
+// were given by the client and could be anything, so they're never used.
+func (t TrustedProxies) RemoteAddr(r *http.Request) string {
+	addr := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		addr = host
+	}
+	if !t.contains(addr) {
+		return addr
+	}
+
+	var hops []string
+	for _, header := range r.Header[forwardedForHeader] {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		addr = hop
+		if !t.contains(addr) {
+			break
+		}
+	}
+	return addr
+}
+
+func (t TrustedProxies) contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
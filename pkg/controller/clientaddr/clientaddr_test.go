@@ -0,0 +1,92 @@
+package clientaddr
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+	require.True(t, trustedProxies.contains("10.1.2.3"))
+	require.True(t, trustedProxies.contains("192.168.1.1"))
+	require.False(t, trustedProxies.contains("192.168.1.2"))
+	require.True(t, trustedProxies.contains("::1"))
+
+	_, err = ParseTrustedProxies([]string{"proxy"})
+	require.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+}
+
+func TestRemoteAddr(t *testing.T) {
+	trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name           string
+		trustedProxies TrustedProxies
+		remoteAddr     string
+		forwardedFor   []string
+		expected       string
+	}{
+		{
+			name:       "no header",
+			remoteAddr: "203.0.113.1:1234",
+			expected:   "203.0.113.1",
+		},
+		{
+			name:         "spoofed header without trusted proxies",
+			remoteAddr:   "203.0.113.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			expected:     "203.0.113.1",
+		},
+		{
+			name:           "spoofed header from an untrusted peer",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "203.0.113.1:1234",
+			forwardedFor:   []string{"198.51.100.1"},
+			expected:       "203.0.113.1",
+		},
+		{
+			name:           "from a trusted proxy",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"203.0.113.1"},
+			expected:       "203.0.113.1",
+		},
+		{
+			name:           "spoofed entry passed on by a trusted proxy",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"198.51.100.1, 203.0.113.1"},
+			expected:       "203.0.113.1",
+		},
+		{
+			name:           "through several trusted proxies",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"198.51.100.1, 203.0.113.1", "10.0.0.2"},
+			expected:       "203.0.113.1",
+		},
+		{
+			name:           "invalid entry",
+			trustedProxies: trustedProxies,
+			remoteAddr:     "10.0.0.1:1234",
+			forwardedFor:   []string{"203.0.113.1, unknown, 10.0.0.2"},
+			expected:       "10.0.0.2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			r.RemoteAddr = tc.remoteAddr
+			for _, forwardedFor := range tc.forwardedFor {
+				r.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+			require.Equal(t, tc.expected, tc.trustedProxies.RemoteAddr(r))
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package configfile
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/interpolation"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+var (
+	validName  = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+	validOwner = regexp.MustCompile(`^[0-9]+:[0-9]+$`)
+)
+
+// Validate checks everything about a config file except whether its name
+// is in use.
+func Validate(configFile models.ConfigFile) error {
+	if !validName.MatchString(configFile.Name) {
+		return errors.New("name can only contain letters, numbers and dashes")
+	}
+	if !path.IsAbs(configFile.Path) || path.Clean(configFile.Path) != configFile.Path || configFile.Path == "/" {
+		return errors.New("path must be a clean absolute path to a file")
+	}
+	if configFile.Mode > 0777 {
+		return errors.New("mode can only contain permission bits")
+	}
+	if configFile.Owner != "" && !validOwner.MatchString(configFile.Owner) {
+		return errors.New("owner must be a numeric uid:gid")
+	}
+	for _, service := range configFile.RestartServices {
+		if len(strings.Split(service, "/")) != 2 {
+			return fmt.Errorf("restart service '%s' must be application/service", service)
+		}
+	}
+	return nil
+}
+
+// Bundle resolves config files for a device. The device's environment is
+// interpolated into their content, and restart services are resolved to the
+// applications in the bundle. Services of applications that aren't in the
+// bundle don't run on the device, so they're left out.
+func Bundle(configFiles []models.ConfigFile, applications []models.FullBundledApplication, environment map[string]string) []models.BundledConfigFile {
+	applicationIDs := make(map[string]string)
+	for _, application := range applications {
+		applicationIDs[application.Application.Name] = application.Application.ID
+	}
+
+	bundledConfigFiles := make([]models.BundledConfigFile, 0, len(configFiles))
+	for _, configFile := range configFiles {
+		restartServices := make([]models.BundledServiceReference, 0)
+		for _, service := range configFile.RestartServices {
+			parts := strings.SplitN(service, "/", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			applicationID, ok := applicationIDs[parts[0]]
+			if !ok {
+				continue
+			}
+			restartServices = append(restartServices, models.BundledServiceReference{
+				ApplicationID: applicationID,
+				Service:       parts[1],
+			})
+		}
+
+		bundledConfigFiles = append(bundledConfigFiles, models.BundledConfigFile{
+			ID:              configFile.ID,
+			Path:            configFile.Path,
+			Content:         interpolation.InterpolateDefined(configFile.Content, environment),
+			Mode:            configFile.Mode,
+			Owner:           configFile.Owner,
+			RestartServices: restartServices,
+		})
+	}
+
+	return bundledConfigFiles
+}
@@ -0,0 +1,66 @@
+package configfile
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	configFile := models.ConfigFile{
+		Name:            "ntp",
+		Path:            "/etc/ntp.conf",
+		Mode:            0644,
+		Owner:           "0:0",
+		RestartServices: []string{"time/ntpd"},
+	}
+	require.NoError(t, Validate(configFile))
+
+	for _, mutate := range []func(*models.ConfigFile){
+		func(c *models.ConfigFile) { c.Name = "ntp conf" },
+		func(c *models.ConfigFile) { c.Path = "etc/ntp.conf" },
+		func(c *models.ConfigFile) { c.Path = "/etc/../ntp.conf" },
+		func(c *models.ConfigFile) { c.Path = "/" },
+		func(c *models.ConfigFile) { c.Mode = 04755 },
+		func(c *models.ConfigFile) { c.Owner = "root" },
+		func(c *models.ConfigFile) { c.RestartServices = []string{"ntpd"} },
+	} {
+		invalid := configFile
+		mutate(&invalid)
+		require.Error(t, Validate(invalid))
+	}
+}
+
+func TestBundle(t *testing.T) {
+	configFiles := []models.ConfigFile{
+		{
+			ID:              "cfg_1",
+			Path:            "/etc/ntp.conf",
+			Content:         "server ${NTP_SERVER}\nlog $LOG\n",
+			Mode:            0644,
+			RestartServices: []string{"time/ntpd", "other/service"},
+		},
+	}
+	applications := []models.FullBundledApplication{
+		{
+			Application: models.BundledApplication{
+				ID:   "app_1",
+				Name: "time",
+			},
+		},
+	}
+
+	bundled := Bundle(configFiles, applications, map[string]string{"NTP_SERVER": "pool.ntp.org"})
+	require.Equal(t, []models.BundledConfigFile{
+		{
+			ID:      "cfg_1",
+			Path:    "/etc/ntp.conf",
+			Content: "server pool.ntp.org\nlog $LOG\n",
+			Mode:    0644,
+			RestartServices: []models.BundledServiceReference{
+				{ApplicationID: "app_1", Service: "ntpd"},
+			},
+		},
+	}, bundled)
+}
@@ -0,0 +1,54 @@
+package connectivity
+
+import (
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	// HistoryPeriod is how far back a history goes by default
+	HistoryPeriod = 7 * 24 * time.Hour
+
+	// Retention is how long connectivity events are kept
+	Retention = 90 * 24 * time.Hour
+
+	// A device going offline FlapThreshold times within FlapWindow is
+	// flapping
+	FlapWindow    = time.Hour
+	FlapThreshold = 3
+)
+
+// History describes a device's connectivity from its events, which are
+// oldest first.
+func History(device models.Device, events []models.DeviceConnectivityEvent, now time.Time) models.DeviceConnectivityHistory {
+	history := models.DeviceConnectivityHistory{
+		Status: device.Status,
+		Events: events,
+	}
+
+	// Offline devices have been offline since they were last seen, whether
+	// or not that's been recorded yet
+	if device.Status == models.DeviceStatusOffline {
+		lastSeenAt := device.LastSeenAt
+		history.StatusSince = &lastSeenAt
+	} else {
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Status == device.Status {
+				at := events[i].At
+				history.StatusSince = &at
+				break
+			}
+		}
+	}
+
+	flapWindowStart := now.Add(-FlapWindow)
+	for _, event := range events {
+		if event.Status == models.DeviceStatusOffline && !event.At.Before(flapWindowStart) {
+			history.FlapCount++
+		}
+	}
+	history.Flapping = history.FlapCount >= FlapThreshold
+
+	return history
+}
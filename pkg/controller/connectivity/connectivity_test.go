@@ -0,0 +1,99 @@
+package connectivity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestHistory(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	event := func(status models.DeviceStatus, ago time.Duration) models.DeviceConnectivityEvent {
+		return models.DeviceConnectivityEvent{
+			Status: status,
+			At:     now.Add(-ago),
+		}
+	}
+
+	t.Run("online", func(t *testing.T) {
+		events := []models.DeviceConnectivityEvent{
+			event(models.DeviceStatusOffline, 3*time.Hour),
+			event(models.DeviceStatusOnline, 2*time.Hour),
+		}
+		history := History(models.Device{
+			Status:     models.DeviceStatusOnline,
+			LastSeenAt: now,
+		}, events, now)
+
+		require.Equal(t, models.DeviceStatusOnline, history.Status)
+		require.NotNil(t, history.StatusSince)
+		require.Equal(t, now.Add(-2*time.Hour), *history.StatusSince)
+		require.False(t, history.Flapping)
+		require.Equal(t, 0, history.FlapCount)
+		require.Len(t, history.Events, 2)
+	})
+
+	t.Run("online with no events", func(t *testing.T) {
+		history := History(models.Device{
+			Status:     models.DeviceStatusOnline,
+			LastSeenAt: now,
+		}, nil, now)
+
+		require.Nil(t, history.StatusSince)
+		require.False(t, history.Flapping)
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		lastSeenAt := now.Add(-72 * time.Hour)
+		history := History(models.Device{
+			Status:     models.DeviceStatusOffline,
+			LastSeenAt: lastSeenAt,
+		}, nil, now)
+
+		require.NotNil(t, history.StatusSince)
+		require.Equal(t, lastSeenAt, *history.StatusSince)
+		require.False(t, history.Flapping)
+	})
+
+	t.Run("flapping", func(t *testing.T) {
+		events := []models.DeviceConnectivityEvent{
+			event(models.DeviceStatusOffline, 2*time.Hour),
+			event(models.DeviceStatusOnline, 90*time.Minute),
+			event(models.DeviceStatusOffline, 50*time.Minute),
+			event(models.DeviceStatusOnline, 45*time.Minute),
+			event(models.DeviceStatusOffline, 30*time.Minute),
+			event(models.DeviceStatusOnline, 25*time.Minute),
+			event(models.DeviceStatusOffline, 10*time.Minute),
+			event(models.DeviceStatusOnline, 5*time.Minute),
+		}
+		history := History(models.Device{
+			Status:     models.DeviceStatusOnline,
+			LastSeenAt: now,
+		}, events, now)
+
+		require.True(t, history.Flapping)
+		require.Equal(t, 3, history.FlapCount)
+		require.Equal(t, now.Add(-5*time.Minute), *history.StatusSince)
+	})
+
+	t.Run("blips outside the window", func(t *testing.T) {
+		events := []models.DeviceConnectivityEvent{
+			event(models.DeviceStatusOffline, 3*time.Hour),
+			event(models.DeviceStatusOnline, 170*time.Minute),
+			event(models.DeviceStatusOffline, 2*time.Hour),
+			event(models.DeviceStatusOnline, 110*time.Minute),
+			event(models.DeviceStatusOffline, 30*time.Minute),
+			event(models.DeviceStatusOnline, 25*time.Minute),
+		}
+		history := History(models.Device{
+			Status:     models.DeviceStatusOnline,
+			LastSeenAt: now,
+		}, events, now)
+
+		require.False(t, history.Flapping)
+		require.Equal(t, 1, history.FlapCount)
+	})
+}
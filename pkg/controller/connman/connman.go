@@ -2,38 +2,183 @@ package connman
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"net"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
 	"github.com/deviceplane/deviceplane/pkg/revdial"
+	"github.com/function61/holepunch-server/pkg/wsconnadapter"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+)
+
+const (
+	// RelayPath is where controllers relay connections to the devices
+	// connected to them, followed by the connection's key.
+	RelayPath = "/api/internal/deviceconnections/"
+
+	ControllerSecretHeader = "X-Deviceplane-Controller-Secret"
 )
 
 var (
 	ErrNoConnection = errors.New("no connection")
 )
 
-// ConnectionManager manages connections to devices
-// It's pretty simple now but it'll be responsible for more
-// once we support running multiple controllers
+type dialer interface {
+	Dial(ctx context.Context) (net.Conn, error)
+	Done() <-chan struct{}
+	Close() error
+}
+
+// ConnectionManager manages connections to devices. When several
+// controllers share a store, which controller each device is connected to
+// is recorded in it, and dials to devices connected to other controllers
+// are relayed through them.
 type ConnectionManager struct {
-	deviceDialers map[string]*revdial.Dialer
+	deviceDialers map[string]dialer
 	lock          sync.RWMutex
+	// removals tracks the goroutines that forget connections once they
+	// close
+	removals sync.WaitGroup
+
+	deviceConnections store.DeviceConnections
+	addr              string
+	secret            string
 }
 
 func New() *ConnectionManager {
 	return &ConnectionManager{
-		deviceDialers: make(map[string]*revdial.Dialer),
+		deviceDialers: make(map[string]dialer),
+	}
+}
+
+// NewShared returns a connection manager for a controller that shares its
+// store with others. addr is the base URL the other controllers reach this
+// one at, and secret is shared by all of them to authenticate relays.
+func NewShared(deviceConnections store.DeviceConnections, addr, secret string) *ConnectionManager {
+	return &ConnectionManager{
+		deviceDialers:     make(map[string]dialer),
+		deviceConnections: deviceConnections,
+		addr:              addr,
+		secret:            secret,
 	}
 }
 
+// Set registers a connection from a device that dials back to the
+// controller for every session.
 func (m *ConnectionManager) Set(key string, conn net.Conn) {
+	m.set(key, revdial.NewDialer(conn, "/revdial"))
+}
+
+// SetMultiplexed registers a connection from a device that accepts
+// sessions as yamux streams over that same connection.
+func (m *ConnectionManager) SetMultiplexed(key string, conn net.Conn) error {
+	session, err := yamux.Client(conn, nil)
+	if err != nil {
+		return err
+	}
+	m.set(key, &sessionDialer{
+		session: session,
+	})
+	return nil
+}
+
+func (m *ConnectionManager) set(key string, d dialer) {
 	m.lock.Lock()
-	m.deviceDialers[key] = revdial.NewDialer(conn, "/revdial")
+	previous, ok := m.deviceDialers[key]
+	m.deviceDialers[key] = d
 	m.lock.Unlock()
+
+	if ok {
+		previous.Close()
+	}
+
+	if m.deviceConnections != nil {
+		if err := m.deviceConnections.SetDeviceConnection(context.Background(), key, m.addr); err != nil {
+			log.WithError(err).Error("set device connection")
+		}
+	}
+
+	m.removals.Add(1)
+	go func() {
+		defer m.removals.Done()
+
+		<-d.Done()
+
+		m.lock.Lock()
+		current := m.deviceDialers[key] == d
+		if current {
+			delete(m.deviceDialers, key)
+		}
+		m.lock.Unlock()
+
+		// The device may have reconnected to another controller already, in
+		// which case its connection isn't ours to delete
+		if current && m.deviceConnections != nil {
+			if err := m.deviceConnections.DeleteDeviceConnection(context.Background(), key, m.addr); err != nil {
+				log.WithError(err).Error("delete device connection")
+			}
+		}
+	}()
+}
+
+// Count returns how many devices are connected to this controller.
+func (m *ConnectionManager) Count() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return len(m.deviceDialers)
 }
 
+// Close closes every device connection, so devices reconnect to another
+// controller, and waits for them to be forgotten or for ctx to be done.
+func (m *ConnectionManager) Close(ctx context.Context) error {
+	m.lock.RLock()
+	for _, d := range m.deviceDialers {
+		d.Close()
+	}
+	m.lock.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.removals.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Dial opens a connection to a device, relaying it through the controller
+// the device is connected to if that's another one.
 func (m *ConnectionManager) Dial(ctx context.Context, key string) (net.Conn, error) {
+	conn, err := m.DialLocal(ctx, key)
+	if err != ErrNoConnection || m.deviceConnections == nil {
+		return conn, err
+	}
+
+	controller, err := m.deviceConnections.GetDeviceConnection(ctx, key)
+	if err == store.ErrDeviceConnectionNotFound || controller == m.addr {
+		return nil, ErrNoConnection
+	} else if err != nil {
+		return nil, err
+	}
+
+	return m.dialRelay(ctx, controller, key)
+}
+
+// DialLocal opens a connection to a device only if it's connected to this
+// controller.
+func (m *ConnectionManager) DialLocal(ctx context.Context, key string) (net.Conn, error) {
 	m.lock.RLock()
 	dialer, ok := m.deviceDialers[key]
 	if !ok {
@@ -44,3 +189,78 @@ func (m *ConnectionManager) Dial(ctx context.Context, key string) (net.Conn, err
 
 	return dialer.Dial(ctx)
 }
+
+func (m *ConnectionManager) dialRelay(ctx context.Context, controller, key string) (net.Conn, error) {
+	relayURL, err := url.Parse(controller)
+	if err != nil {
+		return nil, err
+	}
+	switch relayURL.Scheme {
+	case "https":
+		relayURL.Scheme = "wss"
+	default:
+		relayURL.Scheme = "ws"
+	}
+	relayURL.Path = strings.TrimSuffix(relayURL.Path, "/") + RelayPath + url.PathEscape(key)
+
+	header := http.Header{}
+	header.Set(ControllerSecretHeader, m.secret)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, relayURL.String(), header)
+	if err != nil {
+		// The device disconnected from the other controller
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNoConnection
+		}
+		return nil, err
+	}
+
+	return wsconnadapter.New(conn), nil
+}
+
+// RelayAuthorized returns whether a request to relay a connection came from
+// another controller sharing the store.
+func (m *ConnectionManager) RelayAuthorized(r *http.Request) bool {
+	if m.deviceConnections == nil || m.secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get(ControllerSecretHeader)), []byte(m.secret)) == 1
+}
+
+type sessionDialer struct {
+	session *yamux.Session
+}
+
+// Dial opens a stream, giving up once ctx is done. yamux can't cancel
+// opening a stream, so one opened after that is closed.
+func (d *sessionDialer) Dial(ctx context.Context) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := d.session.Open()
+		results <- result{conn, err}
+	}()
+
+	select {
+	case result := <-results:
+		return result.conn, result.err
+	case <-ctx.Done():
+		go func() {
+			if result := <-results; result.conn != nil {
+				result.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+func (d *sessionDialer) Done() <-chan struct{} {
+	return d.session.CloseChan()
+}
+
+func (d *sessionDialer) Close() error {
+	return d.session.Close()
+}
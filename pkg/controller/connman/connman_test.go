@@ -0,0 +1,205 @@
+package connman
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/function61/holepunch-server/pkg/wsconnadapter"
+	"github.com/gorilla/websocket"
+	"github.com/hashicorp/yamux"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeviceConnections struct {
+	lock        sync.Mutex
+	controllers map[string]string
+}
+
+func (f *fakeDeviceConnections) SetDeviceConnection(ctx context.Context, key, controller string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.controllers[key] = controller
+	return nil
+}
+
+func (f *fakeDeviceConnections) GetDeviceConnection(ctx context.Context, key string) (string, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	controller, ok := f.controllers[key]
+	if !ok {
+		return "", store.ErrDeviceConnectionNotFound
+	}
+	return controller, nil
+}
+
+func (f *fakeDeviceConnections) DeleteDeviceConnection(ctx context.Context, key, controller string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.controllers[key] == controller {
+		delete(f.controllers, key)
+	}
+	return nil
+}
+
+// serveEcho accepts sessions from a multiplexed device connection and
+// echoes them back.
+func serveEcho(t *testing.T, deviceConn net.Conn) *yamux.Session {
+	session, err := yamux.Server(deviceConn, nil)
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			stream, err := session.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer stream.Close()
+				io.Copy(stream, stream)
+			}()
+		}
+	}()
+
+	return session
+}
+
+func TestMultiplexed(t *testing.T) {
+	controllerConn, deviceConn := net.Pipe()
+
+	m := New()
+	require.Equal(t, 0, m.Count())
+	require.NoError(t, m.SetMultiplexed("device", controllerConn))
+	require.Equal(t, 1, m.Count())
+
+	session, err := yamux.Server(deviceConn, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	go func() {
+		for {
+			stream, err := session.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer stream.Close()
+				io.Copy(stream, stream)
+			}()
+		}
+	}()
+
+	var conns []net.Conn
+	for _, message := range []string{"first", "second"} {
+		conn, err := m.Dial(context.Background(), "device")
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte(message))
+		require.NoError(t, err)
+		conns = append(conns, conn)
+	}
+
+	for i, message := range []string{"first", "second"} {
+		buf := make([]byte, len(message))
+		_, err := io.ReadFull(conns[i], buf)
+		require.NoError(t, err)
+		require.Equal(t, message, string(buf))
+	}
+
+	_, err = m.Dial(context.Background(), "other")
+	require.Equal(t, ErrNoConnection, err)
+
+	require.NoError(t, m.Close(context.Background()))
+	require.Equal(t, 0, m.Count())
+}
+
+func TestShared(t *testing.T) {
+	deviceConnections := &fakeDeviceConnections{
+		controllers: make(map[string]string),
+	}
+
+	var a *ConnectionManager
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.RelayAuthorized(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		deviceConn, err := a.DialLocal(r.Context(), strings.TrimPrefix(r.URL.Path, RelayPath))
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		defer deviceConn.Close()
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		relayConn := wsconnadapter.New(conn)
+		defer relayConn.Close()
+
+		go io.Copy(deviceConn, relayConn)
+		io.Copy(relayConn, deviceConn)
+	}))
+	defer server.Close()
+
+	a = NewShared(deviceConnections, server.URL, "secret")
+	b := NewShared(deviceConnections, "http://b.example.com", "secret")
+
+	controllerConn, deviceConn := net.Pipe()
+	require.NoError(t, a.SetMultiplexed("device", controllerConn))
+	session := serveEcho(t, deviceConn)
+
+	conn, err := b.Dial(context.Background(), "device")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("relayed"))
+	require.NoError(t, err)
+	buf := make([]byte, len("relayed"))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, "relayed", string(buf))
+
+	_, err = b.Dial(context.Background(), "other")
+	require.Equal(t, ErrNoConnection, err)
+
+	// Disconnected devices are forgotten
+	session.Close()
+	for deadline := time.Now().Add(time.Second); ; {
+		_, err := deviceConnections.GetDeviceConnection(context.Background(), "device")
+		if err == store.ErrDeviceConnectionNotFound {
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "device connection not deleted")
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, err = b.Dial(context.Background(), "device")
+	require.Equal(t, ErrNoConnection, err)
+}
+
+func TestMultiplexedDialCanceled(t *testing.T) {
+	// Nothing reads from the device's end, so opening a stream hangs
+	controllerConn, deviceConn := net.Pipe()
+	defer deviceConn.Close()
+
+	m := New()
+	require.NoError(t, m.SetMultiplexed("device", controllerConn))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	_, err := m.Dial(ctx, "device")
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.True(t, time.Since(startedAt) < time.Second)
+}
@@ -0,0 +1,103 @@
+package devicegroups
+
+import (
+	"context"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/pkg/errors"
+)
+
+var ErrNestedGroup = errors.New("device group queries can't refer to other device groups")
+
+// ValidateQuery checks the query of a device group. Groups can't be defined
+// in terms of other groups, so that devices' groups can be worked out in a
+// single pass.
+func ValidateQuery(q models.Query) error {
+	for _, filter := range q {
+		for _, condition := range filter {
+			if condition.Type == models.DeviceGroupCondition {
+				return ErrNestedGroup
+			}
+		}
+	}
+	return query.ValidateQuery(q)
+}
+
+// SetGroups fills in the groups that each device is in. A device is in a
+// group if it was added to it or if it matches the group's query.
+func SetGroups(devices []models.Device, groups []models.DeviceGroup, members []models.DeviceGroupMember) error {
+	membersByGroup := make(map[string]map[string]bool)
+	for _, member := range members {
+		if membersByGroup[member.DeviceGroupID] == nil {
+			membersByGroup[member.DeviceGroupID] = make(map[string]bool)
+		}
+		membersByGroup[member.DeviceGroupID][member.DeviceID] = true
+	}
+
+	for i := range devices {
+		devices[i].Groups = make([]string, 0)
+		for _, group := range groups {
+			inGroup := membersByGroup[group.ID][devices[i].ID]
+			if !inGroup && group.Query != nil {
+				var err error
+				inGroup, err = query.DeviceMatchesQuery(devices[i], *group.Query)
+				if err != nil {
+					return errors.Wrapf(err, "device group %s", group.Name)
+				}
+			}
+			if inGroup {
+				devices[i].Groups = append(devices[i].Groups, group.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Load fills in the groups that each device in a project is in. It
+// returns the project's groups.
+func Load(ctx context.Context, deviceGroups store.DeviceGroups, projectID string, devices []models.Device) ([]models.DeviceGroup, error) {
+	groups, err := deviceGroups.ListDeviceGroups(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list device groups")
+	}
+
+	members, err := deviceGroups.ListDeviceGroupMembers(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list device group members")
+	}
+
+	return groups, SetGroups(devices, groups, members)
+}
+
+// InAny returns whether a device is in any of the given groups, which may
+// be IDs or names.
+func InAny(device models.Device, groups []models.DeviceGroup, names []string) bool {
+	for _, name := range names {
+		for _, group := range groups {
+			if name != group.ID && name != group.Name {
+				continue
+			}
+			for _, id := range device.Groups {
+				if id == group.ID {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// LoadDevice fills in the groups that a single device is in. It returns
+// the device's project's groups.
+func LoadDevice(ctx context.Context, deviceGroups store.DeviceGroups, projectID string, device *models.Device) ([]models.DeviceGroup, error) {
+	devices := []models.Device{*device}
+	groups, err := Load(ctx, deviceGroups, projectID, devices)
+	if err != nil {
+		return nil, err
+	}
+	*device = devices[0]
+	return groups, nil
+}
@@ -0,0 +1,83 @@
+package devicegroups
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetGroups(t *testing.T) {
+	devices := []models.Device{
+		{
+			ID:     "dev_1",
+			Labels: map[string]string{"site": "lab"},
+		},
+		{
+			ID:     "dev_2",
+			Labels: map[string]string{"site": "field"},
+		},
+		{
+			ID:     "dev_3",
+			Labels: map[string]string{},
+		},
+	}
+	groups := []models.DeviceGroup{
+		{
+			ID:   "dgp_static",
+			Name: "static",
+		},
+		{
+			ID:   "dgp_lab",
+			Name: "lab",
+			Query: &models.Query{
+				models.Filter{
+					models.Condition{
+						Type: models.LabelValueCondition,
+						Params: map[string]interface{}{
+							"key":      "site",
+							"operator": string(models.OperatorIs),
+							"value":    "lab",
+						},
+					},
+				},
+			},
+		},
+	}
+	members := []models.DeviceGroupMember{
+		{
+			DeviceGroupID: "dgp_static",
+			DeviceID:      "dev_2",
+		},
+		// Devices that were added to a group with a query are in it
+		// whether or not they match
+		{
+			DeviceGroupID: "dgp_lab",
+			DeviceID:      "dev_3",
+		},
+	}
+
+	require.NoError(t, SetGroups(devices, groups, members))
+	require.Equal(t, []string{"dgp_lab"}, devices[0].Groups)
+	require.Equal(t, []string{"dgp_static"}, devices[1].Groups)
+	require.Equal(t, []string{"dgp_lab"}, devices[2].Groups)
+
+	require.True(t, InAny(devices[0], groups, []string{"lab"}))
+	require.True(t, InAny(devices[0], groups, []string{"static", "dgp_lab"}))
+	require.False(t, InAny(devices[0], groups, []string{"static"}))
+	require.False(t, InAny(devices[0], groups, []string{"unknown"}))
+}
+
+func TestValidateQuery(t *testing.T) {
+	require.Equal(t, ErrNestedGroup, ValidateQuery(models.Query{
+		models.Filter{
+			models.Condition{
+				Type: models.DeviceGroupCondition,
+				Params: map[string]interface{}{
+					"group":    "dgp_lab",
+					"operator": string(models.OperatorIs),
+				},
+			},
+		},
+	}))
+}
@@ -0,0 +1,157 @@
+package drift
+
+import (
+	"sort"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/rollout"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Desired is the release of an application that a device should run.
+type Desired struct {
+	Application models.Application
+	Release     models.Release
+	// Since is when the device was first meant to run the release, from
+	// which it has the update grace period to report running it
+	Since time.Time
+	// Held devices are waiting for a maintenance window to update
+	Held bool
+}
+
+// severities orders statuses from best to worst
+var severities = map[models.DriftStatus]int{
+	models.DriftStatusInSync:  0,
+	models.DriftStatusPending: 1,
+	models.DriftStatusUnknown: 2,
+	models.DriftStatusFailing: 3,
+}
+
+func worst(a, b models.DriftStatus) models.DriftStatus {
+	if severities[b] > severities[a] {
+		return b
+	}
+	return a
+}
+
+// Device compares the releases a device should run with the application and
+// service statuses it reports.
+func Device(
+	device models.Device,
+	desired []Desired,
+	applicationStatuses []models.DeviceApplicationStatus,
+	serviceStatuses []models.DeviceServiceStatus,
+	now time.Time,
+) models.DeviceDrift {
+	deviceDrift := models.DeviceDrift{
+		DeviceID:     device.ID,
+		DeviceName:   device.Name,
+		Status:       models.DriftStatusInSync,
+		Applications: make([]models.ApplicationDrift, 0, len(desired)),
+	}
+
+	for _, d := range desired {
+		applicationDrift := models.ApplicationDrift{
+			ApplicationID:    d.Application.ID,
+			ApplicationName:  d.Application.Name,
+			DesiredReleaseID: d.Release.ID,
+			Status:           models.DriftStatusInSync,
+			Services:         make([]models.ServiceDrift, 0, len(d.Release.Config)),
+		}
+		for _, applicationStatus := range applicationStatuses {
+			if applicationStatus.ApplicationID == d.Application.ID {
+				applicationDrift.CurrentReleaseID = applicationStatus.CurrentReleaseID
+			}
+		}
+
+		services := make([]string, 0, len(d.Release.Config))
+		for service := range d.Release.Config {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+
+		for _, service := range services {
+			serviceDrift := models.ServiceDrift{
+				Service: service,
+			}
+			for _, serviceStatus := range serviceStatuses {
+				if serviceStatus.ApplicationID == d.Application.ID && serviceStatus.Service == service {
+					serviceDrift.CurrentReleaseID = serviceStatus.CurrentReleaseID
+				}
+			}
+			serviceDrift.Status = status(device, d, serviceDrift.CurrentReleaseID, now)
+
+			applicationDrift.Status = worst(applicationDrift.Status, serviceDrift.Status)
+			applicationDrift.Services = append(applicationDrift.Services, serviceDrift)
+		}
+
+		deviceDrift.Status = worst(deviceDrift.Status, applicationDrift.Status)
+		deviceDrift.Applications = append(deviceDrift.Applications, applicationDrift)
+	}
+
+	return deviceDrift
+}
+
+func status(device models.Device, desired Desired, currentReleaseID string, now time.Time) models.DriftStatus {
+	switch {
+	case currentReleaseID == desired.Release.ID:
+		return models.DriftStatusInSync
+	case device.Status != models.DeviceStatusOnline:
+		return models.DriftStatusUnknown
+	case desired.Held || now.Sub(desired.Since) < rollout.UpdateGracePeriod:
+		return models.DriftStatusPending
+	default:
+		return models.DriftStatusFailing
+	}
+}
+
+func count(summary *models.DriftSummary, status models.DriftStatus) {
+	switch status {
+	case models.DriftStatusInSync:
+		summary.InSync++
+	case models.DriftStatusPending:
+		summary.Pending++
+	case models.DriftStatusFailing:
+		summary.Failing++
+	case models.DriftStatusUnknown:
+		summary.Unknown++
+	}
+}
+
+// Report summarizes the drift of devices, overall and for each application.
+// Applications are ordered by name.
+func Report(devices []models.DeviceDrift) models.DriftReport {
+	report := models.DriftReport{
+		Applications: make([]models.ApplicationDriftSummary, 0),
+		Devices:      devices,
+	}
+	if report.Devices == nil {
+		report.Devices = make([]models.DeviceDrift, 0)
+	}
+
+	applicationSummaries := make(map[string]*models.ApplicationDriftSummary)
+	for _, device := range devices {
+		count(&report.Summary, device.Status)
+
+		for _, application := range device.Applications {
+			applicationSummary, ok := applicationSummaries[application.ApplicationID]
+			if !ok {
+				applicationSummary = &models.ApplicationDriftSummary{
+					ApplicationID:   application.ApplicationID,
+					ApplicationName: application.ApplicationName,
+				}
+				applicationSummaries[application.ApplicationID] = applicationSummary
+			}
+			count(&applicationSummary.Summary, application.Status)
+		}
+	}
+
+	for _, applicationSummary := range applicationSummaries {
+		report.Applications = append(report.Applications, *applicationSummary)
+	}
+	sort.Slice(report.Applications, func(i, j int) bool {
+		return report.Applications[i].ApplicationName < report.Applications[j].ApplicationName
+	})
+
+	return report
+}
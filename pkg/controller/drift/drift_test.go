@@ -0,0 +1,99 @@
+package drift
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestDevice(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	online := models.Device{ID: "dev_1", Name: "a", Status: models.DeviceStatusOnline}
+
+	web := models.Application{ID: "app_1", Name: "web"}
+	release := models.Release{
+		ID: "rel_2",
+		Config: map[string]models.Service{
+			"nginx": {},
+			"app":   {},
+		},
+	}
+	desired := func(since time.Time, held bool) []Desired {
+		return []Desired{{Application: web, Release: release, Since: since, Held: held}}
+	}
+	statuses := func(nginx, app string) []models.DeviceServiceStatus {
+		return []models.DeviceServiceStatus{
+			{ApplicationID: "app_1", Service: "nginx", CurrentReleaseID: nginx},
+			{ApplicationID: "app_1", Service: "app", CurrentReleaseID: app},
+		}
+	}
+	applicationStatuses := []models.DeviceApplicationStatus{
+		{ApplicationID: "app_1", CurrentReleaseID: "rel_1"},
+	}
+
+	drift := Device(online, desired(now.Add(-time.Hour), false), applicationStatuses, statuses("rel_2", "rel_2"), now)
+	require.Equal(t, models.DriftStatusInSync, drift.Status)
+
+	drift = Device(online, desired(now.Add(-time.Hour), false), applicationStatuses, statuses("rel_2", "rel_1"), now)
+	require.Equal(t, models.DriftStatusFailing, drift.Status)
+	require.Len(t, drift.Applications, 1)
+	require.Equal(t, "rel_2", drift.Applications[0].DesiredReleaseID)
+	require.Equal(t, "rel_1", drift.Applications[0].CurrentReleaseID)
+	require.Equal(t, []models.ServiceDrift{
+		{Service: "app", CurrentReleaseID: "rel_1", Status: models.DriftStatusFailing},
+		{Service: "nginx", CurrentReleaseID: "rel_2", Status: models.DriftStatusInSync},
+	}, drift.Applications[0].Services)
+
+	drift = Device(online, desired(now.Add(-time.Minute), false), applicationStatuses, statuses("rel_1", "rel_1"), now)
+	require.Equal(t, models.DriftStatusPending, drift.Status)
+
+	drift = Device(online, desired(now.Add(-time.Hour), true), applicationStatuses, nil, now)
+	require.Equal(t, models.DriftStatusPending, drift.Status)
+
+	offline := online
+	offline.Status = models.DeviceStatusOffline
+	drift = Device(offline, desired(now.Add(-time.Hour), false), applicationStatuses, statuses("rel_1", "rel_1"), now)
+	require.Equal(t, models.DriftStatusUnknown, drift.Status)
+
+	drift = Device(online, nil, nil, nil, now)
+	require.Equal(t, models.DriftStatusInSync, drift.Status)
+	require.Empty(t, drift.Applications)
+}
+
+func TestReport(t *testing.T) {
+	report := Report([]models.DeviceDrift{
+		{
+			DeviceID: "dev_1",
+			Status:   models.DriftStatusFailing,
+			Applications: []models.ApplicationDrift{
+				{ApplicationID: "app_2", ApplicationName: "web", Status: models.DriftStatusFailing},
+				{ApplicationID: "app_1", ApplicationName: "agent", Status: models.DriftStatusInSync},
+			},
+		},
+		{
+			DeviceID: "dev_2",
+			Status:   models.DriftStatusPending,
+			Applications: []models.ApplicationDrift{
+				{ApplicationID: "app_2", ApplicationName: "web", Status: models.DriftStatusPending},
+			},
+		},
+		{
+			DeviceID: "dev_3",
+			Status:   models.DriftStatusInSync,
+		},
+	})
+
+	require.Equal(t, models.DriftSummary{InSync: 1, Pending: 1, Failing: 1}, report.Summary)
+	require.Equal(t, []models.ApplicationDriftSummary{
+		{ApplicationID: "app_1", ApplicationName: "agent", Summary: models.DriftSummary{InSync: 1}},
+		{ApplicationID: "app_2", ApplicationName: "web", Summary: models.DriftSummary{Pending: 1, Failing: 1}},
+	}, report.Applications)
+	require.Len(t, report.Devices, 3)
+
+	report = Report(nil)
+	require.NotNil(t, report.Devices)
+	require.Empty(t, report.Applications)
+}
@@ -0,0 +1,36 @@
+package environment
+
+import (
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Resolve returns a device's environment. It starts with the environment of
+// every group in config that the device matches, in order, and then adds the
+// device's own environment, so later groups and the device itself take
+// precedence.
+func Resolve(device models.Device, config models.DeviceEnvironmentConfig, deviceEnvironment map[string]string) (map[string]string, error) {
+	environment := make(map[string]string)
+
+	for _, group := range config.Groups {
+		if group.Query != nil {
+			matches, err := query.DeviceMatchesQuery(device, *group.Query)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+		}
+
+		for key, value := range group.Environment {
+			environment[key] = value
+		}
+	}
+
+	for key, value := range deviceEnvironment {
+		environment[key] = value
+	}
+
+	return environment, nil
+}
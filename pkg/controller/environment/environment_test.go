@@ -0,0 +1,58 @@
+package environment
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	config := models.DeviceEnvironmentConfig{
+		Groups: []models.DeviceEnvironmentGroup{
+			{
+				Name: "all",
+				Environment: map[string]string{
+					"SITE":      "default",
+					"LOG_LEVEL": "info",
+				},
+			},
+			{
+				Name: "north",
+				Query: &models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.LabelValueCondition,
+							Params: map[string]interface{}{
+								"key":      "site",
+								"operator": string(models.OperatorIs),
+								"value":    "north",
+							},
+						},
+					},
+				},
+				Environment: map[string]string{
+					"SITE": "north",
+				},
+			},
+		},
+	}
+
+	device := models.Device{
+		ID:     "dev_1",
+		Labels: map[string]string{},
+	}
+
+	environment, err := Resolve(device, config, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"SITE": "default", "LOG_LEVEL": "info"}, environment)
+
+	device.Labels["site"] = "north"
+	environment, err = Resolve(device, config, nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"SITE": "north", "LOG_LEVEL": "info"}, environment)
+
+	environment, err = Resolve(device, config, map[string]string{"LOG_LEVEL": "debug"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"SITE": "north", "LOG_LEVEL": "debug"}, environment)
+}
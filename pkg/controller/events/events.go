@@ -0,0 +1,375 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	EventTypeHeader = "X-Deviceplane-Event"
+
+	timeout          = 10 * time.Second
+	maxAttempts      = 4
+	retryBackoff     = 2 * time.Second
+	maxErrorBodySize = 512
+
+	subscriberBufferSize = 100
+)
+
+var (
+	ErrMissingName = errors.New("event webhooks need a name")
+	ErrInvalidURL  = errors.New("event webhook URLs must be http or https URLs")
+)
+
+// Publisher sends events to the webhooks and notification channels of the
+// project they happened in, and to the project's subscribers.
+type Publisher struct {
+	// pendingDeliveries is first so it's aligned for atomic operations on
+	// 32-bit platforms
+	pendingDeliveries int64
+
+	eventWebhooksConfigs store.EventWebhooksConfigs
+	alertsConfigs        store.AlertsConfigs
+	notifications        *notifications.Sender
+	st                   *statsd.Client
+
+	lock        sync.Mutex
+	subscribers map[string]map[chan models.Event]struct{}
+
+	deliveries sync.WaitGroup
+}
+
+func NewPublisher(eventWebhooksConfigs store.EventWebhooksConfigs, alertsConfigs store.AlertsConfigs, notifications *notifications.Sender, st *statsd.Client) *Publisher {
+	return &Publisher{
+		eventWebhooksConfigs: eventWebhooksConfigs,
+		alertsConfigs:        alertsConfigs,
+		notifications:        notifications,
+		st:                   st,
+		subscribers:          make(map[string]map[chan models.Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel of a project's events as they're published,
+// and a function that unsubscribes. Events are dropped for subscribers that
+// fall behind rather than holding up publishing.
+func (p *Publisher) Subscribe(projectID string) (<-chan models.Event, func()) {
+	events := make(chan models.Event, subscriberBufferSize)
+
+	p.lock.Lock()
+	if p.subscribers[projectID] == nil {
+		p.subscribers[projectID] = make(map[chan models.Event]struct{})
+	}
+	p.subscribers[projectID][events] = struct{}{}
+	p.lock.Unlock()
+
+	return events, func() {
+		p.lock.Lock()
+		delete(p.subscribers[projectID], events)
+		if len(p.subscribers[projectID]) == 0 {
+			delete(p.subscribers, projectID)
+		}
+		p.lock.Unlock()
+	}
+}
+
+// Subscribed returns whether a project has any subscribers.
+func (p *Publisher) Subscribed(projectID string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.subscribers[projectID]) > 0
+}
+
+// Publish sends an event to its project's subscribers, and to each of the
+// project's webhooks and notification channels that subscribe to its type.
+// Sending to webhooks and channels happens in the background and is retried
+// a few times with backoff, so it never holds up whatever the event is
+// about.
+func (p *Publisher) Publish(ctx context.Context, event models.Event) {
+	event.ID = fmt.Sprintf("evt_%s", ksuid.New().String())
+	event.CreatedAt = time.Now()
+
+	p.lock.Lock()
+	for subscriber := range p.subscribers[event.ProjectID] {
+		select {
+		case subscriber <- event:
+		default:
+			p.st.Incr("events.subscriber.dropped", []string{fmt.Sprintf("project_id:%s", event.ProjectID)}, 1)
+		}
+	}
+	p.lock.Unlock()
+
+	p.publishToWebhooks(ctx, event)
+	p.publishToChannels(ctx, event)
+}
+
+func (p *Publisher) publishToWebhooks(ctx context.Context, event models.Event) {
+	config, err := p.eventWebhooksConfigs.GetEventWebhooksConfig(ctx, event.ProjectID)
+	if err != nil {
+		log.WithField("project_id", event.ProjectID).
+			WithError(err).Error("get event webhooks config")
+		return
+	}
+
+	var webhooks []models.EventWebhook
+	for _, webhook := range config.Webhooks {
+		if Subscribes(webhook, event.Type) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.WithError(err).Error("marshal event")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		webhook := webhook
+		p.startDelivery(event, "webhook", webhook.Name, func() error {
+			return Send(context.Background(), webhook, event.Type, body)
+		})
+	}
+}
+
+func (p *Publisher) publishToChannels(ctx context.Context, event models.Event) {
+	config, err := p.alertsConfigs.GetAlertsConfig(ctx, event.ProjectID)
+	if err != nil {
+		log.WithField("project_id", event.ProjectID).
+			WithError(err).Error("get alerts config")
+		return
+	}
+
+	for _, channel := range config.Channels {
+		if !notifications.Subscribes(channel, event.Type) {
+			continue
+		}
+		channel := channel
+		p.startDelivery(event, "channel", channel.Name, func() error {
+			return p.notifications.Send(context.Background(), channel, Message(event))
+		})
+	}
+}
+
+// Wants returns whether anything would be sent an event of a type, so
+// events that take work to put together can be skipped when nothing is
+// listening.
+func (p *Publisher) Wants(ctx context.Context, projectID string, eventType models.EventType) (bool, error) {
+	if p.Subscribed(projectID) {
+		return true, nil
+	}
+
+	eventWebhooksConfig, err := p.eventWebhooksConfigs.GetEventWebhooksConfig(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+	for _, webhook := range eventWebhooksConfig.Webhooks {
+		if Subscribes(webhook, eventType) {
+			return true, nil
+		}
+	}
+
+	alertsConfig, err := p.alertsConfigs.GetAlertsConfig(ctx, projectID)
+	if err != nil {
+		return false, err
+	}
+	for _, channel := range alertsConfig.Channels {
+		if notifications.Subscribes(channel, eventType) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Pending returns how many deliveries are being sent or waiting to be
+// retried.
+func (p *Publisher) Pending() int {
+	return int(atomic.LoadInt64(&p.pendingDeliveries))
+}
+
+// Drain waits for pending deliveries to finish, or for ctx to be done, so
+// events aren't lost when the controller shuts down.
+func (p *Publisher) Drain(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.deliveries.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Publisher) startDelivery(event models.Event, kind, name string, send func() error) {
+	p.deliveries.Add(1)
+	atomic.AddInt64(&p.pendingDeliveries, 1)
+	go func() {
+		defer p.deliveries.Done()
+		defer atomic.AddInt64(&p.pendingDeliveries, -1)
+		p.deliver(event, kind, name, send)
+	}()
+}
+
+func (p *Publisher) deliver(event models.Event, kind, name string, send func() error) {
+	tags := []string{
+		fmt.Sprintf("project_id:%s", event.ProjectID),
+		fmt.Sprintf("type:%s", event.Type),
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(retryBackoff << uint(attempt-1))
+		}
+		if err = send(); err == nil {
+			p.st.Incr(fmt.Sprintf("events.%s.delivered", kind), tags, 1)
+			return
+		}
+	}
+
+	log.WithField("project_id", event.ProjectID).
+		WithField("event_id", event.ID).
+		WithField(kind, name).
+		WithError(err).Warn("deliver event")
+	p.st.Incr(fmt.Sprintf("events.%s.failed", kind), tags, 1)
+}
+
+// Send posts an event's body to a webhook once.
+func Send(ctx context.Context, webhook models.EventWebhook, eventType models.EventType, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, string(eventType))
+	if webhook.Secret != "" {
+		req.Header.Set(releasewebhooks.SignatureHeader, releasewebhooks.Sign(webhook.Secret, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Subscribes returns whether a webhook is sent events of a type.
+func Subscribes(webhook models.EventWebhook, eventType models.EventType) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, t := range webhook.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the webhooks of an event webhooks config.
+func Validate(config models.EventWebhooksConfig) error {
+	for _, webhook := range config.Webhooks {
+		if webhook.Name == "" {
+			return ErrMissingName
+		}
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidURL
+		}
+		for _, eventType := range webhook.Events {
+			if !validEventType(eventType) {
+				return fmt.Errorf("unknown event type %q", eventType)
+			}
+		}
+	}
+	return nil
+}
+
+func validEventType(eventType models.EventType) bool {
+	for _, t := range models.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Message returns the message an event is sent to notification channels as.
+func Message(event models.Event) notifications.Message {
+	var summary string
+	switch {
+	case event.Rollout != nil:
+		summary = fmt.Sprintf("Rollout %s of release %s", event.Rollout.ID, event.Rollout.ReleaseID)
+	case event.Release != nil:
+		summary = fmt.Sprintf("Release %d (%s)", event.Release.Number, event.Release.ID)
+	case event.Device != nil:
+		summary = fmt.Sprintf("Device %s", event.Device.Name)
+	case event.ApplicationStatus != nil:
+		s := event.ApplicationStatus
+		summary = fmt.Sprintf("Device %s application %s at release %s", s.DeviceID, s.ApplicationID, s.CurrentReleaseID)
+	case event.ServiceStatus != nil:
+		s := event.ServiceStatus
+		summary = fmt.Sprintf("Device %s service %s/%s at release %s", s.DeviceID, s.ApplicationID, s.Service, s.CurrentReleaseID)
+	}
+
+	text := fmt.Sprintf("Project: %s\nEvent: %s\nTime: %s\n",
+		event.ProjectID, event.Type, event.CreatedAt.UTC().Format(time.RFC3339))
+	if event.Rollout != nil && event.Rollout.StatusReason != "" {
+		text = event.Rollout.StatusReason + "\n\n" + text
+	}
+
+	severity := notifications.SeverityInfo
+	switch event.Type {
+	case models.EventTypeRolloutFailed:
+		severity = notifications.SeverityCritical
+	case models.EventTypeDeviceOffline:
+		severity = notifications.SeverityWarning
+	}
+
+	return notifications.Message{
+		Subject:  fmt.Sprintf("[%s] %s", event.Type, summary),
+		Text:     text,
+		Key:      event.ID,
+		Severity: severity,
+		Payload:  event,
+		Header: map[string]string{
+			EventTypeHeader: string(event.Type),
+		},
+	}
+}
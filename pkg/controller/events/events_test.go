@@ -0,0 +1,200 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-go/statsd"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var eventType string
+	var received models.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, releasewebhooks.Sign("secret", body), r.Header.Get(releasewebhooks.SignatureHeader))
+		eventType = r.Header.Get(EventTypeHeader)
+		require.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	event := models.Event{
+		ID:        "evt_1",
+		Type:      models.EventTypeDeviceOffline,
+		ProjectID: "prj_1",
+		Device: &models.Device{
+			ID: "dev_1",
+		},
+	}
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	err = Send(context.Background(), models.EventWebhook{
+		Name:   "slack",
+		URL:    server.URL,
+		Secret: "secret",
+	}, event.Type, body)
+	require.NoError(t, err)
+	require.Equal(t, "device.offline", eventType)
+	require.Equal(t, "dev_1", received.Device.ID)
+	require.Nil(t, received.Release)
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := Send(context.Background(), models.EventWebhook{
+		Name: "slack",
+		URL:  server.URL,
+	}, models.EventTypeReleaseCreated, []byte("{}"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unavailable")
+}
+
+func TestSubscribes(t *testing.T) {
+	require.True(t, Subscribes(models.EventWebhook{}, models.EventTypeDeviceOnline))
+	webhook := models.EventWebhook{
+		Events: []models.EventType{models.EventTypeRolloutFailed},
+	}
+	require.True(t, Subscribes(webhook, models.EventTypeRolloutFailed))
+	require.False(t, Subscribes(webhook, models.EventTypeRolloutCompleted))
+}
+
+func TestValidate(t *testing.T) {
+	valid := func(webhook models.EventWebhook) error {
+		return Validate(models.EventWebhooksConfig{
+			Webhooks: []models.EventWebhook{webhook},
+		})
+	}
+	require.NoError(t, valid(models.EventWebhook{Name: "slack", URL: "https://hooks.example.com/events"}))
+	require.NoError(t, valid(models.EventWebhook{Name: "slack", URL: "https://hooks.example.com/events", Events: []models.EventType{models.EventTypeDeviceOffline}}))
+	require.Equal(t, ErrMissingName, valid(models.EventWebhook{URL: "https://hooks.example.com/events"}))
+	require.Equal(t, ErrInvalidURL, valid(models.EventWebhook{Name: "slack", URL: "hooks"}))
+	require.Error(t, valid(models.EventWebhook{Name: "slack", URL: "https://hooks.example.com/events", Events: []models.EventType{"device.exploded"}}))
+}
+
+type eventWebhooksConfigs struct{}
+
+func (eventWebhooksConfigs) SetEventWebhooksConfig(ctx context.Context, projectID string, config models.EventWebhooksConfig) error {
+	return nil
+}
+
+func (eventWebhooksConfigs) GetEventWebhooksConfig(ctx context.Context, projectID string) (*models.EventWebhooksConfig, error) {
+	return &models.EventWebhooksConfig{}, nil
+}
+
+type alertsConfigs struct {
+	config models.AlertsConfig
+}
+
+func (alertsConfigs) SetAlertsConfig(ctx context.Context, projectID string, config models.AlertsConfig) error {
+	return nil
+}
+
+func (a alertsConfigs) GetAlertsConfig(ctx context.Context, projectID string) (*models.AlertsConfig, error) {
+	return &a.config, nil
+}
+
+func TestSubscribe(t *testing.T) {
+	st, err := statsd.New("127.0.0.1:8125")
+	require.NoError(t, err)
+	publisher := NewPublisher(eventWebhooksConfigs{}, alertsConfigs{}, notifications.NewSender(nil, "", ""), st)
+
+	events, unsubscribe := publisher.Subscribe("prj_1")
+	require.True(t, publisher.Subscribed("prj_1"))
+	require.False(t, publisher.Subscribed("prj_2"))
+
+	publisher.Publish(context.Background(), models.Event{
+		Type:      models.EventTypeDeviceOnline,
+		ProjectID: "prj_2",
+	})
+	publisher.Publish(context.Background(), models.Event{
+		Type:      models.EventTypeDeviceOffline,
+		ProjectID: "prj_1",
+	})
+
+	event := <-events
+	require.Equal(t, models.EventTypeDeviceOffline, event.Type)
+	require.NotEmpty(t, event.ID)
+	require.Len(t, events, 0)
+
+	unsubscribe()
+	require.False(t, publisher.Subscribed("prj_1"))
+}
+
+func TestPublishToChannels(t *testing.T) {
+	received := make(chan map[string]string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received <- body
+	}))
+	defer server.Close()
+
+	st, err := statsd.New("127.0.0.1:8125")
+	require.NoError(t, err)
+	publisher := NewPublisher(eventWebhooksConfigs{}, alertsConfigs{
+		config: models.AlertsConfig{
+			Channels: []models.AlertChannel{
+				{
+					Name:   "slack",
+					Type:   models.AlertChannelTypeSlack,
+					URL:    server.URL,
+					Events: []models.EventType{models.EventTypeRolloutFailed},
+				},
+			},
+		},
+	}, notifications.NewSender(nil, "", ""), st)
+
+	wants, err := publisher.Wants(context.Background(), "prj_1", models.EventTypeRolloutFailed)
+	require.NoError(t, err)
+	require.True(t, wants)
+	wants, err = publisher.Wants(context.Background(), "prj_1", models.EventTypeRolloutCompleted)
+	require.NoError(t, err)
+	require.False(t, wants)
+
+	publisher.Publish(context.Background(), models.Event{
+		Type:      models.EventTypeRolloutFailed,
+		ProjectID: "prj_1",
+		Rollout: &models.Rollout{
+			ID:           "rlt_1",
+			ReleaseID:    "rel_1",
+			StatusReason: "too many devices failed",
+		},
+	})
+
+	body := <-received
+	require.Contains(t, body["text"], "*[rollout.failed] Rollout rlt_1 of release rel_1*")
+	require.Contains(t, body["text"], "too many devices failed")
+
+	require.NoError(t, publisher.Drain(context.Background()))
+	require.Equal(t, 0, publisher.Pending())
+}
+
+func TestMessage(t *testing.T) {
+	message := Message(models.Event{
+		ID:        "evt_1",
+		Type:      models.EventTypeDeviceOffline,
+		ProjectID: "prj_1",
+		Device: &models.Device{
+			Name: "a",
+		},
+	})
+	require.Equal(t, "[device.offline] Device a", message.Subject)
+	require.Equal(t, "evt_1", message.Key)
+	require.Equal(t, notifications.SeverityWarning, message.Severity)
+	require.Equal(t, "device.offline", message.Header[EventTypeHeader])
+}
@@ -0,0 +1,142 @@
+package gitrepo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidRepository = errors.New("git repositories must be http, https, ssh or git URLs")
+	ErrInvalidBranch     = errors.New("invalid git branch")
+	ErrBranchNotFound    = errors.New("git branch not found")
+	ErrFileNotFound      = errors.New("file not found in git repository")
+)
+
+// Commit is the commit a file was read at.
+type Commit struct {
+	SHA     string
+	Subject string
+}
+
+// ValidateRepository checks that a repository is a remote URL. Local paths
+// would let the controller's own filesystem be read.
+func ValidateRepository(repository string) error {
+	u, err := url.Parse(repository)
+	if err != nil || u.Host == "" {
+		return ErrInvalidRepository
+	}
+	switch u.Scheme {
+	case "http", "https", "ssh", "git":
+		return nil
+	}
+	return ErrInvalidRepository
+}
+
+// ValidateBranch checks that a branch can be passed to git. An empty branch
+// is the repository's default branch.
+func ValidateBranch(branch string) error {
+	if strings.HasPrefix(branch, "-") || strings.ContainsAny(branch, " \t\n") {
+		return ErrInvalidBranch
+	}
+	return nil
+}
+
+// Redact removes any credentials from a repository URL, so it can be shown.
+// Tokens are often given as the username, so that goes too.
+func Redact(repository string) string {
+	u, err := url.Parse(repository)
+	if err != nil || u.User == nil {
+		return repository
+	}
+	u.User = nil
+	return u.String()
+}
+
+// Head returns the SHA of the commit a branch of a repository points to,
+// without fetching it.
+func Head(ctx context.Context, repository, branch string) (string, error) {
+	ref := "HEAD"
+	if branch != "" {
+		ref = "refs/heads/" + branch
+	}
+
+	out, err := git(ctx, "", "ls-remote", "--", repository, ref)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", ErrBranchNotFound
+	}
+	return fields[0], nil
+}
+
+// ReadFile makes a shallow clone of a branch of a repository and returns
+// the commit at its tip and the contents of a file in it.
+func ReadFile(ctx context.Context, repository, branch, path string) (*Commit, []byte, error) {
+	dir, err := ioutil.TempDir("", "gitrepo")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	args := []string{"clone", "--quiet", "--depth", "1", "--single-branch"}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", repository, dir)
+	if _, err := git(ctx, "", args...); err != nil {
+		return nil, nil, err
+	}
+
+	out, err := git(ctx, dir, "log", "-1", "--format=%H%n%s")
+	if err != nil {
+		return nil, nil, err
+	}
+	lines := strings.SplitN(strings.TrimSpace(out), "\n", 2)
+	commit := Commit{
+		SHA: lines[0],
+	}
+	if len(lines) == 2 {
+		commit.Subject = lines[1]
+	}
+
+	// Rooting the path keeps it from escaping the clone
+	content, err := ioutil.ReadFile(filepath.Join(dir, filepath.Clean("/"+path)))
+	if os.IsNotExist(err) {
+		return nil, nil, errors.Wrap(ErrFileNotFound, path)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	return &commit, content, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// Never wait on a prompt for credentials
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_SSH_COMMAND=ssh -o BatchMode=yes")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", errors.Wrapf(err, "git %s", args[0])
+	}
+	return string(out), nil
+}
@@ -0,0 +1,573 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: deviceplane.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Deviceplane_ListDevices_FullMethodName       = "/deviceplane.v1.Deviceplane/ListDevices"
+	Deviceplane_GetDevice_FullMethodName         = "/deviceplane.v1.Deviceplane/GetDevice"
+	Deviceplane_SetDeviceLabel_FullMethodName    = "/deviceplane.v1.Deviceplane/SetDeviceLabel"
+	Deviceplane_DeleteDeviceLabel_FullMethodName = "/deviceplane.v1.Deviceplane/DeleteDeviceLabel"
+	Deviceplane_ListApplications_FullMethodName  = "/deviceplane.v1.Deviceplane/ListApplications"
+	Deviceplane_GetApplication_FullMethodName    = "/deviceplane.v1.Deviceplane/GetApplication"
+	Deviceplane_ListReleases_FullMethodName      = "/deviceplane.v1.Deviceplane/ListReleases"
+	Deviceplane_GetRelease_FullMethodName        = "/deviceplane.v1.Deviceplane/GetRelease"
+	Deviceplane_StreamEvents_FullMethodName      = "/deviceplane.v1.Deviceplane/StreamEvents"
+	Deviceplane_StreamServiceLogs_FullMethodName = "/deviceplane.v1.Deviceplane/StreamServiceLogs"
+	Deviceplane_StreamMetrics_FullMethodName     = "/deviceplane.v1.Deviceplane/StreamMetrics"
+)
+
+// DeviceplaneClient is the client API for Deviceplane service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeviceplaneClient interface {
+	ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error)
+	GetDevice(ctx context.Context, in *GetDeviceRequest, opts ...grpc.CallOption) (*Device, error)
+	SetDeviceLabel(ctx context.Context, in *SetDeviceLabelRequest, opts ...grpc.CallOption) (*SetDeviceLabelResponse, error)
+	DeleteDeviceLabel(ctx context.Context, in *DeleteDeviceLabelRequest, opts ...grpc.CallOption) (*DeleteDeviceLabelResponse, error)
+	ListApplications(ctx context.Context, in *ListApplicationsRequest, opts ...grpc.CallOption) (*ListApplicationsResponse, error)
+	GetApplication(ctx context.Context, in *GetApplicationRequest, opts ...grpc.CallOption) (*Application, error)
+	ListReleases(ctx context.Context, in *ListReleasesRequest, opts ...grpc.CallOption) (*ListReleasesResponse, error)
+	GetRelease(ctx context.Context, in *GetReleaseRequest, opts ...grpc.CallOption) (*Release, error)
+	// StreamEvents sends a project's events as they happen, until the call is
+	// cancelled.
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Deviceplane_StreamEventsClient, error)
+	// StreamServiceLogs sends a service's output from its device. Unless
+	// follow is set, the stream ends once the output so far has been sent.
+	StreamServiceLogs(ctx context.Context, in *StreamServiceLogsRequest, opts ...grpc.CallOption) (Deviceplane_StreamServiceLogsClient, error)
+	// StreamMetrics scrapes a device's metrics at an interval, until the call
+	// is cancelled or a scrape fails.
+	StreamMetrics(ctx context.Context, in *StreamMetricsRequest, opts ...grpc.CallOption) (Deviceplane_StreamMetricsClient, error)
+}
+
+type deviceplaneClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceplaneClient(cc grpc.ClientConnInterface) DeviceplaneClient {
+	return &deviceplaneClient{cc}
+}
+
+func (c *deviceplaneClient) ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error) {
+	out := new(ListDevicesResponse)
+	err := c.cc.Invoke(ctx, Deviceplane_ListDevices_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) GetDevice(ctx context.Context, in *GetDeviceRequest, opts ...grpc.CallOption) (*Device, error) {
+	out := new(Device)
+	err := c.cc.Invoke(ctx, Deviceplane_GetDevice_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) SetDeviceLabel(ctx context.Context, in *SetDeviceLabelRequest, opts ...grpc.CallOption) (*SetDeviceLabelResponse, error) {
+	out := new(SetDeviceLabelResponse)
+	err := c.cc.Invoke(ctx, Deviceplane_SetDeviceLabel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) DeleteDeviceLabel(ctx context.Context, in *DeleteDeviceLabelRequest, opts ...grpc.CallOption) (*DeleteDeviceLabelResponse, error) {
+	out := new(DeleteDeviceLabelResponse)
+	err := c.cc.Invoke(ctx, Deviceplane_DeleteDeviceLabel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) ListApplications(ctx context.Context, in *ListApplicationsRequest, opts ...grpc.CallOption) (*ListApplicationsResponse, error) {
+	out := new(ListApplicationsResponse)
+	err := c.cc.Invoke(ctx, Deviceplane_ListApplications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) GetApplication(ctx context.Context, in *GetApplicationRequest, opts ...grpc.CallOption) (*Application, error) {
+	out := new(Application)
+	err := c.cc.Invoke(ctx, Deviceplane_GetApplication_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) ListReleases(ctx context.Context, in *ListReleasesRequest, opts ...grpc.CallOption) (*ListReleasesResponse, error) {
+	out := new(ListReleasesResponse)
+	err := c.cc.Invoke(ctx, Deviceplane_ListReleases_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) GetRelease(ctx context.Context, in *GetReleaseRequest, opts ...grpc.CallOption) (*Release, error) {
+	out := new(Release)
+	err := c.cc.Invoke(ctx, Deviceplane_GetRelease_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceplaneClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (Deviceplane_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Deviceplane_ServiceDesc.Streams[0], Deviceplane_StreamEvents_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deviceplaneStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Deviceplane_StreamEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type deviceplaneStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *deviceplaneStreamEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *deviceplaneClient) StreamServiceLogs(ctx context.Context, in *StreamServiceLogsRequest, opts ...grpc.CallOption) (Deviceplane_StreamServiceLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Deviceplane_ServiceDesc.Streams[1], Deviceplane_StreamServiceLogs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deviceplaneStreamServiceLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Deviceplane_StreamServiceLogsClient interface {
+	Recv() (*LogChunk, error)
+	grpc.ClientStream
+}
+
+type deviceplaneStreamServiceLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *deviceplaneStreamServiceLogsClient) Recv() (*LogChunk, error) {
+	m := new(LogChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *deviceplaneClient) StreamMetrics(ctx context.Context, in *StreamMetricsRequest, opts ...grpc.CallOption) (Deviceplane_StreamMetricsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Deviceplane_ServiceDesc.Streams[2], Deviceplane_StreamMetrics_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &deviceplaneStreamMetricsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Deviceplane_StreamMetricsClient interface {
+	Recv() (*Metrics, error)
+	grpc.ClientStream
+}
+
+type deviceplaneStreamMetricsClient struct {
+	grpc.ClientStream
+}
+
+func (x *deviceplaneStreamMetricsClient) Recv() (*Metrics, error) {
+	m := new(Metrics)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DeviceplaneServer is the server API for Deviceplane service.
+// All implementations must embed UnimplementedDeviceplaneServer
+// for forward compatibility
+type DeviceplaneServer interface {
+	ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error)
+	GetDevice(context.Context, *GetDeviceRequest) (*Device, error)
+	SetDeviceLabel(context.Context, *SetDeviceLabelRequest) (*SetDeviceLabelResponse, error)
+	DeleteDeviceLabel(context.Context, *DeleteDeviceLabelRequest) (*DeleteDeviceLabelResponse, error)
+	ListApplications(context.Context, *ListApplicationsRequest) (*ListApplicationsResponse, error)
+	GetApplication(context.Context, *GetApplicationRequest) (*Application, error)
+	ListReleases(context.Context, *ListReleasesRequest) (*ListReleasesResponse, error)
+	GetRelease(context.Context, *GetReleaseRequest) (*Release, error)
+	// StreamEvents sends a project's events as they happen, until the call is
+	// cancelled.
+	StreamEvents(*StreamEventsRequest, Deviceplane_StreamEventsServer) error
+	// StreamServiceLogs sends a service's output from its device. Unless
+	// follow is set, the stream ends once the output so far has been sent.
+	StreamServiceLogs(*StreamServiceLogsRequest, Deviceplane_StreamServiceLogsServer) error
+	// StreamMetrics scrapes a device's metrics at an interval, until the call
+	// is cancelled or a scrape fails.
+	StreamMetrics(*StreamMetricsRequest, Deviceplane_StreamMetricsServer) error
+	mustEmbedUnimplementedDeviceplaneServer()
+}
+
+// UnimplementedDeviceplaneServer must be embedded to have forward compatible implementations.
+type UnimplementedDeviceplaneServer struct {
+}
+
+func (UnimplementedDeviceplaneServer) ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDevices not implemented")
+}
+func (UnimplementedDeviceplaneServer) GetDevice(context.Context, *GetDeviceRequest) (*Device, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDevice not implemented")
+}
+func (UnimplementedDeviceplaneServer) SetDeviceLabel(context.Context, *SetDeviceLabelRequest) (*SetDeviceLabelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDeviceLabel not implemented")
+}
+func (UnimplementedDeviceplaneServer) DeleteDeviceLabel(context.Context, *DeleteDeviceLabelRequest) (*DeleteDeviceLabelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDeviceLabel not implemented")
+}
+func (UnimplementedDeviceplaneServer) ListApplications(context.Context, *ListApplicationsRequest) (*ListApplicationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApplications not implemented")
+}
+func (UnimplementedDeviceplaneServer) GetApplication(context.Context, *GetApplicationRequest) (*Application, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetApplication not implemented")
+}
+func (UnimplementedDeviceplaneServer) ListReleases(context.Context, *ListReleasesRequest) (*ListReleasesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReleases not implemented")
+}
+func (UnimplementedDeviceplaneServer) GetRelease(context.Context, *GetReleaseRequest) (*Release, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRelease not implemented")
+}
+func (UnimplementedDeviceplaneServer) StreamEvents(*StreamEventsRequest, Deviceplane_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedDeviceplaneServer) StreamServiceLogs(*StreamServiceLogsRequest, Deviceplane_StreamServiceLogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamServiceLogs not implemented")
+}
+func (UnimplementedDeviceplaneServer) StreamMetrics(*StreamMetricsRequest, Deviceplane_StreamMetricsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamMetrics not implemented")
+}
+func (UnimplementedDeviceplaneServer) mustEmbedUnimplementedDeviceplaneServer() {}
+
+// UnsafeDeviceplaneServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceplaneServer will
+// result in compilation errors.
+type UnsafeDeviceplaneServer interface {
+	mustEmbedUnimplementedDeviceplaneServer()
+}
+
+func RegisterDeviceplaneServer(s grpc.ServiceRegistrar, srv DeviceplaneServer) {
+	s.RegisterService(&Deviceplane_ServiceDesc, srv)
+}
+
+func _Deviceplane_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDevicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).ListDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_ListDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).ListDevices(ctx, req.(*ListDevicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_GetDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).GetDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_GetDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).GetDevice(ctx, req.(*GetDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_SetDeviceLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDeviceLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).SetDeviceLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_SetDeviceLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).SetDeviceLabel(ctx, req.(*SetDeviceLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_DeleteDeviceLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDeviceLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).DeleteDeviceLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_DeleteDeviceLabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).DeleteDeviceLabel(ctx, req.(*DeleteDeviceLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_ListApplications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApplicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).ListApplications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_ListApplications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).ListApplications(ctx, req.(*ListApplicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_GetApplication_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetApplicationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).GetApplication(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_GetApplication_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).GetApplication(ctx, req.(*GetApplicationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_ListReleases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReleasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).ListReleases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_ListReleases_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).ListReleases(ctx, req.(*ListReleasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_GetRelease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReleaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceplaneServer).GetRelease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Deviceplane_GetRelease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceplaneServer).GetRelease(ctx, req.(*GetReleaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Deviceplane_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeviceplaneServer).StreamEvents(m, &deviceplaneStreamEventsServer{stream})
+}
+
+type Deviceplane_StreamEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type deviceplaneStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *deviceplaneStreamEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Deviceplane_StreamServiceLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamServiceLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeviceplaneServer).StreamServiceLogs(m, &deviceplaneStreamServiceLogsServer{stream})
+}
+
+type Deviceplane_StreamServiceLogsServer interface {
+	Send(*LogChunk) error
+	grpc.ServerStream
+}
+
+type deviceplaneStreamServiceLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *deviceplaneStreamServiceLogsServer) Send(m *LogChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Deviceplane_StreamMetrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamMetricsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DeviceplaneServer).StreamMetrics(m, &deviceplaneStreamMetricsServer{stream})
+}
+
+type Deviceplane_StreamMetricsServer interface {
+	Send(*Metrics) error
+	grpc.ServerStream
+}
+
+type deviceplaneStreamMetricsServer struct {
+	grpc.ServerStream
+}
+
+func (x *deviceplaneStreamMetricsServer) Send(m *Metrics) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Deviceplane_ServiceDesc is the grpc.ServiceDesc for Deviceplane service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Deviceplane_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "deviceplane.v1.Deviceplane",
+	HandlerType: (*DeviceplaneServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDevices",
+			Handler:    _Deviceplane_ListDevices_Handler,
+		},
+		{
+			MethodName: "GetDevice",
+			Handler:    _Deviceplane_GetDevice_Handler,
+		},
+		{
+			MethodName: "SetDeviceLabel",
+			Handler:    _Deviceplane_SetDeviceLabel_Handler,
+		},
+		{
+			MethodName: "DeleteDeviceLabel",
+			Handler:    _Deviceplane_DeleteDeviceLabel_Handler,
+		},
+		{
+			MethodName: "ListApplications",
+			Handler:    _Deviceplane_ListApplications_Handler,
+		},
+		{
+			MethodName: "GetApplication",
+			Handler:    _Deviceplane_GetApplication_Handler,
+		},
+		{
+			MethodName: "ListReleases",
+			Handler:    _Deviceplane_ListReleases_Handler,
+		},
+		{
+			MethodName: "GetRelease",
+			Handler:    _Deviceplane_GetRelease_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _Deviceplane_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamServiceLogs",
+			Handler:       _Deviceplane_StreamServiceLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamMetrics",
+			Handler:       _Deviceplane_StreamMetrics_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "deviceplane.proto",
+}
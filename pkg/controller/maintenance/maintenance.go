@@ -0,0 +1,129 @@
+package maintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+var (
+	ErrInvalidTimeOfDay = errors.New("maintenance window start and end must be times of day such as 02:30")
+	ErrEmptyWindow      = errors.New("maintenance windows can't start and end at the same time")
+)
+
+var days = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Validate checks the windows of a maintenance windows config.
+func Validate(config models.MaintenanceWindowsConfig) error {
+	for _, window := range config.Windows {
+		if _, err := time.LoadLocation(window.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", window.Timezone)
+		}
+		for _, day := range window.Days {
+			if _, ok := days[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("unknown day %q", day)
+			}
+		}
+		start, err := parseTimeOfDay(window.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseTimeOfDay(window.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return ErrEmptyWindow
+		}
+	}
+	return nil
+}
+
+// IsOpen returns whether a maintenance window is open at a given time.
+func IsOpen(window models.MaintenanceWindow, now time.Time) (bool, error) {
+	location, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return false, err
+	}
+	start, err := parseTimeOfDay(window.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseTimeOfDay(window.End)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(location)
+	timeOfDay := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+
+	if start < end {
+		return timeOfDay >= start && timeOfDay < end && opensOn(window, now.Weekday()), nil
+	}
+
+	// The window runs past midnight, so it's open from its start until the
+	// end of the day it opens on, and from midnight until its end on the
+	// day after
+	if timeOfDay >= start {
+		return opensOn(window, now.Weekday()), nil
+	}
+	if timeOfDay < end {
+		return opensOn(window, (now.Weekday()+6)%7), nil
+	}
+	return false, nil
+}
+
+// UpdatesAllowed returns whether a device can be given a new release at a
+// given time. It can be if none of a project's maintenance windows cover it
+// or if one of those that do is open. The device's groups have to be loaded.
+func UpdatesAllowed(device models.Device, groups []models.DeviceGroup, config models.MaintenanceWindowsConfig, now time.Time) (bool, error) {
+	covered := false
+	for _, window := range config.Windows {
+		if len(window.DeviceGroups) != 0 && !devicegroups.InAny(device, groups, window.DeviceGroups) {
+			continue
+		}
+		covered = true
+
+		open, err := IsOpen(window, now)
+		if err != nil {
+			return false, errors.Wrapf(err, "maintenance window %s", window.Name)
+		}
+		if open {
+			return true, nil
+		}
+	}
+	return !covered, nil
+}
+
+func opensOn(window models.MaintenanceWindow, weekday time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+	for _, day := range window.Days {
+		if days[strings.ToLower(day)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, ErrInvalidTimeOfDay
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
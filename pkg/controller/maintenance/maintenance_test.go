@@ -0,0 +1,137 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOpen(t *testing.T) {
+	window := models.MaintenanceWindow{
+		Timezone: "America/New_York",
+		Days:     []string{"Monday", "tuesday"},
+		Start:    "22:00",
+		End:      "04:30",
+	}
+
+	for _, tc := range []struct {
+		at   string
+		open bool
+	}{
+		// 2020-03-02 is a Monday, and New York is 5 hours behind UTC
+		{"2020-03-02T02:00:00Z", false},
+		{"2020-03-03T02:59:00Z", false},
+		{"2020-03-03T03:00:00Z", true},
+		{"2020-03-03T09:29:00Z", true},
+		{"2020-03-03T09:30:00Z", false},
+		{"2020-03-04T03:00:00Z", true},
+		// The window opens on Tuesday night and runs into Wednesday, but
+		// doesn't open again on Wednesday night
+		{"2020-03-04T08:00:00Z", true},
+		{"2020-03-05T03:00:00Z", false},
+	} {
+		at, err := time.Parse(time.RFC3339, tc.at)
+		require.NoError(t, err)
+		open, err := IsOpen(window, at)
+		require.NoError(t, err)
+		require.Equal(t, tc.open, open, tc.at)
+	}
+
+	window = models.MaintenanceWindow{
+		Start: "01:00",
+		End:   "02:00",
+	}
+	open, err := IsOpen(window, time.Date(2020, 3, 7, 1, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, open)
+	open, err = IsOpen(window, time.Date(2020, 3, 7, 2, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, open)
+}
+
+func TestUpdatesAllowed(t *testing.T) {
+	groups := []models.DeviceGroup{
+		{
+			ID:   "dgp_kiosks",
+			Name: "kiosks",
+		},
+	}
+	kiosk := models.Device{
+		ID:     "dev_1",
+		Groups: []string{"dgp_kiosks"},
+	}
+	other := models.Device{
+		ID:     "dev_2",
+		Groups: []string{},
+	}
+	config := models.MaintenanceWindowsConfig{
+		Windows: []models.MaintenanceWindow{
+			{
+				Name:         "overnight",
+				DeviceGroups: []string{"kiosks"},
+				Start:        "01:00",
+				End:          "05:00",
+			},
+		},
+	}
+	day := time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2020, 3, 2, 3, 0, 0, 0, time.UTC)
+
+	allowed, err := UpdatesAllowed(kiosk, groups, config, day)
+	require.NoError(t, err)
+	require.False(t, allowed)
+	allowed, err = UpdatesAllowed(kiosk, groups, config, night)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Devices that no window covers are always updated
+	allowed, err = UpdatesAllowed(other, groups, config, day)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	// Any window that covers a device being open is enough
+	config.Windows = append(config.Windows, models.MaintenanceWindow{
+		Name:  "lunch",
+		Start: "12:00",
+		End:   "13:00",
+	})
+	allowed, err = UpdatesAllowed(kiosk, groups, config, day)
+	require.NoError(t, err)
+	require.True(t, allowed)
+	allowed, err = UpdatesAllowed(other, groups, config, night)
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
+
+func TestValidate(t *testing.T) {
+	window := models.MaintenanceWindow{
+		Timezone: "Europe/London",
+		Days:     []string{"saturday", "Sunday"},
+		Start:    "23:00",
+		End:      "01:00",
+	}
+	valid := func(window models.MaintenanceWindow) error {
+		return Validate(models.MaintenanceWindowsConfig{
+			Windows: []models.MaintenanceWindow{window},
+		})
+	}
+	require.NoError(t, valid(window))
+
+	invalid := window
+	invalid.Timezone = "Mars/Olympus_Mons"
+	require.Error(t, valid(invalid))
+
+	invalid = window
+	invalid.Days = []string{"someday"}
+	require.Error(t, valid(invalid))
+
+	invalid = window
+	invalid.Start = "25:00"
+	require.Equal(t, ErrInvalidTimeOfDay, valid(invalid))
+
+	invalid = window
+	invalid.End = invalid.Start
+	require.Equal(t, ErrEmptyWindow, valid(invalid))
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "deviceplane"
+	subsystem = "controller"
+)
+
+var (
+	// RequestsTotal counts API requests by the route they matched, such as
+	// /api/projects/{project}/devices, rather than by path, so devices and
+	// projects don't each get their own series.
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	// RequestDuration leaves out requests upgraded to WebSockets, which
+	// last as long as their sessions.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latencies by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	WebSocketSessions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "websocket_sessions",
+		Help:      "Open WebSocket sessions, such as SSH and port forwarding, by route.",
+	}, []string{"route"})
+
+	StoreQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "store_query_duration_seconds",
+		Help:      "Store query timings by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		RequestsTotal,
+		RequestDuration,
+		WebSocketSessions,
+		StoreQueryDuration,
+	)
+}
+
+// RegisterConnectedDevices adds a gauge of the devices connected to this
+// controller, as counted by count when metrics are scraped.
+func RegisterConnectedDevices(count func() int) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      "connected_devices",
+		Help:      "Devices connected to this controller.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// Handler serves the controller's metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -4,22 +4,25 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/utils"
 )
 
 const (
-	TotalPagesHeader     = "Total-Pages"
-	TotalItemCountHeader = "Total-Item-Count"
+	TotalPagesHeader     = models.TotalPagesHeader
+	TotalItemCountHeader = models.TotalItemCountHeader
+	NextPageAfterHeader  = models.NextPageAfterHeader
 )
 
-const MaxPageSize = int(100)
+const MaxPageSize = models.MaxPageSize
 const DefaultOrderByParam = "id"
 
 const (
-	PageSizeParam = "page_size"
-	AfterParam    = "after"
+	PageSizeParam = models.PageSizeQueryParam
+	AfterParam    = models.AfterQueryParam
 	OrderParam    = "order"
 	OrderByParam  = "order_by"
 )
@@ -38,64 +41,122 @@ const (
 )
 
 func SortAndPaginateAndRespond(r http.Request, w http.ResponseWriter, arr []interface{}) {
+	arr, ok := SortAndPaginate(r, w, arr, MaxPageSize)
+	if !ok {
+		return
+	}
+	utils.Respond(w, arr)
+}
+
+// SortAndPaginate sorts arr and returns the page of it the request asks
+// for, and sets the pagination headers. defaultPageSize is the page size if
+// the request doesn't give one, and zero makes the whole of arr one page. If
+// the request is invalid it writes the error response and returns false.
+func SortAndPaginate(r http.Request, w http.ResponseWriter, arr []interface{}, defaultPageSize int) ([]interface{}, bool) {
 	values := r.URL.Query()
 	after := values.Get(AfterParam)
 
-	var pageSize *int
-	if pageSizeStr := values.Get(PageSizeParam); pageSizeStr != "" {
-		p, err := strconv.Atoi(pageSizeStr)
-		if err != nil || p <= 0 || p > MaxPageSize {
-			http.Error(w, ErrInvalidPageSizeParameter.Error(), http.StatusBadRequest)
-			return
-		}
-		pageSize = &p
+	if defaultPageSize == 0 {
+		defaultPageSize = len(arr)
 	}
-	if pageSize == nil {
-		m := MaxPageSize
-		pageSize = &m
+	pageSize, err := pageSizeParam(values, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
 	}
 
-	var direction *orderDirection
-	if orderStr := values.Get(OrderParam); orderStr != "" {
-		switch orderStr {
-		case string(OrderAscending):
-			d := OrderAscending
-			direction = &d
-		case string(OrderDescending):
-			d := OrderDescending
-			direction = &d
-		default:
-			http.Error(w, ErrInvalidOrderParameter.Error(), http.StatusBadRequest)
-			return
-		}
-	}
-	if direction == nil {
-		d := OrderAscending
-		direction = &d
+	direction, err := orderParam(values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
 	}
 
 	var orderBy string = values.Get(OrderByParam)
 	if orderBy == "" {
 		// Do nothing, no order
 	} else {
-		err := order(orderBy, *direction, arr)
+		err := order(orderBy, direction, arr)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+			return nil, false
 		}
 	}
 
-	// Set total pages header, as pages are required
-	totalPages := int(math.Ceil(float64(len(arr)) / float64(*pageSize)))
-	w.Header().Set(TotalPagesHeader, strconv.Itoa(totalPages))
+	page, err := paginateAfter(after, "id", pageSize, arr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
 
-	// Set total count header
-	w.Header().Set(TotalItemCountHeader, strconv.Itoa(len(arr)))
+	next, _ := nextAfter("id", page, arr)
+	SetPageHeaders(w, len(arr), pageSize, next)
 
-	arr, err := paginateAfter(after, "id", *pageSize, arr)
+	return page, true
+}
+
+// Ordered returns whether a request orders a listing, which the store can't
+// do, so it has to be paginated with SortAndPaginate.
+func Ordered(r http.Request) bool {
+	return r.URL.Query().Get(OrderByParam) != ""
+}
+
+// PageParams returns the after and page size parameters of a request, for
+// listings that aren't Ordered and are paged by the store in order of ID.
+// The page size is defaultPageSize if the request doesn't give one. If the
+// request is invalid it writes the error response and returns false.
+func PageParams(r http.Request, w http.ResponseWriter, defaultPageSize int) (string, int, bool) {
+	values := r.URL.Query()
+
+	pageSize, err := pageSizeParam(values, defaultPageSize)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return "", 0, false
+	}
+
+	// The order is only checked, since it does nothing without an order_by
+	if _, err := orderParam(values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", 0, false
+	}
+
+	return values.Get(AfterParam), pageSize, true
+}
+
+// SetPageHeaders sets the pagination headers of a page of a listing of total
+// items. next is the ID the following page starts after, which is empty on
+// the last page.
+func SetPageHeaders(w http.ResponseWriter, total, pageSize int, next string) {
+	totalPages := 0
+	if pageSize > 0 {
+		totalPages = int(math.Ceil(float64(total) / float64(pageSize)))
+	}
+	w.Header().Set(TotalPagesHeader, strconv.Itoa(totalPages))
+	w.Header().Set(TotalItemCountHeader, strconv.Itoa(total))
+
+	if next != "" {
+		w.Header().Set(NextPageAfterHeader, next)
+	}
+}
+
+func pageSizeParam(values url.Values, defaultPageSize int) (int, error) {
+	pageSizeStr := values.Get(PageSizeParam)
+	if pageSizeStr == "" {
+		return defaultPageSize, nil
+	}
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil || pageSize <= 0 || pageSize > MaxPageSize {
+		return 0, ErrInvalidPageSizeParameter
+	}
+	return pageSize, nil
+}
+
+func orderParam(values url.Values) (orderDirection, error) {
+	switch orderStr := values.Get(OrderParam); orderStr {
+	case "", string(OrderAscending):
+		return OrderAscending, nil
+	case string(OrderDescending):
+		return OrderDescending, nil
+	default:
+		return "", ErrInvalidOrderParameter
 	}
-	utils.Respond(w, arr)
 }
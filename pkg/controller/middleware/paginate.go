@@ -107,3 +107,35 @@ func paginateAfter(after, paginateOn string, pageSize int, arr []interface{}) (r
 
 	return page, nil
 }
+
+// nextAfter returns the after of the page following page, which is the value
+// of the paginateOn field of its last item, unless it's the last page.
+func nextAfter(paginateOn string, page, arr []interface{}) (string, bool) {
+	if len(page) == 0 || len(arr) == 0 {
+		return "", false
+	}
+
+	last, ok := jsonFieldString(page[len(page)-1], paginateOn)
+	if !ok {
+		return "", false
+	}
+	end, ok := jsonFieldString(arr[len(arr)-1], paginateOn)
+	if !ok || last == end {
+		return "", false
+	}
+
+	return last, true
+}
+
+func jsonFieldString(item interface{}, jsonTag string) (string, bool) {
+	v := reflect.ValueOf(item)
+	if v.Kind() != reflect.Struct {
+		return "", false
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("json") == jsonTag {
+			return fmt.Sprint(v.Field(i).Interface()), true
+		}
+	}
+	return "", false
+}
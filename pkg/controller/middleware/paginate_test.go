@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/deviceplane/deviceplane/pkg/models"
@@ -293,3 +295,57 @@ func TestPagination(t *testing.T) {
 		})
 	}
 }
+
+func TestSortAndPaginate(t *testing.T) {
+	devices := []interface{}{
+		models.Device{ID: "device_a"},
+		models.Device{ID: "device_b"},
+		models.Device{ID: "device_c"},
+	}
+
+	paginate := func(query string, defaultPageSize int) ([]interface{}, *httptest.ResponseRecorder) {
+		r := httptest.NewRequest("GET", "/devices?"+query, nil)
+		w := httptest.NewRecorder()
+		page, ok := SortAndPaginate(*r, w, append([]interface{}{}, devices...), defaultPageSize)
+		if !ok {
+			return nil, w
+		}
+		return page, w
+	}
+
+	page, w := paginate("page_size=2", MaxPageSize)
+	require.Equal(t, devices[:2], page)
+	require.Equal(t, "2", w.Header().Get(TotalPagesHeader))
+	require.Equal(t, "3", w.Header().Get(TotalItemCountHeader))
+	require.Equal(t, "device_b", w.Header().Get(NextPageAfterHeader))
+
+	page, w = paginate("page_size=2&after=device_b", MaxPageSize)
+	require.Equal(t, devices[2:], page)
+	require.Empty(t, w.Header().Get(NextPageAfterHeader))
+
+	// Everything is one page by default if there's no default page size
+	page, w = paginate("", 0)
+	require.Equal(t, devices, page)
+	require.Equal(t, "1", w.Header().Get(TotalPagesHeader))
+	require.Empty(t, w.Header().Get(NextPageAfterHeader))
+
+	page, w = paginate("order_by=id&order=desc&page_size=1", 0)
+	require.Equal(t, []interface{}{models.Device{ID: "device_c"}}, page)
+	require.Equal(t, "device_c", w.Header().Get(NextPageAfterHeader))
+
+	_, w = paginate("page_size=1000", MaxPageSize)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, w = paginate("after=device_x", MaxPageSize)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSortAndPaginateEmpty(t *testing.T) {
+	r := httptest.NewRequest("GET", "/devices", nil)
+	w := httptest.NewRecorder()
+	page, ok := SortAndPaginate(*r, w, []interface{}{}, 0)
+	require.True(t, ok)
+	require.Empty(t, page)
+	require.Equal(t, "0", w.Header().Get(TotalPagesHeader))
+	require.Empty(t, w.Header().Get(NextPageAfterHeader))
+}
@@ -0,0 +1,257 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/email"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	timeout          = 10 * time.Second
+	maxErrorBodySize = 512
+
+	pagerDutySource = "deviceplane"
+)
+
+// pagerDutyEventsURL is a variable so tests can point it elsewhere
+var pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+var (
+	ErrMissingChannelName = errors.New("notification channels need a name")
+	ErrInvalidURL         = errors.New("notification channel URLs must be http or https URLs")
+	ErrMissingEmails      = errors.New("email channels need at least one email address")
+	ErrMissingRoutingKey  = errors.New("PagerDuty channels need a routing key")
+	ErrEmailNotConfigured = errors.New("email isn't configured on this controller")
+)
+
+type Severity string
+
+const (
+	SeverityCritical = Severity("critical")
+	SeverityWarning  = Severity("warning")
+	SeverityInfo     = Severity("info")
+)
+
+// Message is a notification as it's sent to any type of channel.
+type Message struct {
+	// Subject is a one line summary, used as the subject of emails and the
+	// summary of PagerDuty incidents
+	Subject string
+	Text    string
+	// Key identifies what the message is about, so that PagerDuty groups
+	// later messages about the same thing, such as it being resolved, into
+	// the same incident
+	Key      string
+	Resolved bool
+	Severity Severity
+	// Payload is posted to webhook channels as JSON, with Header set on the
+	// request
+	Payload interface{}
+	Header  map[string]string
+}
+
+// Sender sends messages to channels.
+type Sender struct {
+	email            email.Interface
+	emailFromName    string
+	emailFromAddress string
+}
+
+func NewSender(email email.Interface, emailFromName, emailFromAddress string) *Sender {
+	return &Sender{
+		email:            email,
+		emailFromName:    emailFromName,
+		emailFromAddress: emailFromAddress,
+	}
+}
+
+// Send sends a message to a channel once.
+func (s *Sender) Send(ctx context.Context, channel models.AlertChannel, message Message) error {
+	switch channel.Type {
+	case models.AlertChannelTypeWebhook:
+		body, err := json.Marshal(message.Payload)
+		if err != nil {
+			return err
+		}
+
+		header := make(http.Header)
+		for key, value := range message.Header {
+			header.Set(key, value)
+		}
+		if channel.Secret != "" {
+			header.Set(releasewebhooks.SignatureHeader, releasewebhooks.Sign(channel.Secret, body))
+		}
+
+		return post(ctx, channel.URL, header, body)
+
+	case models.AlertChannelTypeSlack:
+		text := fmt.Sprintf("*%s*", message.Subject)
+		if message.Text != "" {
+			text += "\n" + message.Text
+		}
+
+		body, err := json.Marshal(map[string]string{
+			"text": text,
+		})
+		if err != nil {
+			return err
+		}
+
+		return post(ctx, channel.URL, nil, body)
+
+	case models.AlertChannelTypePagerDuty:
+		event := pagerDutyEvent{
+			RoutingKey:  channel.RoutingKey,
+			EventAction: "trigger",
+			DedupKey:    message.Key,
+		}
+		if message.Resolved {
+			event.EventAction = "resolve"
+		} else {
+			severity := message.Severity
+			if severity == "" {
+				severity = SeverityInfo
+			}
+			event.Payload = &pagerDutyPayload{
+				Summary:       message.Subject,
+				Source:        pagerDutySource,
+				Severity:      severity,
+				CustomDetails: message.Text,
+			}
+		}
+
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		return post(ctx, pagerDutyEventsURL, nil, body)
+
+	case models.AlertChannelTypeEmail:
+		if s.email == nil {
+			return ErrEmailNotConfigured
+		}
+
+		for _, address := range channel.Emails {
+			if err := s.email.Send(email.Request{
+				FromName:    s.emailFromName,
+				FromAddress: s.emailFromAddress,
+				ToAddress:   address,
+				Subject:     message.Subject,
+				Body:        message.Text,
+			}); err != nil {
+				return errors.Wrapf(err, "send email to %s", address)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown notification channel type %q", channel.Type)
+	}
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string   `json:"summary"`
+	Source        string   `json:"source"`
+	Severity      Severity `json:"severity"`
+	CustomDetails string   `json:"custom_details,omitempty"`
+}
+
+func post(ctx context.Context, url string, header http.Header, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("%s returned %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Subscribes returns whether a channel is sent events of a type. Unlike
+// event webhooks, channels are only sent the types they list.
+func Subscribes(channel models.AlertChannel, eventType models.EventType) bool {
+	for _, t := range channel.Events {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a channel.
+func Validate(channel models.AlertChannel) error {
+	if channel.Name == "" {
+		return ErrMissingChannelName
+	}
+
+	switch channel.Type {
+	case models.AlertChannelTypeWebhook, models.AlertChannelTypeSlack:
+		u, err := url.Parse(channel.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidURL
+		}
+	case models.AlertChannelTypeEmail:
+		if len(channel.Emails) == 0 {
+			return ErrMissingEmails
+		}
+	case models.AlertChannelTypePagerDuty:
+		if channel.RoutingKey == "" {
+			return ErrMissingRoutingKey
+		}
+	default:
+		return fmt.Errorf("unknown notification channel type %q", channel.Type)
+	}
+
+	for _, eventType := range channel.Events {
+		if !validEventType(eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+
+	return nil
+}
+
+func validEventType(eventType models.EventType) bool {
+	for _, t := range models.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
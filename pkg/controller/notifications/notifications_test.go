@@ -0,0 +1,177 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/email"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestSendWebhook(t *testing.T) {
+	var header string
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, releasewebhooks.Sign("secret", body), r.Header.Get(releasewebhooks.SignatureHeader))
+		header = r.Header.Get("X-Test")
+		require.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	err := NewSender(nil, "", "").Send(context.Background(), models.AlertChannel{
+		Name:   "ops",
+		Type:   models.AlertChannelTypeWebhook,
+		URL:    server.URL,
+		Secret: "secret",
+	}, Message{
+		Subject: "subject",
+		Payload: map[string]string{"id": "alr_1"},
+		Header:  map[string]string{"X-Test": "value"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "value", header)
+	require.Equal(t, "alr_1", received["id"])
+}
+
+func TestSendSlack(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	err := NewSender(nil, "", "").Send(context.Background(), models.AlertChannel{
+		Name: "slack",
+		Type: models.AlertChannelTypeSlack,
+		URL:  server.URL,
+	}, Message{
+		Subject: "Rollout failed",
+		Text:    "Too many devices failed",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "*Rollout failed*\nToo many devices failed", received["text"])
+}
+
+func TestSendPagerDuty(t *testing.T) {
+	var received []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received = append(received, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	defer func(url string) {
+		pagerDutyEventsURL = url
+	}(pagerDutyEventsURL)
+	pagerDutyEventsURL = server.URL
+
+	sender := NewSender(nil, "", "")
+	channel := models.AlertChannel{
+		Name:       "pagerduty",
+		Type:       models.AlertChannelTypePagerDuty,
+		RoutingKey: "key",
+	}
+
+	require.NoError(t, sender.Send(context.Background(), channel, Message{
+		Subject:  "Device a is offline",
+		Key:      "alr_1",
+		Severity: SeverityCritical,
+	}))
+	require.NoError(t, sender.Send(context.Background(), channel, Message{
+		Subject:  "Device a is offline",
+		Key:      "alr_1",
+		Resolved: true,
+	}))
+
+	require.Len(t, received, 2)
+	require.Equal(t, "key", received[0].RoutingKey)
+	require.Equal(t, "trigger", received[0].EventAction)
+	require.Equal(t, "alr_1", received[0].DedupKey)
+	require.Equal(t, "Device a is offline", received[0].Payload.Summary)
+	require.Equal(t, SeverityCritical, received[0].Payload.Severity)
+	require.Equal(t, "resolve", received[1].EventAction)
+	require.Equal(t, "alr_1", received[1].DedupKey)
+	require.Nil(t, received[1].Payload)
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewSender(nil, "", "").Send(context.Background(), models.AlertChannel{
+		Name: "slack",
+		Type: models.AlertChannelTypeSlack,
+		URL:  server.URL,
+	}, Message{Subject: "subject"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid_token")
+}
+
+type fakeEmail struct {
+	requests []email.Request
+}
+
+func (f *fakeEmail) Send(request email.Request) error {
+	f.requests = append(f.requests, request)
+	return nil
+}
+
+func TestSendEmail(t *testing.T) {
+	channel := models.AlertChannel{
+		Name:   "oncall",
+		Type:   models.AlertChannelTypeEmail,
+		Emails: []string{"a@example.com", "b@example.com"},
+	}
+	message := Message{
+		Subject: "[FIRING] offline",
+		Text:    "Device a has been offline",
+	}
+
+	emailer := &fakeEmail{}
+	require.NoError(t, NewSender(emailer, "Deviceplane", "noreply@example.com").Send(context.Background(), channel, message))
+	require.Len(t, emailer.requests, 2)
+	require.Equal(t, "b@example.com", emailer.requests[1].ToAddress)
+	require.Equal(t, "noreply@example.com", emailer.requests[0].FromAddress)
+	require.Equal(t, "[FIRING] offline", emailer.requests[0].Subject)
+	require.Equal(t, "Device a has been offline", emailer.requests[0].Body)
+
+	require.Equal(t, ErrEmailNotConfigured, NewSender(nil, "", "").Send(context.Background(), channel, message))
+}
+
+func TestSubscribes(t *testing.T) {
+	require.False(t, Subscribes(models.AlertChannel{}, models.EventTypeRolloutFailed))
+	channel := models.AlertChannel{
+		Events: []models.EventType{models.EventTypeRolloutFailed},
+	}
+	require.True(t, Subscribes(channel, models.EventTypeRolloutFailed))
+	require.False(t, Subscribes(channel, models.EventTypeRolloutCompleted))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(models.AlertChannel{Name: "ops", Type: models.AlertChannelTypeWebhook, URL: "https://example.com/alerts"}))
+	require.NoError(t, Validate(models.AlertChannel{Name: "slack", Type: models.AlertChannelTypeSlack, URL: "https://hooks.slack.com/services/T0/B0/X"}))
+	require.NoError(t, Validate(models.AlertChannel{Name: "pagerduty", Type: models.AlertChannelTypePagerDuty, RoutingKey: "key"}))
+	require.NoError(t, Validate(models.AlertChannel{Name: "oncall", Type: models.AlertChannelTypeEmail, Emails: []string{"oncall@example.com"},
+		Events: []models.EventType{models.EventTypeRolloutFailed}}))
+
+	require.Equal(t, ErrMissingChannelName, Validate(models.AlertChannel{Type: models.AlertChannelTypePagerDuty, RoutingKey: "key"}))
+	require.Equal(t, ErrInvalidURL, Validate(models.AlertChannel{Name: "slack", Type: models.AlertChannelTypeSlack, URL: "hooks"}))
+	require.Equal(t, ErrMissingRoutingKey, Validate(models.AlertChannel{Name: "pagerduty", Type: models.AlertChannelTypePagerDuty}))
+	require.Equal(t, ErrMissingEmails, Validate(models.AlertChannel{Name: "oncall", Type: models.AlertChannelTypeEmail}))
+	require.Error(t, Validate(models.AlertChannel{Name: "sms", Type: "sms"}))
+	require.Error(t, Validate(models.AlertChannel{Name: "pagerduty", Type: models.AlertChannelTypePagerDuty, RoutingKey: "key",
+		Events: []models.EventType{"device.exploded"}}))
+}
@@ -0,0 +1,492 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	timeout          = 10 * time.Second
+	clockSkew        = time.Minute
+	keysRefreshDelay = time.Minute
+	maxErrorBodySize = 512
+
+	DefaultGroupsClaim = "groups"
+)
+
+var (
+	ErrInvalidToken         = errors.New("invalid id token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported id token signing algorithm")
+	ErrUnknownKey           = errors.New("id token signed with an unknown key")
+	ErrInvalidSignature     = errors.New("invalid id token signature")
+	ErrIssuerMismatch       = errors.New("id token issuer doesn't match")
+	ErrAudienceMismatch     = errors.New("id token wasn't issued for this client")
+	ErrTokenExpired         = errors.New("id token expired")
+	ErrNonceMismatch        = errors.New("id token nonce doesn't match")
+)
+
+// Config is an OpenID Connect provider users can log in with. GroupsClaim
+// is the ID token claim that lists the groups a user is in.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	GroupsClaim  string
+}
+
+// Claims is who a user is, according to their ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Groups        []string
+}
+
+// Provider logs users in with the authorization code flow. Its endpoints
+// are discovered on first use, so the provider being unavailable doesn't
+// stop the controller from starting.
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+
+	lock          sync.Mutex
+	discovery     *discovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+type discovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+func NewProvider(config Config) *Provider {
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = DefaultGroupsClaim
+	}
+	return &Provider{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// Issuer returns the provider's issuer, which identifies it.
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// RedirectURL returns the URL the provider sends users back to.
+func (p *Provider) RedirectURL() string {
+	return p.config.RedirectURL
+}
+
+// AuthCodeURL returns the URL to send users to to log in. The provider
+// sends them back to the redirect URL with state, and nonce ends up in
+// their ID token.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce string) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(d.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", "openid email profile")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Exchange trades the code a user was sent back with for their ID token,
+// and returns its claims once it's verified.
+func (p *Provider) Exchange(ctx context.Context, code, nonce string) (*Claims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+
+	// client_secret_basic is the default when a provider doesn't say
+	basicAuth := len(d.TokenEndpointAuthMethodsSupported) == 0
+	for _, method := range d.TokenEndpointAuthMethodsSupported {
+		if method == "client_secret_basic" {
+			basicAuth = true
+		}
+	}
+	if !basicAuth {
+		form.Set("client_id", p.config.ClientID)
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+
+	req, err := http.NewRequest("POST", d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if basicAuth {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &tokenResponse); err != nil {
+		return nil, errors.Wrap(err, "exchange code")
+	}
+	if tokenResponse.IDToken == "" {
+		return nil, errors.New("provider didn't return an id token")
+	}
+
+	return p.verify(ctx, tokenResponse.IDToken, nonce, time.Now())
+}
+
+func (p *Provider) verify(ctx context.Context, rawIDToken, nonce string, now time.Time) (*Claims, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := p.getKey(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Algorithm, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var token struct {
+		Issuer          string          `json:"iss"`
+		Subject         string          `json:"sub"`
+		Audience        json.RawMessage `json:"aud"`
+		AuthorizedParty string          `json:"azp"`
+		Expiry          float64         `json:"exp"`
+		Nonce           string          `json:"nonce"`
+		Email           string          `json:"email"`
+		EmailVerified   json.RawMessage `json:"email_verified"`
+		GivenName       string          `json:"given_name"`
+		FamilyName      string          `json:"family_name"`
+	}
+	if err := decodeSegment(parts[1], &token); err != nil {
+		return nil, ErrInvalidToken
+	}
+	var rawClaims map[string]json.RawMessage
+	if err := decodeSegment(parts[1], &rawClaims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if token.Issuer != p.config.Issuer {
+		return nil, ErrIssuerMismatch
+	}
+	audience, err := stringOrStrings(token.Audience)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !contains(audience, p.config.ClientID) {
+		return nil, ErrAudienceMismatch
+	}
+	if len(audience) > 1 && token.AuthorizedParty != p.config.ClientID {
+		return nil, ErrAudienceMismatch
+	}
+	if now.After(time.Unix(int64(token.Expiry), 0).Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if token.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	// Some providers send email_verified as a string
+	emailVerified := string(token.EmailVerified) == "true" || string(token.EmailVerified) == `"true"`
+
+	var groups []string
+	if rawGroups, ok := rawClaims[p.config.GroupsClaim]; ok {
+		groups, err = stringOrStrings(rawGroups)
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidToken, "%s claim", p.config.GroupsClaim)
+		}
+	}
+
+	return &Claims{
+		Subject:       token.Subject,
+		Email:         token.Email,
+		EmailVerified: emailVerified,
+		GivenName:     token.GivenName,
+		FamilyName:    token.FamilyName,
+		Groups:        groups,
+	}, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var d discovery
+	if err := p.do(req, &d); err != nil {
+		return nil, errors.Wrap(err, "discover provider")
+	}
+	if d.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("provider's issuer %q doesn't match %q", d.Issuer, p.config.Issuer)
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+// getKey returns the provider's signing key with an ID. Keys are fetched
+// again when an unknown one is asked for, since providers rotate them, but
+// not more than once every keysRefreshDelay.
+func (p *Provider) getKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < keysRefreshDelay {
+		return nil, ErrUnknownKey
+	}
+
+	req, err := http.NewRequest("GET", d.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.do(req, &jwks); err != nil {
+		return nil, errors.Wrap(err, "fetch provider keys")
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys that can't be parsed are ones that aren't supported
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("provider returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid ec key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
+
+// verifySignature checks a signature with the algorithms providers commonly
+// sign ID tokens with. Symmetric algorithms and "none" are never accepted.
+func verifySignature(algorithm string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch algorithm[:2] {
+	case "RS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature); err != nil {
+			return ErrInvalidSignature
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrInvalidSignature
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if size != hash.Size() || len(signature) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return ErrInvalidSignature
+		}
+	}
+	return nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func stringOrStrings(raw json.RawMessage) ([]string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []string{s}, nil
+	}
+	var ss []string
+	if err := json.Unmarshal(raw, &ss); err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+func contains(ss []string, s string) bool {
+	for _, t := range ss {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testProvider struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &testProvider{
+		key: key,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "key-1",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "deviceplane" || clientSecret != "secret" || r.FormValue("code") != "code" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"id_token": p.idToken,
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+func (p *testProvider) sign(t *testing.T, key *rsa.PrivateKey, algorithm string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": algorithm, "kid": "key-1"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (p *testProvider) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            p.URL,
+		"sub":            "user-1",
+		"aud":            "deviceplane",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          "nonce",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+		"groups":         []string{"engineering", "oncall"},
+	}
+}
+
+func TestExchange(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.Close()
+
+	provider := NewProvider(Config{
+		Issuer:       p.URL,
+		ClientID:     "deviceplane",
+		ClientSecret: "secret",
+		RedirectURL:  "https://deviceplane.example.com/api/sso/oidc/callback",
+	})
+
+	authCodeURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce")
+	require.NoError(t, err)
+	require.Contains(t, authCodeURL, p.URL+"/authorize?")
+	require.Contains(t, authCodeURL, "state=state")
+
+	p.idToken = p.sign(t, p.key, "RS256", p.claims())
+	claims, err := provider.Exchange(context.Background(), "code", "nonce")
+	require.NoError(t, err)
+	require.Equal(t, "jane@example.com", claims.Email)
+	require.True(t, claims.EmailVerified)
+	require.Equal(t, "Jane", claims.GivenName)
+	require.Equal(t, []string{"engineering", "oncall"}, claims.Groups)
+
+	_, err = provider.Exchange(context.Background(), "code", "other")
+	require.Equal(t, ErrNonceMismatch, err)
+
+	_, err = provider.Exchange(context.Background(), "wrong", "nonce")
+	require.Error(t, err)
+}
+
+func TestVerify(t *testing.T) {
+	p := newTestProvider(t)
+	defer p.Close()
+
+	provider := NewProvider(Config{
+		Issuer:   p.URL,
+		ClientID: "deviceplane",
+	})
+	verify := func(idToken string) error {
+		_, err := provider.verify(context.Background(), idToken, "nonce", time.Now())
+		return err
+	}
+
+	claims := p.claims()
+	claims["aud"] = []string{"deviceplane", "other"}
+	claims["azp"] = "deviceplane"
+	require.NoError(t, verify(p.sign(t, p.key, "RS256", claims)))
+
+	claims = p.claims()
+	claims["aud"] = "other"
+	require.Equal(t, ErrAudienceMismatch, verify(p.sign(t, p.key, "RS256", claims)))
+
+	claims = p.claims()
+	claims["iss"] = "https://attacker.example.com"
+	require.Equal(t, ErrIssuerMismatch, verify(p.sign(t, p.key, "RS256", claims)))
+
+	claims = p.claims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	require.Equal(t, ErrTokenExpired, verify(p.sign(t, p.key, "RS256", claims)))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	require.Equal(t, ErrInvalidSignature, verify(p.sign(t, otherKey, "RS256", p.claims())))
+
+	require.Equal(t, ErrUnsupportedAlgorithm, verify(p.sign(t, p.key, "none", p.claims())))
+	require.Equal(t, ErrInvalidToken, verify("not a token"))
+}
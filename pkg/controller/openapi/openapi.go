@@ -0,0 +1,386 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const Version = "3.0.3"
+
+var pathParameterRegex = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Document is an OpenAPI document, limited to what the controller uses.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem is a path's operations by lowercase method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// Route describes what can't be learned about a route from the router: a
+// summary, and the types of its request and response bodies. Types are
+// given as values of them, and nil means there's no body.
+type Route struct {
+	OperationID string
+	Summary     string
+	Request     interface{}
+	Response    interface{}
+}
+
+// Generate returns a document with an operation for every route on router
+// that has methods, except those skip returns true for. routes describes
+// them further, keyed by method and path template, like
+// "GET /api/projects/{project}", and it's an error for one of them not to be
+// on router.
+func Generate(router *mux.Router, info Info, routes map[string]Route, skip func(path string) bool) (*Document, error) {
+	g := &generator{
+		schemas: make(map[string]*Schema),
+		types:   make(map[string]reflect.Type),
+		found:   make(map[string]bool),
+	}
+
+	document := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+	}
+
+	if err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		if skip != nil && skip(pathTemplate) {
+			return nil
+		}
+
+		path := pathParameterRegex.ReplaceAllString(pathTemplate, "{$1}")
+		for _, method := range methods {
+			key := method + " " + path
+			g.found[key] = true
+			operation, err := g.operation(method, path, routes[key])
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			if document.Paths[path] == nil {
+				document.Paths[path] = make(PathItem)
+			}
+			document.Paths[path][strings.ToLower(method)] = operation
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Routes that weren't found are most likely typos
+	for key := range routes {
+		if !g.found[key] {
+			return nil, fmt.Errorf("%s: no such route", key)
+		}
+	}
+
+	document.Components.Schemas = g.schemas
+	return document, nil
+}
+
+type generator struct {
+	schemas map[string]*Schema
+	types   map[string]reflect.Type
+	found   map[string]bool
+}
+
+func (g *generator) operation(method, path string, route Route) (*Operation, error) {
+	operation := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Tags:        tags(path),
+		Responses: map[string]Response{
+			"default": {
+				Description: "Error",
+				Content: map[string]MediaType{
+					"text/plain": {
+						Schema: &Schema{Type: "string"},
+					},
+				},
+			},
+		},
+	}
+	if operation.OperationID == "" {
+		operation.OperationID = operationID(method, path)
+	}
+
+	for _, match := range pathParameterRegex.FindAllStringSubmatch(path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	if route.Request != nil {
+		schema, err := g.schema(reflect.TypeOf(route.Request))
+		if err != nil {
+			return nil, err
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"application/json": {
+					Schema: schema,
+				},
+			},
+		}
+	}
+
+	response := Response{
+		Description: "OK",
+	}
+	if route.Response != nil {
+		schema, err := g.schema(reflect.TypeOf(route.Response))
+		if err != nil {
+			return nil, err
+		}
+		response.Content = map[string]MediaType{
+			"application/json": {
+				Schema: schema,
+			},
+		}
+	}
+	operation.Responses["200"] = response
+
+	return operation, nil
+}
+
+// schema returns the schema of a type. Named structs are added to the
+// document's components and referred to.
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if schema.Ref != "" {
+			return schema, nil
+		}
+		nullable := *schema
+		nullable.Nullable = true
+		return &nullable, nil
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		name := t.Name()
+		if existing, ok := g.types[name]; ok && existing != t {
+			name = strings.Title(pkgName(t)) + name
+		}
+		if _, ok := g.types[name]; !ok {
+			g.types[name] = t
+			// Registered before it's filled in, for types that refer to
+			// themselves
+			g.schemas[name] = &Schema{}
+			schema, err := g.object(t)
+			if err != nil {
+				return nil, err
+			}
+			*g.schemas[name] = *schema
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func (g *generator) object(t reflect.Type) (*Schema, error) {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name := field.Name
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+			name = tagName
+		}
+
+		// Embedded structs' fields are marshaled as if they were this one's
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := g.object(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for name, property := range embedded.Properties {
+				schema.Properties[name] = property
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		property, err := g.schema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", t, field.Name, err)
+		}
+		schema.Properties[name] = property
+	}
+
+	return schema, nil
+}
+
+func pkgName(t reflect.Type) string {
+	parts := strings.Split(t.PkgPath(), "/")
+	return parts[len(parts)-1]
+}
+
+// tags groups operations by the resource their path is about, which is the
+// first part of the path after the project, if there is one.
+func tags(path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 0 && parts[0] == "api" {
+		parts = parts[1:]
+	}
+	if len(parts) > 2 && parts[0] == "projects" {
+		parts = parts[2:]
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	return []string{parts[0]}
+}
+
+// operationID names an operation after its method and path, for routes
+// that aren't given an ID.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "api" {
+			continue
+		}
+		if match := pathParameterRegex.FindStringSubmatch(part); match != nil {
+			id += "By" + strings.Title(match[1])
+		} else {
+			id += strings.Title(part)
+		}
+	}
+	return id
+}
+
+// SortedPaths returns a document's paths in order.
+func (d *Document) SortedPaths() []string {
+	var paths []string
+	for path := range d.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
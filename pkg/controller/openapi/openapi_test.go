@@ -0,0 +1,110 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+type base struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type thing struct {
+	base
+	Name     string            `json:"name"`
+	Count    int64             `json:"count"`
+	Labels   map[string]string `json:"labels"`
+	Parent   *thing            `json:"parent"`
+	Data     []byte            `json:"data"`
+	Ignored  string            `json:"-"`
+	Untagged bool
+	private  string
+}
+
+func TestGenerate(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := mux.NewRouter()
+	apiRouter := router.PathPrefix("/api").Subrouter()
+	apiRouter.HandleFunc("/projects/{project}/things", handler).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/things/{thing:[a-z]+}", handler).Methods("GET", "DELETE")
+	apiRouter.HandleFunc("/internal/things", handler).Methods("GET")
+	apiRouter.PathPrefix("/files/").HandlerFunc(handler)
+
+	document, err := Generate(router, Info{Title: "Test", Version: "1"}, map[string]Route{
+		"POST /api/projects/{project}/things": {
+			Request:  struct{ Name string }{},
+			Response: thing{},
+		},
+		"GET /api/projects/{project}/things/{thing}": {
+			OperationID: "getThing",
+			Response:    []thing{},
+		},
+	}, func(path string) bool {
+		return path == "/api/internal/things"
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"/api/projects/{project}/things",
+		"/api/projects/{project}/things/{thing}",
+	}, document.SortedPaths())
+
+	create := document.Paths["/api/projects/{project}/things"]["post"]
+	require.Equal(t, "postProjectsByProjectThings", create.OperationID)
+	require.Equal(t, []string{"things"}, create.Tags)
+	require.Equal(t, []Parameter{
+		{Name: "project", In: "path", Required: true, Schema: &Schema{Type: "string"}},
+	}, create.Parameters)
+	require.Equal(t, &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"Name": {Type: "string"},
+		},
+	}, create.RequestBody.Content["application/json"].Schema)
+	require.Equal(t, &Schema{Ref: "#/components/schemas/thing"}, create.Responses["200"].Content["application/json"].Schema)
+
+	get := document.Paths["/api/projects/{project}/things/{thing}"]["get"]
+	require.Equal(t, "getThing", get.OperationID)
+	require.Len(t, get.Parameters, 2)
+	require.Equal(t, &Schema{
+		Type:  "array",
+		Items: &Schema{Ref: "#/components/schemas/thing"},
+	}, get.Responses["200"].Content["application/json"].Schema)
+
+	remove := document.Paths["/api/projects/{project}/things/{thing}"]["delete"]
+	require.Equal(t, "deleteProjectsByProjectThingsByThing", remove.OperationID)
+	require.Nil(t, remove.RequestBody)
+	require.Nil(t, remove.Responses["200"].Content)
+
+	require.Equal(t, map[string]*Schema{
+		"thing": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"id":        {Type: "string"},
+				"createdAt": {Type: "string", Format: "date-time"},
+				"name":      {Type: "string"},
+				"count":     {Type: "integer", Format: "int64"},
+				"labels":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+				"parent":    {Ref: "#/components/schemas/thing"},
+				"data":      {Type: "string", Format: "byte"},
+				"Untagged":  {Type: "boolean"},
+			},
+		},
+	}, document.Components.Schemas)
+}
+
+func TestGenerateUnknownRoute(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/things", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	_, err := Generate(router, Info{}, map[string]Route{
+		"GET /api/thigns": {},
+	}, nil)
+	require.Error(t, err)
+}
@@ -4,16 +4,26 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/utils"
 )
 
+// ReleasesPropertyPrefix starts the device properties holding the numbers of
+// the releases devices report running, such as releases.web. Devices that
+// don't report running the application only match the negative operators.
+const ReleasesPropertyPrefix = "releases."
+
 var (
 	ErrConditionNotSupported = errors.New("condition not supported")
 	ErrOperatorNotSupported  = errors.New("operator not supported")
 	ErrPropertyNotSupported  = errors.New("device property not supported")
 	ErrNoEmptyFields         = errors.New("fields should not be empty")
+	ErrInvalidLocation       = errors.New("invalid location")
 )
 
 func ValidateQuery(query models.Query) error {
@@ -86,55 +96,79 @@ func validateCondition(condition models.Condition) error {
 		if params.Property == "" {
 			return ErrNoEmptyFields
 		}
-		if params.Value == "" {
+
+		return validateValue(params.Operator, params.Value, params.Values)
+
+	case models.LabelValueCondition:
+		var params models.LabelValueConditionParams
+		err := utils.JSONConvert(condition.Params, &params)
+		if err != nil {
+			return err
+		}
+
+		if params.Key == "" {
+			return ErrNoEmptyFields
+		}
+
+		return validateValue(params.Operator, params.Value, params.Values)
+
+	case models.LabelExistenceCondition:
+		var params models.LabelExistenceConditionParams
+		err := utils.JSONConvert(condition.Params, &params)
+		if err != nil {
+			return err
+		}
+
+		if params.Key == "" {
 			return ErrNoEmptyFields
 		}
 
 		switch params.Operator {
-		case models.OperatorIs:
+		case models.OperatorExists:
 			return nil
-		case models.OperatorIsNot:
+		case models.OperatorNotExists:
 			return nil
 		}
 		return ErrOperatorNotSupported
 
-	case models.LabelValueCondition:
-		var params models.LabelValueConditionParams
+	case models.DeviceLocationCondition:
+		var params models.DeviceLocationConditionParams
 		err := utils.JSONConvert(condition.Params, &params)
 		if err != nil {
 			return err
 		}
 
-		if params.Key == "" {
-			return ErrNoEmptyFields
+		if params.Latitude < -90 || params.Latitude > 90 ||
+			params.Longitude < -180 || params.Longitude > 180 {
+			return ErrInvalidLocation
 		}
-		if params.Value == "" {
-			return ErrNoEmptyFields
+		if params.Radius <= 0 {
+			return ErrInvalidLocation
 		}
 
 		switch params.Operator {
-		case models.OperatorIs:
+		case models.OperatorWithin:
 			return nil
-		case models.OperatorIsNot:
+		case models.OperatorNotWithin:
 			return nil
 		}
 		return ErrOperatorNotSupported
 
-	case models.LabelExistenceCondition:
-		var params models.LabelExistenceConditionParams
+	case models.DeviceGroupCondition:
+		var params models.DeviceGroupConditionParams
 		err := utils.JSONConvert(condition.Params, &params)
 		if err != nil {
 			return err
 		}
 
-		if params.Key == "" {
+		if params.Group == "" {
 			return ErrNoEmptyFields
 		}
 
 		switch params.Operator {
-		case models.OperatorExists:
+		case models.OperatorIs:
 			return nil
-		case models.OperatorNotExists:
+		case models.OperatorIsNot:
 			return nil
 		}
 		return ErrOperatorNotSupported
@@ -160,19 +194,13 @@ func deviceMatchesCondition(device models.Device, condition models.Condition) (b
 			return false, err
 		}
 
-		value, exists := deviceMap[params.Property]
-		if !exists {
+		value, exists := lookupProperty(deviceMap, params.Property)
+		if !exists && !strings.HasPrefix(params.Property, ReleasesPropertyPrefix) {
 			return false, ErrPropertyNotSupported
 		}
 
-		match := value == params.Value
-		switch params.Operator {
-		case models.OperatorIs:
-			return match, nil
-		case models.OperatorIsNot:
-			return !match, nil
-		}
-		return false, ErrOperatorNotSupported
+		str, ok := propertyString(value)
+		return valueMatches(str, ok, params.Operator, params.Value, params.Values)
 
 	case models.LabelValueCondition:
 		var params models.LabelValueConditionParams
@@ -181,16 +209,8 @@ func deviceMatchesCondition(device models.Device, condition models.Condition) (b
 			return false, err
 		}
 
-		value, ok := device.Labels[params.Key]
-		valueMatches := bool(ok && value == params.Value)
-
-		switch params.Operator {
-		case models.OperatorIs:
-			return valueMatches, nil
-		case models.OperatorIsNot:
-			return !valueMatches, nil
-		}
-		return false, ErrOperatorNotSupported
+		value, ok := device.Label(params.Key)
+		return valueMatches(value, ok, params.Operator, params.Value, params.Values)
 
 	case models.LabelExistenceCondition:
 		var params models.LabelExistenceConditionParams
@@ -199,7 +219,7 @@ func deviceMatchesCondition(device models.Device, condition models.Condition) (b
 			return false, err
 		}
 
-		_, ok := device.Labels[params.Key]
+		_, ok := device.Label(params.Key)
 		switch params.Operator {
 		case models.OperatorExists:
 			return ok, nil
@@ -207,10 +227,150 @@ func deviceMatchesCondition(device models.Device, condition models.Condition) (b
 			return !ok, nil
 		}
 		return false, ErrOperatorNotSupported
+
+	case models.DeviceLocationCondition:
+		var params models.DeviceLocationConditionParams
+		err := utils.JSONConvert(condition.Params, &params)
+		if err != nil {
+			return false, err
+		}
+
+		// Devices that haven't reported a location aren't within anywhere
+		location := device.Info.Location
+		within := location != nil &&
+			distance(location.Latitude, location.Longitude, params.Latitude, params.Longitude) <= params.Radius
+
+		switch params.Operator {
+		case models.OperatorWithin:
+			return within, nil
+		case models.OperatorNotWithin:
+			return !within, nil
+		}
+		return false, ErrOperatorNotSupported
+
+	case models.DeviceGroupCondition:
+		var params models.DeviceGroupConditionParams
+		err := utils.JSONConvert(condition.Params, &params)
+		if err != nil {
+			return false, err
+		}
+
+		// Devices' groups have to be filled in beforehand
+		inGroup := false
+		for _, group := range device.Groups {
+			if group == params.Group {
+				inGroup = true
+				break
+			}
+		}
+
+		switch params.Operator {
+		case models.OperatorIs:
+			return inGroup, nil
+		case models.OperatorIsNot:
+			return !inGroup, nil
+		}
+		return false, ErrOperatorNotSupported
 	}
 	return false, ErrConditionNotSupported
 }
 
+func validateValue(operator models.Operator, value string, values []string) error {
+	switch operator {
+	case models.OperatorIs, models.OperatorIsNot,
+		models.OperatorGreaterThan, models.OperatorGreaterThanOrEqual,
+		models.OperatorLessThan, models.OperatorLessThanOrEqual:
+		if value == "" {
+			return ErrNoEmptyFields
+		}
+		return nil
+	case models.OperatorIn, models.OperatorNotIn:
+		if len(values) == 0 {
+			return ErrNoEmptyFields
+		}
+		for _, v := range values {
+			if v == "" {
+				return ErrNoEmptyFields
+			}
+		}
+		return nil
+	}
+	return ErrOperatorNotSupported
+}
+
+// valueMatches compares a label or property against a condition. ok is
+// false if the device doesn't have the label or property, in which case
+// only the negative operators match. Empty values never match the ordering
+// operators.
+func valueMatches(value string, ok bool, operator models.Operator, expected string, expectedValues []string) (bool, error) {
+	ordered := ok && value != ""
+	switch operator {
+	case models.OperatorIs:
+		return ok && value == expected, nil
+	case models.OperatorIsNot:
+		return !(ok && value == expected), nil
+	case models.OperatorIn, models.OperatorNotIn:
+		in := false
+		if ok {
+			for _, v := range expectedValues {
+				if value == v {
+					in = true
+					break
+				}
+			}
+		}
+		return in == (operator == models.OperatorIn), nil
+	case models.OperatorGreaterThan:
+		return ordered && compareValues(value, expected) > 0, nil
+	case models.OperatorGreaterThanOrEqual:
+		return ordered && compareValues(value, expected) >= 0, nil
+	case models.OperatorLessThan:
+		return ordered && compareValues(value, expected) < 0, nil
+	case models.OperatorLessThanOrEqual:
+		return ordered && compareValues(value, expected) <= 0, nil
+	}
+	return false, ErrOperatorNotSupported
+}
+
+// compareValues compares times if value is one and expected is an absolute
+// or relative time, and versions otherwise.
+func compareValues(value, expected string) int {
+	if valueTime, err := time.Parse(time.RFC3339, value); err == nil {
+		if expectedTime, ok := ParseTime(expected, time.Now()); ok {
+			switch {
+			case valueTime.Before(expectedTime):
+				return -1
+			case valueTime.After(expectedTime):
+				return 1
+			}
+			return 0
+		}
+	}
+	return models.CompareVersions(value, expected)
+}
+
+// ParseTime parses the value of a condition on a time, such as lastSeenAt.
+// It's either an RFC 3339 time or relative to now, like now or now-24h.
+func ParseTime(value string, now time.Time) (time.Time, bool) {
+	if !strings.HasPrefix(value, "now") {
+		t, err := time.Parse(time.RFC3339, value)
+		return t, err == nil
+	}
+
+	offset := strings.TrimPrefix(value, "now")
+	if offset == "" {
+		return now, true
+	}
+	if offset[0] != '-' && offset[0] != '+' {
+		return time.Time{}, false
+	}
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return now.Add(d), true
+}
+
 func FiltersFromQuery(query map[string][]string) ([]models.Filter, error) {
 	var filters []models.Filter
 
@@ -234,3 +394,52 @@ func FiltersFromQuery(query map[string][]string) ([]models.Filter, error) {
 
 	return filters, nil
 }
+
+// lookupProperty finds a property in a device map. Nested properties such as
+// info.hardware.architecture are separated by dots.
+func lookupProperty(deviceMap map[string]interface{}, property string) (interface{}, bool) {
+	var value interface{} = deviceMap
+	for _, key := range strings.Split(property, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// propertyString formats a property so it can be compared against the value
+// in a condition. Numbers come out of JSON as floats, so they're formatted
+// without an exponent. Objects, arrays and nulls never match.
+func propertyString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+const earthRadius = 6371000
+
+// distance returns the great-circle distance in meters between two points
+// using the haversine formula.
+func distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	radians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+
+	deltaLatitude := radians(latitude2 - latitude1)
+	deltaLongitude := radians(longitude2 - longitude1)
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(radians(latitude1))*math.Cos(radians(latitude2))*
+			math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
@@ -75,6 +75,62 @@ func TestQueryDevices(t *testing.T) {
 				},
 				out: []models.Device{},
 			},
+			Scenario{
+				desc: "Query devices for nested hardware properties",
+				in: []models.Device{
+					models.Device{
+						ID: "one",
+						Info: models.DeviceInfo{
+							Hardware: models.Hardware{
+								Architecture: "armv7l",
+								CPUCount:     4,
+							},
+						},
+					},
+					models.Device{
+						ID: "two",
+						Info: models.DeviceInfo{
+							Hardware: models.Hardware{
+								Architecture: "x86_64",
+								CPUCount:     4,
+							},
+						},
+					},
+				},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DevicePropertyCondition,
+							Params: map[string]interface{}{
+								"property": "info.hardware.architecture",
+								"operator": models.OperatorIs,
+								"value":    "armv7l",
+							},
+						},
+					},
+					models.Filter{
+						models.Condition{
+							Type: models.DevicePropertyCondition,
+							Params: map[string]interface{}{
+								"property": "info.hardware.cpuCount",
+								"operator": models.OperatorIs,
+								"value":    "4",
+							},
+						},
+					},
+				},
+				out: []models.Device{
+					models.Device{
+						ID: "one",
+						Info: models.DeviceInfo{
+							Hardware: models.Hardware{
+								Architecture: "armv7l",
+								CPUCount:     4,
+							},
+						},
+					},
+				},
+			},
 		}
 
 		for _, scenario := range scenarios {
@@ -262,6 +318,285 @@ func TestQueryDevices(t *testing.T) {
 		}
 	})
 
+	t.Run("system labels", func(t *testing.T) {
+		nano := models.Device{
+			ID: "nano",
+			Info: models.DeviceInfo{
+				Hardware: models.Hardware{
+					Model: "NVIDIA Jetson Nano Developer Kit",
+					SoC:   "nvidia,tegra210",
+				},
+			},
+		}
+		pi := models.Device{
+			ID: "pi",
+			Info: models.DeviceInfo{
+				Hardware: models.Hardware{
+					Model: "Raspberry Pi 4 Model B Rev 1.1",
+				},
+			},
+			Labels: map[string]string{
+				"hardware-model": "NVIDIA Jetson Nano Developer Kit",
+			},
+		}
+
+		scenarios := []Scenario{
+			Scenario{
+				desc: "Query for hardware model",
+				in:   []models.Device{nano, pi},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.LabelValueCondition,
+							Params: map[string]interface{}{
+								"key":      "hardware-model",
+								"operator": models.OperatorIs,
+								"value":    "NVIDIA Jetson Nano Developer Kit",
+							},
+						},
+					},
+				},
+				out: []models.Device{nano},
+			},
+			Scenario{
+				desc: "Query for devices with a known SoC",
+				in:   []models.Device{nano, pi},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.LabelExistenceCondition,
+							Params: map[string]interface{}{
+								"key":      "hardware-soc",
+								"operator": models.OperatorExists,
+							},
+						},
+					},
+				},
+				out: []models.Device{nano},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			testScenario(t, scenario)
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		berlin := models.Device{
+			ID: "berlin",
+			Info: models.DeviceInfo{
+				Location: &models.Location{
+					Latitude:  52.52,
+					Longitude: 13.405,
+				},
+			},
+		}
+		potsdam := models.Device{
+			ID: "potsdam",
+			Info: models.DeviceInfo{
+				Location: &models.Location{
+					Latitude:  52.3906,
+					Longitude: 13.0645,
+				},
+			},
+		}
+		unlocated := models.Device{
+			ID: "unlocated",
+		}
+
+		scenarios := []Scenario{
+			Scenario{
+				desc: "Query devices within 10km of Berlin",
+				in:   []models.Device{berlin, potsdam, unlocated},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DeviceLocationCondition,
+							Params: map[string]interface{}{
+								"latitude":  52.52,
+								"longitude": 13.405,
+								"radius":    10000,
+								"operator":  models.OperatorWithin,
+							},
+						},
+					},
+				},
+				out: []models.Device{berlin},
+			},
+			Scenario{
+				desc: "Query devices not within 10km of Berlin",
+				in:   []models.Device{berlin, potsdam, unlocated},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DeviceLocationCondition,
+							Params: map[string]interface{}{
+								"latitude":  52.52,
+								"longitude": 13.405,
+								"radius":    10000,
+								"operator":  models.OperatorNotWithin,
+							},
+						},
+					},
+				},
+				out: []models.Device{potsdam, unlocated},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			testScenario(t, scenario)
+		}
+
+		require.InDelta(t, 27000, distance(52.52, 13.405, 52.3906, 13.0645), 1000)
+		require.Error(t, ValidateQuery(models.Query{
+			models.Filter{
+				models.Condition{
+					Type: models.DeviceLocationCondition,
+					Params: map[string]interface{}{
+						"latitude":  91,
+						"longitude": 13.405,
+						"radius":    10000,
+						"operator":  models.OperatorWithin,
+					},
+				},
+			},
+		}))
+	})
+
+	t.Run("device groups", func(t *testing.T) {
+		grouped := models.Device{
+			ID:     "grouped",
+			Groups: []string{"dgp_1", "dgp_2"},
+		}
+		ungrouped := models.Device{
+			ID: "ungrouped",
+		}
+
+		scenarios := []Scenario{
+			Scenario{
+				desc: "Query devices in a group",
+				in:   []models.Device{grouped, ungrouped},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DeviceGroupCondition,
+							Params: map[string]interface{}{
+								"group":    "dgp_2",
+								"operator": models.OperatorIs,
+							},
+						},
+					},
+				},
+				out: []models.Device{grouped},
+			},
+			Scenario{
+				desc: "Query devices not in a group",
+				in:   []models.Device{grouped, ungrouped},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DeviceGroupCondition,
+							Params: map[string]interface{}{
+								"group":    "dgp_1",
+								"operator": models.OperatorIsNot,
+							},
+						},
+					},
+				},
+				out: []models.Device{ungrouped},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			testScenario(t, scenario)
+		}
+
+		require.Equal(t, ErrNoEmptyFields, ValidateQuery(models.Query{
+			models.Filter{
+				models.Condition{
+					Type: models.DeviceGroupCondition,
+					Params: map[string]interface{}{
+						"operator": models.OperatorIs,
+					},
+				},
+			},
+		}))
+	})
+
+	t.Run("reported versions", func(t *testing.T) {
+		current := models.Device{
+			ID:       "current",
+			Info:     models.DeviceInfo{AgentVersion: "1.10.0"},
+			Releases: map[string]uint32{"web": 42},
+		}
+		outdated := models.Device{
+			ID:       "outdated",
+			Info:     models.DeviceInfo{AgentVersion: "1.9.2"},
+			Releases: map[string]uint32{"web": 9},
+		}
+		unreported := models.Device{
+			ID: "unreported",
+		}
+
+		scenarios := []Scenario{
+			Scenario{
+				desc: "Query devices still on an old release",
+				in:   []models.Device{current, outdated, unreported},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DevicePropertyCondition,
+							Params: map[string]interface{}{
+								"property": "releases.web",
+								"operator": models.OperatorLessThan,
+								"value":    "42",
+							},
+						},
+					},
+				},
+				out: []models.Device{outdated},
+			},
+			Scenario{
+				desc: "Query devices not on a release",
+				in:   []models.Device{current, outdated, unreported},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DevicePropertyCondition,
+							Params: map[string]interface{}{
+								"property": "releases.web",
+								"operator": models.OperatorIsNot,
+								"value":    "42",
+							},
+						},
+					},
+				},
+				out: []models.Device{outdated, unreported},
+			},
+			Scenario{
+				desc: "Query devices with old agents",
+				in:   []models.Device{current, outdated, unreported},
+				query: models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.DevicePropertyCondition,
+							Params: map[string]interface{}{
+								"property": "info.agentVersion",
+								"operator": models.OperatorLessThan,
+								"value":    "1.10",
+							},
+						},
+					},
+				},
+				out: []models.Device{outdated},
+			},
+		}
+
+		for _, scenario := range scenarios {
+			testScenario(t, scenario)
+		}
+	})
+
 	t.Run("edge cases", func(t *testing.T) {
 		scenarios := []Scenario{
 			Scenario{
@@ -0,0 +1,250 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// ParseSelector parses a label selector expression into a query. An
+// expression is a list of requirements joined by "and" or commas, all of
+// which a device has to meet:
+//
+//	key                  the label exists
+//	!key                 the label doesn't exist
+//	key = value          also == and !=
+//	key in (a, b)        also notin and not in
+//	key >= 1.2.0         also >, < and <=, comparing versions
+//
+// Keys containing a dot, such as info.agentVersion, refer to device
+// properties instead of labels. Top-level properties are prefixed with
+// device, as in device.status = online. The releases devices report running
+// are properties too, as in releases.web < 42. Times such as device.lastSeenAt can
+// be compared with RFC 3339 times or times relative to now, as in
+// device.lastSeenAt < now-24h.
+func ParseSelector(selector string) (models.Query, error) {
+	tokens, err := tokenizeSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &selectorParser{tokens: tokens}
+	query := make(models.Query, 0)
+	for {
+		condition, err := p.requirement()
+		if err != nil {
+			return nil, err
+		}
+		query = append(query, models.Filter{condition})
+
+		if p.done() {
+			break
+		}
+		if next := p.next(); next != "," && !strings.EqualFold(next, "and") {
+			return nil, fmt.Errorf("expected and, got %q", next)
+		}
+	}
+
+	if err := ValidateQuery(query); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+const devicePropertyPrefix = "device."
+
+type selectorParser struct {
+	tokens []string
+	i      int
+}
+
+func (p *selectorParser) done() bool {
+	return p.i >= len(p.tokens)
+}
+
+func (p *selectorParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.i]
+}
+
+func (p *selectorParser) next() string {
+	token := p.peek()
+	p.i++
+	return token
+}
+
+func (p *selectorParser) value() (string, error) {
+	token := p.next()
+	if token == "" || isSelectorSymbol(token) {
+		return "", fmt.Errorf("expected value, got %q", token)
+	}
+	return token, nil
+}
+
+func (p *selectorParser) requirement() (models.Condition, error) {
+	if p.peek() == "!" {
+		p.next()
+		key, err := p.value()
+		if err != nil {
+			return models.Condition{}, err
+		}
+		return models.Condition{
+			Type: models.LabelExistenceCondition,
+			Params: map[string]interface{}{
+				"key":      key,
+				"operator": string(models.OperatorNotExists),
+			},
+		}, nil
+	}
+
+	key, err := p.value()
+	if err != nil {
+		return models.Condition{}, err
+	}
+
+	var operator models.Operator
+	switch token := p.peek(); strings.ToLower(token) {
+	case "", ",", "and":
+		return models.Condition{
+			Type: models.LabelExistenceCondition,
+			Params: map[string]interface{}{
+				"key":      key,
+				"operator": string(models.OperatorExists),
+			},
+		}, nil
+	case "=", "==":
+		operator = models.OperatorIs
+	case "!=":
+		operator = models.OperatorIsNot
+	case ">":
+		operator = models.OperatorGreaterThan
+	case ">=":
+		operator = models.OperatorGreaterThanOrEqual
+	case "<":
+		operator = models.OperatorLessThan
+	case "<=":
+		operator = models.OperatorLessThanOrEqual
+	case "in":
+		operator = models.OperatorIn
+	case "notin":
+		operator = models.OperatorNotIn
+	case "not":
+		p.next()
+		if next := p.peek(); !strings.EqualFold(next, "in") {
+			return models.Condition{}, fmt.Errorf("expected in, got %q", next)
+		}
+		operator = models.OperatorNotIn
+	default:
+		return models.Condition{}, fmt.Errorf("unknown operator %q", token)
+	}
+	p.next()
+
+	params := map[string]interface{}{
+		"operator": string(operator),
+	}
+	if operator == models.OperatorIn || operator == models.OperatorNotIn {
+		values, err := p.values()
+		if err != nil {
+			return models.Condition{}, err
+		}
+		params["values"] = values
+	} else {
+		value, err := p.value()
+		if err != nil {
+			return models.Condition{}, err
+		}
+		params["value"] = value
+	}
+
+	if strings.Contains(key, ".") {
+		params["property"] = strings.TrimPrefix(key, devicePropertyPrefix)
+		return models.Condition{
+			Type:   models.DevicePropertyCondition,
+			Params: params,
+		}, nil
+	}
+	params["key"] = key
+	return models.Condition{
+		Type:   models.LabelValueCondition,
+		Params: params,
+	}, nil
+}
+
+func (p *selectorParser) values() ([]string, error) {
+	if token := p.next(); token != "(" {
+		return nil, fmt.Errorf("expected (, got %q", token)
+	}
+
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		switch token := p.next(); token {
+		case ",":
+			continue
+		case ")":
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected , or ), got %q", token)
+		}
+	}
+}
+
+func isSelectorSymbol(token string) bool {
+	switch token {
+	case "(", ")", ",", "!", "=", "==", "!=", ">", ">=", "<", "<=":
+		return true
+	}
+	return false
+}
+
+func tokenizeSelector(selector string) ([]string, error) {
+	var tokens []string
+	runes := []rune(selector)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '!' || r == '=' || r == '>' || r == '<':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote in selector")
+			}
+			tokens = append(tokens, string(runes[i+1:end]))
+			i = end + 1
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("(),!=<>\"'", runes[i]) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty selector")
+	}
+	return tokens, nil
+}
@@ -0,0 +1,127 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	devices := []models.Device{
+		models.Device{
+			ID:         "one",
+			Status:     models.DeviceStatusOnline,
+			LastSeenAt: now.Add(-time.Minute),
+			Labels: map[string]string{
+				"region": "eu",
+				"hw":     "rpi4",
+				"fw":     "1.10.0",
+			},
+			Info: models.DeviceInfo{
+				AgentVersion: "1.4.0",
+			},
+		},
+		models.Device{
+			ID:         "two",
+			Status:     models.DeviceStatusOffline,
+			LastSeenAt: now.Add(-48 * time.Hour),
+			Labels: map[string]string{
+				"region": "uk",
+				"hw":     "rpi3",
+				"fw":     "1.9.2",
+			},
+			Info: models.DeviceInfo{
+				AgentVersion: "1.2.0",
+			},
+		},
+		models.Device{
+			ID:     "three",
+			Status: models.DeviceStatusOffline,
+			Labels: map[string]string{
+				"region": "us",
+				"canary": "",
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		selector string
+		ids      []string
+	}{
+		{"region in (eu, uk)", []string{"one", "two"}},
+		{"region notin (eu, uk)", []string{"three"}},
+		{"region not in (eu)", []string{"two", "three"}},
+		{"region = eu", []string{"one"}},
+		{"region == uk", []string{"two"}},
+		{"hw != rpi3", []string{"one", "three"}},
+		{"canary", []string{"three"}},
+		{"!canary", []string{"one", "two"}},
+		{"fw >= 1.10", []string{"one"}},
+		{"fw < 1.10", []string{"two"}},
+		{"info.agentVersion > 1.2.0", []string{"one"}},
+		{"info.agentVersion <= 1.2", []string{"two"}},
+		{"region in (eu, uk) and hw != rpi3", []string{"one"}},
+		{"region in (eu,uk),hw=rpi3", []string{"two"}},
+		{`region = "eu"`, []string{"one"}},
+		{"device.status = online", []string{"one"}},
+		{"device.status != online", []string{"two", "three"}},
+		{"device.lastSeenAt >= now-1h", []string{"one"}},
+		{"device.lastSeenAt < now-24h", []string{"two", "three"}},
+		{"device.lastSeenAt > 2000-01-01T00:00:00Z", []string{"one", "two"}},
+	} {
+		query, err := ParseSelector(tc.selector)
+		require.NoError(t, err, tc.selector)
+
+		selectedDevices, _, err := QueryDevices(devices, query)
+		require.NoError(t, err, tc.selector)
+
+		var ids []string
+		for _, device := range selectedDevices {
+			ids = append(ids, device.ID)
+		}
+		require.Equal(t, tc.ids, ids, tc.selector)
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, selector := range []string{
+		"",
+		"   ",
+		"region =",
+		"region in eu",
+		"region in ()",
+		"region in (eu",
+		"region ~ eu",
+		"region = eu or hw = rpi3",
+		"= eu",
+		`region = "eu`,
+	} {
+		_, err := ParseSelector(selector)
+		require.Error(t, err, selector)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{"now", now, true},
+		{"now-24h", now.Add(-24 * time.Hour), true},
+		{"now+90m", now.Add(90 * time.Minute), true},
+		{"2019-12-31T00:00:00Z", time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC), true},
+		{"now24h", time.Time{}, false},
+		{"now-day", time.Time{}, false},
+		{"yesterday", time.Time{}, false},
+		{"1.2.3", time.Time{}, false},
+	} {
+		parsed, ok := ParseTime(tc.value, now)
+		require.Equal(t, tc.ok, ok, tc.value)
+		require.True(t, tc.expected.Equal(parsed), tc.value)
+	}
+}
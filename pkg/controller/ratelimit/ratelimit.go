@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	LimitHeader      = "RateLimit-Limit"
+	RemainingHeader  = "RateLimit-Remaining"
+	ResetHeader      = "RateLimit-Reset"
+	RetryAfterHeader = "Retry-After"
+
+	pruneInterval = time.Minute
+)
+
+// Limiter limits the rate of requests made with each of a set of keys using
+// token buckets. Each key's bucket holds up to burst tokens and refills at
+// rate tokens a second, and each request takes a token.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	lock     sync.Mutex
+	buckets  map[string]*bucket
+	prunedAt time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Result is what a limiter decided about a request.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until a request would be allowed, if this one
+	// wasn't.
+	RetryAfter time.Duration
+}
+
+func New(rate float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from a key's bucket if there is one.
+func (l *Limiter) Allow(key string, now time.Time) Result {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if now.Sub(l.prunedAt) >= pruneInterval {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(l.burst),
+		}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+	}
+	b.updatedAt = now
+
+	result := Result{
+		Limit: l.burst,
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(float64(l.burst) - b.tokens)
+
+	return result
+}
+
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	return math.Min(float64(l.burst), b.tokens+now.Sub(b.updatedAt).Seconds()*l.rate)
+}
+
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.rate * float64(time.Second)))
+}
+
+// prune forgets full buckets, since they're the same as new ones.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.prunedAt = now
+}
+
+// SetHeaders sets the rate limit headers of a response. Durations are
+// rounded up to whole seconds.
+func (r Result) SetHeaders(header http.Header) {
+	header.Set(LimitHeader, strconv.Itoa(r.Limit))
+	header.Set(RemainingHeader, strconv.Itoa(r.Remaining))
+	header.Set(ResetHeader, seconds(r.Reset))
+	if !r.Allowed {
+		header.Set(RetryAfterHeader, seconds(r.RetryAfter))
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllow(t *testing.T) {
+	l := New(2, 3)
+	now := time.Now()
+
+	for i := 2; i >= 0; i-- {
+		result := l.Allow("a", now)
+		require.True(t, result.Allowed)
+		require.Equal(t, 3, result.Limit)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result := l.Allow("a", now)
+	require.False(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+	require.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	require.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// Other keys have their own buckets
+	require.True(t, l.Allow("b", now).Allowed)
+
+	// Half a second refills one token
+	now = now.Add(500 * time.Millisecond)
+	require.True(t, l.Allow("a", now).Allowed)
+	require.False(t, l.Allow("a", now).Allowed)
+
+	// Buckets don't fill past burst
+	now = now.Add(time.Hour)
+	require.Equal(t, 2, l.Allow("a", now).Remaining)
+}
+
+func TestPrune(t *testing.T) {
+	l := New(1, 2)
+	now := time.Now()
+
+	l.Allow("a", now)
+	l.Allow("b", now)
+	l.Allow("b", now)
+
+	l.prune(now.Add(time.Second))
+	require.NotContains(t, l.buckets, "a")
+	require.Contains(t, l.buckets, "b")
+}
+
+func TestSetHeaders(t *testing.T) {
+	header := http.Header{}
+	Result{
+		Allowed:   true,
+		Limit:     10,
+		Remaining: 9,
+		Reset:     1500 * time.Millisecond,
+	}.SetHeaders(header)
+	require.Equal(t, "10", header.Get(LimitHeader))
+	require.Equal(t, "9", header.Get(RemainingHeader))
+	require.Equal(t, "2", header.Get(ResetHeader))
+	require.Empty(t, header.Get(RetryAfterHeader))
+
+	header = http.Header{}
+	Result{
+		Limit:      10,
+		Reset:      10 * time.Second,
+		RetryAfter: 100 * time.Millisecond,
+	}.SetHeaders(header)
+	require.Equal(t, "1", header.Get(RetryAfterHeader))
+}
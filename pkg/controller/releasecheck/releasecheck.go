@@ -0,0 +1,87 @@
+package releasecheck
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/spec"
+)
+
+// Diagnose extends spec.Diagnose with the checks that need the project, such
+// as whether referenced environment files exist and what the project's
+// release webhooks make of the config.
+func Diagnose(ctx context.Context, environmentFiles store.EnvironmentFiles, releaseWebhooksConfigs store.ReleaseWebhooksConfigs,
+	projectID, applicationID, rawConfig string,
+) ([]models.ReleaseDiagnostic, error) {
+	diagnostics := spec.Diagnose([]byte(rawConfig))
+	if spec.HasErrors(diagnostics) {
+		return diagnostics, nil
+	}
+
+	var applicationConfig map[string]models.Service
+	if err := yaml.UnmarshalStrict([]byte(rawConfig), &applicationConfig); err != nil {
+		return nil, err
+	}
+
+	var serviceNames []string
+	for serviceName := range applicationConfig {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		for _, name := range applicationConfig[serviceName].EnvFile {
+			_, err := environmentFiles.LookupEnvironmentFile(ctx, name, projectID)
+			if err == store.ErrEnvironmentFileNotFound {
+				diagnostics = append(diagnostics, models.ReleaseDiagnostic{
+					Service:  serviceName,
+					Key:      "env_file",
+					Severity: models.DiagnosticSeverityError,
+					Message:  fmt.Sprintf("%s: %s", err.Error(), name),
+				})
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Project webhooks only see configs that are otherwise valid
+	if spec.HasErrors(diagnostics) {
+		return diagnostics, nil
+	}
+
+	releaseWebhooksConfig, err := releaseWebhooksConfigs.GetReleaseWebhooksConfig(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range releaseWebhooksConfig.Webhooks {
+		webhookDiagnostics, err := releasewebhooks.Call(ctx, webhook, models.ReleaseWebhookRequest{
+			ProjectID:     projectID,
+			ApplicationID: applicationID,
+			RawConfig:     rawConfig,
+			Config:        applicationConfig,
+		})
+		if err != nil {
+			// Releases aren't accepted without the webhook's say
+			diagnostics = append(diagnostics, models.ReleaseDiagnostic{
+				Severity: models.DiagnosticSeverityError,
+				Message:  fmt.Sprintf("release webhook %s failed: %s", webhook.Name, err.Error()),
+			})
+			continue
+		}
+
+		for _, diagnostic := range webhookDiagnostics {
+			diagnostic.Message = fmt.Sprintf("%s (release webhook %s)", diagnostic.Message, webhook.Name)
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	return diagnostics, nil
+}
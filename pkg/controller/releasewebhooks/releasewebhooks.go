@@ -0,0 +1,99 @@
+package releasewebhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	SignatureHeader = "X-Deviceplane-Signature"
+
+	timeout          = 10 * time.Second
+	maxErrorBodySize = 512
+)
+
+var (
+	ErrMissingName = errors.New("release webhooks need a name")
+	ErrInvalidURL  = errors.New("release webhook URLs must be http or https URLs")
+)
+
+// Validate checks the webhooks of a release webhooks config.
+func Validate(config models.ReleaseWebhooksConfig) error {
+	for _, webhook := range config.Webhooks {
+		if webhook.Name == "" {
+			return ErrMissingName
+		}
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidURL
+		}
+	}
+	return nil
+}
+
+// Call sends a release config to a webhook and returns the diagnostics it
+// responds with. Diagnostics without a severity are errors.
+func Call(ctx context.Context, webhook models.ReleaseWebhook, request models.ReleaseWebhookRequest) ([]models.ReleaseDiagnostic, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var response models.ReleaseWebhookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "decode webhook response")
+	}
+
+	for i := range response.Diagnostics {
+		if response.Diagnostics[i].Severity == "" {
+			response.Diagnostics[i].Severity = models.DiagnosticSeverityError
+		}
+	}
+	return response.Diagnostics, nil
+}
+
+// Sign returns the signature header value of a request body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,104 @@
+package releasewebhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCall(t *testing.T) {
+	var signature string
+	var received models.ReleaseWebhookRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		signature = r.Header.Get(SignatureHeader)
+		require.Equal(t, Sign("secret", body), signature)
+		require.NoError(t, json.Unmarshal(body, &received))
+
+		var diagnostics []models.ReleaseDiagnostic
+		for name, service := range received.Config {
+			if service.Privileged {
+				diagnostics = append(diagnostics, models.ReleaseDiagnostic{
+					Service: name,
+					Key:     "privileged",
+					Message: "privileged containers aren't allowed",
+				})
+			}
+		}
+		json.NewEncoder(w).Encode(models.ReleaseWebhookResponse{
+			Diagnostics: diagnostics,
+		})
+	}))
+	defer server.Close()
+
+	webhook := models.ReleaseWebhook{
+		Name:   "policy",
+		URL:    server.URL,
+		Secret: "secret",
+	}
+
+	diagnostics, err := Call(context.Background(), webhook, models.ReleaseWebhookRequest{
+		ProjectID:     "prj_1",
+		ApplicationID: "app_1",
+		Config: map[string]models.Service{
+			"web": models.Service{
+				Image: "nginx",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Empty(t, diagnostics)
+	require.Equal(t, "app_1", received.ApplicationID)
+	require.NotEmpty(t, signature)
+
+	diagnostics, err = Call(context.Background(), webhook, models.ReleaseWebhookRequest{
+		Config: map[string]models.Service{
+			"web": models.Service{
+				Image:      "nginx",
+				Privileged: true,
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []models.ReleaseDiagnostic{
+		{
+			Service:  "web",
+			Key:      "privileged",
+			Severity: models.DiagnosticSeverityError,
+			Message:  "privileged containers aren't allowed",
+		},
+	}, diagnostics)
+}
+
+func TestCallFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := Call(context.Background(), models.ReleaseWebhook{
+		Name: "policy",
+		URL:  server.URL,
+	}, models.ReleaseWebhookRequest{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unavailable")
+}
+
+func TestValidate(t *testing.T) {
+	valid := func(webhook models.ReleaseWebhook) error {
+		return Validate(models.ReleaseWebhooksConfig{
+			Webhooks: []models.ReleaseWebhook{webhook},
+		})
+	}
+	require.NoError(t, valid(models.ReleaseWebhook{Name: "policy", URL: "https://policy.example.com/check"}))
+	require.Equal(t, ErrMissingName, valid(models.ReleaseWebhook{URL: "https://policy.example.com"}))
+	require.Equal(t, ErrInvalidURL, valid(models.ReleaseWebhook{Name: "policy", URL: "ftp://policy.example.com"}))
+	require.Equal(t, ErrInvalidURL, valid(models.ReleaseWebhook{Name: "policy", URL: "policy"}))
+}
@@ -0,0 +1,185 @@
+package rollout
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+var (
+	ErrNoRolloutSteps           = errors.New("rollouts need at least one step")
+	ErrInvalidRolloutPercentage = errors.New("rollout step percentages must be between 1 and 100")
+	ErrInvalidSoakPeriod        = errors.New("soak period can't be negative")
+	ErrInvalidFailurePercentage = errors.New("max failure percentage must be between 0 and 100")
+)
+
+// ValidateReleaseRollout checks a release rollout's steps and limits.
+func ValidateReleaseRollout(steps []models.RolloutStep, soakPeriod, maxFailurePercentage int) error {
+	if len(steps) == 0 {
+		return ErrNoRolloutSteps
+	}
+	for _, step := range steps {
+		if step.Percentage < 1 || step.Percentage > 100 {
+			return ErrInvalidRolloutPercentage
+		}
+		if step.Query != nil {
+			if err := query.ValidateQuery(*step.Query); err != nil {
+				return err
+			}
+		}
+	}
+	if soakPeriod < 0 {
+		return ErrInvalidSoakPeriod
+	}
+	if maxFailurePercentage < 0 || maxFailurePercentage > 100 {
+		return ErrInvalidFailurePercentage
+	}
+	return nil
+}
+
+// IsDeviceInReleaseRollout returns whether any of a release rollout's steps
+// up to the current one has reached a device. Devices are placed in steps
+// by the same hash of their ID as agent rollouts.
+func IsDeviceInReleaseRollout(device models.Device, rollout models.Rollout) (bool, error) {
+	for i, step := range rollout.Steps {
+		if i > rollout.Step {
+			break
+		}
+
+		if step.Query != nil {
+			matches, err := query.DeviceMatchesQuery(device, *step.Query)
+			if err != nil {
+				return false, err
+			}
+			if !matches {
+				continue
+			}
+		}
+
+		if bucket(device.ID) < step.Percentage {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DesiredRelease returns the release a device that's scheduled onto
+// releaseID should run, given the latest rollout of the application. It's
+// empty if the device shouldn't run the application yet, which happens when
+// the rollout has no previous release to fall back to.
+func DesiredRelease(device models.Device, releaseID string, rollout *models.Rollout) (string, error) {
+	if rollout == nil || rollout.ReleaseID != releaseID {
+		return releaseID, nil
+	}
+
+	switch rollout.Status {
+	case models.RolloutStatusCompleted:
+		return releaseID, nil
+	case models.RolloutStatusInProgress:
+		inRollout, err := IsDeviceInReleaseRollout(device, *rollout)
+		if err != nil {
+			return "", err
+		}
+		if inRollout {
+			return releaseID, nil
+		}
+	}
+	return rollout.PreviousReleaseID, nil
+}
+
+// Decision is what a release rollout should do next.
+type Decision int
+
+const (
+	Wait Decision = iota
+	Progress
+	Halt
+)
+
+// CheckReleaseRollout decides whether a release rollout moves on to its
+// next step, halts or keeps waiting. Devices are the ones scheduled onto the
+// rollout's release and serviceStatuses are their service statuses for the
+// application, keyed by device ID. A device in the rollout is healthy once
+// every one of its services reports running the release, and failing if it's
+// offline or hasn't got there within the update grace period. Only devices
+// that have been seen since the step started are counted, so devices that
+// were already offline don't count against it. Devices in held, keyed by
+// ID, are waiting for a maintenance window and count as pending until they
+// run the release.
+func CheckReleaseRollout(
+	devices []models.Device,
+	serviceStatuses map[string][]models.DeviceServiceStatus,
+	services []string,
+	held map[string]bool,
+	rollout models.Rollout,
+	now time.Time,
+) (Decision, string, error) {
+	elapsed := now.Sub(rollout.StepStartedAt)
+
+	var rolloutDevices, failures, pending int
+	for _, device := range devices {
+		if device.LastSeenAt.Before(rollout.StepStartedAt) || device.PendingApproval {
+			continue
+		}
+
+		inRollout, err := IsDeviceInReleaseRollout(device, rollout)
+		if err != nil {
+			return Wait, "", err
+		}
+		if !inRollout {
+			continue
+		}
+		rolloutDevices++
+
+		updated := runsRelease(serviceStatuses[device.ID], services, rollout.ReleaseID)
+		switch {
+		case device.Status != models.DeviceStatusOnline:
+			failures++
+		case updated:
+		case elapsed > UpdateGracePeriod && !held[device.ID]:
+			failures++
+		default:
+			pending++
+		}
+	}
+
+	maxFailurePercentage := rollout.MaxFailurePercentage
+	if maxFailurePercentage == 0 {
+		maxFailurePercentage = models.DefaultMaxFailurePercentage
+	}
+
+	if rolloutDevices != 0 {
+		failurePercentage := 100 * float64(failures) / float64(rolloutDevices)
+		if failurePercentage > float64(maxFailurePercentage) {
+			return Halt, fmt.Sprintf(
+				"%d of %d devices in step %d of the rollout are failing (%.0f%%)",
+				failures, rolloutDevices, rollout.Step+1, failurePercentage,
+			), nil
+		}
+	}
+
+	if pending != 0 || elapsed < time.Duration(rollout.SoakPeriod)*time.Second {
+		return Wait, "", nil
+	}
+	return Progress, "", nil
+}
+
+func runsRelease(serviceStatuses []models.DeviceServiceStatus, services []string, releaseID string) bool {
+	for _, service := range services {
+		running := false
+		for _, serviceStatus := range serviceStatuses {
+			if serviceStatus.Service == service && serviceStatus.CurrentReleaseID == releaseID {
+				running = true
+				break
+			}
+		}
+		if !running {
+			return false
+		}
+	}
+	return true
+}
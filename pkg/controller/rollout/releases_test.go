@@ -0,0 +1,201 @@
+package rollout
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDesiredRelease(t *testing.T) {
+	rollout := models.Rollout{
+		ReleaseID:         "rel_2",
+		PreviousReleaseID: "rel_1",
+		Steps: []models.RolloutStep{
+			models.RolloutStep{
+				Percentage: 100,
+				Query: &models.Query{
+					models.Filter{
+						models.Condition{
+							Type: models.LabelValueCondition,
+							Params: map[string]interface{}{
+								"key":      "canary",
+								"operator": string(models.OperatorIs),
+								"value":    "true",
+							},
+						},
+					},
+				},
+			},
+			models.RolloutStep{
+				Percentage: 20,
+			},
+			models.RolloutStep{
+				Percentage: 100,
+			},
+		},
+		Status: models.RolloutStatusInProgress,
+	}
+
+	ds := devices(1000)
+	ds[0].Labels["canary"] = "true"
+
+	countOnRelease := func() int {
+		var count int
+		for _, device := range ds {
+			releaseID, err := DesiredRelease(device, "rel_2", &rollout)
+			require.NoError(t, err)
+			if releaseID == "rel_2" {
+				count++
+			} else {
+				require.Equal(t, "rel_1", releaseID)
+			}
+		}
+		return count
+	}
+
+	// Only the canary gets the release in the first step
+	require.Equal(t, 1, countOnRelease())
+
+	rollout.Step = 1
+	require.InDelta(t, 200, countOnRelease(), 50)
+	releaseID, err := DesiredRelease(ds[0], "rel_2", &rollout)
+	require.NoError(t, err)
+	require.Equal(t, "rel_2", releaseID)
+
+	rollout.Step = 2
+	require.Equal(t, 1000, countOnRelease())
+
+	// Halted rollouts move every device back
+	rollout.Status = models.RolloutStatusHalted
+	require.Equal(t, 0, countOnRelease())
+
+	rollout.Status = models.RolloutStatusCompleted
+	rollout.Step = 0
+	require.Equal(t, 1000, countOnRelease())
+
+	// Rollouts of other releases don't matter
+	rollout.Status = models.RolloutStatusHalted
+	releaseID, err = DesiredRelease(ds[1], "rel_3", &rollout)
+	require.NoError(t, err)
+	require.Equal(t, "rel_3", releaseID)
+
+	releaseID, err = DesiredRelease(ds[1], "rel_3", nil)
+	require.NoError(t, err)
+	require.Equal(t, "rel_3", releaseID)
+}
+
+func TestCheckReleaseRollout(t *testing.T) {
+	now := time.Now()
+	rollout := models.Rollout{
+		ReleaseID:         "rel_2",
+		PreviousReleaseID: "rel_1",
+		Steps: []models.RolloutStep{
+			models.RolloutStep{
+				Percentage: 50,
+			},
+		},
+		SoakPeriod:    3600,
+		StepStartedAt: now.Add(-time.Minute),
+		Status:        models.RolloutStatusInProgress,
+	}
+	services := []string{"web", "worker"}
+
+	ds := devices(100)
+	serviceStatuses := make(map[string][]models.DeviceServiceStatus)
+	var inRollout []string
+	for i := range ds {
+		ds[i].LastSeenAt = now
+		ds[i].Status = models.DeviceStatusOnline
+
+		releaseID, err := DesiredRelease(ds[i], "rel_2", &rollout)
+		require.NoError(t, err)
+		if releaseID == "rel_2" {
+			inRollout = append(inRollout, ds[i].ID)
+		}
+		for _, service := range services {
+			serviceStatuses[ds[i].ID] = append(serviceStatuses[ds[i].ID], models.DeviceServiceStatus{
+				Service:          service,
+				CurrentReleaseID: releaseID,
+			})
+		}
+	}
+
+	decision, _, err := CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Wait, decision)
+
+	// Once the soak period is over the rollout moves on
+	rollout.StepStartedAt = now.Add(-2 * time.Hour)
+	decision, _, err = CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Progress, decision)
+
+	// Devices that don't get one of the services running count as failing
+	// after the grace period
+	for _, id := range inRollout[:10] {
+		serviceStatuses[id][1].CurrentReleaseID = "rel_1"
+	}
+	decision, reason, err := CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Halt, decision)
+	require.NotEqual(t, "", reason)
+
+	// unless they're waiting for a maintenance window
+	held := make(map[string]bool)
+	for _, id := range inRollout[:10] {
+		held[id] = true
+	}
+	decision, _, err = CheckReleaseRollout(ds, serviceStatuses, services, held, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Wait, decision)
+
+	// but not before it
+	rollout.StepStartedAt = now.Add(-time.Minute)
+	rollout.SoakPeriod = 0
+	decision, _, err = CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Wait, decision)
+
+	rollout.MaxFailurePercentage = 50
+	rollout.StepStartedAt = now.Add(-time.Hour)
+	decision, _, err = CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Progress, decision)
+
+	// Devices going offline count right away
+	rollout.MaxFailurePercentage = 0
+	rollout.StepStartedAt = now.Add(-time.Minute)
+	for _, id := range inRollout[:10] {
+		serviceStatuses[id][1].CurrentReleaseID = "rel_2"
+	}
+	var offline int
+	for i := range ds {
+		if releaseID, _ := DesiredRelease(ds[i], "rel_2", &rollout); releaseID == "rel_2" && offline < 10 {
+			ds[i].Status = models.DeviceStatusOffline
+			offline++
+		}
+	}
+	decision, _, err = CheckReleaseRollout(ds, serviceStatuses, services, nil, rollout, now)
+	require.NoError(t, err)
+	require.Equal(t, Halt, decision)
+}
+
+func TestValidateReleaseRollout(t *testing.T) {
+	steps := []models.RolloutStep{
+		models.RolloutStep{
+			Percentage: 10,
+		},
+		models.RolloutStep{
+			Percentage: 100,
+		},
+	}
+	require.NoError(t, ValidateReleaseRollout(steps, 600, 0))
+	require.Equal(t, ErrNoRolloutSteps, ValidateReleaseRollout(nil, 600, 0))
+	require.Equal(t, ErrInvalidSoakPeriod, ValidateReleaseRollout(steps, -1, 0))
+	require.Equal(t, ErrInvalidFailurePercentage, ValidateReleaseRollout(steps, 0, 101))
+
+	steps[0].Percentage = 0
+	require.Equal(t, ErrInvalidRolloutPercentage, ValidateReleaseRollout(steps, 600, 0))
+}
@@ -0,0 +1,163 @@
+package rollout
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"golang.org/x/crypto/ed25519"
+)
+
+// UpdateGracePeriod is how long devices in a rollout have to start running
+// the new version before they count as failing.
+const UpdateGracePeriod = 15 * time.Minute
+
+// IsDeviceInRollout returns whether a device is given the rollout's agent
+// version. Devices are placed in the rollout by a hash of their ID, so
+// raising the percentage only ever adds devices to it. Devices pinned to an
+// agent version are never in the rollout.
+func IsDeviceInRollout(device models.Device, config models.AgentRolloutConfig) (bool, error) {
+	if config.Version == "" || config.Halted || config.Percentage <= 0 {
+		return false, nil
+	}
+	if device.DesiredAgentVersion != "" {
+		return false, nil
+	}
+
+	if config.Query != nil {
+		matches, err := query.DeviceMatchesQuery(device, *config.Query)
+		if err != nil {
+			return false, err
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+
+	return bucket(device.ID) < config.Percentage, nil
+}
+
+func bucket(deviceID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(deviceID))
+	return int(h.Sum32() % 100)
+}
+
+// DesiredAgent returns the agent version a device should run and its
+// encoded spec.
+func DesiredAgent(device models.Device, config models.AgentRolloutConfig) (version, spec string, err error) {
+	inRollout, err := IsDeviceInRollout(device, config)
+	if err != nil {
+		return "", "", err
+	}
+	if inRollout {
+		specBytes, err := json.Marshal(config.Spec)
+		if err != nil {
+			return "", "", err
+		}
+		return config.Version, string(specBytes), nil
+	}
+	return device.DesiredAgentVersion, device.DesiredAgentSpec, nil
+}
+
+// ValidateAgentSpec checks that an agent spec's digests and signatures are
+// well formed.
+func ValidateAgentSpec(spec models.AgentSpec) error {
+	for platform, digest := range spec.Digests {
+		if err := validatePlatform(platform); err != nil {
+			return err
+		}
+		if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("digest for %s isn't a hex encoded SHA-256 digest", platform)
+		}
+	}
+	for platform, signature := range spec.Signatures {
+		if err := validatePlatform(platform); err != nil {
+			return err
+		}
+		if _, ok := spec.Digests[platform]; !ok {
+			return fmt.Errorf("signature for %s has no digest", platform)
+		}
+		if b, err := base64.StdEncoding.DecodeString(signature); err != nil || len(b) != ed25519.SignatureSize {
+			return fmt.Errorf("signature for %s isn't a base64 encoded Ed25519 signature", platform)
+		}
+	}
+	return nil
+}
+
+func validatePlatform(platform string) error {
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid platform '%s', expected os/arch", platform)
+	}
+	return nil
+}
+
+// CheckHealth returns a reason to halt the rollout if the share of failing
+// devices in it is too much higher than in the rest of the project. Only
+// devices that have been seen since the rollout started are counted, so
+// devices that were already offline don't count against it.
+func CheckHealth(devices []models.Device, config models.AgentRolloutConfig, now time.Time) (string, error) {
+	var rolloutDevices, rolloutFailures, otherDevices, otherFailures int
+	for _, device := range devices {
+		// Devices pending approval don't get bundles, so they can't update
+		if device.LastSeenAt.Before(config.StartedAt) || device.PendingApproval {
+			continue
+		}
+
+		inRollout, err := IsDeviceInRollout(device, config)
+		if err != nil {
+			return "", err
+		}
+
+		offline := device.Status != models.DeviceStatusOnline
+		if inRollout {
+			rolloutDevices++
+			notUpdated := now.Sub(config.StartedAt) > UpdateGracePeriod &&
+				device.Info.AgentVersion != config.Version
+			// Devices that rolled the update back count right away rather
+			// than after the grace period
+			rolledBack := device.Info.LastFailedAgentUpdate.Version == config.Version &&
+				device.Info.LastFailedAgentUpdate.Time.After(config.StartedAt)
+			if offline || notUpdated || rolledBack {
+				rolloutFailures++
+			}
+		} else {
+			otherDevices++
+			if offline {
+				otherFailures++
+			}
+		}
+	}
+
+	if rolloutDevices == 0 {
+		return "", nil
+	}
+
+	rolloutErrorRate := 100 * float64(rolloutFailures) / float64(rolloutDevices)
+	var otherErrorRate float64
+	if otherDevices != 0 {
+		otherErrorRate = 100 * float64(otherFailures) / float64(otherDevices)
+	}
+
+	maxErrorRateIncrease := config.MaxErrorRateIncrease
+	if maxErrorRateIncrease == 0 {
+		maxErrorRateIncrease = models.DefaultMaxErrorRateIncrease
+	}
+
+	if rolloutErrorRate-otherErrorRate > float64(maxErrorRateIncrease) {
+		return fmt.Sprintf(
+			"%d of %d devices in the rollout are failing (%.0f%%), compared to %.0f%% of other devices",
+			rolloutFailures, rolloutDevices, rolloutErrorRate, otherErrorRate,
+		), nil
+	}
+
+	return "", nil
+}
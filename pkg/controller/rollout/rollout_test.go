@@ -0,0 +1,158 @@
+package rollout
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func devices(n int) []models.Device {
+	var ret []models.Device
+	for i := 0; i < n; i++ {
+		ret = append(ret, models.Device{
+			ID:     fmt.Sprintf("dev_%d", i),
+			Labels: map[string]string{},
+		})
+	}
+	return ret
+}
+
+func TestIsDeviceInRollout(t *testing.T) {
+	config := models.AgentRolloutConfig{
+		Version:    "1.1.0",
+		Percentage: 20,
+	}
+
+	var inRollout []string
+	for _, device := range devices(1000) {
+		ok, err := IsDeviceInRollout(device, config)
+		require.NoError(t, err)
+		if ok {
+			inRollout = append(inRollout, device.ID)
+		}
+	}
+	require.InDelta(t, 200, len(inRollout), 50)
+
+	// Raising the percentage keeps the devices already in the rollout
+	config.Percentage = 50
+	for _, device := range devices(1000) {
+		ok, err := IsDeviceInRollout(device, config)
+		require.NoError(t, err)
+		for _, id := range inRollout {
+			if id == device.ID {
+				require.True(t, ok)
+			}
+		}
+	}
+
+	config.Percentage = 100
+	config.Query = &models.Query{
+		models.Filter{
+			models.Condition{
+				Type: models.LabelValueCondition,
+				Params: map[string]interface{}{
+					"key":      "canary",
+					"operator": string(models.OperatorIs),
+					"value":    "true",
+				},
+			},
+		},
+	}
+	device := devices(1)[0]
+	ok, err := IsDeviceInRollout(device, config)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	device.Labels["canary"] = "true"
+	ok, err = IsDeviceInRollout(device, config)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	version, _, err := DesiredAgent(device, config)
+	require.NoError(t, err)
+	require.Equal(t, "1.1.0", version)
+
+	// Pinned devices keep their version
+	device.DesiredAgentVersion = "1.0.0"
+	version, _, err = DesiredAgent(device, config)
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", version)
+
+	device.DesiredAgentVersion = ""
+	config.Halted = true
+	version, _, err = DesiredAgent(device, config)
+	require.NoError(t, err)
+	require.Equal(t, "", version)
+}
+
+func TestCheckHealth(t *testing.T) {
+	now := time.Now()
+	config := models.AgentRolloutConfig{
+		Version:    "1.1.0",
+		Percentage: 50,
+		StartedAt:  now.Add(-time.Hour),
+	}
+
+	ds := devices(100)
+	for i := range ds {
+		ds[i].LastSeenAt = now
+		ds[i].Status = models.DeviceStatusOnline
+		ds[i].Info.AgentVersion = "1.0.0"
+		if ok, _ := IsDeviceInRollout(ds[i], config); ok {
+			ds[i].Info.AgentVersion = "1.1.0"
+		}
+	}
+
+	reason, err := CheckHealth(ds, config, now)
+	require.NoError(t, err)
+	require.Equal(t, "", reason)
+
+	// Devices in the rollout that went back to the previous version are
+	// failing
+	var failed int
+	for i := range ds {
+		if ds[i].Info.AgentVersion == "1.1.0" && failed < 10 {
+			ds[i].Info.AgentVersion = "1.0.0"
+			failed++
+		}
+	}
+
+	reason, err = CheckHealth(ds, config, now)
+	require.NoError(t, err)
+	require.NotEqual(t, "", reason)
+
+	// Before the grace period is over devices may not have updated yet
+	config.StartedAt = now.Add(-time.Minute)
+	reason, err = CheckHealth(ds, config, now)
+	require.NoError(t, err)
+	require.Equal(t, "", reason)
+
+	// unless they report that they rolled the update back
+	for i := range ds {
+		if ds[i].Info.AgentVersion == "1.0.0" {
+			if ok, _ := IsDeviceInRollout(ds[i], config); ok {
+				ds[i].Info.LastFailedAgentUpdate = models.AgentUpdateFailure{
+					Version: "1.1.0",
+					Reason:  "agent didn't become healthy",
+					Time:    now,
+				}
+			}
+		}
+	}
+	reason, err = CheckHealth(ds, config, now)
+	require.NoError(t, err)
+	require.NotEqual(t, "", reason)
+
+	// Devices pending approval can't update, so they're left out
+	for i := range ds {
+		if ds[i].Info.LastFailedAgentUpdate.Version != "" {
+			ds[i].PendingApproval = true
+		}
+	}
+	reason, err = CheckHealth(ds, config, now)
+	require.NoError(t, err)
+	require.Equal(t, "", reason)
+}
@@ -0,0 +1,88 @@
+package agentrollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/rollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Runner halts agent rollouts whose devices are failing more than the rest
+// of their project.
+type Runner struct {
+	projects            store.Projects
+	devices             store.Devices
+	deviceGroups        store.DeviceGroups
+	agentRolloutConfigs store.AgentRolloutConfigs
+	st                  *statsd.Client
+}
+
+func NewRunner(projects store.Projects, devices store.Devices, deviceGroups store.DeviceGroups, agentRolloutConfigs store.AgentRolloutConfigs, st *statsd.Client) *Runner {
+	return &Runner{
+		projects:            projects,
+		devices:             devices,
+		deviceGroups:        deviceGroups,
+		agentRolloutConfigs: agentRolloutConfigs,
+		st:                  st,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	projects, err := r.projects.ListProjects(ctx)
+	if err != nil {
+		log.WithError(err).Error("list projects")
+		return
+	}
+
+	for _, project := range projects {
+		if err := r.doForProject(ctx, project); err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("check agent rollout")
+		}
+	}
+}
+
+func (r *Runner) doForProject(ctx context.Context, project models.Project) error {
+	config, err := r.agentRolloutConfigs.GetAgentRolloutConfig(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+	if config.Version == "" || config.Halted || config.Percentage <= 0 {
+		return nil
+	}
+
+	devices, err := r.devices.ListDevices(ctx, project.ID, "")
+	if err != nil {
+		return err
+	}
+
+	// Rollouts can be limited to device groups
+	if _, err := devicegroups.Load(ctx, r.deviceGroups, project.ID, devices); err != nil {
+		return err
+	}
+
+	haltReason, err := rollout.CheckHealth(devices, *config, time.Now())
+	if err != nil {
+		return err
+	}
+	if haltReason == "" {
+		return nil
+	}
+
+	log.WithField("project_id", project.ID).
+		WithField("version", config.Version).
+		WithField("reason", haltReason).
+		Info("halting agent rollout")
+	r.st.Incr("runner.agent_rollout.halt", []string{fmt.Sprintf("project_id:%s", project.ID)}, 1)
+
+	config.Halted = true
+	config.HaltReason = haltReason
+	return r.agentRolloutConfigs.SetAgentRolloutConfig(ctx, project.ID, *config)
+}
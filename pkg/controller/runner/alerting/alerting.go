@@ -0,0 +1,245 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/alerting"
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/notifications"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Runner evaluates each project's alert rules, records alerts as they fire
+// and resolve, and notifies the rules' channels unless the alerts are
+// silenced.
+type Runner struct {
+	projects              store.Projects
+	devices               store.Devices
+	deviceGroups          store.DeviceGroups
+	applications          store.Applications
+	deviceServiceStatuses store.DeviceServiceStatuses
+	alertsConfigs         store.AlertsConfigs
+	alerts                store.Alerts
+	alertSilences         store.AlertSilences
+	notifications         *notifications.Sender
+	st                    *statsd.Client
+}
+
+func NewRunner(projects store.Projects, devices store.Devices, deviceGroups store.DeviceGroups, applications store.Applications, deviceServiceStatuses store.DeviceServiceStatuses, alertsConfigs store.AlertsConfigs, alerts store.Alerts, alertSilences store.AlertSilences, notifications *notifications.Sender, st *statsd.Client) *Runner {
+	return &Runner{
+		projects:              projects,
+		devices:               devices,
+		deviceGroups:          deviceGroups,
+		applications:          applications,
+		deviceServiceStatuses: deviceServiceStatuses,
+		alertsConfigs:         alertsConfigs,
+		alerts:                alerts,
+		alertSilences:         alertSilences,
+		notifications:         notifications,
+		st:                    st,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	projects, err := r.projects.ListProjects(ctx)
+	if err != nil {
+		log.WithError(err).Error("list projects")
+		return
+	}
+
+	now := time.Now()
+	for _, project := range projects {
+		if err := r.doForProject(ctx, project, now); err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("evaluate alert rules")
+		}
+	}
+
+	if err := r.alerts.DeleteAlertsResolvedBefore(ctx, now.Add(-alerting.Retention)); err != nil {
+		log.WithError(err).Error("delete expired alerts")
+	}
+	if err := r.alertSilences.DeleteAlertSilencesBefore(ctx, now); err != nil {
+		log.WithError(err).Error("delete expired alert silences")
+	}
+}
+
+func alertKey(rule, deviceID string) string {
+	return rule + "/" + deviceID
+}
+
+func (r *Runner) doForProject(ctx context.Context, project models.Project, now time.Time) error {
+	config, err := r.alertsConfigs.GetAlertsConfig(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+
+	firingAlerts, err := r.alerts.ListFiringAlerts(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(config.Rules) == 0 && len(firingAlerts) == 0 {
+		return nil
+	}
+
+	devices, err := r.devices.ListDevices(ctx, project.ID, "")
+	if err != nil {
+		return err
+	}
+
+	// Rules can be limited to device groups
+	if _, err := devicegroups.Load(ctx, r.deviceGroups, project.ID, devices); err != nil {
+		return err
+	}
+
+	devicesByID := make(map[string]*models.Device, len(devices))
+	for i := range devices {
+		devicesByID[devices[i].ID] = &devices[i]
+	}
+
+	silences, err := r.alertSilences.ListAlertSilences(ctx, project.ID, now)
+	if err != nil {
+		return err
+	}
+
+	rules := make(map[string]models.AlertRule, len(config.Rules))
+	for _, rule := range config.Rules {
+		rules[rule.Name] = rule
+	}
+	channels := make(map[string]models.AlertChannel, len(config.Channels))
+	for _, channel := range config.Channels {
+		channels[channel.Name] = channel
+	}
+
+	existing := make(map[string]models.Alert, len(firingAlerts))
+	for _, alert := range firingAlerts {
+		existing[alertKey(alert.Rule, alert.DeviceID)] = alert
+	}
+
+	serviceRunning := func(deviceID, applicationID, service string) (bool, error) {
+		_, err := r.deviceServiceStatuses.GetDeviceServiceStatus(ctx, project.ID, deviceID, applicationID, service)
+		if err == store.ErrDeviceServiceStatusNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	firing := make(map[string]bool)
+	// Alerts from rules that couldn't be evaluated are left as they are
+	// rather than resolved
+	failedRules := make(map[string]bool)
+
+	for _, rule := range config.Rules {
+		var application *models.Application
+		if rule.Type == models.AlertRuleTypeServiceNotRunning {
+			application, err = r.applications.LookupApplication(ctx, rule.Application, project.ID)
+			if err == store.ErrApplicationNotFound {
+				application = nil
+			} else if err != nil {
+				failedRules[rule.Name] = true
+				log.WithField("project_id", project.ID).
+					WithField("rule", rule.Name).
+					WithError(err).Error("lookup application")
+				continue
+			}
+		}
+
+		firings, err := alerting.Evaluate(rule, devices, application, serviceRunning, now)
+		if err != nil {
+			failedRules[rule.Name] = true
+			log.WithField("project_id", project.ID).
+				WithField("rule", rule.Name).
+				WithError(err).Warn("evaluate alert rule")
+			continue
+		}
+
+		for _, f := range firings {
+			key := alertKey(rule.Name, f.DeviceID)
+			firing[key] = true
+			if _, ok := existing[key]; ok {
+				continue
+			}
+
+			alert, err := r.alerts.CreateAlert(ctx, project.ID, rule.Name, f.DeviceID, f.Summary)
+			if err != nil {
+				return err
+			}
+			r.st.Incr("runner.alerting.fired", []string{fmt.Sprintf("project_id:%s", project.ID)}, 1)
+
+			r.notify(rule, models.AlertNotification{
+				Status: models.AlertStatusFiring,
+				Alert:  *alert,
+				Device: devicesByID[f.DeviceID],
+			}, channels, silences, now)
+		}
+	}
+
+	for key, alert := range existing {
+		if firing[key] || failedRules[alert.Rule] {
+			continue
+		}
+
+		if err := r.alerts.ResolveAlert(ctx, alert.ID, project.ID, now); err != nil {
+			return err
+		}
+		resolvedAt := now
+		alert.ResolvedAt = &resolvedAt
+
+		// Alerts from rules that have been removed resolve quietly
+		rule, ok := rules[alert.Rule]
+		if !ok {
+			continue
+		}
+
+		r.notify(rule, models.AlertNotification{
+			Status: models.AlertStatusResolved,
+			Alert:  alert,
+			Device: devicesByID[alert.DeviceID],
+		}, channels, silences, now)
+	}
+
+	return nil
+}
+
+// notify sends a notification to each of a rule's channels in the
+// background, so slow channels don't hold up evaluating other rules.
+func (r *Runner) notify(rule models.AlertRule, notification models.AlertNotification,
+	channels map[string]models.AlertChannel, silences []models.AlertSilence, now time.Time,
+) {
+	if alerting.Silenced(silences, notification.Alert, now) {
+		return
+	}
+
+	message := alerting.Message(notification)
+
+	for _, name := range rule.Channels {
+		channel, ok := channels[name]
+		if !ok {
+			continue
+		}
+
+		go func(channel models.AlertChannel) {
+			tags := []string{
+				fmt.Sprintf("project_id:%s", notification.Alert.ProjectID),
+				fmt.Sprintf("type:%s", channel.Type),
+			}
+			if err := r.notifications.Send(context.Background(), channel, message); err != nil {
+				log.WithField("project_id", notification.Alert.ProjectID).
+					WithField("alert_id", notification.Alert.ID).
+					WithField("channel", channel.Name).
+					WithError(err).Warn("notify alert channel")
+				r.st.Incr("runner.alerting.notification.failed", tags, 1)
+				return
+			}
+			r.st.Incr("runner.alerting.notification.sent", tags, 1)
+		}(channel)
+	}
+}
@@ -0,0 +1,53 @@
+package auditlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+)
+
+// Runner removes audit log entries once they're older than their project's
+// retention.
+type Runner struct {
+	projects        store.Projects
+	auditLogEntries store.AuditLogEntries
+	auditLogConfigs store.AuditLogConfigs
+}
+
+func NewRunner(projects store.Projects, auditLogEntries store.AuditLogEntries, auditLogConfigs store.AuditLogConfigs) *Runner {
+	return &Runner{
+		projects:        projects,
+		auditLogEntries: auditLogEntries,
+		auditLogConfigs: auditLogConfigs,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	projects, err := r.projects.ListProjects(ctx)
+	if err != nil {
+		log.WithError(err).Error("list projects")
+		return
+	}
+
+	now := time.Now()
+	for _, project := range projects {
+		config, err := r.auditLogConfigs.GetAuditLogConfig(ctx, project.ID)
+		if err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("get audit log config")
+			continue
+		}
+		if config.RetentionDays == 0 {
+			continue
+		}
+
+		before := now.AddDate(0, 0, -config.RetentionDays)
+		if err := r.auditLogEntries.DeleteAuditLogEntriesBefore(ctx, project.ID, before); err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("delete expired audit log entries")
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/apex/log"
 
 	"github.com/deviceplane/deviceplane/pkg/controller/connman"
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
 	"github.com/deviceplane/deviceplane/pkg/controller/store"
 	"github.com/deviceplane/deviceplane/pkg/metrics/datadog"
 	"github.com/deviceplane/deviceplane/pkg/metrics/datadog/translation"
@@ -24,6 +25,7 @@ type Runner struct {
 	projects      store.Projects
 	applications  store.Applications
 	devices       store.Devices
+	deviceGroups  store.DeviceGroups
 	releases      store.Releases
 	metricConfigs store.MetricConfigs
 	st            *statsd.Client
@@ -31,11 +33,12 @@ type Runner struct {
 	statsCache    *translation.StatsCache
 }
 
-func NewRunner(projects store.Projects, applications store.Applications, releases store.Releases, devices store.Devices, metricConfigs store.MetricConfigs, st *statsd.Client, connman *connman.ConnectionManager) *Runner {
+func NewRunner(projects store.Projects, applications store.Applications, releases store.Releases, devices store.Devices, deviceGroups store.DeviceGroups, metricConfigs store.MetricConfigs, st *statsd.Client, connman *connman.ConnectionManager) *Runner {
 	return &Runner{
 		projects:      projects,
 		applications:  applications,
 		devices:       devices,
+		deviceGroups:  deviceGroups,
 		releases:      releases,
 		metricConfigs: metricConfigs,
 		st:            st,
@@ -53,27 +56,47 @@ func (r *Runner) Do(ctx context.Context) {
 
 	var wg sync.WaitGroup
 	for _, project := range projects {
-		if project.DatadogAPIKey == nil {
+		targets, err := r.metricConfigs.GetMetricsExportTargets(ctx, project.ID)
+		if err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("getting metrics export targets")
+			continue
+		}
+		if project.DatadogAPIKey != nil {
+			targets = append(targets, models.MetricsExportTarget{
+				Type:  models.MetricsExportTargetTypeDatadog,
+				Token: *project.DatadogAPIKey,
+			})
+		}
+		if len(targets) == 0 {
 			continue
 		}
 
 		wg.Add(1)
-		go func(project models.Project) {
-			r.doForProject(ctx, project)
+		go func(project models.Project, targets []models.MetricsExportTarget) {
+			r.doForProject(ctx, project, targets)
 			wg.Done()
-		}(project)
+		}(project, targets)
 	}
 
 	wg.Wait()
 }
 
-func (r *Runner) doForProject(ctx context.Context, project models.Project) {
+func (r *Runner) doForProject(ctx context.Context, project models.Project, targets []models.MetricsExportTarget) {
 	devices, err := r.devices.ListDevices(ctx, project.ID, "")
 	if err != nil {
 		log.WithError(err).Error("list devices")
 		return
 	}
 
+	// Exposed metrics can be limited to device groups
+	deviceGroups, err := devicegroups.Load(ctx, r.deviceGroups, project.ID, devices)
+	if err != nil {
+		log.WithField("project_id", project.ID).
+			WithError(err).Error("load device groups")
+		return
+	}
+
 	// Get metric configs
 	projectMetricsConfig, err := r.metricConfigs.GetProjectMetricsConfig(ctx, project.ID)
 	if err != nil {
@@ -129,7 +152,7 @@ func (r *Runner) doForProject(ctx context.Context, project models.Project) {
 
 			if len(projectMetricsConfig.ExposedMetrics) != 0 {
 				projectMetrics := r.getProjectMetrics(ctx, &project, &device)
-				filteredProjectMetrics := FilterMetrics(projectMetrics, &project, &device, models.ProjectMetricsConfigKey, projectMetricsConfig.ExposedMetrics, nil, nil)
+				filteredProjectMetrics := FilterMetrics(projectMetrics, &project, &device, models.ProjectMetricsConfigKey, projectMetricsConfig.ExposedMetrics, deviceGroups, nil, nil)
 				if len(filteredProjectMetrics) != 0 {
 					lock.Lock()
 					req.Series = append(req.Series, filteredProjectMetrics...)
@@ -150,8 +173,10 @@ func (r *Runner) doForProject(ctx context.Context, project models.Project) {
 			defer deviceConn.Close()
 
 			if len(deviceMetricsConfig.ExposedMetrics) != 0 {
-				deviceMetrics := r.getDeviceMetrics(ctx, deviceConn, &project, &device)
-				filteredDeviceMetrics := FilterMetrics(deviceMetrics, &project, &device, models.DeviceMetricsConfigKey, deviceMetricsConfig.ExposedMetrics, nil, nil)
+				deviceMetrics := r.getBufferedDeviceMetrics(ctx, deviceConn, &project, &device)
+				deviceMetrics = append(deviceMetrics, r.getDeviceMetrics(ctx, deviceConn, &project, &device)...)
+				deviceMetrics = append(deviceMetrics, r.getIngestedMetrics(ctx, deviceConn, &project, &device)...)
+				filteredDeviceMetrics := FilterMetrics(deviceMetrics, &project, &device, models.DeviceMetricsConfigKey, deviceMetricsConfig.ExposedMetrics, deviceGroups, nil, nil)
 				if len(filteredDeviceMetrics) != 0 {
 					lock.Lock()
 					req.Series = append(req.Series, filteredDeviceMetrics...)
@@ -160,7 +185,7 @@ func (r *Runner) doForProject(ctx context.Context, project models.Project) {
 			}
 
 			if len(serviceMetricsConfigs) != 0 {
-				serviceMetrics := r.getServiceMetrics(ctx, deviceConn, &project, &device, apps, appsByID, getReleaseByID, serviceMetricsConfigs)
+				serviceMetrics := r.getServiceMetrics(ctx, deviceConn, &project, &device, apps, appsByID, getReleaseByID, serviceMetricsConfigs, deviceGroups)
 				if len(serviceMetrics) != 0 {
 					lock.Lock()
 					req.Series = append(req.Series, serviceMetrics...)
@@ -176,9 +201,15 @@ func (r *Runner) doForProject(ctx context.Context, project models.Project) {
 		return
 	}
 
-	client := datadog.NewClient(*project.DatadogAPIKey)
-	if err := client.PostMetrics(ctx, req); err != nil {
-		log.WithError(err).Error("post metrics")
-		return
+	for _, target := range targets {
+		exportTags := append([]string{"type:" + string(target.Type)}, utils.InternalTags(project.Name)...)
+		if err := export(ctx, target, req.Series); err != nil {
+			log.WithField("project_id", project.ID).
+				WithField("type", target.Type).
+				WithError(err).Error("export metrics")
+			r.st.Incr("runner.datadog.export", append(exportTags, "status:failure"), 1)
+			continue
+		}
+		r.st.Incr("runner.datadog.export", append(exportTags, "status:success"), 1)
 	}
 }
@@ -2,7 +2,10 @@ package datadog
 
 import (
 	"context"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
 
 	"github.com/apex/log"
 
@@ -42,3 +45,84 @@ func (r *Runner) getDeviceMetrics(
 
 	return metrics
 }
+
+// getBufferedDeviceMetrics gets the host metrics that the device collected
+// while it was offline, with the times they were collected at. They're
+// removed from the device once they've been converted.
+func (r *Runner) getBufferedDeviceMetrics(
+	ctx context.Context,
+	deviceConn net.Conn,
+	project *models.Project,
+	device *models.Device,
+) []datadog.Metric {
+	bufferedMetricsResp, err := client.GetBufferedDeviceMetrics(ctx, deviceConn)
+	if err == nil && bufferedMetricsResp.StatusCode == http.StatusNotFound {
+		// Agents from before the buffer was added. The body is drained so
+		// the connection can be used for the other requests.
+		io.Copy(ioutil.Discard, bufferedMetricsResp.Body)
+		return nil
+	}
+	if err != nil || bufferedMetricsResp.StatusCode != 200 {
+		r.st.Incr("runner.datadog.buffered_device_metrics_pull", append([]string{"status:failure"}, utils.InternalTags(project.Name)...), 1)
+		return nil
+	}
+	r.st.Incr("runner.datadog.buffered_device_metrics_pull", append([]string{"status:success"}, utils.InternalTags(project.Name)...), 1)
+
+	through := bufferedMetricsResp.Header.Get(models.BufferedMetricsThroughHeader)
+	if through == "" {
+		return nil
+	}
+
+	metrics, err := translation.ConvertOpenMetricsToDataDog(
+		bufferedMetricsResp.Body,
+		r.statsCache,
+		translation.GetMetricsPrefix(project, device, models.DeviceMetricsConfigKey),
+	)
+	if err != nil {
+		log.WithField("project_id", project.ID).
+			WithField("device_id", device.ID).
+			WithError(err).Error("parsing openmetrics")
+		return nil
+	}
+
+	deleteResp, err := client.DeleteBufferedDeviceMetrics(ctx, deviceConn, through)
+	if err != nil || deleteResp.StatusCode != 200 {
+		log.WithField("project_id", project.ID).
+			WithField("device_id", device.ID).
+			WithError(err).Error("deleting buffered device metrics")
+	} else {
+		deleteResp.Body.Close()
+	}
+
+	return metrics
+}
+
+// getIngestedMetrics gets the metrics that applications sent to the agent
+// over StatsD or OTLP. They're exposed with the device metrics config.
+func (r *Runner) getIngestedMetrics(
+	ctx context.Context,
+	deviceConn net.Conn,
+	project *models.Project,
+	device *models.Device,
+) []datadog.Metric {
+	ingestedMetricsResp, err := client.GetIngestedMetrics(ctx, deviceConn)
+	if err != nil || ingestedMetricsResp.StatusCode != 200 {
+		r.st.Incr("runner.datadog.ingested_metrics_pull", append([]string{"status:failure"}, utils.InternalTags(project.Name)...), 1)
+		return nil
+	}
+	r.st.Incr("runner.datadog.ingested_metrics_pull", append([]string{"status:success"}, utils.InternalTags(project.Name)...), 1)
+
+	metrics, err := translation.ConvertOpenMetricsToDataDog(
+		ingestedMetricsResp.Body,
+		r.statsCache,
+		translation.GetMetricsPrefix(project, device, "ingested"),
+	)
+	if err != nil {
+		log.WithField("project_id", project.ID).
+			WithField("device_id", device.ID).
+			WithError(err).Error("parsing openmetrics")
+		return nil
+	}
+
+	return metrics
+}
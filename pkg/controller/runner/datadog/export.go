@@ -0,0 +1,143 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/metrics/datadog"
+	"github.com/deviceplane/deviceplane/pkg/metrics/influxdb"
+	"github.com/deviceplane/deviceplane/pkg/metrics/remotewrite"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// export sends metrics to a project's export target. Metrics are gathered
+// in Datadog's format, so counters are sent to every target as the change
+// since the last run rather than as a running total.
+func export(ctx context.Context, target models.MetricsExportTarget, series datadog.Series) error {
+	switch target.Type {
+	case models.MetricsExportTargetTypeDatadog:
+		return datadog.NewClient(target.URL, target.Token).PostMetrics(ctx, datadog.PostMetricsRequest{
+			Series: series,
+		})
+	case models.MetricsExportTargetTypePrometheusRemoteWrite:
+		return remotewrite.NewClient(target.URL, target.Token).Write(ctx, toTimeSeries(series))
+	case models.MetricsExportTargetTypeInfluxDB:
+		return influxdb.NewClient(target.URL, target.Token).Write(ctx, toPoints(series))
+	default:
+		return fmt.Errorf("unknown metrics export target type '%s'", target.Type)
+	}
+}
+
+func toTimeSeries(series datadog.Series) []remotewrite.TimeSeries {
+	var timeSeries []remotewrite.TimeSeries
+	for _, metric := range series {
+		labels := map[string]string{
+			"__name__": prometheusName(metric.Metric),
+		}
+		for name, value := range splitTags(metric.Tags) {
+			labels[prometheusName(name)] = value
+		}
+
+		var samples []remotewrite.Sample
+		for _, point := range metric.Points {
+			timestamp, value, ok := parsePoint(point)
+			if !ok {
+				continue
+			}
+			samples = append(samples, remotewrite.Sample{
+				Value:     value,
+				Timestamp: timestamp,
+			})
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		timeSeries = append(timeSeries, remotewrite.TimeSeries{
+			Labels:  labels,
+			Samples: samples,
+		})
+	}
+	return timeSeries
+}
+
+func toPoints(series datadog.Series) []influxdb.Point {
+	var points []influxdb.Point
+	for _, metric := range series {
+		tags := splitTags(metric.Tags)
+		for _, point := range metric.Points {
+			timestamp, value, ok := parsePoint(point)
+			if !ok {
+				continue
+			}
+			points = append(points, influxdb.Point{
+				Measurement: metric.Metric,
+				Tags:        tags,
+				Fields: map[string]float64{
+					"value": value,
+				},
+				Time: timestamp,
+			})
+		}
+	}
+	return points
+}
+
+// splitTags turns Datadog's name:value tags into a map. Tags without a value
+// are kept with an empty value.
+func splitTags(tags []string) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		i := strings.Index(tag, ":")
+		if i < 0 {
+			m[tag] = ""
+			continue
+		}
+		m[tag[:i]] = tag[i+1:]
+	}
+	return m
+}
+
+// prometheusName replaces the characters that aren't allowed in Prometheus
+// metric and label names, such as the dots in Datadog names.
+func prometheusName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == ':':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	if len(name) > 0 && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func parsePoint(point [2]interface{}) (time.Time, float64, bool) {
+	var timestamp time.Time
+	switch t := point[0].(type) {
+	case int64:
+		timestamp = time.Unix(t, 0)
+	case float64:
+		timestamp = time.Unix(int64(t), 0)
+	default:
+		return time.Time{}, 0, false
+	}
+
+	switch v := point[1].(type) {
+	case float32:
+		return timestamp, float64(v), true
+	case float64:
+		return timestamp, v, true
+	case int:
+		return timestamp, float64(v), true
+	case int64:
+		return timestamp, float64(v), true
+	default:
+		return time.Time{}, 0, false
+	}
+}
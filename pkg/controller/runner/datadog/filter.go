@@ -3,6 +3,7 @@ package datadog
 import (
 	"fmt"
 
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
 	"github.com/deviceplane/deviceplane/pkg/metrics/datadog"
 	"github.com/deviceplane/deviceplane/pkg/models"
 )
@@ -15,6 +16,7 @@ func FilterMetrics(
 	device *models.Device,
 	metricType string,
 	exposedMetrics []models.ExposedMetric,
+	deviceGroups []models.DeviceGroup,
 	app *models.Application,
 	serviceName *string,
 ) (filteredMetrics []datadog.Metric) {
@@ -44,6 +46,9 @@ func FilterMetrics(
 		if exposedMetric == nil {
 			continue
 		}
+		if len(exposedMetric.DeviceGroups) != 0 && !devicegroups.InAny(*device, deviceGroups, exposedMetric.DeviceGroups) {
+			continue
+		}
 
 		// Prefix metric name
 		m.Metric = fmt.Sprintf("%s.%s", metricPrefix, m.Metric)
@@ -58,7 +63,7 @@ func FilterMetrics(
 
 		// Optional labels
 		for _, label := range exposedMetric.Labels {
-			labelValue, ok := device.Labels[label]
+			labelValue, ok := device.Label(label)
 			if ok {
 				addTag("deviceplane.labels."+label, labelValue)
 			}
@@ -24,6 +24,7 @@ func (r *Runner) getServiceMetrics(
 	appsByID map[string]*models.Application,
 	getReleaseByID func(releaseID string, appID string) *models.Release,
 	serviceMetricsConfigs []models.ServiceMetricsConfig,
+	deviceGroups []models.DeviceGroup,
 ) (metrics datadog.Series) {
 	scheduledAppReleaseOnDevice := map[string]string{}
 	for _, serviceMetricsConfig := range serviceMetricsConfigs {
@@ -100,7 +101,7 @@ func (r *Runner) getServiceMetrics(
 
 		metrics = append(
 			metrics,
-			FilterMetrics(serviceMetrics, project, device, models.ServiceMetricsConfigKey, serviceMetricsConfig.ExposedMetrics, app, &serviceMetricsConfig.Service)...,
+			FilterMetrics(serviceMetrics, project, device, models.ServiceMetricsConfigKey, serviceMetricsConfig.ExposedMetrics, deviceGroups, app, &serviceMetricsConfig.Service)...,
 		)
 	}
 
@@ -12,7 +12,7 @@ func (r *Runner) getProjectMetrics(
 	project *models.Project,
 	device *models.Device,
 ) datadog.Series {
-	return []datadog.Metric{
+	metrics := []datadog.Metric{
 		datadog.Metric{
 			Metric: "devices",
 			Points: [][2]interface{}{
@@ -22,4 +22,17 @@ func (r *Runner) getProjectMetrics(
 			Tags: []string{"deviceplane.status:" + string(device.Status)},
 		},
 	}
+
+	if len(device.Info.LowDiskFilesystems()) != 0 {
+		metrics = append(metrics, datadog.Metric{
+			Metric: "devices.low_disk",
+			Points: [][2]interface{}{
+				datadog.NewPoint(1),
+			},
+			Type: "count",
+			Tags: []string{"deviceplane.status:" + string(device.Status)},
+		})
+	}
+
+	return metrics
 }
@@ -0,0 +1,121 @@
+package devicestatus
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/connectivity"
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Runner records devices going offline and publishes device.offline events.
+// Devices go offline by not being seen rather than by anything happening, so
+// each run looks for devices that went offline since the last one.
+type Runner struct {
+	projects                 store.Projects
+	devices                  store.Devices
+	heartbeatConfigs         store.HeartbeatConfigs
+	deviceConnectivityEvents store.DeviceConnectivityEvents
+	events                   *events.Publisher
+
+	lastRun time.Time
+}
+
+func NewRunner(projects store.Projects, devices store.Devices, heartbeatConfigs store.HeartbeatConfigs, deviceConnectivityEvents store.DeviceConnectivityEvents, events *events.Publisher) *Runner {
+	return &Runner{
+		projects:                 projects,
+		devices:                  devices,
+		heartbeatConfigs:         heartbeatConfigs,
+		deviceConnectivityEvents: deviceConnectivityEvents,
+		events:                   events,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	now := time.Now()
+
+	// Devices that went offline while the controller wasn't running aren't
+	// reported
+	if r.lastRun.IsZero() {
+		r.lastRun = now
+		return
+	}
+
+	projects, err := r.projects.ListProjects(ctx)
+	if err != nil {
+		log.WithError(err).Error("list projects")
+		return
+	}
+
+	for _, project := range projects {
+		if err := r.doForProject(ctx, project, now); err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("check device statuses")
+		}
+	}
+
+	if err := r.deviceConnectivityEvents.DeleteDeviceConnectivityEventsBefore(ctx, now.Add(-connectivity.Retention)); err != nil {
+		log.WithError(err).Error("delete expired device connectivity events")
+	}
+
+	r.lastRun = now
+}
+
+func (r *Runner) doForProject(ctx context.Context, project models.Project, now time.Time) error {
+	subscribed, err := r.events.Wants(ctx, project.ID, models.EventTypeDeviceOffline)
+	if err != nil {
+		return err
+	}
+
+	heartbeatConfig, err := r.heartbeatConfigs.GetHeartbeatConfig(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+	offlineThreshold := heartbeatConfig.OfflineThreshold()
+
+	// Only devices last seen around the threshold before the last run can
+	// have gone offline since. Selector times are to the second, so the
+	// window is widened by one.
+	devices, err := r.devices.ListDevicesMatching(ctx, project.ID, "", models.Query{
+		{
+			{
+				Type: models.DevicePropertyCondition,
+				Params: map[string]interface{}{
+					"property": "lastSeenAt",
+					"operator": models.OperatorGreaterThanOrEqual,
+					"value":    r.lastRun.Add(-offlineThreshold - time.Second).UTC().Format(time.RFC3339),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, device := range devices {
+		offlineAt := device.LastSeenAt.Add(offlineThreshold)
+		if !offlineAt.After(r.lastRun) || offlineAt.After(now) {
+			continue
+		}
+
+		if err := r.deviceConnectivityEvents.CreateDeviceConnectivityEvent(ctx, project.ID, device.ID,
+			models.DeviceStatusOffline, device.LastSeenAt); err != nil {
+			log.WithField("device_id", device.ID).
+				WithError(err).Error("create device connectivity event")
+		}
+
+		if subscribed {
+			r.events.Publish(ctx, models.Event{
+				Type:      models.EventTypeDeviceOffline,
+				ProjectID: project.ID,
+				Device:    &devices[i],
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package gitsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+	"gopkg.in/yaml.v2"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/gitrepo"
+	"github.com/deviceplane/deviceplane/pkg/controller/releasecheck"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Runner creates releases of applications from the spec files in their git
+// repositories whenever the files change.
+type Runner struct {
+	applicationGitSyncs    store.ApplicationGitSyncs
+	releases               store.Releases
+	environmentFiles       store.EnvironmentFiles
+	releaseWebhooksConfigs store.ReleaseWebhooksConfigs
+	st                     *statsd.Client
+	events                 *events.Publisher
+}
+
+func NewRunner(applicationGitSyncs store.ApplicationGitSyncs, releases store.Releases, environmentFiles store.EnvironmentFiles, releaseWebhooksConfigs store.ReleaseWebhooksConfigs, st *statsd.Client, events *events.Publisher) *Runner {
+	return &Runner{
+		applicationGitSyncs:    applicationGitSyncs,
+		releases:               releases,
+		environmentFiles:       environmentFiles,
+		releaseWebhooksConfigs: releaseWebhooksConfigs,
+		st:                     st,
+		events:                 events,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	gitSyncs, err := r.applicationGitSyncs.ListAllApplicationGitSyncs(ctx)
+	if err != nil {
+		log.WithError(err).Error("list application git syncs")
+		return
+	}
+
+	for _, gitSync := range gitSyncs {
+		if err := r.doForGitSync(ctx, gitSync); err != nil {
+			log.WithField("project_id", gitSync.ProjectID).
+				WithField("application_id", gitSync.ApplicationID).
+				WithError(err).Error("sync application from git")
+		}
+	}
+}
+
+func (r *Runner) doForGitSync(ctx context.Context, gitSync models.ApplicationGitSync) error {
+	// Problems with the repository or the spec file are the project's to fix,
+	// so they're recorded on the sync rather than returned. Failing to reach
+	// the repository keeps the last commit so it's tried again next time.
+	head, err := gitrepo.Head(ctx, gitSync.Repository, gitSync.Branch)
+	if err != nil {
+		return r.applicationGitSyncs.UpdateApplicationGitSyncStatus(ctx, gitSync.ProjectID, gitSync.ApplicationID, gitSync.LastCommit, err.Error())
+	}
+	if head == gitSync.LastCommit {
+		return nil
+	}
+
+	commit, content, err := gitrepo.ReadFile(ctx, gitSync.Repository, gitSync.Branch, gitSync.Path)
+	if err != nil {
+		return r.applicationGitSyncs.UpdateApplicationGitSyncStatus(ctx, gitSync.ProjectID, gitSync.ApplicationID, gitSync.LastCommit, err.Error())
+	}
+
+	syncErr, err := r.sync(ctx, gitSync, *commit, string(content))
+	if err != nil {
+		return err
+	}
+
+	return r.applicationGitSyncs.UpdateApplicationGitSyncStatus(ctx, gitSync.ProjectID, gitSync.ApplicationID, commit.SHA, syncErr)
+}
+
+// sync creates a release from a commit's spec file unless the latest release
+// already has its config. Configs that fail diagnosis are returned as a sync
+// error, so the same commit isn't tried again.
+func (r *Runner) sync(ctx context.Context, gitSync models.ApplicationGitSync, commit gitrepo.Commit, rawConfig string) (string, error) {
+	latestRelease, err := r.releases.GetLatestRelease(ctx, gitSync.ProjectID, gitSync.ApplicationID)
+	if err == nil && latestRelease.RawConfig == rawConfig {
+		return "", nil
+	} else if err != nil && err != store.ErrReleaseNotFound {
+		return "", err
+	}
+
+	diagnostics, err := releasecheck.Diagnose(ctx, r.environmentFiles, r.releaseWebhooksConfigs, gitSync.ProjectID, gitSync.ApplicationID, rawConfig)
+	if err != nil {
+		return "", err
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == models.DiagnosticSeverityError {
+			return diagnostic.String(), nil
+		}
+	}
+
+	var applicationConfig map[string]models.Service
+	if err := yaml.UnmarshalStrict([]byte(rawConfig), &applicationConfig); err != nil {
+		return err.Error(), nil
+	}
+
+	jsonApplicationConfig, err := json.Marshal(applicationConfig)
+	if err != nil {
+		return "", err
+	}
+
+	release, err := r.releases.CreateRelease(
+		ctx,
+		gitSync.ProjectID,
+		gitSync.ApplicationID,
+		rawConfig,
+		string(jsonApplicationConfig),
+		"",
+		commit.Subject,
+		fmt.Sprintf("%s#%s", gitrepo.Redact(gitSync.Repository), commit.SHA),
+		"",
+		"",
+		"",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	log.WithField("project_id", gitSync.ProjectID).
+		WithField("application_id", gitSync.ApplicationID).
+		WithField("release_id", release.ID).
+		WithField("commit", commit.SHA).
+		Info("created release from git")
+	r.st.Incr("runner.git_sync.release", []string{fmt.Sprintf("project_id:%s", gitSync.ProjectID)}, 1)
+	r.events.Publish(ctx, models.Event{
+		Type:      models.EventTypeReleaseCreated,
+		ProjectID: gitSync.ProjectID,
+		Release:   release,
+	})
+
+	return "", nil
+}
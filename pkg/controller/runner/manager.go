@@ -4,49 +4,90 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
 )
 
 const (
 	repeatInverval = time.Minute
+	lockName       = "deviceplane-runners"
 )
 
-// Manager is responsible for running all background runners
-// This will play a more important role down the road when we can run
-// multiple controllers and need distributed locking
+// Manager is responsible for running all background runners. When several
+// controllers share a store, only the one holding the runners' lock runs
+// them each time.
 type Manager struct {
 	runners []Runner
+	locks   store.Locks
+
+	stop    chan struct{}
+	stopped chan struct{}
 }
 
-func NewManager(runners []Runner) *Manager {
+func NewManager(runners []Runner, locks store.Locks) *Manager {
 	return &Manager{
 		runners: runners,
+		locks:   locks,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
 	}
 }
 
 func (m *Manager) Start() {
 	go func() {
+		defer close(m.stopped)
+
 		ticker := time.NewTicker(repeatInverval)
 		defer ticker.Stop()
 
 		for {
 			ctx, cancel := context.WithTimeout(context.Background(), repeatInverval/2)
 
-			var wg sync.WaitGroup
-			for _, runner := range m.runners {
-				wg.Add(1)
-				go func(runner Runner) {
-					runner.Do(ctx)
-					wg.Done()
-				}(runner)
+			unlock, err := m.locks.TryLock(ctx, lockName)
+			switch err {
+			case nil:
+				m.run(ctx)
+				unlock()
+			case store.ErrLockHeld:
+			default:
+				log.WithError(err).Error("lock runners")
 			}
 
-			wg.Wait()
 			cancel()
 
 			select {
 			case <-ticker.C:
 				continue
+			case <-m.stop:
+				return
 			}
 		}
 	}()
 }
+
+// Stop stops the runners from being run again, and waits for a run in
+// progress to finish or for ctx to be done.
+func (m *Manager) Stop(ctx context.Context) error {
+	close(m.stop)
+
+	select {
+	case <-m.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, runner := range m.runners {
+		wg.Add(1)
+		go func(runner Runner) {
+			runner.Do(ctx)
+			wg.Done()
+		}(runner)
+	}
+	wg.Wait()
+}
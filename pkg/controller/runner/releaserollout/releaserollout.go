@@ -0,0 +1,218 @@
+package releaserollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/maintenance"
+	"github.com/deviceplane/deviceplane/pkg/controller/rollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/scheduling"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// Runner moves release rollouts on to their next step once the current one
+// has soaked, and halts them when too many of their devices are failing.
+type Runner struct {
+	applications          store.Applications
+	releases              store.Releases
+	devices               store.Devices
+	deviceGroups          store.DeviceGroups
+	deviceServiceStatuses store.DeviceServiceStatuses
+	rollouts              store.Rollouts
+	deviceReleasePins     store.DeviceReleasePins
+	maintenanceWindows    store.MaintenanceWindowsConfigs
+	st                    *statsd.Client
+	events                *events.Publisher
+}
+
+func NewRunner(applications store.Applications, releases store.Releases, devices store.Devices, deviceGroups store.DeviceGroups, deviceServiceStatuses store.DeviceServiceStatuses, rollouts store.Rollouts, deviceReleasePins store.DeviceReleasePins, maintenanceWindows store.MaintenanceWindowsConfigs, st *statsd.Client, events *events.Publisher) *Runner {
+	return &Runner{
+		applications:          applications,
+		releases:              releases,
+		devices:               devices,
+		deviceGroups:          deviceGroups,
+		deviceServiceStatuses: deviceServiceStatuses,
+		rollouts:              rollouts,
+		deviceReleasePins:     deviceReleasePins,
+		maintenanceWindows:    maintenanceWindows,
+		st:                    st,
+		events:                events,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	rollouts, err := r.rollouts.ListRolloutsByStatus(ctx, models.RolloutStatusInProgress)
+	if err != nil {
+		log.WithError(err).Error("list rollouts")
+		return
+	}
+
+	for _, rollout := range rollouts {
+		if err := r.doForRollout(ctx, rollout); err != nil {
+			log.WithField("project_id", rollout.ProjectID).
+				WithField("rollout_id", rollout.ID).
+				WithError(err).Error("check release rollout")
+		}
+	}
+}
+
+func (r *Runner) doForRollout(ctx context.Context, ro models.Rollout) error {
+	application, err := r.applications.GetApplication(ctx, ro.ApplicationID, ro.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	release, err := r.releases.GetRelease(ctx, ro.ReleaseID, ro.ProjectID, ro.ApplicationID)
+	if err != nil {
+		return err
+	}
+
+	devices, err := r.devices.ListDevices(ctx, ro.ProjectID, "")
+	if err != nil {
+		return err
+	}
+
+	// Scheduling rules, rollout steps and maintenance windows can refer to
+	// device groups
+	groups, err := devicegroups.Load(ctx, r.deviceGroups, ro.ProjectID, devices)
+	if err != nil {
+		return err
+	}
+
+	maintenanceWindowsConfig, err := r.maintenanceWindows.GetMaintenanceWindowsConfig(ctx, ro.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	scheduledDevices, err := scheduling.GetScheduledDevices(devices, application.SchedulingRule)
+	if err != nil {
+		return err
+	}
+
+	// Devices pinned to a release don't take part in rollouts
+	pins, err := r.deviceReleasePins.ListDeviceReleasePins(ctx, ro.ProjectID, ro.ApplicationID)
+	if err != nil {
+		return err
+	}
+	pinned := make(map[string]bool)
+	for _, pin := range pins {
+		pinned[pin.DeviceID] = true
+	}
+
+	// Only devices scheduled onto the rollout's release are in it. Most
+	// devices are scheduled onto the same few releases, such as latest.
+	now := time.Now()
+	var rolloutDevices []models.Device
+	serviceStatuses := make(map[string][]models.DeviceServiceStatus)
+	held := make(map[string]bool)
+	releaseIDs := make(map[string]string)
+	for _, scheduledDevice := range scheduledDevices {
+		if pinned[scheduledDevice.Device.ID] {
+			continue
+		}
+
+		releaseID, ok := releaseIDs[scheduledDevice.ReleaseID]
+		if !ok {
+			scheduledRelease, err := utils.GetReleaseByIdentifier(r.releases, ctx, ro.ProjectID, ro.ApplicationID, scheduledDevice.ReleaseID)
+			if err == nil {
+				releaseID = scheduledRelease.ID
+			} else if err != store.ErrReleaseNotFound {
+				return err
+			}
+			releaseIDs[scheduledDevice.ReleaseID] = releaseID
+		}
+		if releaseID != ro.ReleaseID {
+			continue
+		}
+
+		statuses, err := r.deviceServiceStatuses.GetDeviceServiceStatuses(ctx, ro.ProjectID, scheduledDevice.Device.ID, ro.ApplicationID)
+		if err != nil {
+			return err
+		}
+
+		rolloutDevices = append(rolloutDevices, scheduledDevice.Device)
+		serviceStatuses[scheduledDevice.Device.ID] = statuses
+
+		// Devices are given the grace period from when their maintenance
+		// window opens
+		for _, at := range []time.Time{now, now.Add(-rollout.UpdateGracePeriod)} {
+			allowed, err := maintenance.UpdatesAllowed(scheduledDevice.Device, groups, *maintenanceWindowsConfig, at)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				held[scheduledDevice.Device.ID] = true
+			}
+		}
+	}
+
+	var services []string
+	for service := range release.Config {
+		services = append(services, service)
+	}
+
+	decision, haltReason, err := rollout.CheckReleaseRollout(rolloutDevices, serviceStatuses, services, held, ro, now)
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithField("project_id", ro.ProjectID).
+		WithField("rollout_id", ro.ID).
+		WithField("release_id", ro.ReleaseID)
+	tags := []string{fmt.Sprintf("project_id:%s", ro.ProjectID)}
+
+	switch decision {
+	case rollout.Progress:
+		if ro.Step+1 < len(ro.Steps) {
+			logger.WithField("step", ro.Step+1).Info("progressing release rollout")
+			r.st.Incr("runner.release_rollout.progress", tags, 1)
+			progressed, err := r.rollouts.UpdateRolloutStep(ctx, ro.ID, ro.ProjectID, ro.Step+1)
+			if err != nil {
+				return err
+			}
+			r.events.Publish(ctx, models.Event{
+				Type:      models.EventTypeRolloutProgressed,
+				ProjectID: ro.ProjectID,
+				Rollout:   progressed,
+			})
+			return nil
+		}
+
+		logger.Info("completing release rollout")
+		r.st.Incr("runner.release_rollout.complete", tags, 1)
+		completed, err := r.rollouts.UpdateRolloutStatus(ctx, ro.ID, ro.ProjectID, models.RolloutStatusCompleted, "")
+		if err != nil {
+			return err
+		}
+		r.events.Publish(ctx, models.Event{
+			Type:      models.EventTypeRolloutCompleted,
+			ProjectID: ro.ProjectID,
+			Rollout:   completed,
+		})
+		return nil
+
+	case rollout.Halt:
+		logger.WithField("reason", haltReason).Info("halting release rollout")
+		r.st.Incr("runner.release_rollout.halt", tags, 1)
+		halted, err := r.rollouts.UpdateRolloutStatus(ctx, ro.ID, ro.ProjectID, models.RolloutStatusHalted, haltReason)
+		if err != nil {
+			return err
+		}
+		r.events.Publish(ctx, models.Event{
+			Type:      models.EventTypeRolloutFailed,
+			ProjectID: ro.ProjectID,
+			Rollout:   halted,
+		})
+		return nil
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package staledevices
+
+import (
+	"context"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/staledevices"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// Runner archives or deletes devices that haven't been seen for longer than
+// their project's stale device cleanup policy allows.
+type Runner struct {
+	projects                  store.Projects
+	devices                   store.Devices
+	deviceAccessKeys          store.DeviceAccessKeys
+	staleDeviceCleanupConfigs store.StaleDeviceCleanupConfigs
+	st                        *statsd.Client
+}
+
+func NewRunner(projects store.Projects, devices store.Devices, deviceAccessKeys store.DeviceAccessKeys, staleDeviceCleanupConfigs store.StaleDeviceCleanupConfigs, st *statsd.Client) *Runner {
+	return &Runner{
+		projects:                  projects,
+		devices:                   devices,
+		deviceAccessKeys:          deviceAccessKeys,
+		staleDeviceCleanupConfigs: staleDeviceCleanupConfigs,
+		st:                        st,
+	}
+}
+
+func (r *Runner) Do(ctx context.Context) {
+	projects, err := r.projects.ListProjects(ctx)
+	if err != nil {
+		log.WithError(err).Error("list projects")
+		return
+	}
+
+	now := time.Now()
+	for _, project := range projects {
+		if err := r.doForProject(ctx, project, now); err != nil {
+			log.WithField("project_id", project.ID).
+				WithError(err).Error("clean up stale devices")
+		}
+	}
+}
+
+func (r *Runner) doForProject(ctx context.Context, project models.Project, now time.Time) error {
+	config, err := r.staleDeviceCleanupConfigs.GetStaleDeviceCleanupConfig(ctx, project.ID)
+	if err != nil {
+		return err
+	}
+
+	if !config.Enabled {
+		return nil
+	}
+
+	devices, err := r.devices.ListDevices(ctx, project.ID, "")
+	if err != nil {
+		return err
+	}
+
+	for _, device := range staledevices.Report(*config, devices, now).Devices {
+		if err := r.cleanUp(ctx, project, device, config.Action); err != nil {
+			log.WithField("project_id", project.ID).
+				WithField("device_id", device.ID).
+				WithError(err).Error("clean up stale device")
+			r.st.Incr("runner.stale_devices.cleanup", append([]string{"status:failure"}, utils.InternalTags(project.Name)...), 1)
+			continue
+		}
+
+		log.WithField("project_id", project.ID).
+			WithField("device_id", device.ID).
+			WithField("action", config.Action).
+			WithField("last_seen_at", device.LastSeenAt).
+			Info("cleaned up stale device")
+		r.st.Incr("runner.stale_devices.cleanup", append([]string{"status:success"}, utils.InternalTags(project.Name)...), 1)
+	}
+
+	return nil
+}
+
+func (r *Runner) cleanUp(ctx context.Context, project models.Project, device models.Device, action models.StaleDeviceCleanupAction) error {
+	switch action {
+	case models.StaleDeviceCleanupActionDelete:
+		return r.devices.DeleteDevice(ctx, device.ID, project.ID)
+	default:
+		// Archived devices are kept for their history, but can't connect
+		// again
+		if err := r.devices.ArchiveDevice(ctx, device.ID, project.ID); err != nil {
+			return err
+		}
+		return r.deviceAccessKeys.DeleteDeviceAccessKeys(ctx, device.ID, project.ID)
+	}
+}
@@ -0,0 +1,325 @@
+package saml
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	crewjamsaml "github.com/crewjam/saml"
+	"github.com/pkg/errors"
+)
+
+const (
+	timeout                = 10 * time.Second
+	metadataRefreshDelay   = time.Hour
+	maxMetadataSize        = 1 << 20
+	maxErrorBodySize       = 512
+	emailAddressNameFormat = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+	MetadataPath = "/api/sso/saml/metadata"
+	ACSPath      = "/api/sso/saml/acs"
+
+	DefaultEmailAttribute      = "email"
+	DefaultGivenNameAttribute  = "firstName"
+	DefaultFamilyNameAttribute = "lastName"
+	DefaultGroupsAttribute     = "groups"
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid saml response")
+	ErrNoSSOLocation   = errors.New("identity provider has no HTTP-Redirect single sign-on location")
+)
+
+// Config is a SAML identity provider users can log in with. RootURL is
+// where the controller is served, which its metadata and assertion consumer
+// service URLs are under. The certificate and key are optional. With them,
+// the metadata advertises the certificate and identity providers can
+// encrypt assertions to it. Attributes are matched by name or friendly name.
+type Config struct {
+	IDPMetadataURL      string
+	RootURL             string
+	Certificate         *x509.Certificate
+	Key                 *rsa.PrivateKey
+	EmailAttribute      string
+	GivenNameAttribute  string
+	FamilyNameAttribute string
+	GroupsAttribute     string
+}
+
+// Claims is who a user is, according to a verified assertion. Issuer is
+// the identity provider's entity ID.
+type Claims struct {
+	Issuer     string
+	Subject    string
+	Email      string
+	GivenName  string
+	FamilyName string
+	Groups     []string
+}
+
+// Provider is the service provider side of SAML single sign-on, using the
+// HTTP-Redirect binding for requests and the HTTP-POST binding for
+// responses. Responses are only accepted for requests the provider made,
+// and must be signed by the identity provider. The identity provider's
+// metadata is fetched on first use and refreshed hourly, so it being
+// unavailable doesn't stop the controller from starting.
+type Provider struct {
+	config      Config
+	httpClient  *http.Client
+	metadataURL url.URL
+	acsURL      url.URL
+
+	lock              sync.Mutex
+	idpMetadata       *crewjamsaml.EntityDescriptor
+	metadataFetchedAt time.Time
+}
+
+func NewProvider(config Config) (*Provider, error) {
+	rootURL, err := url.Parse(strings.TrimSuffix(config.RootURL, "/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse root url")
+	}
+	if rootURL.Scheme == "" || rootURL.Host == "" {
+		return nil, fmt.Errorf("root url %q isn't absolute", config.RootURL)
+	}
+	if (config.Certificate == nil) != (config.Key == nil) {
+		return nil, errors.New("the certificate and key have to be given together")
+	}
+
+	if config.EmailAttribute == "" {
+		config.EmailAttribute = DefaultEmailAttribute
+	}
+	if config.GivenNameAttribute == "" {
+		config.GivenNameAttribute = DefaultGivenNameAttribute
+	}
+	if config.FamilyNameAttribute == "" {
+		config.FamilyNameAttribute = DefaultFamilyNameAttribute
+	}
+	if config.GroupsAttribute == "" {
+		config.GroupsAttribute = DefaultGroupsAttribute
+	}
+
+	metadataURL := *rootURL
+	metadataURL.Path += MetadataPath
+	acsURL := *rootURL
+	acsURL.Path += ACSPath
+
+	return &Provider{
+		config: config,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		metadataURL: metadataURL,
+		acsURL:      acsURL,
+	}, nil
+}
+
+// Secure returns whether users are sent back from the identity provider
+// over https, so cookies set for them should be secure.
+func (p *Provider) Secure() bool {
+	return p.acsURL.Scheme == "https"
+}
+
+// Metadata returns the service provider's metadata, for registering the
+// controller with the identity provider.
+func (p *Provider) Metadata() ([]byte, error) {
+	metadata, err := xml.MarshalIndent(p.serviceProvider(nil).Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+// AuthnRequestURL returns the URL to send users to to log in, and the ID of
+// the request, which the response has to be for.
+func (p *Provider) AuthnRequestURL(ctx context.Context) (string, string, error) {
+	idpMetadata, err := p.getIDPMetadata(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	sp := p.serviceProvider(idpMetadata)
+
+	location := sp.GetSSOBindingLocation(crewjamsaml.HTTPRedirectBinding)
+	if location == "" {
+		return "", "", ErrNoSSOLocation
+	}
+
+	request, err := sp.MakeAuthenticationRequest(location, crewjamsaml.HTTPRedirectBinding, crewjamsaml.HTTPPostBinding)
+	if err != nil {
+		return "", "", errors.Wrap(err, "make authentication request")
+	}
+	redirectURL, err := request.Redirect("", sp)
+	if err != nil {
+		return "", "", errors.Wrap(err, "make redirect url")
+	}
+
+	return redirectURL.String(), request.ID, nil
+}
+
+// ParseResponse verifies the response posted to the assertion consumer
+// service. Its assertion has to be signed by the identity provider, be
+// for this service provider and the request with requestID, and be within
+// its validity period.
+func (p *Provider) ParseResponse(r *http.Request, requestID string) (*Claims, error) {
+	idpMetadata, err := p.getIDPMetadata(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	sp := p.serviceProvider(idpMetadata)
+
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, err.Error())
+	}
+	// Only the HTTP-POST binding is advertised
+	if r.PostForm.Get("SAMLResponse") == "" {
+		return nil, ErrInvalidResponse
+	}
+
+	assertion, err := sp.ParseResponse(r, []string{requestID})
+	if err != nil {
+		// The public error is deliberately vague, and the reason is kept
+		// for logging
+		if invalidResponseError, ok := err.(*crewjamsaml.InvalidResponseError); ok && invalidResponseError.PrivateErr != nil {
+			err = invalidResponseError.PrivateErr
+		}
+		return nil, errors.Wrap(ErrInvalidResponse, err.Error())
+	}
+
+	claims := Claims{
+		Issuer: idpMetadata.EntityID,
+	}
+	var emailNameID string
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		claims.Subject = assertion.Subject.NameID.Value
+		if assertion.Subject.NameID.Format == emailAddressNameFormat {
+			emailNameID = assertion.Subject.NameID.Value
+		}
+	}
+
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			var values []string
+			for _, value := range attribute.Values {
+				if value.Value != "" {
+					values = append(values, value.Value)
+				}
+			}
+			if len(values) == 0 {
+				continue
+			}
+
+			switch {
+			case p.isAttribute(attribute, p.config.EmailAttribute):
+				claims.Email = values[0]
+			case p.isAttribute(attribute, p.config.GivenNameAttribute):
+				claims.GivenName = values[0]
+			case p.isAttribute(attribute, p.config.FamilyNameAttribute):
+				claims.FamilyName = values[0]
+			case p.isAttribute(attribute, p.config.GroupsAttribute):
+				claims.Groups = append(claims.Groups, values...)
+			}
+		}
+	}
+	if claims.Email == "" {
+		claims.Email = emailNameID
+	}
+
+	return &claims, nil
+}
+
+func (p *Provider) isAttribute(attribute crewjamsaml.Attribute, name string) bool {
+	return attribute.Name == name || attribute.FriendlyName == name
+}
+
+func (p *Provider) serviceProvider(idpMetadata *crewjamsaml.EntityDescriptor) *crewjamsaml.ServiceProvider {
+	return &crewjamsaml.ServiceProvider{
+		EntityID:          p.metadataURL.String(),
+		Key:               p.config.Key,
+		Certificate:       p.config.Certificate,
+		HTTPClient:        p.httpClient,
+		MetadataURL:       p.metadataURL,
+		AcsURL:            p.acsURL,
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: crewjamsaml.UnspecifiedNameIDFormat,
+	}
+}
+
+func (p *Provider) getIDPMetadata(ctx context.Context) (*crewjamsaml.EntityDescriptor, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.idpMetadata != nil && time.Since(p.metadataFetchedAt) < metadataRefreshDelay {
+		return p.idpMetadata, nil
+	}
+
+	idpMetadata, err := p.fetchIDPMetadata(ctx)
+	if err != nil {
+		// Metadata rarely changes, so a stale copy beats failing logins
+		if p.idpMetadata != nil {
+			return p.idpMetadata, nil
+		}
+		return nil, err
+	}
+
+	p.idpMetadata = idpMetadata
+	p.metadataFetchedAt = time.Now()
+	return idpMetadata, nil
+}
+
+func (p *Provider) fetchIDPMetadata(ctx context.Context) (*crewjamsaml.EntityDescriptor, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.config.IDPMetadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch identity provider metadata")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return nil, fmt.Errorf("fetch identity provider metadata: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "read identity provider metadata")
+	}
+
+	return parseIDPMetadata(data)
+}
+
+// parseIDPMetadata parses an identity provider's entity descriptor, which
+// may be on its own or the first identity provider in a list of entities.
+func parseIDPMetadata(data []byte) (*crewjamsaml.EntityDescriptor, error) {
+	var entity crewjamsaml.EntityDescriptor
+	entityErr := xml.Unmarshal(data, &entity)
+	if entityErr == nil && len(entity.IDPSSODescriptors) > 0 {
+		return &entity, nil
+	}
+
+	var entities crewjamsaml.EntitiesDescriptor
+	if err := xml.Unmarshal(data, &entities); err == nil {
+		for i := range entities.EntityDescriptors {
+			if len(entities.EntityDescriptors[i].IDPSSODescriptors) > 0 {
+				return &entities.EntityDescriptors[i], nil
+			}
+		}
+	}
+
+	if entityErr != nil {
+		return nil, errors.Wrap(entityErr, "parse identity provider metadata")
+	}
+	return nil, errors.New("metadata doesn't describe an identity provider")
+}
@@ -0,0 +1,194 @@
+package saml
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/xml"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	crewjamsaml "github.com/crewjam/saml"
+	"github.com/stretchr/testify/require"
+)
+
+type testIDP struct {
+	*httptest.Server
+	idp      *crewjamsaml.IdentityProvider
+	provider *Provider
+}
+
+func newKeyPair(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return certificate, key
+}
+
+// newTestIDP starts an identity provider that the returned provider trusts,
+// and which trusts the provider.
+func newTestIDP(t *testing.T) *testIDP {
+	certificate, key := newKeyPair(t)
+
+	p := &testIDP{
+		idp: &crewjamsaml.IdentityProvider{
+			Key:         key,
+			Certificate: certificate,
+		},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata", p.idp.ServeMetadata)
+	p.Server = httptest.NewServer(mux)
+
+	metadataURL, err := url.Parse(p.URL + "/metadata")
+	require.NoError(t, err)
+	ssoURL, err := url.Parse(p.URL + "/sso")
+	require.NoError(t, err)
+	p.idp.MetadataURL = *metadataURL
+	p.idp.SSOURL = *ssoURL
+	p.idp.ServiceProviderProvider = p
+
+	p.provider, err = NewProvider(Config{
+		IDPMetadataURL: p.URL + "/metadata",
+		RootURL:        "https://deviceplane.example.com/",
+	})
+	require.NoError(t, err)
+
+	return p
+}
+
+func (p *testIDP) GetServiceProvider(r *http.Request, serviceProviderID string) (*crewjamsaml.EntityDescriptor, error) {
+	metadata, err := p.provider.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	var entity crewjamsaml.EntityDescriptor
+	if err := xml.Unmarshal(metadata, &entity); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// login answers the provider's authn request for Jane, and returns the
+// request ID and the response posted to the assertion consumer service.
+func (p *testIDP) login(t *testing.T) (string, *http.Request) {
+	authnRequestURL, requestID, err := p.provider.AuthnRequestURL(context.Background())
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(authnRequestURL, p.URL+"/sso?"))
+
+	req, err := crewjamsaml.NewIdpAuthnRequest(p.idp, httptest.NewRequest("GET", authnRequestURL, nil))
+	require.NoError(t, err)
+	require.NoError(t, req.Validate())
+	require.NoError(t, crewjamsaml.DefaultAssertionMaker{}.MakeAssertion(req, &crewjamsaml.Session{
+		ID:           "session-1",
+		NameID:       "jane",
+		NameIDFormat: string(crewjamsaml.UnspecifiedNameIDFormat),
+		CustomAttributes: []crewjamsaml.Attribute{
+			{Name: "email", Values: []crewjamsaml.AttributeValue{{Value: "jane@example.com"}}},
+			{Name: "firstName", Values: []crewjamsaml.AttributeValue{{Value: "Jane"}}},
+			{Name: "lastName", Values: []crewjamsaml.AttributeValue{{Value: "Doe"}}},
+			{Name: "groups", Values: []crewjamsaml.AttributeValue{{Value: "engineering"}, {Value: "oncall"}}},
+		},
+	}))
+	form, err := req.PostBinding()
+	require.NoError(t, err)
+	require.Equal(t, "https://deviceplane.example.com"+ACSPath, form.URL)
+
+	return requestID, acsRequest(form.URL, form.SAMLResponse)
+}
+
+func acsRequest(acsURL, samlResponse string) *http.Request {
+	r := httptest.NewRequest("POST", acsURL, strings.NewReader(url.Values{
+		"SAMLResponse": []string{samlResponse},
+	}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestParseResponse(t *testing.T) {
+	p := newTestIDP(t)
+	defer p.Close()
+
+	requestID, r := p.login(t)
+	claims, err := p.provider.ParseResponse(r, requestID)
+	require.NoError(t, err)
+	require.Equal(t, &Claims{
+		Issuer:     p.URL + "/metadata",
+		Subject:    "jane",
+		Email:      "jane@example.com",
+		GivenName:  "Jane",
+		FamilyName: "Doe",
+		Groups:     []string{"engineering", "oncall"},
+	}, claims)
+}
+
+func TestParseResponseRejected(t *testing.T) {
+	p := newTestIDP(t)
+	defer p.Close()
+
+	requestID, r := p.login(t)
+	_, err := p.provider.ParseResponse(r, "other-request")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), ErrInvalidResponse.Error())
+
+	requestID, r = p.login(t)
+	require.NoError(t, r.ParseForm())
+	response, err := base64.StdEncoding.DecodeString(r.PostForm.Get("SAMLResponse"))
+	require.NoError(t, err)
+	require.True(t, bytes.Contains(response, []byte("oncall")))
+	tampered := bytes.Replace(response, []byte("oncall"), []byte("admins"), 1)
+	_, err = p.provider.ParseResponse(acsRequest(r.URL.String(), base64.StdEncoding.EncodeToString(tampered)), requestID)
+	require.Error(t, err)
+
+	// The provider's copy of the metadata has the original certificate, so
+	// responses signed with any other key are rejected
+	p.idp.Certificate, p.idp.Key = newKeyPair(t)
+	requestID, r = p.login(t)
+	_, err = p.provider.ParseResponse(r, requestID)
+	require.Error(t, err)
+
+	_, err = p.provider.ParseResponse(acsRequest(r.URL.String(), ""), requestID)
+	require.Equal(t, ErrInvalidResponse, err)
+}
+
+func TestParseIDPMetadata(t *testing.T) {
+	p := newTestIDP(t)
+	defer p.Close()
+
+	entity, err := xml.Marshal(p.idp.Metadata())
+	require.NoError(t, err)
+	idpMetadata, err := parseIDPMetadata(entity)
+	require.NoError(t, err)
+	require.Equal(t, p.URL+"/metadata", idpMetadata.EntityID)
+
+	entities, err := xml.Marshal(crewjamsaml.EntitiesDescriptor{
+		EntityDescriptors: []crewjamsaml.EntityDescriptor{*p.idp.Metadata()},
+	})
+	require.NoError(t, err)
+	idpMetadata, err = parseIDPMetadata(entities)
+	require.NoError(t, err)
+	require.Equal(t, p.URL+"/metadata", idpMetadata.EntityID)
+
+	_, err = parseIDPMetadata([]byte("<html></html>"))
+	require.Error(t, err)
+}
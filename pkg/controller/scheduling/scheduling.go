@@ -59,12 +59,12 @@ func GetScheduledDevices(devices []models.Device, schedulingRule models.Scheduli
 		selectedDevices = devices
 
 	case models.ScheduleTypeConditional:
-		if schedulingRule.ConditionalQuery == nil {
-			return nil, ErrInvalidConditionValue
+		conditionalQuery, err := ConditionalQuery(schedulingRule)
+		if err != nil {
+			return nil, err
 		}
 
-		var err error
-		selectedDevices, _, err = query.QueryDevices(devices, *schedulingRule.ConditionalQuery)
+		selectedDevices, _, err = query.QueryDevices(devices, conditionalQuery)
 		if err != nil {
 			return nil, errors.Wrap(err, "filtering by schedule query")
 		}
@@ -81,7 +81,12 @@ func GetScheduledDevices(devices []models.Device, schedulingRule models.Scheduli
 
 	// Go through release selectors
 	for _, releaseSelector := range schedulingRule.ReleaseSelectors {
-		releasePinnedDevices, newSelectedDevices, err := query.QueryDevices(selectedDevices, releaseSelector.Query)
+		releaseQuery, err := ReleaseQuery(releaseSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		releasePinnedDevices, newSelectedDevices, err := query.QueryDevices(selectedDevices, releaseQuery)
 		if err != nil {
 			return nil, errors.Wrap(err, "filtering by release query")
 		}
@@ -106,6 +111,41 @@ func GetScheduledDevices(devices []models.Device, schedulingRule models.Scheduli
 	return scheduledDevices, nil
 }
 
+// ConditionalQuery returns the query that a conditional scheduling rule
+// selects devices with, combining its query and selector.
+func ConditionalQuery(schedulingRule models.SchedulingRule) (models.Query, error) {
+	if schedulingRule.ConditionalQuery == nil && schedulingRule.ConditionalSelector == "" {
+		return nil, ErrInvalidConditionValue
+	}
+
+	var conditionalQuery models.Query
+	if schedulingRule.ConditionalQuery != nil {
+		conditionalQuery = append(conditionalQuery, *schedulingRule.ConditionalQuery...)
+	}
+	if schedulingRule.ConditionalSelector != "" {
+		selectorQuery, err := query.ParseSelector(schedulingRule.ConditionalSelector)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing schedule selector")
+		}
+		conditionalQuery = append(conditionalQuery, selectorQuery...)
+	}
+	return conditionalQuery, nil
+}
+
+// ReleaseQuery returns the query that a release selector pins devices
+// with, combining its query and selector.
+func ReleaseQuery(releaseSelector models.ReleaseSelector) (models.Query, error) {
+	if releaseSelector.Selector == "" {
+		return releaseSelector.Query, nil
+	}
+
+	selectorQuery, err := query.ParseSelector(releaseSelector.Selector)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing release selector")
+	}
+	return append(append(models.Query{}, releaseSelector.Query...), selectorQuery...), nil
+}
+
 func ValidateSchedulingRule(schedulingRule models.SchedulingRule, releaseIdExists func(string) (bool, error)) (
 	validationErr error,
 	err error,
@@ -118,10 +158,11 @@ func ValidateSchedulingRule(schedulingRule models.SchedulingRule, releaseIdExist
 		break
 
 	case models.ScheduleTypeConditional:
-		if schedulingRule.ConditionalQuery == nil {
-			return ErrInvalidConditionValue, nil
+		conditionalQuery, err := ConditionalQuery(schedulingRule)
+		if err != nil {
+			return err, nil
 		}
-		err := query.ValidateQuery(*schedulingRule.ConditionalQuery)
+		err = query.ValidateQuery(conditionalQuery)
 		if err != nil {
 			return errors.Wrap(err, "filtering by schedule query"), nil
 		}
@@ -143,7 +184,11 @@ func ValidateSchedulingRule(schedulingRule models.SchedulingRule, releaseIdExist
 
 	// Go through release selectors
 	for _, releaseSelector := range schedulingRule.ReleaseSelectors {
-		err := query.ValidateQuery(releaseSelector.Query)
+		releaseQuery, err := ReleaseQuery(releaseSelector)
+		if err != nil {
+			return err, nil
+		}
+		err = query.ValidateQuery(releaseQuery)
 		if err != nil {
 			return errors.Wrap(err, "filtering by release query"), nil
 		}
@@ -242,6 +242,97 @@ func TestScheduleWithQuery(t *testing.T) {
 	})
 }
 
+func TestScheduleWithSelectors(t *testing.T) {
+	one := models.Device{
+		ID:     "one",
+		Status: models.DeviceStatusOnline,
+		Labels: map[string]string{
+			"region": "eu",
+			"fw":     "1.10.0",
+		},
+	}
+	two := models.Device{
+		ID:     "two",
+		Status: models.DeviceStatusOnline,
+		Labels: map[string]string{
+			"region": "uk",
+			"fw":     "1.9.0",
+		},
+	}
+	three := models.Device{
+		ID:     "three",
+		Status: models.DeviceStatusOnline,
+		Labels: map[string]string{
+			"region": "us",
+			"fw":     "1.10.0",
+		},
+	}
+
+	testScenario(t, Scenario{
+		in: []models.Device{one, two, three},
+		schedulingRule: models.SchedulingRule{
+			ScheduleType:        models.ScheduleTypeConditional,
+			ConditionalSelector: "region in (eu, uk)",
+			ReleaseSelectors: []models.ReleaseSelector{
+				models.ReleaseSelector{
+					Selector:  "fw >= 1.10",
+					ReleaseID: "2",
+				},
+			},
+			DefaultReleaseID: "1",
+		},
+		out: []models.ScheduledDevice{
+			models.ScheduledDevice{
+				Device:    one,
+				ReleaseID: "2",
+			},
+			models.ScheduledDevice{
+				Device:    two,
+				ReleaseID: "1",
+			},
+		},
+	})
+
+	// The query and the selector both have to match
+	testScenario(t, Scenario{
+		in: []models.Device{one, two, three},
+		schedulingRule: models.SchedulingRule{
+			ScheduleType: models.ScheduleTypeConditional,
+			ConditionalQuery: &models.Query{
+				models.Filter{
+					models.Condition{
+						Type: models.LabelValueCondition,
+						Params: map[string]interface{}{
+							"key":      "fw",
+							"operator": models.OperatorIs,
+							"value":    "1.10.0",
+						},
+					},
+				},
+			},
+			ConditionalSelector: "region notin (us)",
+			DefaultReleaseID:    "1",
+		},
+		out: []models.ScheduledDevice{
+			models.ScheduledDevice{
+				Device:    one,
+				ReleaseID: "1",
+			},
+		},
+	})
+
+	_, err := GetScheduledDevices([]models.Device{one}, models.SchedulingRule{
+		ScheduleType:        models.ScheduleTypeConditional,
+		ConditionalSelector: "region in eu",
+	})
+	require.Error(t, err)
+
+	_, err = GetScheduledDevices([]models.Device{one}, models.SchedulingRule{
+		ScheduleType: models.ScheduleTypeConditional,
+	})
+	require.Equal(t, ErrInvalidConditionValue, err)
+}
+
 func TestScheduleWithSimplePinnedQuery(t *testing.T) {
 	testScenario(t, Scenario{
 		in: []models.Device{
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/gorilla/mux"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// Resolved alerts are listed from this far back unless since is given
+const defaultResolvedAlertsPeriod = 24 * time.Hour
+
+// listAlerts lists the project's firing alerts, and those resolved since a
+// time.
+func (s *Service) listAlerts(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	since := time.Now().Add(-defaultResolvedAlertsPeriod)
+	if sinceString := r.URL.Query().Get("since"); sinceString != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceString)
+		if err != nil {
+			http.Error(w, errInvalidAlertsSince.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	alerts, err := s.alerts.ListAlerts(r.Context(), projectID, since)
+	if err != nil {
+		log.WithError(err).Error("list alerts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, alerts)
+}
+
+func (s *Service) listAlertSilences(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	alertSilences, err := s.alertSilences.ListAlertSilences(r.Context(), projectID, time.Now())
+	if err != nil {
+		log.WithError(err).Error("list alert silences")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, alertSilences)
+}
+
+func (s *Service) createAlertSilence(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var createAlertSilenceRequest models.CreateAlertSilenceRequest
+	if err := read(r, &createAlertSilenceRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !createAlertSilenceRequest.Until.After(time.Now()) {
+		http.Error(w, errAlertSilenceInPast.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deviceID := createAlertSilenceRequest.Device
+	if deviceID != "" && !strings.Contains(deviceID, "_") {
+		device, err := s.devices.LookupDevice(r.Context(), deviceID, projectID)
+		if err == store.ErrDeviceNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("lookup device")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		deviceID = device.ID
+	}
+
+	alertSilence, err := s.alertSilences.CreateAlertSilence(r.Context(), projectID,
+		createAlertSilenceRequest.Rule, deviceID, createAlertSilenceRequest.Until,
+		createAlertSilenceRequest.Reason, authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		log.WithError(err).Error("create alert silence")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, alertSilence)
+}
+
+func (s *Service) deleteAlertSilence(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	alertSilenceID := mux.Vars(r)["alertsilence"]
+
+	if _, err := s.alertSilences.GetAlertSilence(r.Context(), alertSilenceID, projectID); err == store.ErrAlertSilenceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get alert silence")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.alertSilences.DeleteAlertSilence(r.Context(), alertSilenceID, projectID); err != nil {
+		log.WithError(err).Error("delete alert silence")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+)
+
+// Only this much of a request body is looked at for its summary, so large
+// uploads are still streamed to their handlers
+const maxAuditLogSummaryBodySize = 64 << 10
+
+var errHijackUnsupported = errors.New("hijacking unsupported")
+
+// auditLogEntry wraps the response of a request being recorded in the audit
+// log, so its status is known once it has finished. Remote access sessions
+// finish when their connection closes.
+type auditLogEntry struct {
+	http.ResponseWriter
+
+	s                *Service
+	r                *http.Request
+	projectID        string
+	userID           string
+	serviceAccountID string
+	action           authz.Action
+	summary          string
+	startedAt        time.Time
+	statusCode       int
+}
+
+func (s *Service) startAuditLogEntry(w http.ResponseWriter, r *http.Request, projectID, userID, serviceAccountID string, action authz.Action) *auditLogEntry {
+	return &auditLogEntry{
+		ResponseWriter:   w,
+		s:                s,
+		r:                r,
+		projectID:        projectID,
+		userID:           userID,
+		serviceAccountID: serviceAccountID,
+		action:           action,
+		summary:          summarizeRequestBody(r),
+		startedAt:        time.Now(),
+	}
+}
+
+func (e *auditLogEntry) WriteHeader(statusCode int) {
+	if e.statusCode == 0 {
+		e.statusCode = statusCode
+	}
+	e.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (e *auditLogEntry) Write(b []byte) (int, error) {
+	if e.statusCode == 0 {
+		e.statusCode = http.StatusOK
+	}
+	return e.ResponseWriter.Write(b)
+}
+
+func (e *auditLogEntry) Flush() {
+	if flusher, ok := e.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (e *auditLogEntry) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := e.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackUnsupported
+	}
+	if e.statusCode == 0 {
+		e.statusCode = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// finish records the entry. The request's context may already be done by
+// now, so it isn't used.
+func (e *auditLogEntry) finish() {
+	statusCode := e.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	if err := e.s.auditLogEntries.CreateAuditLogEntry(context.Background(), e.projectID,
+		e.userID, e.serviceAccountID, string(e.action), e.r.Method, e.r.URL.Path, e.summary,
+		e.s.trustedProxies.RemoteAddr(e.r), statusCode, time.Since(e.startedAt),
+	); err != nil {
+		log.WithField("project_id", e.projectID).
+			WithField("action", string(e.action)).
+			WithError(err).Error("create audit log entry")
+	}
+}
+
+// summarizeRequestBody returns the sorted top-level fields of a JSON request
+// body, leaving the body intact for the handler.
+func summarizeRequestBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	prefix, err := ioutil.ReadAll(io.LimitReader(r.Body, maxAuditLogSummaryBodySize))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(prefix, &fields); err != nil {
+		return ""
+	}
+
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// bulkDeviceOperation applies an operation to a set of devices. Each device
+// goes through the operation's single device endpoint, so it's authorized,
+// validated and audited the same as if it had been called directly.
+func (s *Service) bulkDeviceOperation(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var bulkDeviceOperationRequest models.BulkDeviceOperationRequest
+	if err := read(r, &bulkDeviceOperationRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if (len(bulkDeviceOperationRequest.Devices) == 0) == (bulkDeviceOperationRequest.Selector == "") {
+		http.Error(w, errBulkDevicesOrSelector.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(bulkDeviceOperationRequest.Devices) > maxBulkDevices {
+		http.Error(w, errTooManyBulkDevices.Error(), http.StatusBadRequest)
+		return
+	}
+
+	project := mux.Vars(r)["project"]
+
+	method, segments, body, err := bulkDeviceOperationEndpoint(bulkDeviceOperationRequest)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	devices := bulkDeviceOperationRequest.Devices
+	if bulkDeviceOperationRequest.Selector != "" {
+		filters, err := query.ParseSelector(bulkDeviceOperationRequest.Selector)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "parse selector").Error(), http.StatusBadRequest)
+			return
+		}
+
+		matchingDevices, err := s.devices.ListDevicesMatching(r.Context(), projectID, "", filters)
+		if err != nil {
+			log.WithError(err).Error("list devices matching")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, matchingDevices); err != nil {
+			log.WithError(err).Error("load device groups")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		matchingDevices, _, err = query.QueryDevices(matchingDevices, filters)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "filter devices").Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(matchingDevices) > maxBulkDevices {
+			http.Error(w, errTooManyBulkDevices.Error(), http.StatusBadRequest)
+			return
+		}
+
+		devices = make([]string, len(matchingDevices))
+		for i, device := range matchingDevices {
+			devices[i] = device.ID
+		}
+	}
+
+	response := models.BulkDeviceOperationResponse{
+		Results: make([]models.BulkDeviceOperationResult, 0, len(devices)),
+	}
+	for _, device := range devices {
+		result := s.applyBulkDeviceOperation(r, method, project, device, segments, body)
+		if result.StatusCode < http.StatusBadRequest {
+			response.SucceededCount++
+		} else {
+			response.FailedCount++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	utils.Respond(w, response)
+}
+
+// bulkDeviceOperationEndpoint returns the method, the path segments after
+// the device and the body of the single device endpoint for an operation.
+func bulkDeviceOperationEndpoint(req models.BulkDeviceOperationRequest) (string, []string, interface{}, error) {
+	switch req.Operation {
+	case models.BulkDeviceOperationSetLabel, models.BulkDeviceOperationSetEnvironmentVariable:
+		if req.Key == "" {
+			return "", nil, nil, errBulkOperationNeedsKey
+		}
+		segment := "labels"
+		if req.Operation == models.BulkDeviceOperationSetEnvironmentVariable {
+			segment = "environment"
+		}
+		return http.MethodPut, []string{segment}, keyValueRequest{
+			Key:   req.Key,
+			Value: req.Value,
+		}, nil
+	case models.BulkDeviceOperationDeleteLabel, models.BulkDeviceOperationDeleteEnvironmentVariable:
+		if req.Key == "" {
+			return "", nil, nil, errBulkOperationNeedsKey
+		}
+		segment := "labels"
+		if req.Operation == models.BulkDeviceOperationDeleteEnvironmentVariable {
+			segment = "environment"
+		}
+		return http.MethodDelete, []string{segment, req.Key}, nil, nil
+	case models.BulkDeviceOperationSetReleasePin:
+		if req.Application == "" {
+			return "", nil, nil, errBulkOperationNeedsApplication
+		}
+		if req.Release == "" {
+			return "", nil, nil, errBulkOperationNeedsRelease
+		}
+		return http.MethodPut, []string{"applications", req.Application, "releasepin"}, models.SetDeviceReleasePinRequest{
+			Release: req.Release,
+		}, nil
+	case models.BulkDeviceOperationDeleteReleasePin:
+		if req.Application == "" {
+			return "", nil, nil, errBulkOperationNeedsApplication
+		}
+		return http.MethodDelete, []string{"applications", req.Application, "releasepin"}, nil, nil
+	case models.BulkDeviceOperationDecommission:
+		return http.MethodPost, []string{"decommission"}, nil, nil
+	default:
+		return "", nil, nil, errUnknownBulkOperation
+	}
+}
+
+// applyBulkDeviceOperation serves the operation's single device endpoint
+// for a device, with the credentials the bulk request was made with.
+func (s *Service) applyBulkDeviceOperation(r *http.Request, method, project, device string,
+	segments []string, body interface{},
+) models.BulkDeviceOperationResult {
+	result := models.BulkDeviceOperationResult{
+		Device: device,
+	}
+
+	path := []string{"/api/projects", url.PathEscape(project), "devices", url.PathEscape(device)}
+	for _, segment := range segments {
+		path = append(path, url.PathEscape(segment))
+	}
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			result.StatusCode = http.StatusInternalServerError
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	deviceRequest, err := http.NewRequest(method, strings.Join(path, "/"), &buf)
+	if err != nil {
+		result.StatusCode = http.StatusInternalServerError
+		result.Error = err.Error()
+		return result
+	}
+	deviceRequest = deviceRequest.WithContext(r.Context())
+	for key, values := range r.Header {
+		deviceRequest.Header[key] = append([]string(nil), values...)
+	}
+	deviceRequest.RemoteAddr = r.RemoteAddr
+
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, deviceRequest)
+
+	result.StatusCode = recorder.Code
+	if result.StatusCode >= http.StatusBadRequest {
+		result.Error = strings.TrimSpace(recorder.Body.String())
+		if result.Error == "" {
+			result.Error = http.StatusText(result.StatusCode)
+		}
+	}
+
+	return result
+}
@@ -2,14 +2,19 @@ package service
 
 import (
 	"bufio"
+	"context"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httputil"
+	"strconv"
 	"strings"
 	"sync/atomic"
 
+	"github.com/apex/log"
 	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
 	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/controller/connman"
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/utils"
 	"github.com/function61/holepunch-server/pkg/wsconnadapter"
@@ -17,9 +22,31 @@ import (
 )
 
 func (s *Service) initiateDeviceConnection(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
-	s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
-		s.connman.Set(project.ID+device.ID, conn)
-	})
+	if device.PendingApproval {
+		http.Error(w, errDevicePendingApproval.Error(), http.StatusForbidden)
+		return
+	}
+
+	if r.Header.Get(models.DeviceConnectionProtocolHeader) != models.DeviceConnectionProtocolYamux {
+		s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
+			s.connman.Set(project.ID+device.ID, conn)
+		})
+		return
+	}
+
+	responseHeader := http.Header{}
+	responseHeader.Set(models.DeviceConnectionProtocolHeader, models.DeviceConnectionProtocolYamux)
+
+	// Upgrade responds with an error itself if it fails
+	conn, err := s.upgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		return
+	}
+
+	if err := s.connman.SetMultiplexed(project.ID+device.ID, wsconnadapter.New(conn)); err != nil {
+		log.WithError(err).Error("set multiplexed device connection")
+		conn.Close()
+	}
 }
 
 var currentSSHCount int64
@@ -30,9 +57,23 @@ func (s *Service) initiateSSH(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID,
 	deviceID string,
 ) {
+	endRemoteSession, ok := s.startRemoteSession(w, r, projectID)
+	if !ok {
+		return
+	}
+	defer endRemoteSession()
+
+	sessionRecordingID, err := s.createSessionRecording(r.Context(), projectID, deviceID,
+		models.SessionRecordingKindSSH, "", "", authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		log.WithError(err).Error("create session recording")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
 		s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
-			err := client.InitiateSSH(r.Context(), deviceConn)
+			err := client.InitiateSSH(r.Context(), deviceConn, sessionRecordingID)
 			if err != nil {
 				http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
 				return
@@ -51,17 +92,355 @@ func (s *Service) initiateSSH(w http.ResponseWriter, r *http.Request,
 	})
 }
 
+func (s *Service) initiateExec(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	endRemoteSession, ok := s.startRemoteSession(w, r, projectID)
+	if !ok {
+		return
+	}
+	defer endRemoteSession()
+
+	sessionRecordingID, err := s.createSessionRecording(r.Context(), projectID, deviceID,
+		models.SessionRecordingKindExec, applicationID, service, authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		log.WithError(err).Error("create session recording")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
+		s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+			err := client.InitiateExec(r.Context(), deviceConn, applicationID, service, sessionRecordingID)
+			if err != nil {
+				http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+				return
+			}
+
+			go io.Copy(deviceConn, conn)
+			io.Copy(conn, deviceConn)
+		})
+	})
+}
+
+// createSessionRecording returns the ID of a new session recording if the
+// project records sessions, or an empty ID if it doesn't.
+func (s *Service) createSessionRecording(ctx context.Context,
+	projectID, deviceID, kind, applicationID, service,
+	authenticatedUserID, authenticatedServiceAccountID string,
+) (string, error) {
+	sshConfig, err := s.sshConfigs.GetSSHConfig(ctx, projectID)
+	if err != nil {
+		return "", err
+	}
+	if !sshConfig.RecordSessions {
+		return "", nil
+	}
+
+	sessionRecording, err := s.sessionRecordings.CreateSessionRecording(ctx, projectID, deviceID,
+		kind, applicationID, service, authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		return "", err
+	}
+
+	return sessionRecording.ID, nil
+}
+
+func (s *Service) initiatePortForward(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	s.forwardPort(w, r, projectID, deviceID, "", "", port)
+}
+
+func (s *Service) initiateServicePortForward(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	s.forwardPort(w, r, projectID, deviceID, applicationID, service, port)
+}
+
+// initiateRemoteAccess forwards to the port a remote access profile's
+// server listens on by default, or the port query parameter when it's set.
+// The WebSocket carries the raw protocol, so browser clients like noVNC
+// can connect to it directly.
+func (s *Service) initiateRemoteAccess(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	vars := mux.Vars(r)
+	profile := vars["profile"]
+
+	port, ok := models.RemoteAccessProfilePorts[profile]
+	if !ok {
+		http.Error(w, "unknown remote access profile", http.StatusNotFound)
+		return
+	}
+
+	if portString := r.URL.Query().Get("port"); portString != "" {
+		var err error
+		port, err = strconv.Atoi(portString)
+		if err != nil {
+			http.Error(w, "invalid port", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.forwardPort(w, r, projectID, deviceID, "", "", port)
+}
+
+// forwardPort only upgrades to a WebSocket once the device has connected to
+// the port, so that failures can still be reported as regular responses.
+func (s *Service) forwardPort(w http.ResponseWriter, r *http.Request, projectID, deviceID, applicationID, service string, port int) {
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		portConn, err := client.InitiatePortForward(r.Context(), deviceConn, applicationID, service, port)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
+			go io.Copy(portConn, conn)
+			io.Copy(conn, portConn)
+		})
+	})
+}
+
 func (s *Service) initiateReboot(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID,
 	deviceID string,
 ) {
+	s.initiatePowerAction(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID,
+		deviceID, models.PowerActionReboot, client.InitiateReboot)
+}
+
+func (s *Service) initiateShutdown(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	s.initiatePowerAction(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID,
+		deviceID, models.PowerActionShutdown, client.InitiateShutdown)
+}
+
+func (s *Service) initiatePowerAction(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID, action string,
+	initiate func(ctx context.Context, deviceConn net.Conn, reason string) (*http.Response, error),
+) {
+	// The request body is optional
+	var powerActionRequest models.PowerActionRequest
+	if r.ContentLength != 0 {
+		if err := read(r, &powerActionRequest); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := initiate(r.Context(), deviceConn, powerActionRequest.Reason)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		log.WithField("project_id", projectID).
+			WithField("device_id", deviceID).
+			WithField("user_id", authenticatedUserID).
+			WithField("service_account_id", authenticatedServiceAccountID).
+			WithField("action", action).
+			WithField("reason", powerActionRequest.Reason).
+			WithField("status", resp.StatusCode).
+			Info("initiate power action")
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) downloadFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	path := r.URL.Query().Get(models.FilePathQueryParam)
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.DownloadFile(r.Context(), deviceConn, path)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		logFileTransfer(projectID, deviceID, authenticatedUserID, authenticatedServiceAccountID, path, resp).
+			Info("file download")
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) uploadFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	path := r.URL.Query().Get(models.FilePathQueryParam)
+
+	if r.ContentLength > models.MaxFileTransferSize {
+		http.Error(w, "file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, models.MaxFileTransferSize)
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.UploadFile(r.Context(), deviceConn, path,
+			r.Header.Get(models.FileModeHeader), body, r.ContentLength)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		logFileTransfer(projectID, deviceID, authenticatedUserID, authenticatedServiceAccountID, path, resp).
+			WithField("size", r.ContentLength).
+			Info("file upload")
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+// logFileTransfer returns an entry recording who transferred which file,
+// for auditing.
+func logFileTransfer(projectID, deviceID, authenticatedUserID, authenticatedServiceAccountID, path string, resp *http.Response) *log.Entry {
+	entry := log.WithField("project_id", projectID).
+		WithField("device_id", deviceID).
+		WithField("path", path).
+		WithField("status", resp.StatusCode)
+	if authenticatedUserID != "" {
+		entry = entry.WithField("user_id", authenticatedUserID)
+	} else {
+		entry = entry.WithField("service_account_id", authenticatedServiceAccountID)
+	}
+	if resp.StatusCode == http.StatusOK && resp.ContentLength >= 0 {
+		entry = entry.WithField("size", resp.ContentLength)
+	}
+	return entry
+}
+
+func (s *Service) browseFiles(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	vars := mux.Vars(r)
+	operation := vars["operation"]
+
+	switch operation {
+	case models.FileBrowserOperationRoots,
+		models.FileBrowserOperationStat,
+		models.FileBrowserOperationList,
+		models.FileBrowserOperationRead:
+	default:
+		http.Error(w, "unknown file browser operation", http.StatusNotFound)
+		return
+	}
+
+	path := r.URL.Query().Get(models.FilePathQueryParam)
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.BrowseFiles(r.Context(), deviceConn, operation, path)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		if operation == models.FileBrowserOperationRead {
+			logFileTransfer(projectID, deviceID, authenticatedUserID, authenticatedServiceAccountID, path, resp).
+				Info("file browser read")
+		}
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) writeBrowsedFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	path := r.URL.Query().Get(models.FilePathQueryParam)
+
+	if r.ContentLength > models.MaxFileTransferSize {
+		http.Error(w, "file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	body := http.MaxBytesReader(w, r.Body, models.MaxFileTransferSize)
+
 	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
-		resp, err := client.InitiateReboot(r.Context(), deviceConn)
+		resp, err := client.WriteBrowsedFile(r.Context(), deviceConn, path,
+			r.Header.Get(models.FileModeHeader), body, r.ContentLength)
 		if err != nil {
 			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
 			return
 		}
 
+		logFileTransfer(projectID, deviceID, authenticatedUserID, authenticatedServiceAccountID, path, resp).
+			WithField("size", r.ContentLength).
+			Info("file browser write")
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) listHostCommands(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.ListHostCommands(r.Context(), deviceConn)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) runHostCommand(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	vars := mux.Vars(r)
+	hostCommand := vars["hostcommand"]
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.RunHostCommand(r.Context(), deviceConn, hostCommand)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		log.WithField("project_id", projectID).
+			WithField("device_id", deviceID).
+			WithField("user_id", authenticatedUserID).
+			WithField("service_account_id", authenticatedServiceAccountID).
+			WithField("host_command", hostCommand).
+			WithField("status", resp.StatusCode).
+			Info("run host command")
+
 		utils.ProxyResponseFromDevice(w, resp)
 	})
 }
@@ -154,6 +533,21 @@ func (s *Service) agentMetrics(w http.ResponseWriter, r *http.Request,
 	})
 }
 
+func (s *Service) ingestedMetrics(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.GetIngestedMetrics(r.Context(), deviceConn)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		utils.ProxyResponseFromDevice(w, resp)
+	})
+}
+
 func (s *Service) serviceMetrics(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID,
 	applicationID, deviceID string,
@@ -186,6 +580,196 @@ func (s *Service) serviceMetrics(w http.ResponseWriter, r *http.Request,
 	})
 }
 
+func (s *Service) serviceStats(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.GetServiceStats(r.Context(), deviceConn, applicationID, service)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		utils.StreamResponseFromDevice(w, resp)
+	})
+}
+
+// serviceLogs streams a service's output from its device, as newline
+// delimited JSON chunks. It follows the output if follow is set, starting
+// from the last tail lines.
+func (s *Service) serviceLogs(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	var tail int
+	if tailParam := r.URL.Query().Get("tail"); tailParam != "" {
+		var err error
+		tail, err = strconv.Atoi(tailParam)
+		if err != nil || tail < 0 {
+			http.Error(w, errInvalidTail.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	follow := r.URL.Query().Get("follow") == "true"
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		resp, err := client.GetServiceLogs(r.Context(), deviceConn, applicationID, service, follow, tail)
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		utils.StreamResponseFromDevice(w, resp)
+	})
+}
+
+func (s *Service) deviceEndpoint(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	s.proxyDeviceEndpoint(w, r, projectID, deviceID, "", "")
+}
+
+func (s *Service) serviceEndpoint(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	s.proxyDeviceEndpoint(w, r, projectID, deviceID, applicationID, service)
+}
+
+// proxyDeviceEndpoint proxies HTTP requests to a port the project has
+// exposed through its device endpoints config. Each request gets its own
+// port forward so that nothing is kept open on the device between requests.
+func (s *Service) proxyDeviceEndpoint(w http.ResponseWriter, r *http.Request, projectID, deviceID, applicationID, service string) {
+	vars := mux.Vars(r)
+
+	port, err := strconv.Atoi(vars["port"])
+	if err != nil {
+		http.Error(w, "invalid port", http.StatusBadRequest)
+		return
+	}
+
+	deviceEndpointConfigs, err := s.deviceEndpointConfigs.GetDeviceEndpointConfigs(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get device endpoint configs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	exposed := false
+	for _, deviceEndpointConfig := range deviceEndpointConfigs {
+		if deviceEndpointConfig.ApplicationID == applicationID &&
+			deviceEndpointConfig.Service == service &&
+			deviceEndpointConfig.Port == port {
+			exposed = true
+			break
+		}
+	}
+	if !exposed {
+		http.Error(w, "device endpoint not found", http.StatusNotFound)
+		return
+	}
+
+	prefix := "/api/projects/" + vars["project"] + "/devices/" + vars["device"]
+	if applicationID != "" {
+		prefix += "/applications/" + vars["application"] + "/services/" + vars["service"]
+	}
+	prefix += "/port/" + vars["port"]
+
+	// Redirect to the trailing slash so that relative links resolve
+	// underneath the endpoint
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	if path == "" {
+		http.Redirect(w, r, prefix+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+			req.URL.Path = path
+			req.URL.RawPath = ""
+			req.Host = ""
+
+			// The device shouldn't see the user's controller credentials
+			req.Header.Del("Authorization")
+			removeCookie(req, sessionCookie)
+
+			req.Header.Set("X-Forwarded-Prefix", prefix)
+		},
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				deviceConn, err := s.connman.Dial(ctx, projectID+deviceID)
+				if err != nil {
+					return nil, err
+				}
+
+				conn, err := client.InitiatePortForward(ctx, deviceConn, applicationID, service, port)
+				if err != nil {
+					deviceConn.Close()
+					return nil, err
+				}
+
+				return conn, nil
+			},
+			DisableKeepAlives: true,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+		},
+	}
+
+	proxy.ServeHTTP(w, r)
+}
+
+func removeCookie(r *http.Request, name string) {
+	cookies := r.Cookies()
+	r.Header.Del("Cookie")
+	for _, cookie := range cookies {
+		if cookie.Name != name {
+			r.AddCookie(cookie)
+		}
+	}
+}
+
+// relayDeviceConnection lets other controllers sharing the store reach
+// devices connected to this one.
+func (s *Service) relayDeviceConnection(w http.ResponseWriter, r *http.Request) {
+	if !s.connman.RelayAuthorized(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	deviceConn, err := s.connman.DialLocal(r.Context(), key)
+	if err == connman.ErrNoConnection {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+		return
+	}
+	defer deviceConn.Close()
+
+	s.withHijackedWebSocketConnection(w, r, func(conn net.Conn) {
+		go io.Copy(deviceConn, conn)
+		io.Copy(conn, deviceConn)
+	})
+}
+
 func (s *Service) withHijackedWebSocketConnection(w http.ResponseWriter, r *http.Request, f func(net.Conn)) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+func validateDeviceView(request models.SetDeviceViewRequest) error {
+	seen := make(map[models.DeviceViewColumn]bool, len(request.Columns))
+	for _, column := range request.Columns {
+		if !validDeviceViewColumn(column) {
+			return fmt.Errorf("unknown device view column %q", column)
+		}
+		if seen[column] {
+			return errDuplicateDeviceViewColumn
+		}
+		seen[column] = true
+	}
+	return query.ValidateQuery(request.Query)
+}
+
+func validDeviceViewColumn(column models.DeviceViewColumn) bool {
+	for _, c := range models.DeviceViewColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) createDeviceView(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var createDeviceViewRequest models.SetDeviceViewRequest
+	if err := read(r, &createDeviceViewRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateDeviceView(createDeviceViewRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.deviceViews.LookupDeviceView(r.Context(), createDeviceViewRequest.Name, projectID); err == nil {
+		http.Error(w, store.ErrDeviceViewNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrDeviceViewNotFound {
+		log.WithError(err).Error("lookup device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceView, err := s.deviceViews.CreateDeviceView(r.Context(), projectID,
+		createDeviceViewRequest.Name, createDeviceViewRequest.Description,
+		createDeviceViewRequest.Query, createDeviceViewRequest.Columns,
+		authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		log.WithError(err).Error("create device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceView)
+}
+
+func (s *Service) getDeviceView(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceViewID string,
+) {
+	deviceView, err := s.deviceViews.GetDeviceView(r.Context(), deviceViewID, projectID)
+	if err == store.ErrDeviceViewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceView)
+}
+
+func (s *Service) listDeviceViews(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	deviceViews, err := s.deviceViews.ListDeviceViews(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list device views")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceViews)
+}
+
+func (s *Service) updateDeviceView(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceViewID string,
+) {
+	var updateDeviceViewRequest models.SetDeviceViewRequest
+	if err := read(r, &updateDeviceViewRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateDeviceView(updateDeviceViewRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.deviceViews.GetDeviceView(r.Context(), deviceViewID, projectID); err == store.ErrDeviceViewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if deviceView, err := s.deviceViews.LookupDeviceView(r.Context(),
+		updateDeviceViewRequest.Name, projectID); err == nil && deviceView.ID != deviceViewID {
+		http.Error(w, store.ErrDeviceViewNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrDeviceViewNotFound {
+		log.WithError(err).Error("lookup device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceView, err := s.deviceViews.UpdateDeviceView(r.Context(), deviceViewID, projectID,
+		updateDeviceViewRequest.Name, updateDeviceViewRequest.Description,
+		updateDeviceViewRequest.Query, updateDeviceViewRequest.Columns)
+	if err != nil {
+		log.WithError(err).Error("update device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceView)
+}
+
+func (s *Service) deleteDeviceView(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceViewID string,
+) {
+	if err := s.deviceViews.DeleteDeviceView(r.Context(), deviceViewID, projectID); err != nil {
+		log.WithError(err).Error("delete device view")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
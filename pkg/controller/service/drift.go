@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/drift"
+	"github.com/deviceplane/deviceplane/pkg/controller/maintenance"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/rollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/scheduling"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// getDriftReport compares the releases the project's devices, narrowed down
+// by the same filters and selectors as listDevices, should run with the ones
+// they report running.
+func (s *Service) getDriftReport(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	filters, err := filtersFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	devices, err := s.devices.ListDevicesMatching(r.Context(), projectID, "", filters)
+	if err != nil {
+		log.WithError(err).Error("list devices matching")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	groups, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices)
+	if err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(filters) != 0 {
+		devices, _, err = query.QueryDevices(devices, filters)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "filter devices").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	deviceDrifts, err := s.deviceDrifts(r.Context(), projectID, devices, groups, time.Now())
+	if err != nil {
+		log.WithError(err).Error("get device drifts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, drift.Report(deviceDrifts))
+}
+
+func (s *Service) getDeviceDrift(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	device, err := s.devices.GetDevice(r.Context(), deviceID, projectID)
+	if err == store.ErrDeviceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	devices := []models.Device{*device}
+	groups, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices)
+	if err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceDrifts, err := s.deviceDrifts(r.Context(), projectID, devices, groups, time.Now())
+	if err != nil {
+		log.WithError(err).Error("get device drift")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceDrifts[0])
+}
+
+// deviceDrifts works out the release of each application that devices
+// should run the same way as their bundles do, and compares them with what
+// the devices report. The devices' groups have to be loaded.
+func (s *Service) deviceDrifts(ctx context.Context, projectID string,
+	devices []models.Device, groups []models.DeviceGroup, now time.Time,
+) ([]models.DeviceDrift, error) {
+	applications, err := s.applications.ListApplications(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list applications")
+	}
+
+	maintenanceWindowsConfig, err := s.maintenanceWindowsConfigs.GetMaintenanceWindowsConfig(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get maintenance windows config")
+	}
+
+	held := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		allowed, err := maintenance.UpdatesAllowed(device, groups, *maintenanceWindowsConfig, now)
+		if err != nil {
+			return nil, errors.Wrap(err, "evaluate maintenance windows")
+		}
+		held[device.ID] = !allowed
+	}
+
+	desired := make(map[string][]drift.Desired, len(devices))
+	for _, application := range applications {
+		scheduledDevices, err := scheduling.GetScheduledDevices(devices, application.SchedulingRule)
+		if err != nil {
+			return nil, errors.Wrap(err, "evaluate application scheduling rule")
+		}
+		if len(scheduledDevices) == 0 {
+			continue
+		}
+
+		latestRollout, err := s.rollouts.GetLatestRollout(ctx, projectID, application.ID)
+		if err == store.ErrRolloutNotFound {
+			latestRollout = nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "get latest rollout")
+		}
+
+		pins, err := s.deviceReleasePins.ListDeviceReleasePins(ctx, projectID, application.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list device release pins")
+		}
+		pinsByDevice := make(map[string]models.DeviceReleasePin, len(pins))
+		for _, pin := range pins {
+			pinsByDevice[pin.DeviceID] = pin
+		}
+
+		// Most devices are scheduled onto the same few releases, such as
+		// latest
+		releaseIDs := make(map[string]string)
+		releases := make(map[string]*models.Release)
+		getRelease := func(id string) (*models.Release, error) {
+			release, ok := releases[id]
+			if !ok {
+				var err error
+				release, err = s.releases.GetRelease(ctx, id, projectID, application.ID)
+				if err == store.ErrReleaseNotFound {
+					release = nil
+				} else if err != nil {
+					return nil, errors.Wrap(err, "get release")
+				}
+				releases[id] = release
+			}
+			return release, nil
+		}
+
+		for _, scheduledDevice := range scheduledDevices {
+			device := scheduledDevice.Device
+
+			releaseID, ok := releaseIDs[scheduledDevice.ReleaseID]
+			if !ok {
+				scheduledRelease, err := utils.GetReleaseByIdentifier(s.releases, ctx, projectID, application.ID, scheduledDevice.ReleaseID)
+				if err == nil {
+					releaseID = scheduledRelease.ID
+				} else if err != store.ErrReleaseNotFound {
+					return nil, errors.Wrap(err, "get release by identifier")
+				}
+				releaseIDs[scheduledDevice.ReleaseID] = releaseID
+			}
+			if releaseID == "" {
+				continue
+			}
+
+			desiredReleaseID, err := rollout.DesiredRelease(device, releaseID, latestRollout)
+			if err != nil {
+				return nil, errors.Wrap(err, "evaluate release rollout")
+			}
+
+			// Devices in a rollout are given the grace period from the start
+			// of its current step, which is lenient to those reached by
+			// earlier steps
+			var since time.Time
+			if latestRollout != nil && latestRollout.ReleaseID == desiredReleaseID {
+				since = latestRollout.StepStartedAt
+			}
+			if pin, ok := pinsByDevice[device.ID]; ok {
+				desiredReleaseID = pin.ReleaseID
+				since = pin.CreatedAt
+			}
+			if desiredReleaseID == "" {
+				continue
+			}
+
+			release, err := getRelease(desiredReleaseID)
+			if err != nil {
+				return nil, err
+			}
+			if release == nil {
+				continue
+			}
+			if release.CreatedAt.After(since) {
+				since = release.CreatedAt
+			}
+
+			desired[device.ID] = append(desired[device.ID], drift.Desired{
+				Application: application,
+				Release:     *release,
+				Since:       since,
+				Held:        held[device.ID],
+			})
+		}
+	}
+
+	deviceDrifts := make([]models.DeviceDrift, 0, len(devices))
+	for _, device := range devices {
+		applicationStatuses, err := s.deviceApplicationStatuses.ListDeviceApplicationStatuses(ctx, projectID, device.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list device application statuses")
+		}
+
+		serviceStatuses, err := s.deviceServiceStatuses.ListDeviceServiceStatuses(ctx, projectID, device.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list device service statuses")
+		}
+
+		deviceDrifts = append(deviceDrifts, drift.Device(device, desired[device.ID], applicationStatuses, serviceStatuses, now))
+	}
+
+	return deviceDrifts, nil
+}
+
+// filtersFromRequest returns the filters and selectors a device listing was
+// given.
+func filtersFromRequest(r *http.Request) ([]models.Filter, error) {
+	filters, err := query.FiltersFromQuery(r.URL.Query())
+	if err != nil {
+		return nil, errors.Wrap(err, "get filters from query")
+	}
+	for _, selector := range r.URL.Query()[models.SelectorQueryParam] {
+		selectorFilters, err := query.ParseSelector(selector)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse selector")
+		}
+		filters = append(filters, selectorFilters...)
+	}
+	return filters, nil
+}
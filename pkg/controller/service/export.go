@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Exports are flushed every this many devices, so large inventories start
+// downloading before they've all been looked up
+const deviceExportFlushInterval = 100
+
+var deviceExportCSVHeader = []string{
+	"id", "name", "status", "ip_address", "agent_version", "os", "kernel_version",
+	"labels", "releases", "last_seen_at", "created_at",
+}
+
+// exportDevices streams the project's devices, narrowed down by the same
+// filters and selectors as listDevices, as CSV or newline delimited JSON.
+func (s *Service) exportDevices(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	format := models.ExportFormat(r.URL.Query().Get(models.ExportFormatQueryParam))
+	switch format {
+	case "":
+		format = models.ExportFormatCSV
+	case models.ExportFormatCSV, models.ExportFormatNDJSON:
+	default:
+		http.Error(w, errUnknownExportFormat.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters, err := filtersFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	devices, err := s.devices.ListDevicesMatching(r.Context(), projectID, "", filters)
+	if err != nil {
+		log.WithError(err).Error("list devices matching")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(filters) != 0 {
+		devices, _, err = query.QueryDevices(devices, filters)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "filter devices").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	applications, err := s.applications.ListApplications(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list applications")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	applicationNames := make(map[string]string, len(applications))
+	for _, application := range applications {
+		applicationNames[application.ID] = application.Name
+	}
+
+	filename := "devices-" + time.Now().UTC().Format("20060102-150405") + "." + string(format)
+	switch format {
+	case models.ExportFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+	case models.ExportFormatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+
+	if format == models.ExportFormatCSV {
+		if err := csvWriter.Write(deviceExportCSVHeader); err != nil {
+			return
+		}
+	}
+
+	// Release numbers are looked up once however many devices run them
+	releaseNumbers := make(map[string]uint32)
+
+	for i, device := range devices {
+		record, err := s.deviceExportRecord(r.Context(), device, applicationNames, releaseNumbers)
+		if err != nil {
+			// The response has already started, so all that can be done is
+			// to cut it short
+			log.WithError(err).Error("get device export record")
+			return
+		}
+
+		switch format {
+		case models.ExportFormatCSV:
+			err = csvWriter.Write(deviceExportCSVRecord(*record))
+		case models.ExportFormatNDJSON:
+			err = encoder.Encode(record)
+		}
+		if err != nil {
+			return
+		}
+
+		if (i+1)%deviceExportFlushInterval == 0 {
+			csvWriter.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+
+	csvWriter.Flush()
+}
+
+func (s *Service) deviceExportRecord(ctx context.Context, device models.Device,
+	applicationNames map[string]string, releaseNumbers map[string]uint32,
+) (*models.DeviceExportRecord, error) {
+	deviceApplicationStatuses, err := s.deviceApplicationStatuses.ListDeviceApplicationStatuses(ctx, device.ProjectID, device.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list device application statuses")
+	}
+
+	releases := make(map[string]uint32, len(deviceApplicationStatuses))
+	for _, deviceApplicationStatus := range deviceApplicationStatuses {
+		applicationName, ok := applicationNames[deviceApplicationStatus.ApplicationID]
+		if !ok {
+			continue
+		}
+
+		number, ok := releaseNumbers[deviceApplicationStatus.CurrentReleaseID]
+		if !ok {
+			release, err := s.releases.GetRelease(ctx, deviceApplicationStatus.CurrentReleaseID,
+				device.ProjectID, deviceApplicationStatus.ApplicationID)
+			if err == store.ErrReleaseNotFound {
+				continue
+			} else if err != nil {
+				return nil, errors.Wrap(err, "get release")
+			}
+			number = release.Number
+			releaseNumbers[deviceApplicationStatus.CurrentReleaseID] = number
+		}
+
+		releases[applicationName] = number
+	}
+
+	return &models.DeviceExportRecord{
+		ID:            device.ID,
+		Name:          device.Name,
+		Status:        device.Status,
+		IPAddress:     device.Info.IPAddress,
+		AgentVersion:  device.Info.AgentVersion,
+		OS:            device.Info.OSRelease.PrettyName,
+		KernelVersion: device.Info.KernelVersion,
+		Labels:        device.Labels,
+		Releases:      releases,
+		LastSeenAt:    device.LastSeenAt,
+		CreatedAt:     device.CreatedAt,
+	}, nil
+}
+
+// deviceExportCSVRecord flattens a record into the columns of
+// deviceExportCSVHeader. Labels and releases are written as sorted
+// key=value pairs separated by semicolons.
+func deviceExportCSVRecord(record models.DeviceExportRecord) []string {
+	labels := make([]string, 0, len(record.Labels))
+	for key, value := range record.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	releases := make([]string, 0, len(record.Releases))
+	for application, number := range record.Releases {
+		releases = append(releases, application+"="+strconv.FormatUint(uint64(number), 10))
+	}
+	sort.Strings(releases)
+
+	return []string{
+		record.ID,
+		record.Name,
+		string(record.Status),
+		record.IPAddress,
+		record.AgentVersion,
+		record.OS,
+		record.KernelVersion,
+		strings.Join(labels, ";"),
+		strings.Join(releases, ";"),
+		record.LastSeenAt.UTC().Format(time.RFC3339),
+		record.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
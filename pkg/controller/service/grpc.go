@@ -0,0 +1,586 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/grpcapi"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+	"github.com/deviceplane/deviceplane/pkg/validator"
+)
+
+// grpcServer serves the gRPC API. Calls are authenticated, authorized, rate
+// limited and recorded in the audit log the same way as REST requests.
+type grpcServer struct {
+	grpcapi.UnimplementedDeviceplaneServer
+
+	s *Service
+}
+
+// GRPCServer returns a server for the gRPC API.
+func (s *Service) GRPCServer() *grpc.Server {
+	server := grpc.NewServer()
+	grpcapi.RegisterDeviceplaneServer(server, &grpcServer{s: s})
+	return server
+}
+
+// grpcStatusCodes are the HTTP status codes that audit log entries record
+// for the codes calls end with.
+var grpcStatusCodes = map[codes.Code]int{
+	codes.OK:                http.StatusOK,
+	codes.InvalidArgument:   http.StatusBadRequest,
+	codes.Unauthenticated:   http.StatusUnauthorized,
+	codes.PermissionDenied:  http.StatusForbidden,
+	codes.NotFound:          http.StatusNotFound,
+	codes.ResourceExhausted: http.StatusTooManyRequests,
+}
+
+// grpcCall is a call that has been authorized to take an action in a
+// project.
+type grpcCall struct {
+	g                *grpcServer
+	ctx              context.Context
+	projectID        string
+	userID           string
+	serviceAccountID string
+	action           authz.Action
+	summary          string
+	startedAt        time.Time
+}
+
+// finish records the call in the audit log if its action isn't a read. The
+// call's context may already be done by now, so it isn't used.
+func (c *grpcCall) finish(err error) {
+	if authz.IsReadAction(c.action) {
+		return
+	}
+
+	statusCode, ok := grpcStatusCodes[status.Code(err)]
+	if !ok {
+		statusCode = http.StatusInternalServerError
+	}
+	method, _ := grpc.Method(c.ctx)
+
+	if err := c.g.s.auditLogEntries.CreateAuditLogEntry(context.Background(), c.projectID,
+		c.userID, c.serviceAccountID, string(c.action), http.MethodPost, method, c.summary,
+		c.g.remoteAddr(c.ctx), statusCode, time.Since(c.startedAt),
+	); err != nil {
+		log.WithField("project_id", c.projectID).
+			WithField("action", string(c.action)).
+			WithError(err).Error("create audit log entry")
+	}
+}
+
+// summarizeRequest returns the names of the fields set in a request, like
+// summarizeRequestBody does for REST requests.
+func summarizeRequest(req proto.Message) string {
+	var names []string
+	req.ProtoReflect().Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		names = append(names, field.JSONName())
+		return true
+	})
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func internalError(err error, msg string) error {
+	log.WithError(err).Error(msg)
+	return status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+}
+
+// remoteAddr returns the address a call came from, trusting the
+// X-Forwarded-For metadata set by trusted proxies.
+func (g *grpcServer) remoteAddr(ctx context.Context) string {
+	r := &http.Request{Header: make(http.Header)}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, forwardedFor := range md.Get("x-forwarded-for") {
+		r.Header.Add("X-Forwarded-For", forwardedFor)
+	}
+	return g.s.trustedProxies.RemoteAddr(r)
+}
+
+// rateLimit takes a call from the rate limit of a key, which identifies
+// whoever made it.
+func (g *grpcServer) rateLimit(key string) error {
+	if g.s.rateLimiter == nil {
+		return nil
+	}
+	if !g.s.rateLimiter.Allow(key, time.Now()).Allowed {
+		g.s.st.Incr("rate_limited", nil, 1)
+		return status.Error(codes.ResourceExhausted, errRateLimited.Error())
+	}
+	return nil
+}
+
+// unauthenticated returns the error for a call that failed authentication.
+// Failures count against the rate limit of the address they came from, as
+// they do for REST requests.
+func (g *grpcServer) unauthenticated(ctx context.Context, err error) error {
+	if err := g.rateLimit("addr:" + g.remoteAddr(ctx)); err != nil {
+		return err
+	}
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, errInvalidAccessKey.Error())
+}
+
+// authenticate returns the user, or the service account access key, that
+// a call was made with. The access key is passed as "authorization: Bearer
+// <access key>" metadata.
+func (g *grpcServer) authenticate(ctx context.Context) (string, *models.ServiceAccountAccessKey, error) {
+	var accessKeyValue string
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, authorization := range md.Get("authorization") {
+		if strings.HasPrefix(authorization, "Bearer ") {
+			accessKeyValue = strings.TrimPrefix(authorization, "Bearer ")
+		}
+	}
+
+	userID, serviceAccountAccessKey, err := g.s.validateAccessKey(ctx, accessKeyValue)
+	if err == errInvalidAccessKey {
+		return "", nil, g.unauthenticated(ctx, nil)
+	} else if err == errServiceAccountAccessKeyExpired {
+		return "", nil, g.unauthenticated(ctx, err)
+	} else if err != nil {
+		return "", nil, internalError(err, "validate access key")
+	}
+
+	if userID != "" {
+		user, err := g.s.users.GetUser(ctx, userID)
+		if err != nil {
+			return "", nil, internalError(err, "get user")
+		}
+		if !user.RegistrationCompleted {
+			return "", nil, status.Error(codes.PermissionDenied, "registration not completed")
+		}
+		if err := g.rateLimit("user:" + userID); err != nil {
+			return "", nil, err
+		}
+		return userID, nil, nil
+	}
+
+	if _, err := g.s.serviceAccounts.GetServiceAccount(ctx,
+		serviceAccountAccessKey.ServiceAccountID, serviceAccountAccessKey.ProjectID); err != nil {
+		return "", nil, internalError(err, "get service account")
+	}
+	if err := g.rateLimit("serviceaccount:" + serviceAccountAccessKey.ServiceAccountID); err != nil {
+		return "", nil, err
+	}
+	return "", serviceAccountAccessKey, nil
+}
+
+// authorize authenticates a call and checks that it's allowed to take an
+// action in a project. The device and application are the ones the action
+// is on, by ID or name, if any. Calls whose action isn't a read are
+// recorded in the audit log once they're finished, including denied ones.
+func (g *grpcServer) authorize(ctx context.Context, req proto.Message, project string,
+	requestedResource authz.Resource, requestedAction authz.Action, device, application string,
+) (*grpcCall, error) {
+	userID, serviceAccountAccessKey, err := g.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var serviceAccountID string
+	if serviceAccountAccessKey != nil {
+		serviceAccountID = serviceAccountAccessKey.ServiceAccountID
+	}
+
+	if project == "" {
+		return nil, status.Error(codes.InvalidArgument, "project is required")
+	}
+	projectID, err := g.s.lookupProjectID(ctx, project)
+	if err == store.ErrProjectNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, internalError(err, "lookup project")
+	}
+
+	call := &grpcCall{
+		g:                g,
+		ctx:              ctx,
+		projectID:        projectID,
+		userID:           userID,
+		serviceAccountID: serviceAccountID,
+		action:           requestedAction,
+		summary:          summarizeRequest(req),
+		startedAt:        time.Now(),
+	}
+
+	switch err = g.s.authorize(ctx, projectID, userID, serviceAccountAccessKey,
+		requestedResource, requestedAction, device, application,
+	); err {
+	case nil:
+		return call, nil
+	case store.ErrMembershipNotFound:
+		err = status.Error(codes.NotFound, err.Error())
+	case errRestrictedAccessKey:
+		err = status.Error(codes.PermissionDenied, err.Error())
+	case errForbidden:
+		err = status.Error(codes.PermissionDenied, err.Error())
+	default:
+		log.WithField("user_id", userID).
+			WithField("service_account_id", serviceAccountID).
+			WithField("project_id", projectID).
+			WithError(err).
+			Error("authorize")
+		err = status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+	call.finish(err)
+	return nil, err
+}
+
+func (g *grpcServer) getDevice(ctx context.Context, projectID, device string) (*models.Device, error) {
+	if device == "" {
+		return nil, status.Error(codes.InvalidArgument, "device is required")
+	}
+
+	var d *models.Device
+	var err error
+	if strings.Contains(device, "_") {
+		d, err = g.s.devices.GetDevice(ctx, device, projectID)
+	} else {
+		d, err = g.s.devices.LookupDevice(ctx, device, projectID)
+	}
+	if err == store.ErrDeviceNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, internalError(err, "get device")
+	}
+	return d, nil
+}
+
+func (g *grpcServer) getApplication(ctx context.Context, projectID, application string) (*models.Application, error) {
+	if application == "" {
+		return nil, status.Error(codes.InvalidArgument, "application is required")
+	}
+
+	var a *models.Application
+	var err error
+	if strings.Contains(application, "_") {
+		a, err = g.s.applications.GetApplication(ctx, application, projectID)
+	} else {
+		a, err = g.s.applications.LookupApplication(ctx, application, projectID)
+	}
+	if err == store.ErrApplicationNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, internalError(err, "get application")
+	}
+	return a, nil
+}
+
+func (g *grpcServer) ListDevices(ctx context.Context, req *grpcapi.ListDevicesRequest) (*grpcapi.ListDevicesResponse, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDevices, authz.ActionListDevices, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []models.Filter
+	if req.Selector != "" {
+		filters, err = query.ParseSelector(req.Selector)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	var devices []models.Device
+	if req.Search == "" {
+		devices, err = g.s.devices.ListDevicesMatching(ctx, call.projectID, "", filters)
+	} else {
+		devices, err = g.s.devices.ListDevices(ctx, call.projectID, req.Search)
+	}
+	if err != nil {
+		return nil, internalError(err, "list devices")
+	}
+
+	if _, err := devicegroups.Load(ctx, g.s.deviceGroups, call.projectID, devices); err != nil {
+		return nil, internalError(err, "load device groups")
+	}
+
+	if len(filters) != 0 {
+		devices, _, err = query.QueryDevices(devices, filters)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	resp := &grpcapi.ListDevicesResponse{}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, deviceToProto(device))
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) GetDevice(ctx context.Context, req *grpcapi.GetDeviceRequest) (*grpcapi.Device, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDevices, authz.ActionGetDevice, req.Device, "")
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := g.getDevice(ctx, call.projectID, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := devicegroups.LoadDevice(ctx, g.s.deviceGroups, call.projectID, device); err != nil {
+		return nil, internalError(err, "load device groups")
+	}
+
+	return deviceToProto(*device), nil
+}
+
+func (g *grpcServer) SetDeviceLabel(ctx context.Context, req *grpcapi.SetDeviceLabelRequest) (resp *grpcapi.SetDeviceLabelResponse, err error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDeviceLabels, authz.ActionSetDeviceLabel, req.Device, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { call.finish(err) }()
+
+	setDeviceLabelRequest := struct {
+		Key   string `validate:"labelkey"`
+		Value string `validate:"labelvalue"`
+	}{req.Key, req.Value}
+	if err := validator.Validate(setDeviceLabelRequest); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if strings.HasPrefix(req.Key, models.SystemLabelPrefix) {
+		return nil, status.Error(codes.InvalidArgument, errSystemLabel.Error())
+	}
+
+	device, err := g.getDevice(ctx, call.projectID, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := g.s.devices.SetDeviceLabel(ctx, device.ID, call.projectID, req.Key, req.Value)
+	if err != nil {
+		return nil, internalError(err, "set device label")
+	}
+
+	return &grpcapi.SetDeviceLabelResponse{
+		Key:   req.Key,
+		Value: *value,
+	}, nil
+}
+
+func (g *grpcServer) DeleteDeviceLabel(ctx context.Context, req *grpcapi.DeleteDeviceLabelRequest) (resp *grpcapi.DeleteDeviceLabelResponse, err error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDeviceLabels, authz.ActionDeleteDeviceLabel, req.Device, "")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { call.finish(err) }()
+
+	device, err := g.getDevice(ctx, call.projectID, req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.s.devices.DeleteDeviceLabel(ctx, device.ID, call.projectID, req.Key); err != nil {
+		return nil, internalError(err, "delete device label")
+	}
+
+	return &grpcapi.DeleteDeviceLabelResponse{}, nil
+}
+
+func (g *grpcServer) ListApplications(ctx context.Context, req *grpcapi.ListApplicationsRequest) (*grpcapi.ListApplicationsResponse, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceApplications, authz.ActionListApplications, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	applications, err := g.s.applications.ListApplications(ctx, call.projectID)
+	if err != nil {
+		return nil, internalError(err, "list applications")
+	}
+
+	resp := &grpcapi.ListApplicationsResponse{}
+	for _, application := range applications {
+		resp.Applications = append(resp.Applications, applicationToProto(application))
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) GetApplication(ctx context.Context, req *grpcapi.GetApplicationRequest) (*grpcapi.Application, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceApplications, authz.ActionGetApplication, "", req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	application, err := g.getApplication(ctx, call.projectID, req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	return applicationToProto(*application), nil
+}
+
+func (g *grpcServer) ListReleases(ctx context.Context, req *grpcapi.ListReleasesRequest) (*grpcapi.ListReleasesResponse, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceReleases, authz.ActionListReleases, "", req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	application, err := g.getApplication(ctx, call.projectID, req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	releases, err := g.s.releases.ListReleases(ctx, call.projectID, application.ID)
+	if err != nil {
+		return nil, internalError(err, "list releases")
+	}
+
+	resp := &grpcapi.ListReleasesResponse{}
+	for _, release := range releases {
+		resp.Releases = append(resp.Releases, releaseToProto(release))
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) GetRelease(ctx context.Context, req *grpcapi.GetReleaseRequest) (*grpcapi.Release, error) {
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceReleases, authz.ActionGetRelease, "", req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	application, err := g.getApplication(ctx, call.projectID, req.Application)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Release == "" {
+		return nil, status.Error(codes.InvalidArgument, "release is required")
+	}
+	release, err := utils.GetReleaseByIdentifier(g.s.releases, ctx, call.projectID, application.ID, req.Release)
+	if err == store.ErrReleaseNotFound {
+		return nil, status.Error(codes.NotFound, err.Error())
+	} else if err != nil {
+		return nil, internalError(err, "get release")
+	}
+
+	return releaseToProto(*release), nil
+}
+
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func deviceToProto(device models.Device) *grpcapi.Device {
+	return &grpcapi.Device{
+		Id:              device.ID,
+		ProjectId:       device.ProjectID,
+		Name:            device.Name,
+		CreatedAt:       timestampToProto(device.CreatedAt),
+		LastSeenAt:      timestampToProto(device.LastSeenAt),
+		Status:          string(device.Status),
+		Labels:          device.Labels,
+		AgentVersion:    device.Info.AgentVersion,
+		IpAddress:       device.Info.IPAddress,
+		PendingApproval: device.PendingApproval,
+		Decommissioning: device.Decommissioning,
+		Groups:          device.Groups,
+	}
+}
+
+func applicationToProto(application models.Application) *grpcapi.Application {
+	return &grpcapi.Application{
+		Id:          application.ID,
+		ProjectId:   application.ProjectID,
+		Name:        application.Name,
+		Description: application.Description,
+		CreatedAt:   timestampToProto(application.CreatedAt),
+	}
+}
+
+func releaseToProto(release models.Release) *grpcapi.Release {
+	r := &grpcapi.Release{
+		Id:              release.ID,
+		ProjectId:       release.ProjectID,
+		ApplicationId:   release.ApplicationID,
+		Number:          release.Number,
+		CreatedAt:       timestampToProto(release.CreatedAt),
+		RawConfig:       release.RawConfig,
+		SourceReleaseId: release.SourceReleaseID,
+		Notes:           release.Notes,
+		Source:          release.Source,
+	}
+	if release.CreatedByUserID != nil {
+		r.CreatedByUserId = *release.CreatedByUserID
+	}
+	if release.CreatedByServiceAccountID != nil {
+		r.CreatedByServiceAccountId = *release.CreatedByServiceAccountID
+	}
+	return r
+}
+
+func rolloutToProto(rollout models.Rollout) *grpcapi.Rollout {
+	return &grpcapi.Rollout{
+		Id:                rollout.ID,
+		ApplicationId:     rollout.ApplicationID,
+		ReleaseId:         rollout.ReleaseID,
+		PreviousReleaseId: rollout.PreviousReleaseID,
+		CreatedAt:         timestampToProto(rollout.CreatedAt),
+		Step:              int32(rollout.Step),
+		Status:            string(rollout.Status),
+		StatusReason:      rollout.StatusReason,
+	}
+}
+
+func eventToProto(event models.Event) *grpcapi.Event {
+	e := &grpcapi.Event{
+		Id:        event.ID,
+		Type:      string(event.Type),
+		CreatedAt: timestampToProto(event.CreatedAt),
+		ProjectId: event.ProjectID,
+	}
+	if event.Device != nil {
+		e.Device = deviceToProto(*event.Device)
+	}
+	if event.Release != nil {
+		e.Release = releaseToProto(*event.Release)
+	}
+	if event.Rollout != nil {
+		e.Rollout = rolloutToProto(*event.Rollout)
+	}
+	if event.ApplicationStatus != nil {
+		e.ApplicationStatus = &grpcapi.ApplicationStatus{
+			DeviceId:         event.ApplicationStatus.DeviceID,
+			ApplicationId:    event.ApplicationStatus.ApplicationID,
+			CurrentReleaseId: event.ApplicationStatus.CurrentReleaseID,
+		}
+	}
+	if event.ServiceStatus != nil {
+		e.ServiceStatus = &grpcapi.ServiceStatus{
+			DeviceId:         event.ServiceStatus.DeviceID,
+			ApplicationId:    event.ServiceStatus.ApplicationID,
+			Service:          event.ServiceStatus.Service,
+			CurrentReleaseId: event.ServiceStatus.CurrentReleaseID,
+		}
+	}
+	return e
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/yamux"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+	"github.com/deviceplane/deviceplane/pkg/controller/connman"
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/grpcapi"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+type fakeDevices struct {
+	store.Devices
+	devices map[string]models.Device
+}
+
+func (f *fakeDevices) GetDevice(ctx context.Context, deviceID, projectID string) (*models.Device, error) {
+	device, ok := f.devices[deviceID]
+	if !ok || device.ProjectID != projectID {
+		return nil, store.ErrDeviceNotFound
+	}
+	return &device, nil
+}
+
+func (f *fakeDevices) LookupDevice(ctx context.Context, name, projectID string) (*models.Device, error) {
+	for _, device := range f.devices {
+		if device.Name == name && device.ProjectID == projectID {
+			return &device, nil
+		}
+	}
+	return nil, store.ErrDeviceNotFound
+}
+
+func (f *fakeDevices) SetDeviceLabel(ctx context.Context, deviceID, projectID, key, value string) (*string, error) {
+	f.devices[deviceID].Labels[key] = value
+	return &value, nil
+}
+
+type fakeDeviceGroups struct {
+	store.DeviceGroups
+}
+
+func (f *fakeDeviceGroups) ListDeviceGroups(ctx context.Context, projectID string) ([]models.DeviceGroup, error) {
+	return nil, nil
+}
+
+func (f *fakeDeviceGroups) ListDeviceGroupMembers(ctx context.Context, projectID string) ([]models.DeviceGroupMember, error) {
+	return nil, nil
+}
+
+type fakeApplications struct {
+	store.Applications
+	applications map[string]models.Application
+}
+
+func (f *fakeApplications) GetApplication(ctx context.Context, id, projectID string) (*models.Application, error) {
+	application, ok := f.applications[id]
+	if !ok || application.ProjectID != projectID {
+		return nil, store.ErrApplicationNotFound
+	}
+	return &application, nil
+}
+
+func (f *fakeApplications) LookupApplication(ctx context.Context, name, projectID string) (*models.Application, error) {
+	for _, application := range f.applications {
+		if application.Name == name && application.ProjectID == projectID {
+			return &application, nil
+		}
+	}
+	return nil, store.ErrApplicationNotFound
+}
+
+type fakeEventWebhooksConfigs struct {
+	store.EventWebhooksConfigs
+}
+
+func (f *fakeEventWebhooksConfigs) GetEventWebhooksConfig(ctx context.Context, projectID string) (*models.EventWebhooksConfig, error) {
+	return &models.EventWebhooksConfig{}, nil
+}
+
+type fakeAlertsConfigs struct {
+	store.AlertsConfigs
+}
+
+func (f *fakeAlertsConfigs) GetAlertsConfig(ctx context.Context, projectID string) (*models.AlertsConfig, error) {
+	return &models.AlertsConfig{}, nil
+}
+
+// newTestGRPCService returns newTestService with device dev_1 and
+// application app_1 in project prj_1. The service account's role can also
+// set device labels, get service logs and stream events.
+func newTestGRPCService() *Service {
+	s := newTestService()
+	s.devices = &fakeDevices{
+		devices: map[string]models.Device{
+			"dev_1": {
+				ID:        "dev_1",
+				ProjectID: "prj_1",
+				Name:      "raspberry-pi",
+				Status:    models.DeviceStatusOnline,
+				Labels:    map[string]string{"site": "warehouse"},
+			},
+		},
+	}
+	s.deviceGroups = &fakeDeviceGroups{}
+	s.applications = &fakeApplications{
+		applications: map[string]models.Application{
+			"app_1": {ID: "app_1", ProjectID: "prj_1", Name: "app"},
+		},
+	}
+	s.events = events.NewPublisher(&fakeEventWebhooksConfigs{}, &fakeAlertsConfigs{}, nil, nil)
+	s.connman = connman.New()
+	s.roles.(*fakeRoles).roles["rol_1"] = models.Role{
+		ID:        "rol_1",
+		ProjectID: "prj_1",
+		Config: `rules:
+- resources: [devices]
+  actions: [GetDevice, ListDevices, GetServiceLogs]
+- resources: [devicelabels]
+  actions: [SetDeviceLabel]
+- resources: [projects]
+  actions: [StreamEvents]
+`,
+	}
+	return s
+}
+
+// serveGRPC serves a service's gRPC API on a local port, and returns a
+// client for it and a function that stops serving.
+func serveGRPC(t *testing.T, s *Service) (grpcapi.DeviceplaneClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := s.GRPCServer()
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	return grpcapi.NewDeviceplaneClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func withAccessKey(accessKey string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+accessKey)
+}
+
+func TestGRPCAuthorization(t *testing.T) {
+	client, stop := serveGRPC(t, newTestGRPCService())
+	defer stop()
+
+	req := &grpcapi.GetDeviceRequest{Project: "prj_1", Device: "raspberry-pi"}
+
+	_, err := client.GetDevice(context.Background(), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetDevice(withAccessKey("s1-expired"), req)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Contains(t, err.Error(), errServiceAccountAccessKeyExpired.Error())
+
+	// The restricted key can't list devices, even though the role can
+	_, err = client.ListDevices(withAccessKey("s1-restricted"), &grpcapi.ListDevicesRequest{Project: "prj_1"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	device, err := client.GetDevice(withAccessKey("s1-restricted"), req)
+	require.NoError(t, err)
+	require.Equal(t, "dev_1", device.Id)
+	require.Equal(t, string(models.DeviceStatusOnline), device.Status)
+	require.Equal(t, map[string]string{"site": "warehouse"}, device.Labels)
+
+	_, err = client.GetDevice(withAccessKey("s1"), &grpcapi.GetDeviceRequest{Project: "prj_1", Device: "jetson"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCSetDeviceLabel(t *testing.T) {
+	s := newTestGRPCService()
+	client, stop := serveGRPC(t, s)
+	defer stop()
+
+	resp, err := client.SetDeviceLabel(withAccessKey("s1"), &grpcapi.SetDeviceLabelRequest{
+		Project: "prj_1",
+		Device:  "dev_1",
+		Key:     "site",
+		Value:   "office",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "office", resp.Value)
+	require.Equal(t, "office", s.devices.(*fakeDevices).devices["dev_1"].Labels["site"])
+
+	_, err = client.SetDeviceLabel(withAccessKey("s1"), &grpcapi.SetDeviceLabelRequest{
+		Project: "prj_1",
+		Device:  "dev_1",
+		Key:     models.SystemLabelPrefix + "os",
+		Value:   "linux",
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.SetDeviceLabel(withAccessKey("s1-restricted"), &grpcapi.SetDeviceLabelRequest{
+		Project: "prj_1",
+		Device:  "dev_1",
+		Key:     "site",
+		Value:   "office",
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Failed and denied calls are audited too
+	require.Equal(t, []string{
+		string(authz.ActionSetDeviceLabel),
+		string(authz.ActionSetDeviceLabel),
+		string(authz.ActionSetDeviceLabel),
+	}, s.auditLogEntries.(*fakeAuditLogEntries).actions)
+}
+
+func TestGRPCStreamEvents(t *testing.T) {
+	s := newTestGRPCService()
+	client, stop := serveGRPC(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithCancel(withAccessKey("s1"))
+	defer cancel()
+
+	stream, err := client.StreamEvents(ctx, &grpcapi.StreamEventsRequest{
+		Project: "prj_1",
+		Types:   []string{string(models.EventTypeDeviceOffline)},
+	})
+	require.NoError(t, err)
+	// Headers are sent once the stream has subscribed
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	device := s.devices.(*fakeDevices).devices["dev_1"]
+	s.events.Publish(ctx, models.Event{Type: models.EventTypeDeviceOnline, ProjectID: "prj_1", Device: &device})
+	s.events.Publish(ctx, models.Event{Type: models.EventTypeDeviceOffline, ProjectID: "prj_1", Device: &device})
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, string(models.EventTypeDeviceOffline), event.Type)
+	require.Equal(t, "dev_1", event.Device.Id)
+
+	// Streams end once the controller starts shutting down
+	s.StartShutdown()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	stream, err = client.StreamEvents(ctx, &grpcapi.StreamEventsRequest{
+		Project: "prj_1",
+		Types:   []string{"unknown"},
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCStreamServiceLogs(t *testing.T) {
+	s := newTestGRPCService()
+	client, stop := serveGRPC(t, s)
+	defer stop()
+
+	controllerConn, deviceConn := net.Pipe()
+	require.NoError(t, s.connman.SetMultiplexed("prj_1dev_1", controllerConn))
+	session, err := yamux.Server(deviceConn, nil)
+	require.NoError(t, err)
+	defer session.Close()
+
+	go http.Serve(session, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/applications/app_1/services/web/logs" || r.URL.Query().Get("tail") != "10" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.Encode(models.LogChunk{Stream: models.LogStreamStdout, Data: "listening\n"})
+		encoder.Encode(models.LogChunk{Stream: models.LogStreamStderr, Data: "warning\n"})
+	}))
+
+	stream, err := client.StreamServiceLogs(withAccessKey("s1"), &grpcapi.StreamServiceLogsRequest{
+		Project:     "prj_1",
+		Device:      "raspberry-pi",
+		Application: "app",
+		Service:     "web",
+		Tail:        10,
+	})
+	require.NoError(t, err)
+
+	var chunks []*grpcapi.LogChunk
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, chunk)
+	}
+	require.Len(t, chunks, 2)
+	require.Equal(t, models.LogStreamStdout, chunks[0].Stream)
+	require.Equal(t, "listening\n", string(chunks[0].Data))
+	require.Equal(t, models.LogStreamStderr, chunks[1].Stream)
+	require.Equal(t, "warning\n", string(chunks[1].Data))
+}
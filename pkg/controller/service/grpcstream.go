@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+	"github.com/deviceplane/deviceplane/pkg/controller/grpcapi"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	defaultMetricsStreamInterval = 15 * time.Second
+	minMetricsStreamInterval     = 5 * time.Second
+
+	// Only this much of an error response from a device is passed on
+	maxDeviceErrorSize = 4 << 10
+)
+
+var errShuttingDown = status.Error(codes.Unavailable, "controller is shutting down")
+
+// streamDone returns the error a stream ends with once its context is done.
+func streamDone(ctx context.Context) error {
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+// deviceError returns the error for a response from a device that failed.
+func deviceError(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxDeviceErrorSize))
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		msg = resp.Status
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, msg)
+	case http.StatusNotFound:
+		return status.Error(codes.NotFound, msg)
+	default:
+		return status.Error(codes.Unavailable, msg)
+	}
+}
+
+func (g *grpcServer) StreamEvents(req *grpcapi.StreamEventsRequest, stream grpcapi.Deviceplane_StreamEventsServer) error {
+	ctx := stream.Context()
+
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceProjects, authz.ActionStreamEvents, "", "")
+	if err != nil {
+		return err
+	}
+
+	types := make(map[models.EventType]bool)
+	for _, t := range req.Types {
+		eventType := models.EventType(t)
+		valid := false
+		for _, validType := range models.EventTypes {
+			if eventType == validType {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return status.Error(codes.InvalidArgument, fmt.Sprintf("unknown event type %q", t))
+		}
+		types[eventType] = true
+	}
+
+	events, unsubscribe := g.s.events.Subscribe(call.projectID)
+	defer unsubscribe()
+
+	// Headers are sent once subscribed, so clients know they won't miss
+	// any events from then on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case event := <-events:
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+			if err := stream.Send(eventToProto(event)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return streamDone(ctx)
+		case <-g.s.shutdown:
+			// Clients reconnect, to another controller once this one is
+			// no longer ready
+			return errShuttingDown
+		}
+	}
+}
+
+func (g *grpcServer) StreamServiceLogs(req *grpcapi.StreamServiceLogsRequest, stream grpcapi.Deviceplane_StreamServiceLogsServer) error {
+	ctx := stream.Context()
+
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDevices, authz.ActionGetServiceLogs, req.Device, req.Application)
+	if err != nil {
+		return err
+	}
+
+	if req.Tail < 0 {
+		return status.Error(codes.InvalidArgument, errInvalidTail.Error())
+	}
+	if req.Service == "" {
+		return status.Error(codes.InvalidArgument, "service is required")
+	}
+
+	device, err := g.getDevice(ctx, call.projectID, req.Device)
+	if err != nil {
+		return err
+	}
+	application, err := g.getApplication(ctx, call.projectID, req.Application)
+	if err != nil {
+		return err
+	}
+
+	deviceConn, err := g.s.connman.Dial(ctx, call.projectID+device.ID)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer deviceConn.Close()
+
+	resp, err := client.GetServiceLogs(ctx, deviceConn, application.ID, req.Service, req.Follow, int(req.Tail))
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return deviceError(resp)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk models.LogChunk
+		if err := decoder.Decode(&chunk); err == io.EOF {
+			return nil
+		} else if err != nil {
+			if ctx.Err() != nil {
+				return streamDone(ctx)
+			}
+			return status.Error(codes.Unavailable, err.Error())
+		}
+
+		if err := stream.Send(&grpcapi.LogChunk{
+			Stream: chunk.Stream,
+			Data:   []byte(chunk.Data),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (g *grpcServer) StreamMetrics(req *grpcapi.StreamMetricsRequest, stream grpcapi.Deviceplane_StreamMetricsServer) error {
+	ctx := stream.Context()
+
+	// Service metrics are authorized like the REST API's, on the application
+	action := authz.ActionGetMetrics
+	var application string
+	if req.Source == grpcapi.MetricsSource_METRICS_SOURCE_SERVICE {
+		action = authz.ActionGetServiceMetrics
+		application = req.Application
+	}
+
+	call, err := g.authorize(ctx, req, req.Project, authz.ResourceDevices, action, req.Device, application)
+	if err != nil {
+		return err
+	}
+
+	interval := defaultMetricsStreamInterval
+	if req.IntervalSeconds != 0 {
+		interval = time.Duration(req.IntervalSeconds) * time.Second
+		if interval < minMetricsStreamInterval {
+			return status.Error(codes.InvalidArgument,
+				fmt.Sprintf("interval can't be less than %v", minMetricsStreamInterval))
+		}
+	}
+
+	device, err := g.getDevice(ctx, call.projectID, req.Device)
+	if err != nil {
+		return err
+	}
+
+	var scrape func(context.Context, net.Conn) (*http.Response, error)
+	switch req.Source {
+	case grpcapi.MetricsSource_METRICS_SOURCE_HOST:
+		scrape = client.GetDeviceMetrics
+	case grpcapi.MetricsSource_METRICS_SOURCE_AGENT:
+		scrape = client.GetAgentMetrics
+	case grpcapi.MetricsSource_METRICS_SOURCE_INGESTED:
+		scrape = client.GetIngestedMetrics
+	case grpcapi.MetricsSource_METRICS_SOURCE_SERVICE:
+		if req.Service == "" {
+			return status.Error(codes.InvalidArgument, "service is required")
+		}
+		app, err := g.getApplication(ctx, call.projectID, req.Application)
+		if err != nil {
+			return err
+		}
+		serviceMetricEndpointConfig, exists := app.MetricEndpointConfigs[req.Service]
+		if !exists {
+			serviceMetricEndpointConfig.Port = models.DefaultMetricPort
+			serviceMetricEndpointConfig.Path = models.DefaultMetricPath
+		}
+		scrape = func(ctx context.Context, deviceConn net.Conn) (*http.Response, error) {
+			return client.GetServiceMetrics(ctx, deviceConn, app.ID, req.Service,
+				serviceMetricEndpointConfig.Path, serviceMetricEndpointConfig.Port)
+		}
+	default:
+		return status.Error(codes.InvalidArgument, "metrics source is required")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		metrics, err := g.scrapeMetrics(ctx, call.projectID, device.ID, scrape)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(metrics); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return streamDone(ctx)
+		case <-g.s.shutdown:
+			return errShuttingDown
+		}
+	}
+}
+
+// scrapeMetrics scrapes a device's metrics over a connection of its own.
+func (g *grpcServer) scrapeMetrics(ctx context.Context, projectID, deviceID string,
+	scrape func(context.Context, net.Conn) (*http.Response, error),
+) (*grpcapi.Metrics, error) {
+	deviceConn, err := g.s.connman.Dial(ctx, projectID+deviceID)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	defer deviceConn.Close()
+
+	resp, err := scrape(ctx, deviceConn)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, deviceError(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return &grpcapi.Metrics{
+		ScrapedAt: timestamppb.Now(),
+		Data:      data,
+	}, nil
+}
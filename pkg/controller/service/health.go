@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const (
+	// The controller isn't ready while this many event deliveries are
+	// pending, since it's falling behind on them
+	maxPendingEventDeliveries = 1000
+
+	readinessTimeout = 2 * time.Second
+)
+
+// StartShutdown makes the controller report that it isn't ready, so load
+// balancers stop sending it requests while it drains the ones in flight,
+// and ends event streams, which would otherwise never finish draining.
+func (s *Service) StartShutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
+}
+
+// healthz reports that the controller is up. Unlike readyz it doesn't
+// depend on the store, so a store outage doesn't get controllers restarted.
+func (s *Service) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz reports whether the controller can serve requests: it isn't
+// shutting down, it can reach the store and it's keeping up with event
+// deliveries.
+func (s *Service) readyz(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-s.shutdown:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := s.connectivity.Ping(ctx); err != nil {
+		log.WithError(err).Error("ping store")
+		http.Error(w, "store unreachable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if pending := s.events.Pending(); pending >= maxPendingEventDeliveries {
+		http.Error(w, fmt.Sprintf("%d event deliveries pending", pending), http.StatusServiceUnavailable)
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// getLimits returns the limits that apply to a project, which are its own
+// where they're set and the installation's defaults otherwise.
+func (s *Service) getLimits(ctx context.Context, projectID string) (*models.LimitsConfig, error) {
+	limits, err := s.limitsConfigs.GetLimitsConfig(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxDevices == 0 {
+		limits.MaxDevices = s.defaultLimits.MaxDevices
+	}
+	if limits.MaxApplications == 0 {
+		limits.MaxApplications = s.defaultLimits.MaxApplications
+	}
+	if limits.MaxReleaseConfigSize == 0 {
+		limits.MaxReleaseConfigSize = s.defaultLimits.MaxReleaseConfigSize
+	}
+	if limits.MaxRemoteSessions == 0 {
+		limits.MaxRemoteSessions = s.defaultLimits.MaxRemoteSessions
+	}
+
+	return limits, nil
+}
+
+func quotaExceeded(w http.ResponseWriter, limit int, what string) {
+	http.Error(w, fmt.Sprintf("%s: the project is limited to %d %s", errQuotaExceeded, limit, what), http.StatusForbidden)
+}
+
+// withinDeviceLimit returns whether a device can be added to a project. If
+// it can't, or this can't be checked, it writes the error response.
+func (s *Service) withinDeviceLimit(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	limits, err := s.getLimits(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get limits")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if limits.MaxDevices == 0 {
+		return true
+	}
+
+	deviceCounts, err := s.projectDeviceCounts.GetProjectDeviceCounts(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get project device counts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if deviceCounts.AllCount >= limits.MaxDevices {
+		quotaExceeded(w, limits.MaxDevices, "devices")
+		return false
+	}
+
+	return true
+}
+
+// withinApplicationLimit is withinDeviceLimit for applications.
+func (s *Service) withinApplicationLimit(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	limits, err := s.getLimits(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get limits")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if limits.MaxApplications == 0 {
+		return true
+	}
+
+	applicationCounts, err := s.projectApplicationCounts.GetProjectApplicationCounts(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get project application counts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if applicationCounts.AllCount >= limits.MaxApplications {
+		quotaExceeded(w, limits.MaxApplications, "applications")
+		return false
+	}
+
+	return true
+}
+
+// withinReleaseConfigSizeLimit returns whether a release config is small
+// enough for a project. If it isn't, or this can't be checked, it writes the
+// error response.
+func (s *Service) withinReleaseConfigSizeLimit(w http.ResponseWriter, r *http.Request, projectID, rawConfig string) bool {
+	limits, err := s.getLimits(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get limits")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	if limits.MaxReleaseConfigSize != 0 && len(rawConfig) > limits.MaxReleaseConfigSize {
+		quotaExceeded(w, limits.MaxReleaseConfigSize, "bytes of release config")
+		return false
+	}
+
+	return true
+}
+
+// startRemoteSession counts an SSH or exec session against its project's
+// limit, and returns a function that ends it. If the project is at its
+// limit, or this can't be checked, it writes the error response and returns
+// false. Sessions are counted in the store, across all controllers, and
+// aren't counted at all in projects without a limit.
+func (s *Service) startRemoteSession(w http.ResponseWriter, r *http.Request, projectID string) (func(), bool) {
+	limits, err := s.getLimits(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get limits")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	if limits.MaxRemoteSessions == 0 {
+		return func() {}, true
+	}
+
+	id, err := s.remoteSessions.StartRemoteSession(r.Context(), projectID, limits.MaxRemoteSessions)
+	if err == store.ErrRemoteSessionLimitReached {
+		quotaExceeded(w, limits.MaxRemoteSessions, "concurrent remote sessions")
+		return nil, false
+	} else if err != nil {
+		log.WithError(err).Error("start remote session")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// The session is refreshed while it's open so that it doesn't expire
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(store.RemoteSessionTimeout / 4)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.remoteSessions.RefreshRemoteSession(context.Background(), id); err != nil {
+					log.WithError(err).Error("refresh remote session")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		if err := s.remoteSessions.EndRemoteSession(context.Background(), id); err != nil {
+			log.WithError(err).Error("end remote session")
+		}
+	}, true
+}
+
+// rateLimit takes a request from the rate limit of a key, which identifies
+// whoever made it. If they're over their limit it responds with a 429 and
+// returns false.
+func (s *Service) rateLimit(w http.ResponseWriter, r *http.Request, key string) bool {
+	if s.rateLimiter == nil {
+		return true
+	}
+	result := s.rateLimiter.Allow(key, time.Now())
+	result.SetHeaders(w.Header())
+	if !result.Allowed {
+		s.st.Incr("rate_limited", nil, 1)
+		http.Error(w, errRateLimited.Error(), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// rateLimitAddress rate limits a request by the address it came from. This is
+// used for requests that haven't been authenticated, since anything else in
+// them could be made up to get a fresh limit.
+func (s *Service) rateLimitAddress(w http.ResponseWriter, r *http.Request) bool {
+	return s.rateLimit(w, r, "addr:"+s.trustedProxies.RemoteAddr(r))
+}
+
+// withAddressRateLimit rate limits a handler that doesn't authenticate its
+// requests by their address. Handlers that do are rate limited by who they
+// authenticated as instead.
+func (s *Service) withAddressRateLimit(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.rateLimitAddress(w, r) {
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// unauthorized responds to a request that failed authentication. Failures
+// count against the rate limit of the address they came from, so
+// credentials can't be guessed any faster than anonymous requests are
+// allowed.
+func (s *Service) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if !s.rateLimitAddress(w, r) {
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusUnauthorized)
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/ratelimit"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+type fakeLimitsConfigs struct {
+	store.LimitsConfigs
+}
+
+func (f *fakeLimitsConfigs) GetLimitsConfig(ctx context.Context, projectID string) (*models.LimitsConfig, error) {
+	return &models.LimitsConfig{}, nil
+}
+
+type fakeRemoteSessions struct {
+	store.RemoteSessions
+	sessions map[string]string
+	started  int
+}
+
+func (f *fakeRemoteSessions) StartRemoteSession(ctx context.Context, projectID string, limit int) (string, error) {
+	count := 0
+	for _, sessionProjectID := range f.sessions {
+		if sessionProjectID == projectID {
+			count++
+		}
+	}
+	if count >= limit {
+		return "", store.ErrRemoteSessionLimitReached
+	}
+
+	f.started++
+	id := fmt.Sprintf("rms_%d", f.started)
+	f.sessions[id] = projectID
+	return id, nil
+}
+
+func (f *fakeRemoteSessions) EndRemoteSession(ctx context.Context, id string) error {
+	delete(f.sessions, id)
+	return nil
+}
+
+func TestRateLimitAuthenticated(t *testing.T) {
+	s := newTestService()
+	s.rateLimiter = ratelimit.New(0.001, 1)
+
+	handler := s.withUserOrServiceAccountAccessKeyAuth(func(w http.ResponseWriter, r *http.Request, userID string, serviceAccountAccessKey *models.ServiceAccountAccessKey) {
+	})
+
+	// Users sharing an address have their own limits
+	require.Equal(t, http.StatusOK, serve(t, handler, "203.0.113.1:1234", "u1").Code)
+	require.Equal(t, http.StatusOK, serve(t, handler, "203.0.113.1:1234", "u2").Code)
+
+	// A user's limit follows them between addresses and credentials
+	require.Equal(t, http.StatusTooManyRequests, serve(t, handler, "203.0.113.2:1234", "u1").Code)
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = "203.0.113.3:1234"
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: "session1"})
+	w := httptest.NewRecorder()
+	handler(w, r)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimitUnauthenticated(t *testing.T) {
+	s := newTestService()
+	s.rateLimiter = ratelimit.New(0.001, 1)
+
+	handler := s.withUserOrServiceAccountAccessKeyAuth(func(w http.ResponseWriter, r *http.Request, userID string, serviceAccountAccessKey *models.ServiceAccountAccessKey) {
+	})
+
+	// Made up credentials all count against the address they come from
+	require.Equal(t, http.StatusUnauthorized, serve(t, handler, "203.0.113.1:1234", "ubad1").Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(t, handler, "203.0.113.1:1234", "ubad2").Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(t, handler, "203.0.113.1:1234", "sbad").Code)
+
+	// As do any credentials sent to handlers that don't check them
+	unauthenticatedHandler := s.withAddressRateLimit(func(w http.ResponseWriter, r *http.Request) {})
+	require.Equal(t, http.StatusTooManyRequests, serve(t, unauthenticatedHandler, "203.0.113.1:1234", "ubad3").Code)
+	require.Equal(t, http.StatusOK, serve(t, unauthenticatedHandler, "203.0.113.2:1234", "ubad3").Code)
+}
+
+func TestRemoteSessionLimitAcrossControllers(t *testing.T) {
+	// Controllers sharing a store count each other's sessions
+	remoteSessions := &fakeRemoteSessions{sessions: make(map[string]string)}
+	var controllers []*Service
+	for i := 0; i < 2; i++ {
+		s := newTestService()
+		s.limitsConfigs = &fakeLimitsConfigs{}
+		s.defaultLimits = models.LimitsConfig{MaxRemoteSessions: 1}
+		s.remoteSessions = remoteSessions
+		controllers = append(controllers, s)
+	}
+
+	startRemoteSession := func(s *Service, projectID string) (func(), int) {
+		r, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		end, ok := s.startRemoteSession(w, r, projectID)
+		if !ok {
+			return nil, w.Code
+		}
+		return end, http.StatusOK
+	}
+
+	end, code := startRemoteSession(controllers[0], "prj_1")
+	require.Equal(t, http.StatusOK, code)
+
+	_, code = startRemoteSession(controllers[1], "prj_1")
+	require.Equal(t, http.StatusForbidden, code)
+
+	// Other projects have limits of their own
+	_, code = startRemoteSession(controllers[1], "prj_2")
+	require.Equal(t, http.StatusOK, code)
+
+	end()
+	_, code = startRemoteSession(controllers[1], "prj_1")
+	require.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/metrics"
+)
+
+// withRequestMetrics records the rate, latency and status of requests by
+// the route they matched. Requests upgraded to WebSockets are counted as
+// open sessions until their handlers return.
+func withRequestMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+			if template, err := currentRoute.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		m := &requestMetrics{
+			ResponseWriter: w,
+			route:          route,
+		}
+		startedAt := time.Now()
+
+		defer func() {
+			statusCode := m.statusCode
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			metrics.RequestsTotal.WithLabelValues(route, r.Method, strconv.Itoa(statusCode)).Inc()
+
+			if m.hijacked {
+				metrics.WebSocketSessions.WithLabelValues(route).Dec()
+				return
+			}
+			metrics.RequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(startedAt).Seconds())
+		}()
+
+		next.ServeHTTP(m, r)
+	})
+}
+
+// requestMetrics wraps the response of a request being measured, like
+// auditLogEntry does for the audit log.
+type requestMetrics struct {
+	http.ResponseWriter
+
+	route      string
+	statusCode int
+	hijacked   bool
+}
+
+func (m *requestMetrics) WriteHeader(statusCode int) {
+	if m.statusCode == 0 {
+		m.statusCode = statusCode
+	}
+	m.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (m *requestMetrics) Write(b []byte) (int, error) {
+	if m.statusCode == 0 {
+		m.statusCode = http.StatusOK
+	}
+	return m.ResponseWriter.Write(b)
+}
+
+func (m *requestMetrics) Flush() {
+	if flusher, ok := m.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (m *requestMetrics) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := m.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackUnsupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if m.statusCode == 0 {
+		m.statusCode = http.StatusSwitchingProtocols
+	}
+	if !m.hijacked {
+		m.hijacked = true
+		metrics.WebSocketSessions.WithLabelValues(m.route).Inc()
+	}
+	return conn, rw, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/controller/openapi"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+const (
+	basicAuthSecurityScheme = "accessKey"
+	cookieSecurityScheme    = "session"
+)
+
+// Request bodies that handlers decode into unnamed structs are described
+// here with the same fields.
+type (
+	nameRequest struct {
+		Name string `json:"name"`
+	}
+	nameAndDescriptionRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	keyValueRequest struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	roleRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Config      string `json:"config"`
+	}
+	environmentFileRequest struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Content     string `json:"content"`
+	}
+	deviceRegistrationTokenRequest struct {
+		Name             string     `json:"name"`
+		Description      string     `json:"description"`
+		MaxRegistrations *int       `json:"maxRegistrations"`
+		ExpiresAt        *time.Time `json:"expiresAt"`
+		NamePrefix       string     `json:"namePrefix"`
+		RequireApproval  bool       `json:"requireApproval"`
+	}
+)
+
+// openAPIRoutes describes the request and response bodies of the API's
+// routes. Routes that aren't listed are still documented, with their paths
+// and parameters but without bodies.
+var openAPIRoutes = map[string]openapi.Route{
+	"POST /api/login": {Request: struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}{}},
+
+	"GET /api/me": {Response: models.User{}},
+	"PATCH /api/me": {Request: struct {
+		Password        *string `json:"password"`
+		CurrentPassword *string `json:"currentPassword"`
+		FirstName       *string `json:"firstName"`
+		LastName        *string `json:"lastName"`
+		Company         *string `json:"company"`
+	}{}, Response: models.User{}},
+	"GET /api/memberships": {Response: []models.Membership{}},
+
+	"POST /api/useraccesskeys": {Request: struct {
+		Description string `json:"description"`
+	}{}, Response: models.UserAccessKeyWithValue{}},
+	"GET /api/useraccesskeys/{useraccesskey}": {Response: models.UserAccessKey{}},
+	"GET /api/useraccesskeys":                 {Response: []models.UserAccessKey{}},
+	"POST /api/sshkeys": {Request: struct {
+		Name      string `json:"name"`
+		PublicKey string `json:"publicKey"`
+	}{}, Response: models.SSHKey{}},
+	"GET /api/sshkeys/{sshkey}":   {Response: models.SSHKey{}},
+	"GET /api/sshkeys":            {Response: []models.SSHKey{}},
+	"POST /api/projects":          {Request: nameRequest{}, Response: models.Project{}},
+	"GET /api/projects/{project}": {Response: models.Project{}},
+	"PUT /api/projects/{project}": {Request: struct {
+		Name          string `json:"name"`
+		DatadogApiKey string `json:"datadogApiKey"`
+	}{}, Response: models.Project{}},
+	"POST /api/projects/{project}/roles":       {Request: roleRequest{}, Response: models.Role{}},
+	"GET /api/projects/{project}/roles/{role}": {Response: models.Role{}},
+	"GET /api/projects/{project}/roles":        {Response: []models.Role{}},
+	"PUT /api/projects/{project}/roles/{role}": {Request: roleRequest{}, Response: models.Role{}},
+
+	"POST /api/projects/{project}/memberships": {Request: struct {
+		Email string `json:"email"`
+	}{}, Response: models.Membership{}},
+	"GET /api/projects/{project}/memberships/{user}":               {Response: models.Membership{}},
+	"GET /api/projects/{project}/memberships":                      {Response: []models.Membership{}},
+	"POST /api/projects/{project}/serviceaccounts":                 {Request: nameAndDescriptionRequest{}, Response: models.ServiceAccount{}},
+	"GET /api/projects/{project}/serviceaccounts":                  {Response: []models.ServiceAccount{}},
+	"GET /api/projects/{project}/serviceaccounts/{serviceaccount}": {Response: models.ServiceAccount{}},
+	"PUT /api/projects/{project}/serviceaccounts/{serviceaccount}": {Request: nameAndDescriptionRequest{}, Response: models.ServiceAccount{}},
+
+	"POST /api/projects/{project}/serviceaccounts/{serviceaccount}/serviceaccountaccesskeys": {Request: struct {
+		Description string     `json:"description"`
+		Config      string     `json:"config"`
+		ExpiresAt   *time.Time `json:"expiresAt"`
+	}{}, Response: models.ServiceAccountAccessKeyWithValue{}},
+	"GET /api/projects/{project}/serviceaccounts/{serviceaccount}/serviceaccountaccesskeys/{serviceaccountaccesskey}": {Response: models.ServiceAccountAccessKey{}},
+	"GET /api/projects/{project}/serviceaccounts/{serviceaccount}/serviceaccountaccesskeys":                           {Response: []models.ServiceAccountAccessKey{}},
+
+	"POST /api/projects/{project}/applications":              {Request: nameAndDescriptionRequest{}, Response: models.Application{}},
+	"GET /api/projects/{project}/applications/{application}": {Response: models.Application{}},
+	"GET /api/projects/{project}/applications":               {Response: []models.Application{}},
+	"PATCH /api/projects/{project}/applications/{application}": {Request: struct {
+		Name                  *string                                 `json:"name"`
+		Description           *string                                 `json:"description"`
+		SchedulingRule        *models.SchedulingRule                  `json:"schedulingRule"`
+		MetricEndpointConfigs *map[string]models.MetricEndpointConfig `json:"metricEndpointConfigs"`
+	}{}, Response: models.Application{}},
+	"GET /api/projects/{project}/applications/{application}/gitsync":   {Response: models.ApplicationGitSync{}},
+	"PUT /api/projects/{project}/applications/{application}/gitsync":   {Request: models.SetApplicationGitSyncRequest{}, Response: models.ApplicationGitSync{}},
+	"POST /api/projects/{project}/applications/{application}/rollback": {Request: models.RollbackApplicationRequest{}, Response: models.Release{}},
+
+	"POST /api/projects/{project}/applications/{application}/releases":                {Request: models.CreateReleaseRequest{}, Response: models.Release{}},
+	"POST /api/projects/{project}/applications/{application}/releases/ci":             {Request: models.CreateCIReleaseRequest{}, Response: models.CreateCIReleaseResponse{}},
+	"POST /api/projects/{project}/applications/{application}/releases/validate":       {Request: models.ValidateReleaseRequest{}, Response: models.ValidateReleaseResponse{}},
+	"POST /api/projects/{project}/applications/{application}/releases/compose":        {Request: models.ConvertComposeRequest{}, Response: models.ConvertComposeResponse{}},
+	"GET /api/projects/{project}/applications/{application}/releases/latest":          {Response: models.Release{}},
+	"GET /api/projects/{project}/applications/{application}/releases/{release}":       {Response: models.Release{}},
+	"GET /api/projects/{project}/applications/{application}/releases/{release}/diff":  {Response: models.ReleaseDiff{}},
+	"GET /api/projects/{project}/applications/{application}/releases":                 {Response: []models.Release{}},
+	"POST /api/projects/{project}/applications/{application}/rollouts":                {Request: models.CreateRolloutRequest{}, Response: models.Rollout{}},
+	"GET /api/projects/{project}/applications/{application}/rollouts/{rollout}":       {Response: models.Rollout{}},
+	"GET /api/projects/{project}/applications/{application}/rollouts":                 {Response: []models.Rollout{}},
+	"POST /api/projects/{project}/applications/{application}/rollouts/{rollout}/halt": {Request: models.HaltRolloutRequest{}, Response: models.Rollout{}},
+	"GET /api/projects/{project}/applications/{application}/releasepins":              {Response: []models.DeviceReleasePin{}},
+
+	"POST /api/projects/{project}/environmentfiles":                  {Request: environmentFileRequest{}, Response: models.EnvironmentFile{}},
+	"GET /api/projects/{project}/environmentfiles/{environmentfile}": {Response: models.EnvironmentFile{}},
+	"GET /api/projects/{project}/environmentfiles":                   {Response: []models.EnvironmentFile{}},
+	"PUT /api/projects/{project}/environmentfiles/{environmentfile}": {Request: environmentFileRequest{}, Response: models.EnvironmentFile{}},
+	"POST /api/projects/{project}/devicegroups":                      {Request: deviceGroupRequest{}, Response: models.DeviceGroup{}},
+	"GET /api/projects/{project}/devicegroups/{devicegroup}":         {Response: models.DeviceGroup{}},
+	"GET /api/projects/{project}/devicegroups":                       {Response: []models.DeviceGroup{}},
+	"PUT /api/projects/{project}/devicegroups/{devicegroup}":         {Request: deviceGroupRequest{}, Response: models.DeviceGroup{}},
+	"GET /api/projects/{project}/devicegroups/{devicegroup}/devices": {Response: []models.Device{}},
+	"POST /api/projects/{project}/deviceviews":                       {Request: models.SetDeviceViewRequest{}, Response: models.DeviceView{}},
+	"GET /api/projects/{project}/deviceviews/{deviceview}":           {Response: models.DeviceView{}},
+	"GET /api/projects/{project}/deviceviews":                        {Response: []models.DeviceView{}},
+	"PUT /api/projects/{project}/deviceviews/{deviceview}":           {Request: models.SetDeviceViewRequest{}, Response: models.DeviceView{}},
+	"POST /api/projects/{project}/configfiles":                       {Request: configFileRequest{}, Response: models.ConfigFile{}},
+	"GET /api/projects/{project}/configfiles/{configfile}":           {Response: models.ConfigFile{}},
+	"GET /api/projects/{project}/configfiles":                        {Response: []models.ConfigFile{}},
+	"PUT /api/projects/{project}/configfiles/{configfile}":           {Request: configFileRequest{}, Response: models.ConfigFile{}},
+
+	"GET /api/projects/{project}/sessionrecordings/{sessionrecording}": {Response: models.SessionRecording{}},
+	"GET /api/projects/{project}/sessionrecordings":                    {Response: []models.SessionRecording{}},
+	"GET /api/projects/{project}/auditlog":                             {Response: []models.AuditLogEntry{}},
+	"GET /api/projects/{project}/alerts":                               {Response: []models.Alert{}},
+	"GET /api/projects/{project}/alertsilences":                        {Response: []models.AlertSilence{}},
+	"POST /api/projects/{project}/alertsilences":                       {Request: models.CreateAlertSilenceRequest{}, Response: models.AlertSilence{}},
+
+	"GET /api/projects/{project}/devices/{device}":                                               {Response: models.Device{}},
+	"GET /api/projects/{project}/devices":                                                        {Response: []models.Device{}},
+	"GET /api/projects/{project}/devices/previewscheduling/{application}":                        {Response: []models.Device{}},
+	"GET /api/projects/{project}/devices/export":                                                 {},
+	"GET /api/projects/{project}/devices/stale":                                                  {Response: models.StaleDeviceCleanupReport{}},
+	"GET /api/projects/{project}/devices/drift":                                                  {Response: models.DriftReport{}},
+	"GET /api/projects/{project}/devices/versions":                                               {Response: models.DeviceVersionsReport{}},
+	"POST /api/projects/{project}/devices/bulk":                                                  {Request: models.BulkDeviceOperationRequest{}, Response: models.BulkDeviceOperationResponse{}},
+	"PATCH /api/projects/{project}/devices/{device}":                                             {Request: nameRequest{}, Response: models.Device{}},
+	"POST /api/projects/{project}/devices/{device}/transfer":                                     {Request: models.TransferDeviceRequest{}},
+	"GET /api/projects/{project}/devices/{device}/connectivity":                                  {Response: models.DeviceConnectivityHistory{}},
+	"GET /api/projects/{project}/devices/{device}/drift":                                         {Response: models.DeviceDrift{}},
+	"POST /api/projects/{project}/devices/{device}/agentversion":                                 {Request: models.SetDeviceAgentVersionRequest{}},
+	"PUT /api/projects/{project}/devices/{device}/applications/{application}/releasepin":         {Request: models.SetDeviceReleasePinRequest{}, Response: models.DeviceReleasePin{}},
+	"POST /api/projects/{project}/devices/{device}/reboot":                                       {Request: models.PowerActionRequest{}},
+	"POST /api/projects/{project}/devices/{device}/shutdown":                                     {Request: models.PowerActionRequest{}},
+	"PUT /api/projects/{project}/devices/{device}/labels":                                        {Request: keyValueRequest{}},
+	"GET /api/projects/{project}/devices/{device}/environment":                                   {Response: map[string]string{}},
+	"PUT /api/projects/{project}/devices/{device}/environment":                                   {Request: keyValueRequest{}},
+	"GET /api/projects/{project}/devicelabels":                                                   {Response: []string{}},
+	"GET /api/projects/{project}/deviceregistrationtokens":                                       {Response: []models.DeviceRegistrationToken{}},
+	"POST /api/projects/{project}/deviceregistrationtokens":                                      {Request: deviceRegistrationTokenRequest{}, Response: models.DeviceRegistrationToken{}},
+	"GET /api/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}":             {Response: models.DeviceRegistrationToken{}},
+	"PUT /api/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}":             {Request: deviceRegistrationTokenRequest{}, Response: models.DeviceRegistrationToken{}},
+	"PUT /api/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/labels":      {Request: keyValueRequest{}},
+	"PUT /api/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/environment": {Request: keyValueRequest{}},
+
+	"POST /api/projects/{project}/devices/register":       {Request: models.RegisterDeviceRequest{}, Response: models.RegisterDeviceResponse{}},
+	"GET /api/projects/{project}/devices/{device}/bundle": {Response: models.Bundle{}},
+	"POST /api/projects/{project}/devices/{device}/info":  {Request: models.SetDeviceInfoRequest{}},
+}
+
+// getOpenAPIDocument serves an OpenAPI document describing the API, which
+// clients for other languages can be generated from.
+func (s *Service) getOpenAPIDocument(w http.ResponseWriter, r *http.Request) {
+	s.openAPIOnce.Do(func() {
+		s.openAPIDocument, s.openAPIErr = openapi.Generate(s.router, openapi.Info{
+			Title:   "Deviceplane API",
+			Version: "1",
+		}, openAPIRoutes, func(path string) bool {
+			return strings.HasPrefix(path, "/api/internal/") ||
+				strings.HasPrefix(path, "/api/debug/") ||
+				path == "/api/500" ||
+				path == "/api/openapi.json"
+		})
+		if s.openAPIErr != nil {
+			return
+		}
+		s.openAPIDocument.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+			// Access keys are given as the username
+			basicAuthSecurityScheme: {
+				Type:   "http",
+				Scheme: "basic",
+			},
+			cookieSecurityScheme: {
+				Type: "apiKey",
+				In:   "cookie",
+				Name: sessionCookie,
+			},
+		}
+		s.openAPIDocument.Security = []map[string][]string{
+			{basicAuthSecurityScheme: {}},
+			{cookieSecurityScheme: {}},
+		}
+	})
+	if s.openAPIErr != nil {
+		log.WithError(s.openAPIErr).Error("generate openapi document")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, s.openAPIDocument)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/middleware"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+type fakeReleases struct {
+	store.Releases
+	idempotencyKeys []string
+	// releases are newest first
+	releases []models.Release
+}
+
+func (f *fakeReleases) GetReleaseByIdempotencyKey(ctx context.Context, projectID, applicationID, idempotencyKey string) (*models.Release, error) {
+	f.idempotencyKeys = append(f.idempotencyKeys, idempotencyKey)
+	return nil, store.ErrReleaseNotFound
+}
+
+func (f *fakeReleases) ListReleasesPage(ctx context.Context, projectID, applicationID, after string, limit int) ([]models.Release, error) {
+	releases := f.releases
+	for i, release := range f.releases {
+		if release.ID == after {
+			releases = f.releases[i+1:]
+		}
+	}
+	if len(releases) > limit {
+		releases = releases[:limit]
+	}
+	return releases, nil
+}
+
+func (f *fakeReleases) GetReleaseCount(ctx context.Context, projectID, applicationID string) (int, error) {
+	return len(f.releases), nil
+}
+
+func TestCreateCIReleaseRequiresIdempotencyKey(t *testing.T) {
+	s := newTestService()
+	releases := &fakeReleases{}
+	s.releases = releases
+
+	for _, body := range []string{
+		`{"rawConfig": "web:\n  image: nginx\n"}`,
+		`{"idempotencyKey": "", "rawConfig": "web:\n  image: nginx\n"}`,
+		`{"idempotencyKey": "  ", "rawConfig": "web:\n  image: nginx\n"}`,
+	} {
+		r, err := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		s.createCIRelease(w, r, "prj_1", "", "sac_1", "app_1")
+		require.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+
+	// Releases aren't looked up, or created, without a key
+	require.Empty(t, releases.idempotencyKeys)
+}
+
+func TestListReleasesPage(t *testing.T) {
+	s := newTestService()
+	s.releases = &fakeReleases{
+		releases: []models.Release{{ID: "rel_3"}, {ID: "rel_2"}, {ID: "rel_1"}},
+	}
+
+	for _, test := range []struct {
+		query    string
+		releases []string
+		next     string
+	}{
+		{"page_size=2", []string{"rel_3", "rel_2"}, "rel_2"},
+		{"page_size=2&after=rel_2", []string{"rel_1"}, ""},
+	} {
+		r, err := http.NewRequest(http.MethodGet, "/?"+test.query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		s.listReleases(w, r, "prj_1", "usr_1", "", "app_1")
+		require.Equal(t, http.StatusOK, w.Code, test.query)
+
+		var releases []models.Release
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &releases))
+		var ids []string
+		for _, release := range releases {
+			ids = append(ids, release.ID)
+		}
+		require.Equal(t, test.releases, ids, test.query)
+		require.Equal(t, test.next, w.Header().Get(middleware.NextPageAfterHeader), test.query)
+		require.Equal(t, "2", w.Header().Get(middleware.TotalPagesHeader), test.query)
+		require.Equal(t, "3", w.Header().Get(middleware.TotalItemCountHeader), test.query)
+	}
+}
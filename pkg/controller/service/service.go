@@ -1,23 +1,43 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
 	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/controller/alerting"
 	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+	"github.com/deviceplane/deviceplane/pkg/controller/clientaddr"
+	"github.com/deviceplane/deviceplane/pkg/controller/configfile"
+	"github.com/deviceplane/deviceplane/pkg/controller/connectivity"
 	"github.com/deviceplane/deviceplane/pkg/controller/connman"
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/environment"
+	"github.com/deviceplane/deviceplane/pkg/controller/events"
+	"github.com/deviceplane/deviceplane/pkg/controller/gitrepo"
+	"github.com/deviceplane/deviceplane/pkg/controller/maintenance"
 	"github.com/deviceplane/deviceplane/pkg/controller/middleware"
+	"github.com/deviceplane/deviceplane/pkg/controller/oidc"
+	"github.com/deviceplane/deviceplane/pkg/controller/openapi"
 	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/ratelimit"
+	"github.com/deviceplane/deviceplane/pkg/controller/releasecheck"
+	"github.com/deviceplane/deviceplane/pkg/controller/releasewebhooks"
+	"github.com/deviceplane/deviceplane/pkg/controller/rollout"
+	"github.com/deviceplane/deviceplane/pkg/controller/saml"
 	"github.com/deviceplane/deviceplane/pkg/controller/scheduling"
 	"github.com/deviceplane/deviceplane/pkg/controller/spaserver"
+	"github.com/deviceplane/deviceplane/pkg/controller/staledevices"
 	"github.com/deviceplane/deviceplane/pkg/controller/store"
 	"github.com/deviceplane/deviceplane/pkg/email"
 	"github.com/deviceplane/deviceplane/pkg/hash"
@@ -30,17 +50,74 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/segmentio/ksuid"
+	"golang.org/x/crypto/ssh"
 	"gopkg.in/yaml.v2"
 )
 
 const (
 	sessionCookie = "dp_sess"
+
+	// Recordings are capped on the device, but JSON escaping can still
+	// inflate them considerably
+	maxSessionRecordingRequestSize = 64 << 20
+
+	// Keeps proxies from closing idle event streams
+	eventStreamKeepaliveInterval = 15 * time.Second
+
+	defaultAuditLogEntriesLimit = 100
+	maxAuditLogEntriesLimit     = 1000
+
+	maxBulkDevices = 1000
+
+	maxHeartbeatIntervalSeconds = 24 * 60 * 60
 )
 
 var (
-	errEmailDomainNotAllowed = errors.New("email domain not allowed")
-	errEmailAlreadyTaken     = errors.New("email already taken")
-	errTokenExpired          = errors.New("token expired")
+	errEmailDomainNotAllowed          = errors.New("email domain not allowed")
+	errEmailAlreadyTaken              = errors.New("email already taken")
+	errTokenExpired                   = errors.New("token expired")
+	errDeviceRegistrationTokenExpired = errors.New("device registration token expired")
+	errServiceAccountAccessKeyExpired = errors.New("service account access key expired")
+	errRestrictedAccessKey            = errors.New("restricted access keys can't create access keys")
+	errExpiryInPast                   = errors.New("expiry must be in the future")
+	errDeviceRegistrationLimitReached = errors.New("device registration token has reached its registration limit")
+	errDevicePendingApproval          = errors.New("device is pending approval")
+	errServiceAccountTransfer         = errors.New("service accounts can't transfer devices to other projects")
+	errSystemLabel                    = errors.New("labels starting with " + models.SystemLabelPrefix + " are set from the device's hardware")
+	errRolloutInProgress              = errors.New("application already has a rollout in progress")
+	errRolloutNotInProgress           = errors.New("rollout isn't in progress")
+	errNoPreviousRelease              = errors.New("application has no previous release to roll back to")
+	errNoReleaseToDiff                = errors.New("release has no previous release to diff against")
+	errRollbackToLatestRelease        = errors.New("can't roll back to the latest release")
+	errIdempotencyKeyRequired         = errors.New("idempotency key is required")
+	errIdempotencyKeyReused           = errors.New("idempotency key was already used for a release with a different config")
+	errStreamingUnsupported           = errors.New("streaming unsupported")
+	errInvalidAuditLogRetention       = errors.New("audit log retention can't be negative")
+	errInvalidAuditLogSince           = errors.New("since must be an RFC 3339 time")
+	errInvalidAuditLogLimit           = fmt.Errorf("limit must be between 1 and %d", maxAuditLogEntriesLimit)
+	errInvalidSSOState                = errors.New("invalid or expired single sign-on state")
+	errEmailNotVerified               = errors.New("email address isn't verified by the single sign-on provider")
+	errMissingSSOGroup                = errors.New("single sign-on group roles need a group")
+	errMissingSSOProvider             = errors.New("single sign-on group roles need a provider")
+	errQuotaExceeded                  = errors.New("quota exceeded")
+	errInvalidLimit                   = errors.New("limits can't be negative")
+	errRateLimited                    = errors.New("rate limit exceeded")
+	errBulkDevicesOrSelector          = errors.New("either devices or a selector is needed, but not both")
+	errTooManyBulkDevices             = fmt.Errorf("bulk operations are limited to %d devices", maxBulkDevices)
+	errUnknownBulkOperation           = errors.New("unknown bulk operation")
+	errBulkOperationNeedsKey          = errors.New("operation needs a key")
+	errBulkOperationNeedsApplication  = errors.New("operation needs an application")
+	errBulkOperationNeedsRelease      = errors.New("operation needs a release")
+	errUnknownExportFormat            = errors.New("export format must be csv or ndjson")
+	errInvalidConnectivitySince       = errors.New("since must be an RFC 3339 time")
+	errInvalidHeartbeatInterval       = fmt.Errorf("heartbeat interval must be between 0 and %d seconds, and offline threshold can't be negative", maxHeartbeatIntervalSeconds)
+	errOfflineThresholdTooShort       = errors.New("offline threshold must be at least twice the heartbeat interval")
+	errInvalidAlertsSince             = errors.New("since must be an RFC 3339 time")
+	errAlertSilenceInPast             = errors.New("alert silences must last until a time in the future")
+	errDuplicateDeviceViewColumn      = errors.New("device view columns can't be repeated")
+	errInvalidTail                    = errors.New("tail must be a number of lines that isn't negative")
+	errInvalidAccessKey               = errors.New("invalid access key")
+	errForbidden                      = errors.New("forbidden")
 )
 
 type Service struct {
@@ -48,6 +125,7 @@ type Service struct {
 	passwordRecoveryTokens     store.PasswordRecoveryTokens
 	registrationTokens         store.RegistrationTokens
 	userAccessKeys             store.UserAccessKeys
+	sshKeys                    store.SSHKeys
 	sessions                   store.Sessions
 	projects                   store.Projects
 	projectDeviceCounts        store.ProjectDeviceCounts
@@ -66,15 +144,58 @@ type Service struct {
 	applicationDeviceCounts    store.ApplicationDeviceCounts
 	releases                   store.Releases
 	releaseDeviceCounts        store.ReleaseDeviceCounts
+	environmentFiles           store.EnvironmentFiles
+	configFiles                store.ConfigFiles
+	deviceGroups               store.DeviceGroups
+	rollouts                   store.Rollouts
+	deviceReleasePins          store.DeviceReleasePins
+	sessionRecordings          store.SessionRecordings
 	deviceApplicationStatuses  store.DeviceApplicationStatuses
 	deviceServiceStatuses      store.DeviceServiceStatuses
 	metricConfigs              store.MetricConfigs
+	sshConfigs                 store.SSHConfigs
+	deviceEndpointConfigs      store.DeviceEndpointConfigs
+	agentRolloutConfigs        store.AgentRolloutConfigs
+	deviceEnvironments         store.DeviceEnvironments
+	deviceEnvironmentConfigs   store.DeviceEnvironmentConfigs
+	maintenanceWindowsConfigs  store.MaintenanceWindowsConfigs
+	releaseWebhooksConfigs     store.ReleaseWebhooksConfigs
+	applicationGitSyncs        store.ApplicationGitSyncs
+	eventWebhooksConfigs       store.EventWebhooksConfigs
+	auditLogEntries            store.AuditLogEntries
+	auditLogConfigs            store.AuditLogConfigs
+	ssoConfigs                 store.SSOConfigs
+	limitsConfigs              store.LimitsConfigs
+	deviceConnectivityEvents   store.DeviceConnectivityEvents
+	heartbeatConfigs           store.HeartbeatConfigs
+	alerts                     store.Alerts
+	alertSilences              store.AlertSilences
+	alertsConfigs              store.AlertsConfigs
+	staleDeviceCleanupConfigs  store.StaleDeviceCleanupConfigs
+	deviceViews                store.DeviceViews
+	connectivity               store.Connectivity
+	remoteSessions             store.RemoteSessions
 	email                      email.Interface
 	emailFromName              string
 	emailFromAddress           string
 	allowedEmailDomains        []string
 	st                         *statsd.Client
 	connman                    *connman.ConnectionManager
+	events                     *events.Publisher
+	oidc                       *oidc.Provider
+	saml                       *saml.Provider
+	defaultLimits              models.LimitsConfig
+	rateLimiter                *ratelimit.Limiter
+	trustedProxies             clientaddr.TrustedProxies
+
+	// shutdown is closed once the controller starts shutting down, after
+	// which readyz fails and event streams end
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	openAPIOnce     sync.Once
+	openAPIDocument *openapi.Document
+	openAPIErr      error
 
 	router   *mux.Router
 	upgrader websocket.Upgrader
@@ -86,6 +207,7 @@ func NewService(
 	passwordRecoveryTokens store.PasswordRecoveryTokens,
 	sessions store.Sessions,
 	userAccessKeys store.UserAccessKeys,
+	sshKeys store.SSHKeys,
 	projects store.Projects,
 	projectDeviceCounts store.ProjectDeviceCounts,
 	projectApplicationCounts store.ProjectApplicationCounts,
@@ -103,9 +225,37 @@ func NewService(
 	applicationDeviceCounts store.ApplicationDeviceCounts,
 	releases store.Releases,
 	releasesDeviceCounts store.ReleaseDeviceCounts,
+	environmentFiles store.EnvironmentFiles,
+	configFiles store.ConfigFiles,
+	deviceGroups store.DeviceGroups,
+	rollouts store.Rollouts,
+	deviceReleasePins store.DeviceReleasePins,
+	sessionRecordings store.SessionRecordings,
 	deviceApplicationStatuses store.DeviceApplicationStatuses,
 	deviceServiceStatuses store.DeviceServiceStatuses,
 	metricConfigs store.MetricConfigs,
+	sshConfigs store.SSHConfigs,
+	deviceEndpointConfigs store.DeviceEndpointConfigs,
+	agentRolloutConfigs store.AgentRolloutConfigs,
+	deviceEnvironments store.DeviceEnvironments,
+	deviceEnvironmentConfigs store.DeviceEnvironmentConfigs,
+	maintenanceWindowsConfigs store.MaintenanceWindowsConfigs,
+	releaseWebhooksConfigs store.ReleaseWebhooksConfigs,
+	applicationGitSyncs store.ApplicationGitSyncs,
+	eventWebhooksConfigs store.EventWebhooksConfigs,
+	auditLogEntries store.AuditLogEntries,
+	auditLogConfigs store.AuditLogConfigs,
+	ssoConfigs store.SSOConfigs,
+	limitsConfigs store.LimitsConfigs,
+	deviceConnectivityEvents store.DeviceConnectivityEvents,
+	heartbeatConfigs store.HeartbeatConfigs,
+	alerts store.Alerts,
+	alertSilences store.AlertSilences,
+	alertsConfigs store.AlertsConfigs,
+	staleDeviceCleanupConfigs store.StaleDeviceCleanupConfigs,
+	deviceViews store.DeviceViews,
+	connectivity store.Connectivity,
+	remoteSessions store.RemoteSessions,
 	email email.Interface,
 	emailFromName string,
 	emailFromAddress string,
@@ -113,6 +263,12 @@ func NewService(
 	fileSystem http.FileSystem,
 	st *statsd.Client,
 	connman *connman.ConnectionManager,
+	events *events.Publisher,
+	oidc *oidc.Provider,
+	saml *saml.Provider,
+	defaultLimits models.LimitsConfig,
+	rateLimiter *ratelimit.Limiter,
+	trustedProxies clientaddr.TrustedProxies,
 	allowedOrigins []url.URL,
 ) *Service {
 	s := &Service{
@@ -121,6 +277,7 @@ func NewService(
 		passwordRecoveryTokens:     passwordRecoveryTokens,
 		sessions:                   sessions,
 		userAccessKeys:             userAccessKeys,
+		sshKeys:                    sshKeys,
 		projects:                   projects,
 		projectDeviceCounts:        projectDeviceCounts,
 		projectApplicationCounts:   projectApplicationCounts,
@@ -138,15 +295,51 @@ func NewService(
 		applicationDeviceCounts:    applicationDeviceCounts,
 		releases:                   releases,
 		releaseDeviceCounts:        releasesDeviceCounts,
+		environmentFiles:           environmentFiles,
+		configFiles:                configFiles,
+		deviceGroups:               deviceGroups,
+		rollouts:                   rollouts,
+		deviceReleasePins:          deviceReleasePins,
+		sessionRecordings:          sessionRecordings,
 		deviceApplicationStatuses:  deviceApplicationStatuses,
 		deviceServiceStatuses:      deviceServiceStatuses,
 		metricConfigs:              metricConfigs,
+		sshConfigs:                 sshConfigs,
+		deviceEndpointConfigs:      deviceEndpointConfigs,
+		agentRolloutConfigs:        agentRolloutConfigs,
+		deviceEnvironments:         deviceEnvironments,
+		deviceEnvironmentConfigs:   deviceEnvironmentConfigs,
+		maintenanceWindowsConfigs:  maintenanceWindowsConfigs,
+		releaseWebhooksConfigs:     releaseWebhooksConfigs,
+		applicationGitSyncs:        applicationGitSyncs,
+		eventWebhooksConfigs:       eventWebhooksConfigs,
+		auditLogEntries:            auditLogEntries,
+		auditLogConfigs:            auditLogConfigs,
+		ssoConfigs:                 ssoConfigs,
+		limitsConfigs:              limitsConfigs,
+		deviceConnectivityEvents:   deviceConnectivityEvents,
+		heartbeatConfigs:           heartbeatConfigs,
+		alerts:                     alerts,
+		alertSilences:              alertSilences,
+		alertsConfigs:              alertsConfigs,
+		staleDeviceCleanupConfigs:  staleDeviceCleanupConfigs,
+		deviceViews:                deviceViews,
+		connectivity:               connectivity,
+		remoteSessions:             remoteSessions,
 		email:                      email,
 		emailFromName:              emailFromName,
 		emailFromAddress:           emailFromAddress,
 		allowedEmailDomains:        allowedEmailDomains,
 		st:                         st,
 		connman:                    connman,
+		events:                     events,
+		oidc:                       oidc,
+		saml:                       saml,
+		defaultLimits:              defaultLimits,
+		rateLimiter:                rateLimiter,
+		trustedProxies:             trustedProxies,
+
+		shutdown: make(chan struct{}),
 
 		router: mux.NewRouter(),
 		upgrader: websocket.Upgrader{
@@ -160,16 +353,22 @@ func NewService(
 	}
 
 	apiRouter := s.router.PathPrefix("/api").Subrouter()
+	apiRouter.Use(withRequestMetrics)
 
-	apiRouter.HandleFunc("/register", s.register).Methods("POST")
-	apiRouter.HandleFunc("/completeregistration", s.confirmRegistration).Methods("POST")
+	apiRouter.HandleFunc("/register", s.withAddressRateLimit(s.register)).Methods("POST")
+	apiRouter.HandleFunc("/completeregistration", s.withAddressRateLimit(s.confirmRegistration)).Methods("POST")
 
-	apiRouter.HandleFunc("/recoverpassword", s.recoverPassword).Methods("POST")
-	apiRouter.HandleFunc("/passwordrecoverytokens/{passwordrecoverytokenvalue}", s.getPasswordRecoveryToken).Methods("GET")
-	apiRouter.HandleFunc("/changepassword", s.changePassword).Methods("POST")
+	apiRouter.HandleFunc("/recoverpassword", s.withAddressRateLimit(s.recoverPassword)).Methods("POST")
+	apiRouter.HandleFunc("/passwordrecoverytokens/{passwordrecoverytokenvalue}", s.withAddressRateLimit(s.getPasswordRecoveryToken)).Methods("GET")
+	apiRouter.HandleFunc("/changepassword", s.withAddressRateLimit(s.changePassword)).Methods("POST")
 
-	apiRouter.HandleFunc("/login", s.login).Methods("POST")
-	apiRouter.HandleFunc("/logout", s.logout).Methods("POST")
+	apiRouter.HandleFunc("/login", s.withAddressRateLimit(s.login)).Methods("POST")
+	apiRouter.HandleFunc("/sso/oidc/login", s.withAddressRateLimit(s.oidcLogin)).Methods("GET")
+	apiRouter.HandleFunc("/sso/oidc/callback", s.withAddressRateLimit(s.oidcCallback)).Methods("GET")
+	apiRouter.HandleFunc("/sso/saml/metadata", s.withAddressRateLimit(s.samlMetadata)).Methods("GET")
+	apiRouter.HandleFunc("/sso/saml/login", s.withAddressRateLimit(s.samlLogin)).Methods("GET")
+	apiRouter.HandleFunc("/sso/saml/acs", s.withAddressRateLimit(s.samlACS)).Methods("POST")
+	apiRouter.HandleFunc("/logout", s.withAddressRateLimit(s.logout)).Methods("POST")
 
 	apiRouter.HandleFunc("/me", s.withUserOrServiceAccountAuth(s.getMe)).Methods("GET")
 	apiRouter.HandleFunc("/me", s.withUserOrServiceAccountAuth(s.updateMe)).Methods("PATCH")
@@ -181,10 +380,16 @@ func NewService(
 	apiRouter.HandleFunc("/useraccesskeys", s.withUserOrServiceAccountAuth(s.listUserAccessKeys)).Methods("GET")
 	apiRouter.HandleFunc("/useraccesskeys/{useraccesskey}", s.withUserOrServiceAccountAuth(s.deleteUserAccessKey)).Methods("DELETE")
 
+	apiRouter.HandleFunc("/sshkeys", s.withUserOrServiceAccountAuth(s.createSSHKey)).Methods("POST")
+	apiRouter.HandleFunc("/sshkeys/{sshkey}", s.withUserOrServiceAccountAuth(s.getSSHKey)).Methods("GET")
+	apiRouter.HandleFunc("/sshkeys", s.withUserOrServiceAccountAuth(s.listSSHKeys)).Methods("GET")
+	apiRouter.HandleFunc("/sshkeys/{sshkey}", s.withUserOrServiceAccountAuth(s.deleteSSHKey)).Methods("DELETE")
+
 	apiRouter.HandleFunc("/projects", s.withUserOrServiceAccountAuth(s.createProject)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}", s.validateAuthorization(authz.ResourceProjects, authz.ActionGetProject, s.getProject)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}", s.validateAuthorization(authz.ResourceProjects, authz.ActionUpdateProject, s.updateProject)).Methods("PUT")
 	apiRouter.HandleFunc("/projects/{project}", s.validateAuthorization(authz.ResourceProjects, authz.ActionDeleteProject, s.deleteProject)).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/events", s.validateAuthorization(authz.ResourceProjects, authz.ActionStreamEvents, s.streamEvents)).Methods("GET")
 
 	apiRouter.HandleFunc("/projects/{project}/roles", s.validateAuthorization(authz.ResourceRoles, authz.ActionCreateRole, s.createRole)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/roles/{role}", s.validateAuthorization(authz.ResourceRoles, authz.ActionGetRole, s.withRole(s.getRole))).Methods("GET")
@@ -223,28 +428,115 @@ func NewService(
 	apiRouter.HandleFunc("/projects/{project}/applications", s.validateAuthorization(authz.ResourceApplications, authz.ActionListApplications, s.listApplications)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}", s.validateAuthorization(authz.ResourceApplications, authz.ActionUpdateApplication, s.withApplication(s.updateApplication))).Methods("PATCH")
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}", s.validateAuthorization(authz.ResourceApplications, authz.ActionDeleteApplication, s.withApplication(s.deleteApplication))).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/gitsync", s.validateAuthorization(authz.ResourceApplications, authz.ActionGetApplicationGitSync, s.withApplication(s.getApplicationGitSync))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/gitsync", s.validateAuthorization(authz.ResourceApplications, authz.ActionSetApplicationGitSync, s.withApplication(s.setApplicationGitSync))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/gitsync", s.validateAuthorization(authz.ResourceApplications, authz.ActionDeleteApplicationGitSync, s.withApplication(s.deleteApplicationGitSync))).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/rollback", s.validateAuthorization(authz.ResourceApplications, authz.ActionRollbackApplication, s.withApplication(s.rollbackApplication))).Methods("POST")
 
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases", s.validateAuthorization(authz.ResourceReleases, authz.ActionCreateRelease, s.withApplication(s.createRelease))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/ci", s.validateAuthorization(authz.ResourceReleases, authz.ActionCreateRelease, s.withApplication(s.createCIRelease))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/validate", s.validateAuthorization(authz.ResourceReleases, authz.ActionValidateRelease, s.withApplication(s.validateRelease))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/compose", s.validateAuthorization(authz.ResourceReleases, authz.ActionConvertCompose, s.withApplication(s.convertCompose))).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/latest", s.validateAuthorization(authz.ResourceReleases, authz.ActionGetLatestRelease, s.withApplication(s.getLatestRelease))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/{release}", s.validateAuthorization(authz.ResourceReleases, authz.ActionGetRelease, s.withApplicationAndRelease(s.getRelease))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases/{release}/diff", s.validateAuthorization(authz.ResourceReleases, authz.ActionGetRelease, s.withApplicationAndRelease(s.diffRelease))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releases", s.validateAuthorization(authz.ResourceReleases, authz.ActionListReleases, s.withApplication(s.listReleases))).Methods("GET")
 
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/rollouts", s.validateAuthorization(authz.ResourceRollouts, authz.ActionCreateRollout, s.withApplication(s.createRollout))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/rollouts/{rollout}", s.validateAuthorization(authz.ResourceRollouts, authz.ActionGetRollout, s.withApplicationAndRollout(s.getRollout))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/rollouts", s.validateAuthorization(authz.ResourceRollouts, authz.ActionListRollouts, s.withApplication(s.listRollouts))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/releasepins", s.validateAuthorization(authz.ResourceApplications, authz.ActionListDeviceReleasePins, s.withApplication(s.listDeviceReleasePins))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/applications/{application}/rollouts/{rollout}/halt", s.validateAuthorization(authz.ResourceRollouts, authz.ActionHaltRollout, s.withApplicationAndRollout(s.haltRollout))).Methods("POST")
+
+	apiRouter.HandleFunc("/projects/{project}/environmentfiles", s.validateAuthorization(authz.ResourceEnvironmentFiles, authz.ActionCreateEnvironmentFile, s.createEnvironmentFile)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/environmentfiles/{environmentfile}", s.validateAuthorization(authz.ResourceEnvironmentFiles, authz.ActionGetEnvironmentFile, s.withEnvironmentFile(s.getEnvironmentFile))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/environmentfiles", s.validateAuthorization(authz.ResourceEnvironmentFiles, authz.ActionListEnvironmentFiles, s.listEnvironmentFiles)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/environmentfiles/{environmentfile}", s.validateAuthorization(authz.ResourceEnvironmentFiles, authz.ActionUpdateEnvironmentFile, s.withEnvironmentFile(s.updateEnvironmentFile))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/environmentfiles/{environmentfile}", s.validateAuthorization(authz.ResourceEnvironmentFiles, authz.ActionDeleteEnvironmentFile, s.withEnvironmentFile(s.deleteEnvironmentFile))).Methods("DELETE")
+
+	apiRouter.HandleFunc("/projects/{project}/devicegroups", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionCreateDeviceGroup, s.createDeviceGroup)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionGetDeviceGroup, s.withDeviceGroup(s.getDeviceGroup))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionListDeviceGroups, s.listDeviceGroups)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionUpdateDeviceGroup, s.withDeviceGroup(s.updateDeviceGroup))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionDeleteDeviceGroup, s.withDeviceGroup(s.deleteDeviceGroup))).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}/devices", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionGetDeviceGroup, s.withDeviceGroup(s.listDeviceGroupDevices))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}/devices/{device}", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionAddDeviceGroupMember, s.withDeviceGroupAndDevice(s.addDeviceGroupMember))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devicegroups/{devicegroup}/devices/{device}", s.validateAuthorization(authz.ResourceDeviceGroups, authz.ActionRemoveDeviceGroupMember, s.withDeviceGroupAndDevice(s.removeDeviceGroupMember))).Methods("DELETE")
+
+	apiRouter.HandleFunc("/projects/{project}/deviceviews", s.validateAuthorization(authz.ResourceDeviceViews, authz.ActionCreateDeviceView, s.createDeviceView)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/deviceviews/{deviceview}", s.validateAuthorization(authz.ResourceDeviceViews, authz.ActionGetDeviceView, s.withDeviceView(s.getDeviceView))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/deviceviews", s.validateAuthorization(authz.ResourceDeviceViews, authz.ActionListDeviceViews, s.listDeviceViews)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/deviceviews/{deviceview}", s.validateAuthorization(authz.ResourceDeviceViews, authz.ActionUpdateDeviceView, s.withDeviceView(s.updateDeviceView))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/deviceviews/{deviceview}", s.validateAuthorization(authz.ResourceDeviceViews, authz.ActionDeleteDeviceView, s.withDeviceView(s.deleteDeviceView))).Methods("DELETE")
+
+	apiRouter.HandleFunc("/projects/{project}/configfiles", s.validateAuthorization(authz.ResourceConfigFiles, authz.ActionCreateConfigFile, s.createConfigFile)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/configfiles/{configfile}", s.validateAuthorization(authz.ResourceConfigFiles, authz.ActionGetConfigFile, s.withConfigFile(s.getConfigFile))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/configfiles", s.validateAuthorization(authz.ResourceConfigFiles, authz.ActionListConfigFiles, s.listConfigFiles)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/configfiles/{configfile}", s.validateAuthorization(authz.ResourceConfigFiles, authz.ActionUpdateConfigFile, s.withConfigFile(s.updateConfigFile))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/configfiles/{configfile}", s.validateAuthorization(authz.ResourceConfigFiles, authz.ActionDeleteConfigFile, s.withConfigFile(s.deleteConfigFile))).Methods("DELETE")
+
+	apiRouter.HandleFunc("/projects/{project}/sessionrecordings/{sessionrecording}", s.validateAuthorization(authz.ResourceSessionRecordings, authz.ActionGetSessionRecording, s.getSessionRecording)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/sessionrecordings/{sessionrecording}/recording", s.validateAuthorization(authz.ResourceSessionRecordings, authz.ActionGetSessionRecording, s.getSessionRecordingContent)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/sessionrecordings", s.validateAuthorization(authz.ResourceSessionRecordings, authz.ActionListSessionRecordings, s.listSessionRecordings)).Methods("GET")
+
+	apiRouter.HandleFunc("/projects/{project}/auditlog", s.validateAuthorization(authz.ResourceAuditLog, authz.ActionListAuditLogEntries, s.listAuditLogEntries)).Methods("GET")
+
+	apiRouter.HandleFunc("/projects/{project}/alerts", s.validateAuthorization(authz.ResourceAlerts, authz.ActionListAlerts, s.listAlerts)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/alertsilences", s.validateAuthorization(authz.ResourceAlerts, authz.ActionListAlertSilences, s.listAlertSilences)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/alertsilences", s.validateAuthorization(authz.ResourceAlerts, authz.ActionCreateAlertSilence, s.createAlertSilence)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/alertsilences/{alertsilence}", s.validateAuthorization(authz.ResourceAlerts, authz.ActionDeleteAlertSilence, s.deleteAlertSilence)).Methods("DELETE")
+
+	apiRouter.HandleFunc("/projects/{project}/devices/export", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.exportDevices)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/stale", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.getStaleDeviceCleanupReport)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/drift", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.getDriftReport)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/versions", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.getDeviceVersionsReport)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetDevice, s.withDevice(s.getDevice))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.listDevices)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/bulk", s.validateAuthorization(authz.ResourceDevices, authz.ActionListDevices, s.bulkDeviceOperation)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/previewscheduling/{application}", s.validateAuthorization(authz.ResourceDevices, authz.ActionPreviewApplicationScheduling, s.previewScheduledDevices)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}", s.validateAuthorization(authz.ResourceDevices, authz.ActionUpdateDevice, s.withDevice(s.updateDevice))).Methods("PATCH")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}", s.validateAuthorization(authz.ResourceDevices, authz.ActionDeleteDevice, s.withDevice(s.deleteDevice))).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/decommission", s.validateAuthorization(authz.ResourceDevices, authz.ActionDecommissionDevice, s.withDevice(s.decommissionDevice))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/transfer", s.validateAuthorization(authz.ResourceDevices, authz.ActionTransferDevice, s.withDevice(s.transferDevice))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/approve", s.validateAuthorization(authz.ResourceDevices, authz.ActionApproveDevice, s.withDevice(s.approveDevice))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/connectivity", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetDevice, s.withDevice(s.getDeviceConnectivityHistory))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/drift", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetDevice, s.withDevice(s.getDeviceDrift))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/agentversion", s.validateAuthorization(authz.ResourceDevices, authz.ActionUpdateDevice, s.withDevice(s.setDeviceAgentVersion))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/releasepin", s.validateAuthorization(authz.ResourceDevices, authz.ActionSetDeviceReleasePin, s.withApplicationAndDevice(s.setDeviceReleasePin))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/releasepin", s.validateAuthorization(authz.ResourceDevices, authz.ActionDeleteDeviceReleasePin, s.withApplicationAndDevice(s.deleteDeviceReleasePin))).Methods("DELETE")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/ssh", s.validateAuthorization(authz.ResourceDevices, authz.ActionSSH, s.withDevice(s.initiateSSH))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/terminal", s.validateAuthorization(authz.ResourceDevices, authz.ActionSSH, s.withDevice(s.initiateTerminal))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/portforward", s.validateAuthorization(authz.ResourceDevices, authz.ActionPortForward, s.withDevice(s.initiatePortForward))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/remoteaccess/{profile}", s.validateAuthorization(authz.ResourceDevices, authz.ActionPortForward, s.withDevice(s.initiateRemoteAccess))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/files", s.validateAuthorization(authz.ResourceDevices, authz.ActionDownloadFile, s.withDevice(s.downloadFile))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/files", s.validateAuthorization(authz.ResourceDevices, authz.ActionUploadFile, s.withDevice(s.uploadFile))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/filebrowser/write", s.validateAuthorization(authz.ResourceDevices, authz.ActionWriteBrowsedFile, s.withDevice(s.writeBrowsedFile))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/filebrowser/{operation}", s.validateAuthorization(authz.ResourceDevices, authz.ActionBrowseFiles, s.withDevice(s.browseFiles))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/hostcommands", s.validateAuthorization(authz.ResourceDevices, authz.ActionListHostCommands, s.withDevice(s.listHostCommands))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/hostcommands/{hostcommand}", s.validateAuthorization(authz.ResourceDevices, authz.ActionRunHostCommand, s.withDevice(s.runHostCommand))).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/reboot", s.validateAuthorization(authz.ResourceDevices, authz.ActionReboot, s.withDevice(s.initiateReboot))).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/shutdown", s.validateAuthorization(authz.ResourceDevices, authz.ActionShutdown, s.withDevice(s.initiateShutdown))).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/imagepullprogress", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetImagePullProgress, s.withDevice(s.imagePullProgress))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/metrics/host", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetMetrics, s.withDevice(s.hostMetrics))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/metrics/agent", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetMetrics, s.withDevice(s.agentMetrics))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/metrics/ingested", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetMetrics, s.withDevice(s.ingestedMetrics))).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/metrics", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetServiceMetrics, s.withApplicationAndDevice(s.serviceMetrics))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/exec", s.validateAuthorization(authz.ResourceDevices, authz.ActionExec, s.withApplicationAndDevice(s.initiateExec))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/terminal", s.validateAuthorization(authz.ResourceDevices, authz.ActionExec, s.withApplicationAndDevice(s.initiateServiceTerminal))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/portforward", s.validateAuthorization(authz.ResourceDevices, authz.ActionPortForward, s.withApplicationAndDevice(s.initiateServicePortForward))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/stats", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetServiceStats, s.withApplicationAndDevice(s.serviceStats))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/logs", s.validateAuthorization(authz.ResourceDevices, authz.ActionGetServiceLogs, s.withApplicationAndDevice(s.serviceLogs))).Methods("GET")
+	apiRouter.PathPrefix("/projects/{project}/devices/{device}/port/{port}").HandlerFunc(s.validateAuthorization(authz.ResourceDevices, authz.ActionAccessDeviceEndpoint, s.withDevice(s.deviceEndpoint)))
+	apiRouter.PathPrefix("/projects/{project}/devices/{device}/applications/{application}/services/{service}/port/{port}").HandlerFunc(s.validateAuthorization(authz.ResourceDevices, authz.ActionAccessDeviceEndpoint, s.withApplicationAndDevice(s.serviceEndpoint)))
 	apiRouter.PathPrefix("/projects/{project}/devices/{device}/debug/").HandlerFunc(s.validateAuthorization(authz.ResourceDevices, authz.ActionGetMetrics, s.withDevice(s.deviceDebug)))
 
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/labels", s.validateAuthorization(authz.ResourceDeviceLabels, authz.ActionSetDeviceLabel, s.withDevice(s.setDeviceLabel))).Methods("PUT")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/labels/{key}", s.validateAuthorization(authz.ResourceDeviceLabels, authz.ActionDeleteDeviceLabel, s.withDevice(s.deleteDeviceLabel))).Methods("DELETE")
 
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/environment", s.validateAuthorization(authz.ResourceDeviceEnvironments, authz.ActionGetDeviceEnvironment, s.withDevice(s.getDeviceEnvironment))).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/environment", s.validateAuthorization(authz.ResourceDeviceEnvironments, authz.ActionSetDeviceEnvironmentVariable, s.withDevice(s.setDeviceEnvironmentVariable))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/environment/{key}", s.validateAuthorization(authz.ResourceDeviceEnvironments, authz.ActionDeleteDeviceEnvironmentVariable, s.withDevice(s.deleteDeviceEnvironmentVariable))).Methods("DELETE")
+
 	apiRouter.HandleFunc("/projects/{project}/devicelabels", s.validateAuthorization(authz.ResourceDeviceLabels, authz.ActionListAllDeviceLabels, s.listAllDeviceLabelKeys)).Methods("GET")
 
 	apiRouter.HandleFunc("/projects/{project}/deviceregistrationtokens", s.validateAuthorization(authz.ResourceDeviceRegistrationTokens, authz.ActionListDeviceRegistrationTokens, s.listDeviceRegistrationTokens)).Methods("GET")
@@ -255,20 +547,25 @@ func NewService(
 
 	apiRouter.HandleFunc("/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/labels", s.validateAuthorization(authz.ResourceDeviceRegistrationTokenLabels, authz.ActionSetDeviceRegistrationTokenLabel, s.withDeviceRegistrationToken(s.setDeviceRegistrationTokenLabel))).Methods("PUT")
 	apiRouter.HandleFunc("/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/labels/{key}", s.validateAuthorization(authz.ResourceDeviceRegistrationTokenLabels, authz.ActionDeleteDeviceRegistrationTokenLabel, s.withDeviceRegistrationToken(s.deleteDeviceRegistrationTokenLabel))).Methods("DELETE")
+	apiRouter.HandleFunc("/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/environment", s.validateAuthorization(authz.ResourceDeviceRegistrationTokens, authz.ActionSetDeviceRegistrationTokenEnvironmentVariable, s.withDeviceRegistrationToken(s.setDeviceRegistrationTokenEnvironmentVariable))).Methods("PUT")
+	apiRouter.HandleFunc("/projects/{project}/deviceregistrationtokens/{deviceregistrationtoken}/environment/{key}", s.validateAuthorization(authz.ResourceDeviceRegistrationTokens, authz.ActionDeleteDeviceRegistrationTokenEnvironmentVariable, s.withDeviceRegistrationToken(s.deleteDeviceRegistrationTokenEnvironmentVariable))).Methods("DELETE")
 
 	apiRouter.HandleFunc("/projects/{project}/configs/{key}", s.validateAuthorization(authz.ResourceProjectConfigs, authz.ActionGetProjectConfig, s.getProjectConfig)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/configs/{key}", s.validateAuthorization(authz.ResourceProjectConfigs, authz.ActionSetProjectConfig, s.setProjectConfig)).Methods("PUT")
 
-	apiRouter.HandleFunc("/projects/{project}/devices/register", s.registerDevice).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/register", s.withAddressRateLimit(s.registerDevice)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/bundle", s.withDeviceAuth(s.getBundle)).Methods("GET")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/info", s.withDeviceAuth(s.setDeviceInfo)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/decommissioned", s.withDeviceAuth(s.finishDeviceDecommission)).Methods("POST")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/transferred", s.withDeviceAuth(s.finishDeviceTransfer)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/deviceapplicationstatuses", s.withDeviceAuth(s.setDeviceApplicationStatus)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/deviceapplicationstatuses", s.withDeviceAuth(s.deleteDeviceApplicationStatus)).Methods("DELETE")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/deviceservicestatuses", s.withDeviceAuth(s.setDeviceServiceStatus)).Methods("POST")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/applications/{application}/services/{service}/deviceservicestatuses", s.withDeviceAuth(s.deleteDeviceServiceStatus)).Methods("DELETE")
 	apiRouter.HandleFunc("/projects/{project}/devices/{device}/connection", s.withDeviceAuth(s.initiateDeviceConnection)).Methods("GET")
+	apiRouter.HandleFunc("/projects/{project}/devices/{device}/sessionrecordings/{sessionrecording}", s.withDeviceAuth(s.finishSessionRecording)).Methods("POST")
 
-	apiRouter.Handle("/revdial", revdial.ConnHandler(s.upgrader)).Methods("GET")
+	apiRouter.HandleFunc("/revdial", s.withAddressRateLimit(revdial.ConnHandler(s.upgrader).ServeHTTP)).Methods("GET")
 
 	debugRouter := apiRouter.PathPrefix("/debug/").Subrouter()
 	debugRouter.HandleFunc("/pprof/cmdline", s.withSuperUserAuth(pprof.Cmdline))
@@ -278,12 +575,17 @@ func NewService(
 	debugRouter.PathPrefix("/pprof/").Handler(http.StripPrefix("/api", http.HandlerFunc(s.withSuperUserAuth(pprof.Index))))
 
 	apiRouter.HandleFunc("/health", s.health).Methods("GET")
+	apiRouter.HandleFunc("/openapi.json", s.withAddressRateLimit(s.getOpenAPIDocument)).Methods("GET")
+	apiRouter.HandleFunc("/internal/deviceconnections/{key}", s.relayDeviceConnection).Methods("GET")
 	apiRouter.HandleFunc("/500", s.withSuperUserAuth(s.intentional500)).Methods("GET")
 
 	s.router.PathPrefix("/api").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
 	})
 
+	s.router.HandleFunc("/healthz", s.healthz).Methods("GET")
+	s.router.HandleFunc("/readyz", s.readyz).Methods("GET")
+
 	s.router.PathPrefix("/").Handler(spaserver.NewSPAFileServer(fileSystem))
 
 	return s
@@ -302,6 +604,20 @@ func (s *Service) intentional500(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Service) withUserOrServiceAccountAuth(handler func(http.ResponseWriter, *http.Request, string, string)) func(http.ResponseWriter, *http.Request) {
+	return s.withUserOrServiceAccountAccessKeyAuth(
+		func(w http.ResponseWriter, r *http.Request, authenticatedUserID string, authenticatedServiceAccountAccessKey *models.ServiceAccountAccessKey) {
+			if authenticatedServiceAccountAccessKey != nil {
+				handler(w, r, "", authenticatedServiceAccountAccessKey.ServiceAccountID)
+				return
+			}
+			handler(w, r, authenticatedUserID, "")
+		},
+	)
+}
+
+// withUserOrServiceAccountAccessKeyAuth is withUserOrServiceAccountAuth for
+// handlers that need the access key a service account authenticated with.
+func (s *Service) withUserOrServiceAccountAccessKeyAuth(handler func(http.ResponseWriter, *http.Request, string, *models.ServiceAccountAccessKey)) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var userID string
 		var serviceAccountAccessKey *models.ServiceAccountAccessKey
@@ -312,7 +628,7 @@ func (s *Service) withUserOrServiceAccountAuth(handler func(http.ResponseWriter,
 		case nil:
 			session, err := s.sessions.ValidateSession(r.Context(), hash.Hash(sessionValue.Value))
 			if err == store.ErrSessionNotFound {
-				w.WriteHeader(http.StatusUnauthorized)
+				s.unauthorized(w, r, nil)
 				return
 			} else if err != nil {
 				log.WithError(err).Error("validate session")
@@ -323,44 +639,25 @@ func (s *Service) withUserOrServiceAccountAuth(handler func(http.ResponseWriter,
 			userID = session.UserID
 		case http.ErrNoCookie:
 			accessKeyValue, _, _ := r.BasicAuth()
-			if accessKeyValue == "" {
-				w.WriteHeader(http.StatusUnauthorized)
+			userID, serviceAccountAccessKey, err = s.validateAccessKey(r.Context(), accessKeyValue)
+			if err == errInvalidAccessKey {
+				s.unauthorized(w, r, nil)
 				return
-			}
-
-			if strings.HasPrefix(accessKeyValue, "u") {
-				userAccessKey, err := s.userAccessKeys.ValidateUserAccessKey(r.Context(), hash.Hash(accessKeyValue))
-				if err == store.ErrUserAccessKeyNotFound {
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				} else if err != nil {
-					log.WithError(err).Error("validate user access key")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-
-				userID = userAccessKey.UserID
-			} else if strings.HasPrefix(accessKeyValue, "s") {
-				serviceAccountAccessKey, err = s.serviceAccountAccessKeys.ValidateServiceAccountAccessKey(r.Context(), hash.Hash(accessKeyValue))
-				if err == store.ErrServiceAccountAccessKeyNotFound {
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				} else if err != nil {
-					log.WithError(err).Error("validate service account access key")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-			} else {
-				w.WriteHeader(http.StatusUnauthorized)
+			} else if err == errServiceAccountAccessKeyExpired {
+				s.unauthorized(w, r, err)
+				return
+			} else if err != nil {
+				log.WithError(err).Error("validate access key")
+				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 		default:
-			w.WriteHeader(http.StatusUnauthorized)
+			s.unauthorized(w, r, nil)
 			return
 		}
 
 		if userID == "" && serviceAccountAccessKey == nil {
-			w.WriteHeader(http.StatusUnauthorized)
+			s.unauthorized(w, r, nil)
 			return
 		}
 
@@ -377,17 +674,24 @@ func (s *Service) withUserOrServiceAccountAuth(handler func(http.ResponseWriter,
 				return
 			}
 
-			handler(w, r, userID, "")
+			if !s.rateLimit(w, r, "user:"+userID) {
+				return
+			}
+
+			handler(w, r, userID, nil)
 		} else if serviceAccountAccessKey != nil {
-			serviceAccount, err := s.serviceAccounts.GetServiceAccount(r.Context(),
-				serviceAccountAccessKey.ServiceAccountID, serviceAccountAccessKey.ProjectID)
-			if err != nil {
+			if _, err := s.serviceAccounts.GetServiceAccount(r.Context(),
+				serviceAccountAccessKey.ServiceAccountID, serviceAccountAccessKey.ProjectID); err != nil {
 				log.WithError(err).Error("get service account")
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
 
-			handler(w, r, "", serviceAccount.ID)
+			if !s.rateLimit(w, r, "serviceaccount:"+serviceAccountAccessKey.ServiceAccountID) {
+				return
+			}
+
+			handler(w, r, "", serviceAccountAccessKey)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -395,6 +699,34 @@ func (s *Service) withUserOrServiceAccountAuth(handler func(http.ResponseWriter,
 	}
 }
 
+// validateAccessKey returns the user, or the service account access key,
+// that an access key belongs to.
+func (s *Service) validateAccessKey(ctx context.Context, accessKeyValue string) (string, *models.ServiceAccountAccessKey, error) {
+	switch {
+	case strings.HasPrefix(accessKeyValue, "u"):
+		userAccessKey, err := s.userAccessKeys.ValidateUserAccessKey(ctx, hash.Hash(accessKeyValue))
+		if err == store.ErrUserAccessKeyNotFound {
+			return "", nil, errInvalidAccessKey
+		} else if err != nil {
+			return "", nil, errors.Wrap(err, "validate user access key")
+		}
+		return userAccessKey.UserID, nil, nil
+	case strings.HasPrefix(accessKeyValue, "s"):
+		serviceAccountAccessKey, err := s.serviceAccountAccessKeys.ValidateServiceAccountAccessKey(ctx, hash.Hash(accessKeyValue))
+		if err == store.ErrServiceAccountAccessKeyNotFound {
+			return "", nil, errInvalidAccessKey
+		} else if err != nil {
+			return "", nil, errors.Wrap(err, "validate service account access key")
+		}
+		if serviceAccountAccessKey.Expired(time.Now()) {
+			return "", nil, errServiceAccountAccessKeyExpired
+		}
+		return "", serviceAccountAccessKey, nil
+	default:
+		return "", nil, errInvalidAccessKey
+	}
+}
+
 func (s *Service) withSuperUserAuth(handler func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
 	return s.withUserOrServiceAccountAuth(
 		func(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
@@ -421,7 +753,12 @@ func (s *Service) withSuperUserAuth(handler func(http.ResponseWriter, *http.Requ
 }
 
 func (s *Service) validateAuthorization(requestedResource authz.Resource, requestedAction authz.Action, handler func(http.ResponseWriter, *http.Request, string, string, string)) func(http.ResponseWriter, *http.Request) {
-	return s.withUserOrServiceAccountAuth(func(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	return s.withUserOrServiceAccountAccessKeyAuth(func(w http.ResponseWriter, r *http.Request, authenticatedUserID string, authenticatedServiceAccountAccessKey *models.ServiceAccountAccessKey) {
+		var authenticatedServiceAccountID string
+		if authenticatedServiceAccountAccessKey != nil {
+			authenticatedServiceAccountID = authenticatedServiceAccountAccessKey.ServiceAccountID
+		}
+
 		vars := mux.Vars(r)
 		project := vars["project"]
 		if project == "" {
@@ -430,120 +767,282 @@ func (s *Service) validateAuthorization(requestedResource authz.Resource, reques
 		}
 
 		// TODO: move this logic into a middleware function
-		var projectID string
-		if strings.Contains(project, "_") {
-			projectID = project
-		} else {
-			project, err := s.projects.LookupProject(r.Context(), project)
-			if err == store.ErrProjectNotFound {
-				http.Error(w, err.Error(), http.StatusNotFound)
-				return
-			} else if err != nil {
-				log.WithError(err).Error("lookup project")
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			projectID = project.ID
+		projectID, err := s.lookupProjectID(r.Context(), project)
+		if err == store.ErrProjectNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("lookup project")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
 		}
 
-		var roles []string
-		superAdmin := false
-		if authenticatedUserID != "" {
-			authenticatedUser, err := s.users.GetUser(r.Context(), authenticatedUserID)
-			if err != nil {
-				log.WithError(err).Error("get user")
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+		// Denied requests are recorded too
+		if !authz.IsReadAction(requestedAction) {
+			entry := s.startAuditLogEntry(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, requestedAction)
+			defer entry.finish()
+			w = entry
+		}
 
-			if authenticatedUser.SuperAdmin {
-				superAdmin = true
-			} else {
-				if _, err := s.memberships.GetMembership(r.Context(),
-					authenticatedUserID, projectID,
-				); err == store.ErrMembershipNotFound {
-					http.Error(w, err.Error(), http.StatusNotFound)
-					return
-				} else if err != nil {
-					// TODO: better logging all around
-					log.WithField("user_id", authenticatedUserID).
-						WithField("project_id", projectID).
-						WithError(err).
-						Error("get membership")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
+		switch err := s.authorize(r.Context(), projectID, authenticatedUserID, authenticatedServiceAccountAccessKey,
+			requestedResource, requestedAction, vars["device"], vars["application"],
+		); err {
+		case nil:
+		case store.ErrMembershipNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errRestrictedAccessKey:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		case errForbidden:
+			w.WriteHeader(http.StatusForbidden)
+			return
+		default:
+			log.WithField("user_id", authenticatedUserID).
+				WithField("service_account_id", authenticatedServiceAccountID).
+				WithField("project_id", projectID).
+				WithError(err).
+				Error("authorize")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 
-				roleBindings, err := s.membershipRoleBindings.ListMembershipRoleBindings(r.Context(),
-					authenticatedUserID, projectID)
-				if err != nil {
-					log.WithError(err).Error("list membership role bindings")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID)
+	})
+}
 
-				for _, roleBinding := range roleBindings {
-					roles = append(roles, roleBinding.RoleID)
-				}
-			}
-		} else if authenticatedServiceAccountID != "" {
-			// Sanity check that this service account belongs to this project
-			if _, err := s.serviceAccounts.GetServiceAccount(r.Context(),
-				authenticatedServiceAccountID, projectID,
-			); err == store.ErrServiceAccountNotFound {
-				w.WriteHeader(http.StatusForbidden)
-				return
+// lookupProjectID returns the ID of a project, given either its ID or its
+// name.
+func (s *Service) lookupProjectID(ctx context.Context, project string) (string, error) {
+	if strings.Contains(project, "_") {
+		return project, nil
+	}
+	p, err := s.projects.LookupProject(ctx, project)
+	if err != nil {
+		return "", err
+	}
+	return p.ID, nil
+}
+
+// authorize checks that a user, or a service account authenticated with one
+// of its access keys, is allowed to take an action in a project. The device
+// and application are the ones the action is on, by ID or name, if any.
+func (s *Service) authorize(ctx context.Context, projectID, userID string, serviceAccountAccessKey *models.ServiceAccountAccessKey,
+	requestedResource authz.Resource, requestedAction authz.Action, device, application string,
+) error {
+	var roles []string
+	superAdmin := false
+	if userID != "" {
+		user, err := s.users.GetUser(ctx, userID)
+		if err != nil {
+			return errors.Wrap(err, "get user")
+		}
+
+		if user.SuperAdmin {
+			superAdmin = true
+		} else {
+			if _, err := s.memberships.GetMembership(ctx, userID, projectID); err == store.ErrMembershipNotFound {
+				return err
 			} else if err != nil {
-				log.WithError(err).Error("get service account")
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+				return errors.Wrap(err, "get membership")
 			}
 
-			roleBindings, err := s.serviceAccountRoleBindings.ListServiceAccountRoleBindings(r.Context(),
-				authenticatedServiceAccountID, projectID)
+			roleBindings, err := s.membershipRoleBindings.ListMembershipRoleBindings(ctx, userID, projectID)
 			if err != nil {
-				log.WithError(err).Error("list service account role bindings")
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+				return errors.Wrap(err, "list membership role bindings")
 			}
 
 			for _, roleBinding := range roleBindings {
 				roles = append(roles, roleBinding.RoleID)
 			}
 		}
+	} else if serviceAccountAccessKey != nil {
+		// Sanity check that this service account belongs to this project
+		if _, err := s.serviceAccounts.GetServiceAccount(ctx,
+			serviceAccountAccessKey.ServiceAccountID, projectID,
+		); err == store.ErrServiceAccountNotFound {
+			return errForbidden
+		} else if err != nil {
+			return errors.Wrap(err, "get service account")
+		}
 
-		var configs []authz.Config
-		if superAdmin {
-			configs = []authz.Config{
-				authz.AdminAllRole,
-			}
-		} else {
-			for _, roleID := range roles {
-				role, err := s.roles.GetRole(r.Context(), roleID, projectID)
-				if err == store.ErrRoleNotFound {
-					continue
-				} else if err != nil {
-					log.WithError(err).Error("get role")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				var config authz.Config
-				if err := yaml.Unmarshal([]byte(role.Config), &config); err != nil {
-					log.WithError(err).Error("unmarshal role config")
-					w.WriteHeader(http.StatusInternalServerError)
-					return
-				}
-				configs = append(configs, config)
-			}
+		roleBindings, err := s.serviceAccountRoleBindings.ListServiceAccountRoleBindings(ctx,
+			serviceAccountAccessKey.ServiceAccountID, projectID)
+		if err != nil {
+			return errors.Wrap(err, "list service account role bindings")
 		}
 
-		if !authz.Evaluate(requestedResource, requestedAction, configs) {
-			w.WriteHeader(http.StatusForbidden)
-			return
+		for _, roleBinding := range roleBindings {
+			roles = append(roles, roleBinding.RoleID)
 		}
+	}
 
-		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID)
-	})
+	var configs []authz.Config
+	if superAdmin {
+		configs = []authz.Config{
+			authz.AdminAllRole,
+		}
+	} else {
+		var err error
+		configs, err = s.getRoleConfigs(ctx, projectID, roles)
+		if err != nil {
+			return errors.Wrap(err, "get role configs")
+		}
+	}
+
+	// Restricted access keys can only do what both their own config and
+	// the service account's roles allow
+	var accessKeyConfigs []authz.Config
+	if serviceAccountAccessKey != nil && serviceAccountAccessKey.Restricted() {
+		// Otherwise they could create unrestricted keys for themselves
+		if requestedAction == authz.ActionCreateServiceAccountAccessKey {
+			return errRestrictedAccessKey
+		}
+
+		var accessKeyConfig authz.Config
+		if err := yaml.Unmarshal([]byte(serviceAccountAccessKey.Config), &accessKeyConfig); err != nil {
+			return errors.Wrap(err, "unmarshal service account access key config")
+		}
+		accessKeyConfigs = []authz.Config{accessKeyConfig}
+	}
+	allConfigs := append(append([]authz.Config{}, configs...), accessKeyConfigs...)
+
+	var scope authz.Scope
+	if device != "" && authz.HasDeviceGroupRules(allConfigs) {
+		inGroups, err := s.deviceInGroups(ctx, projectID, device)
+		if err != nil {
+			return errors.Wrap(err, "get device groups")
+		}
+		scope.InDeviceGroups = inGroups
+	}
+	if application != "" && authz.HasApplicationRules(allConfigs) {
+		isApplication, err := s.applicationIs(ctx, projectID, application)
+		if err != nil {
+			return errors.Wrap(err, "get application")
+		}
+		scope.IsApplication = isApplication
+	}
+	if !authz.EvaluateScoped(requestedResource, requestedAction, configs, scope) {
+		return errForbidden
+	}
+	if accessKeyConfigs != nil && !authz.EvaluateScoped(requestedResource, requestedAction, accessKeyConfigs, scope) {
+		return errForbidden
+	}
+	return nil
+}
+
+func (s *Service) getRoleConfigs(ctx context.Context, projectID string, roleIDs []string) ([]authz.Config, error) {
+	var configs []authz.Config
+	for _, roleID := range roleIDs {
+		role, err := s.roles.GetRole(ctx, roleID, projectID)
+		if err == store.ErrRoleNotFound {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrap(err, "get role")
+		}
+		var config authz.Config
+		if err := yaml.Unmarshal([]byte(role.Config), &config); err != nil {
+			return nil, errors.Wrap(err, "unmarshal role config")
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// deviceInGroups returns a function that reports whether a device, given by
+// name or ID, is in any of a set of groups. Devices that don't exist aren't
+// in any group, and are reported as not found by the handler.
+func (s *Service) deviceInGroups(ctx context.Context, projectID, device string) (func([]string) bool, error) {
+	var d *models.Device
+	var err error
+	if strings.Contains(device, "_") {
+		d, err = s.devices.GetDevice(ctx, device, projectID)
+	} else {
+		d, err = s.devices.LookupDevice(ctx, device, projectID)
+	}
+	if err == store.ErrDeviceNotFound {
+		return func([]string) bool {
+			return false
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	groups, err := devicegroups.LoadDevice(ctx, s.deviceGroups, projectID, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(names []string) bool {
+		return devicegroups.InAny(*d, groups, names)
+	}, nil
+}
+
+// applicationIs returns a function that reports whether an application,
+// given by name or ID, is any of a set of applications given by name or ID.
+// Applications that don't exist aren't any of them, and are reported as not
+// found by the handler.
+func (s *Service) applicationIs(ctx context.Context, projectID, application string) (func([]string) bool, error) {
+	var a *models.Application
+	var err error
+	if strings.Contains(application, "_") {
+		a, err = s.applications.GetApplication(ctx, application, projectID)
+	} else {
+		a, err = s.applications.LookupApplication(ctx, application, projectID)
+	}
+	if err == store.ErrApplicationNotFound {
+		return func([]string) bool {
+			return false
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return func(names []string) bool {
+		for _, name := range names {
+			if name == a.ID || name == a.Name {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// userAuthorized returns whether a user may perform an action in a project
+// other than the one a request was authorized for.
+func (s *Service) userAuthorized(ctx context.Context, userID, projectID string,
+	requestedResource authz.Resource, requestedAction authz.Action,
+) (bool, error) {
+	user, err := s.users.GetUser(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "get user")
+	}
+	if user.SuperAdmin {
+		return true, nil
+	}
+
+	if _, err := s.memberships.GetMembership(ctx, userID, projectID); err == store.ErrMembershipNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Wrap(err, "get membership")
+	}
+
+	roleBindings, err := s.membershipRoleBindings.ListMembershipRoleBindings(ctx, userID, projectID)
+	if err != nil {
+		return false, errors.Wrap(err, "list membership role bindings")
+	}
+
+	var roles []string
+	for _, roleBinding := range roleBindings {
+		roles = append(roles, roleBinding.RoleID)
+	}
+
+	configs, err := s.getRoleConfigs(ctx, projectID, roles)
+	if err != nil {
+		return false, err
+	}
+
+	return authz.Evaluate(requestedResource, requestedAction, configs), nil
 }
 
 func (s *Service) register(w http.ResponseWriter, r *http.Request) {
@@ -560,29 +1059,11 @@ func (s *Service) register(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		emailDomain, err := utils.GetDomainFromEmail(registerRequest.Email)
-		if err != nil {
+		if err := s.checkEmailDomain(registerRequest.Email); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		emailDomainAllowed := false
-		if len(s.allowedEmailDomains) == 0 {
-			emailDomainAllowed = true
-		} else {
-			for _, allowedEmailDomain := range s.allowedEmailDomains {
-				if allowedEmailDomain == emailDomain {
-					emailDomainAllowed = true
-					break
-				}
-			}
-		}
-
-		if !emailDomainAllowed {
-			http.Error(w, errEmailDomainNotAllowed.Error(), http.StatusBadRequest)
-			return
-		}
-
 		if _, err := s.users.LookupUser(r.Context(), registerRequest.Email); err == nil {
 			http.Error(w, errEmailAlreadyTaken.Error(), http.StatusBadRequest)
 			return
@@ -641,6 +1122,25 @@ func (s *Service) register(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// checkEmailDomain returns an error unless users with an email address are
+// allowed to sign up.
+func (s *Service) checkEmailDomain(emailAddress string) error {
+	emailDomain, err := utils.GetDomainFromEmail(emailAddress)
+	if err != nil {
+		return err
+	}
+
+	if len(s.allowedEmailDomains) == 0 {
+		return nil
+	}
+	for _, allowedEmailDomain := range s.allowedEmailDomains {
+		if allowedEmailDomain == emailDomain {
+			return nil
+		}
+	}
+	return errEmailDomainNotAllowed
+}
+
 func (s *Service) confirmRegistration(w http.ResponseWriter, r *http.Request) {
 	var confirmRegistrationRequest struct {
 		RegistrationTokenValue string `json:"registrationTokenValue" validate:"required,min=1,max=1000"`
@@ -808,35 +1308,38 @@ func (s *Service) login(w http.ResponseWriter, r *http.Request) {
 
 func (s *Service) newSession(w http.ResponseWriter, r *http.Request, userID string) {
 	utils.WithReferrer(w, r, func(referrer *url.URL) {
-		sessionValue := ksuid.New().String()
-
-		if _, err := s.sessions.CreateSession(r.Context(), userID, hash.Hash(sessionValue)); err != nil {
+		if err := s.setSessionCookie(w, r, userID, referrer.Scheme == "https"); err != nil {
 			log.WithError(err).Error("create session")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
+	})
+}
 
-		var secure bool
-		switch referrer.Scheme {
-		case "http":
-			secure = false
-		case "https":
-			secure = true
-		}
+// setSessionCookie creates a session for a user and sets its cookie.
+func (s *Service) setSessionCookie(w http.ResponseWriter, r *http.Request, userID string, secure bool) error {
+	sessionValue := ksuid.New().String()
 
-		cookie := &http.Cookie{
-			Name:  sessionCookie,
-			Value: sessionValue,
+	if _, err := s.sessions.CreateSession(r.Context(), userID, hash.Hash(sessionValue)); err != nil {
+		return err
+	}
 
-			Expires: time.Now().AddDate(0, 1, 0),
+	cookie := &http.Cookie{
+		Name:  sessionCookie,
+		Value: sessionValue,
 
-			Domain:   r.Host,
-			Secure:   secure,
-			HttpOnly: true,
-		}
+		Expires: time.Now().AddDate(0, 1, 0),
 
-		http.SetCookie(w, cookie)
-	})
+		// Set explicitly, since sessions are created from paths other than
+		// /api/login
+		Path:     "/api",
+		Domain:   r.Host,
+		Secure:   secure,
+		HttpOnly: true,
+	}
+
+	http.SetCookie(w, cookie)
+	return nil
 }
 
 func (s *Service) logout(w http.ResponseWriter, r *http.Request) {
@@ -1106,45 +1609,133 @@ func (s *Service) deleteUserAccessKey(w http.ResponseWriter, r *http.Request, au
 	}
 }
 
-func (s *Service) createProject(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
-	var createProjectRequest struct {
-		Name string `json:"name" validate:"name"`
-	}
-	if err := read(r, &createProjectRequest); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func (s *Service) createSSHKey(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	if authenticatedUserID == "" {
+		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
-	if _, err := s.projects.LookupProject(r.Context(), createProjectRequest.Name); err == nil {
-		http.Error(w, store.ErrProjectNameAlreadyInUse.Error(), http.StatusBadRequest)
-		return
-	} else if err != nil && err != store.ErrProjectNotFound {
-		log.WithError(err).Error("lookup project")
-		w.WriteHeader(http.StatusInternalServerError)
+	var createSSHKeyRequest struct {
+		Name      string `json:"name" validate:"required,min=1,max=100"`
+		PublicKey string `json:"publicKey" validate:"required"`
+	}
+	if err := read(r, &createSSHKeyRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	project, err := s.projects.CreateProject(r.Context(), createProjectRequest.Name)
+	// Store keys in their canonical form so agents can parse them without
+	// options or comments getting in the way
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(createSSHKeyRequest.PublicKey))
 	if err != nil {
-		log.WithError(err).Error("create project")
-		w.WriteHeader(http.StatusInternalServerError)
+		http.Error(w, errors.Wrap(err, "parse public key").Error(), http.StatusBadRequest)
 		return
 	}
 
-	if _, err = s.memberships.CreateMembership(r.Context(), authenticatedUserID, project.ID); err != nil {
-		log.WithError(err).Error("create membership")
+	sshKey, err := s.sshKeys.CreateSSHKey(r.Context(), authenticatedUserID,
+		createSSHKeyRequest.Name, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))))
+	if err != nil {
+		log.WithError(err).Error("create ssh key")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	adminAllRoleBytes, err := yaml.Marshal(authz.AdminAllRole)
-	if err != nil {
-		log.WithError(err).Error("marshal admin role config")
-		w.WriteHeader(http.StatusInternalServerError)
+	utils.Respond(w, sshKey)
+}
+
+func (s *Service) getSSHKey(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	if authenticatedUserID == "" {
+		w.WriteHeader(http.StatusForbidden)
 		return
 	}
 
-	adminRole, err := s.roles.CreateRole(r.Context(), project.ID, "admin-all", "", string(adminAllRoleBytes))
+	vars := mux.Vars(r)
+	sshKeyID := vars["sshkey"]
+
+	sshKey, err := s.sshKeys.GetSSHKey(r.Context(), sshKeyID, authenticatedUserID)
+	if err == store.ErrSSHKeyNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get ssh key")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, sshKey)
+}
+
+func (s *Service) listSSHKeys(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	if authenticatedUserID == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	sshKeys, err := s.sshKeys.ListSSHKeys(r.Context(), authenticatedUserID)
+	if err != nil {
+		log.WithError(err).Error("list ssh keys")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, sshKeys)
+}
+
+func (s *Service) deleteSSHKey(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	if authenticatedUserID == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	vars := mux.Vars(r)
+	sshKeyID := vars["sshkey"]
+
+	if err := s.sshKeys.DeleteSSHKey(r.Context(), sshKeyID, authenticatedUserID); err != nil {
+		log.WithError(err).Error("delete ssh key")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) createProject(w http.ResponseWriter, r *http.Request, authenticatedUserID, authenticatedServiceAccountID string) {
+	var createProjectRequest struct {
+		Name string `json:"name" validate:"name"`
+	}
+	if err := read(r, &createProjectRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.projects.LookupProject(r.Context(), createProjectRequest.Name); err == nil {
+		http.Error(w, store.ErrProjectNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrProjectNotFound {
+		log.WithError(err).Error("lookup project")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	project, err := s.projects.CreateProject(r.Context(), createProjectRequest.Name)
+	if err != nil {
+		log.WithError(err).Error("create project")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = s.memberships.CreateMembership(r.Context(), authenticatedUserID, project.ID); err != nil {
+		log.WithError(err).Error("create membership")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	adminAllRoleBytes, err := yaml.Marshal(authz.AdminAllRole)
+	if err != nil {
+		log.WithError(err).Error("marshal admin role config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	adminRole, err := s.roles.CreateRole(r.Context(), project.ID, "admin-all", "", string(adminAllRoleBytes))
 	if err != nil {
 		log.WithError(err).Error("create admin role")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -1193,6 +1784,9 @@ func (s *Service) createProject(w http.ResponseWriter, r *http.Request, authenti
 		"default",
 		"",
 		nil,
+		nil,
+		"",
+		false,
 	)
 	if err != nil {
 		log.WithError(err).Error("create default registration token")
@@ -1258,6 +1852,76 @@ func (s *Service) deleteProject(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+// streamEvents sends a project's events as server-sent events as they
+// happen, until the client disconnects. Events can be filtered by passing
+// one or more types.
+func (s *Service) streamEvents(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, errStreamingUnsupported.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	types := make(map[models.EventType]bool)
+	for _, t := range r.URL.Query()["type"] {
+		eventType := models.EventType(t)
+		valid := false
+		for _, validType := range models.EventTypes {
+			if eventType == validType {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			http.Error(w, fmt.Sprintf("unknown event type %q", t), http.StatusBadRequest)
+			return
+		}
+		types[eventType] = true
+	}
+
+	events, unsubscribe := s.events.Subscribe(projectID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(eventStreamKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case event := <-events:
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.WithError(err).Error("marshal event")
+				return
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			// Clients reconnect, to another controller once this one is
+			// no longer ready
+			return
+		}
+	}
+}
+
 func (s *Service) createRole(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID string,
 ) {
@@ -1285,6 +1949,10 @@ func (s *Service) createRole(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := authz.Validate(roleConfig); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	role, err := s.roles.CreateRole(r.Context(), projectID, createRoleRequest.Name,
 		createRoleRequest.Description, createRoleRequest.Config)
@@ -1348,6 +2016,10 @@ func (s *Service) updateRole(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if err := authz.Validate(roleConfig); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if role, err := s.roles.LookupRole(r.Context(),
 		updateRoleRequest.Name, projectID); err == nil && role.ID != roleID {
@@ -1555,17 +2227,37 @@ func (s *Service) createServiceAccountAccessKey(w http.ResponseWriter, r *http.R
 	serviceAccountID := vars["serviceaccount"]
 
 	var createServiceAccountAccessKeyRequest struct {
-		Description string `json:"description" validate:"description"`
+		Description string     `json:"description" validate:"description"`
+		Config      string     `json:"config" validate:"max=5000"`
+		ExpiresAt   *time.Time `json:"expiresAt"`
 	}
 	if err := read(r, &createServiceAccountAccessKeyRequest); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if createServiceAccountAccessKeyRequest.Config != "" {
+		var accessKeyConfig authz.Config
+		if err := yaml.UnmarshalStrict([]byte(createServiceAccountAccessKeyRequest.Config), &accessKeyConfig); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := authz.Validate(accessKeyConfig); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if createServiceAccountAccessKeyRequest.ExpiresAt != nil && !createServiceAccountAccessKeyRequest.ExpiresAt.After(time.Now()) {
+		http.Error(w, errExpiryInPast.Error(), http.StatusBadRequest)
+		return
+	}
+
 	serviceAccountAccessKeyValue := "s" + ksuid.New().String()
 
 	serviceAccount, err := s.serviceAccountAccessKeys.CreateServiceAccountAccessKey(r.Context(),
-		projectID, serviceAccountID, hash.Hash(serviceAccountAccessKeyValue), createServiceAccountAccessKeyRequest.Description)
+		projectID, serviceAccountID, hash.Hash(serviceAccountAccessKeyValue), createServiceAccountAccessKeyRequest.Description,
+		createServiceAccountAccessKeyRequest.Config, createServiceAccountAccessKeyRequest.ExpiresAt)
 	if err != nil {
 		log.WithError(err).Error("create service account access key")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -1928,6 +2620,10 @@ func (s *Service) createApplication(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if !s.withinApplicationLimit(w, r, projectID) {
+		return
+	}
+
 	application, err := s.applications.CreateApplication(
 		r.Context(),
 		projectID,
@@ -1972,10 +2668,18 @@ func (s *Service) getApplication(w http.ResponseWriter, r *http.Request,
 			return
 		}
 
+		releasePins, err := s.deviceReleasePins.ListDeviceReleasePins(r.Context(), projectID, applicationID)
+		if err != nil {
+			log.WithError(err).Error("list device release pins")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 		ret = models.ApplicationFull1{
 			Application:   *application,
 			LatestRelease: latestRelease,
 			DeviceCounts:  *applicationDeviceCounts,
+			ReleasePins:   releasePins,
 		}
 	}
 
@@ -1985,11 +2689,36 @@ func (s *Service) getApplication(w http.ResponseWriter, r *http.Request,
 func (s *Service) listApplications(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID string,
 ) {
-	applications, err := s.applications.ListApplications(r.Context(), projectID)
-	if err != nil {
-		log.WithError(err).Error("list applications")
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	// Unlike devices, applications are only paginated if a page is asked
+	// for, since the UI lists them whole
+	var applications []models.Application
+	if pageRequested(r) && !middleware.Ordered(*r) {
+		var ok bool
+		applications, ok = s.listApplicationsPage(w, r, projectID)
+		if !ok {
+			return
+		}
+	} else {
+		var err error
+		applications, err = s.applications.ListApplications(r.Context(), projectID)
+		if err != nil {
+			log.WithError(err).Error("list applications")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		as := make([]interface{}, len(applications))
+		for i := range applications {
+			as[i] = applications[i]
+		}
+		as, ok := middleware.SortAndPaginate(*r, w, as, 0)
+		if !ok {
+			return
+		}
+		applications = make([]models.Application, len(as))
+		for i := range as {
+			applications[i] = as[i].(models.Application)
+		}
 	}
 
 	var ret interface{} = applications
@@ -2011,10 +2740,18 @@ func (s *Service) listApplications(w http.ResponseWriter, r *http.Request,
 				return
 			}
 
+			releasePins, err := s.deviceReleasePins.ListDeviceReleasePins(r.Context(), projectID, application.ID)
+			if err != nil {
+				log.WithError(err).Error("list device release pins")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
 			applicationsFull = append(applicationsFull, models.ApplicationFull1{
 				Application:   application,
 				LatestRelease: latestRelease,
 				DeviceCounts:  *applicationDeviceCounts,
+				ReleasePins:   releasePins,
 			})
 		}
 
@@ -2024,6 +2761,46 @@ func (s *Service) listApplications(w http.ResponseWriter, r *http.Request,
 	utils.Respond(w, ret)
 }
 
+// pageRequested returns whether a request asks for a page of a listing
+// that's otherwise listed whole.
+func pageRequested(r *http.Request) bool {
+	return r.URL.Query().Get(middleware.PageSizeParam) != ""
+}
+
+// listApplicationsPage gets the page of a project's applications a request
+// asks for from the store, and sets the pagination headers. If it fails it
+// writes the error response and returns false.
+func (s *Service) listApplicationsPage(w http.ResponseWriter, r *http.Request, projectID string) ([]models.Application, bool) {
+	after, pageSize, ok := middleware.PageParams(*r, w, middleware.MaxPageSize)
+	if !ok {
+		return nil, false
+	}
+
+	applicationCounts, err := s.projectApplicationCounts.GetProjectApplicationCounts(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get project application counts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// One more than the page is listed to know if there's a next page
+	applications, err := s.applications.ListApplicationsPage(r.Context(), projectID, after, pageSize+1)
+	if err != nil {
+		log.WithError(err).Error("list applications page")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	var next string
+	if len(applications) > pageSize {
+		applications = applications[:pageSize]
+		next = applications[pageSize-1].ID
+	}
+	middleware.SetPageHeaders(w, applicationCounts.AllCount, pageSize, next)
+
+	return applications, true
+}
+
 func (s *Service) updateApplication(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID,
 	applicationID string,
@@ -2139,20 +2916,8 @@ func (s *Service) createRelease(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	if err := spec.Validate([]byte(createReleaseRequest.RawConfig)); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	var applicationConfig map[string]models.Service
-	if err := yaml.UnmarshalStrict([]byte(createReleaseRequest.RawConfig), &applicationConfig); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-	jsonApplicationConfig, err := json.Marshal(applicationConfig)
-	if err != nil {
-		log.WithError(err).Error("marshal json application config")
-		w.WriteHeader(http.StatusInternalServerError)
+	jsonApplicationConfig, ok := s.prepareRelease(w, r, projectID, applicationID, createReleaseRequest.RawConfig)
+	if !ok {
 		return
 	}
 
@@ -2161,7 +2926,11 @@ func (s *Service) createRelease(w http.ResponseWriter, r *http.Request,
 		projectID,
 		applicationID,
 		createReleaseRequest.RawConfig,
-		string(jsonApplicationConfig),
+		jsonApplicationConfig,
+		"",
+		createReleaseRequest.Notes,
+		createReleaseRequest.Source,
+		"",
 		authenticatedUserID,
 		authenticatedServiceAccountID,
 	)
@@ -2171,103 +2940,1350 @@ func (s *Service) createRelease(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeReleaseCreated,
+		ProjectID: projectID,
+		Release:   release,
+	})
+
 	utils.Respond(w, release)
 }
 
-func (s *Service) getRelease(w http.ResponseWriter, r *http.Request,
-	projectID, authenticatedUserID, authenticatedServiceAccountID string,
-	application *models.Application,
-	release *models.Release,
+// createCIRelease is createRelease for CI pipelines, which retry requests.
+// Only one release is created for an idempotency key, and retries get that
+// release back. Reusing a key for a different config is a conflict.
+func (s *Service) createCIRelease(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
 ) {
-	var ret interface{} = release
-	var err error
-	if _, ok := r.URL.Query()["full"]; ok {
-		ret, err = s.getReleaseFull(r.Context(), *release)
-		if err != nil {
-			log.WithError(err).Error("get release full")
-			w.WriteHeader(http.StatusInternalServerError)
+	var createCIReleaseRequest models.CreateCIReleaseRequest
+	if err := read(r, &createCIReleaseRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Without a key, retries can't be matched to the release they created
+	// and would create duplicates
+	if strings.TrimSpace(createCIReleaseRequest.IdempotencyKey) == "" {
+		http.Error(w, errIdempotencyKeyRequired.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondWithExisting := func(release *models.Release) {
+		if release.RawConfig != createCIReleaseRequest.RawConfig {
+			http.Error(w, errIdempotencyKeyReused.Error(), http.StatusConflict)
 			return
 		}
+		utils.Respond(w, models.CreateCIReleaseResponse{
+			Release: *release,
+			Created: false,
+		})
 	}
 
-	utils.Respond(w, ret)
-}
-
-func (s *Service) getLatestRelease(w http.ResponseWriter, r *http.Request,
-	projectID string, authenticatedUserID, authenticatedServiceAccountID,
-	applicationID string,
-) {
-	release, err := s.releases.GetLatestRelease(r.Context(), projectID, applicationID)
-	if err == store.ErrReleaseNotFound {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	release, err := s.releases.GetReleaseByIdempotencyKey(r.Context(), projectID, applicationID, createCIReleaseRequest.IdempotencyKey)
+	if err == nil {
+		respondWithExisting(release)
 		return
-	} else if err != nil {
-		log.WithError(err).Error("get latest release")
+	} else if err != store.ErrReleaseNotFound {
+		log.WithError(err).Error("get release by idempotency key")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	var ret interface{} = release
-	if _, ok := r.URL.Query()["full"]; ok {
-		ret, err = s.getReleaseFull(r.Context(), *release)
-		if err != nil {
-			log.WithError(err).Error("get release full")
-			w.WriteHeader(http.StatusInternalServerError)
+	jsonApplicationConfig, ok := s.prepareRelease(w, r, projectID, applicationID, createCIReleaseRequest.RawConfig)
+	if !ok {
+		return
+	}
+
+	release, err = s.releases.CreateRelease(
+		r.Context(),
+		projectID,
+		applicationID,
+		createCIReleaseRequest.RawConfig,
+		jsonApplicationConfig,
+		"",
+		createCIReleaseRequest.Notes,
+		createCIReleaseRequest.Source,
+		createCIReleaseRequest.IdempotencyKey,
+		authenticatedUserID,
+		authenticatedServiceAccountID,
+	)
+	if err != nil {
+		// A retry running at the same time may have created it first, in
+		// which case the key's unique index fails this one
+		if release, lookupErr := s.releases.GetReleaseByIdempotencyKey(r.Context(), projectID, applicationID, createCIReleaseRequest.IdempotencyKey); lookupErr == nil {
+			respondWithExisting(release)
 			return
 		}
+		log.WithError(err).Error("create release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	utils.Respond(w, ret)
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeReleaseCreated,
+		ProjectID: projectID,
+		Release:   release,
+	})
+
+	utils.Respond(w, models.CreateCIReleaseResponse{
+		Release: *release,
+		Created: true,
+	})
 }
 
-func (s *Service) listReleases(w http.ResponseWriter, r *http.Request,
-	projectID, authenticatedUserID, authenticatedServiceAccountID,
-	applicationID string,
-) {
-	releases, err := s.releases.ListReleases(r.Context(), projectID, applicationID)
+// prepareRelease checks a release config and returns it as JSON. If it
+// can't, it writes the error response and returns false.
+func (s *Service) prepareRelease(w http.ResponseWriter, r *http.Request, projectID, applicationID, rawConfig string) (string, bool) {
+	if !s.withinReleaseConfigSizeLimit(w, r, projectID, rawConfig) {
+		return "", false
+	}
+
+	diagnostics, err := s.diagnoseRelease(r.Context(), projectID, applicationID, rawConfig)
 	if err != nil {
-		log.WithError(err).Error("list releases")
+		log.WithError(err).Error("diagnose release")
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return "", false
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == models.DiagnosticSeverityError {
+			http.Error(w, diagnostic.String(), http.StatusBadRequest)
+			return "", false
+		}
 	}
 
-	var ret interface{} = releases
-	if _, ok := r.URL.Query()["full"]; ok {
-		releasesFull := make([]models.ReleaseFull, 0)
-		for _, release := range releases {
+	var applicationConfig map[string]models.Service
+	if err := yaml.UnmarshalStrict([]byte(rawConfig), &applicationConfig); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+
+	jsonApplicationConfig, err := json.Marshal(applicationConfig)
+	if err != nil {
+		log.WithError(err).Error("marshal json application config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return "", false
+	}
+
+	return string(jsonApplicationConfig), true
+}
+
+func (s *Service) getApplicationGitSync(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	gitSync, err := s.applicationGitSyncs.GetApplicationGitSync(r.Context(), projectID, applicationID)
+	if err == store.ErrApplicationGitSyncNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get application git sync")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, gitSync)
+}
+
+// setApplicationGitSync starts syncing an application from git, or changes
+// where it's synced from. Either way the next sync reads the file again.
+func (s *Service) setApplicationGitSync(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	var setApplicationGitSyncRequest models.SetApplicationGitSyncRequest
+	if err := read(r, &setApplicationGitSyncRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := gitrepo.ValidateRepository(setApplicationGitSyncRequest.Repository); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := gitrepo.ValidateBranch(setApplicationGitSyncRequest.Branch); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gitSync, err := s.applicationGitSyncs.SetApplicationGitSync(
+		r.Context(),
+		projectID,
+		applicationID,
+		setApplicationGitSyncRequest.Repository,
+		setApplicationGitSyncRequest.Branch,
+		setApplicationGitSyncRequest.Path,
+	)
+	if err != nil {
+		log.WithError(err).Error("set application git sync")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, gitSync)
+}
+
+func (s *Service) deleteApplicationGitSync(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	if err := s.applicationGitSyncs.DeleteApplicationGitSync(r.Context(), projectID, applicationID); err != nil {
+		log.WithError(err).Error("delete application git sync")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// rollbackApplication creates a new release with the config of an earlier
+// one. Unless a release is given it's the one before the release that's
+// running, so rolling back again keeps going further back.
+func (s *Service) rollbackApplication(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	var rollbackApplicationRequest models.RollbackApplicationRequest
+	if err := read(r, &rollbackApplicationRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	latestRelease, err := s.releases.GetLatestRelease(r.Context(), projectID, applicationID)
+	if err == store.ErrReleaseNotFound {
+		http.Error(w, errNoPreviousRelease.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get latest release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var targetRelease *models.Release
+	if rollbackApplicationRequest.Release != "" {
+		targetRelease, err = utils.GetReleaseByIdentifier(s.releases, r.Context(), projectID, applicationID, rollbackApplicationRequest.Release)
+		if err == store.ErrReleaseNotFound {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("get release")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	} else {
+		// Releases created by earlier rollbacks stand in for the release
+		// they were copied from
+		currentRelease := latestRelease
+		for currentRelease.SourceReleaseID != "" {
+			sourceRelease, err := s.releases.GetRelease(r.Context(), currentRelease.SourceReleaseID, projectID, applicationID)
+			if err == store.ErrReleaseNotFound {
+				break
+			} else if err != nil {
+				log.WithError(err).Error("get source release")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			currentRelease = sourceRelease
+		}
+
+		if currentRelease.Number <= 1 {
+			http.Error(w, errNoPreviousRelease.Error(), http.StatusBadRequest)
+			return
+		}
+
+		targetRelease, err = s.releases.GetReleaseByNumber(r.Context(), currentRelease.Number-1, projectID, applicationID)
+		if err == store.ErrReleaseNotFound {
+			http.Error(w, errNoPreviousRelease.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("get previous release")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if targetRelease.ID == latestRelease.ID {
+		http.Error(w, errRollbackToLatestRelease.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jsonApplicationConfig, err := json.Marshal(targetRelease.Config)
+	if err != nil {
+		log.WithError(err).Error("marshal json application config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	release, err := s.releases.CreateRelease(
+		r.Context(),
+		projectID,
+		applicationID,
+		targetRelease.RawConfig,
+		string(jsonApplicationConfig),
+		targetRelease.ID,
+		fmt.Sprintf("Rollback to release %d", targetRelease.Number),
+		targetRelease.Source,
+		"",
+		authenticatedUserID,
+		authenticatedServiceAccountID,
+	)
+	if err != nil {
+		log.WithError(err).Error("create release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// A rollout of the release that was rolled back is over
+	if latestRollout, err := s.rollouts.GetLatestRollout(r.Context(), projectID, applicationID); err == nil &&
+		latestRollout.Status == models.RolloutStatusInProgress {
+		if _, err := s.rollouts.UpdateRolloutStatus(r.Context(), latestRollout.ID, projectID, models.RolloutStatusHalted,
+			fmt.Sprintf("rolled back to release %d", targetRelease.Number)); err != nil {
+			log.WithError(err).Error("halt rollout")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	} else if err != nil && err != store.ErrRolloutNotFound {
+		log.WithError(err).Error("get latest rollout")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeReleaseCreated,
+		ProjectID: projectID,
+		Release:   release,
+	})
+
+	utils.Respond(w, release)
+}
+
+func (s *Service) validateRelease(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	var validateReleaseRequest models.ValidateReleaseRequest
+	if err := read(r, &validateReleaseRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diagnostics, err := s.diagnoseRelease(r.Context(), projectID, applicationID, validateReleaseRequest.RawConfig)
+	if err != nil {
+		log.WithError(err).Error("diagnose release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if diagnostics == nil {
+		diagnostics = []models.ReleaseDiagnostic{}
+	}
+
+	utils.Respond(w, models.ValidateReleaseResponse{
+		Valid:       !spec.HasErrors(diagnostics),
+		Diagnostics: diagnostics,
+	})
+}
+
+func (s *Service) convertCompose(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	var convertComposeRequest models.ConvertComposeRequest
+	if err := read(r, &convertComposeRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rawConfig, dropped, err := spec.FromCompose([]byte(convertComposeRequest.Compose))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dropped == nil {
+		dropped = []models.ReleaseDiagnostic{}
+	}
+
+	utils.Respond(w, models.ConvertComposeResponse{
+		RawConfig: string(rawConfig),
+		Dropped:   dropped,
+	})
+}
+
+// diagnoseRelease extends spec.Diagnose with the checks that need the
+// project, such as whether referenced environment files exist.
+func (s *Service) diagnoseRelease(ctx context.Context, projectID, applicationID, rawConfig string) ([]models.ReleaseDiagnostic, error) {
+	return releasecheck.Diagnose(ctx, s.environmentFiles, s.releaseWebhooksConfigs, projectID, applicationID, rawConfig)
+}
+
+func (s *Service) getRelease(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+	application *models.Application,
+	release *models.Release,
+) {
+	var ret interface{} = release
+	var err error
+	if _, ok := r.URL.Query()["full"]; ok {
+		ret, err = s.getReleaseFull(r.Context(), *release)
+		if err != nil {
+			log.WithError(err).Error("get release full")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	utils.Respond(w, ret)
+}
+
+func (s *Service) getLatestRelease(w http.ResponseWriter, r *http.Request,
+	projectID string, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	release, err := s.releases.GetLatestRelease(r.Context(), projectID, applicationID)
+	if err == store.ErrReleaseNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get latest release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var ret interface{} = release
+	if _, ok := r.URL.Query()["full"]; ok {
+		ret, err = s.getReleaseFull(r.Context(), *release)
+		if err != nil {
+			log.WithError(err).Error("get release full")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	utils.Respond(w, ret)
+}
+
+// diffRelease compares a release with the release given by the from query
+// parameter, or with the release before it.
+func (s *Service) diffRelease(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+	application *models.Application,
+	release *models.Release,
+) {
+	var fromRelease *models.Release
+	var err error
+	if from := r.URL.Query().Get("from"); from != "" {
+		fromRelease, err = utils.GetReleaseByIdentifier(s.releases, r.Context(), projectID, application.ID, from)
+	} else if release.Number > 1 {
+		fromRelease, err = s.releases.GetReleaseByNumber(r.Context(), release.Number-1, projectID, application.ID)
+	} else {
+		err = errNoReleaseToDiff
+	}
+	if err == store.ErrReleaseNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err == errNoReleaseToDiff {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	diff, err := spec.Diff(fromRelease.Config, release.Config)
+	if err != nil {
+		log.WithError(err).Error("diff releases")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	diff.FromReleaseID = fromRelease.ID
+	diff.ToReleaseID = release.ID
+
+	utils.Respond(w, diff)
+}
+
+func (s *Service) listReleases(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	// Like applications, releases are only paginated if a page is asked for.
+	// That's done before the full releases are gotten, so only the page's are
+	var releases []models.Release
+	if pageRequested(r) && !middleware.Ordered(*r) {
+		var ok bool
+		releases, ok = s.listReleasesPage(w, r, projectID, applicationID)
+		if !ok {
+			return
+		}
+	} else {
+		var err error
+		releases, err = s.releases.ListReleases(r.Context(), projectID, applicationID)
+		if err != nil {
+			log.WithError(err).Error("list releases")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rs := make([]interface{}, len(releases))
+		for i := range releases {
+			rs[i] = releases[i]
+		}
+		rs, ok := middleware.SortAndPaginate(*r, w, rs, 0)
+		if !ok {
+			return
+		}
+		releases = make([]models.Release, len(rs))
+		for i := range rs {
+			releases[i] = rs[i].(models.Release)
+		}
+	}
+
+	var ret interface{} = releases
+	if _, ok := r.URL.Query()["full"]; ok {
+		releasesFull := make([]models.ReleaseFull, 0)
+		for _, release := range releases {
 			releaseFull, err := s.getReleaseFull(r.Context(), release)
 			if err != nil {
 				log.WithError(err).Error("get release full")
 				w.WriteHeader(http.StatusInternalServerError)
 				return
 			}
-			releasesFull = append(releasesFull, *releaseFull)
+			releasesFull = append(releasesFull, *releaseFull)
+		}
+		ret = releasesFull
+	}
+
+	utils.Respond(w, ret)
+}
+
+// listReleasesPage gets the page of an application's releases a request
+// asks for from the store, newest first, and sets the pagination headers. If
+// it fails it writes the error response and returns false.
+func (s *Service) listReleasesPage(w http.ResponseWriter, r *http.Request, projectID, applicationID string) ([]models.Release, bool) {
+	after, pageSize, ok := middleware.PageParams(*r, w, middleware.MaxPageSize)
+	if !ok {
+		return nil, false
+	}
+
+	releaseCount, err := s.releases.GetReleaseCount(r.Context(), projectID, applicationID)
+	if err != nil {
+		log.WithError(err).Error("get release count")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// One more than the page is listed to know if there's a next page
+	releases, err := s.releases.ListReleasesPage(r.Context(), projectID, applicationID, after, pageSize+1)
+	if err != nil {
+		log.WithError(err).Error("list releases page")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+
+	var next string
+	if len(releases) > pageSize {
+		releases = releases[:pageSize]
+		next = releases[pageSize-1].ID
+	}
+	middleware.SetPageHeaders(w, releaseCount, pageSize, next)
+
+	return releases, true
+}
+
+func (s *Service) createEnvironmentFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var createEnvironmentFileRequest struct {
+		Name        string `json:"name" validate:"name"`
+		Description string `json:"description" validate:"description"`
+		Content     string `json:"content" validate:"content"`
+	}
+	if err := read(r, &createEnvironmentFileRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := spec.ParseEnvironmentFile(createEnvironmentFileRequest.Content); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.environmentFiles.LookupEnvironmentFile(r.Context(), createEnvironmentFileRequest.Name, projectID); err == nil {
+		http.Error(w, store.ErrEnvironmentFileNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrEnvironmentFileNotFound {
+		log.WithError(err).Error("lookup environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	environmentFile, err := s.environmentFiles.CreateEnvironmentFile(r.Context(), projectID,
+		createEnvironmentFileRequest.Name, createEnvironmentFileRequest.Description, createEnvironmentFileRequest.Content)
+	if err != nil {
+		log.WithError(err).Error("create environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, environmentFile)
+}
+
+func (s *Service) getEnvironmentFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	environmentFileID string,
+) {
+	environmentFile, err := s.environmentFiles.GetEnvironmentFile(r.Context(), environmentFileID, projectID)
+	if err == store.ErrEnvironmentFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, environmentFile)
+}
+
+func (s *Service) listEnvironmentFiles(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	environmentFiles, err := s.environmentFiles.ListEnvironmentFiles(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list environment files")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, environmentFiles)
+}
+
+func (s *Service) updateEnvironmentFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	environmentFileID string,
+) {
+	var updateEnvironmentFileRequest struct {
+		Name        string `json:"name" validate:"name"`
+		Description string `json:"description" validate:"description"`
+		Content     string `json:"content" validate:"content"`
+	}
+	if err := read(r, &updateEnvironmentFileRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := spec.ParseEnvironmentFile(updateEnvironmentFileRequest.Content); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	environmentFile, err := s.environmentFiles.GetEnvironmentFile(r.Context(), environmentFileID, projectID)
+	if err == store.ErrEnvironmentFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Releases reference environment files by name
+	if updateEnvironmentFileRequest.Name != environmentFile.Name {
+		users, err := s.environmentFileUsers(r.Context(), projectID, environmentFile.Name)
+		if err != nil {
+			log.WithError(err).Error("get environment file users")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if len(users) > 0 {
+			http.Error(w, fmt.Sprintf("environment file is in use by %s", strings.Join(users, ", ")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if environmentFile, err := s.environmentFiles.LookupEnvironmentFile(r.Context(),
+		updateEnvironmentFileRequest.Name, projectID); err == nil && environmentFile.ID != environmentFileID {
+		http.Error(w, store.ErrEnvironmentFileNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrEnvironmentFileNotFound {
+		log.WithError(err).Error("lookup environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	environmentFile, err = s.environmentFiles.UpdateEnvironmentFile(r.Context(), environmentFileID, projectID,
+		updateEnvironmentFileRequest.Name, updateEnvironmentFileRequest.Description, updateEnvironmentFileRequest.Content)
+	if err != nil {
+		log.WithError(err).Error("update environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, environmentFile)
+}
+
+func (s *Service) deleteEnvironmentFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	environmentFileID string,
+) {
+	environmentFile, err := s.environmentFiles.GetEnvironmentFile(r.Context(), environmentFileID, projectID)
+	if err == store.ErrEnvironmentFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	users, err := s.environmentFileUsers(r.Context(), projectID, environmentFile.Name)
+	if err != nil {
+		log.WithError(err).Error("get environment file users")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(users) > 0 {
+		http.Error(w, fmt.Sprintf("environment file is in use by %s", strings.Join(users, ", ")), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.environmentFiles.DeleteEnvironmentFile(r.Context(), environmentFileID, projectID); err != nil {
+		log.WithError(err).Error("delete environment file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type configFileRequest struct {
+	Name            string   `json:"name" validate:"name"`
+	Description     string   `json:"description" validate:"description"`
+	Path            string   `json:"path"`
+	Content         string   `json:"content" validate:"content"`
+	Mode            uint32   `json:"mode"`
+	Owner           string   `json:"owner"`
+	RestartServices []string `json:"restartServices"`
+}
+
+func (r configFileRequest) configFile() models.ConfigFile {
+	return models.ConfigFile{
+		Name:            r.Name,
+		Description:     r.Description,
+		Path:            r.Path,
+		Content:         r.Content,
+		Mode:            r.Mode,
+		Owner:           r.Owner,
+		RestartServices: r.RestartServices,
+	}
+}
+
+func (s *Service) createConfigFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var createConfigFileRequest configFileRequest
+	if err := read(r, &createConfigFileRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := configfile.Validate(createConfigFileRequest.configFile()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.configFiles.LookupConfigFile(r.Context(), createConfigFileRequest.Name, projectID); err == nil {
+		http.Error(w, store.ErrConfigFileNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrConfigFileNotFound {
+		log.WithError(err).Error("lookup config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	configFile, err := s.configFiles.CreateConfigFile(r.Context(), projectID, createConfigFileRequest.configFile())
+	if err != nil {
+		log.WithError(err).Error("create config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, configFile)
+}
+
+func (s *Service) getConfigFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	configFileID string,
+) {
+	configFile, err := s.configFiles.GetConfigFile(r.Context(), configFileID, projectID)
+	if err == store.ErrConfigFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, configFile)
+}
+
+func (s *Service) listConfigFiles(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	configFiles, err := s.configFiles.ListConfigFiles(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list config files")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, configFiles)
+}
+
+func (s *Service) updateConfigFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	configFileID string,
+) {
+	var updateConfigFileRequest configFileRequest
+	if err := read(r, &updateConfigFileRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := configfile.Validate(updateConfigFileRequest.configFile()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.configFiles.GetConfigFile(r.Context(), configFileID, projectID); err == store.ErrConfigFileNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if configFile, err := s.configFiles.LookupConfigFile(r.Context(),
+		updateConfigFileRequest.Name, projectID); err == nil && configFile.ID != configFileID {
+		http.Error(w, store.ErrConfigFileNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrConfigFileNotFound {
+		log.WithError(err).Error("lookup config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	configFile, err := s.configFiles.UpdateConfigFile(r.Context(), configFileID, projectID, updateConfigFileRequest.configFile())
+	if err != nil {
+		log.WithError(err).Error("update config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, configFile)
+}
+
+func (s *Service) deleteConfigFile(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	configFileID string,
+) {
+	if err := s.configFiles.DeleteConfigFile(r.Context(), configFileID, projectID); err != nil {
+		log.WithError(err).Error("delete config file")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) createRollout(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	var createRolloutRequest models.CreateRolloutRequest
+	if err := read(r, &createRolloutRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := rollout.ValidateReleaseRollout(createRolloutRequest.Steps,
+		createRolloutRequest.SoakPeriod, createRolloutRequest.MaxFailurePercentage); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	releaseIdentifier := createRolloutRequest.Release
+	if releaseIdentifier == "" {
+		releaseIdentifier = models.LatestRelease
+	}
+	release, err := utils.GetReleaseByIdentifier(s.releases, r.Context(), projectID, applicationID, releaseIdentifier)
+	if err == store.ErrReleaseNotFound {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if latestRollout, err := s.rollouts.GetLatestRollout(r.Context(), projectID, applicationID); err == nil &&
+		latestRollout.Status == models.RolloutStatusInProgress {
+		http.Error(w, errRolloutInProgress.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrRolloutNotFound {
+		log.WithError(err).Error("get latest rollout")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Devices that the rollout hasn't reached keep running the release
+	// before it. There's none for an application's first release.
+	var previousReleaseID string
+	if release.Number > 1 {
+		previousRelease, err := s.releases.GetReleaseByNumber(r.Context(), release.Number-1, projectID, applicationID)
+		if err == nil {
+			previousReleaseID = previousRelease.ID
+		} else if err != store.ErrReleaseNotFound {
+			log.WithError(err).Error("get previous release")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	newRollout, err := s.rollouts.CreateRollout(r.Context(), projectID, applicationID, release.ID, previousReleaseID,
+		createRolloutRequest.Steps, createRolloutRequest.SoakPeriod, createRolloutRequest.MaxFailurePercentage)
+	if err != nil {
+		log.WithError(err).Error("create rollout")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, newRollout)
+}
+
+func (s *Service) getRollout(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+	rollout *models.Rollout,
+) {
+	utils.Respond(w, rollout)
+}
+
+func (s *Service) listRollouts(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	rollouts, err := s.rollouts.ListRollouts(r.Context(), projectID, applicationID)
+	if err != nil {
+		log.WithError(err).Error("list rollouts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, rollouts)
+}
+
+func (s *Service) haltRollout(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+	rollout *models.Rollout,
+) {
+	var haltRolloutRequest models.HaltRolloutRequest
+	if err := read(r, &haltRolloutRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if rollout.Status != models.RolloutStatusInProgress {
+		http.Error(w, errRolloutNotInProgress.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rollout, err := s.rollouts.UpdateRolloutStatus(r.Context(), rollout.ID, projectID,
+		models.RolloutStatusHalted, haltRolloutRequest.Reason)
+	if err != nil {
+		log.WithError(err).Error("halt rollout")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, rollout)
+}
+
+type deviceGroupRequest struct {
+	Name        string        `json:"name" validate:"name"`
+	Description string        `json:"description" validate:"description"`
+	Query       *models.Query `json:"query"`
+}
+
+func (r deviceGroupRequest) validate() error {
+	if r.Query == nil {
+		return nil
+	}
+	return devicegroups.ValidateQuery(*r.Query)
+}
+
+func (s *Service) createDeviceGroup(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	var createDeviceGroupRequest deviceGroupRequest
+	if err := read(r, &createDeviceGroupRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := createDeviceGroupRequest.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.deviceGroups.LookupDeviceGroup(r.Context(), createDeviceGroupRequest.Name, projectID); err == nil {
+		http.Error(w, store.ErrDeviceGroupNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrDeviceGroupNotFound {
+		log.WithError(err).Error("lookup device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceGroup, err := s.deviceGroups.CreateDeviceGroup(r.Context(), projectID,
+		createDeviceGroupRequest.Name, createDeviceGroupRequest.Description, createDeviceGroupRequest.Query)
+	if err != nil {
+		log.WithError(err).Error("create device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceGroup)
+}
+
+func (s *Service) getDeviceGroup(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID string,
+) {
+	deviceGroup, err := s.deviceGroups.GetDeviceGroup(r.Context(), deviceGroupID, projectID)
+	if err == store.ErrDeviceGroupNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceGroup)
+}
+
+func (s *Service) listDeviceGroups(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	deviceGroups, err := s.deviceGroups.ListDeviceGroups(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceGroups)
+}
+
+func (s *Service) updateDeviceGroup(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID string,
+) {
+	var updateDeviceGroupRequest deviceGroupRequest
+	if err := read(r, &updateDeviceGroupRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := updateDeviceGroupRequest.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.deviceGroups.GetDeviceGroup(r.Context(), deviceGroupID, projectID); err == store.ErrDeviceGroupNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if deviceGroup, err := s.deviceGroups.LookupDeviceGroup(r.Context(),
+		updateDeviceGroupRequest.Name, projectID); err == nil && deviceGroup.ID != deviceGroupID {
+		http.Error(w, store.ErrDeviceGroupNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil && err != store.ErrDeviceGroupNotFound {
+		log.WithError(err).Error("lookup device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceGroup, err := s.deviceGroups.UpdateDeviceGroup(r.Context(), deviceGroupID, projectID,
+		updateDeviceGroupRequest.Name, updateDeviceGroupRequest.Description, updateDeviceGroupRequest.Query)
+	if err != nil {
+		log.WithError(err).Error("update device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, deviceGroup)
+}
+
+func (s *Service) deleteDeviceGroup(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID string,
+) {
+	if err := s.deviceGroups.DeleteDeviceGroup(r.Context(), deviceGroupID, projectID); err != nil {
+		log.WithError(err).Error("delete device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// listDeviceGroupDevices lists the devices in a group, whether they were
+// added to it or match its query.
+func (s *Service) listDeviceGroupDevices(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID string,
+) {
+	devices, err := s.devices.ListDevices(r.Context(), projectID, "")
+	if err != nil {
+		log.WithError(err).Error("list devices")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ret := make([]models.Device, 0)
+	for _, device := range devices {
+		for _, group := range device.Groups {
+			if group == deviceGroupID {
+				ret = append(ret, device)
+				break
+			}
 		}
-		ret = releasesFull
 	}
 
 	utils.Respond(w, ret)
 }
 
+func (s *Service) addDeviceGroupMember(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID, deviceID string,
+) {
+	if _, err := s.deviceGroups.GetDeviceGroup(r.Context(), deviceGroupID, projectID); err == store.ErrDeviceGroupNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device group")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.devices.GetDevice(r.Context(), deviceID, projectID); err == store.ErrDeviceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.deviceGroups.AddDeviceGroupMember(r.Context(), deviceGroupID, projectID, deviceID); err != nil {
+		log.WithError(err).Error("add device group member")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) removeDeviceGroupMember(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceGroupID, deviceID string,
+) {
+	if err := s.deviceGroups.RemoveDeviceGroupMember(r.Context(), deviceGroupID, projectID, deviceID); err != nil {
+		log.WithError(err).Error("remove device group member")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Service) getSessionRecording(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	vars := mux.Vars(r)
+	sessionRecordingID := vars["sessionrecording"]
+
+	sessionRecording, err := s.sessionRecordings.GetSessionRecording(r.Context(), sessionRecordingID, projectID)
+	if err == store.ErrSessionRecordingNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get session recording")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, sessionRecording)
+}
+
+// getSessionRecordingContent responds with the recording in the asciicast v2
+// format, which can be played back with asciinema.
+func (s *Service) getSessionRecordingContent(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	vars := mux.Vars(r)
+	sessionRecordingID := vars["sessionrecording"]
+
+	content, err := s.sessionRecordings.GetSessionRecordingContent(r.Context(), sessionRecordingID, projectID)
+	if err == store.ErrSessionRecordingNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get session recording content")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	w.Write(content)
+}
+
+func (s *Service) listSessionRecordings(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	sessionRecordings, err := s.sessionRecordings.ListSessionRecordings(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("list session recordings")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, sessionRecordings)
+}
+
+// listAuditLogEntries lists a project's audit log newest first. Passing the
+// ID of the last entry of a page as before lists the next page.
+func (s *Service) listAuditLogEntries(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	query := r.URL.Query()
+
+	var since time.Time
+	if sinceString := query.Get("since"); sinceString != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceString)
+		if err != nil {
+			http.Error(w, errInvalidAuditLogSince.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := defaultAuditLogEntriesLimit
+	if limitString := query.Get("limit"); limitString != "" {
+		var err error
+		limit, err = strconv.Atoi(limitString)
+		if err != nil || limit < 1 || limit > maxAuditLogEntriesLimit {
+			http.Error(w, errInvalidAuditLogLimit.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	auditLogEntries, err := s.auditLogEntries.ListAuditLogEntries(r.Context(), projectID,
+		query.Get("user"), query.Get("serviceaccount"), query.Get("action"), query.Get("before"), since, limit)
+	if err != nil {
+		log.WithError(err).Error("list audit log entries")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, auditLogEntries)
+}
+
+// environmentFileUsers returns the services whose latest release references
+// an environment file. Devices wouldn't be able to resolve the environment of
+// these services if the file was removed or renamed.
+func (s *Service) environmentFileUsers(ctx context.Context, projectID, name string) ([]string, error) {
+	applications, err := s.applications.ListApplications(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []string
+	for _, application := range applications {
+		release, err := s.releases.GetLatestRelease(ctx, projectID, application.ID)
+		if err == store.ErrReleaseNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for serviceName, service := range release.Config {
+			for _, envFile := range service.EnvFile {
+				if envFile == name {
+					users = append(users, fmt.Sprintf("%s/%s", application.Name, serviceName))
+				}
+			}
+		}
+	}
+
+	sort.Strings(users)
+	return users, nil
+}
+
 func (s *Service) listDevices(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID string,
 ) {
 	searchQuery := r.URL.Query().Get("search")
 
-	devices, err := s.devices.ListDevices(r.Context(), projectID, searchQuery)
-	if err != nil {
-		log.WithError(err).Error("list devices")
+	filters, err := filtersFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Devices that aren't searched, filtered or ordered are paged by the store
+	if searchQuery == "" && len(filters) == 0 && !middleware.Ordered(*r) {
+		s.listDevicesPage(w, r, projectID)
+		return
+	}
+
+	// The total is of the devices in the search, before they're filtered.
+	// Without a search that's every device, so the store can narrow the
+	// listing down by the filters.
+	var devices []models.Device
+	var totalCount int
+	if searchQuery == "" {
+		devices, err = s.devices.ListDevicesMatching(r.Context(), projectID, "", filters)
+		if err != nil {
+			log.WithError(err).Error("list devices matching")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		deviceCounts, err := s.projectDeviceCounts.GetProjectDeviceCounts(r.Context(), projectID)
+		if err != nil {
+			log.WithError(err).Error("get project device counts")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		totalCount = deviceCounts.AllCount
+	} else {
+		devices, err = s.devices.ListDevices(r.Context(), projectID, searchQuery)
+		if err != nil {
+			log.WithError(err).Error("list devices")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		totalCount = len(devices)
+	}
+
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Total-Device-Count", strconv.Itoa(len(devices)))
-
-	filters, err := query.FiltersFromQuery(r.URL.Query())
-	if err != nil {
-		http.Error(w, errors.Wrap(err, "get filters from query").Error(), http.StatusBadRequest)
-		return
-	}
+	w.Header().Set("Total-Device-Count", strconv.Itoa(totalCount))
 
 	if len(filters) != 0 {
 		devices, _, err = query.QueryDevices(devices, filters)
@@ -2285,6 +4301,47 @@ func (s *Service) listDevices(w http.ResponseWriter, r *http.Request,
 	middleware.SortAndPaginateAndRespond(*r, w, ds)
 }
 
+// listDevicesPage responds with the page of a project's devices a request
+// asks for, gotten from the store.
+func (s *Service) listDevicesPage(w http.ResponseWriter, r *http.Request, projectID string) {
+	after, pageSize, ok := middleware.PageParams(*r, w, middleware.MaxPageSize)
+	if !ok {
+		return
+	}
+
+	deviceCounts, err := s.projectDeviceCounts.GetProjectDeviceCounts(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get project device counts")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// One more than the page is listed to know if there's a next page
+	devices, err := s.devices.ListDevicesPage(r.Context(), projectID, after, pageSize+1)
+	if err != nil {
+		log.WithError(err).Error("list devices page")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var next string
+	if len(devices) > pageSize {
+		devices = devices[:pageSize]
+		next = devices[pageSize-1].ID
+	}
+
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Total-Device-Count", strconv.Itoa(deviceCounts.AllCount))
+	middleware.SetPageHeaders(w, deviceCounts.AllCount, pageSize, next)
+
+	utils.Respond(w, devices)
+}
+
 func (s *Service) previewScheduledDevices(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID string,
 ) {
@@ -2297,6 +4354,12 @@ func (s *Service) previewScheduledDevices(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	filters, err := query.FiltersFromQuery(r.URL.Query())
 	if err != nil {
 		http.Error(w, errors.Wrap(err, "get filters from query").Error(), http.StatusBadRequest)
@@ -2343,6 +4406,12 @@ func (s *Service) getDevice(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if _, err := devicegroups.LoadDevice(r.Context(), s.deviceGroups, projectID, device); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	var ret interface{} = device
 	if _, ok := r.URL.Query()["full"]; ok {
 		applications, err := s.applications.ListApplications(r.Context(), projectID)
@@ -2378,6 +4447,16 @@ func (s *Service) getDevice(w http.ResponseWriter, r *http.Request,
 				return
 			}
 
+			releasePin, err := s.deviceReleasePins.GetDeviceReleasePin(
+				r.Context(), projectID, device.ID, application.ID)
+			if err == nil {
+				applicationStatusInfo.ReleasePin = releasePin
+			} else if err != store.ErrDeviceReleasePinNotFound {
+				log.WithError(err).Error("get device release pin")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
 			allApplicationStatusInfo = append(allApplicationStatusInfo, applicationStatusInfo)
 		}
 
@@ -2406,20 +4485,354 @@ func (s *Service) updateDevice(w http.ResponseWriter, r *http.Request,
 		updateDeviceRequest.Name, projectID); err == nil && device.ID != deviceID {
 		http.Error(w, store.ErrDeviceNameAlreadyInUse.Error(), http.StatusBadRequest)
 		return
-	} else if err != nil && err != store.ErrDeviceNotFound {
-		log.WithError(err).Error("lookup device")
+	} else if err != nil && err != store.ErrDeviceNotFound {
+		log.WithError(err).Error("lookup device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	device, err := s.devices.UpdateDeviceName(r.Context(), deviceID, projectID, updateDeviceRequest.Name)
+	if err != nil {
+		log.WithError(err).Error("update device name")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, device)
+}
+
+func (s *Service) approveDevice(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	device, err := s.devices.ApproveDevice(r.Context(), deviceID, projectID)
+	if err != nil {
+		log.WithError(err).Error("approve device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, device)
+}
+
+// getDeviceConnectivityHistory returns when a device has come online and
+// gone offline since a time, a week ago by default, and whether it's
+// flapping.
+func (s *Service) getDeviceConnectivityHistory(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	now := time.Now()
+
+	since := now.Add(-connectivity.HistoryPeriod)
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			http.Error(w, errInvalidConnectivitySince.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	device, err := s.devices.GetDevice(r.Context(), deviceID, projectID)
+	if err == store.ErrDeviceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Events from before since are still needed to tell if the device is
+	// flapping
+	flapWindowStart := now.Add(-connectivity.FlapWindow)
+	listSince := since
+	if flapWindowStart.Before(listSince) {
+		listSince = flapWindowStart
+	}
+
+	deviceConnectivityEvents, err := s.deviceConnectivityEvents.ListDeviceConnectivityEvents(r.Context(), projectID, deviceID, listSince)
+	if err != nil {
+		log.WithError(err).Error("list device connectivity events")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	history := connectivity.History(*device, deviceConnectivityEvents, now)
+
+	events := make([]models.DeviceConnectivityEvent, 0, len(history.Events))
+	for _, event := range history.Events {
+		if !event.At.Before(since) {
+			events = append(events, event)
+		}
+	}
+	history.Events = events
+
+	utils.Respond(w, history)
+}
+
+// decommissionDevice asks a device to remove its services, config files
+// and state the next time it gets its bundle. The device is archived and
+// its access keys revoked once it reports that it's done.
+func (s *Service) decommissionDevice(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	device, err := s.devices.DecommissionDevice(r.Context(), deviceID, projectID)
+	if err != nil {
+		log.WithError(err).Error("decommission device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, device)
+}
+
+func (s *Service) finishDeviceDecommission(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
+	if !device.Decommissioning {
+		http.Error(w, "device isn't being decommissioned", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.devices.ArchiveDevice(r.Context(), device.ID, project.ID); err != nil {
+		log.WithError(err).Error("archive device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.deviceAccessKeys.DeleteDeviceAccessKeys(r.Context(), device.ID, project.ID); err != nil {
+		log.WithError(err).Error("delete device access keys")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	log.WithField("project_id", project.ID).
+		WithField("device_id", device.ID).
+		Info("device decommissioned")
+}
+
+// transferDevice asks a device to move to another project. The user has to
+// be allowed to transfer devices in both projects. The device is moved,
+// keeping its labels, environment and session recordings, once its agent
+// reports that it's ready to switch. Until then the transfer can be
+// cancelled by transferring the device to its current project.
+func (s *Service) transferDevice(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	var transferDeviceRequest models.TransferDeviceRequest
+	if err := read(r, &transferDeviceRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Service accounts only exist in a single project
+	if authenticatedServiceAccountID != "" {
+		http.Error(w, errServiceAccountTransfer.Error(), http.StatusForbidden)
+		return
+	}
+
+	var targetProject *models.Project
+	var err error
+	if strings.Contains(transferDeviceRequest.Project, "_") {
+		targetProject, err = s.projects.GetProject(r.Context(), transferDeviceRequest.Project)
+	} else {
+		targetProject, err = s.projects.LookupProject(r.Context(), transferDeviceRequest.Project)
+	}
+	if err == store.ErrProjectNotFound {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get target project")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Transferring a device to its own project cancels a pending transfer
+	if targetProject.ID == projectID {
+		device, err := s.devices.TransferDevice(r.Context(), deviceID, projectID, "")
+		if err != nil {
+			log.WithError(err).Error("cancel device transfer")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		utils.Respond(w, device)
+		return
+	}
+
+	authorized, err := s.userAuthorized(r.Context(), authenticatedUserID, targetProject.ID,
+		authz.ResourceDevices, authz.ActionTransferDevice)
+	if err != nil {
+		log.WithError(err).Error("authorize in target project")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !authorized {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	device, err := s.devices.GetDevice(r.Context(), deviceID, projectID)
+	if err != nil {
+		log.WithError(err).Error("get device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.devices.LookupDevice(r.Context(), device.Name, targetProject.ID); err == nil {
+		http.Error(w, store.ErrDeviceNameAlreadyInUse.Error(), http.StatusBadRequest)
+		return
+	} else if err != store.ErrDeviceNotFound {
+		log.WithError(err).Error("lookup device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if !s.withinDeviceLimit(w, r, targetProject.ID) {
+		return
+	}
+
+	device, err = s.devices.TransferDevice(r.Context(), deviceID, projectID, targetProject.ID)
+	if err != nil {
+		log.WithError(err).Error("transfer device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, device)
+}
+
+func (s *Service) finishDeviceTransfer(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
+	if device.TransferProjectID == "" {
+		http.Error(w, "device isn't being transferred", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.devices.LookupDevice(r.Context(), device.Name, device.TransferProjectID); err == nil {
+		http.Error(w, store.ErrDeviceNameAlreadyInUse.Error(), http.StatusConflict)
+		return
+	} else if err != store.ErrDeviceNotFound {
+		log.WithError(err).Error("lookup device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := s.devices.MoveDevice(r.Context(), device.ID, project.ID, device.TransferProjectID); err != nil {
+		log.WithError(err).Error("move device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	log.WithField("project_id", project.ID).
+		WithField("target_project_id", device.TransferProjectID).
+		WithField("device_id", device.ID).
+		Info("device transferred")
+}
+
+// setDeviceAgentVersion pins a device to an agent version, which keeps it
+// out of the project's agent rollouts.
+func (s *Service) setDeviceAgentVersion(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	var setDeviceAgentVersionRequest models.SetDeviceAgentVersionRequest
+	if err := read(r, &setDeviceAgentVersionRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(setDeviceAgentVersionRequest.Version) > 100 {
+		http.Error(w, "version is too long", http.StatusBadRequest)
+		return
+	}
+	if err := rollout.ValidateAgentSpec(setDeviceAgentVersionRequest.Spec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var spec string
+	if setDeviceAgentVersionRequest.Version != "" {
+		specBytes, err := json.Marshal(setDeviceAgentVersionRequest.Spec)
+		if err != nil {
+			log.WithError(err).Error("marshal agent spec")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		spec = string(specBytes)
+	}
+
+	device, err := s.devices.UpdateDeviceDesiredAgent(r.Context(), deviceID, projectID, setDeviceAgentVersionRequest.Version, spec)
+	if err != nil {
+		log.WithError(err).Error("update device desired agent")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, device)
+}
+
+func (s *Service) setDeviceReleasePin(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	var setDeviceReleasePinRequest models.SetDeviceReleasePinRequest
+	if err := read(r, &setDeviceReleasePinRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.devices.GetDevice(r.Context(), deviceID, projectID); err == store.ErrDeviceNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get device")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	release, err := utils.GetReleaseByIdentifier(s.releases, r.Context(), projectID, applicationID, setDeviceReleasePinRequest.Release)
+	if err == store.ErrReleaseNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("get release")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	releasePin, err := s.deviceReleasePins.SetDeviceReleasePin(r.Context(), projectID, deviceID, applicationID, release.ID)
+	if err != nil {
+		log.WithError(err).Error("set device release pin")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, releasePin)
+}
+
+func (s *Service) deleteDeviceReleasePin(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	if err := s.deviceReleasePins.DeleteDeviceReleasePin(r.Context(), projectID, deviceID, applicationID); err != nil {
+		log.WithError(err).Error("delete device release pin")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+}
 
-	device, err := s.devices.UpdateDeviceName(r.Context(), deviceID, projectID, updateDeviceRequest.Name)
+func (s *Service) listDeviceReleasePins(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID string,
+) {
+	releasePins, err := s.deviceReleasePins.ListDeviceReleasePins(r.Context(), projectID, applicationID)
 	if err != nil {
-		log.WithError(err).Error("update device name")
+		log.WithError(err).Error("list device release pins")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	utils.Respond(w, device)
+	utils.Respond(w, releasePins)
 }
 
 func (s *Service) deleteDevice(w http.ResponseWriter, r *http.Request,
@@ -2461,6 +4874,10 @@ func (s *Service) setDeviceLabel(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if strings.HasPrefix(setDeviceLabelRequest.Key, models.SystemLabelPrefix) {
+		http.Error(w, errSystemLabel.Error(), http.StatusBadRequest)
+		return
+	}
 
 	deviceLabel, err := s.devices.SetDeviceLabel(
 		r.Context(),
@@ -2492,13 +4909,80 @@ func (s *Service) deleteDeviceLabel(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+func (s *Service) getDeviceEnvironment(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	environment, err := s.deviceEnvironments.GetDeviceEnvironment(r.Context(), deviceID, projectID)
+	if err != nil {
+		log.WithError(err).Error("get device environment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, environment)
+}
+
+func (s *Service) setDeviceEnvironmentVariable(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	var setDeviceEnvironmentVariableRequest struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if err := read(r, &setDeviceEnvironmentVariableRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := spec.ValidateEnvironment(map[string]string{
+		setDeviceEnvironmentVariableRequest.Key: setDeviceEnvironmentVariableRequest.Value,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := s.deviceEnvironments.SetDeviceEnvironmentVariable(
+		r.Context(),
+		deviceID,
+		projectID,
+		setDeviceEnvironmentVariableRequest.Key,
+		setDeviceEnvironmentVariableRequest.Value,
+	)
+	if err != nil {
+		log.WithError(err).Error("set device environment variable")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, value)
+}
+
+func (s *Service) deleteDeviceEnvironmentVariable(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	if err := s.deviceEnvironments.DeleteDeviceEnvironmentVariable(r.Context(), deviceID, projectID, key); err != nil {
+		log.WithError(err).Error("delete device environment variable")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (s *Service) createDeviceRegistrationToken(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID string,
 ) {
 	var createDeviceRegistrationTokenRequest struct {
-		Name             string `json:"name" validate:"name"`
-		Description      string `json:"description" validate:"description"`
-		MaxRegistrations *int   `json:"maxRegistrations"`
+		Name             string     `json:"name" validate:"name"`
+		Description      string     `json:"description" validate:"description"`
+		MaxRegistrations *int       `json:"maxRegistrations" validate:"omitempty,min=0"`
+		ExpiresAt        *time.Time `json:"expiresAt"`
+		NamePrefix       string     `json:"namePrefix" validate:"nameprefix"`
+		RequireApproval  bool       `json:"requireApproval"`
 	}
 	if err := read(r, &createDeviceRegistrationTokenRequest); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -2523,7 +5007,10 @@ func (s *Service) createDeviceRegistrationToken(w http.ResponseWriter, r *http.R
 		projectID,
 		createDeviceRegistrationTokenRequest.Name,
 		createDeviceRegistrationTokenRequest.Description,
-		createDeviceRegistrationTokenRequest.MaxRegistrations)
+		createDeviceRegistrationTokenRequest.MaxRegistrations,
+		createDeviceRegistrationTokenRequest.ExpiresAt,
+		createDeviceRegistrationTokenRequest.NamePrefix,
+		createDeviceRegistrationTokenRequest.RequireApproval)
 	if err != nil {
 		log.WithError(err).Error("create device registration token")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -2565,9 +5052,12 @@ func (s *Service) updateDeviceRegistrationToken(w http.ResponseWriter, r *http.R
 	projectID, authenticatedUserID, authenticatedServiceAccountID, tokenID string,
 ) {
 	var updateDeviceRegistrationTokenRequest struct {
-		Name             string `json:"name" validate:"name"`
-		Description      string `json:"description" validate:"description"`
-		MaxRegistrations *int   `json:"maxRegistrations"`
+		Name             string     `json:"name" validate:"name"`
+		Description      string     `json:"description" validate:"description"`
+		MaxRegistrations *int       `json:"maxRegistrations" validate:"omitempty,min=0"`
+		ExpiresAt        *time.Time `json:"expiresAt"`
+		NamePrefix       string     `json:"namePrefix" validate:"nameprefix"`
+		RequireApproval  bool       `json:"requireApproval"`
 	}
 	if err := read(r, &updateDeviceRegistrationTokenRequest); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -2591,6 +5081,9 @@ func (s *Service) updateDeviceRegistrationToken(w http.ResponseWriter, r *http.R
 		updateDeviceRegistrationTokenRequest.Name,
 		updateDeviceRegistrationTokenRequest.Description,
 		updateDeviceRegistrationTokenRequest.MaxRegistrations,
+		updateDeviceRegistrationTokenRequest.ExpiresAt,
+		updateDeviceRegistrationTokenRequest.NamePrefix,
+		updateDeviceRegistrationTokenRequest.RequireApproval,
 	)
 	if err != nil {
 		log.WithError(err).Error("update device registration token")
@@ -2658,6 +5151,10 @@ func (s *Service) setDeviceRegistrationTokenLabel(w http.ResponseWriter, r *http
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if strings.HasPrefix(setLabelRequest.Key, models.SystemLabelPrefix) {
+		http.Error(w, errSystemLabel.Error(), http.StatusBadRequest)
+		return
+	}
 
 	label, err := s.deviceRegistrationTokens.SetDeviceRegistrationTokenLabel(
 		r.Context(),
@@ -2675,6 +5172,56 @@ func (s *Service) setDeviceRegistrationTokenLabel(w http.ResponseWriter, r *http
 	utils.Respond(w, label)
 }
 
+func (s *Service) setDeviceRegistrationTokenEnvironmentVariable(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceRegistrationTokenID string,
+) {
+	var setEnvironmentVariableRequest struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if err := read(r, &setEnvironmentVariableRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := spec.ValidateEnvironment(map[string]string{
+		setEnvironmentVariableRequest.Key: setEnvironmentVariableRequest.Value,
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	value, err := s.deviceRegistrationTokens.SetDeviceRegistrationTokenEnvironmentVariable(
+		r.Context(),
+		deviceRegistrationTokenID,
+		projectID,
+		setEnvironmentVariableRequest.Key,
+		setEnvironmentVariableRequest.Value,
+	)
+	if err != nil {
+		log.WithError(err).Error("set device registration token environment variable")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, value)
+}
+
+func (s *Service) deleteDeviceRegistrationTokenEnvironmentVariable(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceRegistrationTokenID string,
+) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	if err := s.deviceRegistrationTokens.DeleteDeviceRegistrationTokenEnvironmentVariable(r.Context(), deviceRegistrationTokenID, projectID, key); err != nil {
+		log.WithError(err).Error("delete device registration token environment variable")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 func (s *Service) deleteDeviceRegistrationTokenLabel(w http.ResponseWriter, r *http.Request,
 	projectID, authenticatedUserID, authenticatedServiceAccountID,
 	deviceRegistrationTokenID string,
@@ -2704,6 +5251,36 @@ func (s *Service) getProjectConfig(w http.ResponseWriter, r *http.Request,
 		value, err = s.metricConfigs.GetDeviceMetricsConfig(r.Context(), projectID)
 	case string(models.ServiceMetricsConfigKey):
 		value, err = s.metricConfigs.GetServiceMetricsConfigs(r.Context(), projectID)
+	case string(models.DeviceEndpointsConfigKey):
+		value, err = s.deviceEndpointConfigs.GetDeviceEndpointConfigs(r.Context(), projectID)
+	case string(models.SSHConfigKey):
+		value, err = s.sshConfigs.GetSSHConfig(r.Context(), projectID)
+	case string(models.MetricsExportTargetsConfigKey):
+		value, err = s.metricConfigs.GetMetricsExportTargets(r.Context(), projectID)
+	case string(models.MetricsCollectionConfigKey):
+		value, err = s.metricConfigs.GetMetricsCollectionConfig(r.Context(), projectID)
+	case string(models.AgentRolloutConfigKey):
+		value, err = s.agentRolloutConfigs.GetAgentRolloutConfig(r.Context(), projectID)
+	case string(models.DeviceEnvironmentConfigKey):
+		value, err = s.deviceEnvironmentConfigs.GetDeviceEnvironmentConfig(r.Context(), projectID)
+	case string(models.MaintenanceWindowsConfigKey):
+		value, err = s.maintenanceWindowsConfigs.GetMaintenanceWindowsConfig(r.Context(), projectID)
+	case string(models.ReleaseWebhooksConfigKey):
+		value, err = s.releaseWebhooksConfigs.GetReleaseWebhooksConfig(r.Context(), projectID)
+	case string(models.EventWebhooksConfigKey):
+		value, err = s.eventWebhooksConfigs.GetEventWebhooksConfig(r.Context(), projectID)
+	case string(models.AuditLogConfigKey):
+		value, err = s.auditLogConfigs.GetAuditLogConfig(r.Context(), projectID)
+	case string(models.SSOConfigKey):
+		value, err = s.ssoConfigs.GetSSOConfig(r.Context(), projectID)
+	case string(models.LimitsConfigKey):
+		value, err = s.getLimits(r.Context(), projectID)
+	case string(models.HeartbeatConfigKey):
+		value, err = s.heartbeatConfigs.GetHeartbeatConfig(r.Context(), projectID)
+	case string(models.AlertsConfigKey):
+		value, err = s.alertsConfigs.GetAlertsConfig(r.Context(), projectID)
+	case string(models.StaleDeviceCleanupConfigKey):
+		value, err = s.staleDeviceCleanupConfigs.GetStaleDeviceCleanupConfig(r.Context(), projectID)
 	default:
 		http.Error(w, store.ErrProjectConfigNotFound.Error(), http.StatusBadRequest)
 		return
@@ -2750,7 +5327,272 @@ func (s *Service) setProjectConfig(w http.ResponseWriter, r *http.Request,
 			return
 		}
 
-		err = s.metricConfigs.SetServiceMetricsConfigs(r.Context(), projectID, values)
+		err = s.metricConfigs.SetServiceMetricsConfigs(r.Context(), projectID, values)
+	case string(models.DeviceEndpointsConfigKey):
+		var values []models.DeviceEndpointConfig
+		if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, value := range values {
+			if value.Port <= 0 || value.Port > 65535 {
+				http.Error(w, fmt.Sprintf("invalid port %d", value.Port), http.StatusBadRequest)
+				return
+			}
+			if (value.ApplicationID == "") != (value.Service == "") {
+				http.Error(w, "applicationId and service must be set together", http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = s.deviceEndpointConfigs.SetDeviceEndpointConfigs(r.Context(), projectID, values)
+	case string(models.SSHConfigKey):
+		var value models.SSHConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.sshConfigs.SetSSHConfig(r.Context(), projectID, value)
+	case string(models.MetricsExportTargetsConfigKey):
+		var values []models.MetricsExportTarget
+		if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, value := range values {
+			switch value.Type {
+			case models.MetricsExportTargetTypeDatadog:
+				if value.Token == "" {
+					http.Error(w, "datadog targets require an API key token", http.StatusBadRequest)
+					return
+				}
+			case models.MetricsExportTargetTypePrometheusRemoteWrite, models.MetricsExportTargetTypeInfluxDB:
+				if value.URL == "" {
+					http.Error(w, fmt.Sprintf("%s targets require a url", value.Type), http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, fmt.Sprintf("invalid metrics export target type '%s'", value.Type), http.StatusBadRequest)
+				return
+			}
+			if value.URL != "" {
+				if u, err := url.Parse(value.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					http.Error(w, fmt.Sprintf("invalid url '%s'", value.URL), http.StatusBadRequest)
+					return
+				}
+			}
+		}
+
+		err = s.metricConfigs.SetMetricsExportTargets(r.Context(), projectID, values)
+	case string(models.MetricsCollectionConfigKey):
+		var value models.MetricsCollectionConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value.HostIntervalSeconds < 0 {
+			http.Error(w, "hostIntervalSeconds can't be negative", http.StatusBadRequest)
+			return
+		}
+		for _, name := range value.AllowedMetrics {
+			if name == "" {
+				http.Error(w, "allowed metric names can't be empty", http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = s.metricConfigs.SetMetricsCollectionConfig(r.Context(), projectID, value)
+	case string(models.AgentRolloutConfigKey):
+		var value models.AgentRolloutConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value.Percentage < 0 || value.Percentage > 100 {
+			http.Error(w, "percentage must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		if value.MaxErrorRateIncrease < 0 || value.MaxErrorRateIncrease > 100 {
+			http.Error(w, "maxErrorRateIncrease must be between 0 and 100", http.StatusBadRequest)
+			return
+		}
+		if value.Query != nil {
+			if err := query.ValidateQuery(*value.Query); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := rollout.ValidateAgentSpec(value.Spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		value.StartedAt = time.Now()
+
+		err = s.agentRolloutConfigs.SetAgentRolloutConfig(r.Context(), projectID, value)
+	case string(models.DeviceEnvironmentConfigKey):
+		var value models.DeviceEnvironmentConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, group := range value.Groups {
+			if group.Query != nil {
+				if err := query.ValidateQuery(*group.Query); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			}
+			if err := spec.ValidateEnvironment(group.Environment); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		err = s.deviceEnvironmentConfigs.SetDeviceEnvironmentConfig(r.Context(), projectID, value)
+	case string(models.MaintenanceWindowsConfigKey):
+		var value models.MaintenanceWindowsConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := maintenance.Validate(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.maintenanceWindowsConfigs.SetMaintenanceWindowsConfig(r.Context(), projectID, value)
+	case string(models.ReleaseWebhooksConfigKey):
+		var value models.ReleaseWebhooksConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := releasewebhooks.Validate(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.releaseWebhooksConfigs.SetReleaseWebhooksConfig(r.Context(), projectID, value)
+	case string(models.EventWebhooksConfigKey):
+		var value models.EventWebhooksConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := events.Validate(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.eventWebhooksConfigs.SetEventWebhooksConfig(r.Context(), projectID, value)
+	case string(models.AuditLogConfigKey):
+		var value models.AuditLogConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value.RetentionDays < 0 {
+			http.Error(w, errInvalidAuditLogRetention.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.auditLogConfigs.SetAuditLogConfig(r.Context(), projectID, value)
+	case string(models.SSOConfigKey):
+		var value models.SSOConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(value.GroupRoles) > 0 && value.Provider == "" {
+			http.Error(w, errMissingSSOProvider.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, groupRoles := range value.GroupRoles {
+			if groupRoles.Group == "" {
+				http.Error(w, errMissingSSOGroup.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, role := range groupRoles.Roles {
+				if _, err := s.roles.LookupRole(r.Context(), role, projectID); err == store.ErrRoleNotFound {
+					http.Error(w, errors.Wrap(err, role).Error(), http.StatusBadRequest)
+					return
+				} else if err != nil {
+					log.WithError(err).Error("lookup role")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
+		err = s.ssoConfigs.SetSSOConfig(r.Context(), projectID, value)
+	case string(models.LimitsConfigKey):
+		// Otherwise projects could lift their own limits
+		if authenticatedUserID == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if user, err := s.users.GetUser(r.Context(), authenticatedUserID); err != nil {
+			log.WithError(err).Error("get user")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		} else if !user.SuperAdmin {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var value models.LimitsConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value.MaxDevices < 0 || value.MaxApplications < 0 || value.MaxReleaseConfigSize < 0 || value.MaxRemoteSessions < 0 {
+			http.Error(w, errInvalidLimit.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.limitsConfigs.SetLimitsConfig(r.Context(), projectID, value)
+	case string(models.HeartbeatConfigKey):
+		var value models.HeartbeatConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if value.IntervalSeconds < 0 || value.IntervalSeconds > maxHeartbeatIntervalSeconds || value.OfflineThresholdSeconds < 0 {
+			http.Error(w, errInvalidHeartbeatInterval.Error(), http.StatusBadRequest)
+			return
+		}
+		// Otherwise devices would go offline between check-ins
+		if value.OfflineThreshold() < 2*value.Interval() {
+			http.Error(w, errOfflineThresholdTooShort.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.heartbeatConfigs.SetHeartbeatConfig(r.Context(), projectID, value)
+	case string(models.AlertsConfigKey):
+		var value models.AlertsConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := alerting.Validate(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.alertsConfigs.SetAlertsConfig(r.Context(), projectID, value)
+	case string(models.StaleDeviceCleanupConfigKey):
+		var value models.StaleDeviceCleanupConfig
+		if err := read(r, &value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := staledevices.Validate(value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.staleDeviceCleanupConfigs.SetStaleDeviceCleanupConfig(r.Context(), projectID, value)
 	default:
 		http.Error(w, store.ErrProjectConfigNotFound.Error(), http.StatusBadRequest)
 		return
@@ -2772,13 +5614,13 @@ func (s *Service) withDeviceAuth(handler func(http.ResponseWriter, *http.Request
 
 		deviceAccessKeyValue, _, _ := r.BasicAuth()
 		if deviceAccessKeyValue == "" {
-			w.WriteHeader(http.StatusUnauthorized)
+			s.unauthorized(w, r, nil)
 			return
 		}
 
 		deviceAccessKey, err := s.deviceAccessKeys.ValidateDeviceAccessKey(r.Context(), projectID, hash.Hash(deviceAccessKeyValue))
 		if err == store.ErrDeviceAccessKeyNotFound {
-			w.WriteHeader(http.StatusUnauthorized)
+			s.unauthorized(w, r, nil)
 			return
 		} else if err != nil {
 			log.WithError(err).Error("validate device access key")
@@ -2800,6 +5642,10 @@ func (s *Service) withDeviceAuth(handler func(http.ResponseWriter, *http.Request
 			return
 		}
 
+		if !s.rateLimit(w, r, "device:"+device.ID) {
+			return
+		}
+
 		handler(w, r, *project, *device)
 	}
 }
@@ -2821,6 +5667,14 @@ func (s *Service) registerDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if deviceRegistrationToken.Expired(time.Now()) {
+		log.WithField("project_id", projectID).
+			WithField("device_registration_token_id", deviceRegistrationToken.ID).
+			Warn("device registration with expired token")
+		http.Error(w, errDeviceRegistrationTokenExpired.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	if deviceRegistrationToken.MaxRegistrations != nil {
 		devicesRegisteredCount, err := s.devicesRegisteredWithToken.GetDevicesRegisteredWithTokenCount(r.Context(), registerDeviceRequest.DeviceRegistrationTokenID, projectID)
 		if err != nil {
@@ -2830,19 +5684,34 @@ func (s *Service) registerDevice(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if devicesRegisteredCount.AllCount >= *deviceRegistrationToken.MaxRegistrations {
-			log.WithError(err).Error("device allocation limit reached")
-			w.WriteHeader(http.StatusBadRequest)
+			log.WithField("project_id", projectID).
+				WithField("device_registration_token_id", deviceRegistrationToken.ID).
+				Warn("device registration limit reached")
+			http.Error(w, errDeviceRegistrationLimitReached.Error(), http.StatusUnauthorized)
 			return
 		}
 	}
 
-	device, err := s.devices.CreateDevice(r.Context(), projectID, namesgenerator.GetRandomName(), deviceRegistrationToken.ID, deviceRegistrationToken.Labels)
+	if !s.withinDeviceLimit(w, r, projectID) {
+		return
+	}
+
+	name := deviceRegistrationToken.NamePrefix + namesgenerator.GetRandomName()
+	device, err := s.devices.CreateDevice(r.Context(), projectID, name, deviceRegistrationToken.ID, deviceRegistrationToken.Labels, deviceRegistrationToken.RequireApproval)
 	if err != nil {
 		log.WithError(err).Error("create device")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	for key, value := range deviceRegistrationToken.Environment {
+		if _, err := s.deviceEnvironments.SetDeviceEnvironmentVariable(r.Context(), device.ID, projectID, key, value); err != nil {
+			log.WithError(err).Error("set device environment variable")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
 	deviceAccessKeyValue := ksuid.New().String()
 
 	_, err = s.deviceAccessKeys.CreateDeviceAccessKey(r.Context(), projectID, device.ID, hash.Hash(deviceAccessKeyValue))
@@ -2852,12 +5721,61 @@ func (s *Service) registerDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeDeviceRegistered,
+		ProjectID: projectID,
+		Device:    device,
+	})
+
 	utils.Respond(w, models.RegisterDeviceResponse{
 		DeviceID:             device.ID,
 		DeviceAccessKeyValue: deviceAccessKeyValue,
 	})
 }
 
+// getAuthorizedSSHKeys returns the SSH keys of every project member whose
+// roles allow them to SSH into devices. Removing a member, one of their
+// roles or one of their keys revokes access on the next bundle fetch.
+func (s *Service) getAuthorizedSSHKeys(ctx context.Context, projectID string) ([]string, error) {
+	memberships, err := s.memberships.ListMembershipsByProject(ctx, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "list memberships")
+	}
+
+	authorizedKeys := make([]string, 0)
+	for _, membership := range memberships {
+		roleBindings, err := s.membershipRoleBindings.ListMembershipRoleBindings(ctx, membership.UserID, projectID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list membership role bindings")
+		}
+
+		var roles []string
+		for _, roleBinding := range roleBindings {
+			roles = append(roles, roleBinding.RoleID)
+		}
+
+		configs, err := s.getRoleConfigs(ctx, projectID, roles)
+		if err != nil {
+			return nil, err
+		}
+
+		if !authz.Evaluate(authz.ResourceDevices, authz.ActionSSH, configs) {
+			continue
+		}
+
+		sshKeys, err := s.sshKeys.ListSSHKeys(ctx, membership.UserID)
+		if err != nil {
+			return nil, errors.Wrap(err, "list ssh keys")
+		}
+
+		for _, sshKey := range sshKeys {
+			authorizedKeys = append(authorizedKeys, sshKey.PublicKey)
+		}
+	}
+
+	return authorizedKeys, nil
+}
+
 func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
 	s.st.Incr("get_bundle", []string{
 		fmt.Sprintf("project_id:%s", project.ID),
@@ -2870,6 +5788,52 @@ func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project mode
 		return
 	}
 
+	// The device's status is from before it was seen just now
+	if device.Status == models.DeviceStatusOffline {
+		if err := s.deviceConnectivityEvents.CreateDeviceConnectivityEvent(r.Context(), project.ID, device.ID,
+			models.DeviceStatusOnline, time.Now()); err != nil {
+			log.WithError(err).Error("create device connectivity event")
+		}
+
+		onlineDevice := device
+		onlineDevice.Status = models.DeviceStatusOnline
+		s.events.Publish(r.Context(), models.Event{
+			Type:      models.EventTypeDeviceOnline,
+			ProjectID: project.ID,
+			Device:    &onlineDevice,
+		})
+	}
+
+	if device.Decommissioning {
+		utils.Respond(w, models.Bundle{
+			Decommission: true,
+		})
+		return
+	}
+
+	if device.TransferProjectID != "" {
+		utils.Respond(w, models.Bundle{
+			TransferProjectID: device.TransferProjectID,
+		})
+		return
+	}
+
+	// Devices pending approval are still seen so that they can be told
+	// apart before they're approved
+	if device.PendingApproval {
+		http.Error(w, errDevicePendingApproval.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Scheduling rules, rollouts, environments and maintenance windows can
+	// refer to groups
+	groups, err := devicegroups.LoadDevice(r.Context(), s.deviceGroups, project.ID, &device)
+	if err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	applications, err := s.applications.ListApplications(r.Context(), project.ID)
 	if err != nil {
 		log.WithError(err).Error("list applications")
@@ -2877,11 +5841,82 @@ func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project mode
 		return
 	}
 
+	agentRolloutConfig, err := s.agentRolloutConfigs.GetAgentRolloutConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get agent rollout config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	desiredAgentVersion, desiredAgentSpec, err := rollout.DesiredAgent(device, *agentRolloutConfig)
+	if err != nil {
+		log.WithError(err).Error("evaluate agent rollout")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceEnvironmentConfig, err := s.deviceEnvironmentConfigs.GetDeviceEnvironmentConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get device environment config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	deviceEnvironment, err := s.deviceEnvironments.GetDeviceEnvironment(r.Context(), device.ID, project.ID)
+	if err != nil {
+		log.WithError(err).Error("get device environment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resolvedEnvironment, err := environment.Resolve(device, *deviceEnvironmentConfig, deviceEnvironment)
+	if err != nil {
+		log.WithError(err).Error("resolve device environment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	heartbeatConfig, err := s.heartbeatConfigs.GetHeartbeatConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get heartbeat config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	bundle := models.Bundle{
-		DesiredAgentSpec:    device.DesiredAgentSpec,
-		DesiredAgentVersion: device.DesiredAgentVersion,
+		DesiredAgentSpec:         desiredAgentSpec,
+		DesiredAgentVersion:      desiredAgentVersion,
+		Environment:              resolvedEnvironment,
+		HeartbeatIntervalSeconds: heartbeatConfig.IntervalSeconds,
+	}
+
+	releasePins, err := s.deviceReleasePins.ListDeviceReleasePinsByDevice(r.Context(), project.ID, device.ID)
+	if err != nil {
+		log.WithError(err).Error("list device release pins")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	pinnedReleaseIDs := make(map[string]string)
+	for _, releasePin := range releasePins {
+		pinnedReleaseIDs[releasePin.ApplicationID] = releasePin.ReleaseID
+	}
+
+	maintenanceWindowsConfig, err := s.maintenanceWindowsConfigs.GetMaintenanceWindowsConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get maintenance windows config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	updatesAllowed, err := maintenance.UpdatesAllowed(device, groups, *maintenanceWindowsConfig, time.Now())
+	if err != nil {
+		log.WithError(err).Error("evaluate maintenance windows")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
+	var environmentFiles map[string]string
+
 	for _, application := range applications {
 		scheduled, scheduledDevice, err := scheduling.IsApplicationScheduled(device, application.SchedulingRule)
 		if err != nil {
@@ -2903,6 +5938,89 @@ func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project mode
 			return
 		}
 
+		// Devices that a rollout of the release hasn't reached yet keep
+		// running the previous release
+		latestRollout, err := s.rollouts.GetLatestRollout(r.Context(), project.ID, application.ID)
+		if err == store.ErrRolloutNotFound {
+			latestRollout = nil
+		} else if err != nil {
+			log.WithError(err).Error("get latest rollout")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		desiredReleaseID, err := rollout.DesiredRelease(device, release.ID, latestRollout)
+		if err != nil {
+			log.WithError(err).Error("evaluate release rollout")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Devices pinned to a release stay on it regardless of the
+		// scheduled release and rollouts
+		if pinnedReleaseID, ok := pinnedReleaseIDs[application.ID]; ok {
+			desiredReleaseID = pinnedReleaseID
+		}
+
+		// Outside of their maintenance windows devices keep running the
+		// release they have
+		if !updatesAllowed {
+			deviceApplicationStatus, err := s.deviceApplicationStatuses.GetDeviceApplicationStatus(
+				r.Context(), project.ID, device.ID, application.ID)
+			if err == nil {
+				desiredReleaseID = deviceApplicationStatus.CurrentReleaseID
+			} else if err != store.ErrDeviceApplicationStatusNotFound {
+				log.WithError(err).Error("get device application status")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+		if desiredReleaseID == "" {
+			continue
+		}
+		if desiredReleaseID != release.ID {
+			release, err = s.releases.GetRelease(r.Context(), desiredReleaseID, project.ID, application.ID)
+			if err == store.ErrReleaseNotFound {
+				continue
+			}
+			if err != nil {
+				log.WithError(err).Errorf("get release by ID %s", desiredReleaseID)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		*release, err = spec.WithDeviceEnvironment(*release, resolvedEnvironment)
+		if err != nil {
+			log.WithError(err).Errorf("interpolate device environment into release %s", release.ID)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		// Environment files are resolved here so that changes to them are
+		// picked up by devices without a new release
+		for serviceName, service := range release.Config {
+			if len(service.EnvFile) == 0 {
+				continue
+			}
+
+			if environmentFiles == nil {
+				environmentFiles, err = s.getEnvironmentFileContents(r.Context(), project.ID)
+				if err != nil {
+					log.WithError(err).Error("get environment file contents")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+
+			release.Config[serviceName], err = spec.WithEnvironmentFiles(service, environmentFiles)
+			if err != nil {
+				log.WithError(err).Errorf("resolve environment files of service %s in release %s", serviceName, release.ID)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
 		bundle.Applications = append(bundle.Applications, models.FullBundledApplication{
 			Application: models.BundledApplication{
 				ID:                    application.ID,
@@ -2914,6 +6032,41 @@ func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project mode
 		})
 	}
 
+	configFiles, err := s.configFiles.ListConfigFiles(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("list config files")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	bundle.ConfigFiles = configfile.Bundle(configFiles, bundle.Applications, resolvedEnvironment)
+
+	sshConfig, err := s.sshConfigs.GetSSHConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get ssh config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if sshConfig.EnforceSSHKeys {
+		authorizedKeys, err := s.getAuthorizedSSHKeys(r.Context(), project.ID)
+		if err != nil {
+			log.WithError(err).Error("get authorized ssh keys")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		bundle.SSHKeys = models.BundledSSHKeys{
+			Enforce:        true,
+			AuthorizedKeys: authorizedKeys,
+		}
+	}
+
+	metricsCollectionConfig, err := s.metricConfigs.GetMetricsCollectionConfig(r.Context(), project.ID)
+	if err != nil {
+		log.WithError(err).Error("get metrics collection config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	bundle.MetricsConfig = bundledMetricsConfig(*metricsCollectionConfig, project, device)
+
 	deviceApplicationStatuses, err := s.deviceApplicationStatuses.ListDeviceApplicationStatuses(
 		r.Context(), project.ID, device.ID)
 	if err == nil {
@@ -2937,6 +6090,44 @@ func (s *Service) getBundle(w http.ResponseWriter, r *http.Request, project mode
 	utils.Respond(w, bundle)
 }
 
+// bundledMetricsConfig resolves a project's metrics collection config for a
+// device.
+func bundledMetricsConfig(config models.MetricsCollectionConfig, project models.Project, device models.Device) models.BundledMetricsConfig {
+	labels := make(map[string]string)
+	for _, key := range config.DeviceLabels {
+		if value, ok := device.Label(key); ok {
+			labels[key] = value
+		}
+	}
+	if config.IncludeProject {
+		labels["project"] = project.Name
+	}
+	if config.IncludeDevice {
+		labels["device"] = device.Name
+	}
+
+	return models.BundledMetricsConfig{
+		HostCollectors:      config.HostCollectors,
+		HostIntervalSeconds: config.HostIntervalSeconds,
+		AllowedMetrics:      config.AllowedMetrics,
+		Labels:              labels,
+	}
+}
+
+func (s *Service) getEnvironmentFileContents(ctx context.Context, projectID string) (map[string]string, error) {
+	environmentFiles, err := s.environmentFiles.ListEnvironmentFiles(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string)
+	for _, environmentFile := range environmentFiles {
+		contents[environmentFile.Name] = environmentFile.Content
+	}
+
+	return contents, nil
+}
+
 func (s *Service) setDeviceInfo(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
 	var setDeviceInfoRequest models.SetDeviceInfoRequest
 	if err := read(r, &setDeviceInfoRequest); err != nil {
@@ -2949,6 +6140,131 @@ func (s *Service) setDeviceInfo(w http.ResponseWriter, r *http.Request, project
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	s.mergeLocalLabels(r.Context(), project, device, setDeviceInfoRequest.DeviceInfo)
+	s.alertLowDisk(project, device, setDeviceInfoRequest.DeviceInfo)
+	s.alertUnexpectedBoot(project, device, setDeviceInfoRequest.DeviceInfo)
+	s.alertClockSkew(project, device, setDeviceInfoRequest.DeviceInfo)
+}
+
+// mergeLocalLabels applies changes to the labels from the device's labels
+// file to the device's labels. Only labels that changed in the file since
+// the last report are applied, so labels set through the controller win
+// until the file changes again. Labels removed from the file are deleted
+// unless they've since been set to something else.
+func (s *Service) mergeLocalLabels(ctx context.Context, project models.Project, device models.Device, deviceInfo models.DeviceInfo) {
+	previous := device.Info.LocalLabels
+
+	for key, value := range deviceInfo.LocalLabels {
+		if previousValue, ok := previous[key]; ok && previousValue == value {
+			continue
+		}
+		if strings.HasPrefix(key, models.SystemLabelPrefix) {
+			continue
+		}
+		if _, err := s.devices.SetDeviceLabel(ctx, device.ID, project.ID, key, value); err != nil {
+			log.WithError(err).Error("set local device label")
+		}
+	}
+
+	for key, previousValue := range previous {
+		if _, ok := deviceInfo.LocalLabels[key]; ok || device.Labels[key] != previousValue {
+			continue
+		}
+		if err := s.devices.DeleteDeviceLabel(ctx, device.ID, project.ID, key); err != nil {
+			log.WithError(err).Error("delete local device label")
+		}
+	}
+}
+
+// alertClockSkew warns about devices whose clocks have drifted far enough
+// from the controller's to break TLS or timestamps.
+func (s *Service) alertClockSkew(project models.Project, device models.Device, deviceInfo models.DeviceInfo) {
+	if models.ClockSkewed(device.Info.Clock.Skew) || !models.ClockSkewed(deviceInfo.Clock.Skew) {
+		return
+	}
+
+	entry := log.WithField("project_id", project.ID).
+		WithField("device_id", device.ID).
+		WithField("skew", deviceInfo.Clock.Skew)
+	if deviceInfo.Clock.NTPSynchronized != nil {
+		entry = entry.WithField("ntp_synchronized", *deviceInfo.Clock.NTPSynchronized)
+	}
+	entry.Warn("device clock skewed")
+	s.st.Incr("device_clock_skewed", []string{
+		fmt.Sprintf("project_id:%s", project.ID),
+		fmt.Sprintf("project_name:%s", project.Name),
+	}, 1)
+}
+
+// alertUnexpectedBoot warns about devices that have booted again without
+// being asked to. The kernel's boot time can shift slightly as the clock is
+// adjusted, so small changes are ignored.
+func (s *Service) alertUnexpectedBoot(project models.Project, device models.Device, deviceInfo models.DeviceInfo) {
+	previousBoot := device.Info.Boot
+	boot := deviceInfo.Boot
+	if previousBoot.Time.IsZero() || boot.Time.Sub(previousBoot.Time) < time.Minute || !boot.Unexpected() {
+		return
+	}
+
+	log.WithField("project_id", project.ID).
+		WithField("device_id", device.ID).
+		WithField("reason", boot.Reason).
+		WithField("previous_boot", previousBoot.Time).
+		Warn("device booted unexpectedly")
+	s.st.Incr("device_unexpected_boot", []string{
+		fmt.Sprintf("project_id:%s", project.ID),
+		fmt.Sprintf("project_name:%s", project.Name),
+		fmt.Sprintf("reason:%s", boot.Reason),
+	}, 1)
+}
+
+// alertLowDisk warns about filesystems that have become low on disk since
+// the device last reported its info.
+func (s *Service) alertLowDisk(project models.Project, device models.Device, deviceInfo models.DeviceInfo) {
+	wasLowDisk := make(map[string]bool)
+	for _, filesystem := range device.Info.LowDiskFilesystems() {
+		wasLowDisk[filesystem.Mountpoint] = true
+	}
+
+	for _, filesystem := range deviceInfo.LowDiskFilesystems() {
+		if wasLowDisk[filesystem.Mountpoint] {
+			continue
+		}
+
+		log.WithField("project_id", project.ID).
+			WithField("device_id", device.ID).
+			WithField("mountpoint", filesystem.Mountpoint).
+			Warn("device low on disk")
+		s.st.Incr("device_low_disk", []string{
+			fmt.Sprintf("project_id:%s", project.ID),
+			fmt.Sprintf("project_name:%s", project.Name),
+		}, 1)
+	}
+}
+
+func (s *Service) finishSessionRecording(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
+	vars := mux.Vars(r)
+	sessionRecordingID := vars["sessionrecording"]
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxSessionRecordingRequestSize)
+
+	var finishSessionRecordingRequest models.FinishSessionRecordingRequest
+	if err := read(r, &finishSessionRecordingRequest); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.sessionRecordings.FinishSessionRecording(r.Context(), sessionRecordingID, project.ID, device.ID,
+		[]byte(finishSessionRecordingRequest.Recording),
+	); err == store.ErrSessionRecordingNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("finish session recording")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 }
 
 func (s *Service) setDeviceApplicationStatus(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
@@ -2968,6 +6284,17 @@ func (s *Service) setDeviceApplicationStatus(w http.ResponseWriter, r *http.Requ
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeApplicationStatusUpdated,
+		ProjectID: project.ID,
+		ApplicationStatus: &models.DeviceApplicationStatus{
+			ProjectID:        project.ID,
+			DeviceID:         device.ID,
+			ApplicationID:    applicationID,
+			CurrentReleaseID: setDeviceApplicationStatusRequest.CurrentReleaseID,
+		},
+	})
 }
 
 func (s *Service) deleteDeviceApplicationStatus(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
@@ -3001,6 +6328,18 @@ func (s *Service) setDeviceServiceStatus(w http.ResponseWriter, r *http.Request,
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	s.events.Publish(r.Context(), models.Event{
+		Type:      models.EventTypeServiceStatusUpdated,
+		ProjectID: project.ID,
+		ServiceStatus: &models.DeviceServiceStatus{
+			ProjectID:        project.ID,
+			DeviceID:         device.ID,
+			ApplicationID:    applicationID,
+			Service:          service,
+			CurrentReleaseID: setDeviceServiceStatusRequest.CurrentReleaseID,
+		},
+	})
 }
 
 func (s *Service) deleteDeviceServiceStatus(w http.ResponseWriter, r *http.Request, project models.Project, device models.Device) {
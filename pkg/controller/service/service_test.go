@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/authz"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/hash"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// The fakes embed the store interfaces they stand in for, so only the
+// methods a test needs have to be written. Anything else panics.
+
+type fakeUsers struct {
+	store.Users
+	users map[string]models.User
+}
+
+func (f *fakeUsers) GetUser(ctx context.Context, id string) (*models.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, store.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+type fakeSessions struct {
+	store.Sessions
+	sessions map[string]models.Session
+}
+
+func (f *fakeSessions) ValidateSession(ctx context.Context, hash string) (*models.Session, error) {
+	session, ok := f.sessions[hash]
+	if !ok {
+		return nil, store.ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+type fakeUserAccessKeys struct {
+	store.UserAccessKeys
+	userAccessKeys map[string]models.UserAccessKey
+}
+
+func (f *fakeUserAccessKeys) ValidateUserAccessKey(ctx context.Context, hash string) (*models.UserAccessKey, error) {
+	userAccessKey, ok := f.userAccessKeys[hash]
+	if !ok {
+		return nil, store.ErrUserAccessKeyNotFound
+	}
+	return &userAccessKey, nil
+}
+
+type fakeServiceAccounts struct {
+	store.ServiceAccounts
+}
+
+func (f *fakeServiceAccounts) GetServiceAccount(ctx context.Context, id, projectID string) (*models.ServiceAccount, error) {
+	return &models.ServiceAccount{
+		ID:        id,
+		ProjectID: projectID,
+	}, nil
+}
+
+type fakeServiceAccountAccessKeys struct {
+	store.ServiceAccountAccessKeys
+	serviceAccountAccessKeys map[string]models.ServiceAccountAccessKey
+}
+
+func (f *fakeServiceAccountAccessKeys) ValidateServiceAccountAccessKey(ctx context.Context, hash string) (*models.ServiceAccountAccessKey, error) {
+	serviceAccountAccessKey, ok := f.serviceAccountAccessKeys[hash]
+	if !ok {
+		return nil, store.ErrServiceAccountAccessKeyNotFound
+	}
+	return &serviceAccountAccessKey, nil
+}
+
+type fakeRoles struct {
+	store.Roles
+	roles map[string]models.Role
+}
+
+func (f *fakeRoles) GetRole(ctx context.Context, id, projectID string) (*models.Role, error) {
+	role, ok := f.roles[id]
+	if !ok || role.ProjectID != projectID {
+		return nil, store.ErrRoleNotFound
+	}
+	return &role, nil
+}
+
+func (f *fakeRoles) LookupRole(ctx context.Context, name, projectID string) (*models.Role, error) {
+	for _, role := range f.roles {
+		if role.Name == name && role.ProjectID == projectID {
+			return &role, nil
+		}
+	}
+	return nil, store.ErrRoleNotFound
+}
+
+type fakeMemberships struct {
+	store.Memberships
+	memberships []models.Membership
+}
+
+func (f *fakeMemberships) CreateMembership(ctx context.Context, userID, projectID string) (*models.Membership, error) {
+	membership := models.Membership{UserID: userID, ProjectID: projectID}
+	f.memberships = append(f.memberships, membership)
+	return &membership, nil
+}
+
+func (f *fakeMemberships) GetMembership(ctx context.Context, userID, projectID string) (*models.Membership, error) {
+	for _, membership := range f.memberships {
+		if membership.UserID == userID && membership.ProjectID == projectID {
+			return &membership, nil
+		}
+	}
+	return nil, store.ErrMembershipNotFound
+}
+
+type fakeMembershipRoleBindings struct {
+	store.MembershipRoleBindings
+	membershipRoleBindings []models.MembershipRoleBinding
+}
+
+func (f *fakeMembershipRoleBindings) CreateMembershipRoleBinding(ctx context.Context, userID, roleID, projectID string) (*models.MembershipRoleBinding, error) {
+	membershipRoleBinding := models.MembershipRoleBinding{UserID: userID, RoleID: roleID, ProjectID: projectID}
+	f.membershipRoleBindings = append(f.membershipRoleBindings, membershipRoleBinding)
+	return &membershipRoleBinding, nil
+}
+
+func (f *fakeMembershipRoleBindings) ListMembershipRoleBindings(ctx context.Context, userID, projectID string) ([]models.MembershipRoleBinding, error) {
+	var membershipRoleBindings []models.MembershipRoleBinding
+	for _, membershipRoleBinding := range f.membershipRoleBindings {
+		if membershipRoleBinding.UserID == userID && membershipRoleBinding.ProjectID == projectID {
+			membershipRoleBindings = append(membershipRoleBindings, membershipRoleBinding)
+		}
+	}
+	return membershipRoleBindings, nil
+}
+
+func (f *fakeMembershipRoleBindings) DeleteMembershipRoleBinding(ctx context.Context, userID, roleID, projectID string) error {
+	var membershipRoleBindings []models.MembershipRoleBinding
+	for _, membershipRoleBinding := range f.membershipRoleBindings {
+		if membershipRoleBinding.UserID != userID || membershipRoleBinding.RoleID != roleID || membershipRoleBinding.ProjectID != projectID {
+			membershipRoleBindings = append(membershipRoleBindings, membershipRoleBinding)
+		}
+	}
+	f.membershipRoleBindings = membershipRoleBindings
+	return nil
+}
+
+type fakeSSOConfigs struct {
+	store.SSOConfigs
+	ssoConfigs map[string]models.SSOConfig
+}
+
+func (f *fakeSSOConfigs) ListSSOConfigsByProvider(ctx context.Context, provider string) (map[string]models.SSOConfig, error) {
+	ssoConfigs := make(map[string]models.SSOConfig)
+	for projectID, ssoConfig := range f.ssoConfigs {
+		if ssoConfig.Provider == provider {
+			ssoConfigs[projectID] = ssoConfig
+		}
+	}
+	return ssoConfigs, nil
+}
+
+type fakeServiceAccountRoleBindings struct {
+	store.ServiceAccountRoleBindings
+	serviceAccountRoleBindings []models.ServiceAccountRoleBinding
+}
+
+func (f *fakeServiceAccountRoleBindings) ListServiceAccountRoleBindings(ctx context.Context, serviceAccountID, projectID string) ([]models.ServiceAccountRoleBinding, error) {
+	var serviceAccountRoleBindings []models.ServiceAccountRoleBinding
+	for _, serviceAccountRoleBinding := range f.serviceAccountRoleBindings {
+		if serviceAccountRoleBinding.ServiceAccountID == serviceAccountID && serviceAccountRoleBinding.ProjectID == projectID {
+			serviceAccountRoleBindings = append(serviceAccountRoleBindings, serviceAccountRoleBinding)
+		}
+	}
+	return serviceAccountRoleBindings, nil
+}
+
+type fakeAuditLogEntries struct {
+	store.AuditLogEntries
+	actions []string
+}
+
+func (f *fakeAuditLogEntries) CreateAuditLogEntry(ctx context.Context, projectID, userID, serviceAccountID, action, method, path, summary, remoteAddr string, statusCode int, duration time.Duration) error {
+	f.actions = append(f.actions, action)
+	return nil
+}
+
+// newTestService returns a service with users usr_1 and usr_2, whose access
+// keys are u1 and u2, and service account sac_1 in project prj_1, whose
+// access keys are s1, s1-restricted and s1-expired. The service account's
+// role lets it get and list devices and create access keys, and the
+// restricted key is limited to getting devices and applications and
+// creating access keys.
+func newTestService() *Service {
+	expiredAt := time.Now().Add(-time.Hour)
+	return &Service{
+		users: &fakeUsers{
+			users: map[string]models.User{
+				"usr_1": {ID: "usr_1", RegistrationCompleted: true},
+				"usr_2": {ID: "usr_2", RegistrationCompleted: true},
+			},
+		},
+		sessions: &fakeSessions{
+			sessions: map[string]models.Session{
+				hash.Hash("session1"): {ID: "ses_1", UserID: "usr_1"},
+			},
+		},
+		userAccessKeys: &fakeUserAccessKeys{
+			userAccessKeys: map[string]models.UserAccessKey{
+				hash.Hash("u1"): {ID: "uak_1", UserID: "usr_1"},
+				hash.Hash("u2"): {ID: "uak_2", UserID: "usr_2"},
+			},
+		},
+		serviceAccounts: &fakeServiceAccounts{},
+		serviceAccountAccessKeys: &fakeServiceAccountAccessKeys{
+			serviceAccountAccessKeys: map[string]models.ServiceAccountAccessKey{
+				hash.Hash("s1"): {
+					ID:               "sak_1",
+					ProjectID:        "prj_1",
+					ServiceAccountID: "sac_1",
+				},
+				hash.Hash("s1-restricted"): {
+					ID:               "sak_2",
+					ProjectID:        "prj_1",
+					ServiceAccountID: "sac_1",
+					Config: `rules:
+- resources: [devices, applications]
+  actions: [GetDevice, GetApplication]
+- resources: [serviceaccountaccesskeys]
+  actions: [CreateServiceAccountAccessKey]
+`,
+				},
+				hash.Hash("s1-expired"): {
+					ID:               "sak_3",
+					ProjectID:        "prj_1",
+					ServiceAccountID: "sac_1",
+					ExpiresAt:        &expiredAt,
+				},
+			},
+		},
+		serviceAccountRoleBindings: &fakeServiceAccountRoleBindings{
+			serviceAccountRoleBindings: []models.ServiceAccountRoleBinding{
+				{ServiceAccountID: "sac_1", RoleID: "rol_1", ProjectID: "prj_1"},
+			},
+		},
+		roles: &fakeRoles{
+			roles: map[string]models.Role{
+				"rol_1": {
+					ID:        "rol_1",
+					ProjectID: "prj_1",
+					Config: `rules:
+- resources: [devices]
+  actions: [GetDevice, ListDevices]
+- resources: [serviceaccountaccesskeys]
+  actions: [CreateServiceAccountAccessKey]
+`,
+				},
+			},
+		},
+		auditLogEntries: &fakeAuditLogEntries{},
+		shutdown:        make(chan struct{}),
+	}
+}
+
+// serve makes a request to a handler from an address, authenticated with an
+// access key if one is given, and returns the response.
+func serve(t *testing.T, handler http.HandlerFunc, remoteAddr, accessKey string) *httptest.ResponseRecorder {
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = remoteAddr
+	if accessKey != "" {
+		r.SetBasicAuth(accessKey, "")
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// authorize makes a request for an action in project prj_1 with an access
+// key, and returns the response and whether the request reached its handler.
+func authorize(t *testing.T, s *Service, resource authz.Resource, action authz.Action, accessKey string) (*httptest.ResponseRecorder, bool) {
+	handled := false
+	handler := s.validateAuthorization(resource, action, func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
+		require.Equal(t, "prj_1", projectID)
+		require.Equal(t, "sac_1", authenticatedServiceAccountID)
+		handled = true
+	})
+
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	r = mux.SetURLVars(r, map[string]string{"project": "prj_1"})
+	r.SetBasicAuth(accessKey, "")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w, handled
+}
+
+func TestExpiredServiceAccountAccessKey(t *testing.T) {
+	s := newTestService()
+
+	w, handled := authorize(t, s, authz.ResourceDevices, authz.ActionGetDevice, "s1-expired")
+	require.False(t, handled)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), errServiceAccountAccessKeyExpired.Error())
+}
+
+func TestRestrictedServiceAccountAccessKey(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		resource      authz.Resource
+		action        authz.Action
+		allowedByKey  bool
+		allowedByRole bool
+	}{
+		{
+			name:          "allowed by both",
+			resource:      authz.ResourceDevices,
+			action:        authz.ActionGetDevice,
+			allowedByKey:  true,
+			allowedByRole: true,
+		},
+		{
+			name:          "allowed by the role",
+			resource:      authz.ResourceDevices,
+			action:        authz.ActionListDevices,
+			allowedByRole: true,
+		},
+		{
+			name:         "allowed by the key",
+			resource:     authz.ResourceApplications,
+			action:       authz.ActionGetApplication,
+			allowedByKey: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestService()
+
+			w, handled := authorize(t, s, tc.resource, tc.action, "s1")
+			require.Equal(t, tc.allowedByRole, handled)
+			if !tc.allowedByRole {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			}
+
+			w, handled = authorize(t, s, tc.resource, tc.action, "s1-restricted")
+			require.Equal(t, tc.allowedByKey && tc.allowedByRole, handled)
+			if !handled {
+				require.Equal(t, http.StatusForbidden, w.Code)
+			}
+		})
+	}
+}
+
+func TestRestrictedServiceAccountAccessKeyCantCreateAccessKeys(t *testing.T) {
+	s := newTestService()
+
+	_, handled := authorize(t, s, authz.ResourceServiceAccountAccessKeys, authz.ActionCreateServiceAccountAccessKey, "s1")
+	require.True(t, handled)
+
+	// Both the key and the role allow it, but the key could then make itself
+	// an unrestricted one
+	w, handled := authorize(t, s, authz.ResourceServiceAccountAccessKeys, authz.ActionCreateServiceAccountAccessKey, "s1-restricted")
+	require.False(t, handled)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), errRestrictedAccessKey.Error())
+
+	// The refusal is audited
+	require.Equal(t, []string{
+		string(authz.ActionCreateServiceAccountAccessKey),
+		string(authz.ActionCreateServiceAccountAccessKey),
+	}, s.auditLogEntries.(*fakeAuditLogEntries).actions)
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/segmentio/ksuid"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/saml"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/hash"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	oidcStateCookie     = "dp_oidc"
+	oidcStateCookiePath = "/api/sso/oidc"
+	samlStateCookie     = "dp_saml"
+	samlStateCookiePath = "/api/sso/saml"
+	ssoStateMaxAge      = 10 * 60
+
+	defaultSSORedirect = "/projects"
+)
+
+// ssoUser is who a user is according to a single sign-on provider, which is
+// identified as it is in SSO configs.
+type ssoUser struct {
+	provider      string
+	email         string
+	emailVerified bool
+	givenName     string
+	familyName    string
+	groups        []string
+}
+
+// ssoRedirect returns where to send a user once they've logged in. Only
+// paths on the controller itself are allowed, so logins can't be used to
+// send users elsewhere.
+func ssoRedirect(r *http.Request) string {
+	redirect := r.URL.Query().Get("redirect")
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return defaultSSORedirect
+	}
+	return redirect
+}
+
+// oidcLogin sends the user to the OpenID Connect provider to log in. The
+// state and nonce the provider must send back are kept in a short-lived
+// cookie, along with where to send the user once they're logged in.
+func (s *Service) oidcLogin(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	redirect := ssoRedirect(r)
+
+	state := ksuid.New().String()
+	nonce := ksuid.New().String()
+
+	authCodeURL, err := s.oidc.AuthCodeURL(r.Context(), state, nonce)
+	if err != nil {
+		log.WithError(err).Error("get oidc auth code url")
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    strings.Join([]string{state, nonce, base64.RawURLEncoding.EncodeToString([]byte(redirect))}, "."),
+		Path:     oidcStateCookiePath,
+		MaxAge:   ssoStateMaxAge,
+		Secure:   s.oidcSecure(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authCodeURL, http.StatusFound)
+}
+
+// oidcCallback is where the OpenID Connect provider sends users back to. The
+// user is provisioned if they're new, their project memberships are synced
+// with their groups, and they're logged in.
+func (s *Service) oidcCallback(w http.ResponseWriter, r *http.Request) {
+	if s.oidc == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:   oidcStateCookie,
+		Path:   oidcStateCookiePath,
+		MaxAge: -1,
+	})
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 || subtle.ConstantTimeCompare([]byte(parts[0]), []byte(r.URL.Query().Get("state"))) != 1 {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+	nonce := parts[1]
+	redirect, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if providerError := r.URL.Query().Get("error"); providerError != "" {
+		http.Error(w, "single sign-on failed: "+providerError+" "+r.URL.Query().Get("error_description"), http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := s.oidc.Exchange(r.Context(), r.URL.Query().Get("code"), nonce)
+	if err != nil {
+		log.WithError(err).Warn("exchange oidc code")
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	s.loginSSOUser(w, r, ssoUser{
+		provider:      s.oidc.Issuer(),
+		email:         claims.Email,
+		emailVerified: claims.EmailVerified,
+		givenName:     claims.GivenName,
+		familyName:    claims.FamilyName,
+		groups:        claims.Groups,
+	}, s.oidcSecure(), string(redirect))
+}
+
+// oidcSecure returns whether users are sent back from the provider over
+// https, so cookies set for them should be secure.
+func (s *Service) oidcSecure() bool {
+	u, err := url.Parse(s.oidc.RedirectURL())
+	return err == nil && u.Scheme == "https"
+}
+
+// samlMetadata serves the controller's SAML service provider metadata, for
+// registering it with the identity provider.
+func (s *Service) samlMetadata(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	metadata, err := s.saml.Metadata()
+	if err != nil {
+		log.WithError(err).Error("get saml metadata")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
+	w.Write(metadata)
+}
+
+// samlLogin sends the user to the SAML identity provider to log in. The ID
+// of the request, which the response must be for, is kept in a short-lived
+// cookie along with where to send the user once they're logged in.
+func (s *Service) samlLogin(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	redirect := ssoRedirect(r)
+
+	authnRequestURL, requestID, err := s.saml.AuthnRequestURL(r.Context())
+	if err != nil {
+		log.WithError(err).Error("get saml authn request url")
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     samlStateCookie,
+		Value:    strings.Join([]string{requestID, base64.RawURLEncoding.EncodeToString([]byte(redirect))}, "."),
+		Path:     samlStateCookiePath,
+		MaxAge:   ssoStateMaxAge,
+		Secure:   s.saml.Secure(),
+		HttpOnly: true,
+		SameSite: s.samlSameSite(),
+	})
+	http.Redirect(w, r, authnRequestURL, http.StatusFound)
+}
+
+// samlACS is the assertion consumer service the identity provider posts
+// its response to. The response has to be signed by the identity provider
+// and be for the request in the user's cookie. The user is then
+// provisioned, synced and logged in as they are with OpenID Connect.
+func (s *Service) samlACS(w http.ResponseWriter, r *http.Request) {
+	if s.saml == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	cookie, err := r.Cookie(samlStateCookie)
+	if err != nil {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     samlStateCookie,
+		Path:     samlStateCookiePath,
+		MaxAge:   -1,
+		Secure:   s.saml.Secure(),
+		SameSite: s.samlSameSite(),
+	})
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+	requestID := parts[0]
+	redirect, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		http.Error(w, errInvalidSSOState.Error(), http.StatusBadRequest)
+		return
+	}
+
+	claims, err := s.saml.ParseResponse(r, requestID)
+	if err != nil {
+		log.WithError(err).Warn("parse saml response")
+		http.Error(w, saml.ErrInvalidResponse.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// SAML has no notion of verified addresses. Identity providers assert
+	// the addresses of the accounts they manage, so they're trusted as
+	// verified, and the allowed domains still apply.
+	s.loginSSOUser(w, r, ssoUser{
+		provider:      claims.Issuer,
+		email:         claims.Email,
+		emailVerified: true,
+		givenName:     claims.GivenName,
+		familyName:    claims.FamilyName,
+		groups:        claims.Groups,
+	}, s.saml.Secure(), string(redirect))
+}
+
+// samlSameSite returns the SameSite mode of the SAML state cookie. The
+// response is posted from the identity provider's site, and browsers only
+// send cookies with cross-site posts if they're SameSite=None, which has
+// to be secure. Otherwise the browser's default is left to apply.
+func (s *Service) samlSameSite() http.SameSite {
+	if s.saml.Secure() {
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteDefaultMode
+}
+
+// loginSSOUser provisions a user who has logged in with single sign-on,
+// syncs their project memberships with their groups, and logs them in.
+func (s *Service) loginSSOUser(w http.ResponseWriter, r *http.Request, u ssoUser, secure bool, redirect string) {
+	user, err := s.provisionSSOUser(r.Context(), u)
+	if err == errEmailNotVerified || err == errEmailDomainNotAllowed {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		log.WithError(err).Error("provision sso user")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.syncSSOMemberships(r.Context(), user.ID, u.provider, u.groups); err != nil {
+		log.WithField("user_id", user.ID).
+			WithError(err).Error("sync sso memberships")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err := s.setSessionCookie(w, r, user.ID, secure); err != nil {
+		log.WithError(err).Error("create session")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+// provisionSSOUser returns the user with a provider's verified email
+// address, creating them if they're new. They're given a random password,
+// so they can only log in with single sign-on until they reset it.
+func (s *Service) provisionSSOUser(ctx context.Context, u ssoUser) (*models.User, error) {
+	if u.email == "" || !u.emailVerified {
+		return nil, errEmailNotVerified
+	}
+	if err := s.checkEmailDomain(u.email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.LookupUser(ctx, u.email)
+	if err == store.ErrUserNotFound {
+		user, err = s.users.CreateUser(ctx, u.email, hash.Hash(ksuid.New().String()),
+			u.givenName, u.familyName, "")
+		if err != nil {
+			return nil, errors.Wrap(err, "create user")
+		}
+	} else if err != nil {
+		return nil, errors.Wrap(err, "lookup user")
+	}
+
+	// The provider has verified their email address
+	if !user.RegistrationCompleted {
+		if user, err = s.users.MarkRegistrationCompleted(ctx, user.ID); err != nil {
+			return nil, errors.Wrap(err, "mark registration completed")
+		}
+	}
+
+	return user, nil
+}
+
+// syncSSOMemberships applies the SSO configs for a provider to a user who
+// logged in with it.
+func (s *Service) syncSSOMemberships(ctx context.Context, userID, provider string, groups []string) error {
+	configs, err := s.ssoConfigs.ListSSOConfigsByProvider(ctx, provider)
+	if err != nil {
+		return errors.Wrap(err, "list sso configs")
+	}
+
+	inGroup := make(map[string]bool)
+	for _, group := range groups {
+		inGroup[group] = true
+	}
+
+	for projectID, config := range configs {
+		if len(config.GroupRoles) == 0 {
+			continue
+		}
+		if err := s.syncSSOMembership(ctx, userID, projectID, config, inGroup); err != nil {
+			return errors.Wrapf(err, "project %s", projectID)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) syncSSOMembership(ctx context.Context, userID, projectID string, config models.SSOConfig, inGroup map[string]bool) error {
+	// Mapped roles are bound if any of the user's groups map to them, and
+	// unbound otherwise
+	mapped := make(map[string]bool)
+	for _, groupRoles := range config.GroupRoles {
+		for _, roleName := range groupRoles.Roles {
+			role, err := s.roles.LookupRole(ctx, roleName, projectID)
+			if err == store.ErrRoleNotFound {
+				continue
+			} else if err != nil {
+				return errors.Wrap(err, "lookup role")
+			}
+			mapped[role.ID] = mapped[role.ID] || inGroup[groupRoles.Group]
+		}
+	}
+
+	member := true
+	if _, err := s.memberships.GetMembership(ctx, userID, projectID); err == store.ErrMembershipNotFound {
+		member = false
+	} else if err != nil {
+		return errors.Wrap(err, "get membership")
+	}
+
+	if !member {
+		wanted := false
+		for _, bind := range mapped {
+			wanted = wanted || bind
+		}
+		if !wanted {
+			return nil
+		}
+		if _, err := s.memberships.CreateMembership(ctx, userID, projectID); err != nil {
+			return errors.Wrap(err, "create membership")
+		}
+	}
+
+	roleBindings, err := s.membershipRoleBindings.ListMembershipRoleBindings(ctx, userID, projectID)
+	if err != nil {
+		return errors.Wrap(err, "list membership role bindings")
+	}
+	bound := make(map[string]bool)
+	for _, roleBinding := range roleBindings {
+		bound[roleBinding.RoleID] = true
+	}
+
+	for roleID, bind := range mapped {
+		if bind && !bound[roleID] {
+			if _, err := s.membershipRoleBindings.CreateMembershipRoleBinding(ctx, userID, roleID, projectID); err != nil {
+				return errors.Wrap(err, "create membership role binding")
+			}
+		} else if !bind && bound[roleID] {
+			if err := s.membershipRoleBindings.DeleteMembershipRoleBinding(ctx, userID, roleID, projectID); err != nil {
+				return errors.Wrap(err, "delete membership role binding")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestSyncSSOMemberships(t *testing.T) {
+	s := newTestService()
+	s.roles = &fakeRoles{
+		roles: map[string]models.Role{
+			"rol_1": {ID: "rol_1", ProjectID: "prj_1", Name: "developer"},
+			"rol_2": {ID: "rol_2", ProjectID: "prj_1", Name: "oncall"},
+			"rol_3": {ID: "rol_3", ProjectID: "prj_2", Name: "developer"},
+		},
+	}
+	memberships := &fakeMemberships{}
+	s.memberships = memberships
+	membershipRoleBindings := &fakeMembershipRoleBindings{
+		membershipRoleBindings: []models.MembershipRoleBinding{
+			{UserID: "usr_1", RoleID: "rol_2", ProjectID: "prj_1"},
+		},
+	}
+	s.membershipRoleBindings = membershipRoleBindings
+	// Both projects map the same group, but only prj_1 trusts the provider
+	// the user logged in with
+	s.ssoConfigs = &fakeSSOConfigs{
+		ssoConfigs: map[string]models.SSOConfig{
+			"prj_1": {
+				Provider: "https://idp.example.com",
+				GroupRoles: []models.SSOGroupRoles{
+					{Group: "engineering", Roles: []string{"developer"}},
+					{Group: "oncall", Roles: []string{"oncall"}},
+				},
+			},
+			"prj_2": {
+				Provider: "https://attacker.example.com",
+				GroupRoles: []models.SSOGroupRoles{
+					{Group: "engineering", Roles: []string{"developer"}},
+				},
+			},
+		},
+	}
+
+	require.NoError(t, s.syncSSOMemberships(context.Background(), "usr_1", "https://idp.example.com", []string{"engineering"}))
+	require.Equal(t, []models.Membership{
+		{UserID: "usr_1", ProjectID: "prj_1"},
+	}, memberships.memberships)
+	require.Equal(t, []models.MembershipRoleBinding{
+		{UserID: "usr_1", RoleID: "rol_1", ProjectID: "prj_1"},
+	}, membershipRoleBindings.membershipRoleBindings)
+
+	require.NoError(t, s.syncSSOMemberships(context.Background(), "usr_2", "https://other.example.com", []string{"engineering"}))
+	require.Len(t, memberships.memberships, 1)
+}
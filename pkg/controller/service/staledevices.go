@@ -0,0 +1,34 @@
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/apex/log"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/staledevices"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// getStaleDeviceCleanupReport lists the devices the project's stale device
+// cleanup policy would remove if it ran now. The report is made even while
+// the policy is disabled, so it can be checked before being enabled.
+func (s *Service) getStaleDeviceCleanupReport(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	config, err := s.staleDeviceCleanupConfigs.GetStaleDeviceCleanupConfig(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get stale device cleanup config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	devices, err := s.devices.ListDevices(r.Context(), projectID, "")
+	if err != nil {
+		log.WithError(err).Error("list devices")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	utils.Respond(w, staledevices.Report(*config, devices, time.Now()))
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/deviceplane/deviceplane/pkg/agent/service/client"
+	"github.com/deviceplane/deviceplane/pkg/codes"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultTerminalWidth  = 80
+	defaultTerminalHeight = 24
+	terminalTerm          = "xterm-256color"
+)
+
+func (s *Service) initiateTerminal(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	deviceID string,
+) {
+	s.serveTerminal(w, r, projectID, deviceID, "", "", authenticatedUserID, authenticatedServiceAccountID)
+}
+
+func (s *Service) initiateServiceTerminal(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID,
+	applicationID, deviceID string,
+) {
+	vars := mux.Vars(r)
+	service := vars["service"]
+
+	s.serveTerminal(w, r, projectID, deviceID, applicationID, service, authenticatedUserID, authenticatedServiceAccountID)
+}
+
+// serveTerminal bridges a browser terminal to a shell on the device, or in
+// the service's container if applicationID is set. Since the browser has no
+// SSH client, the controller acts as one. Binary WebSocket messages carry
+// terminal data in both directions, while text messages from the browser
+// are models.TerminalMessage.
+func (s *Service) serveTerminal(w http.ResponseWriter, r *http.Request,
+	projectID, deviceID, applicationID, service,
+	authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	width, height := terminalSize(r)
+
+	// The controller can't prove ownership of any of the authorized keys
+	sshConfig, err := s.sshConfigs.GetSSHConfig(r.Context(), projectID)
+	if err != nil {
+		log.WithError(err).Error("get ssh config")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if sshConfig.EnforceSSHKeys {
+		http.Error(w, "the web terminal is unavailable while SSH keys are enforced", http.StatusForbidden)
+		return
+	}
+
+	kind := models.SessionRecordingKindSSH
+	if applicationID != "" {
+		kind = models.SessionRecordingKindExec
+	}
+
+	sessionRecordingID, err := s.createSessionRecording(r.Context(), projectID, deviceID,
+		kind, applicationID, service, authenticatedUserID, authenticatedServiceAccountID)
+	if err != nil {
+		log.WithError(err).Error("create session recording")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	s.withDeviceConnection(w, r, projectID, deviceID, func(deviceConn net.Conn) {
+		var err error
+		if applicationID == "" {
+			err = client.InitiateSSH(r.Context(), deviceConn, sessionRecordingID)
+		} else {
+			err = client.InitiateExec(r.Context(), deviceConn, applicationID, service, sessionRecordingID)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		sshConn, chans, reqs, err := ssh.NewClientConn(deviceConn, deviceID, &ssh.ClientConfig{
+			User: "deviceplane",
+			// The device was already authenticated when it connected
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+		sshClient := ssh.NewClient(sshConn, chans, reqs)
+		defer sshClient.Close()
+
+		session, err := sshClient.NewSession()
+		if err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+		defer session.Close()
+
+		if err := session.RequestPty(terminalTerm, height, width, ssh.TerminalModes{}); err != nil {
+			http.Error(w, err.Error(), codes.StatusDeviceConnectionFailure)
+			return
+		}
+
+		stdin, err := session.StdinPipe()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		conn, err := s.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+
+		output := &terminalWriter{conn: conn}
+		session.Stdout = output
+		session.Stderr = output
+
+		if err := session.Shell(); err != nil {
+			output.close(err.Error())
+			return
+		}
+
+		go func() {
+			defer stdin.Close()
+			for {
+				messageType, data, err := conn.ReadMessage()
+				if err != nil {
+					session.Close()
+					return
+				}
+
+				switch messageType {
+				case websocket.BinaryMessage:
+					if _, err := stdin.Write(data); err != nil {
+						return
+					}
+				case websocket.TextMessage:
+					var message models.TerminalMessage
+					if err := json.Unmarshal(data, &message); err != nil {
+						continue
+					}
+					if message.Type == models.TerminalMessageResize && message.Width > 0 && message.Height > 0 {
+						session.WindowChange(message.Height, message.Width)
+					}
+				}
+			}
+		}()
+
+		if err := session.Wait(); err != nil {
+			if exitError, ok := err.(*ssh.ExitError); ok {
+				output.close("exit status " + strconv.Itoa(exitError.ExitStatus()))
+				return
+			}
+		}
+		output.close("")
+	})
+}
+
+func terminalSize(r *http.Request) (int, int) {
+	width, err := strconv.Atoi(r.URL.Query().Get("width"))
+	if err != nil || width <= 0 {
+		width = defaultTerminalWidth
+	}
+	height, err := strconv.Atoi(r.URL.Query().Get("height"))
+	if err != nil || height <= 0 {
+		height = defaultTerminalHeight
+	}
+	return width, height
+}
+
+// terminalWriter serializes writes to the WebSocket, since stdout and
+// stderr are copied concurrently.
+type terminalWriter struct {
+	conn *websocket.Conn
+	lock sync.Mutex
+}
+
+func (t *terminalWriter) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *terminalWriter) close(reason string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"net/http"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/devicegroups"
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/versions"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+)
+
+// getDeviceVersionsReport counts the project's devices, narrowed down by the
+// same filters and selectors as listDevices, by the agent version and
+// releases they report running.
+func (s *Service) getDeviceVersionsReport(w http.ResponseWriter, r *http.Request,
+	projectID, authenticatedUserID, authenticatedServiceAccountID string,
+) {
+	filters, err := filtersFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	devices, err := s.devices.ListDevicesMatching(r.Context(), projectID, "", filters)
+	if err != nil {
+		log.WithError(err).Error("list devices matching")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := devicegroups.Load(r.Context(), s.deviceGroups, projectID, devices); err != nil {
+		log.WithError(err).Error("load device groups")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(filters) != 0 {
+		devices, _, err = query.QueryDevices(devices, filters)
+		if err != nil {
+			http.Error(w, errors.Wrap(err, "filter devices").Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	utils.Respond(w, versions.Report(devices))
+}
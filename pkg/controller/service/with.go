@@ -140,6 +140,29 @@ func (s *Service) withApplicationAndRelease(handler func(http.ResponseWriter, *h
 	}
 }
 
+func (s *Service) withApplicationAndRollout(handler func(http.ResponseWriter, *http.Request, string, string, string, *models.Rollout)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return s.withApplication(func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID, applicationID string) {
+		vars := mux.Vars(r)
+		rolloutID := vars["rollout"]
+		if rolloutID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		rollout, err := s.rollouts.GetRollout(r.Context(), rolloutID, projectID, applicationID)
+		if err == store.ErrRolloutNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("get rollout")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, rollout)
+	})
+}
+
 func (s *Service) withDevice(handler func(http.ResponseWriter, *http.Request, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
 	return func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
 		vars := mux.Vars(r)
@@ -248,3 +271,127 @@ func (s *Service) withDeviceRegistrationToken(handler func(http.ResponseWriter,
 		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, tokenID)
 	}
 }
+
+func (s *Service) withEnvironmentFile(handler func(http.ResponseWriter, *http.Request, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
+		vars := mux.Vars(r)
+		environmentFile := vars["environmentfile"]
+		if environmentFile == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var environmentFileID string
+		if strings.Contains(environmentFile, "_") {
+			environmentFileID = environmentFile
+		} else {
+			environmentFile, err := s.environmentFiles.LookupEnvironmentFile(r.Context(), environmentFile, projectID)
+			if err == store.ErrEnvironmentFileNotFound {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.WithError(err).Error("lookup environment file")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			environmentFileID = environmentFile.ID
+		}
+
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, environmentFileID)
+	}
+}
+
+func (s *Service) withConfigFile(handler func(http.ResponseWriter, *http.Request, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
+		vars := mux.Vars(r)
+		configFile := vars["configfile"]
+		if configFile == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var configFileID string
+		if strings.Contains(configFile, "_") {
+			configFileID = configFile
+		} else {
+			configFile, err := s.configFiles.LookupConfigFile(r.Context(), configFile, projectID)
+			if err == store.ErrConfigFileNotFound {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.WithError(err).Error("lookup config file")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			configFileID = configFile.ID
+		}
+
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, configFileID)
+	}
+}
+
+func (s *Service) withDeviceGroup(handler func(http.ResponseWriter, *http.Request, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
+		vars := mux.Vars(r)
+		deviceGroup := vars["devicegroup"]
+		if deviceGroup == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var deviceGroupID string
+		if strings.Contains(deviceGroup, "_") {
+			deviceGroupID = deviceGroup
+		} else {
+			deviceGroup, err := s.deviceGroups.LookupDeviceGroup(r.Context(), deviceGroup, projectID)
+			if err == store.ErrDeviceGroupNotFound {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.WithError(err).Error("lookup device group")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			deviceGroupID = deviceGroup.ID
+		}
+
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, deviceGroupID)
+	}
+}
+
+func (s *Service) withDeviceView(handler func(http.ResponseWriter, *http.Request, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID string) {
+		vars := mux.Vars(r)
+		deviceView := vars["deviceview"]
+		if deviceView == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var deviceViewID string
+		if strings.Contains(deviceView, "_") {
+			deviceViewID = deviceView
+		} else {
+			deviceView, err := s.deviceViews.LookupDeviceView(r.Context(), deviceView, projectID)
+			if err == store.ErrDeviceViewNotFound {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil {
+				log.WithError(err).Error("lookup device view")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			deviceViewID = deviceView.ID
+		}
+
+		handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, deviceViewID)
+	}
+}
+
+func (s *Service) withDeviceGroupAndDevice(handler func(http.ResponseWriter, *http.Request, string, string, string, string, string)) func(http.ResponseWriter, *http.Request, string, string, string) {
+	return s.withDeviceGroup(func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID, deviceGroupID string) {
+		s.withDevice(func(w http.ResponseWriter, r *http.Request, projectID, authenticatedUserID, authenticatedServiceAccountID, deviceID string) {
+			handler(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID, deviceGroupID, deviceID)
+		})(w, r, projectID, authenticatedUserID, authenticatedServiceAccountID)
+	})
+}
@@ -0,0 +1,90 @@
+package staledevices
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// MaxAfterDays is about ten years, past which cleanup would never run
+const MaxAfterDays = 3650
+
+var (
+	ErrInvalidAction       = errors.New("stale device cleanup action must be archive or delete")
+	ErrInvalidAfterDays    = errors.New("stale devices must be unseen for between 1 and 3650 days")
+	ErrInvalidExcludeLabel = errors.New("exclusion labels must be a key or key=value")
+)
+
+// Report returns the devices a config would clean up at a time, and how
+// many stale devices its exclusion labels keep.
+func Report(config models.StaleDeviceCleanupConfig, devices []models.Device, now time.Time) models.StaleDeviceCleanupReport {
+	report := models.StaleDeviceCleanupReport{
+		Config:  config,
+		Devices: make([]models.Device, 0),
+	}
+
+	if config.AfterDays <= 0 {
+		return report
+	}
+
+	cutoff := now.AddDate(0, 0, -config.AfterDays)
+	for _, device := range devices {
+		if !device.LastSeenAt.Before(cutoff) {
+			continue
+		}
+		if Excluded(config, device) {
+			report.ExcludedCount++
+			continue
+		}
+		report.Devices = append(report.Devices, device)
+	}
+
+	return report
+}
+
+// Excluded returns whether a device has any of a config's exclusion labels.
+func Excluded(config models.StaleDeviceCleanupConfig, device models.Device) bool {
+	for _, excludeLabel := range config.ExcludeLabels {
+		key, value, hasValue := parseExcludeLabel(excludeLabel)
+		deviceValue, ok := device.Labels[key]
+		if !ok {
+			continue
+		}
+		if !hasValue || deviceValue == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseExcludeLabel(excludeLabel string) (string, string, bool) {
+	parts := strings.SplitN(excludeLabel, "=", 2)
+	if len(parts) == 1 {
+		return parts[0], "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// Validate checks a stale device cleanup config. Disabled configs are still
+// checked so their reports make sense.
+func Validate(config models.StaleDeviceCleanupConfig) error {
+	switch config.Action {
+	case models.StaleDeviceCleanupActionArchive, models.StaleDeviceCleanupActionDelete:
+	default:
+		return ErrInvalidAction
+	}
+
+	if config.AfterDays < 1 || config.AfterDays > MaxAfterDays {
+		return ErrInvalidAfterDays
+	}
+
+	for _, excludeLabel := range config.ExcludeLabels {
+		if key, _, _ := parseExcludeLabel(excludeLabel); strings.TrimSpace(key) == "" {
+			return ErrInvalidExcludeLabel
+		}
+	}
+
+	return nil
+}
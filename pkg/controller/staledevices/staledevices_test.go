@@ -0,0 +1,57 @@
+package staledevices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestReport(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	devices := []models.Device{
+		{ID: "dev_1", LastSeenAt: now.AddDate(0, 0, -40)},
+		{ID: "dev_2", LastSeenAt: now.AddDate(0, 0, -10)},
+		{ID: "dev_3", LastSeenAt: now.AddDate(0, 0, -40), Labels: map[string]string{"keep": "true"}},
+		{ID: "dev_4", LastSeenAt: now.AddDate(0, 0, -40), Labels: map[string]string{"site": "lab"}},
+		{ID: "dev_5", LastSeenAt: now.AddDate(0, 0, -40), Labels: map[string]string{"site": "field"}},
+	}
+
+	config := models.StaleDeviceCleanupConfig{
+		Action:        models.StaleDeviceCleanupActionArchive,
+		AfterDays:     30,
+		ExcludeLabels: []string{"keep", "site=lab"},
+	}
+	report := Report(config, devices, now)
+	require.Equal(t, config, report.Config)
+	require.Len(t, report.Devices, 2)
+	require.Equal(t, "dev_1", report.Devices[0].ID)
+	require.Equal(t, "dev_5", report.Devices[1].ID)
+	require.Equal(t, 2, report.ExcludedCount)
+
+	report = Report(models.StaleDeviceCleanupConfig{}, devices, now)
+	require.NotNil(t, report.Devices)
+	require.Empty(t, report.Devices)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(models.StaleDeviceCleanupConfig{
+		Action:        models.StaleDeviceCleanupActionDelete,
+		AfterDays:     90,
+		ExcludeLabels: []string{"keep", "site=lab"},
+	}))
+	require.Equal(t, ErrInvalidAction, Validate(models.StaleDeviceCleanupConfig{
+		Action:    "shred",
+		AfterDays: 90,
+	}))
+	require.Equal(t, ErrInvalidAfterDays, Validate(models.StaleDeviceCleanupConfig{
+		Action: models.StaleDeviceCleanupActionArchive,
+	}))
+	require.Equal(t, ErrInvalidExcludeLabel, Validate(models.StaleDeviceCleanupConfig{
+		Action:        models.StaleDeviceCleanupActionArchive,
+		AfterDays:     90,
+		ExcludeLabels: []string{"=lab"},
+	}))
+}
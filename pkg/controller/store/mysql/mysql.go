@@ -0,0 +1,13 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlstore"
+)
+
+// NewStore returns a store backed by a MySQL database with schema.sql
+// applied to it.
+func NewStore(db *sql.DB) *sqlstore.Store {
+	return sqlstore.NewStore(db, sqlstore.MySQL)
+}
@@ -110,6 +110,25 @@ create table if not exists user_access_keys (
   index hash (hash)
 );
 
+--
+-- SSHKeys
+--
+
+create table if not exists ssh_keys (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  user_id varchar(32) not null,
+
+  name varchar(100) not null,
+  public_key longtext not null,
+
+  primary key (id),
+  foreign key ssh_keys_user_id(user_id)
+  references users(id)
+  on delete cascade,
+  index user_id_id (user_id, id)
+);
+
 --
 -- Projects
 --
@@ -225,6 +244,8 @@ create table if not exists service_account_access_keys (
   -- SENSITIVE FIELD
   hash varchar(255) not null,
   description longtext not null,
+  config longtext not null,
+  expires_at timestamp null default null,
 
   primary key (id),
   unique hash_unique (hash),
@@ -274,7 +295,11 @@ create table if not exists device_registration_tokens (
   name varchar(100) not null,
   description longtext not null,
   max_registrations int,
+  expires_at timestamp null default null,
   labels longtext not null,
+  name_prefix varchar(100) not null default '',
+  environment longtext not null,
+  require_approval boolean not null default false,
 
   primary key (id),
   unique name_project_id_unique (name, project_id),
@@ -296,8 +321,13 @@ create table if not exists devices (
   desired_agent_spec longtext not null,
   desired_agent_version varchar(100) not null,
   info longtext not null,
+  agent_version varchar(100) not null default '',
   last_seen_at timestamp not null default current_timestamp,
   labels longtext not null,
+  pending_approval boolean not null default false,
+  decommissioning boolean not null default false,
+  decommissioned_at timestamp null default null,
+  transfer_project_id varchar(32) not null default '',
 
   primary key (id),
   unique name_project_id_unique (name, project_id),
@@ -310,9 +340,30 @@ create table if not exists devices (
   index project_id_id (project_id, id),
   index project_id_name (project_id, name),
   index project_id_registration_token_id (project_id, registration_token_id),
+  index project_id_agent_version (project_id, agent_version),
   fulltext(name, labels)
 );
 
+--
+-- DeviceEnvironments
+--
+
+create table if not exists device_environments (
+  device_id varchar(32) not null,
+  project_id varchar(32) not null,
+
+  environment longtext not null,
+
+  primary key (device_id),
+  foreign key device_environments_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key device_environments_device_id(device_id)
+  references devices(id)
+  on delete cascade,
+  index project_id_device_id (project_id, device_id)
+);
+
 --
 -- DeviceAccessKeys
 --
@@ -371,11 +422,16 @@ create table if not exists releases (
 
   config longtext not null,
   raw_config longtext not null,
+  source_release_id varchar(32) not null default '',
+  notes longtext not null,
+  source varchar(1000) not null default '',
   created_by_user_id varchar(32),
   created_by_service_account_id varchar(32),
+  idempotency_key varchar(255),
 
   primary key (id),
   unique application_release_count (`number`, application_id, project_id),
+  unique application_id_idempotency_key (application_id, idempotency_key),
   foreign key releases_application_id(application_id)
   references applications(id)
   on delete cascade,
@@ -390,6 +446,162 @@ create table if not exists releases (
   index project_id_application_id_created_at (project_id, application_id, created_at)
 );
 
+--
+-- EnvironmentFiles
+--
+
+create table if not exists environment_files (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description longtext not null,
+  content longtext not null,
+
+  primary key (id),
+  unique name_project_id_unique (name, project_id),
+  foreign key environment_files_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_id (project_id, id),
+  index project_id_name (project_id, name)
+);
+
+--
+-- ConfigFiles
+--
+
+create table if not exists config_files (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description longtext not null,
+  path varchar(4096) not null,
+  content longtext not null,
+  mode int unsigned not null,
+  owner varchar(100) not null,
+  restart_services longtext not null,
+
+  primary key (id),
+  unique name_project_id_unique (name, project_id),
+  foreign key config_files_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_id (project_id, id),
+  index project_id_name (project_id, name)
+);
+
+--
+-- DeviceGroups
+--
+
+create table if not exists device_groups (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description longtext not null,
+  query longtext not null,
+
+  primary key (id),
+  unique name_project_id_unique (name, project_id),
+  foreign key device_groups_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_id (project_id, id),
+  index project_id_name (project_id, name)
+);
+
+--
+-- DeviceGroupMembers
+--
+
+create table if not exists device_group_members (
+  project_id varchar(32) not null,
+  device_group_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  primary key (device_group_id, device_id),
+  foreign key device_group_members_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key device_group_members_device_group_id(device_group_id)
+  references device_groups(id)
+  on delete cascade,
+  foreign key device_group_members_device_id(device_id)
+  references devices(id)
+  on delete cascade,
+  index project_id (project_id),
+  index device_id (device_id)
+);
+
+--
+-- DeviceViews
+--
+
+create table if not exists device_views (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description longtext not null,
+  query longtext not null,
+  columns longtext not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+
+  primary key (id),
+  unique name_project_id_unique (name, project_id),
+  foreign key device_views_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key device_views_created_by_user_id(created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key device_views_created_by_service_account_id(created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null,
+  index project_id_id (project_id, id),
+  index project_id_name (project_id, name)
+);
+
+--
+-- SessionRecordings
+--
+
+create table if not exists session_recordings (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  kind varchar(32) not null,
+  application_id varchar(32) not null,
+  service varchar(100) not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+  finished_at timestamp null,
+  content longblob,
+
+  primary key (id),
+  foreign key session_recordings_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key session_recordings_created_by_user_id(created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key session_recordings_created_by_service_account_id(created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null,
+  index project_id_id (project_id, id),
+  index project_id_created_at (project_id, created_at)
+);
+
 --
 -- DeviceApplicationStatuses
 --
@@ -415,6 +627,34 @@ create table if not exists device_application_statuses (
   index project_id_application_id_current_release_id (project_id, application_id, current_release_id)
 );
 
+--
+-- DeviceConnections
+--
+
+create table if not exists device_connections (
+  connection_key varchar(100) not null,
+  updated_at timestamp not null default current_timestamp on update current_timestamp,
+  controller varchar(255) not null,
+
+  primary key (connection_key)
+);
+
+--
+-- RemoteSessions
+--
+
+create table if not exists remote_sessions (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+  updated_at timestamp not null default current_timestamp,
+
+  primary key (id),
+  foreign key remote_sessions_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_updated_at (project_id, updated_at)
+);
+
 --
 -- DeviceServiceStatuses
 --
@@ -440,6 +680,194 @@ create table if not exists device_service_statuses (
   index project_id_device_id_application_id (project_id, device_id, application_id)
 );
 
+--
+-- DeviceReleasePins
+--
+
+create table if not exists device_release_pins (
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  release_id varchar(32) not null,
+
+  primary key (device_id, application_id),
+  foreign key device_release_pins_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key device_release_pins_device_id(device_id)
+  references devices(id)
+  on delete cascade,
+  foreign key device_release_pins_application_id(application_id)
+  references applications(id)
+  on delete cascade,
+  foreign key device_release_pins_release_id(release_id)
+  references releases(id)
+  on delete cascade,
+  index project_id_application_id (project_id, application_id),
+  index project_id_device_id (project_id, device_id)
+);
+
+--
+-- ApplicationGitSyncs
+--
+
+create table if not exists application_git_syncs (
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  repository varchar(1000) not null,
+  branch varchar(255) not null,
+  path varchar(1000) not null,
+  last_commit varchar(64) not null default '',
+  last_synced_at timestamp null default null,
+  last_error longtext not null,
+
+  primary key (application_id),
+  foreign key application_git_syncs_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key application_git_syncs_application_id(application_id)
+  references applications(id)
+  on delete cascade,
+  index project_id_application_id (project_id, application_id)
+);
+
+--
+-- AuditLogEntries
+--
+
+create table if not exists audit_log_entries (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  user_id varchar(32) not null,
+  service_account_id varchar(32) not null,
+  action varchar(100) not null,
+  method varchar(10) not null,
+  path varchar(2000) not null,
+  summary longtext not null,
+  remote_addr varchar(100) not null,
+  status_code int not null,
+  duration_ms bigint not null,
+
+  primary key (id),
+  foreign key audit_log_entries_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_id (project_id, id),
+  index project_id_created_at (project_id, created_at)
+);
+
+--
+-- DeviceConnectivityEvents
+--
+
+create table if not exists device_connectivity_events (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  status varchar(20) not null,
+  at timestamp not null,
+
+  primary key (id),
+  foreign key device_connectivity_events_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key device_connectivity_events_device_id(device_id)
+  references devices(id)
+  on delete cascade,
+  index project_id_device_id_at (project_id, device_id, at),
+  index at (at)
+);
+
+--
+-- Alerts
+--
+
+create table if not exists alerts (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+
+  rule varchar(100) not null,
+  device_id varchar(32) not null,
+  summary longtext not null,
+  started_at timestamp not null default current_timestamp,
+  resolved_at timestamp null default null,
+
+  primary key (id),
+  foreign key alerts_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index project_id_resolved_at (project_id, resolved_at),
+  index resolved_at (resolved_at)
+);
+
+--
+-- AlertSilences
+--
+
+create table if not exists alert_silences (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  rule varchar(100) not null,
+  device_id varchar(32) not null,
+  until timestamp not null default current_timestamp,
+  reason longtext not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+
+  primary key (id),
+  foreign key alert_silences_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key alert_silences_created_by_user_id(created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key alert_silences_created_by_service_account_id(created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null,
+  index project_id_until (project_id, until),
+  index until (until)
+);
+
+--
+-- Rollouts
+--
+
+create table if not exists rollouts (
+  id varchar(32) not null,
+  created_at timestamp not null default current_timestamp,
+  project_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  release_id varchar(32) not null,
+  previous_release_id varchar(32) not null,
+  steps longtext not null,
+  soak_period int not null,
+  max_failure_percentage int not null,
+  step int not null default 0,
+  step_started_at timestamp not null default current_timestamp,
+  status varchar(100) not null,
+  status_reason longtext not null,
+
+  primary key (id),
+  foreign key rollouts_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key rollouts_application_id(application_id)
+  references applications(id)
+  on delete cascade,
+  index project_id_application_id_created_at (project_id, application_id, created_at),
+  index status (status)
+);
+
 --
 -- Project Configs
 --
@@ -456,6 +884,23 @@ create table if not exists project_configs (
   on delete cascade
 );
 
+--
+-- SSO Configs
+--
+
+create table if not exists sso_configs (
+  project_id varchar(32) not null,
+  provider varchar(255) not null,
+
+  config longtext not null,
+
+  primary key (project_id),
+  foreign key sso_configs_project_id(project_id)
+  references projects(id)
+  on delete cascade,
+  index provider (provider)
+);
+
 --
 -- Commit
 --
@@ -0,0 +1,907 @@
+package postgres
+
+// migrations are applied in order, each once. They aren't changed once
+// they're released, so changes to mysql/schema.sql need a new migration
+// here too.
+var migrations = []string{
+	// mysql/schema.sql, with its indexes created separately
+	`
+--
+-- Users
+--
+
+create table if not exists users (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+
+  email varchar(255) not null,
+  -- SENSITIVE FIELD
+  password_hash varchar(255) not null,
+  first_name varchar(100) not null,
+  last_name varchar(100) not null,
+  company varchar(100) not null,
+  registration_completed boolean not null default false,
+  super_admin boolean not null default false,
+
+  primary key (id)
+);
+create unique index if not exists users_email_unique on users (lower(email));
+create index if not exists users_id_password_hash on users (id, password_hash);
+create index if not exists users_email_password_hash on users (lower(email), password_hash);
+
+--
+-- RegistrationTokens
+--
+
+create table if not exists registration_tokens (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  user_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+
+  primary key (id),
+  unique (user_id),
+  unique (hash),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade
+);
+create index if not exists registration_tokens_hash on registration_tokens (hash);
+
+--
+-- PasswordRecoveryTokens
+--
+
+create table if not exists password_recovery_tokens (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  expires_at timestamptz not null,
+  user_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+
+  primary key (id),
+  unique (hash),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade
+);
+create index if not exists password_recovery_tokens_hash on password_recovery_tokens (hash);
+
+--
+-- Sessions
+--
+
+create table if not exists sessions (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  user_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+
+  primary key (id),
+  unique (hash),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade
+);
+create index if not exists sessions_hash on sessions (hash);
+
+--
+-- UserAccessKeys
+--
+
+create table if not exists user_access_keys (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  user_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+  description text not null,
+
+  primary key (id),
+  unique (hash),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade
+);
+create index if not exists user_access_keys_user_id on user_access_keys (user_id);
+create index if not exists user_access_keys_hash on user_access_keys (hash);
+
+--
+-- SSHKeys
+--
+
+create table if not exists ssh_keys (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  user_id varchar(32) not null,
+
+  name varchar(100) not null,
+  public_key text not null,
+
+  primary key (id),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade
+);
+create index if not exists ssh_keys_user_id_id on ssh_keys (user_id, id);
+
+--
+-- Projects
+--
+
+create table if not exists projects (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+
+  name varchar(100) not null,
+  datadog_api_key varchar(100),
+
+  primary key (id),
+  unique (name)
+);
+create index if not exists projects_name on projects (name);
+
+--
+-- Roles
+--
+
+create table if not exists roles (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  config text not null,
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists roles_project_id_id on roles (project_id, id);
+create index if not exists roles_project_id_name on roles (project_id, name);
+
+--
+-- Memberships
+--
+
+create table if not exists memberships (
+  user_id varchar(32) not null,
+  project_id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+
+  primary key (user_id, project_id),
+  foreign key (user_id)
+  references users(id)
+  on delete cascade,
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists memberships_user_id on memberships (user_id);
+create index if not exists memberships_project_id on memberships (project_id);
+
+--
+-- MembershipRoleBindings
+--
+
+create table if not exists membership_role_bindings (
+  user_id varchar(32) not null,
+  role_id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  primary key (user_id, project_id, role_id),
+  foreign key (user_id, project_id)
+  references memberships(user_id, project_id)
+  on delete cascade,
+  foreign key (role_id)
+  references roles(id)
+  on delete cascade,
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists membership_role_bindings_project_id_user_id_role_id on membership_role_bindings (project_id, user_id, role_id);
+
+--
+-- ServiceAccounts
+--
+
+create table if not exists service_accounts (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists service_accounts_project_id_id on service_accounts (project_id, id);
+create index if not exists service_accounts_project_id_name on service_accounts (project_id, name);
+
+--
+-- ServiceAccountAccessKeys
+--
+
+create table if not exists service_account_access_keys (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  service_account_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+  description text not null,
+  config text not null,
+  expires_at timestamptz null default null,
+
+  primary key (id),
+  unique (hash),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (service_account_id)
+  references service_accounts(id)
+  on delete cascade
+);
+create index if not exists service_account_access_keys_project_id_service_account_id_id on service_account_access_keys (project_id, service_account_id, id);
+create index if not exists service_account_access_keys_project_id_id on service_account_access_keys (project_id, id);
+create index if not exists service_account_access_keys_hash on service_account_access_keys (hash);
+
+--
+-- ServiceAccountRoleBindings
+--
+
+create table if not exists service_account_role_bindings (
+  service_account_id varchar(32) not null,
+  role_id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  primary key (service_account_id, role_id),
+  foreign key (service_account_id)
+  references service_accounts(id)
+  on delete cascade,
+  foreign key (role_id)
+  references roles(id)
+  on delete cascade,
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists service_account_role_bindings_project_id_service_account_id_role_id on service_account_role_bindings (project_id, service_account_id, role_id);
+
+--
+-- DeviceRegistrationTokens
+--
+
+create table if not exists device_registration_tokens (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  max_registrations int,
+  expires_at timestamptz null default null,
+  labels text not null,
+  name_prefix varchar(100) not null default '',
+  environment text not null,
+  require_approval boolean not null default false,
+
+  primary key (id),
+  unique (name, project_id)
+);
+create index if not exists device_registration_tokens_project_id_id on device_registration_tokens (project_id, id);
+create index if not exists device_registration_tokens_project_id_name on device_registration_tokens (project_id, name);
+
+--
+-- Devices
+--
+
+create table if not exists devices (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  registration_token_id varchar(32),
+  desired_agent_spec text not null default '',
+  desired_agent_version varchar(100) not null default '',
+  info text not null default '',
+  agent_version varchar(100) not null default '',
+  last_seen_at timestamptz not null default current_timestamp,
+  labels text not null,
+  pending_approval boolean not null default false,
+  decommissioning boolean not null default false,
+  decommissioned_at timestamptz null default null,
+  transfer_project_id varchar(32) not null default '',
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (registration_token_id)
+  references device_registration_tokens(id)
+  on delete set null
+);
+create index if not exists devices_project_id_id on devices (project_id, id);
+create index if not exists devices_project_id_name on devices (project_id, name);
+create index if not exists devices_project_id_registration_token_id on devices (project_id, registration_token_id);
+create index if not exists devices_project_id_agent_version on devices (project_id, agent_version);
+
+--
+-- DeviceEnvironments
+--
+
+create table if not exists device_environments (
+  device_id varchar(32) not null,
+  project_id varchar(32) not null,
+
+  environment text not null,
+
+  primary key (device_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade
+);
+create index if not exists device_environments_project_id_device_id on device_environments (project_id, device_id);
+
+--
+-- DeviceAccessKeys
+--
+
+create table if not exists device_access_keys (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  -- SENSITIVE FIELD
+  hash varchar(255) not null,
+
+  primary key (id),
+  unique (hash),
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade
+);
+create index if not exists device_access_keys_project_id_id on device_access_keys (project_id, id);
+create index if not exists device_access_keys_project_id_hash on device_access_keys (project_id, hash);
+
+--
+-- Applications
+--
+
+create table if not exists applications (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  scheduling_rule text not null default '',
+  metric_endpoint_configs text not null default '',
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists applications_project_id_id on applications (project_id, id);
+create index if not exists applications_project_id_name on applications (project_id, name);
+
+--
+-- Releases
+--
+
+create table if not exists releases (
+  id varchar(32) not null,
+  number bigint not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  config text not null,
+  raw_config text not null,
+  source_release_id varchar(32) not null default '',
+  notes text not null,
+  source varchar(1000) not null default '',
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+  idempotency_key varchar(255),
+
+  primary key (id),
+  unique (number, application_id, project_id),
+  unique (application_id, idempotency_key),
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade,
+  foreign key (created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key (created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null
+);
+create index if not exists releases_project_id_application_id_number on releases (project_id, application_id, number);
+create index if not exists releases_project_id_application_id_id on releases (project_id, application_id, id);
+create index if not exists releases_project_id_application_id_created_at on releases (project_id, application_id, created_at);
+
+--
+-- EnvironmentFiles
+--
+
+create table if not exists environment_files (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  content text not null,
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists environment_files_project_id_id on environment_files (project_id, id);
+create index if not exists environment_files_project_id_name on environment_files (project_id, name);
+
+--
+-- ConfigFiles
+--
+
+create table if not exists config_files (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  path varchar(4096) not null,
+  content text not null,
+  mode bigint not null,
+  owner varchar(100) not null,
+  restart_services text not null,
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists config_files_project_id_id on config_files (project_id, id);
+create index if not exists config_files_project_id_name on config_files (project_id, name);
+
+--
+-- DeviceGroups
+--
+
+create table if not exists device_groups (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  query text not null,
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists device_groups_project_id_id on device_groups (project_id, id);
+create index if not exists device_groups_project_id_name on device_groups (project_id, name);
+
+--
+-- DeviceGroupMembers
+--
+
+create table if not exists device_group_members (
+  project_id varchar(32) not null,
+  device_group_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  primary key (device_group_id, device_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_group_id)
+  references device_groups(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade
+);
+create index if not exists device_group_members_project_id on device_group_members (project_id);
+create index if not exists device_group_members_device_id on device_group_members (device_id);
+
+--
+-- DeviceViews
+--
+
+create table if not exists device_views (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  name varchar(100) not null,
+  description text not null,
+  query text not null,
+  columns text not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+
+  primary key (id),
+  unique (name, project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key (created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null
+);
+create index if not exists device_views_project_id_id on device_views (project_id, id);
+create index if not exists device_views_project_id_name on device_views (project_id, name);
+
+--
+-- SessionRecordings
+--
+
+create table if not exists session_recordings (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  kind varchar(32) not null,
+  application_id varchar(32) not null,
+  service varchar(100) not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+  finished_at timestamptz null,
+  content bytea,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key (created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null
+);
+create index if not exists session_recordings_project_id_id on session_recordings (project_id, id);
+create index if not exists session_recordings_project_id_created_at on session_recordings (project_id, created_at);
+
+--
+-- DeviceApplicationStatuses
+--
+
+create table if not exists device_application_statuses (
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  current_release_id varchar(32) not null,
+
+  primary key (project_id, device_id, application_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade,
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade
+);
+create index if not exists device_application_statuses_project_id_device_id on device_application_statuses (project_id, device_id);
+create index if not exists device_application_statuses_project_id_application_id_current_release_id on device_application_statuses (project_id, application_id, current_release_id);
+
+--
+-- DeviceConnections
+--
+
+create table if not exists device_connections (
+  connection_key varchar(100) not null,
+  updated_at timestamptz not null default current_timestamp,
+  controller varchar(255) not null,
+
+  primary key (connection_key)
+);
+
+--
+-- DeviceServiceStatuses
+--
+
+create table if not exists device_service_statuses (
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+  application_id varchar(32) not null,
+  service varchar(100) not null,
+
+  current_release_id varchar(32) not null,
+
+  primary key (project_id, device_id, application_id, service),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade,
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade
+);
+create index if not exists device_service_statuses_project_id_device_id_application_id on device_service_statuses (project_id, device_id, application_id);
+
+--
+-- DeviceReleasePins
+--
+
+create table if not exists device_release_pins (
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  release_id varchar(32) not null,
+
+  primary key (device_id, application_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade,
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade,
+  foreign key (release_id)
+  references releases(id)
+  on delete cascade
+);
+create index if not exists device_release_pins_project_id_application_id on device_release_pins (project_id, application_id);
+create index if not exists device_release_pins_project_id_device_id on device_release_pins (project_id, device_id);
+
+--
+-- ApplicationGitSyncs
+--
+
+create table if not exists application_git_syncs (
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  repository varchar(1000) not null,
+  branch varchar(255) not null,
+  path varchar(1000) not null,
+  last_commit varchar(64) not null default '',
+  last_synced_at timestamptz null default null,
+  last_error text not null,
+
+  primary key (application_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade
+);
+create index if not exists application_git_syncs_project_id_application_id on application_git_syncs (project_id, application_id);
+
+--
+-- AuditLogEntries
+--
+
+create table if not exists audit_log_entries (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  user_id varchar(32) not null,
+  service_account_id varchar(32) not null,
+  action varchar(100) not null,
+  method varchar(10) not null,
+  path varchar(2000) not null,
+  summary text not null,
+  remote_addr varchar(100) not null,
+  status_code int not null,
+  duration_ms bigint not null,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists audit_log_entries_project_id_id on audit_log_entries (project_id, id);
+create index if not exists audit_log_entries_project_id_created_at on audit_log_entries (project_id, created_at);
+
+--
+-- DeviceConnectivityEvents
+--
+
+create table if not exists device_connectivity_events (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+  device_id varchar(32) not null,
+
+  status varchar(20) not null,
+  at timestamptz not null,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (device_id)
+  references devices(id)
+  on delete cascade
+);
+create index if not exists device_connectivity_events_project_id_device_id_at on device_connectivity_events (project_id, device_id, at);
+create index if not exists device_connectivity_events_at on device_connectivity_events (at);
+
+--
+-- Alerts
+--
+
+create table if not exists alerts (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+
+  rule varchar(100) not null,
+  device_id varchar(32) not null,
+  summary text not null,
+  started_at timestamptz not null default current_timestamp,
+  resolved_at timestamptz null default null,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists alerts_project_id_resolved_at on alerts (project_id, resolved_at);
+create index if not exists alerts_resolved_at on alerts (resolved_at);
+
+--
+-- AlertSilences
+--
+
+create table if not exists alert_silences (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+
+  rule varchar(100) not null,
+  device_id varchar(32) not null,
+  until timestamptz not null default current_timestamp,
+  reason text not null,
+  created_by_user_id varchar(32),
+  created_by_service_account_id varchar(32),
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (created_by_user_id)
+  references users(id)
+  on delete set null,
+  foreign key (created_by_service_account_id)
+  references service_accounts(id)
+  on delete set null
+);
+create index if not exists alert_silences_project_id_until on alert_silences (project_id, until);
+create index if not exists alert_silences_until on alert_silences (until);
+
+--
+-- Rollouts
+--
+
+create table if not exists rollouts (
+  id varchar(32) not null,
+  created_at timestamptz not null default current_timestamp,
+  project_id varchar(32) not null,
+  application_id varchar(32) not null,
+
+  release_id varchar(32) not null,
+  previous_release_id varchar(32) not null,
+  steps text not null,
+  soak_period int not null,
+  max_failure_percentage int not null,
+  step int not null default 0,
+  step_started_at timestamptz not null default current_timestamp,
+  status varchar(100) not null,
+  status_reason text not null,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade,
+  foreign key (application_id)
+  references applications(id)
+  on delete cascade
+);
+create index if not exists rollouts_project_id_application_id_created_at on rollouts (project_id, application_id, created_at);
+create index if not exists rollouts_status on rollouts (status);
+
+--
+-- Project Configs
+--
+
+create table if not exists project_configs (
+  project_id varchar(32) not null,
+  k varchar(100) not null,
+
+  v text not null,
+
+  primary key (project_id, k),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+
+--
+-- SSO Configs
+--
+
+create table if not exists sso_configs (
+  project_id varchar(32) not null,
+  provider varchar(255) not null,
+
+  config text not null,
+
+  primary key (project_id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists sso_configs_provider on sso_configs (provider);
+`,
+	`
+--
+-- RemoteSessions
+--
+
+create table if not exists remote_sessions (
+  id varchar(32) not null,
+  project_id varchar(32) not null,
+  updated_at timestamptz not null default current_timestamp,
+
+  primary key (id),
+  foreign key (project_id)
+  references projects(id)
+  on delete cascade
+);
+create index if not exists remote_sessions_project_id_updated_at on remote_sessions (project_id, updated_at);
+`,
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlstore"
+)
+
+// NewStore returns a store backed by a PostgreSQL database, which Migrate
+// has brought up to date.
+func NewStore(db *sql.DB) *sqlstore.Store {
+	return sqlstore.NewStore(db, sqlstore.Postgres)
+}
+
+// Migrate applies the migrations that haven't been applied to db yet.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return sqlstore.Migrate(ctx, db, sqlstore.Postgres, migrations)
+}
@@ -0,0 +1,35 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlstore"
+	_ "modernc.org/sqlite"
+)
+
+// Open opens the SQLite database at path, creating it if it doesn't exist.
+// Foreign keys are enforced, and writers wait for each other rather than
+// failing.
+func Open(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", "file:"+path+"?"+url.Values{
+		"_pragma": []string{
+			"foreign_keys(1)",
+			"busy_timeout(10000)",
+			"journal_mode(wal)",
+		},
+		"_txlock": []string{"immediate"},
+	}.Encode())
+}
+
+// NewStore returns a store backed by a SQLite database, which Migrate has
+// brought up to date.
+func NewStore(db *sql.DB) *sqlstore.Store {
+	return sqlstore.NewStore(db, sqlstore.SQLite)
+}
+
+// Migrate applies the migrations that haven't been applied to db yet.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	return sqlstore.Migrate(ctx, db, sqlstore.SQLite, migrations)
+}
@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the flavor of SQL a database speaks. The store's queries are
+// written for MySQL, and other dialects rewrite them before they're run.
+type Dialect struct {
+	// queries are the queries that are written differently as a whole,
+	// keyed by how they're written for MySQL. Their parameters have to be
+	// in the same order.
+	queries map[string]string
+	// replacer rewrites the parts of queries that are written differently,
+	// which are in queries that are built up, such as searchDevices
+	replacer *strings.Replacer
+	// deleteLimit is whether deletes can be limited to a number of rows
+	deleteLimit bool
+	// numberedParameters is whether parameters are written $1, $2 and so
+	// on, rather than ?
+	numberedParameters bool
+	// identifierQuote is what quotes identifiers, rather than backticks
+	identifierQuote string
+	// timeFormat is the format times are passed in, in UTC, for databases
+	// that store them as text and compare them as strings
+	timeFormat string
+	// localLocks is whether locks are only held within this process, for
+	// databases that only one controller uses
+	localLocks bool
+}
+
+// MySQL is the dialect the store's queries are written in.
+var MySQL = &Dialect{
+	deleteLimit: true,
+}
+
+// withQueries returns the queries in each of queries.
+func withQueries(queries ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, q := range queries {
+		for query, rewritten := range q {
+			merged[query] = rewritten
+		}
+	}
+	return merged
+}
+
+var deleteLimitRegexp = regexp.MustCompile(`\s+limit 1\s*$`)
+
+func (d *Dialect) rewrite(query string) string {
+	if d == MySQL {
+		return query
+	}
+
+	if rewritten, ok := d.queries[query]; ok {
+		query = rewritten
+	}
+	if d.replacer != nil {
+		query = d.replacer.Replace(query)
+	}
+	if !d.deleteLimit && strings.HasPrefix(strings.TrimSpace(query), "delete") {
+		query = deleteLimitRegexp.ReplaceAllString(query, "\n")
+	}
+	if !d.numberedParameters && d.identifierQuote == "" {
+		return query
+	}
+
+	// Parameters and backticks inside of string literals are left alone
+	var b strings.Builder
+	inString := false
+	parameter := 0
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case inString:
+		case r == '?' && d.numberedParameters:
+			parameter++
+			b.WriteString("$" + strconv.Itoa(parameter))
+			continue
+		case r == '`' && d.identifierQuote != "":
+			b.WriteString(d.identifierQuote)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d *Dialect) convertArgs(args []interface{}) []interface{} {
+	if d.timeFormat == "" {
+		return args
+	}
+
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch t := arg.(type) {
+		case time.Time:
+			converted[i] = t.UTC().Format(d.timeFormat)
+		case *time.Time:
+			if t != nil {
+				converted[i] = t.UTC().Format(d.timeFormat)
+			}
+		default:
+			converted[i] = arg
+		}
+	}
+	return converted
+}
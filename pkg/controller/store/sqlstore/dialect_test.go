@@ -0,0 +1,110 @@
+package sqlstore
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// queries returns the queries in sql.go, by name.
+func queries(t *testing.T) map[string]string {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "sql.go", nil, 0)
+	require.NoError(t, err)
+	pkg, err := (&types.Config{}).Check("sqlstore", fset, []*ast.File{file}, nil)
+	require.NoError(t, err)
+
+	queries := make(map[string]string)
+	for _, name := range pkg.Scope().Names() {
+		c, ok := pkg.Scope().Lookup(name).(*types.Const)
+		if ok && c.Val().Kind() == constant.String {
+			queries[name] = constant.StringVal(c.Val())
+		}
+	}
+	require.NotEmpty(t, queries)
+	return queries
+}
+
+var numberedParameterRegexp = regexp.MustCompile(`\$(\d+)`)
+
+func TestRewritePostgres(t *testing.T) {
+	for name, query := range queries(t) {
+		rewritten := Postgres.rewrite(query)
+
+		for _, mysqlism := range []string{
+			"?", "`", "replace into", "insert ignore", "on duplicate key", "match (",
+			"get_lock", "release_lock", "interval 1 hour",
+		} {
+			require.NotContains(t, rewritten, mysqlism, name)
+		}
+		if strings.HasPrefix(strings.TrimSpace(rewritten), "delete") {
+			require.NotContains(t, rewritten, "limit", name)
+		}
+
+		// Queries that are rewritten as a whole have to take the same
+		// parameters
+		parameters := numberedParameterRegexp.FindAllStringSubmatch(rewritten, -1)
+		require.Len(t, parameters, strings.Count(query, "?"), name)
+		for i, parameter := range parameters {
+			require.Equal(t, strconv.Itoa(i+1), parameter[1], name)
+		}
+	}
+}
+
+func TestRewriteSQLite(t *testing.T) {
+	for name, query := range queries(t) {
+		// Locks are held by the controller
+		if name == "tryLock" || name == "releaseLock" {
+			continue
+		}
+		rewritten := SQLite.rewrite(query)
+
+		for _, mysqlism := range []string{
+			"replace into", "insert ignore", "on duplicate key", "match (", "now()",
+		} {
+			require.NotContains(t, rewritten, mysqlism, name)
+		}
+		if strings.HasPrefix(strings.TrimSpace(rewritten), "delete") {
+			require.NotContains(t, rewritten, "limit", name)
+		}
+
+		require.Equal(t, strings.Count(query, "?"), strings.Count(rewritten, "?"), name)
+	}
+}
+
+func TestConvertArgs(t *testing.T) {
+	at := time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", 3600))
+	args := []interface{}{"id", at, &at, (*time.Time)(nil)}
+
+	require.Equal(t, args, Postgres.convertArgs(args))
+	require.Equal(t, []interface{}{"id", "2020-01-02 02:04:05.6", "2020-01-02 02:04:05.6", nil}, SQLite.convertArgs(args))
+}
+
+func TestRewrite(t *testing.T) {
+	require.Equal(t, getDevice, MySQL.rewrite(getDevice))
+
+	require.Equal(t,
+		"select \"number\" from releases where notes = '?`' and id = $1 and application_id = $2",
+		Postgres.rewrite("select `number` from releases where notes = '?`' and id = ? and application_id = ?"),
+	)
+	require.Equal(t,
+		"\n  delete from roles\n  where id = $1 and project_id = $2\n",
+		Postgres.rewrite(deleteRole),
+	)
+	require.Equal(t,
+		strings.NewReplacer(
+			"project_id = ?", "project_id = $1",
+			"match (name, labels) against (concat('*', ?, '*') in boolean mode)", "strpos(lower(name || ' ' || labels), lower($2)) > 0",
+		).Replace(searchDevices)+"  and name = $3\n",
+		Postgres.rewrite(searchDevices+"  and name = ?\n"),
+	)
+}
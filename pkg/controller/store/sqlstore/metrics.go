@@ -0,0 +1,120 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/metrics"
+)
+
+// timedDB rewrites the store's queries for its database's dialect, and
+// records how long they take. Queries are timed until they return, which
+// for QueryContext is before their rows are read.
+type timedDB struct {
+	*sql.DB
+	dialect *Dialect
+}
+
+func (db timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return db.DB.ExecContext(ctx, db.dialect.rewrite(query), db.dialect.convertArgs(args)...)
+}
+
+func (db timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer observeQuery(query, time.Now())
+	return db.DB.QueryContext(ctx, db.dialect.rewrite(query), db.dialect.convertArgs(args)...)
+}
+
+func (db timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return db.DB.QueryRowContext(ctx, db.dialect.rewrite(query), db.dialect.convertArgs(args)...)
+}
+
+func (db timedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (timedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return timedTx{}, err
+	}
+	return timedTx{
+		Tx:      tx,
+		dialect: db.dialect,
+	}, nil
+}
+
+func (db timedDB) Conn(ctx context.Context) (timedConn, error) {
+	conn, err := db.DB.Conn(ctx)
+	if err != nil {
+		return timedConn{}, err
+	}
+	return timedConn{
+		Conn:    conn,
+		dialect: db.dialect,
+	}, nil
+}
+
+type timedTx struct {
+	*sql.Tx
+	dialect *Dialect
+}
+
+func (tx timedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return tx.Tx.ExecContext(ctx, tx.dialect.rewrite(query), tx.dialect.convertArgs(args)...)
+}
+
+func (tx timedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return tx.Tx.QueryRowContext(ctx, tx.dialect.rewrite(query), tx.dialect.convertArgs(args)...)
+}
+
+type timedConn struct {
+	*sql.Conn
+	dialect *Dialect
+}
+
+func (conn timedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer observeQuery(query, time.Now())
+	return conn.Conn.ExecContext(ctx, conn.dialect.rewrite(query), conn.dialect.convertArgs(args)...)
+}
+
+func (conn timedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer observeQuery(query, time.Now())
+	return conn.Conn.QueryRowContext(ctx, conn.dialect.rewrite(query), conn.dialect.convertArgs(args)...)
+}
+
+func observeQuery(query string, startedAt time.Time) {
+	operation, table := queryLabels(query)
+	metrics.StoreQueryDuration.WithLabelValues(operation, table).Observe(time.Since(startedAt).Seconds())
+}
+
+// queryLabels returns a query's operation, such as select, and the first
+// table it names, which keeps the number of series down to about one per
+// query.
+func queryLabels(query string) (string, string) {
+	fields := strings.Fields(strings.ToLower(query))
+	if len(fields) == 0 {
+		return "unknown", "unknown"
+	}
+
+	operation := fields[0]
+	tableAfter := ""
+	switch operation {
+	case "select", "delete":
+		tableAfter = "from"
+	case "insert", "replace":
+		tableAfter = "into"
+	case "update":
+		tableAfter = "update"
+	default:
+		return operation, "unknown"
+	}
+
+	for i, field := range fields[:len(fields)-1] {
+		if field == tableAfter {
+			return operation, strings.Trim(fields[i+1], "`(")
+		}
+	}
+	return operation, "unknown"
+}
@@ -0,0 +1,27 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLabels(t *testing.T) {
+	for _, test := range []struct {
+		query     string
+		operation string
+		table     string
+	}{
+		{getDevice, "select", "devices"},
+		{listDeviceReleases, "select", "device_application_statuses"},
+		{setDeviceInfo, "update", "devices"},
+		{deleteDevice, "delete", "devices"},
+		{"insert into `devices` (id) values (?)", "insert", "devices"},
+		{tryLock, "select", "unknown"},
+		{"", "unknown", "unknown"},
+	} {
+		operation, table := queryLabels(test.query)
+		require.Equal(t, test.operation, operation, test.query)
+		require.Equal(t, test.table, table, test.query)
+	}
+}
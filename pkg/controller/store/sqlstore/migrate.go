@@ -0,0 +1,85 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+)
+
+const createSchemaMigrations = `
+  create table if not exists schema_migrations (
+    version integer not null,
+    applied_at timestamp not null default current_timestamp,
+
+    primary key (version)
+  )
+`
+
+// Controllers that start at the same time take turns migrating
+const lockSchemaMigrations = `
+  lock table schema_migrations in exclusive mode
+`
+
+const getSchemaMigration = `
+  select count(*) from schema_migrations
+  where version = ?
+`
+
+const createSchemaMigration = `
+  insert into schema_migrations (version)
+  values (?)
+`
+
+// Migrate applies the migrations that haven't been applied to db yet, in
+// order. Each is applied in a transaction, so a migration that fails
+// leaves the schema as it was before it. Migrations are written in the
+// database's dialect, and aren't rewritten.
+func Migrate(ctx context.Context, db *sql.DB, dialect *Dialect, migrations []string) error {
+	tdb := timedDB{
+		DB:      db,
+		dialect: dialect,
+	}
+
+	if _, err := tdb.ExecContext(ctx, createSchemaMigrations); err != nil {
+		return errors.Wrap(err, "create schema_migrations")
+	}
+
+	for i, migration := range migrations {
+		version := i + 1
+		if err := migrate(ctx, tdb, version, migration); err != nil {
+			return errors.Wrapf(err, "migration %d", version)
+		}
+	}
+
+	return nil
+}
+
+func migrate(ctx context.Context, db timedDB, version int, migration string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockSchemaMigrations); err != nil {
+		return err
+	}
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, getSchemaMigration, version).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if _, err := tx.Tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, createSchemaMigration, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,1613 @@
+package sqlstore
+
+const createUser = `
+  insert into users (
+    id,
+    email,
+    password_hash,
+    first_name,
+    last_name,
+    company
+  )
+  values (?, ?, ?, ?, ?, ?)
+`
+
+// Index: primary key
+const getUser = `
+  select id, created_at, email, first_name, last_name, company, registration_completed, super_admin from users
+  where id = ?
+`
+
+// Index: email
+const lookupUser = `
+  select id, created_at, email, first_name, last_name, company, registration_completed, super_admin from users
+  where email = ?
+`
+
+// Index: id_password_hash
+const validateUser = `
+  select id, created_at, email, first_name, last_name, company, registration_completed, super_admin from users
+  where id = ? and password_hash = ?
+`
+
+// Index: email_password_hash
+const validateUserWithEmail = `
+  select id, created_at, email, first_name, last_name, company, registration_completed, super_admin from users
+  where email = ? and password_hash = ?
+`
+
+// Index: primary key
+const markRegistrationComplete = `
+  update users
+  set registration_completed = true
+  where id = ?
+`
+
+// Index: primary key
+const updatePasswordHash = `
+  update users
+  set password_hash = ?
+  where id = ?
+`
+
+// Index: primary key
+const updateFirstName = `
+  update users
+  set first_name = ?
+  where id = ?
+`
+
+// Index: primary key
+const updateLastName = `
+  update users
+  set last_name = ?
+  where id = ?
+`
+
+// Index: primary key
+const updateCompany = `
+  update users
+  set company = ?
+  where id = ?
+`
+
+const createRegistrationToken = `
+  insert into registration_tokens (
+    id,
+    user_id,
+    hash
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary key
+const getRegistrationToken = `
+  select id, created_at, user_id from registration_tokens
+  where id = ?
+`
+
+// Index: hash
+const validateRegistrationToken = `
+  select id, created_at, user_id from registration_tokens
+  where hash = ?
+`
+
+const createPasswordRecoveryToken = `
+  insert into password_recovery_tokens (
+    id,
+    expires_at,
+    user_id,
+    hash
+  )
+  values (?, now() + interval 1 hour, ?, ?)
+`
+
+// Index: primary key
+const getPasswordRecoveryToken = `
+  select id, created_at, expires_at, user_id from password_recovery_tokens
+  where id = ?
+`
+
+// Index: hash
+const validatePasswordRecoveryToken = `
+  select id, created_at, expires_at, user_id from password_recovery_tokens
+  where hash = ?
+`
+
+const createSession = `
+  insert into sessions (
+    id,
+    user_id,
+    hash
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary key
+const getSession = `
+  select id, created_at, user_id from sessions
+  where id = ?
+`
+
+// Index: hash
+const validateSession = `
+  select id, created_at, user_id from sessions
+  where hash = ?
+`
+
+// Index: primary key
+const deleteSession = `
+  delete from sessions
+  where id = ?
+  limit 1
+`
+
+const createUserAccessKey = `
+  insert into user_access_keys (
+    id,
+    user_id,
+    hash,
+    description
+  )
+  values (?, ?, ?, ?)
+`
+
+// Index: primary key
+const getUserAccessKey = `
+  select id, created_at, user_id, description from user_access_keys
+  where id = ?
+`
+
+// Index: hash
+const validateUserAccessKey = `
+  select id, created_at, user_id, description from user_access_keys
+  where hash = ?
+`
+
+// Index: user_id
+const listUserAccessKeys = `
+  select id, created_at, user_id, description from user_access_keys
+  where user_id = ?
+`
+
+// Index: primary key
+const deleteUserAccessKey = `
+  delete from user_access_keys
+  where id = ?
+  limit 1
+`
+
+const createSSHKey = `
+  insert into ssh_keys (
+    id,
+    user_id,
+    name,
+    public_key
+  )
+  values (?, ?, ?, ?)
+`
+
+// Index: user_id_id
+const getSSHKey = `
+  select id, created_at, user_id, name, public_key from ssh_keys
+  where id = ? and user_id = ?
+`
+
+// Index: user_id_id
+const listSSHKeys = `
+  select id, created_at, user_id, name, public_key from ssh_keys
+  where user_id = ?
+`
+
+// Index: user_id_id
+const deleteSSHKey = `
+  delete from ssh_keys
+  where id = ? and user_id = ?
+  limit 1
+`
+
+const createProject = `
+  insert into projects (
+    id,
+    name
+  )
+  values (?, ?)
+`
+
+// Index: primary key
+const getProject = `
+  select id, created_at, name, datadog_api_key from projects
+  where id = ?
+`
+
+// Index: name
+const lookupProject = `
+  select id, created_at, name, datadog_api_key from projects
+  where name = ?
+`
+
+const listProjects = `
+  select id, created_at, name, datadog_api_key from projects
+`
+
+// Index: primary key
+const updateProject = `
+  update projects
+  set name = ?, datadog_api_key = ?
+  where id = ?
+`
+
+// Index: primary key
+const deleteProject = `
+  delete from projects
+  where id = ?
+  limit 1
+`
+
+// Index: project_id
+const getProjectDeviceCounts = `
+  select count(*) from devices
+  where project_id = ? and decommissioned_at is null
+`
+
+// Index: project_id
+const getProjectApplicationCounts = `
+  select count(*) from applications
+  where project_id = ?
+`
+
+const createRole = `
+  insert into roles (
+    id,
+    project_id,
+    name,
+    description,
+    config
+  )
+  values (?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getRole = `
+  select id, created_at, project_id, name, description, config from roles
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupRole = `
+  select id, created_at, project_id, name, description, config from roles
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listRoles = `
+  select id, created_at, project_id, name, description, config from roles
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateRole = `
+  update roles
+  set name = ?, description = ?, config = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteRole = `
+  delete from roles
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createMembership = `
+  insert into memberships (
+    user_id,
+    project_id
+  )
+  values (?, ?)
+`
+
+// Index: primary key
+const getMembership = `
+  select user_id, project_id, created_at from memberships
+  where user_id = ? and project_id = ?
+`
+
+// Index: user_id
+const listMembershipsByUser = `
+  select user_id, project_id, created_at from memberships
+  where user_id = ?
+`
+
+// Index: project_id
+const listMembershipsByProject = `
+  select user_id, project_id, created_at from memberships
+  where project_id = ?
+`
+
+// Index: primary key
+const deleteMembership = `
+  delete from memberships
+  where user_id = ? and project_id = ?
+  limit 1
+`
+
+const createMembershipRoleBinding = `
+  insert into membership_role_bindings (
+    user_id,
+    role_id,
+    project_id
+  )
+  values (?, ?, ?)
+`
+
+// Index: project_id_user_id_role_id
+const getMembershipRoleBinding = `
+  select user_id, role_id, created_at, project_id from membership_role_bindings
+  where user_id = ? and role_id = ? and project_id = ?
+`
+
+// Index: project_id_user_id_role_id
+const listMembershipRoleBindings = `
+  select user_id, role_id, created_at, project_id from membership_role_bindings
+  where user_id = ? and project_id = ?
+`
+
+// Index: project_id_user_id_role_id
+const deleteMembershipRoleBinding = `
+  delete from membership_role_bindings
+  where user_id = ? and role_id = ? and project_id = ?
+  limit 1
+`
+
+const createServiceAccount = `
+  insert into service_accounts (
+    id,
+    project_id,
+    name,
+    description
+  )
+  values (?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getServiceAccount = `
+  select id, created_at, project_id, name, description from service_accounts
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupServiceAccount = `
+  select id, created_at, project_id, name, description from service_accounts
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listServiceAccounts = `
+  select id, created_at, project_id, name, description from service_accounts
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateServiceAccount = `
+  update service_accounts
+  set name = ?, description = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteServiceAccount = `
+  delete from service_accounts
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createServiceAccountAccessKey = `
+  insert into service_account_access_keys (
+    id,
+    project_id,
+    service_account_id,
+    hash,
+    description,
+    config,
+    expires_at
+  )
+  values (?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getServiceAccountAccessKey = `
+  select id, created_at, project_id, service_account_id, description, config, expires_at from service_account_access_keys
+  where id = ? and project_id = ?
+`
+
+// Index: hash
+const validateServiceAccountAccessKey = `
+  select id, created_at, project_id, service_account_id, description, config, expires_at from service_account_access_keys
+  where hash = ?
+`
+
+// Index: project_id_service_account_id_id
+const listServiceAccountAccessKeys = `
+  select id, created_at, project_id, service_account_id, description, config, expires_at from service_account_access_keys
+  where project_id = ? and service_account_id = ?
+`
+
+// Index: project_id_id
+const deleteServiceAccountAccessKey = `
+  delete from service_account_access_keys
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createServiceAccountRoleBinding = `
+  insert into service_account_role_bindings (
+    service_account_id,
+    role_id,
+    project_id
+  )
+  values (?, ?, ?)
+`
+
+// Index: project_id_service_account_id_role_id
+const getServiceAccountRoleBinding = `
+  select service_account_id, role_id, created_at, project_id from service_account_role_bindings
+  where service_account_id = ? and role_id = ? and project_id = ?
+`
+
+// Index: project_id_service_account_id_role_id
+const listServiceAccountRoleBindings = `
+  select service_account_id, role_id, created_at, project_id from service_account_role_bindings
+  where service_account_id = ? and project_id = ?
+`
+
+// Index: project_id_service_account_id_role_id
+const deleteServiceAccountRoleBinding = `
+  delete from service_account_role_bindings
+  where service_account_id = ? and role_id = ? and project_id = ?
+  limit 1
+`
+
+const createDevice = `
+  insert into devices (
+    id,
+    project_id,
+    name,
+    registration_token_id,
+    labels,
+    pending_approval
+  )
+  values (?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getDevice = `
+  select id, created_at, project_id, name, registration_token_id, desired_agent_spec, desired_agent_version, info, labels, last_seen_at, pending_approval, decommissioning, decommissioned_at, transfer_project_id from devices
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupDevice = `
+  select id, created_at, project_id, name, registration_token_id, desired_agent_spec, desired_agent_version, info, labels, last_seen_at, pending_approval, decommissioning, decommissioned_at, transfer_project_id from devices
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listDevices = `
+  select id, created_at, project_id, name, registration_token_id, desired_agent_spec, desired_agent_version, info, labels, last_seen_at, pending_approval, decommissioning, decommissioned_at, transfer_project_id from devices
+  where project_id = ? and decommissioned_at is null
+`
+
+// Index: project_id_id
+const listDevicesPage = `
+  select id, created_at, project_id, name, registration_token_id, desired_agent_spec, desired_agent_version, info, labels, last_seen_at, pending_approval, decommissioning, decommissioned_at, transfer_project_id from devices
+  where project_id = ? and decommissioned_at is null
+  and (? = '' or id > ?)
+  order by id
+  limit ?
+`
+
+// Index: project_id_id,fulltext
+const searchDevices = `
+  select id, created_at, project_id, name, registration_token_id, desired_agent_spec, desired_agent_version, info, labels, last_seen_at, pending_approval, decommissioning, decommissioned_at, transfer_project_id from devices
+  where project_id = ? and decommissioned_at is null
+  and match (name, labels) against (concat('*', ?, '*') in boolean mode)
+`
+
+// Index: project_id_id
+const updateDeviceName = `
+  update devices
+  set name = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceDesiredAgent = `
+  update devices
+  set desired_agent_version = ?, desired_agent_spec = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const approveDevice = `
+  update devices
+  set pending_approval = false
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const decommissionDevice = `
+  update devices
+  set decommissioning = true
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const archiveDevice = `
+  update devices
+  set decommissioned_at = current_timestamp
+  where id = ? and project_id = ? and decommissioned_at is null
+`
+
+// Index: project_id_id
+const transferDevice = `
+  update devices
+  set transfer_project_id = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const moveDevice = `
+  update devices
+  set project_id = ?, transfer_project_id = '', registration_token_id = null
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_device_id
+const moveDeviceEnvironment = `
+  update device_environments
+  set project_id = ?
+  where device_id = ? and project_id = ?
+`
+
+// Index: device_access_keys_device_id
+const moveDeviceAccessKeys = `
+  update device_access_keys
+  set project_id = ?
+  where device_id = ? and project_id = ?
+`
+
+// Index: session_recordings_project_id
+const moveDeviceSessionRecordings = `
+  update session_recordings
+  set project_id = ?
+  where device_id = ? and project_id = ?
+`
+
+// Index: project_id_device_id
+const deleteDeviceApplicationStatusesForDevice = `
+  delete from device_application_statuses
+  where device_id = ? and project_id = ?
+`
+
+// Index: project_id_device_id_application_id
+const deleteDeviceServiceStatusesForDevice = `
+  delete from device_service_statuses
+  where device_id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceLabels = `
+  update devices
+  set labels = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_device_id
+const getDeviceEnvironment = `
+  select environment from device_environments
+  where device_id = ? and project_id = ?
+`
+
+const setDeviceEnvironment = `
+  replace into device_environments (
+    device_id,
+    project_id,
+    environment
+  )
+  values (?, ?, ?)
+`
+
+const listAllDeviceLabels = `
+  select labels from devices
+  where project_id = ?
+`
+
+// Index: project_id_id
+const setDeviceInfo = `
+  update devices
+  set info = ?, agent_version = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceLastSeenAt = `
+  update devices
+  set last_seen_at = current_timestamp
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteDevice = `
+  delete from devices
+  where id = ? and project_id = ?
+`
+
+const createDeviceRegistrationToken = `
+  insert into device_registration_tokens (
+    id,
+    project_id,
+    name,
+    description,
+    max_registrations,
+    expires_at,
+    labels,
+    name_prefix,
+    environment,
+    require_approval
+  )
+  values (?, ?, ?, ?, ?, ?, '{}', ?, '{}', ?)
+`
+
+// Index: project_id_id
+const getDeviceRegistrationToken = `
+  select id, created_at, project_id, max_registrations, expires_at, name, description, labels, name_prefix, environment, require_approval from device_registration_tokens
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupDeviceRegistrationToken = `
+  select id, created_at, project_id, max_registrations, expires_at, name, description, labels, name_prefix, environment, require_approval from device_registration_tokens
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listDeviceRegistrationTokens = `
+  select id, created_at, project_id, max_registrations, expires_at, name, description, labels, name_prefix, environment, require_approval from device_registration_tokens
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceRegistrationToken = `
+  update device_registration_tokens
+  set name = ?, description = ?, max_registrations = ?, expires_at = ?, name_prefix = ?, require_approval = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceRegistrationTokenLabels = `
+  update device_registration_tokens
+  set labels = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceRegistrationTokenEnvironment = `
+  update device_registration_tokens
+  set environment = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteDeviceRegistrationToken = `
+  delete from device_registration_tokens
+  where id = ? and project_id = ?
+  limit 1
+`
+
+// Index: project_id_registration_token_id
+const getDevicesRegisteredWithTokenCount = `
+  select count(*) from devices
+  where registration_token_id = ? and project_id = ?
+`
+
+// Index: device_access_keys_device_id
+const deleteDeviceAccessKeys = `
+  delete from device_access_keys
+  where device_id = ? and project_id = ?
+`
+
+const createDeviceAccessKey = `
+  insert into device_access_keys (
+    id,
+    project_id,
+    device_id,
+    hash
+  )
+  values (?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getDeviceAccessKey = `
+  select id, created_at, project_id, device_id from device_access_keys
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_hash
+const validateDeviceAccessKey = `
+  select id, created_at, project_id, device_id from device_access_keys
+  where project_id = ? and hash = ?
+`
+
+const createApplication = `
+  insert into applications (
+    id,
+    project_id,
+    name,
+    description
+  )
+  values (?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getApplication = `
+  select id, created_at, project_id, name, description, scheduling_rule, metric_endpoint_configs from applications
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupApplication = `
+  select id, created_at, project_id, name, description, scheduling_rule, metric_endpoint_configs from applications
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listApplications = `
+  select id, created_at, project_id, name, description, scheduling_rule, metric_endpoint_configs from applications
+  where project_id = ?
+`
+
+// Index: project_id_id
+const listApplicationsPage = `
+  select id, created_at, project_id, name, description, scheduling_rule, metric_endpoint_configs from applications
+  where project_id = ?
+  and (? = '' or id > ?)
+  order by id
+  limit ?
+`
+
+// Index: project_id_id
+const updateApplicationName = `
+  update applications
+  set name = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateApplicationDescription = `
+  update applications
+  set description = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateApplicationSchedulingRule = `
+  update applications
+  set scheduling_rule = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const updateApplicationMetricEndpointConfigs = `
+  update applications
+  set metric_endpoint_configs = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteApplication = `
+  delete from applications
+  where id = ? and project_id = ?
+  limit 1
+`
+
+// Index: project_id_application_id_current_release_id
+const getApplicationDeviceCounts = `
+  select count(*) from device_application_statuses
+  where project_id = ? and application_id = ?
+`
+
+const createRelease = `
+  insert into releases (
+    id,
+    ` + "`number`" + `,
+    project_id,
+    application_id,
+    config,
+    raw_config,
+    source_release_id,
+    notes,
+    source,
+    created_by_user_id,
+    created_by_service_account_id,
+    idempotency_key
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_application_id_id
+const getRelease = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where id = ? and project_id = ? and application_id = ?
+`
+
+// Index: project_id_application_id_number
+const getReleaseByNumber = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where ` + "`number`" + ` = ? and project_id = ? and application_id = ?
+`
+
+// Index: application_id_idempotency_key
+const getReleaseByIdempotencyKey = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where application_id = ? and idempotency_key = ? and project_id = ?
+`
+
+// Index: project_id_application_id_created_at
+const getLatestRelease = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where project_id = ? and application_id = ?
+  order by created_at desc
+  limit 1
+`
+
+// Index: project_id_application_id_created_at
+const listReleases = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where project_id = ? and application_id = ?
+  order by created_at desc
+  limit 10
+`
+
+// Index: project_id_application_id_number
+const listReleasesPage = `
+  select id, ` + "`number`" + `, created_at, project_id, application_id, config, raw_config, source_release_id, notes, source, created_by_user_id, created_by_service_account_id, idempotency_key from releases
+  where project_id = ? and application_id = ?
+  and (? = '' or ` + "`number`" + ` < (select ` + "`number`" + ` from releases where id = ?))
+  order by ` + "`number`" + ` desc
+  limit ?
+`
+
+// Index: project_id_application_id_number
+const getReleaseCount = `
+  select count(*) from releases
+  where project_id = ? and application_id = ?
+`
+
+// Index: project_id_application_id_current_release_id
+const getReleaseDeviceCounts = `
+  select count(*) from device_application_statuses
+  where project_id = ? and application_id = ? and current_release_id = ?
+`
+
+const createEnvironmentFile = `
+  insert into environment_files (
+    id,
+    project_id,
+    name,
+    description,
+    content
+  )
+  values (?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getEnvironmentFile = `
+  select id, created_at, project_id, name, description, content from environment_files
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupEnvironmentFile = `
+  select id, created_at, project_id, name, description, content from environment_files
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listEnvironmentFiles = `
+  select id, created_at, project_id, name, description, content from environment_files
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateEnvironmentFile = `
+  update environment_files
+  set name = ?, description = ?, content = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteEnvironmentFile = `
+  delete from environment_files
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createConfigFile = `
+  insert into config_files (
+    id,
+    project_id,
+    name,
+    description,
+    path,
+    content,
+    mode,
+    owner,
+    restart_services
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getConfigFile = `
+  select id, created_at, project_id, name, description, path, content, mode, owner, restart_services from config_files
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupConfigFile = `
+  select id, created_at, project_id, name, description, path, content, mode, owner, restart_services from config_files
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listConfigFiles = `
+  select id, created_at, project_id, name, description, path, content, mode, owner, restart_services from config_files
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateConfigFile = `
+  update config_files
+  set name = ?, description = ?, path = ?, content = ?, mode = ?, owner = ?, restart_services = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteConfigFile = `
+  delete from config_files
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createDeviceGroup = `
+  insert into device_groups (
+    id,
+    project_id,
+    name,
+    description,
+    query
+  )
+  values (?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getDeviceGroup = `
+  select id, created_at, project_id, name, description, query from device_groups
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupDeviceGroup = `
+  select id, created_at, project_id, name, description, query from device_groups
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_id
+const listDeviceGroups = `
+  select id, created_at, project_id, name, description, query from device_groups
+  where project_id = ?
+`
+
+// Index: project_id_id
+const updateDeviceGroup = `
+  update device_groups
+  set name = ?, description = ?, query = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteDeviceGroup = `
+  delete from device_groups
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const createDeviceView = `
+  insert into device_views (
+    id,
+    project_id,
+    name,
+    description,
+    query,
+    columns,
+    created_by_user_id,
+    created_by_service_account_id
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getDeviceView = `
+  select id, created_at, project_id, name, description, query, columns, created_by_user_id, created_by_service_account_id from device_views
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_name
+const lookupDeviceView = `
+  select id, created_at, project_id, name, description, query, columns, created_by_user_id, created_by_service_account_id from device_views
+  where name = ? and project_id = ?
+`
+
+// Index: project_id_name
+const listDeviceViews = `
+  select id, created_at, project_id, name, description, query, columns, created_by_user_id, created_by_service_account_id from device_views
+  where project_id = ?
+  order by name
+`
+
+// Index: project_id_id
+const updateDeviceView = `
+  update device_views
+  set name = ?, description = ?, query = ?, columns = ?
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const deleteDeviceView = `
+  delete from device_views
+  where id = ? and project_id = ?
+  limit 1
+`
+
+const addDeviceGroupMember = `
+  insert ignore into device_group_members (
+    project_id,
+    device_group_id,
+    device_id
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary
+const removeDeviceGroupMember = `
+  delete from device_group_members
+  where device_group_id = ? and device_id = ? and project_id = ?
+`
+
+// Index: project_id
+const listDeviceGroupMembers = `
+  select project_id, device_group_id, device_id from device_group_members
+  where project_id = ?
+`
+
+// Index: device_id
+const deleteDeviceGroupMembersForDevice = `
+  delete from device_group_members
+  where device_id = ? and project_id = ?
+`
+
+const setDeviceReleasePin = `
+  insert into device_release_pins (
+    project_id,
+    device_id,
+    application_id,
+    release_id
+  )
+  values (?, ?, ?, ?)
+  on duplicate key update release_id = ?, created_at = current_timestamp
+`
+
+// Index: primary
+const getDeviceReleasePin = `
+  select created_at, project_id, device_id, application_id, release_id from device_release_pins
+  where project_id = ? and device_id = ? and application_id = ?
+`
+
+// Index: project_id_application_id
+const listDeviceReleasePins = `
+  select created_at, project_id, device_id, application_id, release_id from device_release_pins
+  where project_id = ? and application_id = ?
+`
+
+// Index: project_id_device_id
+const listDeviceReleasePinsByDevice = `
+  select created_at, project_id, device_id, application_id, release_id from device_release_pins
+  where project_id = ? and device_id = ?
+`
+
+// Index: primary
+const deleteDeviceReleasePin = `
+  delete from device_release_pins
+  where project_id = ? and device_id = ? and application_id = ?
+`
+
+// Index: project_id_device_id
+const deleteDeviceReleasePinsForDevice = `
+  delete from device_release_pins
+  where device_id = ? and project_id = ?
+`
+
+const setApplicationGitSync = `
+  insert into application_git_syncs (
+    project_id,
+    application_id,
+    repository,
+    branch,
+    path,
+    last_error
+  )
+  values (?, ?, ?, ?, ?, '')
+  on duplicate key update repository = ?, branch = ?, path = ?, last_commit = '', last_synced_at = null, last_error = ''
+`
+
+// Index: project_id_application_id
+const getApplicationGitSync = `
+  select created_at, project_id, application_id, repository, branch, path, last_commit, last_synced_at, last_error from application_git_syncs
+  where project_id = ? and application_id = ?
+`
+
+const listAllApplicationGitSyncs = `
+  select created_at, project_id, application_id, repository, branch, path, last_commit, last_synced_at, last_error from application_git_syncs
+`
+
+// Index: project_id_application_id
+const updateApplicationGitSyncStatus = `
+  update application_git_syncs
+  set last_commit = ?, last_synced_at = current_timestamp, last_error = ?
+  where project_id = ? and application_id = ?
+`
+
+// Index: project_id_application_id
+const deleteApplicationGitSync = `
+  delete from application_git_syncs
+  where project_id = ? and application_id = ?
+`
+
+const createAuditLogEntry = `
+  insert into audit_log_entries (
+    id,
+    project_id,
+    user_id,
+    service_account_id,
+    action,
+    method,
+    path,
+    summary,
+    remote_addr,
+    status_code,
+    duration_ms
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const listAuditLogEntries = `
+  select id, created_at, project_id, user_id, service_account_id, action, method, path, summary, remote_addr, status_code, duration_ms from audit_log_entries
+  where project_id = ?
+  and (? = '' or user_id = ?)
+  and (? = '' or service_account_id = ?)
+  and (? = '' or action = ?)
+  and (? = '' or id < ?)
+  and created_at >= ?
+  order by id desc
+  limit ?
+`
+
+// Index: project_id_created_at
+const deleteAuditLogEntriesBefore = `
+  delete from audit_log_entries
+  where project_id = ? and created_at < ?
+`
+
+const createDeviceConnectivityEvent = `
+  insert into device_connectivity_events (
+    id,
+    project_id,
+    device_id,
+    status,
+    at
+  )
+  values (?, ?, ?, ?, ?)
+`
+
+// Index: project_id_device_id_at
+const listDeviceConnectivityEvents = `
+  select id, project_id, device_id, status, at from device_connectivity_events
+  where project_id = ? and device_id = ? and at >= ?
+  order by at, id
+`
+
+// Index: at
+const deleteDeviceConnectivityEventsBefore = `
+  delete from device_connectivity_events
+  where at < ?
+`
+
+const createAlert = `
+  insert into alerts (
+    id,
+    project_id,
+    rule,
+    device_id,
+    summary
+  )
+  values (?, ?, ?, ?, ?)
+`
+
+const getAlert = `
+  select id, project_id, rule, device_id, summary, started_at, resolved_at from alerts
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_resolved_at
+const listFiringAlerts = `
+  select id, project_id, rule, device_id, summary, started_at, resolved_at from alerts
+  where project_id = ? and resolved_at is null
+`
+
+// Index: project_id_resolved_at
+const listAlerts = `
+  select id, project_id, rule, device_id, summary, started_at, resolved_at from alerts
+  where project_id = ? and (resolved_at is null or resolved_at >= ?)
+  order by started_at desc, id desc
+`
+
+const resolveAlert = `
+  update alerts
+  set resolved_at = ?
+  where id = ? and project_id = ? and resolved_at is null
+`
+
+// Index: resolved_at
+const deleteAlertsResolvedBefore = `
+  delete from alerts
+  where resolved_at < ?
+`
+
+const createAlertSilence = `
+  insert into alert_silences (
+    id,
+    project_id,
+    rule,
+    device_id,
+    until,
+    reason,
+    created_by_user_id,
+    created_by_service_account_id
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+const getAlertSilence = `
+  select id, created_at, project_id, rule, device_id, until, reason, created_by_user_id, created_by_service_account_id from alert_silences
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_until
+const listAlertSilences = `
+  select id, created_at, project_id, rule, device_id, until, reason, created_by_user_id, created_by_service_account_id from alert_silences
+  where project_id = ? and until > ?
+  order by until
+`
+
+const deleteAlertSilence = `
+  delete from alert_silences
+  where id = ? and project_id = ?
+  limit 1
+`
+
+// Index: until
+const deleteAlertSilencesBefore = `
+  delete from alert_silences
+  where until < ?
+`
+
+const createRollout = `
+  insert into rollouts (
+    id,
+    project_id,
+    application_id,
+    release_id,
+    previous_release_id,
+    steps,
+    soak_period,
+    max_failure_percentage,
+    status,
+    status_reason
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?, ?, '')
+`
+
+// Index: primary
+const getRollout = `
+  select id, created_at, project_id, application_id, release_id, previous_release_id, steps, soak_period, max_failure_percentage, step, step_started_at, status, status_reason from rollouts
+  where id = ? and project_id = ? and application_id = ?
+`
+
+// Index: primary
+const getRolloutByID = `
+  select id, created_at, project_id, application_id, release_id, previous_release_id, steps, soak_period, max_failure_percentage, step, step_started_at, status, status_reason from rollouts
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_application_id_created_at
+const getLatestRollout = `
+  select id, created_at, project_id, application_id, release_id, previous_release_id, steps, soak_period, max_failure_percentage, step, step_started_at, status, status_reason from rollouts
+  where project_id = ? and application_id = ?
+  order by created_at desc
+  limit 1
+`
+
+// Index: project_id_application_id_created_at
+const listRollouts = `
+  select id, created_at, project_id, application_id, release_id, previous_release_id, steps, soak_period, max_failure_percentage, step, step_started_at, status, status_reason from rollouts
+  where project_id = ? and application_id = ?
+  order by created_at desc
+`
+
+// Index: status
+const listRolloutsByStatus = `
+  select id, created_at, project_id, application_id, release_id, previous_release_id, steps, soak_period, max_failure_percentage, step, step_started_at, status, status_reason from rollouts
+  where status = ?
+`
+
+// Index: primary
+const updateRolloutStep = `
+  update rollouts
+  set step = ?, step_started_at = current_timestamp
+  where id = ? and project_id = ?
+`
+
+// Index: primary
+const updateRolloutStatus = `
+  update rollouts
+  set status = ?, status_reason = ?
+  where id = ? and project_id = ?
+`
+
+const createSessionRecording = `
+  insert into session_recordings (
+    id,
+    project_id,
+    device_id,
+    kind,
+    application_id,
+    service,
+    created_by_user_id,
+    created_by_service_account_id
+  )
+  values (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// Index: project_id_id
+const getSessionRecording = `
+  select id, created_at, project_id, device_id, kind, application_id, service, created_by_user_id, created_by_service_account_id, finished_at, coalesce(length(content), 0) from session_recordings
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_created_at
+const listSessionRecordings = `
+  select id, created_at, project_id, device_id, kind, application_id, service, created_by_user_id, created_by_service_account_id, finished_at, coalesce(length(content), 0) from session_recordings
+  where project_id = ?
+  order by created_at desc
+`
+
+// Index: project_id_id
+const getSessionRecordingContent = `
+  select coalesce(content, '') from session_recordings
+  where id = ? and project_id = ?
+`
+
+// Index: project_id_id
+const finishSessionRecording = `
+  update session_recordings
+  set finished_at = current_timestamp, content = ?
+  where id = ? and project_id = ? and device_id = ? and finished_at is null
+`
+
+// Index: primary key
+const setDeviceApplicationStatus = `
+  insert into device_application_statuses (
+    project_id,
+    device_id,
+    application_id,
+    current_release_id
+  )
+  values (?, ?, ?, ?)
+  on duplicate key update
+    current_release_id = ?
+`
+
+// Index: primary key
+const getDeviceApplicationStatus = `
+  select project_id, device_id, application_id, current_release_id from device_application_statuses
+  where project_id = ? and device_id = ? and application_id = ?
+`
+
+// Index: project_id_device_id
+const listDeviceApplicationStatuses = `
+  select project_id, device_id, application_id, current_release_id from device_application_statuses
+  where project_id = ? and device_id = ?
+`
+
+// Index: project_id_device_id
+const listDeviceReleases = `
+  select device_application_statuses.device_id, applications.name, releases.number from device_application_statuses
+  join applications on applications.id = device_application_statuses.application_id
+  join releases on releases.id = device_application_statuses.current_release_id
+  where device_application_statuses.project_id = ?
+`
+
+// Index: project_id_device_id
+const listDeviceReleasesByDevice = `
+  select device_application_statuses.device_id, applications.name, releases.number from device_application_statuses
+  join applications on applications.id = device_application_statuses.application_id
+  join releases on releases.id = device_application_statuses.current_release_id
+  where device_application_statuses.project_id = ? and device_application_statuses.device_id = ?
+`
+
+// Index: primary key
+const deleteDeviceApplicationStatus = `
+  delete from device_application_statuses
+  where project_id = ? and device_id = ? and application_id = ?
+`
+
+// Index: primary key
+const setDeviceServiceStatus = `
+  insert into device_service_statuses (
+    project_id,
+    device_id,
+    application_id,
+    service,
+    current_release_id
+  )
+  values (?, ?, ?, ?, ?)
+  on duplicate key update
+    current_release_id = ?
+`
+
+// Index: primary key
+const getDeviceServiceStatus = `
+  select project_id, device_id, application_id, service, current_release_id from device_service_statuses
+  where project_id = ? and device_id = ? and application_id = ? and service = ?
+`
+
+// Index: project_id_device_id_application_id
+const getDeviceServiceStatuses = `
+  select project_id, device_id, application_id, service, current_release_id from device_service_statuses
+  where project_id = ? and device_id = ? and application_id = ?
+`
+
+// Index: project_id_device_id_application_id
+const listDeviceServiceStatuses = `
+  select project_id, device_id, application_id, service, current_release_id from device_service_statuses
+  where project_id = ? and device_id = ?
+`
+
+// Index: primary key
+const deleteDeviceServiceStatus = `
+  delete from device_service_statuses
+  where project_id = ? and device_id = ? and application_id = ? and service = ?
+`
+
+// Index: primary key
+const setDeviceConnection = `
+  replace into device_connections (
+    connection_key,
+    controller
+  )
+  values (?, ?)
+`
+
+// Index: primary key
+const getDeviceConnection = `
+  select controller from device_connections
+  where connection_key = ?
+`
+
+// Index: primary key
+const deleteDeviceConnection = `
+  delete from device_connections
+  where connection_key = ? and controller = ?
+`
+
+// Index: primary key
+//
+// The project is locked so that its sessions are counted and started by one
+// controller at a time
+const lockProjectRemoteSessions = `
+  select id from projects
+  where id = ?
+  for update
+`
+
+// Index: project_id_updated_at
+const countRemoteSessions = `
+  select count(*) from remote_sessions
+  where project_id = ? and updated_at > ?
+`
+
+// Index: project_id_updated_at
+const deleteExpiredRemoteSessions = `
+  delete from remote_sessions
+  where project_id = ? and updated_at <= ?
+`
+
+const createRemoteSession = `
+  insert into remote_sessions (
+    id,
+    project_id,
+    updated_at
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary key
+const refreshRemoteSession = `
+  update remote_sessions
+  set updated_at = ?
+  where id = ?
+`
+
+// Index: primary key
+const deleteRemoteSession = `
+  delete from remote_sessions
+  where id = ?
+`
+
+const tryLock = `
+  select get_lock(?, 0)
+`
+
+const releaseLock = `
+  select release_lock(?)
+`
+
+// Index: primary key
+const setProjectConfig = `
+  replace into project_configs (
+    project_id,
+    k,
+    v
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary key
+const getProjectConfig = `
+  select project_id, k, v from project_configs
+  where project_id = ? and k = ?
+`
+
+// Index: primary key
+const setSSOConfig = `
+  replace into sso_configs (
+    project_id,
+    provider,
+    config
+  )
+  values (?, ?, ?)
+`
+
+// Index: primary key
+const getSSOConfig = `
+  select config from sso_configs
+  where project_id = ?
+`
+
+// Index: provider
+const listSSOConfigsByProvider = `
+  select project_id, config from sso_configs
+  where provider = ?
+`
@@ -0,0 +1,33 @@
+package sqlstore
+
+import "strings"
+
+// Postgres is the dialect PostgreSQL speaks. Emails are compared case
+// insensitively, like they are by MySQL's default collation, and devices
+// are searched for by substring instead of with a full text index.
+var Postgres = &Dialect{
+	queries: withQueries(upserts, map[string]string{
+		lookupUser:            strings.Replace(lookupUser, "where email = ?", "where lower(email) = lower(?)", 1),
+		validateUserWithEmail: strings.Replace(validateUserWithEmail, "where email = ?", "where lower(email) = lower(?)", 1),
+
+		createPasswordRecoveryToken: strings.Replace(createPasswordRecoveryToken, "now() + interval 1 hour", "now() + interval '1 hour'", 1),
+
+		tryLock:     postgresTryLock,
+		releaseLock: postgresReleaseLock,
+	}),
+	replacer: strings.NewReplacer(
+		"match (name, labels) against (concat('*', ?, '*') in boolean mode)",
+		"strpos(lower(name || ' ' || labels), lower(?)) > 0",
+	),
+	numberedParameters: true,
+	identifierQuote:    `"`,
+}
+
+// Advisory locks are held by the session, like MySQL's named locks are
+const postgresTryLock = `
+  select case when pg_try_advisory_lock(hashtext(?)) then 1 else 0 end
+`
+
+const postgresReleaseLock = `
+  select pg_advisory_unlock(hashtext(?))
+`
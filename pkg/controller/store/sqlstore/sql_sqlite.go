@@ -0,0 +1,35 @@
+package sqlstore
+
+import "strings"
+
+// SQLite is the dialect SQLite speaks. Its schema compares emails case
+// insensitively, like MySQL's default collation does, and devices are
+// searched for by substring instead of with a full text index. Times are
+// stored as text in the format current_timestamp uses, so they compare in
+// order. Only one controller uses a SQLite database, so its locks are held
+// by the controller.
+var SQLite = &Dialect{
+	queries: withQueries(upserts, map[string]string{
+		createPasswordRecoveryToken: strings.Replace(createPasswordRecoveryToken, "now() + interval 1 hour", "datetime('now', '+1 hour')", 1),
+
+		// Writing takes the database's lock for the rest of the transaction
+		lockSchemaMigrations:      sqliteLockSchemaMigrations,
+		lockProjectRemoteSessions: sqliteLockProjectRemoteSessions,
+	}),
+	replacer: strings.NewReplacer(
+		"match (name, labels) against (concat('*', ?, '*') in boolean mode)",
+		"instr(lower(name || ' ' || labels), lower(?)) > 0",
+	),
+	timeFormat: "2006-01-02 15:04:05.999999999",
+	localLocks: true,
+}
+
+const sqliteLockSchemaMigrations = `
+  delete from schema_migrations
+  where 0
+`
+
+const sqliteLockProjectRemoteSessions = `
+  delete from remote_sessions
+  where project_id = ? and 0
+`
@@ -0,0 +1,121 @@
+package sqlstore
+
+// upserts are how PostgreSQL and SQLite write MySQL's replace into, insert
+// ignore and on duplicate key update.
+var upserts = map[string]string{
+	setDeviceEnvironment:       upsertDeviceEnvironment,
+	addDeviceGroupMember:       upsertDeviceGroupMember,
+	setDeviceReleasePin:        upsertDeviceReleasePin,
+	setApplicationGitSync:      upsertApplicationGitSync,
+	setDeviceApplicationStatus: upsertDeviceApplicationStatus,
+	setDeviceServiceStatus:     upsertDeviceServiceStatus,
+	setDeviceConnection:        upsertDeviceConnection,
+	setProjectConfig:           upsertProjectConfig,
+	setSSOConfig:               upsertSSOConfig,
+}
+
+const upsertDeviceEnvironment = `
+  insert into device_environments (
+    device_id,
+    project_id,
+    environment
+  )
+  values (?, ?, ?)
+  on conflict (device_id) do update set
+    project_id = excluded.project_id,
+    environment = excluded.environment
+`
+
+const upsertDeviceGroupMember = `
+  insert into device_group_members (
+    project_id,
+    device_group_id,
+    device_id
+  )
+  values (?, ?, ?)
+  on conflict do nothing
+`
+
+const upsertDeviceReleasePin = `
+  insert into device_release_pins (
+    project_id,
+    device_id,
+    application_id,
+    release_id
+  )
+  values (?, ?, ?, ?)
+  on conflict (device_id, application_id) do update set release_id = ?, created_at = current_timestamp
+`
+
+const upsertApplicationGitSync = `
+  insert into application_git_syncs (
+    project_id,
+    application_id,
+    repository,
+    branch,
+    path,
+    last_error
+  )
+  values (?, ?, ?, ?, ?, '')
+  on conflict (application_id) do update set repository = ?, branch = ?, path = ?, last_commit = '', last_synced_at = null, last_error = ''
+`
+
+const upsertDeviceApplicationStatus = `
+  insert into device_application_statuses (
+    project_id,
+    device_id,
+    application_id,
+    current_release_id
+  )
+  values (?, ?, ?, ?)
+  on conflict (project_id, device_id, application_id) do update set
+    current_release_id = ?
+`
+
+const upsertDeviceServiceStatus = `
+  insert into device_service_statuses (
+    project_id,
+    device_id,
+    application_id,
+    service,
+    current_release_id
+  )
+  values (?, ?, ?, ?, ?)
+  on conflict (project_id, device_id, application_id, service) do update set
+    current_release_id = ?
+`
+
+// updated_at isn't set on update by the schema, like it is in MySQL
+const upsertDeviceConnection = `
+  insert into device_connections (
+    connection_key,
+    controller
+  )
+  values (?, ?)
+  on conflict (connection_key) do update set
+    controller = excluded.controller,
+    updated_at = current_timestamp
+`
+
+const upsertProjectConfig = `
+  insert into project_configs (
+    project_id,
+    k,
+    v
+  )
+  values (?, ?, ?)
+  on conflict (project_id, k) do update set
+    v = excluded.v
+`
+
+const upsertSSOConfig = `
+  insert into sso_configs (
+    project_id,
+    provider,
+    config
+  )
+  values (?, ?, ?)
+  on conflict (project_id) do update set
+    provider = excluded.provider,
+    config = excluded.config
+`
@@ -0,0 +1,5629 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/query"
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/utils"
+	"github.com/pkg/errors"
+
+	"github.com/segmentio/ksuid"
+	"gopkg.in/yaml.v2"
+)
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+const (
+	userPrefix                      = "usr"
+	registrationTokenPrefix         = "reg"
+	passwordRecoveryTokenPrefix     = "pwr"
+	sessionPrefix                   = "ses"
+	userAccessKeyPrefix             = "uky"
+	sshKeyPrefix                    = "ssh"
+	projectPrefix                   = "prj"
+	rolePrefix                      = "rol"
+	serviceAccountPrefix            = "sac"
+	serviceAccountAccessKeyPrefix   = "sak"
+	devicePrefix                    = "dev"
+	deviceRegistrationTokenPrefix   = "drt"
+	deviceAccessKeyPrefix           = "dak"
+	applicationPrefix               = "app"
+	releasePrefix                   = "rel"
+	environmentFilePrefix           = "env"
+	configFilePrefix                = "cfg"
+	deviceGroupPrefix               = "dgp"
+	deviceViewPrefix                = "dvw"
+	rolloutPrefix                   = "rlt"
+	sessionRecordingPrefix          = "rec"
+	auditLogEntryPrefix             = "aud"
+	deviceConnectivityEventPrefix   = "dce"
+	alertPrefix                     = "alr"
+	alertSilencePrefix              = "als"
+	remoteSessionPrefix             = "rms"
+	ExposedMetricConfigHolderPrefix = "mtc"
+)
+
+func newUserID() string {
+	return fmt.Sprintf("%s_%s", userPrefix, ksuid.New().String())
+}
+
+func newRegistrationTokenID() string {
+	return fmt.Sprintf("%s_%s", registrationTokenPrefix, ksuid.New().String())
+}
+
+func newPasswordRecoveryTokenID() string {
+	return fmt.Sprintf("%s_%s", passwordRecoveryTokenPrefix, ksuid.New().String())
+}
+
+func newSessionID() string {
+	return fmt.Sprintf("%s_%s", sessionPrefix, ksuid.New().String())
+}
+
+func newUserAccessKeyID() string {
+	return fmt.Sprintf("%s_%s", userAccessKeyPrefix, ksuid.New().String())
+}
+
+func newSSHKeyID() string {
+	return fmt.Sprintf("%s_%s", sshKeyPrefix, ksuid.New().String())
+}
+
+func newProjectID() string {
+	return fmt.Sprintf("%s_%s", projectPrefix, ksuid.New().String())
+}
+
+func newRoleID() string {
+	return fmt.Sprintf("%s_%s", rolePrefix, ksuid.New().String())
+}
+
+func newServiceAccountID() string {
+	return fmt.Sprintf("%s_%s", serviceAccountPrefix, ksuid.New().String())
+}
+
+func newServiceAccountAccessKeyID() string {
+	return fmt.Sprintf("%s_%s", serviceAccountAccessKeyPrefix, ksuid.New().String())
+}
+
+func newDeviceID() string {
+	return fmt.Sprintf("%s_%s", devicePrefix, ksuid.New().String())
+}
+
+func newDeviceRegistrationTokenID() string {
+	return fmt.Sprintf("%s_%s", deviceRegistrationTokenPrefix, ksuid.New().String())
+}
+
+func newDeviceAccessKeyID() string {
+	return fmt.Sprintf("%s_%s", deviceAccessKeyPrefix, ksuid.New().String())
+}
+
+func newApplicationID() string {
+	return fmt.Sprintf("%s_%s", applicationPrefix, ksuid.New().String())
+}
+
+func newReleaseID() string {
+	return fmt.Sprintf("%s_%s", releasePrefix, ksuid.New().String())
+}
+
+func newEnvironmentFileID() string {
+	return fmt.Sprintf("%s_%s", environmentFilePrefix, ksuid.New().String())
+}
+
+func newConfigFileID() string {
+	return fmt.Sprintf("%s_%s", configFilePrefix, ksuid.New().String())
+}
+
+func newDeviceGroupID() string {
+	return fmt.Sprintf("%s_%s", deviceGroupPrefix, ksuid.New().String())
+}
+
+func newRolloutID() string {
+	return fmt.Sprintf("%s_%s", rolloutPrefix, ksuid.New().String())
+}
+
+func newSessionRecordingID() string {
+	return fmt.Sprintf("%s_%s", sessionRecordingPrefix, ksuid.New().String())
+}
+
+func newAuditLogEntryID() string {
+	return fmt.Sprintf("%s_%s", auditLogEntryPrefix, ksuid.New().String())
+}
+
+func newDeviceConnectivityEventID() string {
+	return fmt.Sprintf("%s_%s", deviceConnectivityEventPrefix, ksuid.New().String())
+}
+
+func newDeviceViewID() string {
+	return fmt.Sprintf("%s_%s", deviceViewPrefix, ksuid.New().String())
+}
+
+func newAlertID() string {
+	return fmt.Sprintf("%s_%s", alertPrefix, ksuid.New().String())
+}
+
+func newAlertSilenceID() string {
+	return fmt.Sprintf("%s_%s", alertSilencePrefix, ksuid.New().String())
+}
+
+func newRemoteSessionID() string {
+	return fmt.Sprintf("%s_%s", remoteSessionPrefix, ksuid.New().String())
+}
+
+func newExposedMetricConfigHolderID() string {
+	return fmt.Sprintf("%s_%s", ExposedMetricConfigHolderPrefix, ksuid.New().String())
+}
+
+var (
+	_ store.Users                      = &Store{}
+	_ store.RegistrationTokens         = &Store{}
+	_ store.PasswordRecoveryTokens     = &Store{}
+	_ store.Sessions                   = &Store{}
+	_ store.UserAccessKeys             = &Store{}
+	_ store.SSHKeys                    = &Store{}
+	_ store.Projects                   = &Store{}
+	_ store.ProjectDeviceCounts        = &Store{}
+	_ store.Roles                      = &Store{}
+	_ store.Memberships                = &Store{}
+	_ store.MembershipRoleBindings     = &Store{}
+	_ store.ServiceAccounts            = &Store{}
+	_ store.ServiceAccountAccessKeys   = &Store{}
+	_ store.ServiceAccountRoleBindings = &Store{}
+	_ store.Devices                    = &Store{}
+	_ store.DeviceAccessKeys           = &Store{}
+	_ store.DeviceRegistrationTokens   = &Store{}
+	_ store.Applications               = &Store{}
+	_ store.Releases                   = &Store{}
+	_ store.ReleaseDeviceCounts        = &Store{}
+	_ store.EnvironmentFiles           = &Store{}
+	_ store.ConfigFiles                = &Store{}
+	_ store.DeviceGroups               = &Store{}
+	_ store.DeviceViews                = &Store{}
+	_ store.Rollouts                   = &Store{}
+	_ store.DeviceReleasePins          = &Store{}
+	_ store.ApplicationGitSyncs        = &Store{}
+	_ store.SessionRecordings          = &Store{}
+	_ store.AuditLogEntries            = &Store{}
+	_ store.DeviceConnectivityEvents   = &Store{}
+	_ store.Alerts                     = &Store{}
+	_ store.AlertSilences              = &Store{}
+	_ store.DeviceApplicationStatuses  = &Store{}
+	_ store.DeviceServiceStatuses      = &Store{}
+	_ store.SSHConfigs                 = &Store{}
+	_ store.DeviceEndpointConfigs      = &Store{}
+	_ store.AgentRolloutConfigs        = &Store{}
+	_ store.DeviceEnvironments         = &Store{}
+	_ store.DeviceEnvironmentConfigs   = &Store{}
+	_ store.MaintenanceWindowsConfigs  = &Store{}
+	_ store.ReleaseWebhooksConfigs     = &Store{}
+	_ store.EventWebhooksConfigs       = &Store{}
+	_ store.AuditLogConfigs            = &Store{}
+	_ store.SSOConfigs                 = &Store{}
+	_ store.LimitsConfigs              = &Store{}
+	_ store.HeartbeatConfigs           = &Store{}
+	_ store.AlertsConfigs              = &Store{}
+	_ store.StaleDeviceCleanupConfigs  = &Store{}
+	_ store.DeviceConnections          = &Store{}
+	_ store.Locks                      = &Store{}
+	_ store.RemoteSessions             = &Store{}
+	_ store.Connectivity               = &Store{}
+)
+
+// Store implements the store interfaces with SQL. Its queries are written
+// for MySQL, and are rewritten for databases that speak another dialect.
+type Store struct {
+	db timedDB
+
+	localLocksLock sync.Mutex
+	localLocks     map[string]struct{}
+}
+
+func NewStore(db *sql.DB, dialect *Dialect) *Store {
+	return &Store{
+		db: timedDB{
+			DB:      db,
+			dialect: dialect,
+		},
+		localLocks: make(map[string]struct{}),
+	}
+}
+
+func (s *Store) CreateUser(ctx context.Context, email, passwordHash, firstName, lastName, company string) (*models.User, error) {
+	id := newUserID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createUser,
+		id,
+		email,
+		passwordHash,
+		firstName,
+		lastName,
+		company,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) GetUser(ctx context.Context, id string) (*models.User, error) {
+	userRow := s.db.QueryRowContext(ctx, getUser, id)
+
+	user, err := s.scanUser(userRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Store) LookupUser(ctx context.Context, email string) (*models.User, error) {
+	userRow := s.db.QueryRowContext(ctx, lookupUser, email)
+
+	user, err := s.scanUser(userRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Store) ValidateUser(ctx context.Context, id, passwordHash string) (*models.User, error) {
+	userRow := s.db.QueryRowContext(ctx, validateUser, id, passwordHash)
+
+	user, err := s.scanUser(userRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Store) ValidateUserWithEmail(ctx context.Context, email, passwordHash string) (*models.User, error) {
+	userRow := s.db.QueryRowContext(ctx, validateUserWithEmail, email, passwordHash)
+
+	user, err := s.scanUser(userRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *Store) MarkRegistrationCompleted(ctx context.Context, id string) (*models.User, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		markRegistrationComplete,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) UpdatePasswordHash(ctx context.Context, id, passwordHash string) (*models.User, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updatePasswordHash,
+		passwordHash,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) UpdateFirstName(ctx context.Context, id, firstName string) (*models.User, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateFirstName,
+		firstName,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) UpdateLastName(ctx context.Context, id, lastName string) (*models.User, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateLastName,
+		lastName,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) UpdateCompany(ctx context.Context, id, company string) (*models.User, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateCompany,
+		company,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUser(ctx, id)
+}
+
+func (s *Store) scanUser(scanner scanner) (*models.User, error) {
+	var user models.User
+	if err := scanner.Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.FirstName,
+		&user.LastName,
+		&user.Company,
+		&user.RegistrationCompleted,
+		&user.SuperAdmin,
+	); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *Store) CreateRegistrationToken(ctx context.Context, userID, hash string) (*models.RegistrationToken, error) {
+	id := newRegistrationTokenID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createRegistrationToken,
+		id,
+		userID,
+		hash,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetRegistrationToken(ctx, id)
+}
+
+func (s *Store) GetRegistrationToken(ctx context.Context, id string) (*models.RegistrationToken, error) {
+	registrationTokenRow := s.db.QueryRowContext(ctx, getRegistrationToken, id)
+
+	registrationToken, err := s.scanRegistrationToken(registrationTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRegistrationTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return registrationToken, nil
+}
+
+func (s *Store) ValidateRegistrationToken(ctx context.Context, hash string) (*models.RegistrationToken, error) {
+	registrationTokenRow := s.db.QueryRowContext(ctx, validateRegistrationToken, hash)
+
+	registrationToken, err := s.scanRegistrationToken(registrationTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRegistrationTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return registrationToken, nil
+}
+
+func (s *Store) scanRegistrationToken(scanner scanner) (*models.RegistrationToken, error) {
+	var registrationToken models.RegistrationToken
+	if err := scanner.Scan(
+		&registrationToken.ID,
+		&registrationToken.CreatedAt,
+		&registrationToken.UserID,
+	); err != nil {
+		return nil, err
+	}
+	return &registrationToken, nil
+}
+
+func (s *Store) CreatePasswordRecoveryToken(ctx context.Context, userID, hash string) (*models.PasswordRecoveryToken, error) {
+	id := newPasswordRecoveryTokenID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createPasswordRecoveryToken,
+		id,
+		userID,
+		hash,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetPasswordRecoveryToken(ctx, id)
+}
+
+func (s *Store) GetPasswordRecoveryToken(ctx context.Context, id string) (*models.PasswordRecoveryToken, error) {
+	passwordRecoveryTokenRow := s.db.QueryRowContext(ctx, getPasswordRecoveryToken, id)
+
+	passwordRecoveryToken, err := s.scanPasswordRecoveryToken(passwordRecoveryTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrPasswordRecoveryTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return passwordRecoveryToken, nil
+}
+
+func (s *Store) ValidatePasswordRecoveryToken(ctx context.Context, hash string) (*models.PasswordRecoveryToken, error) {
+	passwordRecoveryTokenRow := s.db.QueryRowContext(ctx, validatePasswordRecoveryToken, hash)
+
+	passwordRecoveryToken, err := s.scanPasswordRecoveryToken(passwordRecoveryTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrPasswordRecoveryTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return passwordRecoveryToken, nil
+}
+
+func (s *Store) scanPasswordRecoveryToken(scanner scanner) (*models.PasswordRecoveryToken, error) {
+	var passwordRecoveryToken models.PasswordRecoveryToken
+	if err := scanner.Scan(
+		&passwordRecoveryToken.ID,
+		&passwordRecoveryToken.CreatedAt,
+		&passwordRecoveryToken.ExpiresAt,
+		&passwordRecoveryToken.UserID,
+	); err != nil {
+		return nil, err
+	}
+	return &passwordRecoveryToken, nil
+}
+
+func (s *Store) CreateSession(ctx context.Context, userID, hash string) (*models.Session, error) {
+	id := newSessionID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createSession,
+		id,
+		userID,
+		hash,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetSession(ctx, id)
+}
+
+func (s *Store) GetSession(ctx context.Context, id string) (*models.Session, error) {
+	sessionRow := s.db.QueryRowContext(ctx, getSession, id)
+
+	session, err := s.scanSession(sessionRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *Store) ValidateSession(ctx context.Context, hash string) (*models.Session, error) {
+	sessionRow := s.db.QueryRowContext(ctx, validateSession, hash)
+
+	session, err := s.scanSession(sessionRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, deleteSession, id)
+	return err
+}
+
+func (s *Store) scanSession(scanner scanner) (*models.Session, error) {
+	var session models.Session
+	if err := scanner.Scan(
+		&session.ID,
+		&session.CreatedAt,
+		&session.UserID,
+	); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *Store) CreateUserAccessKey(ctx context.Context, userID, hash, description string) (*models.UserAccessKey, error) {
+	id := newUserAccessKeyID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createUserAccessKey,
+		id,
+		userID,
+		hash,
+		description,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetUserAccessKey(ctx, id)
+}
+
+func (s *Store) GetUserAccessKey(ctx context.Context, id string) (*models.UserAccessKey, error) {
+	userAccessKeyRow := s.db.QueryRowContext(ctx, getUserAccessKey, id)
+
+	userAccessKey, err := s.scanUserAccessKey(userAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return userAccessKey, nil
+}
+
+func (s *Store) ValidateUserAccessKey(ctx context.Context, hash string) (*models.UserAccessKey, error) {
+	userAccessKeyRow := s.db.QueryRowContext(ctx, validateUserAccessKey, hash)
+
+	userAccessKey, err := s.scanUserAccessKey(userAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrUserAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return userAccessKey, nil
+}
+
+func (s *Store) ListUserAccessKeys(ctx context.Context, projectID string) ([]models.UserAccessKey, error) {
+	userAccessKeyRows, err := s.db.QueryContext(ctx, listUserAccessKeys, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query user access keys")
+	}
+	defer userAccessKeyRows.Close()
+
+	userAccessKeys := make([]models.UserAccessKey, 0)
+	for userAccessKeyRows.Next() {
+		userAccessKey, err := s.scanUserAccessKey(userAccessKeyRows)
+		if err != nil {
+			return nil, err
+		}
+		userAccessKeys = append(userAccessKeys, *userAccessKey)
+	}
+
+	if err := userAccessKeyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userAccessKeys, nil
+}
+
+func (s *Store) DeleteUserAccessKey(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteUserAccessKey,
+		id,
+	)
+	return err
+}
+
+func (s *Store) scanUserAccessKey(scanner scanner) (*models.UserAccessKey, error) {
+	var userAccessKey models.UserAccessKey
+	if err := scanner.Scan(
+		&userAccessKey.ID,
+		&userAccessKey.CreatedAt,
+		&userAccessKey.UserID,
+		&userAccessKey.Description,
+	); err != nil {
+		return nil, err
+	}
+	return &userAccessKey, nil
+}
+
+func (s *Store) CreateSSHKey(ctx context.Context, userID, name, publicKey string) (*models.SSHKey, error) {
+	id := newSSHKeyID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createSSHKey,
+		id,
+		userID,
+		name,
+		publicKey,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetSSHKey(ctx, id, userID)
+}
+
+func (s *Store) GetSSHKey(ctx context.Context, id, userID string) (*models.SSHKey, error) {
+	sshKeyRow := s.db.QueryRowContext(ctx, getSSHKey, id, userID)
+
+	sshKey, err := s.scanSSHKey(sshKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrSSHKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sshKey, nil
+}
+
+func (s *Store) ListSSHKeys(ctx context.Context, userID string) ([]models.SSHKey, error) {
+	sshKeyRows, err := s.db.QueryContext(ctx, listSSHKeys, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query ssh keys")
+	}
+	defer sshKeyRows.Close()
+
+	sshKeys := make([]models.SSHKey, 0)
+	for sshKeyRows.Next() {
+		sshKey, err := s.scanSSHKey(sshKeyRows)
+		if err != nil {
+			return nil, err
+		}
+		sshKeys = append(sshKeys, *sshKey)
+	}
+
+	if err := sshKeyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sshKeys, nil
+}
+
+func (s *Store) DeleteSSHKey(ctx context.Context, id, userID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteSSHKey,
+		id,
+		userID,
+	)
+	return err
+}
+
+func (s *Store) scanSSHKey(scanner scanner) (*models.SSHKey, error) {
+	var sshKey models.SSHKey
+	if err := scanner.Scan(
+		&sshKey.ID,
+		&sshKey.CreatedAt,
+		&sshKey.UserID,
+		&sshKey.Name,
+		&sshKey.PublicKey,
+	); err != nil {
+		return nil, err
+	}
+	return &sshKey, nil
+}
+
+func (s *Store) CreateProject(ctx context.Context, name string) (*models.Project, error) {
+	id := newProjectID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createProject,
+		id,
+		name,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetProject(ctx, id)
+}
+
+func (s *Store) GetProject(ctx context.Context, id string) (*models.Project, error) {
+	projectRow := s.db.QueryRowContext(ctx, getProject, id)
+
+	project, err := s.scanProject(projectRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrProjectNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *Store) LookupProject(ctx context.Context, name string) (*models.Project, error) {
+	projectRow := s.db.QueryRowContext(ctx, lookupProject, name)
+
+	project, err := s.scanProject(projectRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrProjectNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+func (s *Store) ListProjects(ctx context.Context) ([]models.Project, error) {
+	projectRows, err := s.db.QueryContext(ctx, listProjects)
+	if err != nil {
+		return nil, errors.Wrap(err, "query projects")
+	}
+	defer projectRows.Close()
+
+	projects := make([]models.Project, 0)
+	for projectRows.Next() {
+		project, err := s.scanProject(projectRows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+
+	if err := projectRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+func (s *Store) UpdateProject(ctx context.Context, id, name, datadogApiKey string) (*models.Project, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateProject,
+		name,
+		datadogApiKey,
+		id,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetProject(ctx, id)
+}
+
+func (s *Store) DeleteProject(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteProject,
+		id,
+	)
+	return err
+}
+
+func (s *Store) scanProject(scanner scanner) (*models.Project, error) {
+	var project models.Project
+	if err := scanner.Scan(
+		&project.ID,
+		&project.CreatedAt,
+		&project.Name,
+		&project.DatadogAPIKey,
+	); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *Store) GetProjectDeviceCounts(ctx context.Context, projectID string) (*models.ProjectDeviceCounts, error) {
+	countRow := s.db.QueryRowContext(ctx, getProjectDeviceCounts, projectID)
+
+	count, err := s.scanProjectDeviceCountRow(countRow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProjectDeviceCounts{
+		AllCount: count,
+	}, nil
+}
+
+func (s *Store) scanProjectDeviceCountRow(scanner scanner) (int, error) {
+	var count int
+	if err := scanner.Scan(
+		&count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) GetProjectApplicationCounts(ctx context.Context, projectID string) (*models.ProjectApplicationCounts, error) {
+	countRow := s.db.QueryRowContext(ctx, getProjectApplicationCounts, projectID)
+
+	count, err := s.scanProjectApplicationCountRow(countRow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProjectApplicationCounts{
+		AllCount: count,
+	}, nil
+}
+
+func (s *Store) scanProjectApplicationCountRow(scanner scanner) (int, error) {
+	var count int
+	if err := scanner.Scan(
+		&count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) CreateRole(ctx context.Context, projectID, name, description, config string) (*models.Role, error) {
+	id := newRoleID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createRole,
+		id,
+		projectID,
+		name,
+		description,
+		config,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetRole(ctx, id, projectID)
+}
+
+func (s *Store) GetRole(ctx context.Context, id, projectID string) (*models.Role, error) {
+	roleRow := s.db.QueryRowContext(ctx, getRole, id, projectID)
+
+	role, err := s.scanRole(roleRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRoleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *Store) LookupRole(ctx context.Context, name, projectID string) (*models.Role, error) {
+	roleRow := s.db.QueryRowContext(ctx, lookupRole, name, projectID)
+
+	role, err := s.scanRole(roleRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRoleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (s *Store) ListRoles(ctx context.Context, projectID string) ([]models.Role, error) {
+	roleRows, err := s.db.QueryContext(ctx, listRoles, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query roles")
+	}
+	defer roleRows.Close()
+
+	roles := make([]models.Role, 0)
+	for roleRows.Next() {
+		role, err := s.scanRole(roleRows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+
+	if err := roleRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (s *Store) UpdateRole(ctx context.Context, id, projectID, name, description, config string) (*models.Role, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateRole,
+		name,
+		description,
+		config,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetRole(ctx, id, projectID)
+}
+
+func (s *Store) DeleteRole(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteRole,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanRole(scanner scanner) (*models.Role, error) {
+	var role models.Role
+	if err := scanner.Scan(
+		&role.ID,
+		&role.CreatedAt,
+		&role.ProjectID,
+		&role.Name,
+		&role.Description,
+		&role.Config,
+	); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *Store) CreateMembership(ctx context.Context, userID, projectID string) (*models.Membership, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		createMembership,
+		userID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetMembership(ctx, userID, projectID)
+}
+
+func (s *Store) GetMembership(ctx context.Context, userID, projectID string) (*models.Membership, error) {
+	membershipRow := s.db.QueryRowContext(ctx, getMembership, userID, projectID)
+
+	membership, err := s.scanMembership(membershipRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrMembershipNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+func (s *Store) ListMembershipsByUser(ctx context.Context, userID string) ([]models.Membership, error) {
+	return s.listMemberships(ctx, userID, listMembershipsByUser)
+}
+
+func (s *Store) ListMembershipsByProject(ctx context.Context, projectID string) ([]models.Membership, error) {
+	return s.listMemberships(ctx, projectID, listMembershipsByProject)
+}
+
+func (s *Store) listMemberships(ctx context.Context, id, query string) ([]models.Membership, error) {
+	membershipRows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "query memberships")
+	}
+	defer membershipRows.Close()
+
+	memberships := make([]models.Membership, 0)
+	for membershipRows.Next() {
+		membership, err := s.scanMembership(membershipRows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, *membership)
+	}
+
+	if err := membershipRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+func (s *Store) DeleteMembership(ctx context.Context, userID, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteMembership,
+		userID,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanMembership(scanner scanner) (*models.Membership, error) {
+	var membership models.Membership
+	if err := scanner.Scan(
+		&membership.UserID,
+		&membership.ProjectID,
+		&membership.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (s *Store) CreateMembershipRoleBinding(ctx context.Context, userID, roleID, projectID string) (*models.MembershipRoleBinding, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		createMembershipRoleBinding,
+		userID,
+		roleID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetMembershipRoleBinding(ctx, userID, roleID, projectID)
+}
+
+func (s *Store) GetMembershipRoleBinding(ctx context.Context, userID, roleID, projectID string) (*models.MembershipRoleBinding, error) {
+	membershipRoleBindingRow := s.db.QueryRowContext(ctx, getMembershipRoleBinding, userID, roleID, projectID)
+
+	membershipRoleBinding, err := s.scanMembershipRoleBinding(membershipRoleBindingRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrMembershipRoleBindingNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return membershipRoleBinding, nil
+}
+
+func (s *Store) ListMembershipRoleBindings(ctx context.Context, userID, projectID string) ([]models.MembershipRoleBinding, error) {
+	membershipRoleBindingRows, err := s.db.QueryContext(ctx, listMembershipRoleBindings, userID, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query membership role bindings")
+	}
+	defer membershipRoleBindingRows.Close()
+
+	membershipRoleBindings := make([]models.MembershipRoleBinding, 0)
+	for membershipRoleBindingRows.Next() {
+		membershipRoleBinding, err := s.scanMembershipRoleBinding(membershipRoleBindingRows)
+		if err != nil {
+			return nil, err
+		}
+		membershipRoleBindings = append(membershipRoleBindings, *membershipRoleBinding)
+	}
+
+	if err := membershipRoleBindingRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return membershipRoleBindings, nil
+}
+
+func (s *Store) DeleteMembershipRoleBinding(ctx context.Context, userID, roleID, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteMembershipRoleBinding,
+		userID,
+		roleID,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanMembershipRoleBinding(scanner scanner) (*models.MembershipRoleBinding, error) {
+	var membershipRoleBinding models.MembershipRoleBinding
+	if err := scanner.Scan(
+		&membershipRoleBinding.UserID,
+		&membershipRoleBinding.RoleID,
+		&membershipRoleBinding.CreatedAt,
+		&membershipRoleBinding.ProjectID,
+	); err != nil {
+		return nil, err
+	}
+	return &membershipRoleBinding, nil
+}
+
+func (s *Store) CreateServiceAccount(ctx context.Context, projectID, name, description string) (*models.ServiceAccount, error) {
+	id := newServiceAccountID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createServiceAccount,
+		id,
+		projectID,
+		name,
+		description,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetServiceAccount(ctx, id, projectID)
+}
+
+func (s *Store) GetServiceAccount(ctx context.Context, id, projectID string) (*models.ServiceAccount, error) {
+	serviceAccountRow := s.db.QueryRowContext(ctx, getServiceAccount, id, projectID)
+
+	serviceAccount, err := s.scanServiceAccount(serviceAccountRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrServiceAccountNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return serviceAccount, nil
+}
+
+func (s *Store) LookupServiceAccount(ctx context.Context, name, projectID string) (*models.ServiceAccount, error) {
+	serviceAccountRow := s.db.QueryRowContext(ctx, lookupServiceAccount, name, projectID)
+
+	serviceAccount, err := s.scanServiceAccount(serviceAccountRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrServiceAccountNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return serviceAccount, nil
+}
+
+func (s *Store) ListServiceAccounts(ctx context.Context, projectID string) ([]models.ServiceAccount, error) {
+	serviceAccountRows, err := s.db.QueryContext(ctx, listServiceAccounts, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query service accounts")
+	}
+	defer serviceAccountRows.Close()
+
+	serviceAccounts := make([]models.ServiceAccount, 0)
+	for serviceAccountRows.Next() {
+		serviceAccount, err := s.scanServiceAccount(serviceAccountRows)
+		if err != nil {
+			return nil, err
+		}
+		serviceAccounts = append(serviceAccounts, *serviceAccount)
+	}
+
+	if err := serviceAccountRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return serviceAccounts, nil
+}
+
+func (s *Store) UpdateServiceAccount(ctx context.Context, id, projectID, name, description string) (*models.ServiceAccount, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateServiceAccount,
+		name,
+		description,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetServiceAccount(ctx, id, projectID)
+}
+
+func (s *Store) DeleteServiceAccount(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteServiceAccount,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanServiceAccount(scanner scanner) (*models.ServiceAccount, error) {
+	var serviceAccount models.ServiceAccount
+	if err := scanner.Scan(
+		&serviceAccount.ID,
+		&serviceAccount.CreatedAt,
+		&serviceAccount.ProjectID,
+		&serviceAccount.Name,
+		&serviceAccount.Description,
+	); err != nil {
+		return nil, err
+	}
+	return &serviceAccount, nil
+}
+
+func (s *Store) CreateServiceAccountAccessKey(ctx context.Context, projectID, serviceAccountID, hash, description, config string, expiresAt *time.Time) (*models.ServiceAccountAccessKey, error) {
+	id := newServiceAccountAccessKeyID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createServiceAccountAccessKey,
+		id,
+		projectID,
+		serviceAccountID,
+		hash,
+		description,
+		config,
+		expiresAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetServiceAccountAccessKey(ctx, id, projectID)
+}
+
+func (s *Store) GetServiceAccountAccessKey(ctx context.Context, id, projectID string) (*models.ServiceAccountAccessKey, error) {
+	serviceAccountAccessKeyRow := s.db.QueryRowContext(ctx, getServiceAccountAccessKey, id, projectID)
+
+	serviceAccountAccessKey, err := s.scanServiceAccountAccessKey(serviceAccountAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrServiceAccountAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return serviceAccountAccessKey, nil
+}
+
+func (s *Store) ValidateServiceAccountAccessKey(ctx context.Context, hash string) (*models.ServiceAccountAccessKey, error) {
+	serviceAccountAccessKeyRow := s.db.QueryRowContext(ctx, validateServiceAccountAccessKey, hash)
+
+	serviceAccountAccessKey, err := s.scanServiceAccountAccessKey(serviceAccountAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrServiceAccountAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return serviceAccountAccessKey, nil
+}
+
+func (s *Store) ListServiceAccountAccessKeys(ctx context.Context, projectID, serviceAccountID string) ([]models.ServiceAccountAccessKey, error) {
+	serviceAccountAccessKeyRows, err := s.db.QueryContext(ctx, listServiceAccountAccessKeys, projectID, serviceAccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query service account access keys")
+	}
+	defer serviceAccountAccessKeyRows.Close()
+
+	serviceAccountAccessKeys := make([]models.ServiceAccountAccessKey, 0)
+	for serviceAccountAccessKeyRows.Next() {
+		serviceAccountAccessKey, err := s.scanServiceAccountAccessKey(serviceAccountAccessKeyRows)
+		if err != nil {
+			return nil, err
+		}
+		serviceAccountAccessKeys = append(serviceAccountAccessKeys, *serviceAccountAccessKey)
+	}
+
+	if err := serviceAccountAccessKeyRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return serviceAccountAccessKeys, nil
+}
+
+func (s *Store) DeleteServiceAccountAccessKey(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteServiceAccountAccessKey,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanServiceAccountAccessKey(scanner scanner) (*models.ServiceAccountAccessKey, error) {
+	var serviceAccountAccessKey models.ServiceAccountAccessKey
+	if err := scanner.Scan(
+		&serviceAccountAccessKey.ID,
+		&serviceAccountAccessKey.CreatedAt,
+		&serviceAccountAccessKey.ProjectID,
+		&serviceAccountAccessKey.ServiceAccountID,
+		&serviceAccountAccessKey.Description,
+		&serviceAccountAccessKey.Config,
+		&serviceAccountAccessKey.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+	return &serviceAccountAccessKey, nil
+}
+
+func (s *Store) CreateServiceAccountRoleBinding(ctx context.Context, serviceAccountID, roleID, projectID string) (*models.ServiceAccountRoleBinding, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		createServiceAccountRoleBinding,
+		serviceAccountID,
+		roleID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetServiceAccountRoleBinding(ctx, serviceAccountID, roleID, projectID)
+}
+
+func (s *Store) GetServiceAccountRoleBinding(ctx context.Context, serviceAccountID, roleID, projectID string) (*models.ServiceAccountRoleBinding, error) {
+	serviceAccountRoleBindingRow := s.db.QueryRowContext(ctx, getServiceAccountRoleBinding, serviceAccountID, roleID, projectID)
+
+	serviceAccountRoleBinding, err := s.scanServiceAccountRoleBinding(serviceAccountRoleBindingRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrServiceAccountRoleBindingNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return serviceAccountRoleBinding, nil
+}
+
+func (s *Store) ListServiceAccountRoleBindings(ctx context.Context, serviceAccountID, projectID string) ([]models.ServiceAccountRoleBinding, error) {
+	serviceAccountRoleBindingRows, err := s.db.QueryContext(ctx, listServiceAccountRoleBindings, serviceAccountID, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query service account role bindings")
+	}
+	defer serviceAccountRoleBindingRows.Close()
+
+	serviceAccountRoleBindings := make([]models.ServiceAccountRoleBinding, 0)
+	for serviceAccountRoleBindingRows.Next() {
+		serviceAccountRoleBinding, err := s.scanServiceAccountRoleBinding(serviceAccountRoleBindingRows)
+		if err != nil {
+			return nil, err
+		}
+		serviceAccountRoleBindings = append(serviceAccountRoleBindings, *serviceAccountRoleBinding)
+	}
+
+	if err := serviceAccountRoleBindingRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return serviceAccountRoleBindings, nil
+}
+
+func (s *Store) DeleteServiceAccountRoleBinding(ctx context.Context, serviceAccountID, roleID, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteServiceAccountRoleBinding,
+		serviceAccountID,
+		roleID,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanServiceAccountRoleBinding(scanner scanner) (*models.ServiceAccountRoleBinding, error) {
+	var serviceAccountRoleBinding models.ServiceAccountRoleBinding
+	if err := scanner.Scan(
+		&serviceAccountRoleBinding.ServiceAccountID,
+		&serviceAccountRoleBinding.RoleID,
+		&serviceAccountRoleBinding.CreatedAt,
+		&serviceAccountRoleBinding.ProjectID,
+	); err != nil {
+		return nil, err
+	}
+	return &serviceAccountRoleBinding, nil
+}
+
+func (s *Store) CreateDevice(ctx context.Context, projectID, name, deviceRegistrationTokenID string, deviceLabels map[string]string, pendingApproval bool) (*models.Device, error) {
+	deviceID := newDeviceID()
+
+	serializedDeviceLabels, err := json.Marshal(deviceLabels)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createDevice,
+		deviceID,
+		projectID,
+		name,
+		deviceRegistrationTokenID,
+		string(serializedDeviceLabels),
+		pendingApproval,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, deviceID, projectID)
+}
+
+func (s *Store) GetDevice(ctx context.Context, id, projectID string) (*models.Device, error) {
+	deviceRow := s.db.QueryRowContext(ctx, getDevice, id, projectID)
+
+	device, err := s.scanDevice(deviceRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	offlineThreshold, err := s.deviceOfflineThreshold(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	setDeviceStatus(device, offlineThreshold, time.Now())
+
+	releaseRows, err := s.db.QueryContext(ctx, listDeviceReleasesByDevice, projectID, device.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseRows.Close()
+
+	deviceReleases, err := s.scanDeviceReleases(releaseRows)
+	if err != nil {
+		return nil, err
+	}
+	device.Releases = deviceReleases[device.ID]
+	if device.Releases == nil {
+		device.Releases = make(map[string]uint32)
+	}
+
+	return device, nil
+}
+
+func (s *Store) LookupDevice(ctx context.Context, name, projectID string) (*models.Device, error) {
+	deviceRow := s.db.QueryRowContext(ctx, lookupDevice, name, projectID)
+
+	device, err := s.scanDevice(deviceRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	offlineThreshold, err := s.deviceOfflineThreshold(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	setDeviceStatus(device, offlineThreshold, time.Now())
+
+	releaseRows, err := s.db.QueryContext(ctx, listDeviceReleasesByDevice, projectID, device.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseRows.Close()
+
+	deviceReleases, err := s.scanDeviceReleases(releaseRows)
+	if err != nil {
+		return nil, err
+	}
+	device.Releases = deviceReleases[device.ID]
+	if device.Releases == nil {
+		device.Releases = make(map[string]uint32)
+	}
+
+	return device, nil
+}
+
+func (s *Store) ListDevices(ctx context.Context, projectID, searchQuery string) ([]models.Device, error) {
+	offlineThreshold, err := s.deviceOfflineThreshold(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deviceRows *sql.Rows
+	if searchQuery == "" {
+		deviceRows, err = s.db.QueryContext(ctx, listDevices, projectID)
+	} else {
+		deviceRows, err = s.db.QueryContext(ctx, searchDevices, projectID, searchQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer deviceRows.Close()
+
+	now := time.Now()
+	devices := make([]models.Device, 0)
+	for deviceRows.Next() {
+		device, err := s.scanDevice(deviceRows)
+		if err != nil {
+			return nil, err
+		}
+		setDeviceStatus(device, offlineThreshold, now)
+		devices = append(devices, *device)
+	}
+
+	if err := deviceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.setDeviceReleases(ctx, projectID, devices); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (s *Store) ListDevicesPage(ctx context.Context, projectID, after string, limit int) ([]models.Device, error) {
+	offlineThreshold, err := s.deviceOfflineThreshold(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceRows, err := s.db.QueryContext(ctx, listDevicesPage, projectID, after, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer deviceRows.Close()
+
+	now := time.Now()
+	devices := make([]models.Device, 0)
+	for deviceRows.Next() {
+		device, err := s.scanDevice(deviceRows)
+		if err != nil {
+			return nil, err
+		}
+		setDeviceStatus(device, offlineThreshold, now)
+		devices = append(devices, *device)
+	}
+
+	if err := deviceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.setDeviceReleases(ctx, projectID, devices); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (s *Store) ListDevicesMatching(ctx context.Context, projectID, searchQuery string, query models.Query) ([]models.Device, error) {
+	offlineThreshold, err := s.deviceOfflineThreshold(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := listDevices
+	args := []interface{}{projectID}
+	if searchQuery != "" {
+		q = searchDevices
+		args = append(args, searchQuery)
+	}
+
+	now := time.Now()
+	clauses, clauseArgs := deviceQueryClauses(query, now, offlineThreshold)
+	for _, clause := range clauses {
+		q += "  and " + clause + "\n"
+	}
+	args = append(args, clauseArgs...)
+
+	deviceRows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer deviceRows.Close()
+
+	devices := make([]models.Device, 0)
+	for deviceRows.Next() {
+		device, err := s.scanDevice(deviceRows)
+		if err != nil {
+			return nil, err
+		}
+		setDeviceStatus(device, offlineThreshold, now)
+		devices = append(devices, *device)
+	}
+
+	if err := deviceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.setDeviceReleases(ctx, projectID, devices); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// deviceQueryColumns are the device properties that are columns, which
+// conditions on can be checked in SQL.
+var deviceQueryColumns = map[string]string{
+	"name":              "name",
+	"info.agentVersion": "agent_version",
+	"createdAt":         "created_at",
+	"lastSeenAt":        "last_seen_at",
+}
+
+// deviceReleaseClause selects devices by the number of the release of an
+// application they report running.
+const deviceReleaseClause = `id in (
+    select device_application_statuses.device_id from device_application_statuses
+    join applications on applications.id = device_application_statuses.application_id
+    join releases on releases.id = device_application_statuses.current_release_id
+    where device_application_statuses.project_id = devices.project_id
+    and applications.name = ? and releases.number %s
+  )`
+
+// deviceQueryClauses returns SQL conditions that devices matching query
+// also match. Filters are only used if they have one condition, since their
+// conditions are alternatives, and only conditions on a device's name,
+// agent version, status, reported releases, or creation or last seen times
+// are used.
+func deviceQueryClauses(q models.Query, now time.Time, offlineThreshold time.Duration) ([]string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+
+	for _, filter := range q {
+		if len(filter) != 1 || filter[0].Type != models.DevicePropertyCondition {
+			continue
+		}
+
+		var params models.DevicePropertyConditionParams
+		if err := utils.JSONConvert(filter[0].Params, &params); err != nil {
+			continue
+		}
+
+		// Devices are offline once they haven't been seen for the threshold
+		if params.Property == "status" {
+			onlineSince := now.Add(-offlineThreshold)
+			switch {
+			case params.Operator == models.OperatorIs && params.Value == string(models.DeviceStatusOnline),
+				params.Operator == models.OperatorIsNot && params.Value == string(models.DeviceStatusOffline):
+				clauses = append(clauses, "last_seen_at >= ?")
+				args = append(args, onlineSince)
+			case params.Operator == models.OperatorIs && params.Value == string(models.DeviceStatusOffline),
+				params.Operator == models.OperatorIsNot && params.Value == string(models.DeviceStatusOnline):
+				clauses = append(clauses, "last_seen_at < ?")
+				args = append(args, onlineSince)
+			}
+			continue
+		}
+
+		// Release numbers are compared as numbers, like versions are, so
+		// every operator but the negative ones can be checked in SQL
+		if strings.HasPrefix(params.Property, query.ReleasesPropertyPrefix) {
+			application := strings.TrimPrefix(params.Property, query.ReleasesPropertyPrefix)
+			switch params.Operator {
+			case models.OperatorIs, models.OperatorGreaterThan, models.OperatorGreaterThanOrEqual,
+				models.OperatorLessThan, models.OperatorLessThanOrEqual:
+				number, err := strconv.ParseUint(params.Value, 10, 32)
+				if err != nil {
+					continue
+				}
+				operator := string(params.Operator)
+				if params.Operator == models.OperatorIs {
+					operator = "="
+				}
+				clauses = append(clauses, fmt.Sprintf(deviceReleaseClause, operator+" ?"))
+				args = append(args, application, number)
+			case models.OperatorIn:
+				if len(params.Values) == 0 {
+					continue
+				}
+				var numbers []interface{}
+				for _, value := range params.Values {
+					number, err := strconv.ParseUint(value, 10, 32)
+					if err != nil {
+						break
+					}
+					numbers = append(numbers, number)
+				}
+				if len(numbers) != len(params.Values) {
+					continue
+				}
+				clauses = append(clauses, fmt.Sprintf(deviceReleaseClause, "in (?"+strings.Repeat(", ?", len(numbers)-1)+")"))
+				args = append(args, application)
+				args = append(args, numbers...)
+			}
+			continue
+		}
+
+		column, ok := deviceQueryColumns[params.Property]
+		if !ok {
+			continue
+		}
+
+		if column == "name" || column == "agent_version" {
+			switch params.Operator {
+			case models.OperatorIs:
+				clauses = append(clauses, column+" = ?")
+				args = append(args, params.Value)
+			case models.OperatorIn:
+				if len(params.Values) == 0 {
+					continue
+				}
+				clauses = append(clauses, column+" in (?"+strings.Repeat(", ?", len(params.Values)-1)+")")
+				for _, value := range params.Values {
+					args = append(args, value)
+				}
+			}
+			continue
+		}
+
+		t, ok := query.ParseTime(params.Value, now)
+		if !ok {
+			continue
+		}
+		switch params.Operator {
+		case models.OperatorGreaterThan, models.OperatorGreaterThanOrEqual,
+			models.OperatorLessThan, models.OperatorLessThanOrEqual:
+			clauses = append(clauses, fmt.Sprintf("%s %s ?", column, params.Operator))
+			args = append(args, t)
+		}
+	}
+
+	return clauses, args
+}
+
+func (s *Store) UpdateDeviceName(ctx context.Context, id, projectID, name string) (*models.Device, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceName,
+		name,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+func (s *Store) ApproveDevice(ctx context.Context, id, projectID string) (*models.Device, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		approveDevice,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+func (s *Store) DecommissionDevice(ctx context.Context, id, projectID string) (*models.Device, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		decommissionDevice,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+func (s *Store) ArchiveDevice(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		archiveDevice,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) TransferDevice(ctx context.Context, id, projectID, targetProjectID string) (*models.Device, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		transferDevice,
+		targetProjectID,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+// MoveDevice moves a device, along with its environment, access keys and
+// session recordings, to another project. Application statuses and group
+// memberships are dropped since they refer to the previous project's
+// applications and groups.
+func (s *Store) MoveDevice(ctx context.Context, id, projectID, targetProjectID string) (*models.Device, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		deleteDeviceServiceStatusesForDevice,
+		deleteDeviceApplicationStatusesForDevice,
+		deleteDeviceGroupMembersForDevice,
+		deleteDeviceReleasePinsForDevice,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, query := range []string{
+		moveDevice,
+		moveDeviceEnvironment,
+		moveDeviceAccessKeys,
+		moveDeviceSessionRecordings,
+	} {
+		if _, err := tx.ExecContext(ctx, query, targetProjectID, id, projectID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, targetProjectID)
+}
+
+func (s *Store) UpdateDeviceDesiredAgent(ctx context.Context, id, projectID, version, spec string) (*models.Device, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceDesiredAgent,
+		version,
+		spec,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+func (s *Store) SetDeviceInfo(ctx context.Context, id, projectID string, deviceInfo models.DeviceInfo) (*models.Device, error) {
+	infoBytes, err := json.Marshal(deviceInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		setDeviceInfo,
+		string(infoBytes),
+		deviceInfo.AgentVersion,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDevice(ctx, id, projectID)
+}
+
+func (s *Store) UpdateDeviceLastSeenAt(ctx context.Context, projectID, deviceID string) error {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceLastSeenAt,
+		projectID,
+		deviceID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteDevice(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDevice,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanDevice(scanner scanner) (*models.Device, error) {
+	var device models.Device
+	var infoString string
+	var labelsString string
+	if err := scanner.Scan(
+		&device.ID,
+		&device.CreatedAt,
+		&device.ProjectID,
+		&device.Name,
+		&device.RegistrationTokenID,
+		&device.DesiredAgentSpec,
+		&device.DesiredAgentVersion,
+		&infoString,
+		&labelsString,
+		&device.LastSeenAt,
+		&device.PendingApproval,
+		&device.Decommissioning,
+		&device.DecommissionedAt,
+		&device.TransferProjectID,
+	); err != nil {
+		return nil, err
+	}
+
+	if infoString != "" {
+		if err := json.Unmarshal([]byte(infoString), &device.Info); err != nil {
+			return nil, err
+		}
+	}
+
+	if labelsString == "" {
+		device.Labels = map[string]string{}
+	} else {
+		if err := json.Unmarshal([]byte(labelsString), &device.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return &device, nil
+}
+
+// deviceOfflineThreshold is how long after a device in the project was last
+// seen that it's offline.
+func (s *Store) deviceOfflineThreshold(ctx context.Context, projectID string) (time.Duration, error) {
+	heartbeatConfig, err := s.GetHeartbeatConfig(ctx, projectID)
+	if err != nil {
+		return 0, errors.Wrap(err, "get heartbeat config")
+	}
+	return heartbeatConfig.OfflineThreshold(), nil
+}
+
+// setDeviceReleases fills in the releases devices in the project report
+// running.
+func (s *Store) setDeviceReleases(ctx context.Context, projectID string, devices []models.Device) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	releaseRows, err := s.db.QueryContext(ctx, listDeviceReleases, projectID)
+	if err != nil {
+		return err
+	}
+	defer releaseRows.Close()
+
+	deviceReleases, err := s.scanDeviceReleases(releaseRows)
+	if err != nil {
+		return err
+	}
+
+	for i := range devices {
+		devices[i].Releases = deviceReleases[devices[i].ID]
+		if devices[i].Releases == nil {
+			devices[i].Releases = make(map[string]uint32)
+		}
+	}
+
+	return nil
+}
+
+// scanDeviceReleases returns the release numbers in rows of device IDs,
+// application names and release numbers, by device ID and then application
+// name.
+func (s *Store) scanDeviceReleases(releaseRows *sql.Rows) (map[string]map[string]uint32, error) {
+	deviceReleases := make(map[string]map[string]uint32)
+	for releaseRows.Next() {
+		var deviceID, applicationName string
+		var number uint32
+		if err := releaseRows.Scan(
+			&deviceID,
+			&applicationName,
+			&number,
+		); err != nil {
+			return nil, err
+		}
+
+		if deviceReleases[deviceID] == nil {
+			deviceReleases[deviceID] = make(map[string]uint32)
+		}
+		deviceReleases[deviceID][applicationName] = number
+	}
+
+	if err := releaseRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceReleases, nil
+}
+
+func setDeviceStatus(device *models.Device, offlineThreshold time.Duration, now time.Time) {
+	if now.After(device.LastSeenAt.Add(offlineThreshold)) {
+		device.Status = models.DeviceStatusOffline
+	} else {
+		device.Status = models.DeviceStatusOnline
+	}
+}
+
+func (s *Store) scanDeviceLabels(scanner scanner) (map[string]string, error) {
+	var labelsString string
+	if err := scanner.Scan(
+		&labelsString,
+	); err != nil {
+		return nil, err
+	}
+
+	var labels map[string]string
+	if labelsString == "" {
+		labels = map[string]string{}
+	} else {
+		if err := json.Unmarshal([]byte(labelsString), &labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return labels, nil
+}
+
+func (s *Store) ListAllDeviceLabelKeys(ctx context.Context, projectID string) ([]string, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		listAllDeviceLabels,
+		projectID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device labels")
+	}
+	defer rows.Close()
+
+	allDeviceLabels := make(map[string]bool)
+	for rows.Next() {
+		deviceLabels, err := s.scanDeviceLabels(rows)
+		if err != nil {
+			return nil, err
+		}
+		for k := range deviceLabels {
+			allDeviceLabels[k] = true
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allDeviceLabelKeys := make([]string, len(allDeviceLabels))
+	i := 0
+	for k := range allDeviceLabels {
+		allDeviceLabelKeys[i] = k
+		i++
+	}
+
+	return allDeviceLabelKeys, nil
+
+}
+
+func (s *Store) SetDeviceLabel(ctx context.Context, deviceID, projectID, key, value string) (*string, error) {
+	device, err := s.GetDevice(ctx, deviceID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Labels[key] = value
+
+	labelsString, err := json.Marshal(device.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceLabels,
+		labelsString,
+		deviceID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	device, err = s.GetDevice(ctx, deviceID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	v := device.Labels[key]
+	return &v, nil
+}
+
+func (s *Store) DeleteDeviceLabel(ctx context.Context, deviceID, projectID, key string) error {
+	device, err := s.GetDevice(ctx, deviceID, projectID)
+	if err != nil {
+		return err
+	}
+
+	delete(device.Labels, key)
+
+	labelsString, err := json.Marshal(device.Labels)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceLabels,
+		labelsString,
+		deviceID,
+		projectID,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) GetDeviceEnvironment(ctx context.Context, deviceID, projectID string) (map[string]string, error) {
+	var environmentString string
+	if err := s.db.QueryRowContext(
+		ctx,
+		getDeviceEnvironment,
+		deviceID,
+		projectID,
+	).Scan(&environmentString); err == sql.ErrNoRows {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	environment := make(map[string]string)
+	if err := json.Unmarshal([]byte(environmentString), &environment); err != nil {
+		return nil, err
+	}
+
+	return environment, nil
+}
+
+func (s *Store) setDeviceEnvironment(ctx context.Context, deviceID, projectID string, environment map[string]string) error {
+	environmentString, err := json.Marshal(environment)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setDeviceEnvironment,
+		deviceID,
+		projectID,
+		environmentString,
+	)
+	return err
+}
+
+func (s *Store) SetDeviceEnvironmentVariable(ctx context.Context, deviceID, projectID, key, value string) (*string, error) {
+	environment, err := s.GetDeviceEnvironment(ctx, deviceID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	environment[key] = value
+
+	if err := s.setDeviceEnvironment(ctx, deviceID, projectID, environment); err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+func (s *Store) DeleteDeviceEnvironmentVariable(ctx context.Context, deviceID, projectID, key string) error {
+	environment, err := s.GetDeviceEnvironment(ctx, deviceID, projectID)
+	if err != nil {
+		return err
+	}
+
+	delete(environment, key)
+
+	return s.setDeviceEnvironment(ctx, deviceID, projectID, environment)
+}
+
+func (s *Store) CreateDeviceRegistrationToken(ctx context.Context, projectID, name, description string, maxRegistrations *int, expiresAt *time.Time, namePrefix string, requireApproval bool) (*models.DeviceRegistrationToken, error) {
+	id := newDeviceRegistrationTokenID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createDeviceRegistrationToken,
+		id,
+		projectID,
+		name,
+		description,
+		maxRegistrations,
+		expiresAt,
+		namePrefix,
+		requireApproval,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceRegistrationToken(ctx, id, projectID)
+}
+
+func (s *Store) LookupDeviceRegistrationToken(ctx context.Context, name, projectID string) (*models.DeviceRegistrationToken, error) {
+	deviceRegistrationTokenRow := s.db.QueryRowContext(ctx, lookupDeviceRegistrationToken, name, projectID)
+
+	deviceRegistrationToken, err := s.scanDeviceRegistrationToken(deviceRegistrationTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceRegistrationTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceRegistrationToken, nil
+}
+
+func (s *Store) ListDeviceRegistrationTokens(ctx context.Context, projectID string) ([]models.DeviceRegistrationToken, error) {
+	tokenRows, err := s.db.QueryContext(ctx, listDeviceRegistrationTokens, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device registration tokens")
+	}
+	defer tokenRows.Close()
+
+	deviceRegistrationTokens := make([]models.DeviceRegistrationToken, 0)
+	for tokenRows.Next() {
+		deviceRegistrationToken, err := s.scanDeviceRegistrationToken(tokenRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceRegistrationTokens = append(deviceRegistrationTokens, *deviceRegistrationToken)
+	}
+
+	if err := tokenRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceRegistrationTokens, nil
+}
+
+func (s *Store) GetDeviceRegistrationToken(ctx context.Context, id, projectID string) (*models.DeviceRegistrationToken, error) {
+	deviceRegistrationTokenRow := s.db.QueryRowContext(ctx, getDeviceRegistrationToken, id, projectID)
+
+	deviceRegistrationToken, err := s.scanDeviceRegistrationToken(deviceRegistrationTokenRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceRegistrationTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceRegistrationToken, nil
+}
+
+func (s *Store) UpdateDeviceRegistrationToken(ctx context.Context, id, projectID, name, description string, maxRegistrations *int, expiresAt *time.Time, namePrefix string, requireApproval bool) (*models.DeviceRegistrationToken, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceRegistrationToken,
+		name,
+		description,
+		maxRegistrations,
+		expiresAt,
+		namePrefix,
+		requireApproval,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceRegistrationToken(ctx, id, projectID)
+}
+
+func (s *Store) DeleteDeviceRegistrationToken(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceRegistrationToken,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) SetDeviceRegistrationTokenLabel(ctx context.Context, deviceRegistrationTokenID, projectID, key, value string) (*string, error) {
+	deviceRegistrationToken, err := s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceRegistrationToken.Labels[key] = value
+
+	labelsString, err := json.Marshal(deviceRegistrationToken.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceRegistrationTokenLabels,
+		labelsString,
+		deviceRegistrationTokenID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	deviceRegistrationToken, err = s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	v := deviceRegistrationToken.Labels[key]
+	return &v, nil
+}
+
+func (s *Store) DeleteDeviceRegistrationTokenLabel(ctx context.Context, deviceRegistrationTokenID, projectID, key string) error {
+	deviceRegistrationToken, err := s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return err
+	}
+
+	delete(deviceRegistrationToken.Labels, key)
+
+	labelsString, err := json.Marshal(deviceRegistrationToken.Labels)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceRegistrationTokenLabels,
+		labelsString,
+		deviceRegistrationTokenID,
+		projectID,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) SetDeviceRegistrationTokenEnvironmentVariable(ctx context.Context, deviceRegistrationTokenID, projectID, key, value string) (*string, error) {
+	deviceRegistrationToken, err := s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceRegistrationToken.Environment[key] = value
+
+	environmentString, err := json.Marshal(deviceRegistrationToken.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceRegistrationTokenEnvironment,
+		environmentString,
+		deviceRegistrationTokenID,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	deviceRegistrationToken, err = s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	v := deviceRegistrationToken.Environment[key]
+	return &v, nil
+}
+
+func (s *Store) DeleteDeviceRegistrationTokenEnvironmentVariable(ctx context.Context, deviceRegistrationTokenID, projectID, key string) error {
+	deviceRegistrationToken, err := s.GetDeviceRegistrationToken(ctx, deviceRegistrationTokenID, projectID)
+	if err != nil {
+		return err
+	}
+
+	delete(deviceRegistrationToken.Environment, key)
+
+	environmentString, err := json.Marshal(deviceRegistrationToken.Environment)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceRegistrationTokenEnvironment,
+		environmentString,
+		deviceRegistrationTokenID,
+		projectID,
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) scanDeviceRegistrationToken(scanner scanner) (*models.DeviceRegistrationToken, error) {
+	var deviceRegistrationToken models.DeviceRegistrationToken
+	var labelsString string
+	var environmentString string
+	if err := scanner.Scan(
+		&deviceRegistrationToken.ID,
+		&deviceRegistrationToken.CreatedAt,
+		&deviceRegistrationToken.ProjectID,
+		&deviceRegistrationToken.MaxRegistrations,
+		&deviceRegistrationToken.ExpiresAt,
+		&deviceRegistrationToken.Name,
+		&deviceRegistrationToken.Description,
+		&labelsString,
+		&deviceRegistrationToken.NamePrefix,
+		&environmentString,
+		&deviceRegistrationToken.RequireApproval,
+	); err != nil {
+		return nil, err
+	}
+
+	if labelsString == "" {
+		deviceRegistrationToken.Labels = map[string]string{}
+	} else {
+		if err := json.Unmarshal([]byte(labelsString), &deviceRegistrationToken.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if environmentString == "" {
+		deviceRegistrationToken.Environment = map[string]string{}
+	} else {
+		if err := json.Unmarshal([]byte(environmentString), &deviceRegistrationToken.Environment); err != nil {
+			return nil, err
+		}
+	}
+
+	return &deviceRegistrationToken, nil
+}
+
+func (s *Store) GetDevicesRegisteredWithTokenCount(ctx context.Context, tokenID, projectID string) (*models.DevicesRegisteredWithTokenCount, error) {
+	countRow := s.db.QueryRowContext(ctx, getDevicesRegisteredWithTokenCount, tokenID, projectID)
+
+	count, err := s.scanDevicesRegisteredCountRow(countRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceRegistrationTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &models.DevicesRegisteredWithTokenCount{
+		AllCount: count,
+	}, nil
+}
+
+func (s *Store) scanDevicesRegisteredCountRow(scanner scanner) (int, error) {
+	var count int
+	if err := scanner.Scan(
+		&count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) CreateDeviceAccessKey(ctx context.Context, projectID, deviceID, hash string) (*models.DeviceAccessKey, error) {
+	id := newDeviceAccessKeyID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createDeviceAccessKey,
+		id,
+		projectID,
+		deviceID,
+		hash,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceAccessKey(ctx, id, projectID)
+}
+
+func (s *Store) DeleteDeviceAccessKeys(ctx context.Context, deviceID, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceAccessKeys,
+		deviceID,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceAccessKey(ctx context.Context, id, projectID string) (*models.DeviceAccessKey, error) {
+	deviceAccessKeyRow := s.db.QueryRowContext(ctx, getDeviceAccessKey, id, projectID)
+
+	deviceAccessKey, err := s.scanDeviceAccessKey(deviceAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceAccessKey, nil
+}
+
+func (s *Store) ValidateDeviceAccessKey(ctx context.Context, projectID, hash string) (*models.DeviceAccessKey, error) {
+	deviceAccessKeyRow := s.db.QueryRowContext(ctx, validateDeviceAccessKey, projectID, hash)
+
+	deviceAccessKey, err := s.scanDeviceAccessKey(deviceAccessKeyRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceAccessKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceAccessKey, nil
+}
+
+func (s *Store) scanDeviceAccessKey(scanner scanner) (*models.DeviceAccessKey, error) {
+	var deviceAccessKey models.DeviceAccessKey
+	if err := scanner.Scan(
+		&deviceAccessKey.ID,
+		&deviceAccessKey.CreatedAt,
+		&deviceAccessKey.ProjectID,
+		&deviceAccessKey.DeviceID,
+	); err != nil {
+		return nil, err
+	}
+	return &deviceAccessKey, nil
+}
+
+func (s *Store) CreateApplication(ctx context.Context, projectID, name, description string) (*models.Application, error) {
+	id := newApplicationID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createApplication,
+		id,
+		projectID,
+		name,
+		description,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplication(ctx, id, projectID)
+}
+
+func (s *Store) GetApplication(ctx context.Context, id, projectID string) (*models.Application, error) {
+	applicationRow := s.db.QueryRowContext(ctx, getApplication, id, projectID)
+
+	application, err := s.scanApplication(applicationRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrApplicationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func (s *Store) LookupApplication(ctx context.Context, name, projectID string) (*models.Application, error) {
+	applicationRow := s.db.QueryRowContext(ctx, lookupApplication, name, projectID)
+
+	application, err := s.scanApplication(applicationRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrApplicationNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return application, nil
+}
+
+func (s *Store) ListApplications(ctx context.Context, projectID string) ([]models.Application, error) {
+	applicationRows, err := s.db.QueryContext(ctx, listApplications, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query applications")
+	}
+	defer applicationRows.Close()
+
+	applications := make([]models.Application, 0)
+	for applicationRows.Next() {
+		application, err := s.scanApplication(applicationRows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, *application)
+	}
+
+	if err := applicationRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func (s *Store) ListApplicationsPage(ctx context.Context, projectID, after string, limit int) ([]models.Application, error) {
+	applicationRows, err := s.db.QueryContext(ctx, listApplicationsPage, projectID, after, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "query applications")
+	}
+	defer applicationRows.Close()
+
+	applications := make([]models.Application, 0)
+	for applicationRows.Next() {
+		application, err := s.scanApplication(applicationRows)
+		if err != nil {
+			return nil, err
+		}
+		applications = append(applications, *application)
+	}
+
+	if err := applicationRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+func (s *Store) UpdateApplicationName(ctx context.Context, id, projectID, name string) (*models.Application, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateApplicationName,
+		name,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplication(ctx, id, projectID)
+}
+
+func (s *Store) UpdateApplicationDescription(ctx context.Context, id, projectID, description string) (*models.Application, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateApplicationDescription,
+		description,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplication(ctx, id, projectID)
+}
+
+func (s *Store) UpdateApplicationSchedulingRule(ctx context.Context, id, projectID string, schedulingRule models.SchedulingRule) (*models.Application, error) {
+	schedulingRuleBytes, err := json.Marshal(schedulingRule)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateApplicationSchedulingRule,
+		string(schedulingRuleBytes),
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplication(ctx, id, projectID)
+}
+
+func (s *Store) UpdateApplicationMetricEndpointConfigs(ctx context.Context, id, projectID string, metricEndpointConfigs map[string]models.MetricEndpointConfig) (*models.Application, error) {
+	metricEndpointConfigsBytes, err := json.Marshal(metricEndpointConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateApplicationMetricEndpointConfigs,
+		string(metricEndpointConfigsBytes),
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplication(ctx, id, projectID)
+}
+
+func (s *Store) DeleteApplication(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteApplication,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanApplication(scanner scanner) (*models.Application, error) {
+	var schedulingRuleStr string
+	var metricEndpointConfigsStr string
+
+	var application models.Application
+	if err := scanner.Scan(
+		&application.ID,
+		&application.CreatedAt,
+		&application.ProjectID,
+		&application.Name,
+		&application.Description,
+		&schedulingRuleStr,
+		&metricEndpointConfigsStr,
+	); err != nil {
+		return nil, err
+	}
+
+	if schedulingRuleStr == "" {
+		application.SchedulingRule = models.SchedulingRule{
+			ScheduleType:     models.ScheduleTypeNoDevices,
+			DefaultReleaseID: models.LatestRelease,
+			ConditionalQuery: nil,
+			ReleaseSelectors: []models.ReleaseSelector{},
+		}
+	} else {
+		err := json.Unmarshal([]byte(schedulingRuleStr), &application.SchedulingRule)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if metricEndpointConfigsStr == "" {
+		application.MetricEndpointConfigs = make(map[string]models.MetricEndpointConfig)
+	} else {
+		err := json.Unmarshal([]byte(metricEndpointConfigsStr), &application.MetricEndpointConfigs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &application, nil
+}
+
+func (s *Store) GetApplicationDeviceCounts(ctx context.Context, projectID, applicationID string) (*models.ApplicationDeviceCounts, error) {
+	countRow := s.db.QueryRowContext(ctx, getApplicationDeviceCounts, projectID, applicationID)
+
+	count, err := s.scanApplicationDeviceCountRow(countRow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ApplicationDeviceCounts{
+		AllCount: count,
+	}, nil
+}
+
+func (s *Store) scanApplicationDeviceCountRow(scanner scanner) (int, error) {
+	var count int
+	if err := scanner.Scan(
+		&count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) CreateRelease(ctx context.Context, projectID, applicationID, yamlConfig, jsonConfig, sourceReleaseID, notes, source, idempotencyKey, createdByUserID, createdByServiceAccountID string) (*models.Release, error) {
+	id := newReleaseID()
+
+	// Releases without a key are null, which the unique index doesn't
+	// compare
+	var idempotencyKeyNullable *string
+	if idempotencyKey != "" {
+		idempotencyKeyNullable = &idempotencyKey
+	}
+
+	var createdByUserIDNullable *string
+	if createdByUserID != "" {
+		createdByUserIDNullable = &createdByUserID
+	}
+	var createdByServiceAccountIDNullable *string
+	if createdByServiceAccountID != "" {
+		createdByServiceAccountIDNullable = &createdByServiceAccountID
+	}
+
+	lastRelease, err := s.GetLatestRelease(ctx, projectID, applicationID)
+	var number uint32
+	if err != nil && err != store.ErrReleaseNotFound {
+		return nil, err
+	}
+	if err == store.ErrReleaseNotFound {
+		number = 1
+	} else {
+		number = lastRelease.Number + 1
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createRelease,
+		id,
+		number,
+		projectID,
+		applicationID,
+		jsonConfig,
+		yamlConfig,
+		sourceReleaseID,
+		notes,
+		source,
+		createdByUserIDNullable,
+		createdByServiceAccountIDNullable,
+		idempotencyKeyNullable,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetRelease(ctx, id, projectID, applicationID)
+}
+
+func (s *Store) GetReleaseByIdempotencyKey(ctx context.Context, projectID, applicationID, idempotencyKey string) (*models.Release, error) {
+	releaseRow := s.db.QueryRowContext(ctx, getReleaseByIdempotencyKey, applicationID, idempotencyKey, projectID)
+
+	release, err := s.scanRelease(releaseRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrReleaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (s *Store) GetRelease(ctx context.Context, id, projectID, applicationID string) (*models.Release, error) {
+	applicationRow := s.db.QueryRowContext(ctx, getRelease, id, projectID, applicationID)
+
+	release, err := s.scanRelease(applicationRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrReleaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (s *Store) GetReleaseByNumber(ctx context.Context, number uint32, projectID, applicationID string) (*models.Release, error) {
+	applicationRow := s.db.QueryRowContext(ctx, getReleaseByNumber, number, projectID, applicationID)
+
+	release, err := s.scanRelease(applicationRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrReleaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (s *Store) GetLatestRelease(ctx context.Context, projectID, applicationID string) (*models.Release, error) {
+	applicationRow := s.db.QueryRowContext(ctx, getLatestRelease, projectID, applicationID)
+
+	release, err := s.scanRelease(applicationRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrReleaseNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return release, nil
+}
+
+func (s *Store) ListReleases(ctx context.Context, projectID, applicationID string) ([]models.Release, error) {
+	releaseRows, err := s.db.QueryContext(ctx, listReleases, projectID, applicationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query releases")
+	}
+	defer releaseRows.Close()
+
+	releases := make([]models.Release, 0)
+	for releaseRows.Next() {
+		release, err := s.scanRelease(releaseRows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, *release)
+	}
+
+	if err := releaseRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+func (s *Store) ListReleasesPage(ctx context.Context, projectID, applicationID, after string, limit int) ([]models.Release, error) {
+	releaseRows, err := s.db.QueryContext(ctx, listReleasesPage, projectID, applicationID, after, after, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "query releases")
+	}
+	defer releaseRows.Close()
+
+	releases := make([]models.Release, 0)
+	for releaseRows.Next() {
+		release, err := s.scanRelease(releaseRows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, *release)
+	}
+
+	if err := releaseRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+func (s *Store) GetReleaseCount(ctx context.Context, projectID, applicationID string) (int, error) {
+	var count int
+	if err := s.db.QueryRowContext(ctx, getReleaseCount, projectID, applicationID).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) scanRelease(scanner scanner) (*models.Release, error) {
+	var jsonConfig string
+	var idempotencyKey *string
+	var release models.Release
+	if err := scanner.Scan(
+		&release.ID,
+		&release.Number,
+		&release.CreatedAt,
+		&release.ProjectID,
+		&release.ApplicationID,
+		&jsonConfig,
+		&release.RawConfig,
+		&release.SourceReleaseID,
+		&release.Notes,
+		&release.Source,
+		&release.CreatedByUserID,
+		&release.CreatedByServiceAccountID,
+		&idempotencyKey,
+	); err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != nil {
+		release.IdempotencyKey = *idempotencyKey
+	}
+
+	var parsedConfig map[string]models.Service
+	if len(jsonConfig) == 0 {
+		if err := yaml.UnmarshalStrict([]byte(release.RawConfig), &parsedConfig); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := json.Unmarshal([]byte(jsonConfig), &parsedConfig); err != nil {
+			return nil, err
+		}
+	}
+	release.Config = parsedConfig
+
+	return &release, nil
+}
+
+func (s *Store) GetReleaseDeviceCounts(ctx context.Context, projectID, applicationID, releaseID string) (*models.ReleaseDeviceCounts, error) {
+	countRow := s.db.QueryRowContext(ctx, getReleaseDeviceCounts, projectID, applicationID, releaseID)
+
+	count, err := s.scanReleaseDeviceCountRow(countRow)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ReleaseDeviceCounts{
+		AllCount: count,
+	}, nil
+}
+
+func (s *Store) scanReleaseDeviceCountRow(scanner scanner) (int, error) {
+	var count int
+	if err := scanner.Scan(
+		&count,
+	); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (s *Store) CreateEnvironmentFile(ctx context.Context, projectID, name, description, content string) (*models.EnvironmentFile, error) {
+	id := newEnvironmentFileID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createEnvironmentFile,
+		id,
+		projectID,
+		name,
+		description,
+		content,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetEnvironmentFile(ctx, id, projectID)
+}
+
+func (s *Store) GetEnvironmentFile(ctx context.Context, id, projectID string) (*models.EnvironmentFile, error) {
+	environmentFileRow := s.db.QueryRowContext(ctx, getEnvironmentFile, id, projectID)
+
+	environmentFile, err := s.scanEnvironmentFile(environmentFileRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrEnvironmentFileNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return environmentFile, nil
+}
+
+func (s *Store) LookupEnvironmentFile(ctx context.Context, name, projectID string) (*models.EnvironmentFile, error) {
+	environmentFileRow := s.db.QueryRowContext(ctx, lookupEnvironmentFile, name, projectID)
+
+	environmentFile, err := s.scanEnvironmentFile(environmentFileRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrEnvironmentFileNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return environmentFile, nil
+}
+
+func (s *Store) ListEnvironmentFiles(ctx context.Context, projectID string) ([]models.EnvironmentFile, error) {
+	environmentFileRows, err := s.db.QueryContext(ctx, listEnvironmentFiles, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query environment files")
+	}
+	defer environmentFileRows.Close()
+
+	environmentFiles := make([]models.EnvironmentFile, 0)
+	for environmentFileRows.Next() {
+		environmentFile, err := s.scanEnvironmentFile(environmentFileRows)
+		if err != nil {
+			return nil, err
+		}
+		environmentFiles = append(environmentFiles, *environmentFile)
+	}
+
+	if err := environmentFileRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return environmentFiles, nil
+}
+
+func (s *Store) UpdateEnvironmentFile(ctx context.Context, id, projectID, name, description, content string) (*models.EnvironmentFile, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateEnvironmentFile,
+		name,
+		description,
+		content,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetEnvironmentFile(ctx, id, projectID)
+}
+
+func (s *Store) DeleteEnvironmentFile(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteEnvironmentFile,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanEnvironmentFile(scanner scanner) (*models.EnvironmentFile, error) {
+	var environmentFile models.EnvironmentFile
+	if err := scanner.Scan(
+		&environmentFile.ID,
+		&environmentFile.CreatedAt,
+		&environmentFile.ProjectID,
+		&environmentFile.Name,
+		&environmentFile.Description,
+		&environmentFile.Content,
+	); err != nil {
+		return nil, err
+	}
+	return &environmentFile, nil
+}
+
+func (s *Store) CreateConfigFile(ctx context.Context, projectID string, configFile models.ConfigFile) (*models.ConfigFile, error) {
+	id := newConfigFileID()
+
+	restartServicesString, err := json.Marshal(configFile.RestartServices)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createConfigFile,
+		id,
+		projectID,
+		configFile.Name,
+		configFile.Description,
+		configFile.Path,
+		configFile.Content,
+		configFile.Mode,
+		configFile.Owner,
+		string(restartServicesString),
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetConfigFile(ctx, id, projectID)
+}
+
+func (s *Store) GetConfigFile(ctx context.Context, id, projectID string) (*models.ConfigFile, error) {
+	configFileRow := s.db.QueryRowContext(ctx, getConfigFile, id, projectID)
+
+	configFile, err := s.scanConfigFile(configFileRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrConfigFileNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return configFile, nil
+}
+
+func (s *Store) LookupConfigFile(ctx context.Context, name, projectID string) (*models.ConfigFile, error) {
+	configFileRow := s.db.QueryRowContext(ctx, lookupConfigFile, name, projectID)
+
+	configFile, err := s.scanConfigFile(configFileRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrConfigFileNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return configFile, nil
+}
+
+func (s *Store) ListConfigFiles(ctx context.Context, projectID string) ([]models.ConfigFile, error) {
+	configFileRows, err := s.db.QueryContext(ctx, listConfigFiles, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query config files")
+	}
+	defer configFileRows.Close()
+
+	configFiles := make([]models.ConfigFile, 0)
+	for configFileRows.Next() {
+		configFile, err := s.scanConfigFile(configFileRows)
+		if err != nil {
+			return nil, err
+		}
+		configFiles = append(configFiles, *configFile)
+	}
+
+	if err := configFileRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return configFiles, nil
+}
+
+func (s *Store) UpdateConfigFile(ctx context.Context, id, projectID string, configFile models.ConfigFile) (*models.ConfigFile, error) {
+	restartServicesString, err := json.Marshal(configFile.RestartServices)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateConfigFile,
+		configFile.Name,
+		configFile.Description,
+		configFile.Path,
+		configFile.Content,
+		configFile.Mode,
+		configFile.Owner,
+		string(restartServicesString),
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetConfigFile(ctx, id, projectID)
+}
+
+func (s *Store) DeleteConfigFile(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteConfigFile,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanConfigFile(scanner scanner) (*models.ConfigFile, error) {
+	var configFile models.ConfigFile
+	var restartServicesString string
+	if err := scanner.Scan(
+		&configFile.ID,
+		&configFile.CreatedAt,
+		&configFile.ProjectID,
+		&configFile.Name,
+		&configFile.Description,
+		&configFile.Path,
+		&configFile.Content,
+		&configFile.Mode,
+		&configFile.Owner,
+		&restartServicesString,
+	); err != nil {
+		return nil, err
+	}
+
+	configFile.RestartServices = make([]string, 0)
+	if err := json.Unmarshal([]byte(restartServicesString), &configFile.RestartServices); err != nil {
+		return nil, err
+	}
+	if configFile.RestartServices == nil {
+		configFile.RestartServices = make([]string, 0)
+	}
+
+	return &configFile, nil
+}
+
+func (s *Store) CreateDeviceGroup(ctx context.Context, projectID, name, description string, query *models.Query) (*models.DeviceGroup, error) {
+	id := newDeviceGroupID()
+
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createDeviceGroup,
+		id,
+		projectID,
+		name,
+		description,
+		string(queryBytes),
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceGroup(ctx, id, projectID)
+}
+
+func (s *Store) GetDeviceGroup(ctx context.Context, id, projectID string) (*models.DeviceGroup, error) {
+	deviceGroupRow := s.db.QueryRowContext(ctx, getDeviceGroup, id, projectID)
+
+	deviceGroup, err := s.scanDeviceGroup(deviceGroupRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceGroupNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceGroup, nil
+}
+
+func (s *Store) LookupDeviceGroup(ctx context.Context, name, projectID string) (*models.DeviceGroup, error) {
+	deviceGroupRow := s.db.QueryRowContext(ctx, lookupDeviceGroup, name, projectID)
+
+	deviceGroup, err := s.scanDeviceGroup(deviceGroupRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceGroupNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceGroup, nil
+}
+
+func (s *Store) ListDeviceGroups(ctx context.Context, projectID string) ([]models.DeviceGroup, error) {
+	deviceGroupRows, err := s.db.QueryContext(ctx, listDeviceGroups, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device groups")
+	}
+	defer deviceGroupRows.Close()
+
+	deviceGroups := make([]models.DeviceGroup, 0)
+	for deviceGroupRows.Next() {
+		deviceGroup, err := s.scanDeviceGroup(deviceGroupRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceGroups = append(deviceGroups, *deviceGroup)
+	}
+
+	if err := deviceGroupRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceGroups, nil
+}
+
+func (s *Store) UpdateDeviceGroup(ctx context.Context, id, projectID, name, description string, query *models.Query) (*models.DeviceGroup, error) {
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceGroup,
+		name,
+		description,
+		string(queryBytes),
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceGroup(ctx, id, projectID)
+}
+
+func (s *Store) DeleteDeviceGroup(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceGroup,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) AddDeviceGroupMember(ctx context.Context, id, projectID, deviceID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		addDeviceGroupMember,
+		projectID,
+		id,
+		deviceID,
+	)
+	return err
+}
+
+func (s *Store) RemoveDeviceGroupMember(ctx context.Context, id, projectID, deviceID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		removeDeviceGroupMember,
+		id,
+		deviceID,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) ListDeviceGroupMembers(ctx context.Context, projectID string) ([]models.DeviceGroupMember, error) {
+	memberRows, err := s.db.QueryContext(ctx, listDeviceGroupMembers, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device group members")
+	}
+	defer memberRows.Close()
+
+	members := make([]models.DeviceGroupMember, 0)
+	for memberRows.Next() {
+		var member models.DeviceGroupMember
+		if err := memberRows.Scan(
+			&member.ProjectID,
+			&member.DeviceGroupID,
+			&member.DeviceID,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err := memberRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *Store) scanDeviceGroup(scanner scanner) (*models.DeviceGroup, error) {
+	var deviceGroup models.DeviceGroup
+	var queryString string
+	if err := scanner.Scan(
+		&deviceGroup.ID,
+		&deviceGroup.CreatedAt,
+		&deviceGroup.ProjectID,
+		&deviceGroup.Name,
+		&deviceGroup.Description,
+		&queryString,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(queryString), &deviceGroup.Query); err != nil {
+		return nil, err
+	}
+
+	return &deviceGroup, nil
+}
+
+func (s *Store) CreateDeviceView(ctx context.Context, projectID, name, description string, query models.Query, columns []models.DeviceViewColumn, createdByUserID, createdByServiceAccountID string) (*models.DeviceView, error) {
+	id := newDeviceViewID()
+
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	columnsBytes, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdByUserIDNullable *string
+	if createdByUserID != "" {
+		createdByUserIDNullable = &createdByUserID
+	}
+	var createdByServiceAccountIDNullable *string
+	if createdByServiceAccountID != "" {
+		createdByServiceAccountIDNullable = &createdByServiceAccountID
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createDeviceView,
+		id,
+		projectID,
+		name,
+		description,
+		string(queryBytes),
+		string(columnsBytes),
+		createdByUserIDNullable,
+		createdByServiceAccountIDNullable,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceView(ctx, id, projectID)
+}
+
+func (s *Store) GetDeviceView(ctx context.Context, id, projectID string) (*models.DeviceView, error) {
+	deviceViewRow := s.db.QueryRowContext(ctx, getDeviceView, id, projectID)
+
+	deviceView, err := s.scanDeviceView(deviceViewRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceViewNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceView, nil
+}
+
+func (s *Store) LookupDeviceView(ctx context.Context, name, projectID string) (*models.DeviceView, error) {
+	deviceViewRow := s.db.QueryRowContext(ctx, lookupDeviceView, name, projectID)
+
+	deviceView, err := s.scanDeviceView(deviceViewRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceViewNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceView, nil
+}
+
+func (s *Store) ListDeviceViews(ctx context.Context, projectID string) ([]models.DeviceView, error) {
+	deviceViewRows, err := s.db.QueryContext(ctx, listDeviceViews, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device views")
+	}
+	defer deviceViewRows.Close()
+
+	deviceViews := make([]models.DeviceView, 0)
+	for deviceViewRows.Next() {
+		deviceView, err := s.scanDeviceView(deviceViewRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceViews = append(deviceViews, *deviceView)
+	}
+
+	if err := deviceViewRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceViews, nil
+}
+
+func (s *Store) UpdateDeviceView(ctx context.Context, id, projectID, name, description string, query models.Query, columns []models.DeviceViewColumn) (*models.DeviceView, error) {
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	columnsBytes, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateDeviceView,
+		name,
+		description,
+		string(queryBytes),
+		string(columnsBytes),
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceView(ctx, id, projectID)
+}
+
+func (s *Store) DeleteDeviceView(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceView,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) scanDeviceView(scanner scanner) (*models.DeviceView, error) {
+	var deviceView models.DeviceView
+	var queryString string
+	var columnsString string
+	if err := scanner.Scan(
+		&deviceView.ID,
+		&deviceView.CreatedAt,
+		&deviceView.ProjectID,
+		&deviceView.Name,
+		&deviceView.Description,
+		&queryString,
+		&columnsString,
+		&deviceView.CreatedByUserID,
+		&deviceView.CreatedByServiceAccountID,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(queryString), &deviceView.Query); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(columnsString), &deviceView.Columns); err != nil {
+		return nil, err
+	}
+
+	if deviceView.Query == nil {
+		deviceView.Query = make(models.Query, 0)
+	}
+	if len(deviceView.Columns) == 0 {
+		deviceView.Columns = models.DefaultDeviceViewColumns
+	}
+
+	return &deviceView, nil
+}
+
+func (s *Store) SetDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID, releaseID string) (*models.DeviceReleasePin, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		setDeviceReleasePin,
+		projectID,
+		deviceID,
+		applicationID,
+		releaseID,
+		releaseID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetDeviceReleasePin(ctx, projectID, deviceID, applicationID)
+}
+
+func (s *Store) GetDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID string) (*models.DeviceReleasePin, error) {
+	pinRow := s.db.QueryRowContext(ctx, getDeviceReleasePin, projectID, deviceID, applicationID)
+
+	pin, err := s.scanDeviceReleasePin(pinRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceReleasePinNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return pin, nil
+}
+
+func (s *Store) ListDeviceReleasePins(ctx context.Context, projectID, applicationID string) ([]models.DeviceReleasePin, error) {
+	pinRows, err := s.db.QueryContext(ctx, listDeviceReleasePins, projectID, applicationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device release pins")
+	}
+
+	return s.scanDeviceReleasePins(pinRows)
+}
+
+func (s *Store) ListDeviceReleasePinsByDevice(ctx context.Context, projectID, deviceID string) ([]models.DeviceReleasePin, error) {
+	pinRows, err := s.db.QueryContext(ctx, listDeviceReleasePinsByDevice, projectID, deviceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device release pins")
+	}
+
+	return s.scanDeviceReleasePins(pinRows)
+}
+
+func (s *Store) DeleteDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceReleasePin,
+		projectID,
+		deviceID,
+		applicationID,
+	)
+	return err
+}
+
+func (s *Store) scanDeviceReleasePins(pinRows *sql.Rows) ([]models.DeviceReleasePin, error) {
+	defer pinRows.Close()
+
+	pins := make([]models.DeviceReleasePin, 0)
+	for pinRows.Next() {
+		pin, err := s.scanDeviceReleasePin(pinRows)
+		if err != nil {
+			return nil, err
+		}
+		pins = append(pins, *pin)
+	}
+
+	if err := pinRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return pins, nil
+}
+
+func (s *Store) scanDeviceReleasePin(scanner scanner) (*models.DeviceReleasePin, error) {
+	var pin models.DeviceReleasePin
+	if err := scanner.Scan(
+		&pin.CreatedAt,
+		&pin.ProjectID,
+		&pin.DeviceID,
+		&pin.ApplicationID,
+		&pin.ReleaseID,
+	); err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+func (s *Store) SetApplicationGitSync(ctx context.Context, projectID, applicationID, repository, branch, path string) (*models.ApplicationGitSync, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		setApplicationGitSync,
+		projectID,
+		applicationID,
+		repository,
+		branch,
+		path,
+		repository,
+		branch,
+		path,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetApplicationGitSync(ctx, projectID, applicationID)
+}
+
+func (s *Store) GetApplicationGitSync(ctx context.Context, projectID, applicationID string) (*models.ApplicationGitSync, error) {
+	gitSyncRow := s.db.QueryRowContext(ctx, getApplicationGitSync, projectID, applicationID)
+
+	gitSync, err := s.scanApplicationGitSync(gitSyncRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrApplicationGitSyncNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return gitSync, nil
+}
+
+func (s *Store) ListAllApplicationGitSyncs(ctx context.Context) ([]models.ApplicationGitSync, error) {
+	gitSyncRows, err := s.db.QueryContext(ctx, listAllApplicationGitSyncs)
+	if err != nil {
+		return nil, errors.Wrap(err, "query application git syncs")
+	}
+	defer gitSyncRows.Close()
+
+	gitSyncs := make([]models.ApplicationGitSync, 0)
+	for gitSyncRows.Next() {
+		gitSync, err := s.scanApplicationGitSync(gitSyncRows)
+		if err != nil {
+			return nil, err
+		}
+		gitSyncs = append(gitSyncs, *gitSync)
+	}
+
+	if err := gitSyncRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return gitSyncs, nil
+}
+
+func (s *Store) UpdateApplicationGitSyncStatus(ctx context.Context, projectID, applicationID, lastCommit, lastError string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		updateApplicationGitSyncStatus,
+		lastCommit,
+		lastError,
+		projectID,
+		applicationID,
+	)
+	return err
+}
+
+func (s *Store) DeleteApplicationGitSync(ctx context.Context, projectID, applicationID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteApplicationGitSync,
+		projectID,
+		applicationID,
+	)
+	return err
+}
+
+func (s *Store) scanApplicationGitSync(scanner scanner) (*models.ApplicationGitSync, error) {
+	var gitSync models.ApplicationGitSync
+	if err := scanner.Scan(
+		&gitSync.CreatedAt,
+		&gitSync.ProjectID,
+		&gitSync.ApplicationID,
+		&gitSync.Repository,
+		&gitSync.Branch,
+		&gitSync.Path,
+		&gitSync.LastCommit,
+		&gitSync.LastSyncedAt,
+		&gitSync.LastError,
+	); err != nil {
+		return nil, err
+	}
+	return &gitSync, nil
+}
+
+func (s *Store) CreateRollout(ctx context.Context, projectID, applicationID, releaseID, previousReleaseID string, steps []models.RolloutStep, soakPeriod, maxFailurePercentage int) (*models.Rollout, error) {
+	id := newRolloutID()
+
+	stepsBytes, err := json.Marshal(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createRollout,
+		id,
+		projectID,
+		applicationID,
+		releaseID,
+		previousReleaseID,
+		string(stepsBytes),
+		soakPeriod,
+		maxFailurePercentage,
+		models.RolloutStatusInProgress,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetRollout(ctx, id, projectID, applicationID)
+}
+
+func (s *Store) GetRollout(ctx context.Context, id, projectID, applicationID string) (*models.Rollout, error) {
+	rolloutRow := s.db.QueryRowContext(ctx, getRollout, id, projectID, applicationID)
+
+	rollout, err := s.scanRollout(rolloutRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRolloutNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+func (s *Store) GetLatestRollout(ctx context.Context, projectID, applicationID string) (*models.Rollout, error) {
+	rolloutRow := s.db.QueryRowContext(ctx, getLatestRollout, projectID, applicationID)
+
+	rollout, err := s.scanRollout(rolloutRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRolloutNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+func (s *Store) ListRollouts(ctx context.Context, projectID, applicationID string) ([]models.Rollout, error) {
+	rolloutRows, err := s.db.QueryContext(ctx, listRollouts, projectID, applicationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query rollouts")
+	}
+
+	return s.scanRollouts(rolloutRows)
+}
+
+func (s *Store) ListRolloutsByStatus(ctx context.Context, status models.RolloutStatus) ([]models.Rollout, error) {
+	rolloutRows, err := s.db.QueryContext(ctx, listRolloutsByStatus, status)
+	if err != nil {
+		return nil, errors.Wrap(err, "query rollouts")
+	}
+
+	return s.scanRollouts(rolloutRows)
+}
+
+func (s *Store) UpdateRolloutStep(ctx context.Context, id, projectID string, step int) (*models.Rollout, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateRolloutStep,
+		step,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.getRolloutByID(ctx, id, projectID)
+}
+
+func (s *Store) UpdateRolloutStatus(ctx context.Context, id, projectID string, status models.RolloutStatus, statusReason string) (*models.Rollout, error) {
+	if _, err := s.db.ExecContext(
+		ctx,
+		updateRolloutStatus,
+		status,
+		statusReason,
+		id,
+		projectID,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.getRolloutByID(ctx, id, projectID)
+}
+
+func (s *Store) getRolloutByID(ctx context.Context, id, projectID string) (*models.Rollout, error) {
+	rolloutRow := s.db.QueryRowContext(ctx, getRolloutByID, id, projectID)
+
+	rollout, err := s.scanRollout(rolloutRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRolloutNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+func (s *Store) scanRollouts(rolloutRows *sql.Rows) ([]models.Rollout, error) {
+	defer rolloutRows.Close()
+
+	rollouts := make([]models.Rollout, 0)
+	for rolloutRows.Next() {
+		rollout, err := s.scanRollout(rolloutRows)
+		if err != nil {
+			return nil, err
+		}
+		rollouts = append(rollouts, *rollout)
+	}
+
+	if err := rolloutRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rollouts, nil
+}
+
+func (s *Store) scanRollout(scanner scanner) (*models.Rollout, error) {
+	var rollout models.Rollout
+	var stepsString string
+	if err := scanner.Scan(
+		&rollout.ID,
+		&rollout.CreatedAt,
+		&rollout.ProjectID,
+		&rollout.ApplicationID,
+		&rollout.ReleaseID,
+		&rollout.PreviousReleaseID,
+		&stepsString,
+		&rollout.SoakPeriod,
+		&rollout.MaxFailurePercentage,
+		&rollout.Step,
+		&rollout.StepStartedAt,
+		&rollout.Status,
+		&rollout.StatusReason,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(stepsString), &rollout.Steps); err != nil {
+		return nil, err
+	}
+
+	return &rollout, nil
+}
+
+func (s *Store) CreateSessionRecording(ctx context.Context, projectID, deviceID, kind, applicationID, service, createdByUserID, createdByServiceAccountID string) (*models.SessionRecording, error) {
+	id := newSessionRecordingID()
+
+	var createdByUserIDNullable *string
+	if createdByUserID != "" {
+		createdByUserIDNullable = &createdByUserID
+	}
+	var createdByServiceAccountIDNullable *string
+	if createdByServiceAccountID != "" {
+		createdByServiceAccountIDNullable = &createdByServiceAccountID
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createSessionRecording,
+		id,
+		projectID,
+		deviceID,
+		kind,
+		applicationID,
+		service,
+		createdByUserIDNullable,
+		createdByServiceAccountIDNullable,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetSessionRecording(ctx, id, projectID)
+}
+
+func (s *Store) GetSessionRecording(ctx context.Context, id, projectID string) (*models.SessionRecording, error) {
+	sessionRecordingRow := s.db.QueryRowContext(ctx, getSessionRecording, id, projectID)
+
+	sessionRecording, err := s.scanSessionRecording(sessionRecordingRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrSessionRecordingNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sessionRecording, nil
+}
+
+func (s *Store) ListSessionRecordings(ctx context.Context, projectID string) ([]models.SessionRecording, error) {
+	sessionRecordingRows, err := s.db.QueryContext(ctx, listSessionRecordings, projectID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query session recordings")
+	}
+	defer sessionRecordingRows.Close()
+
+	sessionRecordings := make([]models.SessionRecording, 0)
+	for sessionRecordingRows.Next() {
+		sessionRecording, err := s.scanSessionRecording(sessionRecordingRows)
+		if err != nil {
+			return nil, err
+		}
+		sessionRecordings = append(sessionRecordings, *sessionRecording)
+	}
+
+	if err := sessionRecordingRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessionRecordings, nil
+}
+
+func (s *Store) GetSessionRecordingContent(ctx context.Context, id, projectID string) ([]byte, error) {
+	var content []byte
+	err := s.db.QueryRowContext(ctx, getSessionRecordingContent, id, projectID).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrSessionRecordingNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// FinishSessionRecording only stores content once, and only from the device
+// the session was on.
+func (s *Store) FinishSessionRecording(ctx context.Context, id, projectID, deviceID string, content []byte) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		finishSessionRecording,
+		content,
+		id,
+		projectID,
+		deviceID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return store.ErrSessionRecordingNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) scanSessionRecording(scanner scanner) (*models.SessionRecording, error) {
+	var sessionRecording models.SessionRecording
+	if err := scanner.Scan(
+		&sessionRecording.ID,
+		&sessionRecording.CreatedAt,
+		&sessionRecording.ProjectID,
+		&sessionRecording.DeviceID,
+		&sessionRecording.Kind,
+		&sessionRecording.ApplicationID,
+		&sessionRecording.Service,
+		&sessionRecording.CreatedByUserID,
+		&sessionRecording.CreatedByServiceAccountID,
+		&sessionRecording.FinishedAt,
+		&sessionRecording.Size,
+	); err != nil {
+		return nil, err
+	}
+	return &sessionRecording, nil
+}
+
+func (s *Store) CreateAuditLogEntry(ctx context.Context, projectID, userID, serviceAccountID, action, method, path, summary, remoteAddr string, statusCode int, duration time.Duration) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		createAuditLogEntry,
+		newAuditLogEntryID(),
+		projectID,
+		userID,
+		serviceAccountID,
+		action,
+		method,
+		path,
+		summary,
+		remoteAddr,
+		statusCode,
+		int64(duration/time.Millisecond),
+	)
+	return err
+}
+
+func (s *Store) ListAuditLogEntries(ctx context.Context, projectID, userID, serviceAccountID, action, before string, since time.Time, limit int) ([]models.AuditLogEntry, error) {
+	auditLogEntryRows, err := s.db.QueryContext(
+		ctx,
+		listAuditLogEntries,
+		projectID,
+		userID, userID,
+		serviceAccountID, serviceAccountID,
+		action, action,
+		before, before,
+		since,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query audit log entries")
+	}
+	defer auditLogEntryRows.Close()
+
+	auditLogEntries := make([]models.AuditLogEntry, 0)
+	for auditLogEntryRows.Next() {
+		auditLogEntry, err := s.scanAuditLogEntry(auditLogEntryRows)
+		if err != nil {
+			return nil, err
+		}
+		auditLogEntries = append(auditLogEntries, *auditLogEntry)
+	}
+
+	if err := auditLogEntryRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return auditLogEntries, nil
+}
+
+func (s *Store) DeleteAuditLogEntriesBefore(ctx context.Context, projectID string, before time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteAuditLogEntriesBefore,
+		projectID,
+		before,
+	)
+	return err
+}
+
+func (s *Store) CreateDeviceConnectivityEvent(ctx context.Context, projectID, deviceID string, status models.DeviceStatus, at time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		createDeviceConnectivityEvent,
+		newDeviceConnectivityEventID(),
+		projectID,
+		deviceID,
+		status,
+		at,
+	)
+	return err
+}
+
+func (s *Store) ListDeviceConnectivityEvents(ctx context.Context, projectID, deviceID string, since time.Time) ([]models.DeviceConnectivityEvent, error) {
+	deviceConnectivityEventRows, err := s.db.QueryContext(
+		ctx,
+		listDeviceConnectivityEvents,
+		projectID,
+		deviceID,
+		since,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device connectivity events")
+	}
+	defer deviceConnectivityEventRows.Close()
+
+	deviceConnectivityEvents := make([]models.DeviceConnectivityEvent, 0)
+	for deviceConnectivityEventRows.Next() {
+		deviceConnectivityEvent, err := s.scanDeviceConnectivityEvent(deviceConnectivityEventRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceConnectivityEvents = append(deviceConnectivityEvents, *deviceConnectivityEvent)
+	}
+
+	if err := deviceConnectivityEventRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceConnectivityEvents, nil
+}
+
+func (s *Store) DeleteDeviceConnectivityEventsBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceConnectivityEventsBefore,
+		before,
+	)
+	return err
+}
+
+func (s *Store) scanDeviceConnectivityEvent(scanner scanner) (*models.DeviceConnectivityEvent, error) {
+	var deviceConnectivityEvent models.DeviceConnectivityEvent
+	if err := scanner.Scan(
+		&deviceConnectivityEvent.ID,
+		&deviceConnectivityEvent.ProjectID,
+		&deviceConnectivityEvent.DeviceID,
+		&deviceConnectivityEvent.Status,
+		&deviceConnectivityEvent.At,
+	); err != nil {
+		return nil, err
+	}
+	return &deviceConnectivityEvent, nil
+}
+
+func (s *Store) CreateAlert(ctx context.Context, projectID, rule, deviceID, summary string) (*models.Alert, error) {
+	id := newAlertID()
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createAlert,
+		id,
+		projectID,
+		rule,
+		deviceID,
+		summary,
+	); err != nil {
+		return nil, err
+	}
+
+	alertRow := s.db.QueryRowContext(ctx, getAlert, id, projectID)
+
+	return s.scanAlert(alertRow)
+}
+
+func (s *Store) ListFiringAlerts(ctx context.Context, projectID string) ([]models.Alert, error) {
+	alertRows, err := s.db.QueryContext(
+		ctx,
+		listFiringAlerts,
+		projectID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query alerts")
+	}
+
+	return s.scanAlerts(alertRows)
+}
+
+func (s *Store) ListAlerts(ctx context.Context, projectID string, since time.Time) ([]models.Alert, error) {
+	alertRows, err := s.db.QueryContext(
+		ctx,
+		listAlerts,
+		projectID,
+		since,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query alerts")
+	}
+
+	return s.scanAlerts(alertRows)
+}
+
+func (s *Store) ResolveAlert(ctx context.Context, id, projectID string, at time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		resolveAlert,
+		at,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) DeleteAlertsResolvedBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteAlertsResolvedBefore,
+		before,
+	)
+	return err
+}
+
+func (s *Store) scanAlerts(alertRows *sql.Rows) ([]models.Alert, error) {
+	defer alertRows.Close()
+
+	alerts := make([]models.Alert, 0)
+	for alertRows.Next() {
+		alert, err := s.scanAlert(alertRows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err := alertRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alerts, nil
+}
+
+func (s *Store) scanAlert(scanner scanner) (*models.Alert, error) {
+	var alert models.Alert
+	if err := scanner.Scan(
+		&alert.ID,
+		&alert.ProjectID,
+		&alert.Rule,
+		&alert.DeviceID,
+		&alert.Summary,
+		&alert.StartedAt,
+		&alert.ResolvedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (s *Store) CreateAlertSilence(ctx context.Context, projectID, rule, deviceID string, until time.Time, reason, createdByUserID, createdByServiceAccountID string) (*models.AlertSilence, error) {
+	id := newAlertSilenceID()
+
+	var createdByUserIDNullable *string
+	if createdByUserID != "" {
+		createdByUserIDNullable = &createdByUserID
+	}
+	var createdByServiceAccountIDNullable *string
+	if createdByServiceAccountID != "" {
+		createdByServiceAccountIDNullable = &createdByServiceAccountID
+	}
+
+	if _, err := s.db.ExecContext(
+		ctx,
+		createAlertSilence,
+		id,
+		projectID,
+		rule,
+		deviceID,
+		until,
+		reason,
+		createdByUserIDNullable,
+		createdByServiceAccountIDNullable,
+	); err != nil {
+		return nil, err
+	}
+
+	return s.GetAlertSilence(ctx, id, projectID)
+}
+
+func (s *Store) GetAlertSilence(ctx context.Context, id, projectID string) (*models.AlertSilence, error) {
+	alertSilenceRow := s.db.QueryRowContext(ctx, getAlertSilence, id, projectID)
+
+	alertSilence, err := s.scanAlertSilence(alertSilenceRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrAlertSilenceNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return alertSilence, nil
+}
+
+func (s *Store) ListAlertSilences(ctx context.Context, projectID string, now time.Time) ([]models.AlertSilence, error) {
+	alertSilenceRows, err := s.db.QueryContext(
+		ctx,
+		listAlertSilences,
+		projectID,
+		now,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query alert silences")
+	}
+	defer alertSilenceRows.Close()
+
+	alertSilences := make([]models.AlertSilence, 0)
+	for alertSilenceRows.Next() {
+		alertSilence, err := s.scanAlertSilence(alertSilenceRows)
+		if err != nil {
+			return nil, err
+		}
+		alertSilences = append(alertSilences, *alertSilence)
+	}
+
+	if err := alertSilenceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return alertSilences, nil
+}
+
+func (s *Store) DeleteAlertSilence(ctx context.Context, id, projectID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteAlertSilence,
+		id,
+		projectID,
+	)
+	return err
+}
+
+func (s *Store) DeleteAlertSilencesBefore(ctx context.Context, before time.Time) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteAlertSilencesBefore,
+		before,
+	)
+	return err
+}
+
+func (s *Store) scanAlertSilence(scanner scanner) (*models.AlertSilence, error) {
+	var alertSilence models.AlertSilence
+	if err := scanner.Scan(
+		&alertSilence.ID,
+		&alertSilence.CreatedAt,
+		&alertSilence.ProjectID,
+		&alertSilence.Rule,
+		&alertSilence.DeviceID,
+		&alertSilence.Until,
+		&alertSilence.Reason,
+		&alertSilence.CreatedByUserID,
+		&alertSilence.CreatedByServiceAccountID,
+	); err != nil {
+		return nil, err
+	}
+	return &alertSilence, nil
+}
+
+func (s *Store) scanAuditLogEntry(scanner scanner) (*models.AuditLogEntry, error) {
+	var auditLogEntry models.AuditLogEntry
+	if err := scanner.Scan(
+		&auditLogEntry.ID,
+		&auditLogEntry.CreatedAt,
+		&auditLogEntry.ProjectID,
+		&auditLogEntry.UserID,
+		&auditLogEntry.ServiceAccountID,
+		&auditLogEntry.Action,
+		&auditLogEntry.Method,
+		&auditLogEntry.Path,
+		&auditLogEntry.Summary,
+		&auditLogEntry.RemoteAddr,
+		&auditLogEntry.StatusCode,
+		&auditLogEntry.DurationMs,
+	); err != nil {
+		return nil, err
+	}
+	return &auditLogEntry, nil
+}
+
+func (s *Store) SetDeviceApplicationStatus(ctx context.Context, projectID, deviceID, applicationID, currentReleaseID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		setDeviceApplicationStatus,
+		projectID,
+		deviceID,
+		applicationID,
+		currentReleaseID,
+		currentReleaseID,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceApplicationStatus(ctx context.Context, projectID, deviceID, applicationID string) (*models.DeviceApplicationStatus, error) {
+	deviceApplicationStatusRow := s.db.QueryRowContext(ctx, getDeviceApplicationStatus, projectID, deviceID, applicationID)
+
+	deviceApplicationStatus, err := s.scanDeviceApplicationStatus(deviceApplicationStatusRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceApplicationStatusNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceApplicationStatus, nil
+}
+
+func (s *Store) ListDeviceApplicationStatuses(ctx context.Context, projectID, deviceID string) ([]models.DeviceApplicationStatus, error) {
+	deviceApplicationStatusRows, err := s.db.QueryContext(ctx, listDeviceApplicationStatuses, projectID, deviceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device application statuses")
+	}
+	defer deviceApplicationStatusRows.Close()
+
+	deviceApplicationStatuses := make([]models.DeviceApplicationStatus, 0)
+	for deviceApplicationStatusRows.Next() {
+		deviceApplicationStatus, err := s.scanDeviceApplicationStatus(deviceApplicationStatusRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceApplicationStatuses = append(deviceApplicationStatuses, *deviceApplicationStatus)
+	}
+
+	if err := deviceApplicationStatusRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceApplicationStatuses, nil
+}
+
+func (s *Store) DeleteDeviceApplicationStatus(ctx context.Context, projectID, deviceID, applicationID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceApplicationStatus,
+		projectID,
+		deviceID,
+		applicationID,
+	)
+	return err
+}
+
+func (s *Store) scanDeviceApplicationStatus(scanner scanner) (*models.DeviceApplicationStatus, error) {
+	var deviceApplicationStatus models.DeviceApplicationStatus
+	if err := scanner.Scan(
+		&deviceApplicationStatus.ProjectID,
+		&deviceApplicationStatus.DeviceID,
+		&deviceApplicationStatus.ApplicationID,
+		&deviceApplicationStatus.CurrentReleaseID,
+	); err != nil {
+		return nil, err
+	}
+	return &deviceApplicationStatus, nil
+}
+
+func (s *Store) SetDeviceServiceStatus(ctx context.Context, projectID, deviceID, applicationID, service, currentReleaseID string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		setDeviceServiceStatus,
+		projectID,
+		deviceID,
+		applicationID,
+		service,
+		currentReleaseID,
+		currentReleaseID,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceServiceStatus(ctx context.Context, projectID, deviceID, applicationID, service string) (*models.DeviceServiceStatus, error) {
+	deviceServiceStatusRow := s.db.QueryRowContext(ctx, getDeviceServiceStatus, projectID, deviceID, applicationID, service)
+
+	deviceServiceStatus, err := s.scanDeviceServiceStatus(deviceServiceStatusRow)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrDeviceApplicationStatusNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return deviceServiceStatus, nil
+}
+
+func (s *Store) GetDeviceServiceStatuses(ctx context.Context, projectID, deviceID, applicationID string) ([]models.DeviceServiceStatus, error) {
+	deviceServiceStatusRows, err := s.db.QueryContext(ctx, getDeviceServiceStatuses, projectID, deviceID, applicationID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device service statuses")
+	}
+	defer deviceServiceStatusRows.Close()
+
+	deviceServiceStatuses := make([]models.DeviceServiceStatus, 0)
+	for deviceServiceStatusRows.Next() {
+		deviceServiceStatus, err := s.scanDeviceServiceStatus(deviceServiceStatusRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceServiceStatuses = append(deviceServiceStatuses, *deviceServiceStatus)
+	}
+
+	if err := deviceServiceStatusRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceServiceStatuses, nil
+}
+
+func (s *Store) ListDeviceServiceStatuses(ctx context.Context, projectID, deviceID string) ([]models.DeviceServiceStatus, error) {
+	deviceServiceStatusRows, err := s.db.QueryContext(ctx, listDeviceServiceStatuses, projectID, deviceID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query device service statuses")
+	}
+	defer deviceServiceStatusRows.Close()
+
+	deviceServiceStatuses := make([]models.DeviceServiceStatus, 0)
+	for deviceServiceStatusRows.Next() {
+		deviceServiceStatus, err := s.scanDeviceServiceStatus(deviceServiceStatusRows)
+		if err != nil {
+			return nil, err
+		}
+		deviceServiceStatuses = append(deviceServiceStatuses, *deviceServiceStatus)
+	}
+
+	if err := deviceServiceStatusRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deviceServiceStatuses, nil
+}
+
+func (s *Store) DeleteDeviceServiceStatus(ctx context.Context, projectID, deviceID, applicationID, service string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceServiceStatus,
+		projectID,
+		deviceID,
+		applicationID,
+		service,
+	)
+	return err
+}
+
+func (s *Store) scanDeviceServiceStatus(scanner scanner) (*models.DeviceServiceStatus, error) {
+	var deviceServiceStatus models.DeviceServiceStatus
+	if err := scanner.Scan(
+		&deviceServiceStatus.ProjectID,
+		&deviceServiceStatus.DeviceID,
+		&deviceServiceStatus.ApplicationID,
+		&deviceServiceStatus.Service,
+		&deviceServiceStatus.CurrentReleaseID,
+	); err != nil {
+		return nil, err
+	}
+	return &deviceServiceStatus, nil
+}
+
+func (s *Store) SetDeviceConnection(ctx context.Context, key, controller string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		setDeviceConnection,
+		key,
+		controller,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceConnection(ctx context.Context, key string) (string, error) {
+	var controller string
+	err := s.db.QueryRowContext(ctx, getDeviceConnection, key).Scan(&controller)
+	if err == sql.ErrNoRows {
+		return "", store.ErrDeviceConnectionNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return controller, nil
+}
+
+func (s *Store) DeleteDeviceConnection(ctx context.Context, key, controller string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteDeviceConnection,
+		key,
+		controller,
+	)
+	return err
+}
+
+// StartRemoteSession counts the project's sessions and starts a new one in
+// a transaction that holds the project's lock, so that controllers starting
+// sessions at the same time can't go over the limit together.
+func (s *Store) StartRemoteSession(ctx context.Context, projectID string, limit int) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockProjectRemoteSessions, projectID); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiredBefore := now.Add(-store.RemoteSessionTimeout)
+
+	if _, err := tx.ExecContext(ctx, deleteExpiredRemoteSessions, projectID, expiredBefore); err != nil {
+		return "", err
+	}
+
+	var count int
+	if err := tx.QueryRowContext(ctx, countRemoteSessions, projectID, expiredBefore).Scan(&count); err != nil {
+		return "", err
+	}
+	if count >= limit {
+		return "", store.ErrRemoteSessionLimitReached
+	}
+
+	id := newRemoteSessionID()
+	if _, err := tx.ExecContext(ctx, createRemoteSession, id, projectID, now); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (s *Store) RefreshRemoteSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		refreshRemoteSession,
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+func (s *Store) EndRemoteSession(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		deleteRemoteSession,
+		id,
+	)
+	return err
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// TryLock uses a named lock, or an advisory lock in PostgreSQL, which belongs
+// to the connection that took it, so the connection is kept out of the pool
+// until the lock is released.
+func (s *Store) TryLock(ctx context.Context, name string) (func(), error) {
+	if s.db.dialect.localLocks {
+		return s.tryLocalLock(name)
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, tryLock, name).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		conn.Close()
+		return nil, store.ErrLockHeld
+	}
+
+	return func() {
+		conn.ExecContext(context.Background(), releaseLock, name)
+		conn.Close()
+	}, nil
+}
+
+func (s *Store) tryLocalLock(name string) (func(), error) {
+	s.localLocksLock.Lock()
+	defer s.localLocksLock.Unlock()
+
+	if _, ok := s.localLocks[name]; ok {
+		return nil, store.ErrLockHeld
+	}
+	s.localLocks[name] = struct{}{}
+
+	return func() {
+		s.localLocksLock.Lock()
+		delete(s.localLocks, name)
+		s.localLocksLock.Unlock()
+	}, nil
+}
+
+func (s *Store) scanProjectConfig(scanner scanner) (*models.ProjectConfig, error) {
+	var projectConfig models.ProjectConfig
+	if err := scanner.Scan(
+		&projectConfig.ProjectID,
+		&projectConfig.Key,
+		&projectConfig.Value,
+	); err != nil {
+		return nil, err
+	}
+
+	return &projectConfig, nil
+}
+
+func (s *Store) scanDeviceMetricsConfig(scanner scanner) (*models.DeviceMetricsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var dmc models.DeviceMetricsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &dmc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dmc, nil
+}
+
+func (s *Store) scanServiceMetricsConfig(scanner scanner) ([]models.ServiceMetricsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var smc []models.ServiceMetricsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &smc)
+	if err != nil {
+		return nil, err
+	}
+
+	return smc, nil
+}
+
+func (s *Store) scanProjectMetricsConfig(scanner scanner) (*models.ProjectMetricsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var pmc models.ProjectMetricsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &pmc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pmc, nil
+}
+
+func (s *Store) SetServiceMetricsConfigs(ctx context.Context, projectID string, value []models.ServiceMetricsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.ServiceMetricsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetServiceMetricsConfigs(ctx context.Context, projectID string) ([]models.ServiceMetricsConfig, error) {
+	smcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.ServiceMetricsConfigKey,
+	)
+
+	smc, err := s.scanServiceMetricsConfig(smcRow)
+	if err == sql.ErrNoRows {
+		return make([]models.ServiceMetricsConfig, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return smc, nil
+}
+
+func (s *Store) SetProjectMetricsConfig(ctx context.Context, projectID string, value models.ProjectMetricsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.ProjectMetricsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetProjectMetricsConfig(ctx context.Context, projectID string) (*models.ProjectMetricsConfig, error) {
+	pmcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.ProjectMetricsConfigKey,
+	)
+
+	pmc, err := s.scanProjectMetricsConfig(pmcRow)
+	if err == sql.ErrNoRows {
+		return &models.ProjectMetricsConfig{
+			ExposedMetrics: make([]models.ExposedMetric, 0),
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return pmc, nil
+}
+
+func (s *Store) SetDeviceMetricsConfig(ctx context.Context, projectID string, value models.DeviceMetricsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.DeviceMetricsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceMetricsConfig(ctx context.Context, projectID string) (*models.DeviceMetricsConfig, error) {
+	dmcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.DeviceMetricsConfigKey,
+	)
+
+	dmc, err := s.scanDeviceMetricsConfig(dmcRow)
+	if err == sql.ErrNoRows {
+		return &models.DeviceMetricsConfig{
+			ExposedMetrics: make([]models.ExposedMetric, 0),
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return dmc, nil
+}
+
+func (s *Store) scanSSHConfig(scanner scanner) (*models.SSHConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var sc models.SSHConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sc, nil
+}
+
+func (s *Store) SetSSHConfig(ctx context.Context, projectID string, value models.SSHConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.SSHConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetSSHConfig(ctx context.Context, projectID string) (*models.SSHConfig, error) {
+	scRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.SSHConfigKey,
+	)
+
+	sc, err := s.scanSSHConfig(scRow)
+	if err == sql.ErrNoRows {
+		return &models.SSHConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+func (s *Store) scanAgentRolloutConfig(scanner scanner) (*models.AgentRolloutConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var arc models.AgentRolloutConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &arc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &arc, nil
+}
+
+func (s *Store) SetAgentRolloutConfig(ctx context.Context, projectID string, value models.AgentRolloutConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.AgentRolloutConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetAgentRolloutConfig(ctx context.Context, projectID string) (*models.AgentRolloutConfig, error) {
+	arcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.AgentRolloutConfigKey,
+	)
+
+	arc, err := s.scanAgentRolloutConfig(arcRow)
+	if err == sql.ErrNoRows {
+		return &models.AgentRolloutConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return arc, nil
+}
+
+func (s *Store) scanDeviceEnvironmentConfig(scanner scanner) (*models.DeviceEnvironmentConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var dec models.DeviceEnvironmentConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dec, nil
+}
+
+func (s *Store) SetDeviceEnvironmentConfig(ctx context.Context, projectID string, value models.DeviceEnvironmentConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.DeviceEnvironmentConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceEnvironmentConfig(ctx context.Context, projectID string) (*models.DeviceEnvironmentConfig, error) {
+	decRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.DeviceEnvironmentConfigKey,
+	)
+
+	dec, err := s.scanDeviceEnvironmentConfig(decRow)
+	if err == sql.ErrNoRows {
+		return &models.DeviceEnvironmentConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return dec, nil
+}
+
+func (s *Store) scanMaintenanceWindowsConfig(scanner scanner) (*models.MaintenanceWindowsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var mwc models.MaintenanceWindowsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &mwc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mwc, nil
+}
+
+func (s *Store) SetMaintenanceWindowsConfig(ctx context.Context, projectID string, value models.MaintenanceWindowsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.MaintenanceWindowsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetMaintenanceWindowsConfig(ctx context.Context, projectID string) (*models.MaintenanceWindowsConfig, error) {
+	mwcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.MaintenanceWindowsConfigKey,
+	)
+
+	mwc, err := s.scanMaintenanceWindowsConfig(mwcRow)
+	if err == sql.ErrNoRows {
+		return &models.MaintenanceWindowsConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return mwc, nil
+}
+
+func (s *Store) scanReleaseWebhooksConfig(scanner scanner) (*models.ReleaseWebhooksConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var rwc models.ReleaseWebhooksConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &rwc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rwc, nil
+}
+
+func (s *Store) SetReleaseWebhooksConfig(ctx context.Context, projectID string, value models.ReleaseWebhooksConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.ReleaseWebhooksConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetReleaseWebhooksConfig(ctx context.Context, projectID string) (*models.ReleaseWebhooksConfig, error) {
+	rwcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.ReleaseWebhooksConfigKey,
+	)
+
+	rwc, err := s.scanReleaseWebhooksConfig(rwcRow)
+	if err == sql.ErrNoRows {
+		return &models.ReleaseWebhooksConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return rwc, nil
+}
+
+func (s *Store) scanEventWebhooksConfig(scanner scanner) (*models.EventWebhooksConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var ewc models.EventWebhooksConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &ewc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ewc, nil
+}
+
+func (s *Store) SetEventWebhooksConfig(ctx context.Context, projectID string, value models.EventWebhooksConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.EventWebhooksConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetEventWebhooksConfig(ctx context.Context, projectID string) (*models.EventWebhooksConfig, error) {
+	ewcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.EventWebhooksConfigKey,
+	)
+
+	ewc, err := s.scanEventWebhooksConfig(ewcRow)
+	if err == sql.ErrNoRows {
+		return &models.EventWebhooksConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ewc, nil
+}
+
+func (s *Store) scanAuditLogConfig(scanner scanner) (*models.AuditLogConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var alc models.AuditLogConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &alc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alc, nil
+}
+
+func (s *Store) SetAuditLogConfig(ctx context.Context, projectID string, value models.AuditLogConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.AuditLogConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetAuditLogConfig(ctx context.Context, projectID string) (*models.AuditLogConfig, error) {
+	alcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.AuditLogConfigKey,
+	)
+
+	alc, err := s.scanAuditLogConfig(alcRow)
+	if err == sql.ErrNoRows {
+		return &models.AuditLogConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return alc, nil
+}
+
+func (s *Store) SetSSOConfig(ctx context.Context, projectID string, value models.SSOConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setSSOConfig,
+		projectID,
+		value.Provider,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetSSOConfig(ctx context.Context, projectID string) (*models.SSOConfig, error) {
+	var config string
+	if err := s.db.QueryRowContext(
+		ctx,
+		getSSOConfig,
+		projectID,
+	).Scan(&config); err == sql.ErrNoRows {
+		return &models.SSOConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var sc models.SSOConfig
+	if err := json.Unmarshal([]byte(config), &sc); err != nil {
+		return nil, err
+	}
+
+	return &sc, nil
+}
+
+func (s *Store) ListSSOConfigsByProvider(ctx context.Context, provider string) (map[string]models.SSOConfig, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		listSSOConfigsByProvider,
+		provider,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query sso configs")
+	}
+	defer rows.Close()
+
+	configs := make(map[string]models.SSOConfig)
+	for rows.Next() {
+		var projectID, config string
+		if err := rows.Scan(&projectID, &config); err != nil {
+			return nil, err
+		}
+
+		var sc models.SSOConfig
+		if err := json.Unmarshal([]byte(config), &sc); err != nil {
+			return nil, err
+		}
+		configs[projectID] = sc
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return configs, nil
+}
+
+func (s *Store) scanLimitsConfig(scanner scanner) (*models.LimitsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var lc models.LimitsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &lc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lc, nil
+}
+
+func (s *Store) SetLimitsConfig(ctx context.Context, projectID string, value models.LimitsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.LimitsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetLimitsConfig(ctx context.Context, projectID string) (*models.LimitsConfig, error) {
+	lcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.LimitsConfigKey,
+	)
+
+	lc, err := s.scanLimitsConfig(lcRow)
+	if err == sql.ErrNoRows {
+		return &models.LimitsConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return lc, nil
+}
+
+func (s *Store) scanHeartbeatConfig(scanner scanner) (*models.HeartbeatConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var hc models.HeartbeatConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &hc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &hc, nil
+}
+
+func (s *Store) SetHeartbeatConfig(ctx context.Context, projectID string, value models.HeartbeatConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.HeartbeatConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetHeartbeatConfig(ctx context.Context, projectID string) (*models.HeartbeatConfig, error) {
+	hcRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.HeartbeatConfigKey,
+	)
+
+	hc, err := s.scanHeartbeatConfig(hcRow)
+	if err == sql.ErrNoRows {
+		return &models.HeartbeatConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return hc, nil
+}
+
+func (s *Store) scanAlertsConfig(scanner scanner) (*models.AlertsConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var ac models.AlertsConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &ac)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ac, nil
+}
+
+func (s *Store) SetAlertsConfig(ctx context.Context, projectID string, value models.AlertsConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.AlertsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetAlertsConfig(ctx context.Context, projectID string) (*models.AlertsConfig, error) {
+	acRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.AlertsConfigKey,
+	)
+
+	ac, err := s.scanAlertsConfig(acRow)
+	if err == sql.ErrNoRows {
+		return &models.AlertsConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ac, nil
+}
+
+func (s *Store) scanStaleDeviceCleanupConfig(scanner scanner) (*models.StaleDeviceCleanupConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var sdcc models.StaleDeviceCleanupConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &sdcc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sdcc, nil
+}
+
+func (s *Store) SetStaleDeviceCleanupConfig(ctx context.Context, projectID string, value models.StaleDeviceCleanupConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.StaleDeviceCleanupConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetStaleDeviceCleanupConfig(ctx context.Context, projectID string) (*models.StaleDeviceCleanupConfig, error) {
+	sdccRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.StaleDeviceCleanupConfigKey,
+	)
+
+	sdcc, err := s.scanStaleDeviceCleanupConfig(sdccRow)
+	if err == sql.ErrNoRows {
+		return &models.StaleDeviceCleanupConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sdcc, nil
+}
+
+func (s *Store) scanDeviceEndpointConfigs(scanner scanner) ([]models.DeviceEndpointConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var dec []models.DeviceEndpointConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &dec)
+	if err != nil {
+		return nil, err
+	}
+
+	return dec, nil
+}
+
+func (s *Store) SetDeviceEndpointConfigs(ctx context.Context, projectID string, value []models.DeviceEndpointConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.DeviceEndpointsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetDeviceEndpointConfigs(ctx context.Context, projectID string) ([]models.DeviceEndpointConfig, error) {
+	decRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.DeviceEndpointsConfigKey,
+	)
+
+	dec, err := s.scanDeviceEndpointConfigs(decRow)
+	if err == sql.ErrNoRows {
+		return make([]models.DeviceEndpointConfig, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return dec, nil
+}
+
+func (s *Store) scanMetricsExportTargets(scanner scanner) ([]models.MetricsExportTarget, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var met []models.MetricsExportTarget
+	err = json.Unmarshal([]byte(pConfig.Value), &met)
+	if err != nil {
+		return nil, err
+	}
+
+	return met, nil
+}
+
+func (s *Store) SetMetricsExportTargets(ctx context.Context, projectID string, value []models.MetricsExportTarget) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.MetricsExportTargetsConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetMetricsExportTargets(ctx context.Context, projectID string) ([]models.MetricsExportTarget, error) {
+	metRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.MetricsExportTargetsConfigKey,
+	)
+
+	met, err := s.scanMetricsExportTargets(metRow)
+	if err == sql.ErrNoRows {
+		return make([]models.MetricsExportTarget, 0), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return met, nil
+}
+
+func (s *Store) scanMetricsCollectionConfig(scanner scanner) (*models.MetricsCollectionConfig, error) {
+	pConfig, err := s.scanProjectConfig(scanner)
+	if err != nil {
+		return nil, err
+	}
+
+	var mcc models.MetricsCollectionConfig
+	err = json.Unmarshal([]byte(pConfig.Value), &mcc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcc, nil
+}
+
+func (s *Store) SetMetricsCollectionConfig(ctx context.Context, projectID string, value models.MetricsCollectionConfig) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		setProjectConfig,
+		projectID,
+		models.MetricsCollectionConfigKey,
+		valueBytes,
+	)
+	return err
+}
+
+func (s *Store) GetMetricsCollectionConfig(ctx context.Context, projectID string) (*models.MetricsCollectionConfig, error) {
+	mccRow := s.db.QueryRowContext(
+		ctx,
+		getProjectConfig,
+		projectID,
+		models.MetricsCollectionConfigKey,
+	)
+
+	mcc, err := s.scanMetricsCollectionConfig(mccRow)
+	if err == sql.ErrNoRows {
+		return &models.MetricsCollectionConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return mcc, nil
+}
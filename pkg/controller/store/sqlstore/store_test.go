@@ -0,0 +1,268 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/controller/store"
+	"github.com/deviceplane/deviceplane/pkg/controller/store/postgres"
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlite"
+	"github.com/deviceplane/deviceplane/pkg/controller/store/sqlstore"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	_ "github.com/lib/pq"
+)
+
+// TestPostgres runs against the database POSTGRES_TEST_URL points to, in a
+// schema of its own that's dropped afterwards.
+func TestPostgres(t *testing.T) {
+	url := os.Getenv("POSTGRES_TEST_URL")
+	if url == "" {
+		t.Skip("POSTGRES_TEST_URL isn't set")
+	}
+	ctx := context.Background()
+
+	admin, err := sql.Open("postgres", url)
+	require.NoError(t, err)
+	defer admin.Close()
+
+	schema := fmt.Sprintf("store_test_%d", time.Now().UnixNano())
+	_, err = admin.ExecContext(ctx, "create schema "+schema)
+	require.NoError(t, err)
+	defer admin.ExecContext(ctx, "drop schema "+schema+" cascade")
+
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	db, err := sql.Open("postgres", url+separator+"search_path="+schema)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Migrating an up to date database does nothing
+	require.NoError(t, postgres.Migrate(ctx, db))
+	require.NoError(t, postgres.Migrate(ctx, db))
+	var migrations int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from schema_migrations").Scan(&migrations))
+	require.NotZero(t, migrations)
+
+	testStore(t, postgres.NewStore(db))
+}
+
+func TestSQLite(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "sqlstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := sqlite.Open(filepath.Join(dir, "deviceplane.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, sqlite.Migrate(ctx, db))
+	require.NoError(t, sqlite.Migrate(ctx, db))
+	var migrations int
+	require.NoError(t, db.QueryRowContext(ctx, "select count(*) from schema_migrations").Scan(&migrations))
+	require.NotZero(t, migrations)
+
+	testStore(t, sqlite.NewStore(db))
+}
+
+// testStore exercises the queries that are written differently in each
+// dialect.
+func testStore(t *testing.T, s *sqlstore.Store) {
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, "Jane@Example.com", "hash", "Jane", "Doe", "")
+	require.NoError(t, err)
+	lookedUp, err := s.LookupUser(ctx, "jane@example.com")
+	require.NoError(t, err)
+	require.Equal(t, user.ID, lookedUp.ID)
+	_, err = s.ValidateUserWithEmail(ctx, "JANE@example.com", "hash")
+	require.NoError(t, err)
+
+	token, err := s.CreatePasswordRecoveryToken(ctx, user.ID, "recovery-hash")
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+
+	project, err := s.CreateProject(ctx, "project")
+	require.NoError(t, err)
+	otherProject, err := s.CreateProject(ctx, "other-project")
+	require.NoError(t, err)
+
+	for _, provider := range []string{"https://old.example.com", "https://idp.example.com"} {
+		require.NoError(t, s.SetSSOConfig(ctx, project.ID, models.SSOConfig{Provider: provider}))
+	}
+	ssoConfigs, err := s.ListSSOConfigsByProvider(ctx, "https://idp.example.com")
+	require.NoError(t, err)
+	require.Len(t, ssoConfigs, 1)
+	require.NoError(t, s.SetHeartbeatConfig(ctx, project.ID, models.HeartbeatConfig{}))
+	require.NoError(t, s.SetHeartbeatConfig(ctx, project.ID, models.HeartbeatConfig{}))
+
+	role, err := s.CreateRole(ctx, project.ID, "developer", "", "{}")
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteRole(ctx, role.ID, project.ID))
+	_, err = s.GetRole(ctx, role.ID, project.ID)
+	require.Equal(t, store.ErrRoleNotFound, err)
+
+	registrationToken, err := s.CreateDeviceRegistrationToken(ctx, project.ID, "default", "", nil, nil, "", false)
+	require.NoError(t, err)
+	device, err := s.CreateDevice(ctx, project.ID, "raspberry-pi", registrationToken.ID, map[string]string{"site": "warehouse"}, false)
+	require.NoError(t, err)
+	_, err = s.CreateDevice(ctx, project.ID, "jetson", registrationToken.ID, map[string]string{}, false)
+	require.NoError(t, err)
+
+	require.WithinDuration(t, time.Now(), device.CreatedAt, time.Minute)
+
+	devices, err := s.ListDevices(ctx, project.ID, "wareHOUSE")
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	require.Equal(t, device.ID, devices[0].ID)
+
+	// Times are compared in SQL
+	for status, count := range map[string]int{"online": 2, "offline": 0} {
+		devices, err = s.ListDevicesMatching(ctx, project.ID, "", models.Query{{{
+			Type: models.DevicePropertyCondition,
+			Params: map[string]interface{}{
+				"property": "status",
+				"operator": models.OperatorIs,
+				"value":    status,
+			},
+		}}})
+		require.NoError(t, err)
+		require.Len(t, devices, count, status)
+	}
+
+	// Pages continue after the last device of the previous one
+	firstPage, err := s.ListDevicesPage(ctx, project.ID, "", 1)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 1)
+	secondPage, err := s.ListDevicesPage(ctx, project.ID, firstPage[0].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	require.True(t, secondPage[0].ID > firstPage[0].ID)
+
+	for _, value := range []string{"1", "2"} {
+		_, err = s.SetDeviceEnvironmentVariable(ctx, device.ID, project.ID, "KEY", value)
+		require.NoError(t, err)
+	}
+	environment, err := s.GetDeviceEnvironment(ctx, device.ID, project.ID)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"KEY": "2"}, environment)
+
+	deviceGroup, err := s.CreateDeviceGroup(ctx, project.ID, "warehouse", "", nil)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.AddDeviceGroupMember(ctx, deviceGroup.ID, project.ID, device.ID))
+	}
+	members, err := s.ListDeviceGroupMembers(ctx, project.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+
+	application, err := s.CreateApplication(ctx, project.ID, "app", "")
+	require.NoError(t, err)
+	var releases []*models.Release
+	for i := 0; i < 2; i++ {
+		release, err := s.CreateRelease(ctx, project.ID, application.ID, "", "{}", "", "", "", "", user.ID, "")
+		require.NoError(t, err)
+		releases = append(releases, release)
+	}
+	latestRelease, err := s.GetLatestRelease(ctx, project.ID, application.ID)
+	require.NoError(t, err)
+	require.Equal(t, uint32(2), latestRelease.Number)
+	releaseCount, err := s.GetReleaseCount(ctx, project.ID, application.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, releaseCount)
+	releasePage, err := s.ListReleasesPage(ctx, project.ID, application.ID, "", 1)
+	require.NoError(t, err)
+	require.Len(t, releasePage, 1)
+	require.Equal(t, releases[1].ID, releasePage[0].ID)
+	releasePage, err = s.ListReleasesPage(ctx, project.ID, application.ID, releases[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, releasePage, 1)
+	require.Equal(t, releases[0].ID, releasePage[0].ID)
+	applicationPage, err := s.ListApplicationsPage(ctx, project.ID, application.ID, 1)
+	require.NoError(t, err)
+	require.Empty(t, applicationPage)
+
+	for _, release := range releases {
+		_, err = s.SetDeviceReleasePin(ctx, project.ID, device.ID, application.ID, release.ID)
+		require.NoError(t, err)
+		require.NoError(t, s.SetDeviceApplicationStatus(ctx, project.ID, device.ID, application.ID, release.ID))
+	}
+	pin, err := s.GetDeviceReleasePin(ctx, project.ID, device.ID, application.ID)
+	require.NoError(t, err)
+	require.Equal(t, releases[1].ID, pin.ReleaseID)
+	applicationStatus, err := s.GetDeviceApplicationStatus(ctx, project.ID, device.ID, application.ID)
+	require.NoError(t, err)
+	require.Equal(t, releases[1].ID, applicationStatus.CurrentReleaseID)
+
+	matching, err := s.ListDevicesMatching(ctx, project.ID, "", models.Query{{{
+		Type: models.DevicePropertyCondition,
+		Params: map[string]interface{}{
+			"property": "releases.app",
+			"operator": models.OperatorIn,
+			"values":   []string{"1", "2"},
+		},
+	}}})
+	require.NoError(t, err)
+	require.Len(t, matching, 1)
+
+	for _, controller := range []string{"controller-1", "controller-2"} {
+		require.NoError(t, s.SetDeviceConnection(ctx, device.ID, controller))
+	}
+	controller, err := s.GetDeviceConnection(ctx, device.ID)
+	require.NoError(t, err)
+	require.Equal(t, "controller-2", controller)
+
+	release, err := s.TryLock(ctx, "runner")
+	require.NoError(t, err)
+	_, err = s.TryLock(ctx, "runner")
+	require.Equal(t, store.ErrLockHeld, err)
+	release()
+	release, err = s.TryLock(ctx, "runner")
+	require.NoError(t, err)
+	release()
+
+	// Sessions started at the same time can't go over the limit together
+	sessionIDs := make(chan string, 5)
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			id, err := s.StartRemoteSession(ctx, project.ID, 2)
+			sessionIDs <- id
+			errs <- err
+		}()
+	}
+	var started []string
+	for i := 0; i < 5; i++ {
+		if id, err := <-sessionIDs, <-errs; err == nil {
+			started = append(started, id)
+		} else {
+			require.Equal(t, store.ErrRemoteSessionLimitReached, err)
+		}
+	}
+	require.Len(t, started, 2)
+	_, err = s.StartRemoteSession(ctx, otherProject.ID, 2)
+	require.NoError(t, err)
+	require.NoError(t, s.RefreshRemoteSession(ctx, started[0]))
+	require.NoError(t, s.EndRemoteSession(ctx, started[1]))
+	_, err = s.StartRemoteSession(ctx, project.ID, 2)
+	require.NoError(t, err)
+
+	moved, err := s.MoveDevice(ctx, device.ID, project.ID, otherProject.ID)
+	require.NoError(t, err)
+	require.Equal(t, otherProject.ID, moved.ProjectID)
+	members, err = s.ListDeviceGroupMembers(ctx, project.ID)
+	require.NoError(t, err)
+	require.Empty(t, members)
+}
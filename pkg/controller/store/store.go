@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/models"
 )
@@ -57,6 +58,15 @@ type UserAccessKeys interface {
 
 var ErrUserAccessKeyNotFound = errors.New("user access key not found")
 
+type SSHKeys interface {
+	CreateSSHKey(ctx context.Context, userID, name, publicKey string) (*models.SSHKey, error)
+	GetSSHKey(ctx context.Context, id, userID string) (*models.SSHKey, error)
+	ListSSHKeys(ctx context.Context, userID string) ([]models.SSHKey, error)
+	DeleteSSHKey(ctx context.Context, id, userID string) error
+}
+
+var ErrSSHKeyNotFound = errors.New("ssh key not found")
+
 type Projects interface {
 	CreateProject(ctx context.Context, name string) (*models.Project, error)
 	GetProject(ctx context.Context, id string) (*models.Project, error)
@@ -121,7 +131,7 @@ var ErrServiceAccountNotFound = errors.New("service account not found")
 var ErrServiceAccountNameAlreadyInUse = errors.New("service account name already in use")
 
 type ServiceAccountAccessKeys interface {
-	CreateServiceAccountAccessKey(ctx context.Context, projectID, serviceAccountID string, hash, description string) (*models.ServiceAccountAccessKey, error)
+	CreateServiceAccountAccessKey(ctx context.Context, projectID, serviceAccountID string, hash, description, config string, expiresAt *time.Time) (*models.ServiceAccountAccessKey, error)
 	GetServiceAccountAccessKey(ctx context.Context, id, projectID string) (*models.ServiceAccountAccessKey, error)
 	ValidateServiceAccountAccessKey(ctx context.Context, hash string) (*models.ServiceAccountAccessKey, error)
 	ListServiceAccountAccessKeys(ctx context.Context, projectID, serviceAccountID string) ([]models.ServiceAccountAccessKey, error)
@@ -140,11 +150,24 @@ type ServiceAccountRoleBindings interface {
 var ErrServiceAccountRoleBindingNotFound = errors.New("service account role binding not found")
 
 type Devices interface {
-	CreateDevice(ctx context.Context, projectID, name, registrationTokenID string, deviceLabels map[string]string) (*models.Device, error)
+	CreateDevice(ctx context.Context, projectID, name, registrationTokenID string, deviceLabels map[string]string, pendingApproval bool) (*models.Device, error)
 	GetDevice(ctx context.Context, deviceID, projectID string) (*models.Device, error)
 	LookupDevice(ctx context.Context, name, projectID string) (*models.Device, error)
 	ListDevices(ctx context.Context, projectID, searchQuery string) ([]models.Device, error)
+	// ListDevicesMatching lists devices like ListDevices, narrowed down by
+	// the conditions of query that the store can check itself. The devices
+	// still have to be checked against the whole query.
+	ListDevicesMatching(ctx context.Context, projectID, searchQuery string, query models.Query) ([]models.Device, error)
+	// ListDevicesPage lists up to limit devices in order of ID, starting
+	// after the device with ID after, or from the first if after is empty.
+	ListDevicesPage(ctx context.Context, projectID, after string, limit int) ([]models.Device, error)
 	UpdateDeviceName(ctx context.Context, deviceID, projectID, name string) (*models.Device, error)
+	UpdateDeviceDesiredAgent(ctx context.Context, deviceID, projectID, version, spec string) (*models.Device, error)
+	ApproveDevice(ctx context.Context, deviceID, projectID string) (*models.Device, error)
+	DecommissionDevice(ctx context.Context, deviceID, projectID string) (*models.Device, error)
+	ArchiveDevice(ctx context.Context, deviceID, projectID string) error
+	TransferDevice(ctx context.Context, deviceID, projectID, targetProjectID string) (*models.Device, error)
+	MoveDevice(ctx context.Context, deviceID, projectID, targetProjectID string) (*models.Device, error)
 	DeleteDevice(ctx context.Context, deviceID, projectID string) error
 	SetDeviceInfo(ctx context.Context, deviceID, projectID string, deviceInfo models.DeviceInfo) (*models.Device, error)
 	UpdateDeviceLastSeenAt(ctx context.Context, deviceID, projectID string) error
@@ -153,18 +176,26 @@ type Devices interface {
 	DeleteDeviceLabel(ctx context.Context, deviceID, projectID, key string) error
 }
 
+type DeviceEnvironments interface {
+	GetDeviceEnvironment(ctx context.Context, deviceID, projectID string) (map[string]string, error)
+	SetDeviceEnvironmentVariable(ctx context.Context, deviceID, projectID, key, value string) (*string, error)
+	DeleteDeviceEnvironmentVariable(ctx context.Context, deviceID, projectID, key string) error
+}
+
 var ErrDeviceNotFound = errors.New("device not found")
 var ErrDeviceNameAlreadyInUse = errors.New("device name already in use")
 
 type DeviceRegistrationTokens interface {
-	CreateDeviceRegistrationToken(ctx context.Context, projectID, name, description string, maxRegistrations *int) (*models.DeviceRegistrationToken, error)
+	CreateDeviceRegistrationToken(ctx context.Context, projectID, name, description string, maxRegistrations *int, expiresAt *time.Time, namePrefix string, requireApproval bool) (*models.DeviceRegistrationToken, error)
 	GetDeviceRegistrationToken(ctx context.Context, tokenID, projectID string) (*models.DeviceRegistrationToken, error)
 	LookupDeviceRegistrationToken(ctx context.Context, name, projectID string) (*models.DeviceRegistrationToken, error)
 	ListDeviceRegistrationTokens(ctx context.Context, projectID string) ([]models.DeviceRegistrationToken, error)
-	UpdateDeviceRegistrationToken(ctx context.Context, tokenID, projectID, name, description string, maxRegistrations *int) (*models.DeviceRegistrationToken, error)
+	UpdateDeviceRegistrationToken(ctx context.Context, tokenID, projectID, name, description string, maxRegistrations *int, expiresAt *time.Time, namePrefix string, requireApproval bool) (*models.DeviceRegistrationToken, error)
 	DeleteDeviceRegistrationToken(ctx context.Context, tokenID, projectID string) error
 	SetDeviceRegistrationTokenLabel(ctx context.Context, tokenID, projectID, key, value string) (*string, error)
 	DeleteDeviceRegistrationTokenLabel(ctx context.Context, tokenID, projectID, key string) error
+	SetDeviceRegistrationTokenEnvironmentVariable(ctx context.Context, tokenID, projectID, key, value string) (*string, error)
+	DeleteDeviceRegistrationTokenEnvironmentVariable(ctx context.Context, tokenID, projectID, key string) error
 }
 
 type DevicesRegisteredWithToken interface {
@@ -178,6 +209,7 @@ type DeviceAccessKeys interface {
 	CreateDeviceAccessKey(ctx context.Context, projectID, deviceID, hash string) (*models.DeviceAccessKey, error)
 	GetDeviceAccessKey(ctx context.Context, id, projectID string) (*models.DeviceAccessKey, error)
 	ValidateDeviceAccessKey(ctx context.Context, projectID, hash string) (*models.DeviceAccessKey, error)
+	DeleteDeviceAccessKeys(ctx context.Context, deviceID, projectID string) error
 }
 
 var ErrDeviceAccessKeyNotFound = errors.New("device access key not found")
@@ -187,6 +219,10 @@ type Applications interface {
 	GetApplication(ctx context.Context, id, projectID string) (*models.Application, error)
 	LookupApplication(ctx context.Context, name, projectID string) (*models.Application, error)
 	ListApplications(ctx context.Context, projectID string) ([]models.Application, error)
+	// ListApplicationsPage lists up to limit applications in order of ID,
+	// starting after the application with ID after, or from the first if
+	// after is empty.
+	ListApplicationsPage(ctx context.Context, projectID, after string, limit int) ([]models.Application, error)
 	UpdateApplicationName(ctx context.Context, id, projectID, name string) (*models.Application, error)
 	UpdateApplicationDescription(ctx context.Context, id, projectID, description string) (*models.Application, error)
 	UpdateApplicationSchedulingRule(ctx context.Context, id, projectID string, schedulingRule models.SchedulingRule) (*models.Application, error)
@@ -202,15 +238,150 @@ type ApplicationDeviceCounts interface {
 }
 
 type Releases interface {
-	CreateRelease(ctx context.Context, projectID, applicationID, yamlConfig, jsonConfig, createdByUserID, createdByServiceAccountID string) (*models.Release, error)
+	CreateRelease(ctx context.Context, projectID, applicationID, yamlConfig, jsonConfig, sourceReleaseID, notes, source, idempotencyKey, createdByUserID, createdByServiceAccountID string) (*models.Release, error)
+	GetReleaseByIdempotencyKey(ctx context.Context, projectID, applicationID, idempotencyKey string) (*models.Release, error)
 	GetRelease(ctx context.Context, id, projectID, applicationID string) (*models.Release, error)
 	GetReleaseByNumber(ctx context.Context, id uint32, projectID, applicationID string) (*models.Release, error)
 	GetLatestRelease(ctx context.Context, projectID, applicationID string) (*models.Release, error)
 	ListReleases(ctx context.Context, projectID, applicationID string) ([]models.Release, error)
+	// ListReleasesPage lists up to limit releases, newest first, starting
+	// after the release with ID after, or from the latest if after is empty.
+	ListReleasesPage(ctx context.Context, projectID, applicationID, after string, limit int) ([]models.Release, error)
+	GetReleaseCount(ctx context.Context, projectID, applicationID string) (int, error)
 }
 
 var ErrReleaseNotFound = errors.New("release not found")
 
+type EnvironmentFiles interface {
+	CreateEnvironmentFile(ctx context.Context, projectID, name, description, content string) (*models.EnvironmentFile, error)
+	GetEnvironmentFile(ctx context.Context, id, projectID string) (*models.EnvironmentFile, error)
+	LookupEnvironmentFile(ctx context.Context, name, projectID string) (*models.EnvironmentFile, error)
+	ListEnvironmentFiles(ctx context.Context, projectID string) ([]models.EnvironmentFile, error)
+	UpdateEnvironmentFile(ctx context.Context, id, projectID, name, description, content string) (*models.EnvironmentFile, error)
+	DeleteEnvironmentFile(ctx context.Context, id, projectID string) error
+}
+
+var ErrEnvironmentFileNotFound = errors.New("environment file not found")
+var ErrEnvironmentFileNameAlreadyInUse = errors.New("environment file name already in use")
+
+type SessionRecordings interface {
+	CreateSessionRecording(ctx context.Context, projectID, deviceID, kind, applicationID, service, createdByUserID, createdByServiceAccountID string) (*models.SessionRecording, error)
+	GetSessionRecording(ctx context.Context, id, projectID string) (*models.SessionRecording, error)
+	ListSessionRecordings(ctx context.Context, projectID string) ([]models.SessionRecording, error)
+	GetSessionRecordingContent(ctx context.Context, id, projectID string) ([]byte, error)
+	FinishSessionRecording(ctx context.Context, id, projectID, deviceID string, content []byte) error
+}
+
+var ErrSessionRecordingNotFound = errors.New("session recording not found")
+
+type ConfigFiles interface {
+	CreateConfigFile(ctx context.Context, projectID string, configFile models.ConfigFile) (*models.ConfigFile, error)
+	GetConfigFile(ctx context.Context, id, projectID string) (*models.ConfigFile, error)
+	LookupConfigFile(ctx context.Context, name, projectID string) (*models.ConfigFile, error)
+	ListConfigFiles(ctx context.Context, projectID string) ([]models.ConfigFile, error)
+	UpdateConfigFile(ctx context.Context, id, projectID string, configFile models.ConfigFile) (*models.ConfigFile, error)
+	DeleteConfigFile(ctx context.Context, id, projectID string) error
+}
+
+var ErrConfigFileNotFound = errors.New("config file not found")
+var ErrConfigFileNameAlreadyInUse = errors.New("config file name already in use")
+
+type DeviceGroups interface {
+	CreateDeviceGroup(ctx context.Context, projectID, name, description string, query *models.Query) (*models.DeviceGroup, error)
+	GetDeviceGroup(ctx context.Context, id, projectID string) (*models.DeviceGroup, error)
+	LookupDeviceGroup(ctx context.Context, name, projectID string) (*models.DeviceGroup, error)
+	ListDeviceGroups(ctx context.Context, projectID string) ([]models.DeviceGroup, error)
+	UpdateDeviceGroup(ctx context.Context, id, projectID, name, description string, query *models.Query) (*models.DeviceGroup, error)
+	DeleteDeviceGroup(ctx context.Context, id, projectID string) error
+	AddDeviceGroupMember(ctx context.Context, id, projectID, deviceID string) error
+	RemoveDeviceGroupMember(ctx context.Context, id, projectID, deviceID string) error
+	ListDeviceGroupMembers(ctx context.Context, projectID string) ([]models.DeviceGroupMember, error)
+}
+
+var ErrDeviceGroupNotFound = errors.New("device group not found")
+var ErrDeviceGroupNameAlreadyInUse = errors.New("device group name already in use")
+
+type DeviceViews interface {
+	CreateDeviceView(ctx context.Context, projectID, name, description string, query models.Query, columns []models.DeviceViewColumn, createdByUserID, createdByServiceAccountID string) (*models.DeviceView, error)
+	GetDeviceView(ctx context.Context, id, projectID string) (*models.DeviceView, error)
+	LookupDeviceView(ctx context.Context, name, projectID string) (*models.DeviceView, error)
+	ListDeviceViews(ctx context.Context, projectID string) ([]models.DeviceView, error)
+	UpdateDeviceView(ctx context.Context, id, projectID, name, description string, query models.Query, columns []models.DeviceViewColumn) (*models.DeviceView, error)
+	DeleteDeviceView(ctx context.Context, id, projectID string) error
+}
+
+var ErrDeviceViewNotFound = errors.New("device view not found")
+var ErrDeviceViewNameAlreadyInUse = errors.New("device view name already in use")
+
+type Rollouts interface {
+	CreateRollout(ctx context.Context, projectID, applicationID, releaseID, previousReleaseID string, steps []models.RolloutStep, soakPeriod, maxFailurePercentage int) (*models.Rollout, error)
+	GetRollout(ctx context.Context, id, projectID, applicationID string) (*models.Rollout, error)
+	GetLatestRollout(ctx context.Context, projectID, applicationID string) (*models.Rollout, error)
+	ListRollouts(ctx context.Context, projectID, applicationID string) ([]models.Rollout, error)
+	ListRolloutsByStatus(ctx context.Context, status models.RolloutStatus) ([]models.Rollout, error)
+	UpdateRolloutStep(ctx context.Context, id, projectID string, step int) (*models.Rollout, error)
+	UpdateRolloutStatus(ctx context.Context, id, projectID string, status models.RolloutStatus, statusReason string) (*models.Rollout, error)
+}
+
+var ErrRolloutNotFound = errors.New("rollout not found")
+
+type DeviceReleasePins interface {
+	SetDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID, releaseID string) (*models.DeviceReleasePin, error)
+	GetDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID string) (*models.DeviceReleasePin, error)
+	ListDeviceReleasePins(ctx context.Context, projectID, applicationID string) ([]models.DeviceReleasePin, error)
+	ListDeviceReleasePinsByDevice(ctx context.Context, projectID, deviceID string) ([]models.DeviceReleasePin, error)
+	DeleteDeviceReleasePin(ctx context.Context, projectID, deviceID, applicationID string) error
+}
+
+var ErrDeviceReleasePinNotFound = errors.New("device release pin not found")
+
+type ApplicationGitSyncs interface {
+	SetApplicationGitSync(ctx context.Context, projectID, applicationID, repository, branch, path string) (*models.ApplicationGitSync, error)
+	GetApplicationGitSync(ctx context.Context, projectID, applicationID string) (*models.ApplicationGitSync, error)
+	ListAllApplicationGitSyncs(ctx context.Context) ([]models.ApplicationGitSync, error)
+	UpdateApplicationGitSyncStatus(ctx context.Context, projectID, applicationID, lastCommit, lastError string) error
+	DeleteApplicationGitSync(ctx context.Context, projectID, applicationID string) error
+}
+
+var ErrApplicationGitSyncNotFound = errors.New("application git sync not found")
+
+type DeviceConnectivityEvents interface {
+	CreateDeviceConnectivityEvent(ctx context.Context, projectID, deviceID string, status models.DeviceStatus, at time.Time) error
+	// ListDeviceConnectivityEvents lists a device's events since a time,
+	// oldest first.
+	ListDeviceConnectivityEvents(ctx context.Context, projectID, deviceID string, since time.Time) ([]models.DeviceConnectivityEvent, error)
+	DeleteDeviceConnectivityEventsBefore(ctx context.Context, before time.Time) error
+}
+
+type Alerts interface {
+	CreateAlert(ctx context.Context, projectID, rule, deviceID, summary string) (*models.Alert, error)
+	ListFiringAlerts(ctx context.Context, projectID string) ([]models.Alert, error)
+	// ListAlerts lists the alerts that are firing or were resolved since a
+	// time, newest first.
+	ListAlerts(ctx context.Context, projectID string, since time.Time) ([]models.Alert, error)
+	ResolveAlert(ctx context.Context, id, projectID string, at time.Time) error
+	DeleteAlertsResolvedBefore(ctx context.Context, before time.Time) error
+}
+
+type AlertSilences interface {
+	CreateAlertSilence(ctx context.Context, projectID, rule, deviceID string, until time.Time, reason, createdByUserID, createdByServiceAccountID string) (*models.AlertSilence, error)
+	GetAlertSilence(ctx context.Context, id, projectID string) (*models.AlertSilence, error)
+	// ListAlertSilences lists the silences that haven't expired by a time.
+	ListAlertSilences(ctx context.Context, projectID string, now time.Time) ([]models.AlertSilence, error)
+	DeleteAlertSilence(ctx context.Context, id, projectID string) error
+	DeleteAlertSilencesBefore(ctx context.Context, before time.Time) error
+}
+
+var ErrAlertSilenceNotFound = errors.New("alert silence not found")
+
+type AuditLogEntries interface {
+	CreateAuditLogEntry(ctx context.Context, projectID, userID, serviceAccountID, action, method, path, summary, remoteAddr string, statusCode int, duration time.Duration) error
+	// ListAuditLogEntries lists entries newest first. Empty filters match
+	// every entry, and before is the ID of the entry to list from.
+	ListAuditLogEntries(ctx context.Context, projectID, userID, serviceAccountID, action, before string, since time.Time, limit int) ([]models.AuditLogEntry, error)
+	DeleteAuditLogEntriesBefore(ctx context.Context, projectID string, before time.Time) error
+}
+
 type ReleaseDeviceCounts interface {
 	GetReleaseDeviceCounts(ctx context.Context, projectID, applicationID, releaseID string) (*models.ReleaseDeviceCounts, error)
 }
@@ -234,6 +405,46 @@ type DeviceServiceStatuses interface {
 
 var ErrDeviceServiceStatusNotFound = errors.New("device service status not found")
 
+// DeviceConnections records which controller each device is connected to,
+// by connection key, when several controllers share a store.
+type DeviceConnections interface {
+	SetDeviceConnection(ctx context.Context, key, controller string) error
+	GetDeviceConnection(ctx context.Context, key string) (string, error)
+	DeleteDeviceConnection(ctx context.Context, key, controller string) error
+}
+
+var ErrDeviceConnectionNotFound = errors.New("device connection not found")
+
+// Locks are held by one controller at a time. TryLock returns a function
+// that releases the lock, or ErrLockHeld if another controller holds it.
+type Locks interface {
+	TryLock(ctx context.Context, name string) (func(), error)
+}
+
+// Connectivity checks that the store can be reached, for the controller's
+// readiness checks.
+type Connectivity interface {
+	Ping(ctx context.Context) error
+}
+
+var ErrLockHeld = errors.New("lock held")
+
+// RemoteSessions counts the SSH and exec sessions open in each project
+// across all the controllers sharing a store. Sessions that haven't been
+// refreshed within RemoteSessionTimeout aren't counted, so that those of a
+// controller that stopped without ending them expire.
+type RemoteSessions interface {
+	// StartRemoteSession returns ErrRemoteSessionLimitReached if the
+	// project already has limit sessions open.
+	StartRemoteSession(ctx context.Context, projectID string, limit int) (string, error)
+	RefreshRemoteSession(ctx context.Context, id string) error
+	EndRemoteSession(ctx context.Context, id string) error
+}
+
+const RemoteSessionTimeout = 2 * time.Minute
+
+var ErrRemoteSessionLimitReached = errors.New("remote session limit reached")
+
 var ErrProjectConfigNotFound = errors.New("project config not found")
 
 type MetricConfigs interface {
@@ -243,4 +454,76 @@ type MetricConfigs interface {
 	SetDeviceMetricsConfig(ctx context.Context, projectID string, value models.DeviceMetricsConfig) error
 	GetServiceMetricsConfigs(ctx context.Context, projectID string) ([]models.ServiceMetricsConfig, error)
 	SetServiceMetricsConfigs(ctx context.Context, projectID string, value []models.ServiceMetricsConfig) error
+	GetMetricsExportTargets(ctx context.Context, projectID string) ([]models.MetricsExportTarget, error)
+	SetMetricsExportTargets(ctx context.Context, projectID string, value []models.MetricsExportTarget) error
+	GetMetricsCollectionConfig(ctx context.Context, projectID string) (*models.MetricsCollectionConfig, error)
+	SetMetricsCollectionConfig(ctx context.Context, projectID string, value models.MetricsCollectionConfig) error
+}
+
+type SSHConfigs interface {
+	GetSSHConfig(ctx context.Context, projectID string) (*models.SSHConfig, error)
+	SetSSHConfig(ctx context.Context, projectID string, value models.SSHConfig) error
+}
+
+type AgentRolloutConfigs interface {
+	GetAgentRolloutConfig(ctx context.Context, projectID string) (*models.AgentRolloutConfig, error)
+	SetAgentRolloutConfig(ctx context.Context, projectID string, value models.AgentRolloutConfig) error
+}
+
+type DeviceEnvironmentConfigs interface {
+	GetDeviceEnvironmentConfig(ctx context.Context, projectID string) (*models.DeviceEnvironmentConfig, error)
+	SetDeviceEnvironmentConfig(ctx context.Context, projectID string, value models.DeviceEnvironmentConfig) error
+}
+
+type MaintenanceWindowsConfigs interface {
+	GetMaintenanceWindowsConfig(ctx context.Context, projectID string) (*models.MaintenanceWindowsConfig, error)
+	SetMaintenanceWindowsConfig(ctx context.Context, projectID string, value models.MaintenanceWindowsConfig) error
+}
+
+type ReleaseWebhooksConfigs interface {
+	GetReleaseWebhooksConfig(ctx context.Context, projectID string) (*models.ReleaseWebhooksConfig, error)
+	SetReleaseWebhooksConfig(ctx context.Context, projectID string, value models.ReleaseWebhooksConfig) error
+}
+
+type EventWebhooksConfigs interface {
+	GetEventWebhooksConfig(ctx context.Context, projectID string) (*models.EventWebhooksConfig, error)
+	SetEventWebhooksConfig(ctx context.Context, projectID string, value models.EventWebhooksConfig) error
+}
+
+type AuditLogConfigs interface {
+	GetAuditLogConfig(ctx context.Context, projectID string) (*models.AuditLogConfig, error)
+	SetAuditLogConfig(ctx context.Context, projectID string, value models.AuditLogConfig) error
+}
+
+type SSOConfigs interface {
+	GetSSOConfig(ctx context.Context, projectID string) (*models.SSOConfig, error)
+	SetSSOConfig(ctx context.Context, projectID string, value models.SSOConfig) error
+	// ListSSOConfigsByProvider returns the SSO configs for a provider, keyed
+	// by project ID.
+	ListSSOConfigsByProvider(ctx context.Context, provider string) (map[string]models.SSOConfig, error)
+}
+
+type LimitsConfigs interface {
+	GetLimitsConfig(ctx context.Context, projectID string) (*models.LimitsConfig, error)
+	SetLimitsConfig(ctx context.Context, projectID string, value models.LimitsConfig) error
+}
+
+type HeartbeatConfigs interface {
+	GetHeartbeatConfig(ctx context.Context, projectID string) (*models.HeartbeatConfig, error)
+	SetHeartbeatConfig(ctx context.Context, projectID string, value models.HeartbeatConfig) error
+}
+
+type AlertsConfigs interface {
+	GetAlertsConfig(ctx context.Context, projectID string) (*models.AlertsConfig, error)
+	SetAlertsConfig(ctx context.Context, projectID string, value models.AlertsConfig) error
+}
+
+type StaleDeviceCleanupConfigs interface {
+	GetStaleDeviceCleanupConfig(ctx context.Context, projectID string) (*models.StaleDeviceCleanupConfig, error)
+	SetStaleDeviceCleanupConfig(ctx context.Context, projectID string, value models.StaleDeviceCleanupConfig) error
+}
+
+type DeviceEndpointConfigs interface {
+	GetDeviceEndpointConfigs(ctx context.Context, projectID string) ([]models.DeviceEndpointConfig, error)
+	SetDeviceEndpointConfigs(ctx context.Context, projectID string, value []models.DeviceEndpointConfig) error
 }
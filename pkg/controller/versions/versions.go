@@ -0,0 +1,63 @@
+package versions
+
+import (
+	"sort"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Report counts devices by the agent version and releases they report
+// running. Devices' releases have to be filled in beforehand. Newer agent
+// versions and releases come first, and applications are sorted by name.
+func Report(devices []models.Device) models.DeviceVersionsReport {
+	agentVersionCounts := make(map[string]int)
+	releaseCounts := make(map[string]map[uint32]int)
+	for _, device := range devices {
+		agentVersionCounts[device.Info.AgentVersion]++
+		for application, release := range device.Releases {
+			if releaseCounts[application] == nil {
+				releaseCounts[application] = make(map[uint32]int)
+			}
+			releaseCounts[application][release]++
+		}
+	}
+
+	report := models.DeviceVersionsReport{
+		AgentVersions: make([]models.AgentVersionCount, 0, len(agentVersionCounts)),
+		Applications:  make([]models.ApplicationReleaseCounts, 0, len(releaseCounts)),
+	}
+
+	for version, count := range agentVersionCounts {
+		report.AgentVersions = append(report.AgentVersions, models.AgentVersionCount{
+			Version: version,
+			Count:   count,
+		})
+	}
+	sort.Slice(report.AgentVersions, func(i, j int) bool {
+		return models.CompareVersions(report.AgentVersions[i].Version, report.AgentVersions[j].Version) > 0
+	})
+
+	for application, counts := range releaseCounts {
+		applicationCounts := models.ApplicationReleaseCounts{
+			Application: application,
+			Releases:    make([]models.ReleaseCount, 0, len(counts)),
+			NotReported: len(devices),
+		}
+		for release, count := range counts {
+			applicationCounts.Releases = append(applicationCounts.Releases, models.ReleaseCount{
+				Release: release,
+				Count:   count,
+			})
+			applicationCounts.NotReported -= count
+		}
+		sort.Slice(applicationCounts.Releases, func(i, j int) bool {
+			return applicationCounts.Releases[i].Release > applicationCounts.Releases[j].Release
+		})
+		report.Applications = append(report.Applications, applicationCounts)
+	}
+	sort.Slice(report.Applications, func(i, j int) bool {
+		return report.Applications[i].Application < report.Applications[j].Application
+	})
+
+	return report
+}
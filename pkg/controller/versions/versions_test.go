@@ -0,0 +1,51 @@
+package versions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+func TestReport(t *testing.T) {
+	device := func(agentVersion string, releases map[string]uint32) models.Device {
+		return models.Device{
+			Info:     models.DeviceInfo{AgentVersion: agentVersion},
+			Releases: releases,
+		}
+	}
+
+	report := Report([]models.Device{
+		device("1.9.0", map[string]uint32{"web": 41}),
+		device("1.10.0", map[string]uint32{"web": 42, "db": 3}),
+		device("1.10.0", map[string]uint32{"web": 42}),
+		device("", nil),
+	})
+
+	require.Equal(t, []models.AgentVersionCount{
+		{Version: "1.10.0", Count: 2},
+		{Version: "1.9.0", Count: 1},
+		{Version: "", Count: 1},
+	}, report.AgentVersions)
+
+	require.Equal(t, []models.ApplicationReleaseCounts{
+		{
+			Application: "db",
+			Releases:    []models.ReleaseCount{{Release: 3, Count: 1}},
+			NotReported: 3,
+		},
+		{
+			Application: "web",
+			Releases: []models.ReleaseCount{
+				{Release: 42, Count: 2},
+				{Release: 41, Count: 1},
+			},
+			NotReported: 1,
+		},
+	}, report.Applications)
+
+	report = Report(nil)
+	require.Empty(t, report.AgentVersions)
+	require.Empty(t, report.Applications)
+}
@@ -9,8 +9,10 @@ import (
 	"github.com/deviceplane/deviceplane/pkg/yamltypes"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/go-connections/nat"
+	units "github.com/docker/go-units"
 )
 
 func convert(s models.Service) (*container.Config, *container.HostConfig, error) {
@@ -28,6 +30,7 @@ func convert(s models.Service) (*container.Config, *container.HostConfig, error)
 			Image:        s.Image,
 			Labels:       s.Labels,
 			StopSignal:   s.StopSignal,
+			StopTimeout:  stopTimeout(s.StopGracePeriod),
 			User:         s.User,
 			WorkingDir:   s.WorkingDir,
 		}, &container.HostConfig{
@@ -39,7 +42,9 @@ func convert(s models.Service) (*container.Config, *container.HostConfig, error)
 			DNSSearch:      s.DNSSearch,
 			ExtraHosts:     s.ExtraHosts,
 			GroupAdd:       s.GroupAdd,
+			Init:           initProcess(s.Init),
 			IpcMode:        container.IpcMode(s.Ipc),
+			LogConfig:      logConfig(s.Logging),
 			NetworkMode:    container.NetworkMode(s.NetworkMode),
 			OomScoreAdj:    int(s.OomScoreAdj),
 			PidMode:        container.PidMode(s.Pid),
@@ -55,6 +60,8 @@ func convert(s models.Service) (*container.Config, *container.HostConfig, error)
 				MemoryReservation: int64(s.MemReservation),
 				MemorySwap:        int64(s.MemSwapLimit),
 				OomKillDisable:    &s.OomKillDisable, // TODO: this might have the wrong default value
+				PidsLimit:         s.PidsLimit,
+				Ulimits:           ulimits(s.Ulimits),
 			},
 			RestartPolicy: container.RestartPolicy{
 				Name: s.Restart,
@@ -62,10 +69,31 @@ func convert(s models.Service) (*container.Config, *container.HostConfig, error)
 			Runtime:     s.Runtime,
 			ShmSize:     int64(s.ShmSize),
 			SecurityOpt: s.SecurityOpt,
+			Sysctls:     s.Sysctls.ToMap(),
 			UTSMode:     container.UTSMode(s.Uts),
 		}, nil
 }
 
+// initProcess only sets the init flag when it's enabled so that the daemon's
+// default is used otherwise.
+func initProcess(init bool) *bool {
+	if !init {
+		return nil
+	}
+	return &init
+}
+
+// stopTimeout returns the number of seconds docker waits after sending the
+// stop signal before killing the container. The timeout is stored on the
+// container so that it's honored by every stop, even by a newer agent.
+func stopTimeout(stopGracePeriod yamltypes.Duration) *int {
+	if stopGracePeriod == 0 {
+		return nil
+	}
+	seconds := int(stopGracePeriod.Seconds())
+	return &seconds
+}
+
 func devices(devices []string) []container.DeviceMapping {
 	var deviceMappings []container.DeviceMapping
 
@@ -89,6 +117,47 @@ func devices(devices []string) []container.DeviceMapping {
 	return deviceMappings
 }
 
+func logConfig(logging *models.Logging) container.LogConfig {
+	if logging == nil {
+		return container.LogConfig{}
+	}
+	return container.LogConfig{
+		Type:   logging.Driver,
+		Config: logging.Options,
+	}
+}
+
+type endpoint struct {
+	network  string
+	settings *network.EndpointSettings
+}
+
+// endpoints returns the networks a service is attached to. Every service can
+// be reached by its name on the networks it's attached to.
+func endpoints(s models.Service) []endpoint {
+	if s.Networks == nil {
+		return nil
+	}
+
+	var endpoints []endpoint
+	for _, n := range s.Networks.Networks {
+		var aliases []string
+		if serviceName, ok := s.Labels[models.ServiceLabel]; ok {
+			aliases = append(aliases, serviceName)
+		}
+		aliases = append(aliases, n.Aliases...)
+
+		endpoints = append(endpoints, endpoint{
+			network: n.Name,
+			settings: &network.EndpointSettings{
+				Aliases: aliases,
+			},
+		})
+	}
+
+	return endpoints
+}
+
 func ports(portSpecs []string) (map[nat.Port]struct{}, nat.PortMap, error) {
 	ports, binding, err := nat.ParsePortSpecs(portSpecs)
 	if err != nil {
@@ -115,6 +184,23 @@ func ports(portSpecs []string) (map[nat.Port]struct{}, nat.PortMap, error) {
 	return exposedPorts, portBindings, nil
 }
 
+func ulimits(ulimits *yamltypes.Ulimits) []*units.Ulimit {
+	if ulimits == nil {
+		return nil
+	}
+
+	var ret []*units.Ulimit
+	for _, ulimit := range ulimits.Elements {
+		ret = append(ret, &units.Ulimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+
+	return ret
+}
+
 func volumes(volumes *yamltypes.Volumes) []string {
 	if volumes == nil {
 		return nil
@@ -11,7 +11,9 @@ import (
 	"github.com/deviceplane/deviceplane/pkg/engine"
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/pkg/errors"
 )
@@ -27,9 +29,15 @@ func NewEngine() (*Engine, error) {
 	if err != nil {
 		return nil, err
 	}
+	return NewEngineWithClient(client), nil
+}
+
+// NewEngineWithClient creates an engine backed by an already configured
+// client, which allows targeting Docker API compatible daemons.
+func NewEngineWithClient(client *client.Client) *Engine {
 	return &Engine{
 		client: client,
-	}, nil
+	}
 }
 
 func (e *Engine) CreateContainer(ctx context.Context, name string, s models.Service) (string, error) {
@@ -38,11 +46,34 @@ func (e *Engine) CreateContainer(ctx context.Context, name string, s models.Serv
 		return "", err
 	}
 
-	resp, err := e.client.ContainerCreate(ctx, config, hostConfig, nil, name)
+	// Containers can only be created with a single network, the others have
+	// to be connected afterwards
+	endpoints := endpoints(s)
+	var networkingConfig *network.NetworkingConfig
+	if len(endpoints) > 0 {
+		hostConfig.NetworkMode = container.NetworkMode(endpoints[0].network)
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				endpoints[0].network: endpoints[0].settings,
+			},
+		}
+	}
+
+	resp, err := e.client.ContainerCreate(ctx, config, hostConfig, networkingConfig, name)
 	if err != nil {
 		return "", err
 	}
 
+	for i, endpoint := range endpoints {
+		if i == 0 {
+			continue
+		}
+		if err := e.client.NetworkConnect(ctx, endpoint.network, resp.ID, endpoint.settings); err != nil {
+			e.client.ContainerRemove(ctx, resp.ID, types.ContainerRemoveOptions{})
+			return "", err
+		}
+	}
+
 	return resp.ID, nil
 }
 
@@ -52,14 +83,15 @@ func (e *Engine) InspectContainer(ctx context.Context, id string) (*engine.Inspe
 		return nil, err
 	}
 	return &engine.InspectResponse{
-		PID: container.State.Pid,
+		PID:       container.State.Pid,
+		IPAddress: ipAddress(container),
 	}, nil
 }
 
 func (e *Engine) StartContainer(ctx context.Context, id string) error {
 	if err := e.client.ContainerStart(ctx, id, types.ContainerStartOptions{}); err != nil {
 		// TODO
-		if strings.Contains(err.Error(), "No such container") {
+		if isNoSuchContainer(err) {
 			return engine.ErrInstanceNotFound
 		}
 		return err
@@ -95,7 +127,7 @@ func (e *Engine) ListContainers(ctx context.Context, keyFilters map[string]struc
 func (e *Engine) StopContainer(ctx context.Context, id string) error {
 	if err := e.client.ContainerStop(ctx, id, nil); err != nil {
 		// TODO
-		if strings.Contains(err.Error(), "No such container") {
+		if isNoSuchContainer(err) {
 			return engine.ErrInstanceNotFound
 		}
 		return engine.ErrInstanceNotFound
@@ -106,7 +138,7 @@ func (e *Engine) StopContainer(ctx context.Context, id string) error {
 func (e *Engine) RemoveContainer(ctx context.Context, id string) error {
 	if err := e.client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{}); err != nil {
 		// TODO
-		if strings.Contains(err.Error(), "No such container") {
+		if isNoSuchContainer(err) {
 			return engine.ErrInstanceNotFound
 		}
 		return engine.ErrInstanceNotFound
@@ -135,6 +167,32 @@ func (e *Engine) PullImage(ctx context.Context, image, registryAuth string, w io
 	return err
 }
 
+func (e *Engine) Version(ctx context.Context) (*engine.Version, error) {
+	version, err := e.client.ServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.Version{
+		Name:    "docker",
+		Version: version.Version,
+	}, nil
+}
+
+func ipAddress(container types.ContainerJSON) string {
+	if container.NetworkSettings == nil {
+		return ""
+	}
+	if container.NetworkSettings.IPAddress != "" {
+		return container.NetworkSettings.IPAddress
+	}
+	for _, network := range container.NetworkSettings.Networks {
+		if network != nil && network.IPAddress != "" {
+			return network.IPAddress
+		}
+	}
+	return ""
+}
+
 func getProcessedRegistryAuth(registryAuth string) (string, error) {
 	decodedRegistryAuth, err := base64.StdEncoding.DecodeString(registryAuth)
 	if err != nil {
@@ -156,3 +214,9 @@ func getProcessedRegistryAuth(registryAuth string) (string, error) {
 
 	return base64.URLEncoding.EncodeToString(processedRegistryAuthBytes), nil
 }
+
+// isNoSuchContainer matches both Docker's "No such container" and Podman's
+// "no such container" error messages.
+func isNoSuchContainer(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "no such container")
+}
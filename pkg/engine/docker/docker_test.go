@@ -1,10 +1,14 @@
 package docker
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
 	"github.com/docker/docker/api/types"
 	"github.com/stretchr/testify/require"
 )
@@ -32,3 +36,86 @@ func TestGetRegistryAuthConfig(t *testing.T) {
 	require.Equal(t, "username", authConfig.Username)
 	require.Equal(t, "password", authConfig.Password)
 }
+
+func TestConvertStats(t *testing.T) {
+	var stats types.StatsJSON
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{150, 150}
+	stats.CPUStats.SystemUsage = 2000
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Limit = 4000
+	stats.MemoryStats.Stats = map[string]uint64{"cache": 200}
+	stats.Networks = map[string]types.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 20},
+		"eth1": {RxBytes: 1, TxBytes: 2},
+	}
+	stats.BlkioStats.IoServiceBytesRecursive = []types.BlkioStatEntry{
+		{Op: "Read", Value: 5},
+		{Op: "Write", Value: 7},
+		{Op: "Total", Value: 12},
+	}
+	stats.PidsStats.Current = 3
+
+	converted := convertStats(stats)
+	require.Equal(t, float64(40), converted.CPUPercent)
+	require.Equal(t, uint64(800), converted.MemoryUsage)
+	require.Equal(t, uint64(4000), converted.MemoryLimit)
+	require.Equal(t, uint64(11), converted.NetworkRx)
+	require.Equal(t, uint64(22), converted.NetworkTx)
+	require.Equal(t, uint64(5), converted.BlockRead)
+	require.Equal(t, uint64(7), converted.BlockWrite)
+	require.Equal(t, uint64(3), converted.PIDs)
+}
+
+func TestEndpoints(t *testing.T) {
+	require.Nil(t, endpoints(models.Service{}))
+
+	endpoints := endpoints(models.Service{
+		Labels: yamltypes.SliceorMap{
+			models.ServiceLabel: "db",
+		},
+		Networks: &yamltypes.Networks{
+			Networks: []*yamltypes.Network{
+				{Name: "backend-abc123", Aliases: []string{"postgres"}},
+				{Name: "metrics-abc123"},
+			},
+		},
+	})
+	require.Len(t, endpoints, 2)
+	require.Equal(t, "backend-abc123", endpoints[0].network)
+	require.Equal(t, []string{"db", "postgres"}, endpoints[0].settings.Aliases)
+	require.Equal(t, "metrics-abc123", endpoints[1].network)
+	require.Equal(t, []string{"db"}, endpoints[1].settings.Aliases)
+}
+
+func TestInitProcess(t *testing.T) {
+	require.Nil(t, initProcess(false))
+	require.True(t, *initProcess(true))
+}
+
+func TestStopTimeout(t *testing.T) {
+	require.Nil(t, stopTimeout(0))
+	require.Equal(t, 90, *stopTimeout(yamltypes.Duration(90 * time.Second)))
+	require.Equal(t, 1, *stopTimeout(yamltypes.Duration(100 * time.Millisecond)))
+}
+
+func TestDemultiplex(t *testing.T) {
+	frame := func(stream byte, payload string) []byte {
+		header := []byte{stream, 0, 0, 0, 0, 0, 0, byte(len(payload))}
+		return append(header, payload...)
+	}
+
+	var in bytes.Buffer
+	in.Write(frame(stdoutStream, "hello "))
+	in.Write(frame(stderrStream, "oops"))
+	in.Write(frame(stdoutStream, "world"))
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, demultiplex(&stdout, &stderr, &in))
+	require.Equal(t, "hello world", stdout.String())
+	require.Equal(t, "oops", stderr.String())
+
+	require.Equal(t, errUnknownStream, demultiplex(&stdout, &stderr, bytes.NewReader(frame(9, "x"))))
+}
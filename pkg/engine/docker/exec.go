@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/docker/docker/api/types"
+)
+
+const (
+	stdoutStream = 1
+	stderrStream = 2
+)
+
+var (
+	errUnknownStream = errors.New("unknown stream in exec output")
+)
+
+func (e *Engine) ExecContainer(ctx context.Context, id string, config engine.ExecConfig) (int, error) {
+	execConfig := types.ExecConfig{
+		Tty:          config.Tty,
+		AttachStdin:  config.Stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          config.Env,
+		Cmd:          config.Cmd,
+	}
+
+	execResp, err := e.client.ContainerExecCreate(ctx, id, execConfig)
+	if err != nil {
+		if isNoSuchContainer(err) {
+			return 0, engine.ErrInstanceNotFound
+		}
+		return 0, err
+	}
+
+	hijackedResp, err := e.client.ContainerExecAttach(ctx, execResp.ID, execConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer hijackedResp.Close()
+
+	if config.Tty && config.Resize != nil {
+		go func() {
+			for size := range config.Resize {
+				if err := e.client.ContainerExecResize(ctx, execResp.ID, types.ResizeOptions{
+					Height: size.Height,
+					Width:  size.Width,
+				}); err != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	if config.Stdin != nil {
+		go func() {
+			io.Copy(hijackedResp.Conn, config.Stdin)
+			hijackedResp.CloseWrite()
+		}()
+	}
+
+	// Without a TTY stdout and stderr are multiplexed onto the same stream
+	if config.Tty {
+		_, err = io.Copy(config.Stdout, hijackedResp.Reader)
+	} else {
+		err = demultiplex(config.Stdout, config.Stderr, hijackedResp.Reader)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	inspectResp, err := e.client.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	return inspectResp.ExitCode, nil
+}
+
+// demultiplex splits exec output into stdout and stderr. Each frame starts
+// with an eight byte header holding the stream in the first byte and the
+// big endian length of the frame in the last four.
+func demultiplex(stdout, stderr io.Writer, r io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		var w io.Writer
+		switch header[0] {
+		case stdoutStream:
+			w = stdout
+		case stderrStream:
+			w = stderr
+		default:
+			return errUnknownStream
+		}
+
+		if _, err := io.CopyN(w, r, int64(binary.BigEndian.Uint32(header[4:]))); err != nil {
+			return err
+		}
+	}
+}
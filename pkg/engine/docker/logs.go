@@ -0,0 +1,51 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/docker/docker/api/types"
+)
+
+func (e *Engine) StreamContainerLogs(ctx context.Context, id string, config engine.LogsConfig) error {
+	inspectResp, err := e.client.ContainerInspect(ctx, id)
+	if err != nil {
+		if isNoSuchContainer(err) {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     config.Follow,
+		Tail:       "all",
+	}
+	if config.Tail > 0 {
+		options.Tail = strconv.Itoa(config.Tail)
+	}
+
+	resp, err := e.client.ContainerLogs(ctx, id, options)
+	if err != nil {
+		if isNoSuchContainer(err) {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+	defer resp.Close()
+
+	// Logs are multiplexed the same way exec output is, unless the
+	// container has a TTY
+	if inspectResp.Config != nil && inspectResp.Config.Tty {
+		_, err = io.Copy(config.Stdout, resp)
+	} else {
+		err = demultiplex(config.Stdout, config.Stderr, resp)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func (e *Engine) CreateNetwork(ctx context.Context, name string, labels map[string]string) (string, error) {
+	resp, err := e.client.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Labels:         labels,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+func (e *Engine) ListNetworks(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string) ([]engine.Network, error) {
+	args := filters.NewArgs()
+	for k := range keyFilters {
+		args.Add("label", k)
+	}
+	for k, v := range keyAndValueFilters {
+		args.Add("label", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	networks, err := e.client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []engine.Network
+	for _, network := range networks {
+		ret = append(ret, engine.Network{
+			ID:     network.ID,
+			Name:   network.Name,
+			Labels: network.Labels,
+		})
+	}
+
+	return ret, nil
+}
+
+func (e *Engine) RemoveNetwork(ctx context.Context, id string) error {
+	if err := e.client.NetworkRemove(ctx, id); err != nil {
+		if isNoSuchNetwork(err) {
+			return engine.ErrNetworkNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// isNoSuchNetwork matches the different "not found" error messages returned
+// when removing networks.
+func isNoSuchNetwork(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such network") || strings.Contains(message, "not found")
+}
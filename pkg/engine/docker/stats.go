@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/docker/docker/api/types"
+)
+
+func (e *Engine) StreamContainerStats(ctx context.Context, id string, f func(engine.Stats) error) error {
+	resp, err := e.client.ContainerStats(ctx, id, true)
+	if err != nil {
+		if isNoSuchContainer(err) {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var stats types.StatsJSON
+		if err := decoder.Decode(&stats); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if err := f(convertStats(stats)); err != nil {
+			return err
+		}
+	}
+}
+
+// convertStats computes the same values as the docker stats command.
+func convertStats(stats types.StatsJSON) engine.Stats {
+	ret := engine.Stats{
+		Time:        stats.Read,
+		MemoryUsage: stats.MemoryStats.Usage,
+		MemoryLimit: stats.MemoryStats.Limit,
+		PIDs:        stats.PidsStats.Current,
+	}
+
+	// Page cache can be reclaimed so it isn't counted as used memory
+	if cache, ok := stats.MemoryStats.Stats["cache"]; ok && cache < ret.MemoryUsage {
+		ret.MemoryUsage -= cache
+	}
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := len(stats.CPUStats.CPUUsage.PercpuUsage)
+		if cpus == 0 {
+			cpus = 1
+		}
+		ret.CPUPercent = cpuDelta / systemDelta * float64(cpus) * 100
+	}
+
+	for _, network := range stats.Networks {
+		ret.NetworkRx += network.RxBytes
+		ret.NetworkTx += network.TxBytes
+	}
+
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch entry.Op {
+		case "Read", "read":
+			ret.BlockRead += entry.Value
+		case "Write", "write":
+			ret.BlockWrite += entry.Value
+		}
+	}
+
+	return ret
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"errors"
 	"io"
+	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/models"
 )
 
 var (
-	ErrInstanceNotFound = errors.New("instance not found")
+	ErrInstanceNotFound  = errors.New("instance not found")
+	ErrStatsNotSupported = errors.New("stats are not supported for this instance")
+	ErrNetworkNotFound   = errors.New("network not found")
+	ErrExecNotSupported  = errors.New("exec is not supported for this instance")
+	ErrLogsNotSupported  = errors.New("logs are not supported for this instance")
 )
 
 type Engine interface {
@@ -20,7 +25,27 @@ type Engine interface {
 	StopContainer(context.Context, string) error
 	RemoveContainer(context.Context, string) error
 
+	// StreamContainerStats calls the given function with a new sample
+	// roughly every second until the context is cancelled, the instance
+	// stops, or the function returns an error.
+	StreamContainerStats(context.Context, string, func(Stats) error) error
+
+	// ExecContainer runs a command inside a running instance and returns
+	// its exit code once it exits.
+	ExecContainer(context.Context, string, ExecConfig) (int, error)
+
+	// StreamContainerLogs writes an instance's output to the writers in
+	// the config. If Follow is set it keeps writing until the context is
+	// cancelled or the instance stops.
+	StreamContainerLogs(context.Context, string, LogsConfig) error
+
 	PullImage(context.Context, string, string, io.Writer) error
+
+	CreateNetwork(context.Context, string, map[string]string) (string, error)
+	ListNetworks(context.Context, map[string]struct{}, map[string]string) ([]Network, error)
+	RemoveNetwork(context.Context, string) error
+
+	Version(context.Context) (*Version, error)
 }
 
 type Instance struct {
@@ -29,6 +54,76 @@ type Instance struct {
 	Running bool
 }
 
+type Network struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+type Version struct {
+	Name    string
+	Version string
+}
+
+// InspectResponse describes a running instance. Engines that can't see the
+// instance's process leave PID as zero, and it's reached on its IP address
+// instead.
 type InspectResponse struct {
-	PID int
+	PID       int
+	IPAddress string
+}
+
+type ExecConfig struct {
+	Cmd    []string
+	Env    []string
+	Tty    bool
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Resize receives the size of the terminal whenever it changes. It's
+	// only used when Tty is set.
+	Resize <-chan WindowSize
+}
+
+type LogsConfig struct {
+	Follow bool
+	// Tail is how many of the most recent lines to start from. All of them
+	// are written if it's zero.
+	Tail   int
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type WindowSize struct {
+	Height uint
+	Width  uint
+}
+
+type Stats struct {
+	Time        time.Time `json:"time"`
+	CPUPercent  float64   `json:"cpuPercent"`
+	MemoryUsage uint64    `json:"memoryUsage"`
+	MemoryLimit uint64    `json:"memoryLimit"`
+	NetworkRx   uint64    `json:"networkRx"`
+	NetworkTx   uint64    `json:"networkTx"`
+	BlockRead   uint64    `json:"blockRead"`
+	BlockWrite  uint64    `json:"blockWrite"`
+	PIDs        uint64    `json:"pids"`
+}
+
+// MatchesFilters reports whether labels satisfy the filters passed to
+// ListContainers, for engines that can't filter on labels themselves.
+func MatchesFilters(labels map[string]string, keyFilters map[string]struct{}, keyAndValueFilters map[string]string) bool {
+	for k := range keyFilters {
+		if _, ok := labels[k]; !ok {
+			return false
+		}
+	}
+	for k, v := range keyAndValueFilters {
+		if value, ok := labels[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
 }
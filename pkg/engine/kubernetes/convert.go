@@ -0,0 +1,440 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/spec"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
+	"github.com/docker/go-connections/nat"
+)
+
+const (
+	managedByLabel      = "app.kubernetes.io/managed-by"
+	managedByValue      = "deviceplane"
+	labelsAnnotation    = "deviceplane.com/labels"
+	containerName       = "service"
+	registryAuthSecret  = "deviceplane-registry-auth"
+	maxNameLength       = 63
+	cpuQuotaPeriodMicro = 100000
+)
+
+var invalidNameCharacters = regexp.MustCompile("[^a-z0-9-]+")
+
+// podName converts a container name into a valid DNS-1123 label.
+func podName(name string) string {
+	name = invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return strings.Trim(name, "-")
+}
+
+func convert(name string, s models.Service) (*pod, error) {
+	// Every service runs in its own pod, and containers can't join the
+	// network namespace of another pod
+	if strings.HasPrefix(s.NetworkMode, models.NetworkModeContainerPrefix) {
+		return nil, fmt.Errorf("network mode %s is not supported by the kubernetes engine", s.NetworkMode)
+	}
+
+	labelsBytes, err := json.Marshal(map[string]string(s.Labels))
+	if err != nil {
+		return nil, err
+	}
+
+	ports, err := ports(s.Ports)
+	if err != nil {
+		return nil, err
+	}
+
+	env, err := env(s.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, volumeMounts := volumes(s.Volumes, s.Devices)
+
+	securityContext, err := containerSecurityContext(s)
+	if err != nil {
+		return nil, err
+	}
+
+	podSecurityContext, err := newPodSecurityContext(s)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsPolicy, dnsConfig := dns(s)
+
+	return &pod{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Metadata: objectMeta{
+			Name: podName(name),
+			Labels: map[string]string{
+				managedByLabel: managedByValue,
+			},
+			Annotations: map[string]string{
+				labelsAnnotation: string(labelsBytes),
+			},
+		},
+		Spec: podSpec{
+			Containers: []container{
+				{
+					Name:            containerName,
+					Image:           s.Image,
+					Command:         s.Entrypoint,
+					Args:            s.Command,
+					WorkingDir:      s.WorkingDir,
+					Env:             env,
+					Ports:           ports,
+					VolumeMounts:    volumeMounts,
+					Resources:       resources(s),
+					SecurityContext: securityContext,
+					// Images are pulled by the agent ahead of time whenever
+					// possible, let the kubelet reuse them
+					ImagePullPolicy: "IfNotPresent",
+				},
+			},
+			Volumes:                       volumes,
+			RestartPolicy:                 restartPolicy(s.Restart),
+			HostNetwork:                   s.NetworkMode == models.NetworkModeHost,
+			HostPID:                       s.Pid == "host",
+			ShareProcessNamespace:         shareProcessNamespace(s),
+			HostIPC:                       s.Ipc == "host",
+			Hostname:                      s.Hostname,
+			Subdomain:                     s.DomainName,
+			HostAliases:                   hostAliases(s.ExtraHosts),
+			TerminationGracePeriodSeconds: terminationGracePeriod(s.StopGracePeriod),
+			DNSPolicy:                     dnsPolicy,
+			DNSConfig:                     dnsConfig,
+			RuntimeClassName:              s.Runtime,
+			SecurityContext:               podSecurityContext,
+			ImagePullSecrets: []localObjectReference{
+				{
+					Name: registryAuthSecret,
+				},
+			},
+		},
+	}, nil
+}
+
+func convertToInstance(p pod) (*engine.Instance, error) {
+	var labels map[string]string
+	if l, ok := p.Metadata.Annotations[labelsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(l), &labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return &engine.Instance{
+		ID:      p.Metadata.Name,
+		Labels:  labels,
+		Running: p.Status.Phase == "Running" && p.Metadata.DeletionTimestamp == nil,
+	}, nil
+}
+
+func env(environment yamltypes.MaporEqualSlice) ([]envVar, error) {
+	var envVars []envVar
+	for _, e := range environment {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid environment variable %q", e)
+		}
+		envVars = append(envVars, envVar{
+			Name:  parts[0],
+			Value: parts[1],
+		})
+	}
+	return envVars, nil
+}
+
+func ports(portSpecs []string) ([]containerPort, error) {
+	var containerPorts []containerPort
+	for _, portSpec := range portSpecs {
+		portMappings, err := nat.ParsePortSpec(portSpec)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, portMapping := range portMappings {
+			cp := containerPort{
+				ContainerPort: portMapping.Port.Int(),
+				HostIP:        portMapping.Binding.HostIP,
+				Protocol:      strings.ToUpper(portMapping.Port.Proto()),
+			}
+			if portMapping.Binding.HostPort != "" {
+				hostPort, err := strconv.Atoi(portMapping.Binding.HostPort)
+				if err != nil {
+					return nil, err
+				}
+				cp.HostPort = hostPort
+			}
+			containerPorts = append(containerPorts, cp)
+		}
+	}
+	return containerPorts, nil
+}
+
+func volumes(vols *yamltypes.Volumes, devices []string) ([]volume, []volumeMount) {
+	var volumes []volume
+	var volumeMounts []volumeMount
+
+	if vols != nil {
+		for i, v := range vols.Volumes {
+			name := fmt.Sprintf("volume-%d", i)
+
+			vol := volume{
+				Name: name,
+			}
+			if filepath.IsAbs(v.Source) {
+				vol.HostPath = &hostPathVolumeSource{
+					Path: v.Source,
+				}
+			} else {
+				vol.EmptyDir = &emptyDirVolumeSource{}
+			}
+			volumes = append(volumes, vol)
+
+			volumeMounts = append(volumeMounts, volumeMount{
+				Name:      name,
+				MountPath: v.Destination,
+				ReadOnly:  strings.Contains(v.AccessMode, "ro"),
+			})
+		}
+	}
+
+	// Kubernetes has no notion of device mappings outside of device plugins,
+	// so devices are mounted from the host instead. The device cgroup only
+	// allows access to them for privileged services.
+	for i, device := range devices {
+		name := fmt.Sprintf("device-%d", i)
+
+		parts := strings.SplitN(device, ":", 3)
+		pathOnHost, pathInContainer := parts[0], parts[0]
+		if len(parts) > 1 {
+			pathInContainer = parts[1]
+		}
+
+		volumes = append(volumes, volume{
+			Name: name,
+			HostPath: &hostPathVolumeSource{
+				Path: pathOnHost,
+			},
+		})
+		volumeMounts = append(volumeMounts, volumeMount{
+			Name:      name,
+			MountPath: pathInContainer,
+		})
+	}
+
+	return volumes, volumeMounts
+}
+
+func resources(s models.Service) resourceRequirements {
+	limits := make(map[string]string)
+	requests := make(map[string]string)
+
+	if s.MemLimit > 0 {
+		limits["memory"] = strconv.FormatInt(int64(s.MemLimit), 10)
+	}
+	if s.MemReservation > 0 {
+		requests["memory"] = strconv.FormatInt(int64(s.MemReservation), 10)
+	}
+	if s.CPUQuota > 0 {
+		limits["cpu"] = fmt.Sprintf("%dm", int64(s.CPUQuota)*1000/cpuQuotaPeriodMicro)
+	}
+	if s.CPUShares > 0 {
+		// 1024 shares is equivalent to a full CPU
+		requests["cpu"] = fmt.Sprintf("%dm", int64(s.CPUShares)*1000/1024)
+	}
+
+	var r resourceRequirements
+	if len(limits) > 0 {
+		r.Limits = limits
+	}
+	if len(requests) > 0 {
+		r.Requests = requests
+	}
+	return r
+}
+
+func containerSecurityContext(s models.Service) (*securityContext, error) {
+	sc := securityContext{}
+	empty := true
+
+	if s.Privileged {
+		sc.Privileged = &s.Privileged
+		empty = false
+	}
+	if s.ReadOnly {
+		sc.ReadOnlyRootFilesystem = &s.ReadOnly
+		empty = false
+	}
+	if spec.NoNewPrivileges(s) {
+		allowPrivilegeEscalation := false
+		sc.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		empty = false
+	}
+	if len(s.CapAdd) > 0 || len(s.CapDrop) > 0 {
+		sc.Capabilities = &capabilities{
+			Add:  capabilityNames(s.CapAdd),
+			Drop: capabilityNames(s.CapDrop),
+		}
+		empty = false
+	}
+	if s.User != "" {
+		// Kubernetes only accepts numeric users and groups
+		parts := strings.SplitN(s.User, ":", 2)
+		uid, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("user %q must be numeric", s.User)
+		}
+		sc.RunAsUser = &uid
+		if len(parts) == 2 {
+			gid, err := strconv.ParseInt(parts[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("group %q must be numeric", s.User)
+			}
+			sc.RunAsGroup = &gid
+		}
+		empty = false
+	}
+
+	if empty {
+		return nil, nil
+	}
+	return &sc, nil
+}
+
+// shareProcessNamespace makes the pause container PID 1 so that it reaps
+// zombies, which is the closest equivalent to docker's init process.
+// Pods using the host's PID namespace can't share their own.
+func shareProcessNamespace(s models.Service) bool {
+	return s.Init && s.Pid != "host"
+}
+
+func terminationGracePeriod(stopGracePeriod yamltypes.Duration) *int64 {
+	if stopGracePeriod == 0 {
+		return nil
+	}
+	seconds := stopGracePeriod.Seconds()
+	return &seconds
+}
+
+func capabilityNames(caps []string) []string {
+	var names []string
+	for _, c := range caps {
+		names = append(names, strings.TrimPrefix(strings.ToUpper(c), "CAP_"))
+	}
+	return names
+}
+
+func newPodSecurityContext(s models.Service) (*podSecurityContext, error) {
+	if len(s.GroupAdd) == 0 && len(s.Sysctls) == 0 {
+		return nil, nil
+	}
+
+	var gids []int64
+	for _, g := range s.GroupAdd {
+		gid, err := strconv.ParseInt(g, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("group %q must be numeric", g)
+		}
+		gids = append(gids, gid)
+	}
+
+	var sysctls []sysctl
+	for name, value := range s.Sysctls.ToMap() {
+		sysctls = append(sysctls, sysctl{
+			Name:  name,
+			Value: value,
+		})
+	}
+	sort.Slice(sysctls, func(i, j int) bool {
+		return sysctls[i].Name < sysctls[j].Name
+	})
+
+	return &podSecurityContext{
+		SupplementalGroups: gids,
+		Sysctls:            sysctls,
+	}, nil
+}
+
+func hostAliases(extraHosts []string) []hostAlias {
+	var aliases []hostAlias
+	for _, extraHost := range extraHosts {
+		parts := strings.SplitN(extraHost, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		aliases = append(aliases, hostAlias{
+			IP:        parts[1],
+			Hostnames: []string{parts[0]},
+		})
+	}
+	return aliases
+}
+
+func dns(s models.Service) (string, *podDNSConfig) {
+	if len(s.DNS) == 0 && len(s.DNSSearch) == 0 && len(s.DNSOpts) == 0 {
+		return "", nil
+	}
+
+	config := podDNSConfig{
+		Nameservers: s.DNS,
+		Searches:    s.DNSSearch,
+	}
+	for _, opt := range s.DNSOpts {
+		parts := strings.SplitN(opt, ":", 2)
+		option := podDNSConfigOption{
+			Name: parts[0],
+		}
+		if len(parts) == 2 {
+			option.Value = &parts[1]
+		}
+		config.Options = append(config.Options, option)
+	}
+
+	// Explicit nameservers replace the cluster's resolver, same as with
+	// docker
+	policy := ""
+	if len(s.DNS) > 0 {
+		policy = "None"
+	}
+
+	return policy, &config
+}
+
+func restartPolicy(restart string) string {
+	switch {
+	case restart == "always", restart == "unless-stopped":
+		return "Always"
+	case strings.HasPrefix(restart, "on-failure"):
+		return "OnFailure"
+	default:
+		return "Never"
+	}
+}
+
+// registryServer returns the docker config key for the registry hosting a
+// canonical image name.
+func registryServer(image string) string {
+	server := strings.SplitN(image, "/", 2)[0]
+	if server == "docker.io" {
+		return "https://index.docker.io/v1/"
+	}
+	return server
+}
+
+func base64Encode(b []byte) string {
+	return base64.StdEncoding.EncodeToString(b)
+}
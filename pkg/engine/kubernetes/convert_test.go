@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodName(t *testing.T) {
+	require.Equal(t, "my-service-abc123-def456", podName("My_Service-abc123-def456"))
+	require.Equal(t, 63, len(podName("a-very-long-service-name-that-goes-well-over-the-limit-abc123-def456")))
+	require.Equal(t, "service", podName("-service-"))
+}
+
+func TestConvert(t *testing.T) {
+	p, err := convert("web-abc123-def456", models.Service{
+		Image:       "nginx",
+		Command:     yamltypes.Command{"nginx", "-g", "daemon off;"},
+		Environment: yamltypes.MaporEqualSlice{"A=b", "C=d=e"},
+		Labels: yamltypes.SliceorMap{
+			models.ServiceLabel: "web",
+		},
+		Ports:           []string{"8080:80", "53/udp"},
+		NetworkMode:     "host",
+		Privileged:      true,
+		Init:            true,
+		ReadOnly:        true,
+		SecurityOpt:     []string{"no-new-privileges"},
+		User:            "1000:1000",
+		MemLimit:        1024,
+		CPUQuota:        50000,
+		Restart:         "always",
+		StopGracePeriod: yamltypes.Duration(30 * time.Second),
+		ExtraHosts:      []string{"db:10.0.0.2"},
+		Sysctls:         yamltypes.MaporEqualSlice{"net.ipv4.ip_forward=1"},
+		Volumes: &yamltypes.Volumes{
+			Volumes: []*yamltypes.Volume{
+				{Source: "/data", Destination: "/data", AccessMode: "ro"},
+				{Destination: "/cache"},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "web-abc123-def456", p.Metadata.Name)
+	require.Equal(t, `{"com.deviceplane.service":"web"}`, p.Metadata.Annotations[labelsAnnotation])
+
+	c := p.Spec.Containers[0]
+	require.Equal(t, "nginx", c.Image)
+	require.Equal(t, []string{"nginx", "-g", "daemon off;"}, c.Args)
+	require.Equal(t, []envVar{{Name: "A", Value: "b"}, {Name: "C", Value: "d=e"}}, c.Env)
+	require.Equal(t, []containerPort{
+		{ContainerPort: 80, HostPort: 8080, Protocol: "TCP"},
+		{ContainerPort: 53, Protocol: "UDP"},
+	}, c.Ports)
+	require.True(t, *c.SecurityContext.Privileged)
+	require.True(t, *c.SecurityContext.ReadOnlyRootFilesystem)
+	require.False(t, *c.SecurityContext.AllowPrivilegeEscalation)
+	require.Equal(t, int64(1000), *c.SecurityContext.RunAsUser)
+	require.Equal(t, int64(1000), *c.SecurityContext.RunAsGroup)
+	require.Equal(t, "1024", c.Resources.Limits["memory"])
+	require.Equal(t, "500m", c.Resources.Limits["cpu"])
+	require.Equal(t, []volumeMount{
+		{Name: "volume-0", MountPath: "/data", ReadOnly: true},
+		{Name: "volume-1", MountPath: "/cache"},
+	}, c.VolumeMounts)
+
+	require.True(t, p.Spec.HostNetwork)
+	require.True(t, p.Spec.ShareProcessNamespace)
+	require.Equal(t, "Always", p.Spec.RestartPolicy)
+	require.Equal(t, int64(30), *p.Spec.TerminationGracePeriodSeconds)
+	require.Equal(t, []hostAlias{{IP: "10.0.0.2", Hostnames: []string{"db"}}}, p.Spec.HostAliases)
+	require.Equal(t, []sysctl{{Name: "net.ipv4.ip_forward", Value: "1"}}, p.Spec.SecurityContext.Sysctls)
+	require.Equal(t, "/data", p.Spec.Volumes[0].HostPath.Path)
+	require.NotNil(t, p.Spec.Volumes[1].EmptyDir)
+
+	instance, err := convertToInstance(*p)
+	require.NoError(t, err)
+	require.Equal(t, "web", instance.Labels[models.ServiceLabel])
+	require.False(t, instance.Running)
+
+	_, err = convert("web", models.Service{User: "nobody"})
+	require.Error(t, err)
+
+	_, err = convert("web", models.Service{NetworkMode: "container:vpn-abc123-def456"})
+	require.Error(t, err)
+}
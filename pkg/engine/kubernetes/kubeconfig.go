@@ -0,0 +1,182 @@
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+			Token                 string `yaml:"token"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+type restConfig struct {
+	server     string
+	token      string
+	httpClient *http.Client
+}
+
+func loadKubeconfig(path string) (*restConfig, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config kubeconfig
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, errors.Wrap(err, "parse kubeconfig")
+	}
+
+	clusterName, userName := "", ""
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+		}
+	}
+
+	tlsConfig := &tls.Config{}
+	restConfig := &restConfig{}
+
+	clusterFound := false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		clusterFound = true
+
+		restConfig.server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		caBytes, err := dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, errors.Wrap(err, "certificate authority")
+		}
+		if caBytes != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caBytes) {
+				return nil, errors.New("invalid certificate authority")
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !clusterFound {
+		return nil, fmt.Errorf("cluster for context %q not found", config.CurrentContext)
+	}
+
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+
+		restConfig.token = u.User.Token
+
+		certBytes, err := dataOrFile(u.User.ClientCertificateData, u.User.ClientCertificate)
+		if err != nil {
+			return nil, errors.Wrap(err, "client certificate")
+		}
+		keyBytes, err := dataOrFile(u.User.ClientKeyData, u.User.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "client key")
+		}
+		if certBytes != nil && keyBytes != nil {
+			cert, err := tls.X509KeyPair(certBytes, keyBytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "client key pair")
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	restConfig.httpClient = newHTTPClient(tlsConfig)
+	return restConfig, nil
+}
+
+// loadInClusterConfig is used when the agent itself runs as a pod.
+func loadInClusterConfig() (*restConfig, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a cluster")
+	}
+
+	token, err := ioutil.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, err
+	}
+
+	caBytes, err := ioutil.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBytes) {
+		return nil, errors.New("invalid certificate authority")
+	}
+
+	return &restConfig{
+		server: "https://" + net.JoinHostPort(host, port),
+		token:  string(token),
+		httpClient: newHTTPClient(&tls.Config{
+			RootCAs: pool,
+		}),
+	}, nil
+}
+
+func dataOrFile(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return ioutil.ReadFile(file)
+	}
+	return nil, nil
+}
+
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
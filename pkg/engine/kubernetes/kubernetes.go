@@ -0,0 +1,342 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultKubeconfig = "/etc/rancher/k3s/k3s.yaml"
+	DefaultNamespace  = "deviceplane"
+)
+
+var (
+	_ engine.Engine = &Engine{}
+
+	errNotFound = errors.New("not found")
+
+	// Pods all share the cluster network
+	ErrNetworksNotSupported = errors.New("networks are not supported by the kubernetes engine")
+)
+
+// Engine runs each service as a single container pod on a local k3s or
+// kubelet based cluster. Pods are created in a dedicated namespace and the
+// kubelet is left in charge of pulling images and restarting containers.
+type Engine struct {
+	config    *restConfig
+	namespace string
+}
+
+func NewEngine(kubeconfigPath, namespace string) (*Engine, error) {
+	var config *restConfig
+	var err error
+	if kubeconfigPath == "" {
+		config, err = loadInClusterConfig()
+	} else {
+		config, err = loadKubeconfig(kubeconfigPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+
+	return &Engine{
+		config:    config,
+		namespace: namespace,
+	}, nil
+}
+
+func (e *Engine) CreateContainer(ctx context.Context, name string, s models.Service) (string, error) {
+	pod, err := convert(name, s)
+	if err != nil {
+		return "", err
+	}
+
+	err = e.do(ctx, "POST", e.namespacePath("pods"), pod, nil)
+	if err == errNotFound {
+		if err := e.createNamespace(ctx); err != nil {
+			return "", err
+		}
+		err = e.do(ctx, "POST", e.namespacePath("pods"), pod, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return pod.Metadata.Name, nil
+}
+
+// InspectContainer returns the pod's IP address, which is empty until the
+// pod has been scheduled. The kubelet doesn't expose host PIDs through the
+// API, so there's no PID, and the pod's ports are reached on its address
+// rather than from inside its network namespace.
+func (e *Engine) InspectContainer(ctx context.Context, id string) (*engine.InspectResponse, error) {
+	var p pod
+	if err := e.do(ctx, "GET", e.namespacePath("pods", id), nil, &p); err != nil {
+		if err == errNotFound {
+			return nil, engine.ErrInstanceNotFound
+		}
+		return nil, err
+	}
+	return &engine.InspectResponse{
+		IPAddress: p.Status.PodIP,
+	}, nil
+}
+
+// StartContainer only checks that the pod exists, since the kubelet starts
+// pods as soon as they're scheduled.
+func (e *Engine) StartContainer(ctx context.Context, id string) error {
+	var p pod
+	if err := e.do(ctx, "GET", e.namespacePath("pods", id), nil, &p); err != nil {
+		if err == errNotFound {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) ListContainers(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string, all bool) ([]engine.Instance, error) {
+	query := url.Values{}
+	query.Set("labelSelector", fmt.Sprintf("%s=%s", managedByLabel, managedByValue))
+
+	var pods podList
+	err := e.do(ctx, "GET", e.namespacePath("pods")+"?"+query.Encode(), nil, &pods)
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []engine.Instance
+	for _, p := range pods.Items {
+		instance, err := convertToInstance(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if !all && !instance.Running {
+			continue
+		}
+		if !engine.MatchesFilters(instance.Labels, keyFilters, keyAndValueFilters) {
+			continue
+		}
+
+		instances = append(instances, *instance)
+	}
+
+	return instances, nil
+}
+
+// StopContainer deletes the pod, since pods can't be stopped. The stop
+// signal and grace period are handled by the kubelet.
+func (e *Engine) StopContainer(ctx context.Context, id string) error {
+	if err := e.do(ctx, "DELETE", e.namespacePath("pods", id), nil, nil); err != nil {
+		if err == errNotFound {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) RemoveContainer(ctx context.Context, id string) error {
+	return e.StopContainer(ctx, id)
+}
+
+// StreamContainerStats isn't supported since the kubelet only exposes
+// resource usage through the metrics server, which isn't always installed.
+func (e *Engine) StreamContainerStats(ctx context.Context, id string, f func(engine.Stats) error) error {
+	return engine.ErrStatsNotSupported
+}
+
+// ExecContainer isn't supported since the kubelet only exposes exec over
+// SPDY or WebSocket streams that this client doesn't speak.
+func (e *Engine) ExecContainer(ctx context.Context, id string, config engine.ExecConfig) (int, error) {
+	return 0, engine.ErrExecNotSupported
+}
+
+// StreamContainerLogs writes the pod's logs to stdout, since the kubelet
+// interleaves stdout and stderr.
+func (e *Engine) StreamContainerLogs(ctx context.Context, id string, config engine.LogsConfig) error {
+	query := url.Values{}
+	if config.Follow {
+		query.Set("follow", "true")
+	}
+	if config.Tail > 0 {
+		query.Set("tailLines", strconv.Itoa(config.Tail))
+	}
+
+	resp, err := e.send(ctx, http.MethodGet, e.namespacePath("pods", id, "log")+"?"+query.Encode(), nil, "*/*")
+	if err != nil {
+		if err == errNotFound {
+			return engine.ErrInstanceNotFound
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(config.Stdout, resp.Body)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// PullImage doesn't pull anything itself since pulls are done by the
+// kubelet. It does keep the registry credentials referenced by pods up to
+// date.
+func (e *Engine) PullImage(ctx context.Context, image, registryAuth string, w io.Writer) error {
+	if registryAuth == "" {
+		return nil
+	}
+
+	dockerConfigBytes, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			registryServer(image): map[string]string{
+				"auth": registryAuth,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s := secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name: registryAuthSecret,
+			Labels: map[string]string{
+				managedByLabel: managedByValue,
+			},
+		},
+		Type: "kubernetes.io/dockerconfigjson",
+		Data: map[string]string{
+			".dockerconfigjson": base64Encode(dockerConfigBytes),
+		},
+	}
+
+	err = e.do(ctx, "PUT", e.namespacePath("secrets", registryAuthSecret), s, nil)
+	if err == errNotFound {
+		err = e.do(ctx, "POST", e.namespacePath("secrets"), s, nil)
+		if err == errNotFound {
+			if err := e.createNamespace(ctx); err != nil {
+				return err
+			}
+			err = e.do(ctx, "POST", e.namespacePath("secrets"), s, nil)
+		}
+	}
+	return err
+}
+
+func (e *Engine) CreateNetwork(ctx context.Context, name string, labels map[string]string) (string, error) {
+	return "", ErrNetworksNotSupported
+}
+
+func (e *Engine) ListNetworks(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string) ([]engine.Network, error) {
+	return nil, nil
+}
+
+func (e *Engine) RemoveNetwork(ctx context.Context, id string) error {
+	return engine.ErrNetworkNotFound
+}
+
+// Version reports the version of the kubelet's API server.
+func (e *Engine) Version(ctx context.Context) (*engine.Version, error) {
+	var info versionInfo
+	if err := e.do(ctx, "GET", "/version", nil, &info); err != nil {
+		return nil, err
+	}
+	return &engine.Version{
+		Name:    "kubernetes",
+		Version: info.GitVersion,
+	}, nil
+}
+
+func (e *Engine) createNamespace(ctx context.Context) error {
+	return e.do(ctx, "POST", "/api/v1/namespaces", namespace{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Metadata: objectMeta{
+			Name: e.namespace,
+		},
+	}, nil)
+}
+
+func (e *Engine) namespacePath(parts ...string) string {
+	return strings.Join(append([]string{"/api/v1/namespaces", e.namespace}, parts...), "/")
+}
+
+func (e *Engine) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		reqBytes, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(reqBytes)
+	}
+
+	resp, err := e.send(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send makes a request to the API server, and returns the response if it
+// succeeded. The caller has to close its body.
+func (e *Engine) send(ctx context.Context, method, path string, body io.Reader, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.config.server, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.config.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.config.token)
+	}
+
+	resp, err := e.config.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errNotFound
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		defer resp.Body.Close()
+		var s status
+		if err := json.NewDecoder(resp.Body).Decode(&s); err != nil || s.Message == "" {
+			return nil, fmt.Errorf("kubernetes API returned %s", resp.Status)
+		}
+		return nil, errors.New(s.Message)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,73 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectContainer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/deviceplane/pods/web":
+			w.Write([]byte(`{"metadata":{"name":"web"},"spec":{"containers":[]},"status":{"phase":"Running","podIP":"10.42.0.5"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	e := &Engine{
+		config: &restConfig{
+			server:     server.URL,
+			httpClient: server.Client(),
+		},
+		namespace: DefaultNamespace,
+	}
+
+	inspectResponse, err := e.InspectContainer(context.Background(), "web")
+	require.NoError(t, err)
+	require.Equal(t, &engine.InspectResponse{IPAddress: "10.42.0.5"}, inspectResponse)
+
+	_, err = e.InspectContainer(context.Background(), "db")
+	require.Equal(t, engine.ErrInstanceNotFound, err)
+}
+
+func TestStreamContainerLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/deviceplane/pods/web/log":
+			require.Equal(t, "true", r.URL.Query().Get("follow"))
+			require.Equal(t, "10", r.URL.Query().Get("tailLines"))
+			w.Write([]byte("listening on :80\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	e := &Engine{
+		config: &restConfig{
+			server:     server.URL,
+			httpClient: server.Client(),
+		},
+		namespace: DefaultNamespace,
+	}
+
+	var stdout bytes.Buffer
+	err := e.StreamContainerLogs(context.Background(), "web", engine.LogsConfig{
+		Follow: true,
+		Tail:   10,
+		Stdout: &stdout,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "listening on :80\n", stdout.String())
+
+	err = e.StreamContainerLogs(context.Background(), "db", engine.LogsConfig{Stdout: &stdout})
+	require.Equal(t, engine.ErrInstanceNotFound, err)
+}
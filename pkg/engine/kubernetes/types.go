@@ -0,0 +1,169 @@
+package kubernetes
+
+// Only the subset of the Kubernetes API objects used by the engine is
+// modeled here.
+
+type objectMeta struct {
+	Name              string            `json:"name,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	DeletionTimestamp *string           `json:"deletionTimestamp,omitempty"`
+}
+
+type pod struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   objectMeta `json:"metadata"`
+	Spec       podSpec    `json:"spec"`
+	Status     podStatus  `json:"status,omitempty"`
+}
+
+type podList struct {
+	Items []pod `json:"items"`
+}
+
+type podSpec struct {
+	Containers                    []container            `json:"containers"`
+	Volumes                       []volume               `json:"volumes,omitempty"`
+	RestartPolicy                 string                 `json:"restartPolicy,omitempty"`
+	HostNetwork                   bool                   `json:"hostNetwork,omitempty"`
+	HostPID                       bool                   `json:"hostPID,omitempty"`
+	ShareProcessNamespace         bool                   `json:"shareProcessNamespace,omitempty"`
+	HostIPC                       bool                   `json:"hostIPC,omitempty"`
+	Hostname                      string                 `json:"hostname,omitempty"`
+	Subdomain                     string                 `json:"subdomain,omitempty"`
+	HostAliases                   []hostAlias            `json:"hostAliases,omitempty"`
+	DNSPolicy                     string                 `json:"dnsPolicy,omitempty"`
+	DNSConfig                     *podDNSConfig          `json:"dnsConfig,omitempty"`
+	RuntimeClassName              string                 `json:"runtimeClassName,omitempty"`
+	SecurityContext               *podSecurityContext    `json:"securityContext,omitempty"`
+	ImagePullSecrets              []localObjectReference `json:"imagePullSecrets,omitempty"`
+	TerminationGracePeriodSeconds *int64                 `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+type podStatus struct {
+	Phase string `json:"phase,omitempty"`
+	PodIP string `json:"podIP,omitempty"`
+}
+
+type container struct {
+	Name            string               `json:"name"`
+	Image           string               `json:"image"`
+	Command         []string             `json:"command,omitempty"`
+	Args            []string             `json:"args,omitempty"`
+	WorkingDir      string               `json:"workingDir,omitempty"`
+	Env             []envVar             `json:"env,omitempty"`
+	Ports           []containerPort      `json:"ports,omitempty"`
+	VolumeMounts    []volumeMount        `json:"volumeMounts,omitempty"`
+	Resources       resourceRequirements `json:"resources,omitempty"`
+	SecurityContext *securityContext     `json:"securityContext,omitempty"`
+	ImagePullPolicy string               `json:"imagePullPolicy,omitempty"`
+}
+
+type envVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type containerPort struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort,omitempty"`
+	HostIP        string `json:"hostIP,omitempty"`
+	Protocol      string `json:"protocol,omitempty"`
+}
+
+type volumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+type volume struct {
+	Name     string                `json:"name"`
+	HostPath *hostPathVolumeSource `json:"hostPath,omitempty"`
+	EmptyDir *emptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+type hostPathVolumeSource struct {
+	Path string `json:"path"`
+	Type string `json:"type,omitempty"`
+}
+
+type emptyDirVolumeSource struct {
+	Medium    string `json:"medium,omitempty"`
+	SizeLimit string `json:"sizeLimit,omitempty"`
+}
+
+type resourceRequirements struct {
+	Limits   map[string]string `json:"limits,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"`
+}
+
+type securityContext struct {
+	Privileged               *bool         `json:"privileged,omitempty"`
+	ReadOnlyRootFilesystem   *bool         `json:"readOnlyRootFilesystem,omitempty"`
+	AllowPrivilegeEscalation *bool         `json:"allowPrivilegeEscalation,omitempty"`
+	RunAsUser                *int64        `json:"runAsUser,omitempty"`
+	RunAsGroup               *int64        `json:"runAsGroup,omitempty"`
+	Capabilities             *capabilities `json:"capabilities,omitempty"`
+}
+
+type capabilities struct {
+	Add  []string `json:"add,omitempty"`
+	Drop []string `json:"drop,omitempty"`
+}
+
+type podSecurityContext struct {
+	SupplementalGroups []int64  `json:"supplementalGroups,omitempty"`
+	Sysctls            []sysctl `json:"sysctls,omitempty"`
+}
+
+type sysctl struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type hostAlias struct {
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+}
+
+type podDNSConfig struct {
+	Nameservers []string             `json:"nameservers,omitempty"`
+	Searches    []string             `json:"searches,omitempty"`
+	Options     []podDNSConfigOption `json:"options,omitempty"`
+}
+
+type podDNSConfigOption struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value,omitempty"`
+}
+
+type localObjectReference struct {
+	Name string `json:"name"`
+}
+
+type secret struct {
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind,omitempty"`
+	Metadata   objectMeta        `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+type namespace struct {
+	APIVersion string     `json:"apiVersion,omitempty"`
+	Kind       string     `json:"kind,omitempty"`
+	Metadata   objectMeta `json:"metadata"`
+}
+
+type versionInfo struct {
+	GitVersion string `json:"gitVersion"`
+}
+
+type status struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+	Code    int    `json:"code"`
+}
@@ -0,0 +1,69 @@
+package podman
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/engine/docker"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// Podman's REST service exposes a Docker compatible API alongside
+	// libpod's native one, so the Docker engine is reused on top of it.
+	apiVersion = "1.40"
+
+	rootfulSocketPath = "/run/podman/podman.sock"
+)
+
+var _ engine.Engine = &Engine{}
+
+type Engine struct {
+	*docker.Engine
+}
+
+// NewEngine connects to the Podman REST socket at socketPath. If socketPath
+// is empty the socket is located based on whether the agent is running as
+// root or as a rootless user.
+func NewEngine(socketPath string) (*Engine, error) {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath()
+	}
+
+	client, err := client.NewClient("unix://"+socketPath, apiVersion, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Engine{
+		Engine: docker.NewEngineWithClient(client),
+	}, nil
+}
+
+// Version reports the Podman version, which the Docker compatible API
+// returns in place of the Docker one.
+func (e *Engine) Version(ctx context.Context) (*engine.Version, error) {
+	version, err := e.Engine.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version.Name = "podman"
+	return version, nil
+}
+
+func DefaultSocketPath() string {
+	return defaultSocketPath(os.Geteuid(), os.Getenv("XDG_RUNTIME_DIR"))
+}
+
+func defaultSocketPath(uid int, runtimeDir string) string {
+	if uid == 0 {
+		return rootfulSocketPath
+	}
+	if runtimeDir == "" {
+		runtimeDir = filepath.Join("/run/user", strconv.Itoa(uid))
+	}
+	return filepath.Join(runtimeDir, "podman", "podman.sock")
+}
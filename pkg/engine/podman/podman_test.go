@@ -0,0 +1,14 @@
+package podman
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultSocketPath(t *testing.T) {
+	require.Equal(t, "/run/podman/podman.sock", defaultSocketPath(0, "/run/user/0"))
+	require.Equal(t, "/run/user/1000/podman/podman.sock", defaultSocketPath(1000, "/run/user/1000"))
+	require.Equal(t, "/run/user/1000/podman/podman.sock", defaultSocketPath(1000, ""))
+	require.Equal(t, "/tmp/xdg/podman/podman.sock", defaultSocketPath(1000, "/tmp/xdg"))
+}
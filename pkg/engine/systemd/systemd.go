@@ -0,0 +1,289 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/apex/log"
+	"github.com/coreos/go-systemd/dbus"
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/file"
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+const (
+	DefaultUnitDir = "/etc/systemd/system"
+)
+
+var _ engine.Engine = &Engine{}
+
+// Engine runs services of type systemd as units on the host and delegates
+// every other service to the container engine it wraps. Units are told
+// apart from containers by their name.
+type Engine struct {
+	engine.Engine
+	unitDir string
+}
+
+func NewEngine(containerEngine engine.Engine, unitDir string) *Engine {
+	if unitDir == "" {
+		unitDir = DefaultUnitDir
+	}
+	return &Engine{
+		Engine:  containerEngine,
+		unitDir: unitDir,
+	}
+}
+
+func (e *Engine) CreateContainer(ctx context.Context, name string, s models.Service) (string, error) {
+	if s.Type != models.ServiceTypeSystemd {
+		return e.Engine.CreateContainer(ctx, name, s)
+	}
+
+	unitFile, err := unitFile(s)
+	if err != nil {
+		return "", err
+	}
+
+	id := unitName(name)
+	if err := file.WriteFileAtomic(e.unitPath(id), unitFile, 0644); err != nil {
+		return "", err
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := conn.Reload(); err != nil {
+		return "", err
+	}
+	if _, _, err := conn.EnableUnitFiles([]string{e.unitPath(id)}, false, true); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (e *Engine) InspectContainer(ctx context.Context, id string) (*engine.InspectResponse, error) {
+	if !isUnit(id) {
+		return e.Engine.InspectContainer(ctx, id)
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	property, err := conn.GetServiceProperty(id, "MainPID")
+	if err != nil {
+		return nil, err
+	}
+	pid, ok := property.Value.Value().(uint32)
+	if !ok || pid == 0 {
+		return nil, fmt.Errorf("unit %s has no main process", id)
+	}
+
+	return &engine.InspectResponse{
+		PID: int(pid),
+	}, nil
+}
+
+func (e *Engine) StartContainer(ctx context.Context, id string) error {
+	if !isUnit(id) {
+		return e.Engine.StartContainer(ctx, id)
+	}
+
+	if _, err := os.Stat(e.unitPath(id)); os.IsNotExist(err) {
+		return engine.ErrInstanceNotFound
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if state, err := activeState(conn, id); err == nil && state == "failed" {
+		log.WithField("unit", id).Warn("restarting failed unit")
+		if err := conn.ResetFailedUnit(id); err != nil {
+			return err
+		}
+	}
+
+	return runJob(ctx, func(ch chan<- string) (int, error) {
+		return conn.StartUnit(id, "replace", ch)
+	})
+}
+
+func (e *Engine) ListContainers(ctx context.Context, keyFilters map[string]struct{}, keyAndValueFilters map[string]string, all bool) ([]engine.Instance, error) {
+	instances, err := e.Engine.ListContainers(ctx, keyFilters, keyAndValueFilters, all)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(e.unitDir, unitPrefix+"*"+unitSuffix))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return instances, nil
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, path := range paths {
+		unitFile, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		labels, err := parseLabels(unitFile)
+		if err != nil {
+			return nil, err
+		}
+		if !engine.MatchesFilters(labels, keyFilters, keyAndValueFilters) {
+			continue
+		}
+
+		id := filepath.Base(path)
+		state, err := activeState(conn, id)
+		if err != nil {
+			return nil, err
+		}
+
+		running := state == "active" || state == "activating" || state == "reloading"
+		if !all && !running {
+			continue
+		}
+
+		instances = append(instances, engine.Instance{
+			ID:      id,
+			Labels:  labels,
+			Running: running,
+		})
+	}
+
+	return instances, nil
+}
+
+func (e *Engine) StopContainer(ctx context.Context, id string) error {
+	if !isUnit(id) {
+		return e.Engine.StopContainer(ctx, id)
+	}
+
+	if _, err := os.Stat(e.unitPath(id)); os.IsNotExist(err) {
+		return engine.ErrInstanceNotFound
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return runJob(ctx, func(ch chan<- string) (int, error) {
+		return conn.StopUnit(id, "replace", ch)
+	})
+}
+
+func (e *Engine) RemoveContainer(ctx context.Context, id string) error {
+	if !isUnit(id) {
+		return e.Engine.RemoveContainer(ctx, id)
+	}
+
+	if _, err := os.Stat(e.unitPath(id)); os.IsNotExist(err) {
+		return engine.ErrInstanceNotFound
+	}
+
+	conn, err := dbus.NewSystemConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.DisableUnitFiles([]string{id}, false); err != nil {
+		return err
+	}
+	if err := os.Remove(e.unitPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return conn.Reload()
+}
+
+func (e *Engine) StreamContainerStats(ctx context.Context, id string, f func(engine.Stats) error) error {
+	if !isUnit(id) {
+		return e.Engine.StreamContainerStats(ctx, id, f)
+	}
+	return engine.ErrStatsNotSupported
+}
+
+func (e *Engine) ExecContainer(ctx context.Context, id string, config engine.ExecConfig) (int, error) {
+	if !isUnit(id) {
+		return e.Engine.ExecContainer(ctx, id, config)
+	}
+	return 0, engine.ErrExecNotSupported
+}
+
+// StreamContainerLogs reads a unit's logs from the journal.
+func (e *Engine) StreamContainerLogs(ctx context.Context, id string, config engine.LogsConfig) error {
+	if !isUnit(id) {
+		return e.Engine.StreamContainerLogs(ctx, id, config)
+	}
+
+	cmd := exec.CommandContext(ctx, "journalctl", journalctlArgs(id, config)...)
+	cmd.Stdout = config.Stdout
+	cmd.Stderr = config.Stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) PullImage(ctx context.Context, image, registryAuth string, w io.Writer) error {
+	return e.Engine.PullImage(ctx, image, registryAuth, w)
+}
+
+func (e *Engine) unitPath(id string) string {
+	return filepath.Join(e.unitDir, id)
+}
+
+func activeState(conn *dbus.Conn, id string) (string, error) {
+	property, err := conn.GetUnitProperty(id, "ActiveState")
+	if err != nil {
+		return "", err
+	}
+	state, _ := property.Value.Value().(string)
+	return state, nil
+}
+
+func runJob(ctx context.Context, start func(chan<- string) (int, error)) error {
+	ch := make(chan string, 1)
+	if _, err := start(ch); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-ch:
+		if result != "done" {
+			return fmt.Errorf("job finished with result %s", result)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,178 @@
+package systemd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/spec"
+)
+
+const (
+	unitPrefix = "deviceplane-"
+	unitSuffix = ".service"
+
+	metadataSection = "[X-Deviceplane]"
+	labelsKey       = "Labels="
+)
+
+var invalidUnitCharacters = regexp.MustCompile(`[^a-zA-Z0-9:_.\-]`)
+
+func unitName(name string) string {
+	return unitPrefix + invalidUnitCharacters.ReplaceAllString(name, "_") + unitSuffix
+}
+
+func isUnit(id string) bool {
+	return strings.HasPrefix(id, unitPrefix) && strings.HasSuffix(id, unitSuffix)
+}
+
+// journalctlArgs returns the arguments journalctl is run with to get a
+// unit's output as it was written.
+func journalctlArgs(id string, config engine.LogsConfig) []string {
+	args := []string{"--unit", id, "--output", "cat", "--no-pager"}
+	if config.Follow {
+		args = append(args, "--follow")
+	}
+	if config.Tail > 0 {
+		args = append(args, "--lines", strconv.Itoa(config.Tail))
+	} else {
+		args = append(args, "--lines", "all")
+	}
+	return args
+}
+
+// unitFile renders the unit for a service. Services can either provide a
+// complete unit file or have one generated from their command. Labels are
+// stored in a section that systemd ignores so that units can be listed and
+// matched the same way containers are.
+func unitFile(s models.Service) ([]byte, error) {
+	labelsBytes, err := json.Marshal(map[string]string(s.Labels))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if s.Unit != "" {
+		buf.WriteString(strings.TrimRight(s.Unit, "\n"))
+		buf.WriteString("\n")
+	} else {
+		command := append(append([]string{}, s.Entrypoint...), s.Command...)
+		if len(command) == 0 {
+			return nil, fmt.Errorf("systemd service %s requires a unit or a command", s.Labels[models.ServiceLabel])
+		}
+
+		fmt.Fprintf(&buf, "[Unit]\n")
+		fmt.Fprintf(&buf, "Description=Deviceplane service %s\n", s.Labels[models.ServiceLabel])
+		fmt.Fprintf(&buf, "Wants=network-online.target\n")
+		fmt.Fprintf(&buf, "After=network-online.target\n")
+		fmt.Fprintf(&buf, "\n[Service]\n")
+		fmt.Fprintf(&buf, "ExecStart=%s\n", quoteCommand(command))
+		for _, env := range s.Environment {
+			fmt.Fprintf(&buf, "Environment=%s\n", quote(env))
+		}
+		if s.User != "" {
+			parts := strings.SplitN(s.User, ":", 2)
+			fmt.Fprintf(&buf, "User=%s\n", parts[0])
+			if len(parts) == 2 {
+				fmt.Fprintf(&buf, "Group=%s\n", parts[1])
+			}
+		}
+		if s.WorkingDir != "" {
+			fmt.Fprintf(&buf, "WorkingDirectory=%s\n", s.WorkingDir)
+		}
+		if s.StopSignal != "" {
+			fmt.Fprintf(&buf, "KillSignal=%s\n", s.StopSignal)
+		}
+		if s.StopGracePeriod != 0 {
+			fmt.Fprintf(&buf, "TimeoutStopSec=%d\n", s.StopGracePeriod.Seconds())
+		}
+		if s.ReadOnly {
+			fmt.Fprintf(&buf, "ProtectSystem=strict\n")
+		}
+		if spec.NoNewPrivileges(s) {
+			fmt.Fprintf(&buf, "NoNewPrivileges=yes\n")
+		}
+		if s.PidsLimit != 0 {
+			fmt.Fprintf(&buf, "TasksMax=%s\n", limit(s.PidsLimit))
+		}
+		if s.Ulimits != nil {
+			for _, ulimit := range s.Ulimits.Elements {
+				fmt.Fprintf(&buf, "Limit%s=%s:%s\n", strings.ToUpper(ulimit.Name), limit(ulimit.Soft), limit(ulimit.Hard))
+			}
+		}
+		fmt.Fprintf(&buf, "Restart=%s\n", restart(s.Restart))
+		fmt.Fprintf(&buf, "\n[Install]\n")
+		fmt.Fprintf(&buf, "WantedBy=multi-user.target\n")
+	}
+
+	fmt.Fprintf(&buf, "\n%s\n", metadataSection)
+	fmt.Fprintf(&buf, "%s%s\n", labelsKey, labelsBytes)
+
+	return buf.Bytes(), nil
+}
+
+func parseLabels(unitFile []byte) (map[string]string, error) {
+	inSection := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(unitFile))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == metadataSection
+			continue
+		}
+		if inSection && strings.HasPrefix(line, labelsKey) {
+			var labels map[string]string
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, labelsKey)), &labels); err != nil {
+				return nil, err
+			}
+			return labels, nil
+		}
+	}
+
+	return nil, scanner.Err()
+}
+
+func restart(restart string) string {
+	switch {
+	case restart == "always", restart == "unless-stopped":
+		return "always"
+	case strings.HasPrefix(restart, "on-failure"):
+		return "on-failure"
+	default:
+		return "no"
+	}
+}
+
+func limit(value int64) string {
+	if value < 0 {
+		return "infinity"
+	}
+	return strconv.FormatInt(value, 10)
+}
+
+func quoteCommand(command []string) string {
+	var quoted []string
+	for _, arg := range command {
+		// Only command lines are subject to variable expansion
+		quoted = append(quoted, quote(strings.Replace(arg, "$", "$$", -1)))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quote escapes a value according to systemd's quoting and specifier rules.
+func quote(arg string) string {
+	arg = strings.Replace(arg, "%", "%%", -1)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	arg = strings.Replace(arg, `\`, `\\`, -1)
+	arg = strings.Replace(arg, `"`, `\"`, -1)
+	return `"` + arg + `"`
+}
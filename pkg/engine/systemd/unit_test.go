@@ -0,0 +1,93 @@
+package systemd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deviceplane/deviceplane/pkg/engine"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitName(t *testing.T) {
+	require.Equal(t, "deviceplane-sensor-abc123-def456.service", unitName("sensor-abc123-def456"))
+	require.Equal(t, "deviceplane-my_sensor-abc123.service", unitName("my sensor-abc123"))
+	require.True(t, isUnit(unitName("sensor")))
+	require.False(t, isUnit("0123456789abcdef"))
+}
+
+func TestJournalctlArgs(t *testing.T) {
+	require.Equal(t,
+		[]string{"--unit", "deviceplane-sensor.service", "--output", "cat", "--no-pager", "--lines", "all"},
+		journalctlArgs("deviceplane-sensor.service", engine.LogsConfig{}),
+	)
+	require.Equal(t,
+		[]string{"--unit", "deviceplane-sensor.service", "--output", "cat", "--no-pager", "--follow", "--lines", "100"},
+		journalctlArgs("deviceplane-sensor.service", engine.LogsConfig{Follow: true, Tail: 100}),
+	)
+}
+
+func TestUnitFile(t *testing.T) {
+	labels := yamltypes.SliceorMap{
+		models.ServiceLabel: "sensor",
+		models.HashLabel:    "abc",
+	}
+
+	t.Run("generated", func(t *testing.T) {
+		unit, err := unitFile(models.Service{
+			Command:         yamltypes.Command{"/opt/sensor/bin/sensor", "--name", "hello world", "$HOME"},
+			Environment:     yamltypes.MaporEqualSlice{"A=b c"},
+			User:            "sensor:dialout",
+			Restart:         "always",
+			StopSignal:      "SIGINT",
+			StopGracePeriod: yamltypes.Duration(90 * time.Second),
+			ReadOnly:        true,
+			SecurityOpt:     []string{"no-new-privileges:true"},
+			PidsLimit:       64,
+			Ulimits: &yamltypes.Ulimits{
+				Elements: []yamltypes.Ulimit{
+					{Name: "memlock", Soft: -1, Hard: -1},
+					{Name: "nofile", Soft: 1024, Hard: 4096},
+				},
+			},
+			Labels: labels,
+		})
+		require.NoError(t, err)
+
+		s := string(unit)
+		require.True(t, strings.Contains(s, `ExecStart=/opt/sensor/bin/sensor --name "hello world" $$HOME`+"\n"))
+		require.True(t, strings.Contains(s, `Environment="A=b c"`+"\n"))
+		require.True(t, strings.Contains(s, "User=sensor\nGroup=dialout\n"))
+		require.True(t, strings.Contains(s, "Restart=always\n"))
+		require.True(t, strings.Contains(s, "KillSignal=SIGINT\nTimeoutStopSec=90\n"))
+		require.True(t, strings.Contains(s, "ProtectSystem=strict\nNoNewPrivileges=yes\nTasksMax=64\n"))
+		require.True(t, strings.Contains(s, "LimitMEMLOCK=infinity:infinity\nLimitNOFILE=1024:4096\n"))
+		require.True(t, strings.Contains(s, "WantedBy=multi-user.target\n"))
+
+		parsedLabels, err := parseLabels(unit)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string(labels), parsedLabels)
+	})
+
+	t.Run("provided", func(t *testing.T) {
+		unit, err := unitFile(models.Service{
+			Unit:   "[Service]\nExecStart=/usr/bin/vendord\nLabels=not-ours\n",
+			Labels: labels,
+		})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(string(unit), "[Service]\nExecStart=/usr/bin/vendord\n"))
+
+		parsedLabels, err := parseLabels(unit)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string(labels), parsedLabels)
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		_, err := unitFile(models.Service{
+			Labels: labels,
+		})
+		require.Error(t, err)
+	})
+}
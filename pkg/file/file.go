@@ -4,11 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
+	"path/filepath"
 )
 
 func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
-	dir, file := path.Split(filename)
+	dir, file := filepath.Split(filename)
 	tempFile, err := ioutil.TempFile(dir, fmt.Sprintf(".%s", file))
 	if err != nil {
 		return err
@@ -41,6 +41,66 @@ func Interpolate(s string, getVariable func(string) string) (string, error) {
 	return s, nil
 }
 
+// Variables returns the names of the variables referenced in s, in the
+// order they first appear.
+func Variables(s string) ([]string, error) {
+	var variables []string
+	seen := make(map[string]bool)
+	_, success, err := interpolate(s, func(variable string) (string, error) {
+		if !seen[variable] {
+			seen[variable] = true
+			variables = append(variables, variable)
+		}
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !success {
+		return nil, errInvalidInterpolation
+	}
+	return variables, nil
+}
+
+// InterpolateDefined replaces the variables in s that are in variables and
+// leaves everything else as it is, including escaped dollar signs and
+// variables that aren't defined. It's used on configs that may already have
+// been interpolated once.
+func InterpolateDefined(s string, variables map[string]string) string {
+	var buffer bytes.Buffer
+
+	for pos := 0; pos < len(s); pos++ {
+		c := s[pos]
+		if c != '$' {
+			buffer.WriteByte(c)
+			continue
+		}
+
+		var variable string
+		_, end, success, _ := parseInterpolationExpression(s, pos+1, func(v string) (string, error) {
+			variable = v
+			return "", nil
+		})
+		if !success {
+			buffer.WriteByte(c)
+			continue
+		}
+		// Variables at the end of s end past it
+		if end >= len(s) {
+			end = len(s) - 1
+		}
+
+		if value, ok := variables[variable]; ok && variable != "" {
+			buffer.WriteString(value)
+		} else {
+			buffer.WriteString(s[pos : end+1])
+		}
+		pos = end
+	}
+
+	return buffer.String()
+}
+
 func interpolate(s string, getVariable func(string) (string, error)) (string, bool, error) {
 	var buffer bytes.Buffer
 
@@ -70,6 +130,9 @@ func interpolate(s string, getVariable func(string) (string, error)) (string, bo
 }
 
 func parseInterpolationExpression(s string, pos int, getVariable func(string) (string, error)) (string, int, bool, error) {
+	if pos >= len(s) {
+		return "", 0, false, nil
+	}
 	c := s[pos]
 
 	switch {
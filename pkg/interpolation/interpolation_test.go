@@ -75,4 +75,34 @@ func TestParseLine(t *testing.T) {
 	testInvalidInterpolate(t, "${ A}")
 	testInvalidInterpolate(t, "${A!}")
 	testInvalidInterpolate(t, "$!")
+	testInvalidInterpolate(t, "$")
+}
+
+func TestVariables(t *testing.T) {
+	variables, err := Variables("$A ${B}-$A $$C")
+	require.Nil(t, err)
+	require.Equal(t, []string{"A", "B"}, variables)
+
+	variables, err = Variables("no variables")
+	require.Nil(t, err)
+	require.Empty(t, variables)
+
+	_, err = Variables("${A")
+	require.Equal(t, errInvalidInterpolation, err)
+}
+
+func TestInterpolateDefined(t *testing.T) {
+	variables := map[string]string{
+		"A": "ABC",
+		"X": "XYZ",
+	}
+
+	require.Equal(t, "ABC", InterpolateDefined("$A", variables))
+	require.Equal(t, "ABC DE", InterpolateDefined("${A} DE", variables))
+	require.Equal(t, "ABC $B XYZ", InterpolateDefined("$A $B $X", variables))
+	require.Equal(t, "${B}", InterpolateDefined("${B}", variables))
+	require.Equal(t, "$B", InterpolateDefined("$B", variables))
+	require.Equal(t, "$$A", InterpolateDefined("$$A", variables))
+	require.Equal(t, "$ ${ $", InterpolateDefined("$ ${ $", variables))
+	require.Equal(t, "image: app:XYZ\n", InterpolateDefined("image: app:${X}\n", variables))
 }
@@ -25,18 +25,34 @@ type Metric struct {
 }
 
 func NewPoint(value float32) [2]interface{} {
+	return NewPointAt(time.Now(), value)
+}
+
+// NewPointAt returns a point for a value that was measured at time t rather
+// than now.
+func NewPointAt(t time.Time, value float32) [2]interface{} {
 	return [2]interface{}{
-		time.Now().Unix(),
+		t.Unix(),
 		value,
 	}
 }
 
+// DefaultURL is the series endpoint of Datadog's US site.
+const DefaultURL = "https://api.datadoghq.com/api/v1/series"
+
 type Client struct {
+	url    string
 	apiKey string
 }
 
-func NewClient(apiKey string) *Client {
+// NewClient returns a client that posts metrics to url, or DefaultURL if
+// url is empty.
+func NewClient(url, apiKey string) *Client {
+	if url == "" {
+		url = DefaultURL
+	}
 	return &Client{
+		url:    url,
 		apiKey: apiKey,
 	}
 }
@@ -47,13 +63,21 @@ func (c *Client) PostMetrics(ctx context.Context, req PostMetricsRequest) error
 		return err
 	}
 
-	resp, err := http.Post(
-		fmt.Sprintf("https://api.datadoghq.com/api/v1/series?api_key=%s", c.apiKey),
-		"application/json", bytes.NewBuffer(reqBytes),
-	)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewBuffer(reqBytes))
 	if err != nil {
 		return err
 	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("DD-API-KEY", c.apiKey)
 
-	return resp.Body.Close()
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("datadog returned %s", resp.Status)
+	}
+	return nil
 }
@@ -2,6 +2,7 @@ package translation
 
 import (
 	"io"
+	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/metrics/datadog"
 
@@ -48,7 +49,7 @@ func ConvertOpenMetricsToDataDog(in io.Reader, statsCache *StatsCache, statsPref
 					continue
 				}
 
-				points = append(points, datadog.NewPoint(float32(gauge.GetValue())))
+				points = append(points, newPoint(v, float32(gauge.GetValue())))
 				m := datadog.Metric{
 					Metric: promMetric.GetName(),
 					Points: points,
@@ -68,7 +69,7 @@ func ConvertOpenMetricsToDataDog(in io.Reader, statsCache *StatsCache, statsPref
 					continue
 				}
 
-				points = append(points, datadog.NewPoint(float32(delta)))
+				points = append(points, newPoint(v, float32(delta)))
 				m := datadog.Metric{
 					Metric: promMetric.GetName(),
 					Points: points,
@@ -82,3 +83,13 @@ func ConvertOpenMetricsToDataDog(in io.Reader, statsCache *StatsCache, statsPref
 
 	return ddMetrics, nil
 }
+
+// newPoint uses the sample's timestamp when it has one, such as when it was
+// backfilled from a device's offline buffer.
+func newPoint(v *prometheus.Metric, value float32) [2]interface{} {
+	if v.TimestampMs == nil {
+		return datadog.NewPoint(value)
+	}
+	timestampMs := v.GetTimestampMs()
+	return datadog.NewPointAt(time.Unix(timestampMs/1000, (timestampMs%1000)*int64(time.Millisecond)), value)
+}
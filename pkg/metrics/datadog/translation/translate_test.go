@@ -193,3 +193,16 @@ promhttp_metric_handler_requests_total{code="200"} 15
 promhttp_metric_handler_requests_total{code="500"} 0
 promhttp_metric_handler_requests_total{code="503"} 0
 `
+
+func TestTimestamps(t *testing.T) {
+	metrics, err := ConvertOpenMetricsToDataDog(strings.NewReader("# TYPE load1 gauge\nload1 0.5 1500000\n"), NewStatsCache(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(metrics))
+	}
+	if timestamp := metrics[0].Points[0][0]; timestamp != int64(1500) {
+		t.Errorf("expected the sample's timestamp, got %v", timestamp)
+	}
+}
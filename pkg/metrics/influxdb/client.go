@@ -0,0 +1,124 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxErrorBodySize = 512
+
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]float64
+	Time        time.Time
+}
+
+// Client writes points in line protocol. The URL is the full write
+// endpoint, such as http://influxdb:8086/write?db=deviceplane for InfluxDB
+// 1.x or http://influxdb:8086/api/v2/write?org=acme&bucket=deviceplane for
+// InfluxDB 2.x.
+type Client struct {
+	url   string
+	token string
+}
+
+func NewClient(url, token string) *Client {
+	return &Client{
+		url:   url,
+		token: token,
+	}
+}
+
+func (c *Client) Write(ctx context.Context, points []Point) error {
+	var buf bytes.Buffer
+	for _, point := range points {
+		writeLine(&buf, point)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("influxdb returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+func writeLine(buf *bytes.Buffer, point Point) {
+	// Line protocol has no representation for NaN or infinity
+	var fieldKeys []string
+	for key, value := range point.Fields {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		fieldKeys = append(fieldKeys, key)
+	}
+	if len(fieldKeys) == 0 {
+		return
+	}
+	sort.Strings(fieldKeys)
+
+	buf.WriteString(measurementEscaper.Replace(point.Measurement))
+
+	for _, key := range sortedKeys(point.Tags) {
+		// Empty tag values aren't allowed
+		if point.Tags[key] == "" {
+			continue
+		}
+		buf.WriteByte(',')
+		buf.WriteString(keyEscaper.Replace(key))
+		buf.WriteByte('=')
+		buf.WriteString(keyEscaper.Replace(point.Tags[key]))
+	}
+
+	buf.WriteByte(' ')
+	for i, key := range fieldKeys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(keyEscaper.Replace(key))
+		buf.WriteByte('=')
+		buf.WriteString(strconv.FormatFloat(point.Fields[key], 'g', -1, 64))
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.FormatInt(point.Time.UnixNano(), 10))
+	buf.WriteByte('\n')
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
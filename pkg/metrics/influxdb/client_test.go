@@ -0,0 +1,65 @@
+package influxdb
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	writeLine(&buf, Point{
+		Measurement: "deviceplane.device.load, 1m",
+		Tags: map[string]string{
+			"device":  "edge 1",
+			"project": "acme",
+			"empty":   "",
+		},
+		Fields: map[string]float64{
+			"value": 0.5,
+			"nan":   math.NaN(),
+		},
+		Time: time.Unix(1, 0),
+	})
+	writeLine(&buf, Point{
+		Measurement: "skipped",
+		Fields: map[string]float64{
+			"value": math.Inf(1),
+		},
+	})
+
+	require.Equal(t, `deviceplane.device.load\,\ 1m,device=edge\ 1,project=acme value=0.5 1000000000`+"\n", buf.String())
+}
+
+func TestWrite(t *testing.T) {
+	var body []byte
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = ioutil.ReadAll(r.Body)
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	err := NewClient(server.URL+"/api/v2/write?org=acme&bucket=metrics", "secret").Write(context.Background(), []Point{
+		{Measurement: "up", Fields: map[string]float64{"value": 1}, Time: time.Unix(2, 0)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "up value=1 2000000000\n", string(body))
+	require.Equal(t, "Token secret", authorization)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bucket not found", http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	err = NewClient(failing.URL, "").Write(context.Background(), nil)
+	require.EqualError(t, err, "influxdb returned 404 Not Found: bucket not found")
+}
@@ -0,0 +1,66 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxErrorBodySize = 512
+
+type TimeSeries struct {
+	// Labels must include the metric name as __name__
+	Labels  map[string]string
+	Samples []Sample
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp time.Time
+}
+
+// Client writes samples to an endpoint that implements Prometheus' remote
+// write protocol, such as Prometheus itself, Cortex, Thanos or Grafana
+// Cloud.
+type Client struct {
+	url   string
+	token string
+}
+
+func NewClient(url, token string) *Client {
+	return &Client{
+		url:   url,
+		token: token,
+	}
+}
+
+func (c *Client) Write(ctx context.Context, series []TimeSeries) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url,
+		bytes.NewReader(encodeSnappy(encodeWriteRequest(series))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		return fmt.Errorf("remote write returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package remotewrite
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+)
+
+// The remote write protocol is a snappy compressed protobuf WriteRequest.
+// The messages are simple enough to encode by hand, which saves pulling in
+// the Prometheus server's protobuf definitions:
+//
+//   message WriteRequest { repeated TimeSeries timeseries = 1; }
+//   message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+//   message Label { string name = 1; string value = 2; }
+//   message Sample { double value = 1; int64 timestamp = 2; }
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func encodeWriteRequest(series []TimeSeries) []byte {
+	var buf []byte
+	for _, s := range series {
+		buf = appendBytesField(buf, 1, encodeTimeSeries(s))
+	}
+	return buf
+}
+
+func encodeTimeSeries(series TimeSeries) []byte {
+	// Receivers expect labels to be sorted by name
+	var names []string
+	for name := range series.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf []byte
+	for _, name := range names {
+		var label []byte
+		label = appendBytesField(label, 1, []byte(name))
+		label = appendBytesField(label, 2, []byte(series.Labels[name]))
+		buf = appendBytesField(buf, 1, label)
+	}
+	for _, sample := range series.Samples {
+		var s []byte
+		s = appendTag(s, 1, wireFixed64)
+		s = appendFixed64(s, math.Float64bits(sample.Value))
+		s = appendTag(s, 2, wireVarint)
+		s = appendVarint(s, uint64(sample.Timestamp.UnixNano()/1e6))
+		buf = appendBytesField(buf, 2, s)
+	}
+	return buf
+}
+
+func appendTag(buf []byte, field int, wireType int) []byte {
+	return appendVarint(buf, uint64(field<<3|wireType))
+}
+
+func appendBytesField(buf []byte, field int, b []byte) []byte {
+	buf = appendTag(buf, field, wireBytes)
+	buf = appendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendVarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+func appendFixed64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+const maxSnappyLiteral = 1 << 16
+
+// encodeSnappy wraps src in a snappy block made up only of literals. This
+// doesn't compress anything, but it's valid snappy and keeps the encoder
+// trivial. Metrics are only sent once per run so the extra bytes are cheap.
+func encodeSnappy(src []byte) []byte {
+	buf := appendVarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := len(src)
+		if n > maxSnappyLiteral {
+			n = maxSnappyLiteral
+		}
+
+		switch l := n - 1; {
+		case l < 60:
+			buf = append(buf, byte(l<<2))
+		case l < 1<<8:
+			buf = append(buf, 60<<2, byte(l))
+		default:
+			buf = append(buf, 61<<2, byte(l), byte(l>>8))
+		}
+		buf = append(buf, src[:n]...)
+		src = src[n:]
+	}
+	return buf
+}
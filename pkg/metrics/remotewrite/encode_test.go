@@ -0,0 +1,49 @@
+package remotewrite
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeWriteRequest(t *testing.T) {
+	encoded := encodeWriteRequest([]TimeSeries{
+		{
+			Labels: map[string]string{
+				"job":      "a",
+				"__name__": "up",
+			},
+			Samples: []Sample{
+				{Value: 1, Timestamp: time.Unix(1, 0)},
+			},
+		},
+	})
+
+	require.Equal(t, []byte{
+		0x0a, 0x28, // timeseries
+		0x0a, 0x0e, // label
+		0x0a, 0x08, '_', '_', 'n', 'a', 'm', 'e', '_', '_',
+		0x12, 0x02, 'u', 'p',
+		0x0a, 0x08, // label
+		0x0a, 0x03, 'j', 'o', 'b',
+		0x12, 0x01, 'a',
+		0x12, 0x0c, // sample
+		0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f,
+		0x10, 0xe8, 0x07,
+	}, encoded)
+}
+
+func TestEncodeSnappy(t *testing.T) {
+	require.Equal(t, []byte{0x03, 0x08, 'a', 'b', 'c'}, encodeSnappy([]byte("abc")))
+
+	src := bytes.Repeat([]byte("x"), 100)
+	require.Equal(t, append([]byte{100, 60 << 2, 99}, src...), encodeSnappy(src))
+
+	src = bytes.Repeat([]byte("x"), maxSnappyLiteral+1)
+	encoded := encodeSnappy(src)
+	require.Equal(t, []byte{0x81, 0x80, 0x04, 61 << 2, 0xff, 0xff}, encoded[:6])
+	require.Equal(t, []byte{0x00, 'x'}, encoded[len(encoded)-2:])
+	require.Len(t, encoded, 3+3+maxSnappyLiteral+1+1)
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+type AlertRuleType string
+
+const (
+	// AlertRuleTypeDeviceOffline fires for each device that has been
+	// offline for longer than the rule's DurationSeconds.
+	AlertRuleTypeDeviceOffline = AlertRuleType("deviceOffline")
+	// AlertRuleTypeServiceNotRunning fires when more than the rule's
+	// Threshold percent of the online devices an application is scheduled
+	// on aren't running the rule's Service.
+	AlertRuleTypeServiceNotRunning = AlertRuleType("serviceNotRunning")
+	// AlertRuleTypeDiskUsage fires for each device with a filesystem whose
+	// space or inode usage is above the rule's Threshold percent.
+	AlertRuleTypeDiskUsage = AlertRuleType("diskUsage")
+)
+
+// AlertRule is evaluated against the devices that match Query, or every
+// device if it's nil. Alerts from the rule are sent to the channels named
+// in Channels.
+type AlertRule struct {
+	Name            string        `json:"name" yaml:"name"`
+	Type            AlertRuleType `json:"type" yaml:"type"`
+	Query           *Query        `json:"query" yaml:"query"`
+	DurationSeconds int           `json:"durationSeconds" yaml:"durationSeconds"`
+	Threshold       float64       `json:"threshold" yaml:"threshold"`
+	Application     string        `json:"application" yaml:"application"`
+	Service         string        `json:"service" yaml:"service"`
+	Channels        []string      `json:"channels" yaml:"channels"`
+}
+
+type AlertChannelType string
+
+const (
+	// AlertChannelTypeWebhook posts AlertNotifications or Events to URL,
+	// signed the same way as release webhooks if Secret is set.
+	AlertChannelTypeWebhook = AlertChannelType("webhook")
+	// AlertChannelTypeEmail emails Emails.
+	AlertChannelTypeEmail = AlertChannelType("email")
+	// AlertChannelTypeSlack posts messages to the Slack incoming webhook
+	// at URL.
+	AlertChannelTypeSlack = AlertChannelType("slack")
+	// AlertChannelTypePagerDuty triggers and resolves incidents through the
+	// PagerDuty Events API, for the service integration with RoutingKey.
+	AlertChannelTypePagerDuty = AlertChannelType("pagerduty")
+)
+
+// AlertChannel is somewhere notifications are sent. Alert rules name the
+// channels their alerts go to, and channels are also sent the project's
+// events of the types in Events.
+type AlertChannel struct {
+	Name       string           `json:"name" yaml:"name"`
+	Type       AlertChannelType `json:"type" yaml:"type"`
+	URL        string           `json:"url" yaml:"url"`
+	Secret     string           `json:"secret" yaml:"secret"`
+	Emails     []string         `json:"emails" yaml:"emails"`
+	RoutingKey string           `json:"routingKey" yaml:"routingKey"`
+	Events     []EventType      `json:"events" yaml:"events"`
+}
+
+// AlertsConfig holds a project's alert rules and the channels they notify.
+type AlertsConfig struct {
+	Rules    []AlertRule    `json:"rules" yaml:"rules"`
+	Channels []AlertChannel `json:"channels" yaml:"channels"`
+}
+
+// Alert is a rule firing, for a device or, if DeviceID is empty, for the
+// project. It's resolved once the rule stops firing.
+type Alert struct {
+	ID         string     `json:"id" yaml:"id"`
+	ProjectID  string     `json:"projectId" yaml:"projectId"`
+	Rule       string     `json:"rule" yaml:"rule"`
+	DeviceID   string     `json:"deviceId" yaml:"deviceId"`
+	Summary    string     `json:"summary" yaml:"summary"`
+	StartedAt  time.Time  `json:"startedAt" yaml:"startedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt" yaml:"resolvedAt"`
+}
+
+// AlertSilence stops notifications for alerts from Rule on DeviceID until
+// Until. An empty Rule or DeviceID matches any. Alerts are still recorded
+// while they're silenced.
+type AlertSilence struct {
+	ID                        string    `json:"id" yaml:"id"`
+	CreatedAt                 time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID                 string    `json:"projectId" yaml:"projectId"`
+	Rule                      string    `json:"rule" yaml:"rule"`
+	DeviceID                  string    `json:"deviceId" yaml:"deviceId"`
+	Until                     time.Time `json:"until" yaml:"until"`
+	Reason                    string    `json:"reason" yaml:"reason"`
+	CreatedByUserID           *string   `json:"createdByUserId" yaml:"createdByUserId"`
+	CreatedByServiceAccountID *string   `json:"createdByServiceAccountId" yaml:"createdByServiceAccountId"`
+}
+
+type AlertStatus string
+
+const (
+	AlertStatusFiring   = AlertStatus("firing")
+	AlertStatusResolved = AlertStatus("resolved")
+)
+
+// AlertNotification is sent to an alert's channels when it fires and when
+// it's resolved.
+type AlertNotification struct {
+	Status AlertStatus `json:"status"`
+	Alert  Alert       `json:"alert"`
+	Device *Device     `json:"device,omitempty"`
+}
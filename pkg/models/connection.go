@@ -0,0 +1,33 @@
+package models
+
+// Agents that can multiplex sessions over their connection to the
+// controller set this header when initiating it. Controllers that can do
+// the same set it in their response, otherwise agents fall back to dialing
+// a new connection for every session.
+const (
+	DeviceConnectionProtocolHeader = "X-Deviceplane-Connection-Protocol"
+	DeviceConnectionProtocolYamux  = "yamux"
+)
+
+// Remote access profiles name protocols that are proxied to a well known
+// port on the device, so that clients don't need to know it.
+const (
+	RemoteAccessProfileVNC = "vnc"
+	RemoteAccessProfileRDP = "rdp"
+)
+
+var RemoteAccessProfilePorts = map[string]int{
+	RemoteAccessProfileVNC: 5900,
+	RemoteAccessProfileRDP: 3389,
+}
+
+const (
+	TerminalMessageResize = "resize"
+)
+
+// TerminalMessage is a control message sent by web terminal clients.
+type TerminalMessage struct {
+	Type   string `json:"type"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
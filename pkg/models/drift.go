@@ -0,0 +1,69 @@
+package models
+
+// DriftStatus is how what a device runs compares with what it's scheduled
+// to run.
+type DriftStatus string
+
+const (
+	// DriftStatusInSync devices report running their desired releases
+	DriftStatusInSync = DriftStatus("inSync")
+	// DriftStatusPending devices haven't reported running a release yet,
+	// but are still within the update grace period or are waiting for a
+	// maintenance window
+	DriftStatusPending = DriftStatus("pending")
+	// DriftStatusFailing devices are online but haven't reported running a
+	// release within the update grace period
+	DriftStatusFailing = DriftStatus("failing")
+	// DriftStatusUnknown devices are offline, so what they'll run once they
+	// reconnect isn't known
+	DriftStatusUnknown = DriftStatus("unknown")
+)
+
+type ServiceDrift struct {
+	Service          string      `json:"service" yaml:"service"`
+	CurrentReleaseID string      `json:"currentReleaseId" yaml:"currentReleaseId"`
+	Status           DriftStatus `json:"status" yaml:"status"`
+}
+
+// ApplicationDrift compares the release of an application a device should
+// run, after rollouts, release pins and maintenance windows, with the ones
+// it reports running. Its status is the worst of its services'.
+type ApplicationDrift struct {
+	ApplicationID    string         `json:"applicationId" yaml:"applicationId"`
+	ApplicationName  string         `json:"applicationName" yaml:"applicationName"`
+	DesiredReleaseID string         `json:"desiredReleaseId" yaml:"desiredReleaseId"`
+	CurrentReleaseID string         `json:"currentReleaseId" yaml:"currentReleaseId"`
+	Status           DriftStatus    `json:"status" yaml:"status"`
+	Services         []ServiceDrift `json:"services" yaml:"services"`
+}
+
+// DeviceDrift is the drift of each application scheduled onto a device. Its
+// status is the worst of its applications', or in sync if it has none.
+type DeviceDrift struct {
+	DeviceID     string             `json:"deviceId" yaml:"deviceId"`
+	DeviceName   string             `json:"deviceName" yaml:"deviceName"`
+	Status       DriftStatus        `json:"status" yaml:"status"`
+	Applications []ApplicationDrift `json:"applications" yaml:"applications"`
+}
+
+// DriftSummary counts devices, or devices' applications, by drift status.
+type DriftSummary struct {
+	InSync  int `json:"inSync" yaml:"inSync"`
+	Pending int `json:"pending" yaml:"pending"`
+	Failing int `json:"failing" yaml:"failing"`
+	Unknown int `json:"unknown" yaml:"unknown"`
+}
+
+type ApplicationDriftSummary struct {
+	ApplicationID   string       `json:"applicationId" yaml:"applicationId"`
+	ApplicationName string       `json:"applicationName" yaml:"applicationName"`
+	Summary         DriftSummary `json:"summary" yaml:"summary"`
+}
+
+// DriftReport summarizes the drift of a set of devices, overall and for
+// each application scheduled onto any of them.
+type DriftReport struct {
+	Summary      DriftSummary              `json:"summary" yaml:"summary"`
+	Applications []ApplicationDriftSummary `json:"applications" yaml:"applications"`
+	Devices      []DeviceDrift             `json:"devices" yaml:"devices"`
+}
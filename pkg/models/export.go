@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// ExportFormatQueryParam picks the format of a device export, one of the
+// ExportFormat constants.
+const ExportFormatQueryParam = "format"
+
+type ExportFormat string
+
+const (
+	ExportFormatCSV    = ExportFormat("csv")
+	ExportFormatNDJSON = ExportFormat("ndjson")
+)
+
+// DeviceExportRecord is a device's row in an inventory export. Releases maps
+// the names of the applications running on the device to the numbers of
+// their running releases.
+type DeviceExportRecord struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Status        DeviceStatus      `json:"status"`
+	IPAddress     string            `json:"ipAddress"`
+	AgentVersion  string            `json:"agentVersion"`
+	OS            string            `json:"os"`
+	KernelVersion string            `json:"kernelVersion"`
+	Labels        map[string]string `json:"labels"`
+	Releases      map[string]uint32 `json:"releases"`
+	LastSeenAt    time.Time         `json:"lastSeenAt"`
+	CreatedAt     time.Time         `json:"createdAt"`
+}
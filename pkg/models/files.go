@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Files larger than this can't be transferred to or from devices.
+const MaxFileTransferSize = 100 << 20
+
+// FileModeHeader carries the permission bits of a transferred file, in
+// octal.
+const FileModeHeader = "X-Deviceplane-File-Mode"
+
+const FilePathQueryParam = "path"
+
+const (
+	FileBrowserOperationRoots = "roots"
+	FileBrowserOperationStat  = "stat"
+	FileBrowserOperationList  = "list"
+	FileBrowserOperationRead  = "read"
+	FileBrowserOperationWrite = "write"
+)
+
+// FileBrowserRoot is a directory on a device that can be browsed remotely.
+type FileBrowserRoot struct {
+	Path     string `json:"path" yaml:"path"`
+	Writable bool   `json:"writable" yaml:"writable"`
+}
+
+type FileInfo struct {
+	Name    string    `json:"name" yaml:"name"`
+	Path    string    `json:"path" yaml:"path"`
+	Size    int64     `json:"size" yaml:"size"`
+	Mode    string    `json:"mode" yaml:"mode"`
+	ModTime time.Time `json:"modTime" yaml:"modTime"`
+	IsDir   bool      `json:"isDir" yaml:"isDir"`
+}
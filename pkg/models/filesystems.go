@@ -0,0 +1,62 @@
+package models
+
+// LowDiskThreshold is the fraction of a filesystem's space or inodes that
+// can be used before its device is considered low on disk.
+const LowDiskThreshold = 0.9
+
+type Filesystem struct {
+	Device      string `json:"device" yaml:"device"`
+	Mountpoint  string `json:"mountpoint" yaml:"mountpoint"`
+	Type        string `json:"type" yaml:"type"`
+	TotalBytes  uint64 `json:"totalBytes" yaml:"totalBytes"`
+	FreeBytes   uint64 `json:"freeBytes" yaml:"freeBytes"`
+	TotalInodes uint64 `json:"totalInodes" yaml:"totalInodes"`
+	FreeInodes  uint64 `json:"freeInodes" yaml:"freeInodes"`
+}
+
+// LowDisk returns true if the filesystem's space or inode usage is above
+// LowDiskThreshold.
+func (f Filesystem) LowDisk() bool {
+	return f.Usage() > LowDiskThreshold
+}
+
+// Usage returns the fraction of the filesystem's space or inodes that's
+// used, whichever is higher.
+func (f Filesystem) Usage() float64 {
+	bytesUsage := usage(f.TotalBytes, f.FreeBytes)
+	inodesUsage := usage(f.TotalInodes, f.FreeInodes)
+	if inodesUsage > bytesUsage {
+		return inodesUsage
+	}
+	return bytesUsage
+}
+
+func usage(total, free uint64) float64 {
+	if total == 0 || free > total {
+		return 0
+	}
+	return float64(total-free) / float64(total)
+}
+
+// Disk is a block device's health as reported by SMART or, for eMMC
+// storage, the device's own wear estimates. Fields are left empty when the
+// device doesn't report them.
+type Disk struct {
+	Name string `json:"name" yaml:"name"`
+	// Healthy is nil if the disk's health is unknown
+	Healthy *bool `json:"healthy" yaml:"healthy"`
+	// WearLevel is the percentage of the disk's rated endurance that has
+	// been used, or nil if it's unknown
+	WearLevel *int `json:"wearLevel" yaml:"wearLevel"`
+}
+
+// LowDiskFilesystems returns the device's filesystems that are low on disk.
+func (i DeviceInfo) LowDiskFilesystems() []Filesystem {
+	var filesystems []Filesystem
+	for _, filesystem := range i.Filesystems {
+		if filesystem.LowDisk() {
+			filesystems = append(filesystems, filesystem)
+		}
+	}
+	return filesystems
+}
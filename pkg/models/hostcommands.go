@@ -0,0 +1,15 @@
+package models
+
+// HostCommand is a command a device allows the controller to run on its
+// host. Devices only report the names of the commands they allow, never the
+// commands themselves.
+type HostCommand struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+type HostCommandResult struct {
+	ExitCode  int    `json:"exitCode" yaml:"exitCode"`
+	Output    string `json:"output" yaml:"output"`
+	Truncated bool   `json:"truncated" yaml:"truncated"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
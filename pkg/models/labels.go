@@ -6,4 +6,7 @@ const (
 	ServiceLabel      = labelPrefix + "service"
 	ApplicationLabel  = labelPrefix + "application"
 	AgentVersionLabel = labelPrefix + "agent-version"
+
+	NetworkContainerLabel = labelPrefix + "network-container"
+	NetworkLabel          = labelPrefix + "network"
 )
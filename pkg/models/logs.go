@@ -0,0 +1,13 @@
+package models
+
+const (
+	LogStreamStdout = "stdout"
+	LogStreamStderr = "stderr"
+)
+
+// LogChunk is part of a service's output, as streamed by its device. Stream
+// is which of stdout and stderr it was written to.
+type LogChunk struct {
+	Stream string `json:"stream" yaml:"stream"`
+	Data   string `json:"data" yaml:"data"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -41,6 +43,14 @@ type UserAccessKey struct {
 	Description string    `json:"description" yaml:"description"`
 }
 
+type SSHKey struct {
+	ID        string    `json:"id" yaml:"id"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	UserID    string    `json:"userId" yaml:"userId"`
+	Name      string    `json:"name" yaml:"name"`
+	PublicKey string    `json:"publicKey" yaml:"publicKey"`
+}
+
 type UserAccessKeyWithValue struct {
 	UserAccessKey
 	Value string `json:"value" yaml:"value"`
@@ -91,12 +101,28 @@ type ServiceAccount struct {
 	Description string    `json:"description" yaml:"description"`
 }
 
+// ServiceAccountAccessKey authenticates as a service account. Keys with a
+// config, which is written like a role's, can only do what both it and the
+// service account's roles allow.
 type ServiceAccountAccessKey struct {
-	ID               string    `json:"id" yaml:"id"`
-	CreatedAt        time.Time `json:"createdAt" yaml:"createdAt"`
-	ProjectID        string    `json:"projectId" yaml:"projectId"`
-	ServiceAccountID string    `json:"serviceAccountId" yaml:"serviceAccountId"`
-	Description      string    `json:"description" yaml:"description"`
+	ID               string     `json:"id" yaml:"id"`
+	CreatedAt        time.Time  `json:"createdAt" yaml:"createdAt"`
+	ProjectID        string     `json:"projectId" yaml:"projectId"`
+	ServiceAccountID string     `json:"serviceAccountId" yaml:"serviceAccountId"`
+	Description      string     `json:"description" yaml:"description"`
+	Config           string     `json:"config" yaml:"config"`
+	ExpiresAt        *time.Time `json:"expiresAt" yaml:"expiresAt"`
+}
+
+// Restricted returns true if the key has a config limiting what it can do.
+func (k ServiceAccountAccessKey) Restricted() bool {
+	return k.Config != ""
+}
+
+// Expired returns true if the key can no longer be used because it has
+// expired.
+func (k ServiceAccountAccessKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
 }
 
 type ServiceAccountAccessKeyWithValue struct {
@@ -123,6 +149,64 @@ type Device struct {
 	LastSeenAt          time.Time         `json:"lastSeenAt" yaml:"lastSeenAt"`
 	Status              DeviceStatus      `json:"status" yaml:"status"`
 	Labels              map[string]string `json:"labels" yaml:"labels"`
+
+	// PendingApproval is set on devices registered with a token that
+	// requires approval. They don't get bundles or remote access until
+	// they're approved.
+	PendingApproval bool `json:"pendingApproval" yaml:"pendingApproval"`
+
+	// Decommissioning is set once the device has been asked to remove its
+	// services and state. DecommissionedAt is set once it has, after which
+	// the device is archived and can no longer authenticate.
+	Decommissioning  bool       `json:"decommissioning" yaml:"decommissioning"`
+	DecommissionedAt *time.Time `json:"decommissionedAt" yaml:"decommissionedAt"`
+
+	// TransferProjectID is set while the device is being moved to another
+	// project. The device stays in its current project until its agent is
+	// ready to switch.
+	TransferProjectID string `json:"transferProjectId" yaml:"transferProjectId"`
+
+	// Groups holds the IDs of the device groups the device is in. It's
+	// filled in by the controller rather than stored with the device.
+	Groups []string `json:"groups" yaml:"groups"`
+
+	// Releases maps the names of the applications the device reports
+	// running to the numbers of their running releases. It's filled in by
+	// the store from the device's application statuses, so queries can
+	// select devices by property releases.<application>.
+	Releases map[string]uint32 `json:"releases" yaml:"releases"`
+}
+
+// SystemLabelPrefix starts the keys of labels that are derived from what
+// devices report about themselves. They can't be set through the API.
+const SystemLabelPrefix = "hardware-"
+
+// SystemLabels returns the labels derived from the device's hardware, so
+// that queries can select devices such as every Jetson Nano.
+func (d Device) SystemLabels() map[string]string {
+	labels := make(map[string]string)
+	for key, value := range map[string]string{
+		"model":          d.Info.Hardware.Model,
+		"serial-number":  d.Info.Hardware.SerialNumber,
+		"soc":            d.Info.Hardware.SoC,
+		"board-revision": d.Info.Hardware.BoardRevision,
+		"architecture":   d.Info.Hardware.Architecture,
+	} {
+		if value != "" {
+			labels[SystemLabelPrefix+key] = value
+		}
+	}
+	return labels
+}
+
+// Label returns the value of one of the device's labels or system labels.
+func (d Device) Label(key string) (string, bool) {
+	if strings.HasPrefix(key, SystemLabelPrefix) {
+		value, ok := d.SystemLabels()[key]
+		return value, ok
+	}
+	value, ok := d.Labels[key]
+	return value, ok
 }
 
 type DeviceStatus string
@@ -132,14 +216,38 @@ const (
 	DeviceStatusOffline = DeviceStatus("offline")
 )
 
+// DeviceOfflineThreshold is how long after a device was last seen that it's
+// offline, unless its project's heartbeat config sets otherwise.
+const DeviceOfflineThreshold = 2 * time.Minute
+
+// DefaultHeartbeatInterval is how often devices check in, unless their
+// project's heartbeat config sets otherwise.
+const DefaultHeartbeatInterval = 5 * time.Second
+
 type DeviceRegistrationToken struct {
 	ID               string            `json:"id" yaml:"id"`
 	CreatedAt        time.Time         `json:"createdAt" yaml:"createdAt"`
 	ProjectID        string            `json:"projectId" yaml:"projectId"`
 	MaxRegistrations *int              `json:"maxRegistrations" yaml:"maxRegistrations"`
+	ExpiresAt        *time.Time        `json:"expiresAt" yaml:"expiresAt"`
 	Name             string            `json:"name" yaml:"name"`
 	Description      string            `json:"description" yaml:"description"`
 	Labels           map[string]string `json:"labels" yaml:"labels"`
+
+	// NamePrefix and Environment are given to every device registered
+	// with the token, along with its labels
+	NamePrefix  string            `json:"namePrefix" yaml:"namePrefix"`
+	Environment map[string]string `json:"environment" yaml:"environment"`
+
+	// RequireApproval holds devices registered with the token until
+	// they're approved
+	RequireApproval bool `json:"requireApproval" yaml:"requireApproval"`
+}
+
+// Expired returns true if the token can no longer be used to register
+// devices because it has expired.
+func (t DeviceRegistrationToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
 }
 
 type DevicesRegisteredWithTokenCount struct {
@@ -177,6 +285,362 @@ type Release struct {
 	RawConfig                 string             `json:"rawConfig" yaml:"rawConfig"`
 	CreatedByUserID           *string            `json:"createdByUserId" yaml:"createdByUserId"`
 	CreatedByServiceAccountID *string            `json:"createdByServiceAccountId" yaml:"createdByServiceAccountId"`
+	// SourceReleaseID is set on releases created by rolling an application
+	// back, to the release whose config they reuse.
+	SourceReleaseID string `json:"sourceReleaseId,omitempty" yaml:"sourceReleaseId,omitempty"`
+	// Notes describe what's in a release. Source is what it was built from,
+	// such as a commit SHA or a CI build URL.
+	Notes  string `json:"notes" yaml:"notes"`
+	Source string `json:"source" yaml:"source"`
+	// IdempotencyKey is set on releases created from CI, to the key that
+	// keeps retries of a build from creating more releases.
+	IdempotencyKey string `json:"idempotencyKey,omitempty" yaml:"idempotencyKey,omitempty"`
+}
+
+// ReleaseDiff is what changed between two releases of an application.
+type ReleaseDiff struct {
+	FromReleaseID   string        `json:"fromReleaseId" yaml:"fromReleaseId"`
+	ToReleaseID     string        `json:"toReleaseId" yaml:"toReleaseId"`
+	AddedServices   []string      `json:"addedServices" yaml:"addedServices"`
+	RemovedServices []string      `json:"removedServices" yaml:"removedServices"`
+	ChangedServices []ServiceDiff `json:"changedServices" yaml:"changedServices"`
+}
+
+// ServiceDiff is what changed in a service that's in both releases of a
+// ReleaseDiff. Changes to keys other than the image, environment and ports
+// are only listed by key.
+type ServiceDiff struct {
+	Service      string      `json:"service" yaml:"service"`
+	Image        *Change     `json:"image,omitempty" yaml:"image,omitempty"`
+	Environment  []KeyChange `json:"environment,omitempty" yaml:"environment,omitempty"`
+	AddedPorts   []string    `json:"addedPorts,omitempty" yaml:"addedPorts,omitempty"`
+	RemovedPorts []string    `json:"removedPorts,omitempty" yaml:"removedPorts,omitempty"`
+	OtherChanges []string    `json:"otherChanges,omitempty" yaml:"otherChanges,omitempty"`
+}
+
+type Change struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+// KeyChange is a change to the value of a key. From is nil if the key was
+// added and To is nil if it was removed.
+type KeyChange struct {
+	Key  string  `json:"key" yaml:"key"`
+	From *string `json:"from" yaml:"from"`
+	To   *string `json:"to" yaml:"to"`
+}
+
+const (
+	DiagnosticSeverityError   = "error"
+	DiagnosticSeverityWarning = "warning"
+)
+
+// ReleaseDiagnostic is a problem found while validating a release config.
+// Service and Key are empty when the problem isn't specific to one.
+type ReleaseDiagnostic struct {
+	Service  string `json:"service,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (d ReleaseDiagnostic) String() string {
+	switch {
+	case d.Service == "":
+		return d.Message
+	case d.Key == "":
+		return fmt.Sprintf("service '%s': %s", d.Service, d.Message)
+	default:
+		return fmt.Sprintf("service '%s', key '%s': %s", d.Service, d.Key, d.Message)
+	}
+}
+
+// Rollout deploys a release to an application's devices in steps. Devices
+// scheduled onto the release keep running PreviousReleaseID until a step
+// reaches them. Once every device in the current step has run the release
+// for SoakPeriod seconds without failing the rollout moves on to the next
+// step, and once the last step is done every device runs the release. The
+// rollout is halted, moving its devices back to the previous release, if
+// more than MaxFailurePercentage of them fail.
+type Rollout struct {
+	ID                   string        `json:"id" yaml:"id"`
+	CreatedAt            time.Time     `json:"createdAt" yaml:"createdAt"`
+	ProjectID            string        `json:"projectId" yaml:"projectId"`
+	ApplicationID        string        `json:"applicationId" yaml:"applicationId"`
+	ReleaseID            string        `json:"releaseId" yaml:"releaseId"`
+	PreviousReleaseID    string        `json:"previousReleaseId" yaml:"previousReleaseId"`
+	Steps                []RolloutStep `json:"steps" yaml:"steps"`
+	SoakPeriod           int           `json:"soakPeriod" yaml:"soakPeriod"`
+	MaxFailurePercentage int           `json:"maxFailurePercentage" yaml:"maxFailurePercentage"`
+	// Step is the index of the current step in Steps
+	Step          int           `json:"step" yaml:"step"`
+	StepStartedAt time.Time     `json:"stepStartedAt" yaml:"stepStartedAt"`
+	Status        RolloutStatus `json:"status" yaml:"status"`
+	StatusReason  string        `json:"statusReason" yaml:"statusReason"`
+}
+
+// RolloutStep adds the devices that match Query, if it's set, to a rollout.
+// Percentage limits the step to part of those devices, such as a canary
+// group, and is kept stable between steps so that raising it only ever adds
+// devices.
+type RolloutStep struct {
+	Percentage int    `json:"percentage" yaml:"percentage"`
+	Query      *Query `json:"query,omitempty" yaml:"query,omitempty"`
+}
+
+type RolloutStatus string
+
+const (
+	RolloutStatusInProgress = RolloutStatus("in progress")
+	RolloutStatusCompleted  = RolloutStatus("completed")
+	RolloutStatusHalted     = RolloutStatus("halted")
+)
+
+// DefaultMaxFailurePercentage is used for rollouts that don't set
+// MaxFailurePercentage.
+const DefaultMaxFailurePercentage = 10
+
+type EnvironmentFile struct {
+	ID          string    `json:"id" yaml:"id"`
+	CreatedAt   time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID   string    `json:"projectId" yaml:"projectId"`
+	Name        string    `json:"name" yaml:"name"`
+	Description string    `json:"description" yaml:"description"`
+	Content     string    `json:"content" yaml:"content"`
+}
+
+const (
+	SessionRecordingKindSSH  = "ssh"
+	SessionRecordingKindExec = "exec"
+
+	// SessionRecordingQueryParam tells the agent which recording to upload
+	// a session under
+	SessionRecordingQueryParam = "recording"
+)
+
+// SessionRecording is the record of a remote terminal session. The
+// recording itself is uploaded by the device once the session ends.
+type SessionRecording struct {
+	ID                        string     `json:"id" yaml:"id"`
+	CreatedAt                 time.Time  `json:"createdAt" yaml:"createdAt"`
+	ProjectID                 string     `json:"projectId" yaml:"projectId"`
+	DeviceID                  string     `json:"deviceId" yaml:"deviceId"`
+	Kind                      string     `json:"kind" yaml:"kind"`
+	ApplicationID             string     `json:"applicationId" yaml:"applicationId"`
+	Service                   string     `json:"service" yaml:"service"`
+	CreatedByUserID           *string    `json:"createdByUserId" yaml:"createdByUserId"`
+	CreatedByServiceAccountID *string    `json:"createdByServiceAccountId" yaml:"createdByServiceAccountId"`
+	FinishedAt                *time.Time `json:"finishedAt" yaml:"finishedAt"`
+	Size                      int        `json:"size" yaml:"size"`
+}
+
+// AuditLogEntry records a mutating API call or remote access session in a
+// project, once it has finished. Summary lists the fields of the request's
+// JSON body; their values aren't recorded, since they can hold secrets.
+type AuditLogEntry struct {
+	ID               string    `json:"id" yaml:"id"`
+	CreatedAt        time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID        string    `json:"projectId" yaml:"projectId"`
+	UserID           string    `json:"userId" yaml:"userId"`
+	ServiceAccountID string    `json:"serviceAccountId" yaml:"serviceAccountId"`
+	Action           string    `json:"action" yaml:"action"`
+	Method           string    `json:"method" yaml:"method"`
+	Path             string    `json:"path" yaml:"path"`
+	Summary          string    `json:"summary" yaml:"summary"`
+	RemoteAddr       string    `json:"remoteAddr" yaml:"remoteAddr"`
+	StatusCode       int       `json:"statusCode" yaml:"statusCode"`
+	DurationMs       int64     `json:"durationMs" yaml:"durationMs"`
+}
+
+// DeviceConnectivityEvent records a device coming online or going offline.
+// Devices go offline when they were last seen, rather than once the offline
+// threshold has passed.
+type DeviceConnectivityEvent struct {
+	ID        string       `json:"id" yaml:"id"`
+	ProjectID string       `json:"projectId" yaml:"projectId"`
+	DeviceID  string       `json:"deviceId" yaml:"deviceId"`
+	Status    DeviceStatus `json:"status" yaml:"status"`
+	At        time.Time    `json:"at" yaml:"at"`
+}
+
+// DeviceConnectivityHistory is a device's connectivity events, oldest
+// first. StatusSince is when the device's current status started, if it's
+// known. A device is Flapping if it has gone offline FlapCount times within
+// the flap window.
+type DeviceConnectivityHistory struct {
+	Status      DeviceStatus              `json:"status" yaml:"status"`
+	StatusSince *time.Time                `json:"statusSince" yaml:"statusSince"`
+	Flapping    bool                      `json:"flapping" yaml:"flapping"`
+	FlapCount   int                       `json:"flapCount" yaml:"flapCount"`
+	Events      []DeviceConnectivityEvent `json:"events" yaml:"events"`
+}
+
+// ConfigFile is a file that's kept up to date on the host of every device in
+// the project. Variables from the device's environment are interpolated
+// into Content. Mode holds the file's permission bits, and Owner is either
+// empty to leave the owner alone or a numeric uid:gid. RestartServices are
+// services, as application/service, that are restarted when the file
+// changes.
+type ConfigFile struct {
+	ID              string    `json:"id" yaml:"id"`
+	CreatedAt       time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID       string    `json:"projectId" yaml:"projectId"`
+	Name            string    `json:"name" yaml:"name"`
+	Description     string    `json:"description" yaml:"description"`
+	Path            string    `json:"path" yaml:"path"`
+	Content         string    `json:"content" yaml:"content"`
+	Mode            uint32    `json:"mode" yaml:"mode"`
+	Owner           string    `json:"owner" yaml:"owner"`
+	RestartServices []string  `json:"restartServices" yaml:"restartServices"`
+}
+
+// DeviceGroup is a named set of devices in a project. Devices are in a
+// group if they were added to it or, if Query is set, if they match it.
+// Groups can be used in queries through DeviceGroupCondition, to scope the
+// rules of roles, and to scope exposed metrics.
+type DeviceGroup struct {
+	ID          string    `json:"id" yaml:"id"`
+	CreatedAt   time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID   string    `json:"projectId" yaml:"projectId"`
+	Name        string    `json:"name" yaml:"name"`
+	Description string    `json:"description" yaml:"description"`
+	Query       *Query    `json:"query,omitempty" yaml:"query,omitempty"`
+}
+
+// DeviceGroupMember is a device that was added to a group.
+type DeviceGroupMember struct {
+	ProjectID     string `json:"projectId" yaml:"projectId"`
+	DeviceGroupID string `json:"deviceGroupId" yaml:"deviceGroupId"`
+	DeviceID      string `json:"deviceId" yaml:"deviceId"`
+}
+
+// DeviceView is a saved device listing shared with everyone in a project:
+// the devices matching Query, shown with Columns. Unlike a DeviceGroup, a
+// view isn't a set of devices that anything else can refer to.
+type DeviceView struct {
+	ID                        string             `json:"id" yaml:"id"`
+	CreatedAt                 time.Time          `json:"createdAt" yaml:"createdAt"`
+	ProjectID                 string             `json:"projectId" yaml:"projectId"`
+	Name                      string             `json:"name" yaml:"name"`
+	Description               string             `json:"description" yaml:"description"`
+	Query                     Query              `json:"query" yaml:"query"`
+	Columns                   []DeviceViewColumn `json:"columns" yaml:"columns"`
+	CreatedByUserID           *string            `json:"createdByUserId" yaml:"createdByUserId"`
+	CreatedByServiceAccountID *string            `json:"createdByServiceAccountId" yaml:"createdByServiceAccountId"`
+}
+
+type DeviceViewColumn string
+
+const (
+	DeviceViewColumnName          = DeviceViewColumn("name")
+	DeviceViewColumnID            = DeviceViewColumn("id")
+	DeviceViewColumnStatus        = DeviceViewColumn("status")
+	DeviceViewColumnIPAddress     = DeviceViewColumn("ipAddress")
+	DeviceViewColumnOS            = DeviceViewColumn("os")
+	DeviceViewColumnKernelVersion = DeviceViewColumn("kernelVersion")
+	DeviceViewColumnAgentVersion  = DeviceViewColumn("agentVersion")
+	DeviceViewColumnUptime        = DeviceViewColumn("uptime")
+	DeviceViewColumnLabels        = DeviceViewColumn("labels")
+	DeviceViewColumnLastSeen      = DeviceViewColumn("lastSeen")
+	DeviceViewColumnCreated       = DeviceViewColumn("created")
+)
+
+var DeviceViewColumns = []DeviceViewColumn{
+	DeviceViewColumnName,
+	DeviceViewColumnID,
+	DeviceViewColumnStatus,
+	DeviceViewColumnIPAddress,
+	DeviceViewColumnOS,
+	DeviceViewColumnKernelVersion,
+	DeviceViewColumnAgentVersion,
+	DeviceViewColumnUptime,
+	DeviceViewColumnLabels,
+	DeviceViewColumnLastSeen,
+	DeviceViewColumnCreated,
+}
+
+// DefaultDeviceViewColumns are the columns of views saved without any,
+// which are those devices are listed with by default.
+var DefaultDeviceViewColumns = []DeviceViewColumn{
+	DeviceViewColumnName,
+	DeviceViewColumnStatus,
+	DeviceViewColumnIPAddress,
+	DeviceViewColumnOS,
+	DeviceViewColumnUptime,
+	DeviceViewColumnLabels,
+	DeviceViewColumnLastSeen,
+	DeviceViewColumnCreated,
+}
+
+// DeviceReleasePin keeps a device on a release of an application, whatever
+// release its scheduling rule and rollouts would give it.
+type DeviceReleasePin struct {
+	CreatedAt     time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID     string    `json:"projectId" yaml:"projectId"`
+	DeviceID      string    `json:"deviceId" yaml:"deviceId"`
+	ApplicationID string    `json:"applicationId" yaml:"applicationId"`
+	ReleaseID     string    `json:"releaseId" yaml:"releaseId"`
+}
+
+type EventType string
+
+const (
+	EventTypeDeviceOnline             = EventType("device.online")
+	EventTypeDeviceOffline            = EventType("device.offline")
+	EventTypeDeviceRegistered         = EventType("device.registered")
+	EventTypeReleaseCreated           = EventType("release.created")
+	EventTypeRolloutCompleted         = EventType("rollout.completed")
+	EventTypeRolloutFailed            = EventType("rollout.failed")
+	EventTypeRolloutProgressed        = EventType("rollout.progressed")
+	EventTypeApplicationStatusUpdated = EventType("application.status")
+	EventTypeServiceStatusUpdated     = EventType("service.status")
+)
+
+var EventTypes = []EventType{
+	EventTypeDeviceOnline,
+	EventTypeDeviceOffline,
+	EventTypeDeviceRegistered,
+	EventTypeReleaseCreated,
+	EventTypeRolloutCompleted,
+	EventTypeRolloutFailed,
+	EventTypeRolloutProgressed,
+	EventTypeApplicationStatusUpdated,
+	EventTypeServiceStatusUpdated,
+}
+
+// Event is something that happened in a project, as sent to its event
+// webhooks and event streams. Only the resources the event is about are set.
+type Event struct {
+	ID        string    `json:"id" yaml:"id"`
+	Type      EventType `json:"type" yaml:"type"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID string    `json:"projectId" yaml:"projectId"`
+	Device    *Device   `json:"device,omitempty" yaml:"device,omitempty"`
+	Release   *Release  `json:"release,omitempty" yaml:"release,omitempty"`
+	Rollout   *Rollout  `json:"rollout,omitempty" yaml:"rollout,omitempty"`
+	// ApplicationStatus and ServiceStatus are the releases a device reports
+	// it's now running.
+	ApplicationStatus *DeviceApplicationStatus `json:"applicationStatus,omitempty" yaml:"applicationStatus,omitempty"`
+	ServiceStatus     *DeviceServiceStatus     `json:"serviceStatus,omitempty" yaml:"serviceStatus,omitempty"`
+}
+
+// ApplicationGitSync keeps an application in sync with a spec file in a git
+// repository. Whenever the tip of the branch changes the file is read, and a
+// release is created if it's different from the latest one. An empty branch
+// is the repository's default branch.
+type ApplicationGitSync struct {
+	CreatedAt     time.Time `json:"createdAt" yaml:"createdAt"`
+	ProjectID     string    `json:"projectId" yaml:"projectId"`
+	ApplicationID string    `json:"applicationId" yaml:"applicationId"`
+	Repository    string    `json:"repository" yaml:"repository"`
+	Branch        string    `json:"branch" yaml:"branch"`
+	Path          string    `json:"path" yaml:"path"`
+	// LastCommit is the SHA of the last commit that was synced, whether or
+	// not it made a release. LastError is why the last sync failed.
+	LastCommit   string     `json:"lastCommit" yaml:"lastCommit"`
+	LastSyncedAt *time.Time `json:"lastSyncedAt" yaml:"lastSyncedAt"`
+	LastError    string     `json:"lastError" yaml:"lastError"`
 }
 
 type ReleaseDeviceCounts struct {
@@ -230,12 +694,14 @@ type DeviceApplicationStatusInfo struct {
 	Application       Application              `json:"application" yaml:"application"`
 	ApplicationStatus *DeviceApplicationStatus `json:"applicationStatus" yaml:"applicationStatus"`
 	ServiceStatuses   []DeviceServiceStatus    `json:"serviceStatuses" yaml:"serviceStatuses"`
+	ReleasePin        *DeviceReleasePin        `json:"releasePin" yaml:"releasePin"`
 }
 
 type ApplicationFull1 struct {
 	Application
 	LatestRelease *Release                `json:"latestRelease" yaml:"latestRelease"`
 	DeviceCounts  ApplicationDeviceCounts `json:"deviceCounts" yaml:"deviceCounts"`
+	ReleasePins   []DeviceReleasePin      `json:"releasePins" yaml:"releasePins"`
 }
 
 type DeviceRegistrationTokenFull struct {
@@ -256,8 +722,61 @@ type Bundle struct {
 	ServiceStatuses     []DeviceServiceStatus     `json:"serviceStatuses" yaml:"serviceStatuses"`
 	DesiredAgentSpec    string                    `json:"desiredAgentSpec" yaml:"desiredAgentSpec"`
 	DesiredAgentVersion string                    `json:"desiredAgentVersion" yaml:"desiredAgentVersion"`
+	SSHKeys             BundledSSHKeys            `json:"sshKeys" yaml:"sshKeys"`
+	MetricsConfig       BundledMetricsConfig      `json:"metricsConfig" yaml:"metricsConfig"`
+	// Environment is the device's resolved environment, which has already
+	// been interpolated into the releases in Applications
+	Environment map[string]string   `json:"environment" yaml:"environment"`
+	ConfigFiles []BundledConfigFile `json:"configFiles" yaml:"configFiles"`
+	// Decommission tells the agent to remove its services, config files and
+	// state. Bundles that set it have nothing else in them.
+	Decommission bool `json:"decommission" yaml:"decommission"`
+	// TransferProjectID tells the agent to move to another project. Bundles
+	// that set it have nothing else in them.
+	TransferProjectID string `json:"transferProjectId" yaml:"transferProjectId"`
+	// HeartbeatIntervalSeconds is how often the agent checks in for a new
+	// bundle. Zero uses the default.
+	HeartbeatIntervalSeconds int `json:"heartbeatIntervalSeconds" yaml:"heartbeatIntervalSeconds"`
+}
+
+// BundledConfigFile is a ConfigFile with the device's environment
+// interpolated into its content and its restart services resolved to
+// application IDs.
+type BundledConfigFile struct {
+	ID              string                    `json:"id" yaml:"id"`
+	Path            string                    `json:"path" yaml:"path"`
+	Content         string                    `json:"content" yaml:"content"`
+	Mode            uint32                    `json:"mode" yaml:"mode"`
+	Owner           string                    `json:"owner" yaml:"owner"`
+	RestartServices []BundledServiceReference `json:"restartServices" yaml:"restartServices"`
+}
+
+type BundledServiceReference struct {
+	ApplicationID string `json:"applicationId" yaml:"applicationId"`
+	Service       string `json:"service" yaml:"service"`
+}
+
+// BundledSSHKeys are the keys of every project member whose roles allow
+// SSH. When Enforce is set, agents only accept these keys and any they
+// have configured locally.
+type BundledSSHKeys struct {
+	Enforce        bool     `json:"enforce" yaml:"enforce"`
+	AuthorizedKeys []string `json:"authorizedKeys" yaml:"authorizedKeys"`
+}
+
+// BundledMetricsConfig is a project's MetricsCollectionConfig resolved for
+// a device, with the labels to attach to every series.
+type BundledMetricsConfig struct {
+	HostCollectors      []string          `json:"hostCollectors" yaml:"hostCollectors"`
+	HostIntervalSeconds int               `json:"hostIntervalSeconds" yaml:"hostIntervalSeconds"`
+	AllowedMetrics      []string          `json:"allowedMetrics" yaml:"allowedMetrics"`
+	Labels              map[string]string `json:"labels" yaml:"labels"`
 }
 
+// BufferedMetricsThroughHeader carries the timestamp, in milliseconds, of
+// the newest metrics collection served from an agent's offline buffer.
+const BufferedMetricsThroughHeader = "X-Deviceplane-Buffered-Through"
+
 type BundledApplication struct {
 	ID                    string                          `json:"id" yaml:"id"`
 	ProjectID             string                          `json:"projectId" yaml:"projectId"`
@@ -274,6 +793,177 @@ type DeviceInfo struct {
 	AgentVersion string    `json:"agentVersion" yaml:"agentVersion"`
 	IPAddress    string    `json:"ipAddress" yaml:"ipAddress"`
 	OSRelease    OSRelease `json:"osRelease" yaml:"osRelease"`
+
+	KernelVersion string     `json:"kernelVersion" yaml:"kernelVersion"`
+	Boot          Boot       `json:"boot" yaml:"boot"`
+	Clock         Clock      `json:"clock" yaml:"clock"`
+	Hardware      Hardware   `json:"hardware" yaml:"hardware"`
+	Engine        EngineInfo `json:"engine" yaml:"engine"`
+
+	NetworkInterfaces   []NetworkInterface `json:"networkInterfaces" yaml:"networkInterfaces"`
+	DefaultRoute        Route              `json:"defaultRoute" yaml:"defaultRoute"`
+	ControllerInterface string             `json:"controllerInterface" yaml:"controllerInterface"`
+
+	Filesystems []Filesystem `json:"filesystems" yaml:"filesystems"`
+	Disks       []Disk       `json:"disks" yaml:"disks"`
+
+	Thermal Thermal `json:"thermal" yaml:"thermal"`
+	Modems  []Modem `json:"modems" yaml:"modems"`
+
+	Location *Location `json:"location" yaml:"location"`
+
+	LastConnectorDisconnect ConnectorDisconnect `json:"lastConnectorDisconnect" yaml:"lastConnectorDisconnect"`
+	LastPowerAction         PowerAction         `json:"lastPowerAction" yaml:"lastPowerAction"`
+	LastFailedAgentUpdate   AgentUpdateFailure  `json:"lastFailedAgentUpdate" yaml:"lastFailedAgentUpdate"`
+
+	// VariableErrors has the errors from reading the agent's variables, such
+	// as registry credentials that can't be parsed, keyed by variable
+	VariableErrors map[string]string `json:"variableErrors" yaml:"variableErrors"`
+
+	// LocalLabels are the labels from the device's labels file, which are
+	// merged into the device's labels
+	LocalLabels map[string]string `json:"localLabels" yaml:"localLabels"`
+}
+
+// AgentUpdateFailure records the last agent update that was rolled back
+// because the new version didn't become healthy.
+type AgentUpdateFailure struct {
+	Version string    `json:"version" yaml:"version"`
+	Reason  string    `json:"reason" yaml:"reason"`
+	Time    time.Time `json:"time" yaml:"time"`
+}
+
+// ConnectorDisconnect records why and when a device's remote access
+// connection to the controller last dropped.
+type ConnectorDisconnect struct {
+	Reason string    `json:"reason" yaml:"reason"`
+	Time   time.Time `json:"time" yaml:"time"`
+}
+
+const (
+	PowerActionReboot   = "reboot"
+	PowerActionShutdown = "shutdown"
+)
+
+// PowerAction records the last reboot or shutdown a device was asked to
+// perform. CompletedAt is set once the agent starts again afterwards, and
+// Error is set if the device failed to perform it.
+type PowerAction struct {
+	Action      string    `json:"action" yaml:"action"`
+	Reason      string    `json:"reason" yaml:"reason"`
+	RequestedAt time.Time `json:"requestedAt" yaml:"requestedAt"`
+	CompletedAt time.Time `json:"completedAt" yaml:"completedAt"`
+	Error       string    `json:"error" yaml:"error"`
+}
+
+const (
+	BootReasonUnknown   = "unknown"
+	BootReasonRequested = "requested"
+	BootReasonWatchdog  = "watchdog"
+	BootReasonOverheat  = "overheat"
+	BootReasonPower     = "power"
+)
+
+// Boot is when a device last booted and why. A requested boot follows a
+// reboot or shutdown initiated through deviceplane.
+type Boot struct {
+	Time   time.Time `json:"time" yaml:"time"`
+	Reason string    `json:"reason" yaml:"reason"`
+}
+
+// Unexpected returns true if the boot wasn't requested through
+// deviceplane.
+func (b Boot) Unexpected() bool {
+	return b.Reason != BootReasonRequested
+}
+
+// Clock is the state of a device's clock. Skew is how many seconds ahead of
+// the controller's clock the device's clock is, and is zero if it's within
+// the accuracy it can be measured to. NTPSynchronized is nil if the device
+// can't tell whether its clock is synchronized.
+type Clock struct {
+	Skew            int64 `json:"skew" yaml:"skew"`
+	NTPSynchronized *bool `json:"ntpSynchronized" yaml:"ntpSynchronized"`
+}
+
+// MaxClockSkew is how many seconds a device's clock can be off by before
+// it's considered skewed.
+const MaxClockSkew = 60
+
+// ClockSkewed returns true if skew is more than MaxClockSkew seconds in
+// either direction.
+func ClockSkewed(skew int64) bool {
+	return skew > MaxClockSkew || skew < -MaxClockSkew
+}
+
+type Hardware struct {
+	Architecture string `json:"architecture" yaml:"architecture"`
+	CPUModel     string `json:"cpuModel" yaml:"cpuModel"`
+	CPUCount     int    `json:"cpuCount" yaml:"cpuCount"`
+	TotalMemory  uint64 `json:"totalMemory" yaml:"totalMemory"`
+
+	Model         string `json:"model" yaml:"model"`
+	SerialNumber  string `json:"serialNumber" yaml:"serialNumber"`
+	SoC           string `json:"soc" yaml:"soc"`
+	BoardRevision string `json:"boardRevision" yaml:"boardRevision"`
+}
+
+// EngineInfo identifies the container runtime services run on.
+type EngineInfo struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// Thermal is a device's temperatures and whether it's being throttled. The
+// since boot fields are only reported by Raspberry Pi firmware.
+type Thermal struct {
+	Zones                 []ThermalZone `json:"zones" yaml:"zones"`
+	Throttled             bool          `json:"throttled" yaml:"throttled"`
+	ThrottledSinceBoot    bool          `json:"throttledSinceBoot" yaml:"throttledSinceBoot"`
+	UnderVoltage          bool          `json:"underVoltage" yaml:"underVoltage"`
+	UnderVoltageSinceBoot bool          `json:"underVoltageSinceBoot" yaml:"underVoltageSinceBoot"`
+}
+
+// ThermalZone is a thermal zone's temperature in degrees Celsius.
+type ThermalZone struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Temperature int    `json:"temperature" yaml:"temperature"`
+}
+
+// Location is where a device was last located and how. Time is when the
+// location was determined.
+type Location struct {
+	Latitude  float64   `json:"latitude" yaml:"latitude"`
+	Longitude float64   `json:"longitude" yaml:"longitude"`
+	Source    string    `json:"source" yaml:"source"`
+	Time      time.Time `json:"time" yaml:"time"`
+}
+
+// Modem is a cellular modem's state. Signal strength and data usage are
+// reported through host metrics since they change constantly.
+type Modem struct {
+	Manufacturer     string `json:"manufacturer" yaml:"manufacturer"`
+	Model            string `json:"model" yaml:"model"`
+	State            string `json:"state" yaml:"state"`
+	AccessTechnology string `json:"accessTechnology" yaml:"accessTechnology"`
+	Operator         string `json:"operator" yaml:"operator"`
+	APN              string `json:"apn" yaml:"apn"`
+}
+
+type NetworkInterface struct {
+	Name          string   `json:"name" yaml:"name"`
+	HardwareAddr  string   `json:"hardwareAddr" yaml:"hardwareAddr"`
+	MTU           int      `json:"mtu" yaml:"mtu"`
+	Up            bool     `json:"up" yaml:"up"`
+	IPv4Addresses []string `json:"ipv4Addresses" yaml:"ipv4Addresses"`
+	IPv6Addresses []string `json:"ipv6Addresses" yaml:"ipv6Addresses"`
+}
+
+// Route is the interface and gateway traffic is sent through.
+type Route struct {
+	Interface string `json:"interface" yaml:"interface"`
+	Gateway   string `json:"gateway" yaml:"gateway"`
 }
 
 type OSRelease struct {
@@ -0,0 +1,18 @@
+package models
+
+// Paginated listings take these query parameters. A page starts after the
+// item whose ID is given as after.
+const (
+	PageSizeQueryParam = "page_size"
+	AfterQueryParam    = "after"
+
+	MaxPageSize = 100
+)
+
+// Paginated listings set these headers. NextPageAfterHeader is the after of
+// the next page, and isn't set on the last one.
+const (
+	TotalPagesHeader     = "Total-Pages"
+	TotalItemCountHeader = "Total-Item-Count"
+	NextPageAfterHeader  = "Next-Page-After"
+)
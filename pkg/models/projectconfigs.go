@@ -1,5 +1,11 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type ProjectConfig struct {
 	ProjectID string `json:"projectId" yaml:"projectId"`
 	Key       string `json:"key" yaml:"key"`
@@ -7,9 +13,24 @@ type ProjectConfig struct {
 }
 
 const (
-	ServiceMetricsConfigKey = "service-metrics-config"
-	ProjectMetricsConfigKey = "project-metrics-config"
-	DeviceMetricsConfigKey  = "device-metrics-config"
+	ServiceMetricsConfigKey       = "service-metrics-config"
+	ProjectMetricsConfigKey       = "project-metrics-config"
+	DeviceMetricsConfigKey        = "device-metrics-config"
+	DeviceEndpointsConfigKey      = "device-endpoints-config"
+	SSHConfigKey                  = "ssh-config"
+	MetricsExportTargetsConfigKey = "metrics-export-targets-config"
+	MetricsCollectionConfigKey    = "metrics-collection-config"
+	AgentRolloutConfigKey         = "agent-rollout-config"
+	DeviceEnvironmentConfigKey    = "device-environment-config"
+	MaintenanceWindowsConfigKey   = "maintenance-windows-config"
+	ReleaseWebhooksConfigKey      = "release-webhooks-config"
+	EventWebhooksConfigKey        = "event-webhooks-config"
+	AuditLogConfigKey             = "audit-log-config"
+	SSOConfigKey                  = "sso-config"
+	LimitsConfigKey               = "limits-config"
+	HeartbeatConfigKey            = "heartbeat-config"
+	AlertsConfigKey               = "alerts-config"
+	StaleDeviceCleanupConfigKey   = "stale-device-cleanup-config"
 )
 
 type ServiceMetricsConfig struct {
@@ -30,4 +51,274 @@ type ExposedMetric struct {
 	Name       string   `json:"name" yaml:"name"`
 	Labels     []string `json:"labels" yaml:"labels"`
 	Properties []string `json:"properties" yaml:"properties"`
+	// DeviceGroups limits the metric to devices in these groups, given by
+	// name or ID. It's exposed for every device if it's empty.
+	DeviceGroups []string `json:"deviceGroups,omitempty" yaml:"deviceGroups,omitempty"`
+}
+
+// DeviceEndpointConfig exposes a port on every device in a project through
+// the controller. If ApplicationID is empty the port is on the device
+// itself, otherwise it's in the service's container.
+type DeviceEndpointConfig struct {
+	ApplicationID string `json:"applicationId" yaml:"applicationId"`
+	Service       string `json:"service" yaml:"service"`
+	Port          int    `json:"port" yaml:"port"`
+}
+
+type SSHConfig struct {
+	EnforceSSHKeys bool `json:"enforceSshKeys" yaml:"enforceSshKeys"`
+	RecordSessions bool `json:"recordSessions" yaml:"recordSessions"`
+}
+
+type MetricsExportTargetType string
+
+const (
+	MetricsExportTargetTypeDatadog               = MetricsExportTargetType("datadog")
+	MetricsExportTargetTypePrometheusRemoteWrite = MetricsExportTargetType("prometheus-remote-write")
+	MetricsExportTargetTypeInfluxDB              = MetricsExportTargetType("influxdb")
+)
+
+// MetricsExportTarget is a monitoring system that a project's exposed
+// metrics are sent to. URL is the endpoint metrics are written to, which
+// defaults to Datadog's US site for Datadog targets. Token is the Datadog API
+// key, the InfluxDB token, or a bearer token for remote write.
+type MetricsExportTarget struct {
+	Type  MetricsExportTargetType `json:"type" yaml:"type"`
+	URL   string                  `json:"url" yaml:"url"`
+	Token string                  `json:"token" yaml:"token"`
+}
+
+// MetricsCollectionConfig controls which metrics the agents in a project
+// collect and the labels they attach to them, so that fleets on expensive
+// links can trim their telemetry.
+type MetricsCollectionConfig struct {
+	// HostCollectors are the collectors used for host metrics. The agent's
+	// defaults are used if it's empty.
+	HostCollectors []string `json:"hostCollectors" yaml:"hostCollectors"`
+	// HostIntervalSeconds is how often host metrics are collected. The
+	// agent's default is used if it's zero.
+	HostIntervalSeconds int `json:"hostIntervalSeconds" yaml:"hostIntervalSeconds"`
+	// AllowedMetrics are the names of the host, service and ingested
+	// metrics that are kept. A name ending in * matches any name with that
+	// prefix. Every metric is kept if it's empty.
+	AllowedMetrics []string `json:"allowedMetrics" yaml:"allowedMetrics"`
+	// DeviceLabels are the keys of the device labels that are attached to
+	// every series.
+	DeviceLabels   []string `json:"deviceLabels" yaml:"deviceLabels"`
+	IncludeProject bool     `json:"includeProject" yaml:"includeProject"`
+	IncludeDevice  bool     `json:"includeDevice" yaml:"includeDevice"`
+}
+
+// DefaultMaxErrorRateIncrease is used for agent rollouts that don't set
+// MaxErrorRateIncrease.
+const DefaultMaxErrorRateIncrease = 10
+
+// AgentRolloutConfig rolls an agent version out to part of a project before
+// the rest of it. Devices that match Query, if it's set, and fall within
+// Percentage of the project are given Version and Spec instead of their own
+// desired agent version and spec. Raising Percentage to 100 rolls the
+// version out to every matching device. Devices that are pinned to an agent
+// version are left out.
+type AgentRolloutConfig struct {
+	Version    string    `json:"version" yaml:"version"`
+	Spec       AgentSpec `json:"spec" yaml:"spec"`
+	Percentage int       `json:"percentage" yaml:"percentage"`
+	Query      *Query    `json:"query,omitempty" yaml:"query,omitempty"`
+	// MaxErrorRateIncrease is how many percentage points higher the share of
+	// failing devices in the rollout can be than in the rest of the project
+	// before the rollout is halted. A device in the rollout is failing if
+	// it's offline or, once the rollout has had time to reach it, it isn't
+	// running the new version.
+	MaxErrorRateIncrease int `json:"maxErrorRateIncrease" yaml:"maxErrorRateIncrease"`
+	// StartedAt is set by the controller whenever the config is changed.
+	StartedAt time.Time `json:"startedAt" yaml:"startedAt"`
+	// Halted rollouts aren't given to any devices, so they fall back to
+	// their own desired agent version. Rollouts are resumed by setting it
+	// back to false.
+	Halted     bool   `json:"halted" yaml:"halted"`
+	HaltReason string `json:"haltReason" yaml:"haltReason"`
+}
+
+// AgentSpec holds what agents need to verify the binary of an agent version
+// before updating to it. Both maps are keyed by platform, such as
+// linux/arm. Digests are hex encoded SHA-256 digests of the binaries.
+// Signatures are base64 encoded Ed25519 signatures of SignedMessage, which
+// agents configured with an update public key require. Agents don't update
+// to a version older than their own unless Rollback is set.
+type AgentSpec struct {
+	Digests    map[string]string `json:"digests,omitempty" yaml:"digests,omitempty"`
+	Signatures map[string]string `json:"signatures,omitempty" yaml:"signatures,omitempty"`
+	Rollback   bool              `json:"rollback,omitempty" yaml:"rollback,omitempty"`
+}
+
+// SignedMessage returns what the signature of an agent version's binary for
+// a platform signs. It's the version, platform and lower case digest, one to
+// a line after a "deviceplane-agent" line, followed by a "rollback" line if
+// the spec allows rolling back to the version. A signature can't be reused
+// for another version or platform, and one for an old version doesn't let
+// agents be downgraded to it unless that was signed for too.
+func (s AgentSpec) SignedMessage(version, platform string) []byte {
+	message := fmt.Sprintf("deviceplane-agent\n%s\n%s\n%s\n", version, platform, strings.ToLower(s.Digests[platform]))
+	if s.Rollback {
+		message += "rollback\n"
+	}
+	return []byte(message)
+}
+
+// DeviceEnvironmentConfig sets environment variables for groups of devices.
+// A device gets the environment of every group whose query it matches, with
+// later groups overriding earlier ones, and then its own environment on top.
+type DeviceEnvironmentConfig struct {
+	Groups []DeviceEnvironmentGroup `json:"groups" yaml:"groups"`
+}
+
+type DeviceEnvironmentGroup struct {
+	Name        string            `json:"name" yaml:"name"`
+	Query       *Query            `json:"query,omitempty" yaml:"query,omitempty"`
+	Environment map[string]string `json:"environment" yaml:"environment"`
+}
+
+// MaintenanceWindowsConfig holds back release updates until devices are in
+// one of their maintenance windows. Devices keep running the release they
+// have until a window opens, while devices that aren't covered by any window
+// are updated right away. Applications are still installed on devices that
+// don't run them yet.
+type MaintenanceWindowsConfig struct {
+	Windows []MaintenanceWindow `json:"windows" yaml:"windows"`
+}
+
+// MaintenanceWindow is a time of day, on some days of the week, during which
+// devices can be updated. Start and End are times of day such as 02:30. A
+// window that ends before it starts runs into the next day, and its days are
+// the days it starts on.
+type MaintenanceWindow struct {
+	Name string `json:"name" yaml:"name"`
+	// DeviceGroups limits the window to devices in these groups, given by
+	// name or ID. It covers every device in the project if it's empty.
+	DeviceGroups []string `json:"deviceGroups,omitempty" yaml:"deviceGroups,omitempty"`
+	// Timezone is an IANA time zone, such as Europe/London. UTC is used if
+	// it's empty.
+	Timezone string `json:"timezone" yaml:"timezone"`
+	// Days are the days of the week the window opens on, such as monday.
+	// It opens every day if it's empty.
+	Days  []string `json:"days,omitempty" yaml:"days,omitempty"`
+	Start string   `json:"start" yaml:"start"`
+	End   string   `json:"end" yaml:"end"`
+}
+
+// ReleaseWebhooksConfig lists the webhooks that check every release config in
+// a project before it's accepted.
+type ReleaseWebhooksConfig struct {
+	Webhooks []ReleaseWebhook `json:"webhooks" yaml:"webhooks"`
+}
+
+// ReleaseWebhook is called with a ReleaseWebhookRequest and responds with a
+// ReleaseWebhookResponse. If Secret is set, requests are signed with it in
+// the X-Deviceplane-Signature header, as sha256= followed by the hex encoded
+// HMAC-SHA256 of the body.
+type ReleaseWebhook struct {
+	Name   string `json:"name" yaml:"name"`
+	URL    string `json:"url" yaml:"url"`
+	Secret string `json:"secret" yaml:"secret"`
+}
+
+// EventWebhooksConfig lists the webhooks a project's events are sent to.
+type EventWebhooksConfig struct {
+	Webhooks []EventWebhook `json:"webhooks" yaml:"webhooks"`
+}
+
+// EventWebhook is sent each Event whose type is in Events, or every event if
+// Events is empty. Requests are signed the same way as release webhooks'.
+type EventWebhook struct {
+	Name   string      `json:"name" yaml:"name"`
+	URL    string      `json:"url" yaml:"url"`
+	Secret string      `json:"secret" yaml:"secret"`
+	Events []EventType `json:"events" yaml:"events"`
+}
+
+// AuditLogConfig sets how long a project's audit log entries are kept. Zero
+// keeps them forever.
+type AuditLogConfig struct {
+	RetentionDays int `json:"retentionDays" yaml:"retentionDays"`
+}
+
+// SSOConfig maps the groups of users who log in with single sign-on to roles
+// in the project. Provider is who the groups are from, which is the issuer
+// of an OpenID Connect provider or the entity ID of a SAML identity
+// provider. Users who log in with it are made members of the project when
+// they're in any of the groups, and their bindings to the mapped roles are
+// kept in sync with their groups each time they log in. Roles that aren't
+// mapped are left alone.
+type SSOConfig struct {
+	Provider   string          `json:"provider" yaml:"provider"`
+	GroupRoles []SSOGroupRoles `json:"groupRoles" yaml:"groupRoles"`
+}
+
+// SSOGroupRoles binds the members of Group to roles, given by name.
+type SSOGroupRoles struct {
+	Group string   `json:"group" yaml:"group"`
+	Roles []string `json:"roles" yaml:"roles"`
+}
+
+// LimitsConfig caps what a project can use, so one project can't exhaust an
+// installation shared with others. Zero uses the installation's default,
+// which is unlimited unless it's set with controller flags. Only super
+// admins can change a project's limits.
+type LimitsConfig struct {
+	MaxDevices           int `json:"maxDevices" yaml:"maxDevices"`
+	MaxApplications      int `json:"maxApplications" yaml:"maxApplications"`
+	MaxReleaseConfigSize int `json:"maxReleaseConfigSize" yaml:"maxReleaseConfigSize"`
+	MaxRemoteSessions    int `json:"maxRemoteSessions" yaml:"maxRemoteSessions"`
+}
+
+// HeartbeatConfig sets how often a project's devices check in, and how long
+// after a device was last seen that it's considered offline. Zero uses the
+// default. Longer intervals use less bandwidth, at the cost of changes
+// reaching devices and devices being seen less often.
+type HeartbeatConfig struct {
+	IntervalSeconds         int `json:"intervalSeconds" yaml:"intervalSeconds"`
+	OfflineThresholdSeconds int `json:"offlineThresholdSeconds" yaml:"offlineThresholdSeconds"`
+}
+
+func (c HeartbeatConfig) Interval() time.Duration {
+	if c.IntervalSeconds == 0 {
+		return DefaultHeartbeatInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+func (c HeartbeatConfig) OfflineThreshold() time.Duration {
+	if c.OfflineThresholdSeconds == 0 {
+		return DeviceOfflineThreshold
+	}
+	return time.Duration(c.OfflineThresholdSeconds) * time.Second
+}
+
+type StaleDeviceCleanupAction string
+
+const (
+	// StaleDeviceCleanupActionArchive archives devices the same way as
+	// finishing a decommission, which hides them and revokes their access
+	// keys.
+	StaleDeviceCleanupActionArchive = StaleDeviceCleanupAction("archive")
+	StaleDeviceCleanupActionDelete  = StaleDeviceCleanupAction("delete")
+)
+
+// StaleDeviceCleanupConfig archives or deletes a project's devices once
+// they haven't connected for AfterDays. Devices with any of ExcludeLabels,
+// given as a key or key=value, are kept.
+type StaleDeviceCleanupConfig struct {
+	Enabled       bool                     `json:"enabled" yaml:"enabled"`
+	Action        StaleDeviceCleanupAction `json:"action" yaml:"action"`
+	AfterDays     int                      `json:"afterDays" yaml:"afterDays"`
+	ExcludeLabels []string                 `json:"excludeLabels" yaml:"excludeLabels"`
+}
+
+// StaleDeviceCleanupReport lists the devices a project's stale device
+// cleanup would remove, and how many stale devices its exclusion labels
+// keep.
+type StaleDeviceCleanupReport struct {
+	Config        StaleDeviceCleanupConfig `json:"config" yaml:"config"`
+	Devices       []Device                 `json:"devices" yaml:"devices"`
+	ExcludedCount int                      `json:"excludedCount" yaml:"excludedCount"`
 }
@@ -2,6 +2,10 @@ package models
 
 type Query []Filter
 
+// SelectorQueryParam is the query parameter device listings take selectors
+// in, as parsed by the controller's query package.
+const SelectorQueryParam = "selector"
+
 type Filter []Condition
 
 type Condition struct {
@@ -15,18 +19,26 @@ const (
 	DevicePropertyCondition = ConditionType("DevicePropertyCondition")
 	LabelValueCondition     = ConditionType("LabelValueCondition")
 	LabelExistenceCondition = ConditionType("LabelExistenceCondition")
+	DeviceLocationCondition = ConditionType("DeviceLocationCondition")
+	DeviceGroupCondition    = ConditionType("DeviceGroupCondition")
 )
 
+// DevicePropertyConditionParams and LabelValueConditionParams compare a
+// property or label against Value, or against Values for the in and not in
+// operators. The ordering operators compare versions, such as 1.10.2, with
+// numeric parts compared as numbers.
 type DevicePropertyConditionParams struct {
 	Property string   `json:"property"`
 	Operator Operator `json:"operator"`
 	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"`
 }
 
 type LabelValueConditionParams struct {
 	Key      string   `json:"key"`
 	Operator Operator `json:"operator"`
 	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"`
 }
 
 type LabelExistenceConditionParams struct {
@@ -34,12 +46,38 @@ type LabelExistenceConditionParams struct {
 	Operator Operator `json:"operator"`
 }
 
+// DeviceLocationConditionParams matches devices within Radius meters of a
+// point.
+type DeviceLocationConditionParams struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Radius    float64  `json:"radius"`
+	Operator  Operator `json:"operator"`
+}
+
+// DeviceGroupConditionParams matches devices in the group with the ID
+// Group.
+type DeviceGroupConditionParams struct {
+	Group    string   `json:"group"`
+	Operator Operator `json:"operator"`
+}
+
 type Operator string
 
 const (
 	OperatorIs    = Operator("is")
 	OperatorIsNot = Operator("is not")
+	OperatorIn    = Operator("in")
+	OperatorNotIn = Operator("not in")
+
+	OperatorGreaterThan        = Operator(">")
+	OperatorGreaterThanOrEqual = Operator(">=")
+	OperatorLessThan           = Operator("<")
+	OperatorLessThanOrEqual    = Operator("<=")
 
 	OperatorExists    = Operator("exists")
 	OperatorNotExists = Operator("does not exist")
+
+	OperatorWithin    = Operator("within")
+	OperatorNotWithin = Operator("not within")
 )
@@ -1,13 +1,157 @@
 package models
 
+import "time"
+
 type CreateReleaseRequest struct {
 	RawConfig string `json:"rawConfig" validate:"config"`
+	Notes     string `json:"notes" validate:"description"`
+	Source    string `json:"source" validate:"max=1000"`
+}
+
+// CreateCIReleaseRequest creates a release from a CI pipeline. The
+// IdempotencyKey is unique to the build, such as its ID, so retrying the
+// request returns the release the first attempt created.
+type CreateCIReleaseRequest struct {
+	IdempotencyKey string `json:"idempotencyKey" validate:"required,max=255"`
+	RawConfig      string `json:"rawConfig" validate:"config"`
+	Notes          string `json:"notes" validate:"description"`
+	Source         string `json:"source" validate:"max=1000"`
+}
+
+// CreateCIReleaseResponse is the release for an idempotency key. Created is
+// false if an earlier request with the key created it.
+type CreateCIReleaseResponse struct {
+	Release Release `json:"release"`
+	Created bool    `json:"created"`
+}
+
+// RollbackApplicationRequest rolls an application back to Release, a
+// release ID or number, or to the release before the running one if it's
+// empty.
+type RollbackApplicationRequest struct {
+	Release string `json:"release"`
+}
+
+type ConvertComposeRequest struct {
+	Compose string `json:"compose" validate:"config"`
+}
+
+type ConvertComposeResponse struct {
+	RawConfig string              `json:"rawConfig"`
+	Dropped   []ReleaseDiagnostic `json:"dropped"`
 }
 
 type ExecuteResponse struct {
 	ExitCode int `json:"exitCode"`
 }
 
+type PowerActionRequest struct {
+	Reason string `json:"reason" validate:"description"`
+}
+
+// SetDeviceAgentVersionRequest pins a device to an agent version. An empty
+// version unpins it.
+type SetDeviceAgentVersionRequest struct {
+	Version string    `json:"version"`
+	Spec    AgentSpec `json:"spec"`
+}
+
+// SetDeviceReleasePinRequest pins a device to a release of an application,
+// given by its ID or number.
+type SetDeviceReleasePinRequest struct {
+	Release string `json:"release" validate:"required"`
+}
+
+type BulkDeviceOperation string
+
+const (
+	BulkDeviceOperationSetLabel                  = BulkDeviceOperation("setLabel")
+	BulkDeviceOperationDeleteLabel               = BulkDeviceOperation("deleteLabel")
+	BulkDeviceOperationSetEnvironmentVariable    = BulkDeviceOperation("setEnvironmentVariable")
+	BulkDeviceOperationDeleteEnvironmentVariable = BulkDeviceOperation("deleteEnvironmentVariable")
+	BulkDeviceOperationSetReleasePin             = BulkDeviceOperation("setReleasePin")
+	BulkDeviceOperationDeleteReleasePin          = BulkDeviceOperation("deleteReleasePin")
+	BulkDeviceOperationDecommission              = BulkDeviceOperation("decommission")
+)
+
+// BulkDeviceOperationRequest applies an operation to either the devices
+// given by ID or name, or the devices matching Selector. Key and Value are
+// for label and environment variable operations, Application and Release
+// for release pin operations.
+type BulkDeviceOperationRequest struct {
+	Devices     []string            `json:"devices"`
+	Selector    string              `json:"selector"`
+	Operation   BulkDeviceOperation `json:"operation" validate:"required"`
+	Key         string              `json:"key"`
+	Value       string              `json:"value"`
+	Application string              `json:"application"`
+	Release     string              `json:"release"`
+}
+
+// BulkDeviceOperationResult is the outcome of an operation on one device.
+// StatusCode is what the single device endpoint for the operation returned.
+type BulkDeviceOperationResult struct {
+	Device     string `json:"device"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+}
+
+type BulkDeviceOperationResponse struct {
+	Results        []BulkDeviceOperationResult `json:"results"`
+	SucceededCount int                         `json:"succeededCount"`
+	FailedCount    int                         `json:"failedCount"`
+}
+
+// SetApplicationGitSyncRequest syncs an application from the spec file at
+// Path in a git repository. Credentials for private repositories can be
+// given in the repository URL. Releases record the repository and commit as
+// their source, so the repository has to leave room for the commit.
+type SetApplicationGitSyncRequest struct {
+	Repository string `json:"repository" validate:"required,max=950"`
+	Branch     string `json:"branch" validate:"max=255"`
+	Path       string `json:"path" validate:"required,max=1000"`
+}
+
+// TransferDeviceRequest moves a device to the project with the given name
+// or ID.
+type TransferDeviceRequest struct {
+	Project string `json:"project" validate:"required"`
+}
+
+// CreateRolloutRequest starts rolling a release out to an application's
+// devices. Release is a release ID or number, or the latest release if it's
+// empty. SoakPeriod is in seconds.
+type CreateRolloutRequest struct {
+	Release              string        `json:"release"`
+	Steps                []RolloutStep `json:"steps"`
+	SoakPeriod           int           `json:"soakPeriod"`
+	MaxFailurePercentage int           `json:"maxFailurePercentage"`
+}
+
+// HaltRolloutRequest halts a rollout, moving the devices in it back to the
+// previous release.
+type HaltRolloutRequest struct {
+	Reason string `json:"reason" validate:"description"`
+}
+
+// CreateAlertSilenceRequest silences alerts from a rule on a device, given
+// by its ID or name, until a time. An empty rule or device silences all.
+type CreateAlertSilenceRequest struct {
+	Rule   string    `json:"rule"`
+	Device string    `json:"device"`
+	Until  time.Time `json:"until" validate:"required"`
+	Reason string    `json:"reason" validate:"description"`
+}
+
+// SetDeviceViewRequest creates or replaces a device view. Views saved
+// without columns are shown with DefaultDeviceViewColumns.
+type SetDeviceViewRequest struct {
+	Name        string             `json:"name" validate:"name"`
+	Description string             `json:"description" validate:"description"`
+	Query       Query              `json:"query"`
+	Columns     []DeviceViewColumn `json:"columns"`
+}
+
 type RegisterDeviceRequest struct {
 	DeviceRegistrationTokenID string `json:"deviceRegistrationTokenId" validate:"id"`
 }
@@ -28,3 +172,31 @@ type SetDeviceApplicationStatusRequest struct {
 type SetDeviceServiceStatusRequest struct {
 	CurrentReleaseID string `json:"currentReleaseId" validate:"id"`
 }
+
+type FinishSessionRecordingRequest struct {
+	Recording string `json:"recording"`
+}
+
+type ValidateReleaseRequest struct {
+	RawConfig string `json:"rawConfig" validate:"config"`
+}
+
+// ReleaseWebhookRequest is sent to a project's release webhooks. Config is
+// the parsed RawConfig.
+type ReleaseWebhookRequest struct {
+	ProjectID     string             `json:"projectId"`
+	ApplicationID string             `json:"applicationId"`
+	RawConfig     string             `json:"rawConfig"`
+	Config        map[string]Service `json:"config"`
+}
+
+// ReleaseWebhookResponse rejects a release if any of its diagnostics are
+// errors.
+type ReleaseWebhookResponse struct {
+	Diagnostics []ReleaseDiagnostic `json:"diagnostics"`
+}
+
+type ValidateReleaseResponse struct {
+	Valid       bool                `json:"valid"`
+	Diagnostics []ReleaseDiagnostic `json:"diagnostics"`
+}
@@ -7,11 +7,17 @@ type ScheduledDevice struct {
 	ReleaseID string `json:"releaseId"`
 }
 
+// SchedulingRule picks the devices an application runs on and the release
+// each of them runs. Conditional rules select devices that match both
+// ConditionalQuery and ConditionalSelector, whichever are set. Selectors
+// are label selector expressions such as "region in (eu, uk) and hw !=
+// rpi3".
 type SchedulingRule struct {
-	ScheduleType     ScheduleType      `json:"scheduleType"`
-	DefaultReleaseID string            `json:"defaultReleaseId"` // TODO: validate Release ID?
-	ConditionalQuery *Query            `json:"conditionalQuery,omitempty"`
-	ReleaseSelectors []ReleaseSelector `json:"releaseSelectors"`
+	ScheduleType        ScheduleType      `json:"scheduleType"`
+	DefaultReleaseID    string            `json:"defaultReleaseId"` // TODO: validate Release ID?
+	ConditionalQuery    *Query            `json:"conditionalQuery,omitempty"`
+	ConditionalSelector string            `json:"conditionalSelector,omitempty"`
+	ReleaseSelectors    []ReleaseSelector `json:"releaseSelectors"`
 }
 
 type ScheduleType string
@@ -24,5 +30,6 @@ const (
 
 type ReleaseSelector struct {
 	Query     Query  `json:"releaseQuery"`
+	Selector  string `json:"selector,omitempty"`
 	ReleaseID string `json:"releaseId"` // TODO: validate Release ID?
 }
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DeviceVersionsReport counts devices by the agent version and the releases
+// they report running, to check how far rollouts have got.
+type DeviceVersionsReport struct {
+	AgentVersions []AgentVersionCount        `json:"agentVersions" yaml:"agentVersions"`
+	Applications  []ApplicationReleaseCounts `json:"applications" yaml:"applications"`
+}
+
+// AgentVersionCount is how many devices run an agent version. Devices that
+// haven't reported one are counted under an empty version.
+type AgentVersionCount struct {
+	Version string `json:"version" yaml:"version"`
+	Count   int    `json:"count" yaml:"count"`
+}
+
+// ApplicationReleaseCounts is how many devices run each release of an
+// application, and how many don't report running it at all.
+type ApplicationReleaseCounts struct {
+	Application string         `json:"application" yaml:"application"`
+	Releases    []ReleaseCount `json:"releases" yaml:"releases"`
+	NotReported int            `json:"notReported" yaml:"notReported"`
+}
+
+type ReleaseCount struct {
+	Release uint32 `json:"release" yaml:"release"`
+	Count   int    `json:"count" yaml:"count"`
+}
+
+// CompareVersions compares two versions, returning -1, 0 or 1. Versions are
+// split into parts on dots, dashes and pluses, and parts that are both
+// numbers are compared as numbers, so 1.10 is greater than 1.9. Other parts
+// are compared as strings. A leading v is ignored.
+func CompareVersions(a, b string) int {
+	split := func(version string) []string {
+		version = strings.TrimPrefix(strings.TrimPrefix(version, "v"), "V")
+		return strings.FieldsFunc(version, func(r rune) bool {
+			return r == '.' || r == '-' || r == '+'
+		})
+	}
+
+	aParts, bParts := split(a), split(b)
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		// Missing parts are treated as zero, so 1.2 equals 1.2.0
+		aPart, bPart := "0", "0"
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNumber, aErr := strconv.ParseUint(aPart, 10, 64)
+		bNumber, bErr := strconv.ParseUint(bPart, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNumber != bNumber {
+				if aNumber < bNumber {
+					return -1
+				}
+				return 1
+			}
+		case aPart != bPart:
+			if aPart < bPart {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.2", "1.2.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.0.0-rc1", "1.0.0-rc2", -1},
+		{"2", "10", -1},
+	} {
+		require.Equal(t, tc.expected, CompareVersions(tc.a, tc.b), "%s %s", tc.a, tc.b)
+	}
+}
@@ -0,0 +1,164 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	composeNetworkModeServicePrefix = "service:"
+)
+
+var (
+	errComposeMissingServices = errors.New("compose file has no services")
+)
+
+// FromCompose converts a docker-compose file into a release config. Only
+// version 2 and 3 files are supported, since earlier versions don't have a
+// services section. Directives that don't have a deviceplane equivalent are
+// dropped and returned as warnings.
+func FromCompose(c []byte) ([]byte, []models.ReleaseDiagnostic, error) {
+	var compose map[string]interface{}
+	if err := yaml.Unmarshal(c, &compose); err != nil {
+		return nil, nil, err
+	}
+
+	version := fmt.Sprint(compose["version"])
+	if _, ok := compose["version"]; ok && !strings.HasPrefix(version, "2") && !strings.HasPrefix(version, "3") {
+		return nil, nil, fmt.Errorf("unsupported compose file version '%s'", version)
+	}
+
+	services, ok := compose["services"].(map[interface{}]interface{})
+	if !ok {
+		return nil, nil, errComposeMissingServices
+	}
+
+	var dropped []models.ReleaseDiagnostic
+
+	var topLevelKeys []string
+	for key := range compose {
+		topLevelKeys = append(topLevelKeys, key)
+	}
+	sort.Strings(topLevelKeys)
+	for _, key := range topLevelKeys {
+		if key == "version" || key == "services" {
+			continue
+		}
+		dropped = append(dropped,
+			diagnosticf("", key, models.DiagnosticSeverityWarning, "top-level %s are not supported", key))
+	}
+
+	applicationConfig := make(map[string]map[interface{}]interface{})
+	for _, serviceName := range sortedKeys(services) {
+		service, ok := services[serviceName].(map[interface{}]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("service '%s': expected type object", serviceName)
+		}
+
+		converted := make(map[interface{}]interface{})
+		for _, key := range sortedKeys(service) {
+			value, err := convertComposeKey(key, service[key])
+			if err != nil {
+				dropped = append(dropped,
+					diagnosticf(serviceName, key, models.DiagnosticSeverityWarning, "%v", err))
+				continue
+			}
+			converted[key] = value
+		}
+		applicationConfig[serviceName] = converted
+	}
+
+	ret, err := yaml.Marshal(applicationConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := Validate(ret); err != nil {
+		return nil, nil, err
+	}
+
+	return ret, dropped, nil
+}
+
+func convertComposeKey(key string, value interface{}) (interface{}, error) {
+	validators, ok := validators[key]
+	if !ok {
+		return nil, errors.New("not supported")
+	}
+
+	var err error
+	switch key {
+	case "network_mode":
+		value, err = convertComposeNetworkMode(value)
+	case "ports":
+		value, err = convertComposePorts(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, validator := range validators {
+		if err := validator(value); err != nil {
+			return nil, fmt.Errorf("not supported: %v", err)
+		}
+	}
+	return value, nil
+}
+
+// Compose allows ports to be written as objects, which are converted to the
+// short syntax here.
+func convertComposePorts(value interface{}) (interface{}, error) {
+	ports, ok := value.([]interface{})
+	if !ok {
+		return value, nil
+	}
+
+	var ret []interface{}
+	for _, port := range ports {
+		longPort, ok := port.(map[interface{}]interface{})
+		if !ok {
+			ret = append(ret, port)
+			continue
+		}
+
+		target, ok := longPort["target"]
+		if !ok {
+			return nil, errors.New("not supported: port is missing a target")
+		}
+		shortPort := fmt.Sprint(target)
+		if published, ok := longPort["published"]; ok {
+			shortPort = fmt.Sprintf("%v:%s", published, shortPort)
+			if hostIP, ok := longPort["host_ip"]; ok {
+				shortPort = fmt.Sprintf("%v:%s", hostIP, shortPort)
+			}
+		}
+		if protocol, ok := longPort["protocol"]; ok {
+			shortPort = fmt.Sprintf("%s/%v", shortPort, protocol)
+		}
+		ret = append(ret, shortPort)
+	}
+	return ret, nil
+}
+
+// Compose refers to other services with service:<name>, which is what
+// container:<name> means in a release config. Compose's own container:<name>
+// refers to containers outside of the project, which releases can't do.
+func convertComposeNetworkMode(value interface{}) (interface{}, error) {
+	networkMode, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	switch {
+	case strings.HasPrefix(networkMode, composeNetworkModeServicePrefix):
+		return models.NetworkModeContainerPrefix + strings.TrimPrefix(networkMode, composeNetworkModeServicePrefix), nil
+	case strings.HasPrefix(networkMode, models.NetworkModeContainerPrefix):
+		return nil, errors.New("not supported: containers outside of the release can't be referenced")
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,65 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFromCompose(t *testing.T) {
+	t.Run("converted", func(t *testing.T) {
+		config, dropped, err := FromCompose([]byte(`version: "3.7"
+services:
+  web:
+    image: nginx
+    build: .
+    ports:
+      - 8080:80
+      - target: 443
+        published: 8443
+        protocol: tcp
+    environment:
+      A: b
+    depends_on:
+      - proxy
+  proxy:
+    image: envoy
+    network_mode: service:web
+volumes:
+  data: {}
+`))
+		require.NoError(t, err)
+		require.NoError(t, Validate(config))
+
+		var applicationConfig map[string]models.Service
+		require.NoError(t, yaml.UnmarshalStrict(config, &applicationConfig))
+		require.Equal(t, "nginx", applicationConfig["web"].Image)
+		require.Equal(t, []string{"8080:80", "8443:443/tcp"}, applicationConfig["web"].Ports)
+		require.Equal(t, "container:web", applicationConfig["proxy"].NetworkMode)
+
+		require.Equal(t, []models.ReleaseDiagnostic{
+			{Key: "volumes", Severity: models.DiagnosticSeverityWarning, Message: "top-level volumes are not supported"},
+			{Service: "web", Key: "build", Severity: models.DiagnosticSeverityWarning, Message: "not supported"},
+			{Service: "web", Key: "depends_on", Severity: models.DiagnosticSeverityWarning, Message: "not supported"},
+		}, dropped)
+	})
+
+	t.Run("external container", func(t *testing.T) {
+		_, dropped, err := FromCompose([]byte("version: '2'\nservices:\n  s:\n    image: x\n    network_mode: container:other\n"))
+		require.NoError(t, err)
+		require.Len(t, dropped, 1)
+		require.Equal(t, "network_mode", dropped[0].Key)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, _, err := FromCompose([]byte("version: '1'\nservices:\n  s:\n    image: x\n"))
+		require.Error(t, err)
+	})
+
+	t.Run("missing services", func(t *testing.T) {
+		_, _, err := FromCompose([]byte("s:\n  image: x\n"))
+		require.Equal(t, errComposeMissingServices, err)
+	})
+}
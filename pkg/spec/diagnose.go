@@ -0,0 +1,80 @@
+package spec
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deviceplane/deviceplane/pkg/interpolation"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// Diagnose reports every problem it can find in a release config, unlike
+// Validate which stops at the first one. Problems that would keep the
+// release from running are errors. Anything else worth a look, such as
+// variables that were never interpolated, is a warning.
+func Diagnose(c []byte) []models.ReleaseDiagnostic {
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(c, &m); err != nil {
+		return []models.ReleaseDiagnostic{
+			diagnosticf("", "", models.DiagnosticSeverityError, "%v", err),
+		}
+	}
+
+	diagnostics := validate(m)
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	var applicationConfig map[string]models.Service
+	if err := yaml.UnmarshalStrict(c, &applicationConfig); err != nil {
+		return []models.ReleaseDiagnostic{
+			diagnosticf("", "", models.DiagnosticSeverityError, "%v", err),
+		}
+	}
+
+	var serviceNames []string
+	for serviceName := range applicationConfig {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames)
+
+	for _, serviceName := range serviceNames {
+		service := applicationConfig[serviceName]
+		if service.Type != models.ServiceTypeSystemd && service.Image == "" {
+			diagnostics = append(diagnostics,
+				diagnosticf(serviceName, "image", models.DiagnosticSeverityError, "image is required"))
+		}
+	}
+
+	variables, err := interpolation.Variables(string(c))
+	if err != nil {
+		diagnostics = append(diagnostics,
+			diagnosticf("", "", models.DiagnosticSeverityWarning, "%v", err))
+	}
+	for _, variable := range variables {
+		diagnostics = append(diagnostics,
+			diagnosticf("", "", models.DiagnosticSeverityWarning, "variable %s was not interpolated", variable))
+	}
+
+	return diagnostics
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diagnostics []models.ReleaseDiagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == models.DiagnosticSeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func diagnosticf(serviceName, key, severity, format string, a ...interface{}) models.ReleaseDiagnostic {
+	return models.ReleaseDiagnostic{
+		Service:  serviceName,
+		Key:      key,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	}
+}
@@ -0,0 +1,43 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnose(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		diagnostics := Diagnose([]byte("s:\n  image: nginx\n  ports:\n    - 8080:80\n"))
+		require.Empty(t, diagnostics)
+	})
+
+	t.Run("structural", func(t *testing.T) {
+		diagnostics := Diagnose([]byte("a:\n  image: nginx\n  foo: bar\nb:\n  image: nginx\n  ports:\n    - 80:http\n"))
+		require.Equal(t, []models.ReleaseDiagnostic{
+			{Service: "a", Key: "foo", Severity: models.DiagnosticSeverityError, Message: "invalid key"},
+			{Service: "b", Key: "ports", Severity: models.DiagnosticSeverityError, Message: `Invalid containerPort: http`},
+		}, diagnostics)
+		require.True(t, HasErrors(diagnostics))
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		diagnostics := Diagnose([]byte("a:\n  command: sleep\nb:\n  type: systemd\n  command: sleep\n"))
+		require.Equal(t, []models.ReleaseDiagnostic{
+			{Service: "a", Key: "image", Severity: models.DiagnosticSeverityError, Message: "image is required"},
+		}, diagnostics)
+	})
+
+	t.Run("interpolation", func(t *testing.T) {
+		diagnostics := Diagnose([]byte("s:\n  image: nginx:${TAG}\n"))
+		require.Equal(t, []models.ReleaseDiagnostic{
+			{Severity: models.DiagnosticSeverityWarning, Message: "variable TAG was not interpolated"},
+		}, diagnostics)
+		require.False(t, HasErrors(diagnostics))
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		require.True(t, HasErrors(Diagnose([]byte("s: [\n"))))
+	})
+}
@@ -0,0 +1,175 @@
+package spec
+
+import (
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+// Diff compares the configs of two releases. Image, environment and port
+// changes are broken down, and other changes are listed by the key that
+// changed.
+func Diff(from, to map[string]models.Service) (models.ReleaseDiff, error) {
+	diff := models.ReleaseDiff{
+		AddedServices:   make([]string, 0),
+		RemovedServices: make([]string, 0),
+		ChangedServices: make([]models.ServiceDiff, 0),
+	}
+
+	for _, name := range sortedNames(to) {
+		if _, ok := from[name]; !ok {
+			diff.AddedServices = append(diff.AddedServices, name)
+		}
+	}
+
+	for _, name := range sortedNames(from) {
+		toService, ok := to[name]
+		if !ok {
+			diff.RemovedServices = append(diff.RemovedServices, name)
+			continue
+		}
+
+		serviceDiff, err := diffService(name, from[name], toService)
+		if err != nil {
+			return models.ReleaseDiff{}, err
+		}
+		if serviceDiff != nil {
+			diff.ChangedServices = append(diff.ChangedServices, *serviceDiff)
+		}
+	}
+
+	return diff, nil
+}
+
+func diffService(name string, from, to models.Service) (*models.ServiceDiff, error) {
+	serviceDiff := models.ServiceDiff{
+		Service: name,
+	}
+	changed := false
+
+	if from.Image != to.Image {
+		serviceDiff.Image = &models.Change{
+			From: from.Image,
+			To:   to.Image,
+		}
+		changed = true
+	}
+
+	fromEnvironment := from.Environment.ToMap()
+	toEnvironment := to.Environment.ToMap()
+	environmentKeys := make(map[string]bool)
+	for key := range fromEnvironment {
+		environmentKeys[key] = true
+	}
+	for key := range toEnvironment {
+		environmentKeys[key] = true
+	}
+	for _, key := range sortedSet(environmentKeys) {
+		fromValue, inFrom := fromEnvironment[key]
+		toValue, inTo := toEnvironment[key]
+		if inFrom && inTo && fromValue == toValue {
+			continue
+		}
+
+		keyChange := models.KeyChange{
+			Key: key,
+		}
+		if inFrom {
+			keyChange.From = &fromValue
+		}
+		if inTo {
+			keyChange.To = &toValue
+		}
+		serviceDiff.Environment = append(serviceDiff.Environment, keyChange)
+		changed = true
+	}
+
+	serviceDiff.AddedPorts = missingFrom(to.Ports, from.Ports)
+	serviceDiff.RemovedPorts = missingFrom(from.Ports, to.Ports)
+	if len(serviceDiff.AddedPorts) != 0 || len(serviceDiff.RemovedPorts) != 0 {
+		changed = true
+	}
+
+	fromKeys, err := serviceKeys(from)
+	if err != nil {
+		return nil, err
+	}
+	toKeys, err := serviceKeys(to)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for key := range fromKeys {
+		keys[key] = true
+	}
+	for key := range toKeys {
+		keys[key] = true
+	}
+	for _, key := range sortedSet(keys) {
+		switch key {
+		case "image", "environment", "ports":
+			continue
+		}
+		if !reflect.DeepEqual(fromKeys[key], toKeys[key]) {
+			serviceDiff.OtherChanges = append(serviceDiff.OtherChanges, key)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil, nil
+	}
+	return &serviceDiff, nil
+}
+
+// serviceKeys returns a service's config keyed by the keys it has in a
+// release config.
+func serviceKeys(s models.Service) (map[string]interface{}, error) {
+	serviceBytes, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var keys map[string]interface{}
+	if err := yaml.Unmarshal(serviceBytes, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func missingFrom(values, others []string) []string {
+	var missing []string
+	for _, value := range values {
+		found := false
+		for _, other := range others {
+			if value == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+func sortedNames(m map[string]models.Service) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedSet(set map[string]bool) []string {
+	var sorted []string
+	for value := range set {
+		sorted = append(sorted, value)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
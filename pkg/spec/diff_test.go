@@ -0,0 +1,93 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(config string) map[string]models.Service {
+		var services map[string]models.Service
+		require.NoError(t, yaml.UnmarshalStrict([]byte(config), &services))
+		return services
+	}
+
+	from := parse(`
+web:
+  image: nginx:1.17
+  environment:
+    LOG_LEVEL: info
+    REGION: eu
+    OLD: "1"
+  ports:
+  - 80:80
+  - 443:443
+  restart: always
+worker:
+  image: worker@sha256:aaaa
+cache:
+  image: redis
+`)
+	to := parse(`
+web:
+  image: nginx:1.18
+  environment:
+    LOG_LEVEL: debug
+    REGION: eu
+    NEW: "2"
+  ports:
+  - 443:443
+  - 8080:8080
+  restart: unless-stopped
+  privileged: true
+worker:
+  image: worker@sha256:bbbb
+cache:
+  image: redis
+metrics:
+  image: exporter
+`)
+
+	diff, err := Diff(from, to)
+	require.NoError(t, err)
+	require.Equal(t, []string{"metrics"}, diff.AddedServices)
+	require.Equal(t, []string{}, diff.RemovedServices)
+	require.Len(t, diff.ChangedServices, 2)
+
+	s := func(s string) *string { return &s }
+	require.Equal(t, models.ServiceDiff{
+		Service: "web",
+		Image: &models.Change{
+			From: "nginx:1.17",
+			To:   "nginx:1.18",
+		},
+		Environment: []models.KeyChange{
+			{Key: "LOG_LEVEL", From: s("info"), To: s("debug")},
+			{Key: "NEW", To: s("2")},
+			{Key: "OLD", From: s("1")},
+		},
+		AddedPorts:   []string{"8080:8080"},
+		RemovedPorts: []string{"80:80"},
+		OtherChanges: []string{"privileged", "restart"},
+	}, diff.ChangedServices[0])
+	require.Equal(t, models.ServiceDiff{
+		Service: "worker",
+		Image: &models.Change{
+			From: "worker@sha256:aaaa",
+			To:   "worker@sha256:bbbb",
+		},
+	}, diff.ChangedServices[1])
+
+	diff, err = Diff(to, from)
+	require.NoError(t, err)
+	require.Equal(t, []string{"metrics"}, diff.RemovedServices)
+
+	diff, err = Diff(from, from)
+	require.NoError(t, err)
+	require.Empty(t, diff.AddedServices)
+	require.Empty(t, diff.RemovedServices)
+	require.Empty(t, diff.ChangedServices)
+}
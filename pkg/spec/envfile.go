@@ -0,0 +1,71 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+)
+
+var validEnvironmentVariableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseEnvironmentFile parses the content of an environment file. Every line
+// sets a variable using KEY=VALUE, empty lines and lines starting with # are
+// ignored.
+func ParseEnvironmentFile(content string) ([]string, error) {
+	var environment []string
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(strings.TrimRight(line, "\r"), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || !validEnvironmentVariableName.MatchString(parts[0]) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+
+		environment = append(environment, line)
+	}
+	return environment, nil
+}
+
+// WithEnvironmentFiles adds the variables from the environment files
+// referenced by s to its environment. Variables set in the service's own
+// environment take precedence over the ones from files, and later files take
+// precedence over earlier ones.
+func WithEnvironmentFiles(s models.Service, environmentFiles map[string]string) (models.Service, error) {
+	if len(s.EnvFile) == 0 {
+		return s, nil
+	}
+
+	var environment []string
+	for _, name := range s.EnvFile {
+		content, ok := environmentFiles[name]
+		if !ok {
+			return s, fmt.Errorf("environment file '%s' not found", name)
+		}
+		fileEnvironment, err := ParseEnvironmentFile(content)
+		if err != nil {
+			return s, fmt.Errorf("environment file '%s': %v", name, err)
+		}
+		environment = append(environment, fileEnvironment...)
+	}
+	environment = append(environment, s.Environment...)
+
+	indexes := make(map[string]int)
+	var merged []string
+	for _, variable := range environment {
+		key := strings.SplitN(variable, "=", 2)[0]
+		if i, ok := indexes[key]; ok {
+			merged[i] = variable
+			continue
+		}
+		indexes[key] = len(merged)
+		merged = append(merged, variable)
+	}
+
+	s.Environment = merged
+	return s, nil
+}
@@ -0,0 +1,46 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/deviceplane/deviceplane/pkg/yamltypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvironmentFile(t *testing.T) {
+	environment, err := ParseEnvironmentFile("# database\nDB_HOST=db\r\n\n  DB_URL=postgres://db?a=b\nEMPTY=\n")
+	require.NoError(t, err)
+	require.Equal(t, []string{"DB_HOST=db", "DB_URL=postgres://db?a=b", "EMPTY="}, environment)
+
+	_, err = ParseEnvironmentFile("DB_HOST")
+	require.Error(t, err)
+
+	_, err = ParseEnvironmentFile("DB HOST=db")
+	require.Error(t, err)
+}
+
+func TestWithEnvironmentFiles(t *testing.T) {
+	environmentFiles := map[string]string{
+		"common": "A=1\nB=2\n",
+		"prod":   "B=3\nC=4\n",
+	}
+
+	s, err := WithEnvironmentFiles(models.Service{
+		EnvFile:     yamltypes.Stringorslice{"common", "prod"},
+		Environment: yamltypes.MaporEqualSlice{"C=5", "D=6"},
+	}, environmentFiles)
+	require.NoError(t, err)
+	require.Equal(t, yamltypes.MaporEqualSlice{"A=1", "B=3", "C=5", "D=6"}, s.Environment)
+
+	s, err = WithEnvironmentFiles(models.Service{
+		Environment: yamltypes.MaporEqualSlice{"A=1"},
+	}, environmentFiles)
+	require.NoError(t, err)
+	require.Equal(t, yamltypes.MaporEqualSlice{"A=1"}, s.Environment)
+
+	_, err = WithEnvironmentFiles(models.Service{
+		EnvFile: yamltypes.Stringorslice{"staging"},
+	}, environmentFiles)
+	require.Error(t, err)
+}
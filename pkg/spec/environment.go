@@ -0,0 +1,47 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deviceplane/deviceplane/pkg/interpolation"
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"gopkg.in/yaml.v2"
+)
+
+// ValidateEnvironment checks the names and values of device environment
+// variables. Values can't span lines since they're interpolated into YAML.
+func ValidateEnvironment(environment map[string]string) error {
+	for key, value := range environment {
+		if !validEnvironmentVariableName.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name '%s'", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("environment variable '%s' can't contain newlines", key)
+		}
+	}
+	return nil
+}
+
+// WithDeviceEnvironment interpolates a device's environment into a release.
+// Only variables in the environment are replaced, so the rest of the config
+// is left as it was when the release was created.
+func WithDeviceEnvironment(release models.Release, environment map[string]string) (models.Release, error) {
+	if len(environment) == 0 {
+		return release, nil
+	}
+
+	rawConfig := interpolation.InterpolateDefined(release.RawConfig, environment)
+	if rawConfig == release.RawConfig {
+		return release, nil
+	}
+
+	var config map[string]models.Service
+	if err := yaml.Unmarshal([]byte(rawConfig), &config); err != nil {
+		return release, err
+	}
+
+	release.RawConfig = rawConfig
+	release.Config = config
+	return release, nil
+}
@@ -0,0 +1,38 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/deviceplane/deviceplane/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEnvironment(t *testing.T) {
+	require.NoError(t, ValidateEnvironment(map[string]string{"SITE": "north", "EMPTY": ""}))
+	require.Error(t, ValidateEnvironment(map[string]string{"SITE NAME": "north"}))
+	require.Error(t, ValidateEnvironment(map[string]string{"SITE": "north\nsouth"}))
+}
+
+func TestWithDeviceEnvironment(t *testing.T) {
+	release := models.Release{
+		RawConfig: "app:\n  image: app:${TAG}\n  environment:\n  - SITE=$SITE\n",
+		Config: map[string]models.Service{
+			"app": {
+				Image:       "app:${TAG}",
+				Environment: []string{"SITE=$SITE"},
+			},
+		},
+	}
+
+	interpolated, err := WithDeviceEnvironment(release, nil)
+	require.NoError(t, err)
+	require.Equal(t, release, interpolated)
+
+	interpolated, err = WithDeviceEnvironment(release, map[string]string{"TAG": "1.2.0"})
+	require.NoError(t, err)
+	require.Equal(t, "app:1.2.0", interpolated.Config["app"].Image)
+	require.Equal(t, []string{"SITE=$SITE"}, []string(interpolated.Config["app"].Environment))
+
+	// The original release isn't changed
+	require.Equal(t, "app:${TAG}", release.Config["app"].Image)
+}
@@ -24,6 +24,40 @@ func WithStandardLabels(s models.Service, applicationID, serviceName string) mod
 	return s
 }
 
+// NetworkContainer returns the name of the service whose network stack s
+// shares, if any. Services can only share the network stack of another
+// service in the same application.
+func NetworkContainer(s models.Service) (string, bool) {
+	if !strings.HasPrefix(s.NetworkMode, models.NetworkModeContainerPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(s.NetworkMode, models.NetworkModeContainerPrefix), true
+}
+
+// NoNewPrivileges returns whether s prevents its processes from gaining
+// privileges through setuid binaries or file capabilities.
+func NoNewPrivileges(s models.Service) bool {
+	noNewPrivileges := false
+	for _, opt := range s.SecurityOpt {
+		key, value := splitSecurityOpt(opt)
+		if key != models.SecurityOptNoNewPrivileges {
+			continue
+		}
+		// Later options take precedence, like they do for docker
+		noNewPrivileges = value == "" || value == "true"
+	}
+	return noNewPrivileges
+}
+
+// splitSecurityOpt splits a security option into its key and value. Docker
+// accepts both "key=value" and the older "key:value" form.
+func splitSecurityOpt(opt string) (string, string) {
+	if i := strings.IndexAny(opt, "=:"); i >= 0 {
+		return opt[:i], opt[i+1:]
+	}
+	return opt, ""
+}
+
 func Hash(s models.Service, name string) string {
 	return applyHash(s, name, hash.Hash)
 }
@@ -61,22 +95,50 @@ func applyHash(s models.Service, name string, hash func(string) string) string {
 	parts = append(parts, s.GroupAdd...)
 	parts = append(parts, s.Image)
 	parts = append(parts, s.Hostname)
+	if s.Init {
+		parts = append(parts, fmt.Sprint(s.Init))
+	}
 	parts = append(parts, s.Ipc)
 	parts = append(parts, mapToSlice(s.Labels)...)
+	if s.Logging != nil {
+		parts = append(parts, s.Logging.Driver)
+		parts = append(parts, mapToSlice(s.Logging.Options)...)
+	}
 	parts = append(parts, fmt.Sprint(s.MemLimit))
 	parts = append(parts, fmt.Sprint(s.MemReservation, 10))
 	parts = append(parts, fmt.Sprint(s.MemSwapLimit, 10))
 	parts = append(parts, fmt.Sprint(s.NetworkMode, 10))
+	if s.Networks != nil {
+		parts = append(parts, s.Networks.HashString())
+	}
 	parts = append(parts, fmt.Sprint(s.OomKillDisable))
 	parts = append(parts, fmt.Sprint(s.OomScoreAdj))
 	parts = append(parts, s.Pid)
+	if s.PidsLimit != 0 {
+		parts = append(parts, fmt.Sprint(s.PidsLimit))
+	}
 	parts = append(parts, s.Ports...)
 	parts = append(parts, fmt.Sprint(s.Privileged))
 	parts = append(parts, fmt.Sprint(s.ReadOnly))
 	parts = append(parts, s.Runtime)
 	parts = append(parts, s.SecurityOpt...)
 	parts = append(parts, fmt.Sprint(s.ShmSize))
+	if s.StopGracePeriod != 0 {
+		parts = append(parts, fmt.Sprint(s.StopGracePeriod))
+	}
 	parts = append(parts, s.StopSignal)
+	if len(s.Sysctls) > 0 {
+		parts = append(parts, mapToSlice(s.Sysctls.ToMap())...)
+	}
+	if s.Type != "" {
+		parts = append(parts, s.Type)
+	}
+	if s.Ulimits != nil {
+		parts = append(parts, s.Ulimits.HashString())
+	}
+	if s.Unit != "" {
+		parts = append(parts, s.Unit)
+	}
 	parts = append(parts, s.User)
 	parts = append(parts, s.Uts)
 	parts = append(parts, s.Volumes.HashString())
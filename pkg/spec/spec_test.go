@@ -2,6 +2,7 @@ package spec
 
 import (
 	"testing"
+	"time"
 
 	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/yamltypes"
@@ -17,39 +18,65 @@ func fullService() models.Service {
 		CPUShares:   yamltypes.StringorInt(1),
 		CPUQuota:    yamltypes.StringorInt(1),
 		Devices:     []string{"x", "y", "z"},
-		DNS:         yamltypes.Stringorslice([]string{"x", "y", "z"}),
+		DNS:         yamltypes.Stringorslice([]string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}),
 		DNSOpts:     []string{"x", "y", "z"},
 		DNSSearch:   yamltypes.Stringorslice([]string{"x", "y", "z"}),
 		DomainName:  "x",
 		Entrypoint:  yamltypes.Command([]string{"x", "y", "z"}),
+		EnvFile:     yamltypes.Stringorslice{"x"},
 		Environment: yamltypes.MaporEqualSlice([]string{"x", "y", "z"}),
-		ExtraHosts:  []string{"x", "y", "z"},
+		ExtraHosts:  yamltypes.MaporColonSlice([]string{"x:10.0.0.1", "y:10.0.0.2", "z:10.0.0.3"}),
 		GroupAdd:    []string{"x", "y", "z"},
 		Image:       "x",
 		Hostname:    "x",
+		Init:        true,
 		Ipc:         "x",
 		Labels: yamltypes.SliceorMap(map[string]string{
 			"k1": "v1",
 			"k2": "v2",
 			"k3": "v3",
 		}),
+		Logging: &models.Logging{
+			Driver: "json-file",
+			Options: map[string]string{
+				"max-size": "10m",
+			},
+		},
 		MemLimit:       yamltypes.MemStringorInt(1),
 		MemReservation: yamltypes.MemStringorInt(1),
 		MemSwapLimit:   yamltypes.MemStringorInt(1),
-		NetworkMode:    "x",
-		OomKillDisable: true,
-		OomScoreAdj:    yamltypes.StringorInt(1),
-		Pid:            "x",
-		Ports:          []string{"x", "y", "z"},
-		Privileged:     true,
-		ReadOnly:       true,
-		Restart:        "always",
-		Runtime:        "nvidia",
-		SecurityOpt:    []string{"x", "y", "z"},
-		ShmSize:        yamltypes.MemStringorInt(1),
-		StopSignal:     "x",
-		User:           "x",
-		Uts:            "x",
+		NetworkMode:    models.NetworkModeBridge,
+		Networks: &yamltypes.Networks{
+			Networks: []*yamltypes.Network{
+				{
+					Name:    "x",
+					Aliases: []string{"y"},
+				},
+			},
+		},
+		OomKillDisable:  true,
+		OomScoreAdj:     yamltypes.StringorInt(1),
+		Pid:             "x",
+		PidsLimit:       100,
+		Ports:           []string{"80", "8080:80", "127.0.0.1:53:53/udp"},
+		Privileged:      true,
+		ReadOnly:        true,
+		Restart:         "always",
+		Runtime:         "nvidia",
+		SecurityOpt:     []string{"no-new-privileges", "apparmor=x", "seccomp=y"},
+		ShmSize:         yamltypes.MemStringorInt(1),
+		StopGracePeriod: yamltypes.Duration(time.Minute),
+		StopSignal:      "SIGINT",
+		Sysctls:         yamltypes.MaporEqualSlice([]string{"net.core.somaxconn=1024"}),
+		Type:            models.ServiceTypeContainer,
+		Unit:            "x",
+		User:            "x",
+		Uts:             "x",
+		Ulimits: &yamltypes.Ulimits{
+			Elements: []yamltypes.Ulimit{
+				{Name: "nofile", Soft: 1024, Hard: 4096},
+			},
+		},
 		Volumes: &yamltypes.Volumes{
 			Volumes: []*yamltypes.Volume{
 				{
@@ -78,6 +105,61 @@ func TestHash(t *testing.T) {
 			s.Command = yamltypes.Command([]string{"xx", "yy", "zz"})
 			return s
 		},
+		func(s models.Service) models.Service {
+			s.Type = models.ServiceTypeSystemd
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Unit = "xx"
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Logging = nil
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Logging = &models.Logging{
+				Driver: "json-file",
+				Options: map[string]string{
+					"max-size": "20m",
+				},
+			}
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Networks = &yamltypes.Networks{
+				Networks: []*yamltypes.Network{
+					{
+						Name: "x",
+					},
+				},
+			}
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Sysctls = yamltypes.MaporEqualSlice([]string{"net.core.somaxconn=2048"})
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Init = false
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.StopGracePeriod = yamltypes.Duration(time.Second)
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.PidsLimit = 200
+			return s
+		},
+		func(s models.Service) models.Service {
+			s.Ulimits = &yamltypes.Ulimits{
+				Elements: []yamltypes.Ulimit{
+					{Name: "nofile", Soft: 4096, Hard: 4096},
+				},
+			}
+			return s
+		},
 		func(s models.Service) models.Service {
 			s.MemLimit = yamltypes.MemStringorInt(2)
 			return s
@@ -163,3 +245,38 @@ func TestHash(t *testing.T) {
 		require.NotEqual(t, Hash(s, ""), Hash(f(s), ""))
 	}
 }
+
+// Agents recreate services whose hash changes, so fields added to services
+// can't change the hash of services that don't set them
+func TestHashUnchangedByNewFields(t *testing.T) {
+	require.Equal(t,
+		"254aaee73f9f833276fedbe73846ec0578200a25d518cb451c9ebd23ea153a02",
+		Hash(models.Service{Image: "nginx"}, "web"),
+	)
+	require.Equal(t,
+		"abf1f0257d43cf7e67eb9b67d2d152fb7a78971b70af14d0b383e5ccd2308ec1",
+		Hash(models.Service{
+			Image:       "nginx:1.17",
+			Command:     yamltypes.Command([]string{"nginx", "-g", "daemon off;"}),
+			Environment: yamltypes.MaporEqualSlice([]string{"A=1"}),
+			Ports:       []string{"8080:80"},
+			Restart:     "always",
+		}, "web"),
+	)
+}
+
+func TestNoNewPrivileges(t *testing.T) {
+	require.False(t, NoNewPrivileges(models.Service{}))
+	require.True(t, NoNewPrivileges(models.Service{SecurityOpt: []string{"no-new-privileges"}}))
+	require.True(t, NoNewPrivileges(models.Service{SecurityOpt: []string{"no-new-privileges=true"}}))
+	require.False(t, NoNewPrivileges(models.Service{SecurityOpt: []string{"no-new-privileges", "no-new-privileges:false"}}))
+}
+
+func TestNetworkContainer(t *testing.T) {
+	_, ok := NetworkContainer(models.Service{NetworkMode: models.NetworkModeHost})
+	require.False(t, ok)
+
+	target, ok := NetworkContainer(models.Service{NetworkMode: "container:vpn"})
+	require.True(t, ok)
+	require.Equal(t, "vpn", target)
+}
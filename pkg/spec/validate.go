@@ -1,95 +1,557 @@
 package spec
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/deviceplane/deviceplane/pkg/models"
 	"github.com/deviceplane/deviceplane/pkg/validation"
+	"github.com/docker/go-connections/nat"
 	"gopkg.in/yaml.v2"
 )
 
 var (
 	validators = map[string][]func(interface{}) error{
-		"cap_add":          []func(interface{}) error{validation.ValidateStringArray},
-		"cap_drop":         []func(interface{}) error{validation.ValidateStringArray},
-		"command":          []func(interface{}) error{validation.ValidateStringOrStringArray},
-		"cpuset":           []func(interface{}) error{validation.ValidateString},
-		"cpu_shares":       []func(interface{}) error{validation.ValidateStringOrInteger},
-		"cpu_quota":        []func(interface{}) error{validation.ValidateStringOrInteger},
-		"devices":          []func(interface{}) error{validation.ValidateStringArray},
-		"dns":              []func(interface{}) error{validation.ValidateStringOrStringArray},
-		"dns_opt":          []func(interface{}) error{validation.ValidateStringOrStringArray},
-		"dns_search":       []func(interface{}) error{validation.ValidateStringOrStringArray},
-		"domainname":       []func(interface{}) error{validation.ValidateString},
-		"entrypoint":       []func(interface{}) error{validation.ValidateStringOrStringArray},
-		"environment":      []func(interface{}) error{validation.ValidateArrayOrObject},
-		"extra_hosts":      []func(interface{}) error{validation.ValidateArrayOrObject},
-		"group_add":        []func(interface{}) error{validation.ValidateStringIntegerArray},
-		"image":            []func(interface{}) error{validation.ValidateString},
-		"hostname":         []func(interface{}) error{validation.ValidateString},
-		"ipc":              []func(interface{}) error{validation.ValidateString},
-		"labels":           []func(interface{}) error{validation.ValidateArrayOrObject},
-		"mem_limit":        []func(interface{}) error{validation.ValidateStringOrInteger},
-		"mem_reservation":  []func(interface{}) error{validation.ValidateStringOrInteger},
-		"memswap_limit":    []func(interface{}) error{validation.ValidateStringOrInteger},
-		"network_mode":     []func(interface{}) error{validation.ValidateString},
-		"oom_kill_disable": []func(interface{}) error{validation.ValidateBoolean},
-		"oom_score_adj":    []func(interface{}) error{validation.ValidateInteger},
-		"pid":              []func(interface{}) error{validation.ValidateString},
-		"ports":            []func(interface{}) error{validation.ValidateStringIntegerArray},
-		"privileged":       []func(interface{}) error{validation.ValidateBoolean},
-		"read_only":        []func(interface{}) error{validation.ValidateBoolean},
-		"restart":          []func(interface{}) error{validation.ValidateString},
-		"runtime":          []func(interface{}) error{validation.ValidateString},
-		"security_opt":     []func(interface{}) error{validation.ValidateStringArray},
-		"shm_size":         []func(interface{}) error{validation.ValidateStringOrInteger},
-		"stop_signal":      []func(interface{}) error{validation.ValidateString},
-		"user":             []func(interface{}) error{validation.ValidateString},
-		"uts":              []func(interface{}) error{validation.ValidateString},
-		"volumes":          []func(interface{}) error{validation.ValidateStringArray},
-		"working_dir":      []func(interface{}) error{validation.ValidateString},
+		"cap_add":           []func(interface{}) error{validation.ValidateStringArray},
+		"cap_drop":          []func(interface{}) error{validation.ValidateStringArray},
+		"command":           []func(interface{}) error{validation.ValidateStringOrStringArray},
+		"cpuset":            []func(interface{}) error{validation.ValidateString},
+		"cpu_shares":        []func(interface{}) error{validation.ValidateStringOrInteger},
+		"cpu_quota":         []func(interface{}) error{validation.ValidateStringOrInteger},
+		"devices":           []func(interface{}) error{validation.ValidateStringArray},
+		"dns":               []func(interface{}) error{validation.ValidateStringOrStringArray, validateDNS},
+		"dns_opt":           []func(interface{}) error{validation.ValidateStringOrStringArray},
+		"dns_search":        []func(interface{}) error{validation.ValidateStringOrStringArray, validateDNSSearch},
+		"domainname":        []func(interface{}) error{validation.ValidateString},
+		"entrypoint":        []func(interface{}) error{validation.ValidateStringOrStringArray},
+		"env_file":          []func(interface{}) error{validation.ValidateStringOrStringArray, validateEnvFile},
+		"environment":       []func(interface{}) error{validation.ValidateArrayOrObject},
+		"extra_hosts":       []func(interface{}) error{validation.ValidateArrayOrObject, validateExtraHosts},
+		"group_add":         []func(interface{}) error{validation.ValidateStringIntegerArray},
+		"image":             []func(interface{}) error{validation.ValidateString},
+		"hostname":          []func(interface{}) error{validation.ValidateString},
+		"init":              []func(interface{}) error{validation.ValidateBoolean},
+		"ipc":               []func(interface{}) error{validation.ValidateString},
+		"labels":            []func(interface{}) error{validation.ValidateArrayOrObject},
+		"logging":           []func(interface{}) error{validateLogging},
+		"mem_limit":         []func(interface{}) error{validation.ValidateStringOrInteger},
+		"mem_reservation":   []func(interface{}) error{validation.ValidateStringOrInteger},
+		"memswap_limit":     []func(interface{}) error{validation.ValidateStringOrInteger},
+		"metrics":           []func(interface{}) error{validateMetrics},
+		"network_mode":      []func(interface{}) error{validation.ValidateString, validateNetworkMode},
+		"networks":          []func(interface{}) error{validateNetworks},
+		"oom_kill_disable":  []func(interface{}) error{validation.ValidateBoolean},
+		"oom_score_adj":     []func(interface{}) error{validation.ValidateInteger},
+		"pid":               []func(interface{}) error{validation.ValidateString},
+		"pids_limit":        []func(interface{}) error{validation.ValidateInteger, validatePidsLimit},
+		"ports":             []func(interface{}) error{validation.ValidateStringIntegerArray, validatePorts},
+		"privileged":        []func(interface{}) error{validation.ValidateBoolean},
+		"read_only":         []func(interface{}) error{validation.ValidateBoolean},
+		"restart":           []func(interface{}) error{validation.ValidateString},
+		"runtime":           []func(interface{}) error{validation.ValidateString},
+		"security_opt":      []func(interface{}) error{validation.ValidateStringArray, validateSecurityOpt},
+		"shm_size":          []func(interface{}) error{validation.ValidateStringOrInteger},
+		"stop_grace_period": []func(interface{}) error{validation.ValidateString, validateDuration},
+		"stop_signal":       []func(interface{}) error{validation.ValidateString, validateSignal},
+		"sysctls":           []func(interface{}) error{validateSysctls},
+		"type":              []func(interface{}) error{validation.ValidateString, validateServiceType},
+		"ulimits":           []func(interface{}) error{validateUlimits},
+		"unit":              []func(interface{}) error{validation.ValidateString},
+		"user":              []func(interface{}) error{validation.ValidateString},
+		"uts":               []func(interface{}) error{validation.ValidateString},
+		"volumes":           []func(interface{}) error{validation.ValidateStringArray},
+		"working_dir":       []func(interface{}) error{validation.ValidateString},
 	}
+
+	validateNetwork = validation.ValidateObject(map[string][]func(interface{}) error{
+		"aliases": []func(interface{}) error{validation.ValidateStringArray},
+	})
+
+	validDomain = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*\.?$`)
+
+	// Only sysctls that are namespaced can be set per container
+	namespacedSysctlPrefixes = []string{"kernel.msg", "kernel.sem", "kernel.shm", "fs.mqueue.", "net."}
+	validSysctlName          = regexp.MustCompile(`^[a-z0-9_]+(\.[a-zA-Z0-9_-]+)+$`)
+
+	ulimitNames = map[string]struct{}{
+		"core": {}, "cpu": {}, "data": {}, "fsize": {}, "locks": {},
+		"memlock": {}, "msgqueue": {}, "nice": {}, "nofile": {}, "nproc": {},
+		"rss": {}, "rtprio": {}, "rttime": {}, "sigpending": {}, "stack": {},
+	}
+
+	validateUlimit = validation.ValidateObject(map[string][]func(interface{}) error{
+		"soft": []func(interface{}) error{validation.ValidateInteger},
+		"hard": []func(interface{}) error{validation.ValidateInteger},
+	})
+
+	// Environment files are referenced by the name they're stored under
+	validEnvironmentFileName = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
+	validSignal = regexp.MustCompile(`^(SIG)?[A-Z][A-Z0-9]*([+-][0-9]+)?$|^[0-9]+$`)
+
+	validNetworkName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+	validateLogging = validation.ValidateObject(map[string][]func(interface{}) error{
+		"driver":  []func(interface{}) error{validation.ValidateString},
+		"options": []func(interface{}) error{validation.ValidateStringOrIntegerObject},
+	})
+
+	validateMetricsEndpoint = validation.ValidateObject(map[string][]func(interface{}) error{
+		"port":   []func(interface{}) error{validation.ValidateInteger},
+		"path":   []func(interface{}) error{validation.ValidateString},
+		"labels": []func(interface{}) error{validation.ValidateStringOrIntegerObject},
+	})
+
+	validMetricsLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
 )
 
+func validateServiceType(elem interface{}) error {
+	switch elem {
+	case models.ServiceTypeContainer, models.ServiceTypeSystemd:
+		return nil
+	default:
+		return fmt.Errorf("expected one of %s, %s", models.ServiceTypeContainer, models.ServiceTypeSystemd)
+	}
+}
+
+func validatePorts(elem interface{}) error {
+	for _, port := range elem.([]interface{}) {
+		if _, err := nat.ParsePortSpec(fmt.Sprint(port)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateNetworkMode(elem interface{}) error {
+	networkMode := elem.(string)
+	switch {
+	case networkMode == models.NetworkModeDefault,
+		networkMode == models.NetworkModeBridge,
+		networkMode == models.NetworkModeHost,
+		networkMode == models.NetworkModeNone:
+		return nil
+	case strings.HasPrefix(networkMode, models.NetworkModeContainerPrefix) &&
+		len(networkMode) > len(models.NetworkModeContainerPrefix):
+		return nil
+	default:
+		return fmt.Errorf("expected one of %s, %s, %s, %s or %s<service>",
+			models.NetworkModeDefault, models.NetworkModeBridge, models.NetworkModeHost,
+			models.NetworkModeNone, models.NetworkModeContainerPrefix)
+	}
+}
+
+func validateDNS(elem interface{}) error {
+	for _, nameserver := range stringOrStringArray(elem) {
+		if net.ParseIP(nameserver) == nil {
+			return fmt.Errorf("invalid nameserver '%s', expected an IP address", nameserver)
+		}
+	}
+	return nil
+}
+
+func validateDNSSearch(elem interface{}) error {
+	for _, domain := range stringOrStringArray(elem) {
+		// A single dot disables the search list
+		if domain != "." && !validDomain.MatchString(domain) {
+			return fmt.Errorf("invalid search domain '%s'", domain)
+		}
+	}
+	return nil
+}
+
+func stringOrStringArray(elem interface{}) []string {
+	switch typedElem := elem.(type) {
+	case string:
+		return []string{typedElem}
+	case []interface{}:
+		var ret []string
+		for _, e := range typedElem {
+			ret = append(ret, e.(string))
+		}
+		return ret
+	default:
+		return nil
+	}
+}
+
+func validateEnvFile(elem interface{}) error {
+	for _, name := range stringOrStringArray(elem) {
+		if !validEnvironmentFileName.MatchString(name) {
+			return fmt.Errorf("invalid environment file name '%s'", name)
+		}
+	}
+	return nil
+}
+
+func validateExtraHosts(elem interface{}) error {
+	var extraHosts []string
+	switch typedElem := elem.(type) {
+	case []interface{}:
+		for _, extraHost := range typedElem {
+			extraHosts = append(extraHosts, extraHost.(string))
+		}
+	case map[interface{}]interface{}:
+		for host, ip := range typedElem {
+			extraHosts = append(extraHosts, fmt.Sprintf("%v:%v", host, ip))
+		}
+	}
+
+	for _, extraHost := range extraHosts {
+		parts := strings.SplitN(extraHost, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || net.ParseIP(parts[1]) == nil {
+			return fmt.Errorf("invalid extra host '%s', expected host:ip", extraHost)
+		}
+	}
+
+	return nil
+}
+
+func validateSysctls(elem interface{}) error {
+	var names []string
+	switch typedElem := elem.(type) {
+	case []interface{}:
+		for _, sysctl := range typedElem {
+			typedSysctl, ok := sysctl.(string)
+			if !ok {
+				return fmt.Errorf("expected type string")
+			}
+			parts := strings.SplitN(typedSysctl, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("invalid sysctl '%s', expected name=value", typedSysctl)
+			}
+			names = append(names, parts[0])
+		}
+	case map[interface{}]interface{}:
+		if err := validation.ValidateStringOrIntegerObject(typedElem); err != nil {
+			return err
+		}
+		for name := range typedElem {
+			names = append(names, name.(string))
+		}
+	default:
+		return fmt.Errorf("expected type array of strings or object")
+	}
+
+	for _, name := range names {
+		if !validSysctlName.MatchString(name) {
+			return fmt.Errorf("invalid sysctl '%s'", name)
+		}
+		namespaced := false
+		for _, prefix := range namespacedSysctlPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				namespaced = true
+				break
+			}
+		}
+		if !namespaced {
+			return fmt.Errorf("sysctl '%s' is not namespaced and can't be set per service", name)
+		}
+	}
+
+	return nil
+}
+
+// validateHostNetworkSysctls rejects network sysctls for services using the
+// host's network namespace, since they would change the host's settings.
+func validateHostNetworkSysctls(elem interface{}) error {
+	var names []string
+	switch typedElem := elem.(type) {
+	case []interface{}:
+		for _, sysctl := range typedElem {
+			names = append(names, strings.SplitN(sysctl.(string), "=", 2)[0])
+		}
+	case map[interface{}]interface{}:
+		for name := range typedElem {
+			names = append(names, name.(string))
+		}
+	}
+
+	for _, name := range names {
+		if strings.HasPrefix(name, "net.") {
+			return fmt.Errorf("sysctl '%s' can't be used with network mode '%s'", name, models.NetworkModeHost)
+		}
+	}
+
+	return nil
+}
+
+func validateDuration(elem interface{}) error {
+	duration, err := time.ParseDuration(elem.(string))
+	if err != nil {
+		return err
+	}
+	if duration < 0 {
+		return fmt.Errorf("expected a positive duration")
+	}
+	return nil
+}
+
+func validateSignal(elem interface{}) error {
+	if !validSignal.MatchString(elem.(string)) {
+		return fmt.Errorf("invalid signal '%s', expected a name like SIGTERM or a number", elem)
+	}
+	return nil
+}
+
+func validatePidsLimit(elem interface{}) error {
+	// -1 removes the limit
+	if pidsLimit := elem.(int); pidsLimit == 0 || pidsLimit < -1 {
+		return fmt.Errorf("expected a positive integer or -1")
+	}
+	return nil
+}
+
+func validateSecurityOpt(elem interface{}) error {
+	for _, opt := range elem.([]interface{}) {
+		typedOpt := opt.(string)
+		key, value := splitSecurityOpt(typedOpt)
+		switch key {
+		case models.SecurityOptNoNewPrivileges:
+			if value != "" && value != "true" && value != "false" {
+				return fmt.Errorf("invalid security option '%s', expected %s, %s:true or %s:false", typedOpt,
+					models.SecurityOptNoNewPrivileges, models.SecurityOptNoNewPrivileges, models.SecurityOptNoNewPrivileges)
+			}
+		case "apparmor", "label", "seccomp":
+			if value == "" {
+				return fmt.Errorf("invalid security option '%s', expected %s=<value>", typedOpt, key)
+			}
+		case "systempaths":
+			if value != "unconfined" {
+				return fmt.Errorf("invalid security option '%s', expected systempaths=unconfined", typedOpt)
+			}
+		default:
+			return fmt.Errorf("invalid security option '%s'", typedOpt)
+		}
+	}
+	return nil
+}
+
+func validateUlimits(elem interface{}) error {
+	typedElem, ok := elem.(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("expected type object")
+	}
+
+	for name, value := range typedElem {
+		if _, ok := ulimitNames[fmt.Sprint(name)]; !ok {
+			return fmt.Errorf("invalid ulimit '%v'", name)
+		}
+
+		switch typedValue := value.(type) {
+		case int:
+			continue
+		case map[interface{}]interface{}:
+			if err := validateUlimit(typedValue); err != nil {
+				return fmt.Errorf("ulimit '%v': %v", name, err)
+			}
+			soft, softOk := typedValue["soft"].(int)
+			hard, hardOk := typedValue["hard"].(int)
+			if !softOk || !hardOk {
+				return fmt.Errorf("ulimit '%v': expected both soft and hard limits", name)
+			}
+			if hard >= 0 && (soft < 0 || soft > hard) {
+				return fmt.Errorf("ulimit '%v': soft limit can't be greater than hard limit", name)
+			}
+		default:
+			return fmt.Errorf("ulimit '%v': expected type integer or object", name)
+		}
+	}
+
+	return nil
+}
+
+func validateMetrics(elem interface{}) error {
+	typedElem, ok := elem.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected type array of objects")
+	}
+
+	for i, endpoint := range typedElem {
+		if err := validateMetricsEndpoint(endpoint); err != nil {
+			return fmt.Errorf("endpoint %d: %v", i, err)
+		}
+
+		typedEndpoint := endpoint.(map[interface{}]interface{})
+		port, ok := typedEndpoint["port"].(int)
+		if !ok {
+			return fmt.Errorf("endpoint %d: port is required", i)
+		}
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("endpoint %d: invalid port %d", i, port)
+		}
+		if path, ok := typedEndpoint["path"].(string); ok && !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("endpoint %d: path must start with /", i)
+		}
+		if labels, ok := typedEndpoint["labels"].(map[interface{}]interface{}); ok {
+			for name := range labels {
+				typedName, ok := name.(string)
+				if !ok || !validMetricsLabelName.MatchString(typedName) || strings.HasPrefix(typedName, "__") {
+					return fmt.Errorf("endpoint %d: invalid label name '%v'", i, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateNetworks(elem interface{}) error {
+	var names []interface{}
+	switch typedElem := elem.(type) {
+	case []interface{}:
+		if err := validation.ValidateStringArray(typedElem); err != nil {
+			return err
+		}
+		names = typedElem
+	case map[interface{}]interface{}:
+		for name, network := range typedElem {
+			if network == nil {
+				continue
+			}
+			if err := validateNetwork(network); err != nil {
+				return fmt.Errorf("network '%v': %v", name, err)
+			}
+		}
+		for name := range typedElem {
+			names = append(names, name)
+		}
+	default:
+		return fmt.Errorf("expected type array of strings or object")
+	}
+
+	for _, name := range names {
+		typedName, ok := name.(string)
+		if !ok || !validNetworkName.MatchString(typedName) {
+			return fmt.Errorf("invalid network name '%v'", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that a release config is structurally valid and returns
+// the first problem it finds.
 func Validate(c []byte) error {
 	var m map[string]interface{}
 	if err := yaml.Unmarshal(c, &m); err != nil {
 		return err
 	}
 
-	for serviceName := range m {
+	if diagnostics := validate(m); len(diagnostics) > 0 {
+		return errors.New(diagnostics[0].String())
+	}
+	return nil
+}
+
+func validate(m map[string]interface{}) []models.ReleaseDiagnostic {
+	var diagnostics []models.ReleaseDiagnostic
+	addError := func(serviceName, key, format string, a ...interface{}) {
+		diagnostics = append(diagnostics,
+			diagnosticf(serviceName, key, models.DiagnosticSeverityError, format, a...))
+	}
+
+	serviceNames := sortedServiceNames(m)
+
+	for _, serviceName := range serviceNames {
 		if len(serviceName) > 100 {
-			return fmt.Errorf("service name '%s' is longer than 100 characters", serviceName)
+			addError(serviceName, "", "service name is longer than 100 characters")
 		}
 	}
 
-	for serviceName, service := range m {
-		service, ok := service.(map[interface{}]interface{})
+	for _, serviceName := range serviceNames {
+		service, ok := m[serviceName].(map[interface{}]interface{})
 		if !ok {
-			return fmt.Errorf("service '%s' is not an object", serviceName)
+			addError(serviceName, "", "expected type object")
+			continue
 		}
 
 		for key := range service {
 			typedKey, ok := key.(string)
 			if !ok {
-				return fmt.Errorf("service '%s': invalid key '%v'", serviceName, key)
+				addError(serviceName, fmt.Sprint(key), "invalid key")
+				continue
 			}
 			if _, ok = validators[typedKey]; !ok {
-				return fmt.Errorf("service '%s': invalid key '%s'", serviceName, typedKey)
+				addError(serviceName, typedKey, "invalid key")
 			}
 		}
 
-		for key, validators := range validators {
-			value, ok := service[key]
+		for _, key := range sortedKeys(service) {
+			validators, ok := validators[key]
 			if !ok {
 				continue
 			}
 			for _, validator := range validators {
-				if err := validator(value); err != nil {
-					return fmt.Errorf("service '%s', key '%s': %v", serviceName, key, err)
+				if err := validator(service[key]); err != nil {
+					addError(serviceName, key, "%v", err)
+					break
 				}
 			}
 		}
 	}
 
-	return nil
+	// The checks below rely on every service being valid on its own
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	for _, serviceName := range serviceNames {
+		service := m[serviceName].(map[interface{}]interface{})
+
+		networkMode, _ := service["network_mode"].(string)
+		if _, ok := service["networks"]; ok {
+			switch networkMode {
+			case "", models.NetworkModeDefault, models.NetworkModeBridge:
+			default:
+				addError(serviceName, "networks", "networks can't be used with network mode '%s'", networkMode)
+			}
+		}
+
+		if networkMode == models.NetworkModeHost {
+			if err := validateHostNetworkSysctls(service["sysctls"]); err != nil {
+				addError(serviceName, "sysctls", "%v", err)
+			}
+		}
+
+		target, ok := NetworkContainer(models.Service{NetworkMode: networkMode})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"dns", "dns_opt", "dns_search"} {
+			if _, ok := service[key]; ok {
+				addError(serviceName, key, "%s can't be used with network mode '%s'", key, networkMode)
+			}
+		}
+		if _, ok := m[target]; !ok || target == serviceName {
+			addError(serviceName, "network_mode", "'%s' is not another service in this release", target)
+		}
+	}
+
+	return diagnostics
+}
+
+func sortedServiceNames(m map[string]interface{}) []string {
+	var names []string
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedKeys(m map[interface{}]interface{}) []string {
+	var keys []string
+	for key := range m {
+		if typedKey, ok := key.(string); ok {
+			keys = append(keys, typedKey)
+		}
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -15,4 +15,121 @@ func TestValidate(t *testing.T) {
 		})
 		require.NoError(t, Validate(full))
 	})
+
+	t.Run("type", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  type: systemd\n")))
+		require.Error(t, Validate([]byte("s:\n  type: vm\n")))
+	})
+
+	t.Run("logging", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  logging:\n    driver: json-file\n    options:\n      max-size: 10m\n      max-file: 3\n")))
+		require.Error(t, Validate([]byte("s:\n  logging: journald\n")))
+		require.Error(t, Validate([]byte("s:\n  logging:\n    type: journald\n")))
+		require.Error(t, Validate([]byte("s:\n  logging:\n    options:\n      - max-size=10m\n")))
+	})
+
+	t.Run("network_mode", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  network_mode: host\n")))
+		require.NoError(t, Validate([]byte("s:\n  image: x\nt:\n  network_mode: container:s\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: hostt\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: container:\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: container:t\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: container:s\n")))
+	})
+
+	t.Run("networks", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  networks:\n    - backend\n")))
+		require.NoError(t, Validate([]byte("s:\n  networks:\n    backend:\n      aliases:\n        - db\n    frontend:\n")))
+		require.Error(t, Validate([]byte("s:\n  networks: backend\n")))
+		require.Error(t, Validate([]byte("s:\n  networks:\n    - back end\n")))
+		require.Error(t, Validate([]byte("s:\n  networks:\n    backend:\n      ipv4_address: 10.0.0.2\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: host\n  networks:\n    - backend\n")))
+	})
+
+	t.Run("extra_hosts", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  extra_hosts:\n    - plc:10.0.0.5\n    - router:fe80::1\n")))
+		require.NoError(t, Validate([]byte("s:\n  extra_hosts:\n    plc: 10.0.0.5\n")))
+		require.Error(t, Validate([]byte("s:\n  extra_hosts:\n    - plc\n")))
+		require.Error(t, Validate([]byte("s:\n  extra_hosts:\n    - plc:plc.local\n")))
+		require.Error(t, Validate([]byte("s:\n  extra_hosts:\n    plc: 5\n")))
+	})
+
+	t.Run("dns", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  dns: 8.8.8.8\n  dns_search: corp.example.com\n")))
+		require.NoError(t, Validate([]byte("s:\n  dns:\n    - 1.1.1.1\n    - 2606:4700:4700::1111\n  dns_search:\n    - .\n")))
+		require.Error(t, Validate([]byte("s:\n  dns: dns.google\n")))
+		require.Error(t, Validate([]byte("s:\n  dns_search: corp_example\n")))
+		require.Error(t, Validate([]byte("s:\n  image: x\nt:\n  network_mode: container:s\n  dns: 8.8.8.8\n")))
+	})
+
+	t.Run("sysctls", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  sysctls:\n    net.ipv4.ip_forward: 1\n    net.core.somaxconn: \"1024\"\n")))
+		require.NoError(t, Validate([]byte("s:\n  sysctls:\n    - kernel.shmmax=65536\n")))
+		require.Error(t, Validate([]byte("s:\n  sysctls:\n    - net.ipv4.ip_forward\n")))
+		require.Error(t, Validate([]byte("s:\n  sysctls:\n    vm.swappiness: 10\n")))
+		require.Error(t, Validate([]byte("s:\n  sysctls:\n    net.ipv4.ip_forward:\n")))
+		require.Error(t, Validate([]byte("s:\n  network_mode: host\n  sysctls:\n    net.ipv4.ip_forward: 1\n")))
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  metrics:\n    - port: 2112\n    - port: 9100\n      path: /stats\n      labels:\n        role: edge\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    port: 2112\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    - path: /metrics\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    - port: 70000\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    - port: 2112\n      path: metrics\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    - port: 2112\n      labels:\n        role-name: edge\n")))
+		require.Error(t, Validate([]byte("s:\n  metrics:\n    - port: 2112\n      interval: 10s\n")))
+	})
+
+	t.Run("ulimits", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  ulimits:\n    memlock: -1\n    nofile:\n      soft: 20000\n      hard: 40000\n")))
+		require.Error(t, Validate([]byte("s:\n  ulimits:\n    files: 1024\n")))
+		require.Error(t, Validate([]byte("s:\n  ulimits:\n    nofile:\n      soft: 20000\n")))
+		require.Error(t, Validate([]byte("s:\n  ulimits:\n    nofile:\n      soft: 40000\n      hard: 20000\n")))
+		require.Error(t, Validate([]byte("s:\n  ulimits:\n    - nofile=1024\n")))
+	})
+
+	t.Run("pids_limit", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  pids_limit: 100\n")))
+		require.NoError(t, Validate([]byte("s:\n  pids_limit: -1\n")))
+		require.Error(t, Validate([]byte("s:\n  pids_limit: 0\n")))
+		require.Error(t, Validate([]byte("s:\n  pids_limit: 100m\n")))
+	})
+
+	t.Run("security_opt", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  security_opt:\n    - no-new-privileges\n    - no-new-privileges:false\n    - label=disable\n    - seccomp:unconfined\n")))
+		require.Error(t, Validate([]byte("s:\n  security_opt:\n    - no-new-privileges:yes\n")))
+		require.Error(t, Validate([]byte("s:\n  security_opt:\n    - apparmor\n")))
+		require.Error(t, Validate([]byte("s:\n  security_opt:\n    - privileged\n")))
+	})
+
+	t.Run("stop_grace_period", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  stop_grace_period: 1m30s\n")))
+		require.Error(t, Validate([]byte("s:\n  stop_grace_period: 90\n")))
+		require.Error(t, Validate([]byte("s:\n  stop_grace_period: -1s\n")))
+	})
+
+	t.Run("ports", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  ports:\n    - 80\n    - 8080:80\n    - 127.0.0.1:53:53/udp\n")))
+		require.Error(t, Validate([]byte("s:\n  ports:\n    - 80:http\n")))
+		require.Error(t, Validate([]byte("s:\n  ports:\n    - 80/quic\n")))
+	})
+
+	t.Run("stop_signal", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  stop_signal: SIGINT\n")))
+		require.NoError(t, Validate([]byte("s:\n  stop_signal: SIGRTMIN+3\n")))
+		require.NoError(t, Validate([]byte("s:\n  stop_signal: \"15\"\n")))
+		require.Error(t, Validate([]byte("s:\n  stop_signal: sig int\n")))
+	})
+
+	t.Run("init", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  init: true\n")))
+		require.Error(t, Validate([]byte("s:\n  init: /sbin/tini\n")))
+	})
+
+	t.Run("env_file", func(t *testing.T) {
+		require.NoError(t, Validate([]byte("s:\n  env_file: common\n")))
+		require.NoError(t, Validate([]byte("s:\n  env_file:\n    - common\n    - prod\n")))
+		require.Error(t, Validate([]byte("s:\n  env_file: ./.env\n")))
+	})
 }
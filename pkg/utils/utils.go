@@ -123,6 +123,38 @@ func ProxyResponseFromDevice(w http.ResponseWriter, resp *http.Response) {
 	resp.Body.Close()
 }
 
+// StreamResponseFromDevice is like ProxyResponseFromDevice but flushes
+// after every read so that long lived responses reach the client as they're
+// produced.
+func StreamResponseFromDevice(w http.ResponseWriter, resp *http.Response) {
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Set(ProxiedFromDeviceHeader, "")
+
+	w.WriteHeader(resp.StatusCode)
+	defer resp.Body.Close()
+
+	flusher, _ := w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
 func ProxyResponse(w http.ResponseWriter, resp *http.Response) {
 	for key, values := range resp.Header {
 		for _, value := range values {
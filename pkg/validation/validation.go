@@ -79,6 +79,52 @@ func ValidateArrayOrObject(elem interface{}) error {
 	}
 }
 
+// ValidateObject returns a validator for objects that may only contain the
+// given keys, each of which is checked with its own validators.
+func ValidateObject(validators map[string][]func(interface{}) error) func(interface{}) error {
+	return func(elem interface{}) error {
+		typedElem, ok := elem.(map[interface{}]interface{})
+		if !ok {
+			return fmt.Errorf("expected type object")
+		}
+
+		for key, value := range typedElem {
+			typedKey, ok := key.(string)
+			if !ok {
+				return fmt.Errorf("invalid key '%v'", key)
+			}
+			keyValidators, ok := validators[typedKey]
+			if !ok {
+				return fmt.Errorf("invalid key '%s'", typedKey)
+			}
+			for _, validator := range keyValidators {
+				if err := validator(value); err != nil {
+					return fmt.Errorf("key '%s': %v", typedKey, err)
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+func ValidateStringOrIntegerObject(elem interface{}) error {
+	switch typedElem := elem.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range typedElem {
+			if _, ok := key.(string); !ok {
+				return fmt.Errorf("invalid key '%v'", key)
+			}
+			if err := ValidateStringOrInteger(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("expected type object of strings or integers")
+	}
+}
+
 func validateElementsAreStrings(elems []interface{}) error {
 	for _, elem := range elems {
 		switch elem.(type) {
@@ -26,11 +26,13 @@ func Validate(s interface{}) error {
 
 		vldr.RegisterAlias("id", "required,min=1,max=32,internaltitle")
 		vldr.RegisterAlias("name", "required,min=1,max=100,usertitle")
+		vldr.RegisterAlias("nameprefix", "omitempty,max=50,usertitle")
 		vldr.RegisterAlias("labelkey", "required,min=1,max=100,usertitle")
 		vldr.RegisterAlias("labelvalue", "required,min=1,max=100")
 		vldr.RegisterAlias("password", "required,min=8,max=100")
 		vldr.RegisterAlias("config", "required,min=1,max=5000")
 		vldr.RegisterAlias("description", "max=5000")
+		vldr.RegisterAlias("content", "max=1000000")
 	})
 	return vldr.Struct(s)
 }
@@ -46,3 +46,23 @@ func TestUserTitledRegex(t *testing.T) {
 	}
 
 }
+
+func TestNamePrefix(t *testing.T) {
+	type request struct {
+		NamePrefix string `validate:"nameprefix"`
+	}
+
+	for _, valid := range []string{
+		"",
+		"site-a-",
+	} {
+		require.NoError(t, Validate(request{NamePrefix: valid}))
+	}
+
+	for _, invalid := range []string{
+		"site a",
+		"site_a_",
+	} {
+		require.Error(t, Validate(request{NamePrefix: invalid}))
+	}
+}
@@ -0,0 +1,35 @@
+package yamltypes
+
+import (
+	"errors"
+	"time"
+)
+
+// Duration represents a duration written in Go notation, like 1m30s
+type Duration time.Duration
+
+// MarshalYAML implements the Marshaller interface.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var stringType string
+	if err := unmarshal(&stringType); err != nil {
+		return errors.New("Failed to unmarshal Duration")
+	}
+
+	duration, err := time.ParseDuration(stringType)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Seconds returns the duration as whole seconds, rounded up so that short
+// durations aren't truncated to nothing.
+func (d Duration) Seconds() int64 {
+	return int64((time.Duration(d) + time.Second - 1) / time.Second)
+}
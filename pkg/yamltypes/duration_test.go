@@ -0,0 +1,33 @@
+package yamltypes
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalDuration(t *testing.T) {
+	var d Duration
+	err := yaml.Unmarshal([]byte(`1m30s`), &d)
+	assert.Nil(t, err)
+	assert.Equal(t, Duration(90*time.Second), d)
+	assert.Equal(t, int64(90), d.Seconds())
+
+	bytes, err := yaml.Marshal(d)
+	assert.Nil(t, err)
+
+	var actual Duration
+	err = yaml.Unmarshal(bytes, &actual)
+	assert.Nil(t, err)
+	assert.Equal(t, d, actual)
+
+	err = yaml.Unmarshal([]byte(`500ms`), &d)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), d.Seconds())
+
+	err = yaml.Unmarshal([]byte(`10`), &d)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,82 @@
+package yamltypes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Networks represents a list of service networks in compose file.
+// It has several representation, hence this specific struct.
+type Networks struct {
+	Networks []*Network
+}
+
+// Network represents a service network in compose file.
+type Network struct {
+	Name    string   `yaml:"-"`
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+// Generate a hash string to detect service network config changes
+func (n *Networks) HashString() string {
+	if n == nil {
+		return ""
+	}
+	result := []string{}
+	for _, network := range n.Networks {
+		aliases := append([]string{}, network.Aliases...)
+		sort.Strings(aliases)
+		result = append(result, network.Name+"="+strings.Join(aliases, "+"))
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
+
+// MarshalYAML implements the Marshaller interface.
+func (n Networks) MarshalYAML() (interface{}, error) {
+	m := map[string]*Network{}
+	for _, network := range n.Networks {
+		m[network.Name] = network
+	}
+	return m, nil
+}
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (n *Networks) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var sliceType []interface{}
+	if err := unmarshal(&sliceType); err == nil {
+		n.Networks = []*Network{}
+		for _, network := range sliceType {
+			name, ok := network.(string)
+			if !ok {
+				return fmt.Errorf("Cannot unmarshal '%v' to type %T into a string value", network, name)
+			}
+			n.Networks = append(n.Networks, &Network{
+				Name: name,
+			})
+		}
+		return nil
+	}
+
+	var mapType map[string]*Network
+	if err := unmarshal(&mapType); err == nil {
+		n.Networks = []*Network{}
+		for name, network := range mapType {
+			if network == nil {
+				network = &Network{}
+			}
+			network.Name = name
+			n.Networks = append(n.Networks, network)
+		}
+		// Keep the order stable since the first network is the one
+		// containers are created with
+		sort.Slice(n.Networks, func(i, j int) bool {
+			return n.Networks[i].Name < n.Networks[j].Name
+		})
+		return nil
+	}
+
+	return errors.New("Failed to unmarshal Networks")
+}
@@ -0,0 +1,68 @@
+package yamltypes
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalNetworks(t *testing.T) {
+	networks := []struct {
+		networks string
+		expected *Networks
+	}{
+		{
+			networks: `- backend
+- frontend
+`,
+			expected: &Networks{
+				Networks: []*Network{
+					{Name: "backend"},
+					{Name: "frontend"},
+				},
+			},
+		},
+		{
+			networks: `frontend:
+backend:
+  aliases:
+    - db
+`,
+			expected: &Networks{
+				Networks: []*Network{
+					{Name: "backend", Aliases: []string{"db"}},
+					{Name: "frontend"},
+				},
+			},
+		},
+	}
+	for _, network := range networks {
+		actual := &Networks{}
+		err := yaml.Unmarshal([]byte(network.networks), actual)
+		assert.Nil(t, err)
+		assert.Equal(t, network.expected, actual)
+	}
+
+	err := yaml.Unmarshal([]byte("backend"), &Networks{})
+	assert.NotNil(t, err)
+}
+
+func TestMarshalNetworks(t *testing.T) {
+	networks := &Networks{
+		Networks: []*Network{
+			{Name: "backend", Aliases: []string{"db"}},
+			{Name: "frontend"},
+		},
+	}
+
+	bytes, err := yaml.Marshal(networks)
+	assert.Nil(t, err)
+
+	actual := &Networks{}
+	err = yaml.Unmarshal(bytes, actual)
+	assert.Nil(t, err)
+	assert.Equal(t, networks, actual)
+	assert.Equal(t, networks.HashString(), actual.HashString())
+}
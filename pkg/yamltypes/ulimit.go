@@ -0,0 +1,88 @@
+package yamltypes
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Ulimits represents a list of Ulimit.
+// It is, however, represented in yaml as keys (and thus map in Go)
+type Ulimits struct {
+	Elements []Ulimit
+}
+
+// Ulimit represents ulimit information.
+type Ulimit struct {
+	Name string `yaml:"-"`
+	Soft int64  `yaml:"soft"`
+	Hard int64  `yaml:"hard"`
+}
+
+// Generate a hash string to detect service ulimit config changes
+func (u *Ulimits) HashString() string {
+	if u == nil {
+		return ""
+	}
+	result := []string{}
+	for _, ulimit := range u.Elements {
+		result = append(result, fmt.Sprintf("%s=%d:%d", ulimit.Name, ulimit.Soft, ulimit.Hard))
+	}
+	sort.Strings(result)
+	return strings.Join(result, ",")
+}
+
+// MarshalYAML implements the Marshaller interface.
+func (u Ulimits) MarshalYAML() (interface{}, error) {
+	ulimitMap := make(map[string]interface{})
+	for _, ulimit := range u.Elements {
+		if ulimit.Soft == ulimit.Hard {
+			ulimitMap[ulimit.Name] = ulimit.Soft
+		} else {
+			ulimitMap[ulimit.Name] = ulimit
+		}
+	}
+	return ulimitMap, nil
+}
+
+// UnmarshalYAML implements the Unmarshaller interface.
+func (u *Ulimits) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var mapType map[string]interface{}
+	if err := unmarshal(&mapType); err != nil {
+		return errors.New("Failed to unmarshal Ulimits")
+	}
+
+	u.Elements = []Ulimit{}
+	for name, value := range mapType {
+		switch v := value.(type) {
+		case int:
+			u.Elements = append(u.Elements, Ulimit{
+				Name: name,
+				Soft: int64(v),
+				Hard: int64(v),
+			})
+		case map[interface{}]interface{}:
+			soft, ok := v["soft"].(int)
+			if !ok {
+				return fmt.Errorf("Cannot unmarshal soft limit '%v' of ulimit %s into an integer value", v["soft"], name)
+			}
+			hard, ok := v["hard"].(int)
+			if !ok {
+				return fmt.Errorf("Cannot unmarshal hard limit '%v' of ulimit %s into an integer value", v["hard"], name)
+			}
+			u.Elements = append(u.Elements, Ulimit{
+				Name: name,
+				Soft: int64(soft),
+				Hard: int64(hard),
+			})
+		default:
+			return fmt.Errorf("Cannot unmarshal '%v' of type %T into a ulimit", value, value)
+		}
+	}
+	sort.Slice(u.Elements, func(i, j int) bool {
+		return u.Elements[i].Name < u.Elements[j].Name
+	})
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package yamltypes
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalUlimits(t *testing.T) {
+	ulimits := &Ulimits{}
+	err := yaml.Unmarshal([]byte(`nproc: 65535
+nofile:
+  soft: 20000
+  hard: 40000
+`), ulimits)
+	assert.Nil(t, err)
+	assert.Equal(t, &Ulimits{
+		Elements: []Ulimit{
+			{Name: "nofile", Soft: 20000, Hard: 40000},
+			{Name: "nproc", Soft: 65535, Hard: 65535},
+		},
+	}, ulimits)
+
+	bytes, err := yaml.Marshal(ulimits)
+	assert.Nil(t, err)
+
+	actual := &Ulimits{}
+	err = yaml.Unmarshal(bytes, actual)
+	assert.Nil(t, err)
+	assert.Equal(t, ulimits, actual)
+	assert.Equal(t, ulimits.HashString(), actual.HashString())
+
+	err = yaml.Unmarshal([]byte(`nofile:
+  soft: 20000
+`), &Ulimits{})
+	assert.NotNil(t, err)
+
+	err = yaml.Unmarshal([]byte(`- nofile=20000`), &Ulimits{})
+	assert.NotNil(t, err)
+}
@@ -66,7 +66,7 @@ func (v *Volumes) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			if !ok {
 				return fmt.Errorf("Cannot unmarshal '%v' to type %T into a string value", name, name)
 			}
-			elts := strings.SplitN(name, ":", 3)
+			elts := splitVolume(name)
 			var vol *Volume
 			switch {
 			case len(elts) == 1:
@@ -95,3 +95,25 @@ func (v *Volumes) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	return errors.New("Failed to unmarshal Volumes")
 }
+
+// splitVolume splits a volume into at most three parts on colons, except for
+// the ones that are part of a Windows drive letter.
+func splitVolume(volume string) []string {
+	var elts []string
+	start := 0
+	for i := 0; i < len(volume) && len(elts) < 2; i++ {
+		if volume[i] != ':' {
+			continue
+		}
+		if isDriveLetter(volume[start:i]) && i+1 < len(volume) && (volume[i+1] == '\\' || volume[i+1] == '/') {
+			continue
+		}
+		elts = append(elts, volume[start:i])
+		start = i + 1
+	}
+	return append(elts, volume[start:])
+}
+
+func isDriveLetter(s string) bool {
+	return len(s) == 1 && (s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
@@ -133,6 +133,23 @@ func TestUnmarshalVolumes(t *testing.T) {
 				},
 			},
 		},
+		{
+			yaml: `- C:\data:C:\app\data:ro
+- c:/logs:/logs`,
+			expected: &Volumes{
+				Volumes: []*Volume{
+					{
+						Source:      `C:\data`,
+						Destination: `C:\app\data`,
+						AccessMode:  "ro",
+					},
+					{
+						Source:      "c:/logs",
+						Destination: "/logs",
+					},
+				},
+			},
+		},
 	}
 	for _, volume := range volumes {
 		actual := &Volumes{}